@@ -1,13 +1,31 @@
 #!/usr/bin/python 
 # -*- coding: utf-8 -*-   
 
-import sys,getopt,os,shutil
+import sys,getopt,os,shutil,base64
 from string import Template
 
 
 class FileTemplate(Template):
     delimiter='$'
 
+def encryption_keys(conf_file):
+    # keep the master keys of the existing configure, the secrets encrypted
+    # with them can not be decrypted any more once the keys are regenerated.
+    master_keys = ''
+    active_key = ''
+    if os.path.exists(conf_file):
+        with open(conf_file) as f:
+            for line in f:
+                line = line.strip()
+                if line.startswith('master_keys='):
+                    master_keys = line[len('master_keys='):]
+                elif line.startswith('active_key='):
+                    active_key = line[len('active_key='):]
+    if master_keys == '' or active_key == '':
+        active_key = 'k1'
+        master_keys = active_key + ':' + base64.b64encode(os.urandom(32)).decode()
+    return master_keys, active_key
+
 def generate_config_file(rd_server_v,db_name_v,redis_ip_v,redis_port_v,redis_user_v,redis_pass_v,mongo_ip_v,mongo_port_v,mongo_user_v,mongo_pass_v,cc_url_v,paas_url_v):
     
     output = os.getcwd()+"/cmdb_adminserver/configures/"
@@ -74,6 +92,9 @@ host = $redis_host
 usr = $redis_user
 pwd = $redis_pass
 database = 0
+
+[analyzers]
+names =
 '''
 
     template = FileTemplate(datacollection_file_template_str)
@@ -100,10 +121,37 @@ database=0
 port=$redis_port
 maxOpenConns=3000
 maxIDleConns=1000
+
+[delivery]
+max_attempts=6
+backoff_base=10
+backoff_max=600
+
+[callback]
+ca_file=
+cert_file=
+key_file=
+password=
+
+[watch]
+max_events=100000
+
+[sink]
+enabled=
+
+[kafka]
+brokers=
+topic_prefix=bk_cmdb_
+
+[encryption]
+# the master keys used to encrypt the subscription secrets, should be the same with host.conf
+master_keys=$master_keys
+active_key=$active_key
 '''
     
+    master_keys_v, active_key_v = encryption_keys(output + "host.conf")
     template = FileTemplate(eventserver_file_template_str)
-    result = template.substitute(dict(db=db_name_v,redis_host=redis_ip_v,redis_port=redis_port_v,redis_user=redis_user_v,redis_pass=redis_pass_v, mongo_user=mongo_user_v,mongo_host=mongo_ip_v,mongo_pass=mongo_pass_v,mongo_port=mongo_port_v))
+    result = template.substitute(dict(db=db_name_v,redis_host=redis_ip_v,redis_port=redis_port_v,redis_user=redis_user_v,redis_pass=redis_pass_v, mongo_user=mongo_user_v,mongo_host=mongo_ip_v,mongo_pass=mongo_pass_v,mongo_port=mongo_port_v,master_keys=master_keys_v,active_key=active_key_v))
     with open( output + "eventserver.conf",'w') as tmp_file:
         tmp_file.write(result)
 
@@ -121,9 +169,20 @@ database=0
 port=$redis_port
 maxOpenConns=3000
 maxIDleConns=1000
+
+[encryption]
+# the master keys used to encrypt the cloud account and subscription secrets, format: id1:base64key,id2:base64key
+# add a new key and set it as active_key to rotate, then run "cmdb_adminserver reencrypt"
+master_keys=$master_keys
+active_key=$active_key
+
+[cloud]
+# the dir of the fixture files of the "fixture" cloud vendor, which syncs the hosts from the file named
+# by the secret id of the account instead of a real cloud account, only set it in the test environments
+fixture_dir=
 '''
     template = FileTemplate(host_file_template_str)
-    result = template.substitute(dict(rd_server=rd_server_v,redis_host=redis_ip_v,redis_port=redis_port_v,redis_user=redis_user_v,redis_pass=redis_pass_v))
+    result = template.substitute(dict(rd_server=rd_server_v,redis_host=redis_ip_v,redis_port=redis_port_v,redis_user=redis_user_v,redis_pass=redis_pass_v,master_keys=master_keys_v,active_key=active_key_v))
     with open( output + "host.conf",'w') as tmp_file:
         tmp_file.write(result)
 
@@ -233,6 +292,13 @@ database=0
 port=$redis_port
 maxOpenConns=3000
 maxIDleConns=1000
+
+[recycle]
+enabled=false
+ttlHours=168
+
+[history]
+retentionDays=180
 '''
 
     template = FileTemplate(coreservice_file_template_str)
@@ -41,7 +41,8 @@ type Service struct {
 }
 
 const (
-	rootPath = "/api/v3"
+	rootPath        = "/api/v3"
+	mimeEventStream = "text/event-stream"
 )
 
 func (s *Service) V3WebService() *restful.WebService {
@@ -52,7 +53,7 @@ func (s *Service) V3WebService() *restful.WebService {
 	ws.Path(rootPath).
 		Filter(rdapi.AllGlobalFilter(getErrFunc)).
 		Produces(restful.MIME_JSON)
-	ws.Route(ws.GET("{.*}").Filter(s.URLFilterChan).To(s.Get))
+	ws.Route(ws.GET("{.*}").Filter(s.URLFilterChan).To(s.Get).Produces(restful.MIME_JSON, mimeEventStream))
 	ws.Route(ws.POST("{.*}").Filter(s.URLFilterChan).To(s.Post))
 	ws.Route(ws.PUT("{.*}").Filter(s.URLFilterChan).To(s.Put))
 	ws.Route(ws.DELETE("{.*}").Filter(s.URLFilterChan).To(s.Delete))
@@ -121,6 +122,14 @@ func (s *Service) Do(req *restful.Request, resp *restful.Response) {
 
 	resp.ResponseWriter.WriteHeader(response.StatusCode)
 
+	// the event stream should be sent to the client as soon as it is received
+	if strings.HasPrefix(response.Header.Get("Content-Type"), mimeEventStream) {
+		if err := copyWithFlush(resp, response.Body); err != nil {
+			blog.Errorf("response request[url: %s] failed, err: %v", req.Request.RequestURI, err)
+		}
+		return
+	}
+
 	if _, err := io.Copy(resp, response.Body); err != nil {
 		blog.Errorf("response request[url: %s] failed, err: %v", req.Request.RequestURI, err)
 		return
@@ -129,6 +138,28 @@ func (s *Service) Do(req *restful.Request, resp *restful.Response) {
 	return
 }
 
+func copyWithFlush(resp *restful.Response, body io.Reader) error {
+	flusher, _ := resp.ResponseWriter.(http.Flusher)
+	buf := make([]byte, 4096)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if _, werr := resp.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
 func (s *Service) URLFilterChan(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
 	var kind RequestType
 	var err error
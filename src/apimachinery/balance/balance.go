@@ -0,0 +1,274 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package balance balance the requests among the servers of a service, the servers
+// whose circuit is open are skipped until the circuit is half opened for a probe,
+// and the retries of the requests are limited by a retry budget.
+package balance
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"configcenter/src/apimachinery/util"
+	"configcenter/src/common/blog"
+)
+
+// ErrNoAvailableServer the circuits of the servers picked are opened by the other requests
+var ErrNoAvailableServer = errors.New("no available server, the circuits of all the servers are open")
+
+const (
+	defaultOpenDuration = 10 * time.Second
+	// the weight of the latest latency in the average latency
+	latencyWeight = 0.3
+	// the latency samples of a server needed to tell whether it's an outlier
+	minLatencySamples = 20
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type server struct {
+	// the service the server belongs to
+	service  string
+	inflight int64
+	state    circuitState
+	failures int
+	openedAt time.Time
+	// average latency in nanoseconds
+	latency float64
+	samples int
+	// the circuits of all the servers of the service are open, and the server
+	// is picked as the fallback, the next request is let through as a probe
+	fallback bool
+	// a probe request is in flight while the circuit is half open
+	probing bool
+}
+
+type balancer struct {
+	strategy Strategy
+	breaker  util.CircuitBreakerConfig
+	budget   *retryBudget
+
+	lock    sync.Mutex
+	servers map[string]*server
+}
+
+// NewBalancer create a balancer with the strategy, breaker and retry budget in the config
+func NewBalancer(c *util.APIMachineryConfig) (util.Balancer, error) {
+	name := c.Balance
+	if name == "" {
+		name = RoundRobin
+	}
+	strategy, err := newStrategy(name)
+	if err != nil {
+		return nil, err
+	}
+
+	breaker := c.CircuitBreaker
+	if breaker.OpenDuration <= 0 {
+		breaker.OpenDuration = defaultOpenDuration
+	}
+	return &balancer{
+		strategy: strategy,
+		breaker:  breaker,
+		budget:   newRetryBudget(c.RetryBudget),
+		servers:  make(map[string]*server),
+	}, nil
+}
+
+func (b *balancer) breakerEnabled() bool {
+	return b.breaker.FailureThreshold > 0 || b.breaker.OutlierFactor > 0
+}
+
+func (b *balancer) get(name string) *server {
+	s, ok := b.servers[name]
+	if !ok {
+		s = new(server)
+		b.servers[name] = s
+	}
+	return s
+}
+
+func (b *balancer) Pick(service string, servers []string, key string) ([]string, error) {
+	b.budget.deposit()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	available := servers
+	if b.breakerEnabled() {
+		now := time.Now()
+		available = make([]string, 0, len(servers))
+		var fallback *server
+		fallbackName := ""
+		for _, name := range servers {
+			s := b.get(name)
+			s.service = service
+			switch s.state {
+			case circuitClosed:
+				available = append(available, name)
+			case circuitOpen:
+				if now.Sub(s.openedAt) >= b.breaker.OpenDuration {
+					available = append(available, name)
+				}
+			case circuitHalfOpen:
+				if !s.probing {
+					available = append(available, name)
+				}
+			}
+			if fallback == nil || s.openedAt.Before(fallback.openedAt) {
+				fallback, fallbackName = s, name
+			}
+		}
+		if len(available) == 0 && fallback != nil {
+			// failing fast does no good when all the servers are in trouble, try
+			// the one which is in trouble for the longest time
+			blog.Warnf("[apimachinery][balance] the circuits of all the %s servers are open, fall back to %s",
+				service, fallbackName)
+			fallback.fallback = true
+			return []string{fallbackName}, nil
+		}
+	}
+
+	return b.strategy.Order(service, available, key, func(name string) int64 {
+		return b.get(name).inflight
+	}), nil
+}
+
+func (b *balancer) Acquire(name string) (func(outcome util.Outcome), bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	s := b.get(name)
+	probe := false
+	if b.breakerEnabled() {
+		switch {
+		case s.state != circuitClosed && s.fallback:
+			s.fallback = false
+			probe = true
+		case s.state == circuitHalfOpen && s.probing:
+			// only one probe request at a time
+			return nil, false
+		case s.state == circuitHalfOpen:
+			// the last probe is abandoned, let this one through instead
+			probe = true
+		case s.state == circuitOpen:
+			if time.Since(s.openedAt) < b.breaker.OpenDuration {
+				return nil, false
+			}
+			probe = true
+		}
+		if probe {
+			s.state = circuitHalfOpen
+			s.probing = true
+		}
+	}
+
+	s.inflight++
+	start := time.Now()
+	return func(outcome util.Outcome) {
+		b.release(name, s, probe, outcome, time.Since(start))
+	}, true
+}
+
+func (b *balancer) release(name string, s *server, probe bool, outcome util.Outcome, latency time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	s.inflight--
+	if !b.breakerEnabled() {
+		return
+	}
+
+	if probe {
+		s.probing = false
+	}
+	if outcome == util.Abandoned {
+		// neither a success nor a failure of the server, a probe keeps the
+		// circuit half open so that the next request probes the server again
+		return
+	}
+
+	if probe {
+		if outcome == util.Failed {
+			blog.Warnf("[apimachinery][balance] probe server %s failed, keep the circuit open", name)
+			b.open(s)
+			return
+		}
+		blog.Infof("[apimachinery][balance] probe server %s success, close the circuit", name)
+		s.state = circuitClosed
+		s.failures = 0
+		// the latency before the circuit opened is out of date
+		s.latency = float64(latency)
+		s.samples = 1
+		return
+	}
+
+	if outcome == util.Failed {
+		s.failures++
+		if b.breaker.FailureThreshold > 0 && s.failures >= b.breaker.FailureThreshold && s.state == circuitClosed {
+			blog.Warnf("[apimachinery][balance] server %s failed %d times in a row, open the circuit", name, s.failures)
+			b.open(s)
+		}
+		return
+	}
+
+	s.failures = 0
+	if s.samples == 0 {
+		s.latency = float64(latency)
+	} else {
+		s.latency = latencyWeight*float64(latency) + (1-latencyWeight)*s.latency
+	}
+	s.samples++
+	if b.isOutlier(name, s) {
+		blog.Warnf("[apimachinery][balance] server %s is an outlier with average latency %v, eject it",
+			name, time.Duration(s.latency))
+		b.open(s)
+	}
+}
+
+// isOutlier check whether the latency of the server exceeds the factor times of the
+// fastest server of the same service, the last server whose circuit is closed is never ejected.
+func (b *balancer) isOutlier(name string, s *server) bool {
+	if b.breaker.OutlierFactor <= 0 || s.state != circuitClosed || s.samples < minLatencySamples {
+		return false
+	}
+	fastest := float64(0)
+	for other, o := range b.servers {
+		if other == name || o.service != s.service || o.state != circuitClosed || o.samples < minLatencySamples {
+			continue
+		}
+		if fastest == 0 || o.latency < fastest {
+			fastest = o.latency
+		}
+	}
+	return fastest > 0 && s.latency > b.breaker.OutlierFactor*fastest
+}
+
+func (b *balancer) open(s *server) {
+	s.state = circuitOpen
+	s.openedAt = time.Now()
+	s.failures = 0
+	s.fallback = false
+	s.probing = false
+}
+
+func (b *balancer) AllowRetry() bool {
+	return b.budget.withdraw()
+}
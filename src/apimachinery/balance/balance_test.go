@@ -0,0 +1,274 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package balance
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/apimachinery/util"
+)
+
+var servers = []string{"http://127.0.0.1:1", "http://127.0.0.1:2", "http://127.0.0.1:3"}
+
+func newTestBalancer(t *testing.T, c *util.APIMachineryConfig) *balancer {
+	b, err := NewBalancer(c)
+	if err != nil {
+		t.Fatalf("new balancer failed, err: %v", err)
+	}
+	return b.(*balancer)
+}
+
+func TestRoundRobin(t *testing.T) {
+	b := newTestBalancer(t, &util.APIMachineryConfig{})
+	first := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		ordered, err := b.Pick("topo", servers, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ordered) != len(servers) {
+			t.Fatalf("expect all the servers, got %v", ordered)
+		}
+		first[ordered[0]]++
+	}
+	for _, server := range servers {
+		if first[server] != 10 {
+			t.Errorf("expect server %s picked first 10 times, got %d", server, first[server])
+		}
+	}
+}
+
+func TestLeastInflight(t *testing.T) {
+	b := newTestBalancer(t, &util.APIMachineryConfig{Balance: LeastInflight})
+	release0, _ := b.Acquire(servers[0])
+	release1, _ := b.Acquire(servers[1])
+	for i := 0; i < 5; i++ {
+		ordered, _ := b.Pick("topo", servers, "")
+		if ordered[0] != servers[2] {
+			t.Errorf("expect the idle server first, got %v", ordered)
+		}
+	}
+	release0(util.Succeeded)
+	release1(util.Succeeded)
+}
+
+func TestConsistentHash(t *testing.T) {
+	b := newTestBalancer(t, &util.APIMachineryConfig{Balance: ConsistentHash})
+	hit := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		key := "rid-" + time.Duration(i).String()
+		ordered, _ := b.Pick("topo", servers, key)
+		again, _ := b.Pick("topo", []string{servers[2], servers[0], servers[1]}, key)
+		if len(ordered) != len(servers) || ordered[0] != again[0] {
+			t.Fatalf("expect the same server for key %s, got %v and %v", key, ordered, again)
+		}
+		hit[ordered[0]] = true
+	}
+	if len(hit) != len(servers) {
+		t.Errorf("expect the keys spread over all the servers, got %v", hit)
+	}
+}
+
+func TestUnknownStrategy(t *testing.T) {
+	if _, err := NewBalancer(&util.APIMachineryConfig{Balance: "random"}); err == nil {
+		t.Error("expect error for unknown strategy")
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	b := newTestBalancer(t, &util.APIMachineryConfig{
+		CircuitBreaker: util.CircuitBreakerConfig{FailureThreshold: 2, OpenDuration: 50 * time.Millisecond},
+	})
+	for i := 0; i < 2; i++ {
+		release, ok := b.Acquire(servers[0])
+		if !ok {
+			t.Fatal("expect the circuit closed")
+		}
+		release(util.Failed)
+	}
+
+	ordered, _ := b.Pick("topo", servers, "")
+	for _, server := range ordered {
+		if server == servers[0] {
+			t.Fatalf("expect the server with open circuit skipped, got %v", ordered)
+		}
+	}
+	if _, ok := b.Acquire(servers[0]); ok {
+		t.Fatal("expect the open circuit rejects requests")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	release, ok := b.Acquire(servers[0])
+	if !ok {
+		t.Fatal("expect a probe request after the open duration")
+	}
+	if _, ok := b.Acquire(servers[0]); ok {
+		t.Fatal("expect only one probe request")
+	}
+	release(util.Failed)
+	if _, ok := b.Acquire(servers[0]); ok {
+		t.Fatal("expect the circuit opened again after the probe failed")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	release, _ = b.Acquire(servers[0])
+	release(util.Succeeded)
+	if release, ok = b.Acquire(servers[0]); !ok {
+		t.Fatal("expect the circuit closed after the probe succeeded")
+	}
+	release(util.Succeeded)
+}
+
+func TestAbandonedRequest(t *testing.T) {
+	b := newTestBalancer(t, &util.APIMachineryConfig{
+		CircuitBreaker: util.CircuitBreakerConfig{FailureThreshold: 2, OpenDuration: 50 * time.Millisecond},
+	})
+	release, _ := b.Acquire(servers[0])
+	release(util.Failed)
+	release, _ = b.Acquire(servers[0])
+	release(util.Abandoned)
+	release, _ = b.Acquire(servers[0])
+	release(util.Failed)
+	if _, ok := b.Acquire(servers[0]); ok {
+		t.Fatal("expect the abandoned request not reset the failures")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	release, ok := b.Acquire(servers[0])
+	if !ok {
+		t.Fatal("expect a probe request after the open duration")
+	}
+	release(util.Abandoned)
+	if s := b.get(servers[0]); s.state != circuitHalfOpen || s.samples != 0 {
+		t.Fatalf("expect the circuit kept half open after the probe abandoned, got %+v", s)
+	}
+	ordered, _ := b.Pick("topo", servers, "")
+	if len(ordered) != len(servers) {
+		t.Fatalf("expect the half open server picked for the next probe, got %v", ordered)
+	}
+	release, ok = b.Acquire(servers[0])
+	if !ok {
+		t.Fatal("expect the next request probes the server again")
+	}
+	if _, ok := b.Acquire(servers[0]); ok {
+		t.Fatal("expect only one probe request")
+	}
+	release(util.Succeeded)
+	if s := b.get(servers[0]); s.state != circuitClosed {
+		t.Fatalf("expect the circuit closed after the probe succeeded, got %+v", s)
+	}
+}
+
+func TestConsistentHashPerService(t *testing.T) {
+	b := newTestBalancer(t, &util.APIMachineryConfig{Balance: ConsistentHash})
+	others := []string{"http://127.0.0.1:4", "http://127.0.0.1:5"}
+	ordered, _ := b.Pick("topo", servers, "rid")
+	b.Pick("host", others, "rid")
+	again, _ := b.Pick("topo", servers, "rid")
+	if ordered[0] != again[0] {
+		t.Fatalf("expect the same server for the key, got %v and %v", ordered, again)
+	}
+	rings := b.strategy.(*consistentHash).rings
+	if len(rings) != 2 || rings["topo"].key == rings["host"].key {
+		t.Errorf("expect a ring per service, got %v", rings)
+	}
+}
+
+func TestAllCircuitsOpen(t *testing.T) {
+	b := newTestBalancer(t, &util.APIMachineryConfig{
+		CircuitBreaker: util.CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute},
+	})
+	for _, server := range servers {
+		release, _ := b.Acquire(server)
+		release(util.Failed)
+		time.Sleep(time.Millisecond)
+	}
+	ordered, err := b.Pick("topo", servers, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ordered) != 1 || ordered[0] != servers[0] {
+		t.Fatalf("expect the least recently opened server, got %v", ordered)
+	}
+	release, ok := b.Acquire(servers[0])
+	if !ok {
+		t.Fatal("expect the fallback server let through")
+	}
+	release(util.Failed)
+
+	ordered, _ = b.Pick("topo", servers, "")
+	if len(ordered) != 1 || ordered[0] != servers[1] {
+		t.Fatalf("expect the next least recently opened server after the fallback failed, got %v", ordered)
+	}
+	release, _ = b.Acquire(servers[1])
+	release(util.Succeeded)
+	if ordered, _ = b.Pick("topo", servers, ""); len(ordered) != 1 || ordered[0] != servers[1] {
+		t.Errorf("expect the circuit closed after the fallback succeeded, got %v", ordered)
+	}
+}
+
+func TestOutlierEjection(t *testing.T) {
+	b := newTestBalancer(t, &util.APIMachineryConfig{
+		CircuitBreaker: util.CircuitBreakerConfig{OutlierFactor: 3, OpenDuration: time.Minute},
+	})
+	// the fast server of another service doesn't make the servers outliers
+	other := "http://127.0.0.1:4"
+	b.Pick("host", []string{other}, "")
+	b.Pick("topo", servers, "")
+	for i := 0; i < minLatencySamples; i++ {
+		b.release(other, b.get(other), false, util.Succeeded, time.Microsecond)
+	}
+	for i := 0; i < minLatencySamples; i++ {
+		b.release(servers[0], b.get(servers[0]), false, util.Succeeded, time.Millisecond)
+		b.release(servers[1], b.get(servers[1]), false, util.Succeeded, time.Millisecond)
+		b.release(servers[2], b.get(servers[2]), false, util.Succeeded, 10*time.Millisecond)
+	}
+	ordered, _ := b.Pick("topo", servers, "")
+	if len(ordered) != 2 {
+		t.Fatalf("expect the slow server ejected, got %v", ordered)
+	}
+	for _, server := range ordered {
+		if server == servers[2] {
+			t.Fatalf("expect the slow server ejected, got %v", ordered)
+		}
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	b := newTestBalancer(t, &util.APIMachineryConfig{RetryBudget: util.RetryBudgetConfig{Ratio: 0.5}})
+	if b.AllowRetry() {
+		t.Fatal("expect no budget before any request")
+	}
+	for i := 0; i < 4; i++ {
+		b.Pick("topo", servers, "")
+	}
+	for i := 0; i < 2; i++ {
+		if !b.AllowRetry() {
+			t.Fatalf("expect retry %d allowed", i)
+		}
+	}
+	if b.AllowRetry() {
+		t.Fatal("expect the budget exhausted")
+	}
+
+	b = newTestBalancer(t, &util.APIMachineryConfig{RetryBudget: util.RetryBudgetConfig{MinPerSecond: 1}})
+	if !b.AllowRetry() {
+		t.Fatal("expect the minimum retry allowed")
+	}
+
+	b = newTestBalancer(t, &util.APIMachineryConfig{})
+	if !b.AllowRetry() {
+		t.Fatal("expect retry unlimited without budget")
+	}
+}
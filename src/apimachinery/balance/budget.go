@@ -0,0 +1,71 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package balance
+
+import (
+	"sync"
+
+	"configcenter/src/apimachinery/flowctrl"
+	"configcenter/src/apimachinery/util"
+)
+
+// maxBudget the retries saved by the requests at most, so that a burst of
+// retries after a long healthy period is still limited
+const maxBudget = 100
+
+// retryBudget every request earns ratio of a retry, and every retry costs one,
+// besides, the minimum retries per second are always allowed by the rate limiter.
+type retryBudget struct {
+	ratio   float64
+	limiter flowctrl.RateLimiter
+
+	lock    sync.Mutex
+	balance float64
+}
+
+func newRetryBudget(c util.RetryBudgetConfig) *retryBudget {
+	if c.Ratio <= 0 && c.MinPerSecond <= 0 {
+		return nil
+	}
+	b := &retryBudget{ratio: c.Ratio}
+	if c.MinPerSecond > 0 {
+		b.limiter = flowctrl.NewRateLimiter(c.MinPerSecond, c.MinPerSecond)
+	}
+	return b
+}
+
+func (b *retryBudget) deposit() {
+	if b == nil || b.ratio <= 0 {
+		return
+	}
+	b.lock.Lock()
+	b.balance += b.ratio
+	if b.balance > maxBudget {
+		b.balance = maxBudget
+	}
+	b.lock.Unlock()
+}
+
+func (b *retryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.lock.Lock()
+	if b.balance >= 1 {
+		b.balance--
+		b.lock.Unlock()
+		return true
+	}
+	b.lock.Unlock()
+	return b.limiter != nil && b.limiter.TryAccept()
+}
@@ -0,0 +1,171 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package balance
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// RoundRobin send the requests to the servers in turn
+	RoundRobin = "round-robin"
+	// LeastInflight send the request to the server with the least inflight requests
+	LeastInflight = "least-inflight"
+	// ConsistentHash send the requests with the same request id to the same server
+	ConsistentHash = "consistent-hash"
+)
+
+// Strategy decide the order of the servers to try for a request
+type Strategy interface {
+	// Order returns the servers of the service in the order to try, it must not
+	// modify the servers, inflight returns the inflight requests of a server
+	Order(service string, servers []string, key string, inflight func(server string) int64) []string
+}
+
+// StrategyFactory create a strategy, a balancer has its own strategy
+type StrategyFactory func() Strategy
+
+var (
+	strategyLock sync.RWMutex
+	strategies   = make(map[string]StrategyFactory)
+)
+
+// RegisterStrategy register a strategy with the name
+func RegisterStrategy(name string, factory StrategyFactory) {
+	strategyLock.Lock()
+	defer strategyLock.Unlock()
+	strategies[name] = factory
+}
+
+func newStrategy(name string) (Strategy, error) {
+	strategyLock.RLock()
+	defer strategyLock.RUnlock()
+	factory, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown balance strategy %s", name)
+	}
+	return factory(), nil
+}
+
+func init() {
+	RegisterStrategy(RoundRobin, func() Strategy { return new(roundRobin) })
+	RegisterStrategy(LeastInflight, func() Strategy { return new(leastInflight) })
+	RegisterStrategy(ConsistentHash, func() Strategy { return &consistentHash{rings: make(map[string]*ring)} })
+}
+
+type roundRobin struct {
+	next uint64
+}
+
+func (r *roundRobin) Order(service string, servers []string, key string, inflight func(server string) int64) []string {
+	ordered := make([]string, 0, len(servers))
+	if len(servers) == 0 {
+		return ordered
+	}
+	start := int((atomic.AddUint64(&r.next, 1) - 1) % uint64(len(servers)))
+	ordered = append(ordered, servers[start:]...)
+	return append(ordered, servers[:start]...)
+}
+
+// leastInflight rotate the servers like round robin, so that the servers with
+// the same inflight requests share the load
+type leastInflight struct {
+	roundRobin
+}
+
+func (l *leastInflight) Order(service string, servers []string, key string, inflight func(server string) int64) []string {
+	ordered := l.roundRobin.Order(service, servers, key, inflight)
+	counts := make(map[string]int64, len(ordered))
+	for _, server := range ordered {
+		counts[server] = inflight(server)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return counts[ordered[i]] < counts[ordered[j]]
+	})
+	return ordered
+}
+
+const virtualNodes = 100
+
+type ring struct {
+	// the sorted servers joined, to tell whether the servers are changed
+	key     string
+	hashes  []uint32
+	servers map[uint32]string
+}
+
+func newRing(key string, servers []string) *ring {
+	r := &ring{key: key, servers: make(map[uint32]string, len(servers)*virtualNodes)}
+	for _, server := range servers {
+		for i := 0; i < virtualNodes; i++ {
+			hash := crc32.ChecksumIEEE([]byte(server + "#" + strconv.Itoa(i)))
+			if _, exist := r.servers[hash]; exist {
+				continue
+			}
+			r.servers[hash] = server
+			r.hashes = append(r.hashes, hash)
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+// consistentHash walk the hash ring clockwise from the key, so the request with
+// the same key goes to the same server, and the following servers are the fallbacks.
+// the request without a key is balanced by round robin.
+type consistentHash struct {
+	roundRobin
+	lock sync.Mutex
+	// the hash ring of each service
+	rings map[string]*ring
+}
+
+func (c *consistentHash) Order(service string, servers []string, key string, inflight func(server string) int64) []string {
+	if key == "" {
+		return c.roundRobin.Order(service, servers, key, inflight)
+	}
+
+	sorted := append([]string(nil), servers...)
+	sort.Strings(sorted)
+	ringKey := strings.Join(sorted, ",")
+	c.lock.Lock()
+	r, ok := c.rings[service]
+	if !ok || r.key != ringKey {
+		// the servers of the service are changed, rebuild its ring
+		r = newRing(ringKey, sorted)
+		c.rings[service] = r
+	}
+	c.lock.Unlock()
+
+	ordered := make([]string, 0, len(servers))
+	if len(r.hashes) == 0 {
+		return ordered
+	}
+	seen := make(map[string]bool, len(servers))
+	hash := crc32.ChecksumIEEE([]byte(key))
+	start := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= hash })
+	for i := 0; i < len(r.hashes) && len(ordered) < len(servers); i++ {
+		server := r.servers[r.hashes[(start+i)%len(r.hashes)]]
+		if !seen[server] {
+			seen[server] = true
+			ordered = append(ordered, server)
+		}
+	}
+	return ordered
+}
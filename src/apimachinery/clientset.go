@@ -16,6 +16,7 @@ import (
 	"configcenter/src/apimachinery/adminserver"
 	"configcenter/src/apimachinery/apiserver"
 	"configcenter/src/apimachinery/auditcontroller"
+	"configcenter/src/apimachinery/balance"
 	"configcenter/src/apimachinery/coreservice"
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/apimachinery/eventserver"
@@ -28,6 +29,7 @@ import (
 	"configcenter/src/apimachinery/procserver"
 	"configcenter/src/apimachinery/toposerver"
 	"configcenter/src/apimachinery/util"
+	"configcenter/src/common/types"
 )
 
 type ClientSetInterface interface {
@@ -53,16 +55,22 @@ func NewApiMachinery(c *util.APIMachineryConfig, discover discovery.DiscoveryInt
 		return nil, err
 	}
 
+	balancer, err := balance.NewBalancer(c)
+	if err != nil {
+		return nil, err
+	}
+
 	flowcontrol := flowctrl.NewRateLimiter(c.QPS, c.Burst)
-	return NewClientSet(client, discover, flowcontrol), nil
+	return NewClientSet(client, discover, flowcontrol, balancer), nil
 }
 
-func NewClientSet(client util.HttpClient, discover discovery.DiscoveryInterface, throttle flowctrl.RateLimiter) ClientSetInterface {
+func NewClientSet(client util.HttpClient, discover discovery.DiscoveryInterface, throttle flowctrl.RateLimiter, balancer util.Balancer) ClientSetInterface {
 	return &ClientSet{
 		version:  "v3",
 		client:   client,
 		discover: discover,
 		throttle: throttle,
+		balancer: balancer,
 	}
 }
 
@@ -81,14 +89,17 @@ type ClientSet struct {
 	client   util.HttpClient
 	discover discovery.DiscoveryInterface
 	throttle flowctrl.RateLimiter
+	balancer util.Balancer
 	Mock     util.MockInfo
 }
 
 func (cs *ClientSet) HostServer() hostserver.HostServerClientInterface {
 	c := &util.Capability{
+		Service:  types.CC_MODULE_HOST,
 		Client:   cs.client,
 		Discover: cs.discover.HostServer(),
 		Throttle: cs.throttle,
+		Balancer: cs.balancer,
 		Mock:     cs.Mock,
 	}
 	cs.Mock.SetMockData = false
@@ -97,9 +108,11 @@ func (cs *ClientSet) HostServer() hostserver.HostServerClientInterface {
 
 func (cs *ClientSet) TopoServer() toposerver.TopoServerClientInterface {
 	c := &util.Capability{
+		Service:  types.CC_MODULE_TOPO,
 		Client:   cs.client,
 		Discover: cs.discover.TopoServer(),
 		Throttle: cs.throttle,
+		Balancer: cs.balancer,
 		Mock:     cs.Mock,
 	}
 	cs.Mock.SetMockData = false
@@ -108,9 +121,11 @@ func (cs *ClientSet) TopoServer() toposerver.TopoServerClientInterface {
 
 func (cs *ClientSet) ObjectController() objcontroller.ObjControllerClientInterface {
 	c := &util.Capability{
+		Service:  types.CC_MODULE_OBJECTCONTROLLER,
 		Client:   cs.client,
 		Discover: cs.discover.ObjectCtrl(),
 		Throttle: cs.throttle,
+		Balancer: cs.balancer,
 		Mock:     cs.Mock,
 	}
 	return objcontroller.NewObjectControllerInterface(c, cs.version)
@@ -118,9 +133,11 @@ func (cs *ClientSet) ObjectController() objcontroller.ObjControllerClientInterfa
 
 func (cs *ClientSet) ProcServer() procserver.ProcServerClientInterface {
 	c := &util.Capability{
+		Service:  types.CC_MODULE_PROC,
 		Client:   cs.client,
 		Discover: cs.discover.ProcServer(),
 		Throttle: cs.throttle,
+		Balancer: cs.balancer,
 	}
 	cs.Mock.SetMockData = false
 	return procserver.NewProcServerClientInterface(c, cs.version)
@@ -128,9 +145,11 @@ func (cs *ClientSet) ProcServer() procserver.ProcServerClientInterface {
 
 func (cs *ClientSet) AdminServer() adminserver.AdminServerClientInterface {
 	c := &util.Capability{
+		Service:  types.CC_MODULE_MIGRATE,
 		Client:   cs.client,
 		Discover: cs.discover.MigrateServer(),
 		Throttle: cs.throttle,
+		Balancer: cs.balancer,
 		Mock:     cs.Mock,
 	}
 	cs.Mock.SetMockData = false
@@ -139,18 +158,22 @@ func (cs *ClientSet) AdminServer() adminserver.AdminServerClientInterface {
 
 func (cs *ClientSet) ApiServer() apiserver.ApiServerClientInterface {
 	c := &util.Capability{
+		Service:  types.CC_MODULE_APISERVER,
 		Client:   cs.client,
 		Discover: cs.discover.ApiServer(),
 		Throttle: cs.throttle,
+		Balancer: cs.balancer,
 	}
 	return apiserver.NewApiServerClientInterface(c, cs.version)
 }
 
 func (cs *ClientSet) EventServer() eventserver.EventServerClientInterface {
 	c := &util.Capability{
+		Service:  types.CC_MODULE_EVENTSERVER,
 		Client:   cs.client,
 		Discover: cs.discover.EventServer(),
 		Throttle: cs.throttle,
+		Balancer: cs.balancer,
 		Mock:     cs.Mock,
 	}
 	cs.Mock.SetMockData = false
@@ -159,9 +182,11 @@ func (cs *ClientSet) EventServer() eventserver.EventServerClientInterface {
 
 func (cs *ClientSet) AuditController() auditcontroller.AuditCtrlInterface {
 	c := &util.Capability{
+		Service:  types.CC_MODULE_AUDITCONTROLLER,
 		Client:   cs.client,
 		Discover: cs.discover.AuditCtrl(),
 		Throttle: cs.throttle,
+		Balancer: cs.balancer,
 		Mock:     cs.Mock,
 	}
 	cs.Mock.SetMockData = false
@@ -170,9 +195,11 @@ func (cs *ClientSet) AuditController() auditcontroller.AuditCtrlInterface {
 
 func (cs *ClientSet) ProcController() proccontroller.ProcCtrlClientInterface {
 	c := &util.Capability{
+		Service:  types.CC_MODULE_PROCCONTROLLER,
 		Client:   cs.client,
 		Discover: cs.discover.ProcCtrl(),
 		Throttle: cs.throttle,
+		Balancer: cs.balancer,
 		Mock:     cs.Mock,
 	}
 	cs.Mock.SetMockData = false
@@ -181,9 +208,11 @@ func (cs *ClientSet) ProcController() proccontroller.ProcCtrlClientInterface {
 
 func (cs *ClientSet) HostController() hostcontroller.HostCtrlClientInterface {
 	c := &util.Capability{
+		Service:  types.CC_MODULE_HOSTCONTROLLER,
 		Client:   cs.client,
 		Discover: cs.discover.HostCtrl(),
 		Throttle: cs.throttle,
+		Balancer: cs.balancer,
 		Mock:     cs.Mock,
 	}
 	return hostcontroller.NewHostCtrlClientInterface(c, cs.version)
@@ -199,9 +228,11 @@ func (cs *ClientSet) Healthz() healthz.HealthzInterface {
 
 func (cs *ClientSet) CoreService() coreservice.CoreServiceClientInterface {
 	c := &util.Capability{
+		Service:  types.CC_MODULE_CORESERVICE,
 		Client:   cs.client,
 		Discover: cs.discover.CoreService(),
 		Throttle: cs.throttle,
+		Balancer: cs.balancer,
 		Mock:     cs.Mock,
 	}
 	return coreservice.NewCoreServiceClient(c, cs.version)
@@ -76,6 +76,34 @@ func (inst *instance) UpdateInstance(ctx context.Context, h http.Header, objID s
 	return
 }
 
+func (inst *instance) UpdateManyInstance(ctx context.Context, h http.Header, objID string, input *metadata.UpdateManyModelInstance) (resp *metadata.UpdatedManyOptionResult, err error) {
+	resp = new(metadata.UpdatedManyOptionResult)
+	subPath := fmt.Sprintf("/updatemany/model/%s/instance", objID)
+
+	err = inst.client.Put().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *instance) UpsertInstance(ctx context.Context, h http.Header, objID string, input *metadata.UpsertModelInstance) (resp *metadata.SetOptionResult, err error) {
+	resp = new(metadata.SetOptionResult)
+	subPath := fmt.Sprintf("/upsert/model/%s/instance", objID)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
 func (inst *instance) ReadInstance(ctx context.Context, h http.Header, objID string, input *metadata.QueryCondition) (resp *metadata.QueryConditionResult, err error) {
 	resp = new(metadata.QueryConditionResult)
 	subPath := fmt.Sprintf("/read/model/%s/instances", objID)
@@ -117,3 +145,73 @@ func (inst *instance) DeleteInstanceCascade(ctx context.Context, h http.Header,
 		Into(resp)
 	return
 }
+
+func (inst *instance) SearchDelArchive(ctx context.Context, h http.Header, input *metadata.QueryCondition) (resp *metadata.SearchDelArchiveResult, err error) {
+	resp = new(metadata.SearchDelArchiveResult)
+	subPath := "/read/recycle/archives"
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *instance) RestoreDelArchive(ctx context.Context, h http.Header, id int64) (resp *metadata.RestoreDelArchiveResult, err error) {
+	resp = new(metadata.RestoreDelArchiveResult)
+	subPath := fmt.Sprintf("/restore/recycle/archive/%d", id)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *instance) PurgeDelArchive(ctx context.Context, h http.Header, input *metadata.DeleteOption) (resp *metadata.DeletedOptionResult, err error) {
+	resp = new(metadata.DeletedOptionResult)
+	subPath := "/delete/recycle/archives"
+
+	err = inst.client.Delete().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *instance) SearchInstHistory(ctx context.Context, h http.Header, objID string, instID int64, input *metadata.QueryCondition) (resp *metadata.SearchInstHistoryResult, err error) {
+	resp = new(metadata.SearchInstHistoryResult)
+	subPath := fmt.Sprintf("/read/history/model/%s/instance/%d", objID, instID)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *instance) ReadInstanceAsOf(ctx context.Context, h http.Header, objID string, input *metadata.AsOfQueryCondition) (resp *metadata.QueryConditionResult, err error) {
+	resp = new(metadata.QueryConditionResult)
+	subPath := fmt.Sprintf("/read/history/model/%s/instances", objID)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
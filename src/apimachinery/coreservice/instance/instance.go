@@ -25,9 +25,18 @@ type InstanceClientInterface interface {
 	CreateManyInstance(ctx context.Context, h http.Header, objID string, input *metadata.CreateManyModelInstance) (resp *metadata.CreatedManyOptionResult, err error)
 	SetManyInstance(ctx context.Context, h http.Header, objID string, input *metadata.SetManyModelInstance) (resp *metadata.SetOptionResult, err error)
 	UpdateInstance(ctx context.Context, h http.Header, objID string, input *metadata.UpdateOption) (resp *metadata.UpdatedOptionResult, err error)
+	UpdateManyInstance(ctx context.Context, h http.Header, objID string, input *metadata.UpdateManyModelInstance) (resp *metadata.UpdatedManyOptionResult, err error)
+	UpsertInstance(ctx context.Context, h http.Header, objID string, input *metadata.UpsertModelInstance) (resp *metadata.SetOptionResult, err error)
 	ReadInstance(ctx context.Context, h http.Header, objID string, input *metadata.QueryCondition) (resp *metadata.QueryConditionResult, err error)
 	DeleteInstance(ctx context.Context, h http.Header, objID string, input *metadata.DeleteOption) (resp *metadata.DeletedOptionResult, err error)
 	DeleteInstanceCascade(ctx context.Context, h http.Header, objID string, input *metadata.DeleteOption) (resp *metadata.DeletedOptionResult, err error)
+
+	SearchDelArchive(ctx context.Context, h http.Header, input *metadata.QueryCondition) (resp *metadata.SearchDelArchiveResult, err error)
+	RestoreDelArchive(ctx context.Context, h http.Header, id int64) (resp *metadata.RestoreDelArchiveResult, err error)
+	PurgeDelArchive(ctx context.Context, h http.Header, input *metadata.DeleteOption) (resp *metadata.DeletedOptionResult, err error)
+
+	SearchInstHistory(ctx context.Context, h http.Header, objID string, instID int64, input *metadata.QueryCondition) (resp *metadata.SearchInstHistoryResult, err error)
+	ReadInstanceAsOf(ctx context.Context, h http.Header, objID string, input *metadata.AsOfQueryCondition) (resp *metadata.QueryConditionResult, err error)
 }
 
 func NewInstanceClientInterface(client rest.ClientInterface) InstanceClientInterface {
@@ -103,3 +103,45 @@ func (e *eventServer) Rebook(ctx context.Context, ownerID string, appID string,
 		Into(resp)
 	return
 }
+
+func (e *eventServer) SearchDeadLetter(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header, dat metadata.ParamDeadLetterSearch) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/subscribe/%s/%s/%s/deadletter/search", ownerID, appID, subscribeID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) ReplayDeadLetter(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header, dat metadata.ParamDeadLetterOperate) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/subscribe/%s/%s/%s/deadletter/replay", ownerID, appID, subscribeID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) PurgeDeadLetter(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header, dat metadata.ParamDeadLetterOperate) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/subscribe/%s/%s/%s/deadletter", ownerID, appID, subscribeID)
+
+	err = e.client.Delete().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
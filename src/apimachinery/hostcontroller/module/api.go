@@ -117,6 +117,20 @@ func (m *mod) GetModulesHostConfig(ctx context.Context, h http.Header, dat map[s
 	return
 }
 
+func (m *mod) GetModulesHostConfigAsOf(ctx context.Context, h http.Header, dat *metadata.ModuleHostAsOfCondition) (resp *metadata.HostConfig, err error) {
+	resp = new(metadata.HostConfig)
+	subPath := "/meta/hosts/module/config/search/asof"
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
 func (m *mod) TransferHostToDefaultModule(ctx context.Context, h http.Header, dat *metadata.TransferHostToDefaultModuleConfig) (resp *metadata.Response, err error) {
 	resp = new(metadata.Response)
 	subPath := "transfer/host/default/module"
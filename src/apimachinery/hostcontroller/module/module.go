@@ -28,6 +28,7 @@ type ModuleInterface interface {
 	MoveHost2ResourcePool(ctx context.Context, h http.Header, dat *metadata.ParamData) (resp *metadata.BaseResp, err error)
 	AssignHostToApp(ctx context.Context, h http.Header, dat interface{}) (resp *metadata.BaseResp, err error)
 	GetModulesHostConfig(ctx context.Context, h http.Header, dat map[string][]int64) (resp *metadata.HostConfig, err error)
+	GetModulesHostConfigAsOf(ctx context.Context, h http.Header, dat *metadata.ModuleHostAsOfCondition) (resp *metadata.HostConfig, err error)
 	TransferHostToDefaultModule(ctx context.Context, h http.Header, dat *metadata.TransferHostToDefaultModuleConfig) (resp *metadata.Response, err error)
 }
 
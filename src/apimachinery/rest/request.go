@@ -25,13 +25,16 @@ import (
 	"net/url"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"configcenter/src/apimachinery/balance"
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/trace"
 	commonUtil "configcenter/src/common/util"
 )
 
@@ -74,7 +77,9 @@ type Request struct {
 	timeout time.Duration
 
 	peek bool
-	err  error
+	// the request can be retried even if it's a write operation
+	idempotent bool
+	err        error
 }
 
 func (r *Request) WithParams(params map[string]string) *Request {
@@ -114,6 +119,13 @@ func (r *Request) Peek() *Request {
 	return r
 }
 
+// Idempotent mark the write request can be retried safely, the GET
+// request is always retried on the transient errors.
+func (r *Request) Idempotent() *Request {
+	r.idempotent = true
+	return r
+}
+
 func (r *Request) WithContext(ctx context.Context) *Request {
 	r.ctx = ctx
 	return r
@@ -221,8 +233,18 @@ func (r *Request) Do() *Result {
 		return result
 	}
 
+	balancer := r.capability.Balancer
+	if balancer != nil {
+		hosts, err = balancer.Pick(r.capability.Service, hosts, commonUtil.GetHTTPCCRequestID(r.headers))
+		if err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
 	maxRetryCycle := 3
 	var retries int
+	var lastResult *Result
 	for try := 0; try < maxRetryCycle; try++ {
 		for index, host := range hosts {
 			retries = try + index
@@ -234,7 +256,7 @@ func (r *Request) Do() *Result {
 			}
 
 			if r.ctx != nil {
-				req.WithContext(r.ctx)
+				req = req.WithContext(r.ctx)
 			}
 
 			req.Header = r.headers
@@ -245,16 +267,34 @@ func (r *Request) Do() *Result {
 			req.Header.Set("Accept", "application/json")
 
 			if retries > 0 {
+				if lastResult != nil && balancer != nil && !balancer.AllowRetry() {
+					blog.Warnf("[apimachinary] retry budget exhausted, give up %s %s, rid: %s", string(r.verb), url, commonUtil.GetHTTPCCRequestID(r.headers))
+					return lastResult
+				}
 				r.tryThrottle(url)
 			}
 
+			release := func(outcome util.Outcome) {}
+			if balancer != nil {
+				var ok bool
+				if release, ok = balancer.Acquire(host); !ok {
+					// the circuit of the host is opened by other requests
+					continue
+				}
+			}
+
+			span := r.startSpan(req, host, retries)
+
 			resp, err := client.Do(req)
 			if err != nil {
+				release(r.failure())
+				finishSpan(span, 0, err)
+				// the request which is not sent out can be retried safely on another host.
 				// "Connection reset by peer" is a special err which in most scenario is a a transient error.
 				// Which means that we can retry it. And so does the GET operation.
 				// While the other "write" operation can not simply retry it again, because they are not idempotent.
 
-				if !isConnectionReset(err) || r.verb != GET {
+				if !isDialError(err) && (!isConnectionReset(err) || !r.isIdempotent()) {
 					result.Err = err
 					if r.peek {
 						blog.Infof("[apimachinary][peek] %s %s with body %s, but %v", string(r.verb), url, r.body, err)
@@ -263,6 +303,7 @@ func (r *Request) Do() *Result {
 				}
 
 				// retry now
+				lastResult = &Result{Err: err}
 				time.Sleep(20 * time.Millisecond)
 				continue
 
@@ -271,9 +312,13 @@ func (r *Request) Do() *Result {
 			var body []byte
 			if resp.Body != nil {
 				data, err := ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
 				if err != nil {
+					release(r.failure())
+					finishSpan(span, resp.StatusCode, err)
 					if err == io.ErrUnexpectedEOF {
 						// retry now
+						lastResult = &Result{Err: err}
 						time.Sleep(20 * time.Millisecond)
 						continue
 					}
@@ -285,6 +330,12 @@ func (r *Request) Do() *Result {
 				}
 				body = data
 			}
+			if isServerUnavailable(resp.StatusCode) {
+				release(util.Failed)
+			} else {
+				release(util.Succeeded)
+			}
+			finishSpan(span, resp.StatusCode, nil)
 			blog.V(4).InfoDepthf(2, "[apimachinary][peek] %s %s with body %s, response %s, rid: %s", string(r.verb), url, r.body, body, commonUtil.GetHTTPCCRequestID(r.headers))
 			result.Body = body
 			result.StatusCode = resp.StatusCode
@@ -292,15 +343,75 @@ func (r *Request) Do() *Result {
 				blog.Infof("[apimachinary][peek] %s %s with body %s, response %s", string(r.verb), url, r.body, body)
 			}
 
+			if isServerUnavailable(resp.StatusCode) && r.isIdempotent() {
+				// the host is overloaded or going down, try another one
+				lastResult = &Result{Body: body, StatusCode: resp.StatusCode}
+				continue
+			}
 			return result
 		}
 
 	}
 
+	if lastResult != nil {
+		return lastResult
+	}
+	if balancer != nil && len(hosts) > 0 {
+		result.Err = balance.ErrNoAvailableServer
+		return result
+	}
 	result.Err = errors.New("unexpected error")
 	return result
 }
 
+// startSpan start a client span of the request, the parent is the span in the context
+// or in the traceparent header forwarded from the caller.
+func (r *Request) startSpan(req *http.Request, host string, retries int) *trace.Span {
+	parent := trace.SpanContextFromContext(r.ctx)
+	if !parent.IsValid() {
+		parent = trace.Extract(req.Header)
+	}
+	span := trace.StartSpan(string(r.verb)+" "+req.URL.Path, trace.SpanKindClient, parent)
+	if span == nil {
+		return nil
+	}
+	span.SetTag("http.method", string(r.verb))
+	span.SetTag("http.url", req.URL.String())
+	span.SetTag("peer.host", host)
+	span.SetTag("rid", commonUtil.GetHTTPCCRequestID(r.headers))
+	if retries > 0 {
+		span.SetTag("retries", strconv.Itoa(retries))
+	}
+
+	// the headers are shared by the retries and the caller
+	header := make(http.Header, len(req.Header))
+	for key, values := range req.Header {
+		header[key] = append([]string(nil), values...)
+	}
+	trace.Inject(header, span.Context())
+	req.Header = header
+	return span
+}
+
+func finishSpan(span *trace.Span, statusCode int, err error) {
+	if span == nil {
+		return
+	}
+	if statusCode != 0 {
+		span.SetTag("http.status_code", strconv.Itoa(statusCode))
+	}
+	if err == nil && statusCode >= http.StatusInternalServerError {
+		err = fmt.Errorf("http status %d", statusCode)
+	}
+	span.SetError(err)
+	span.Finish()
+}
+
+// isIdempotent returns whether the request can be sent again after it's sent out
+func (r *Request) isIdempotent() bool {
+	return r.verb == GET || r.idempotent
+}
+
 const maxLatency = 100 * time.Millisecond
 
 func (r *Request) tryThrottle(url string) {
@@ -417,3 +528,31 @@ func isConnectionReset(err error) bool {
 	}
 	return false
 }
+
+// Returns if the given err happens when dialing, the request is not sent out.
+func isDialError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	if opErr, ok := err.(*net.OpError); ok {
+		return opErr.Op == "dial"
+	}
+	return false
+}
+
+// failure returns the outcome of a request failed before the response is read,
+// the request canceled or timed out by the caller is not the fault of the server
+func (r *Request) failure() util.Outcome {
+	if r.ctx != nil && r.ctx.Err() != nil {
+		return util.Abandoned
+	}
+	return util.Failed
+}
+
+// Returns if the server is unavailable, such as the gateway errors and
+// the service is overloaded, the host is unhealthy for the balancer.
+func isServerUnavailable(statusCode int) bool {
+	return statusCode == http.StatusBadGateway ||
+		statusCode == http.StatusServiceUnavailable ||
+		statusCode == http.StatusGatewayTimeout
+}
@@ -13,6 +13,8 @@
 package util
 
 import (
+	"time"
+
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/apimachinery/flowctrl"
 )
@@ -23,15 +25,71 @@ type APIMachineryConfig struct {
 	// request's burst value
 	Burst     int64
 	TLSConfig *TLSClientConfig
+	// the strategy to balance the requests among the servers of a service,
+	// such as round-robin, least-inflight and consistent-hash
+	Balance        string
+	CircuitBreaker CircuitBreakerConfig
+	RetryBudget    RetryBudgetConfig
+}
+
+// CircuitBreakerConfig the per server circuit breaker config, the breaker is disabled
+// if both the FailureThreshold and OutlierFactor are zero
+type CircuitBreakerConfig struct {
+	// the consecutive failures to open the circuit of a server
+	FailureThreshold int
+	// how long the circuit keeps open before a probe request is let through
+	OpenDuration time.Duration
+	// eject the server whose average latency exceeds the factor times of the fastest one
+	OutlierFactor float64
+}
+
+// RetryBudgetConfig limit the retries of the requests, so that the retries will not
+// overload the servers when they are in trouble, unlimited if both are zero
+type RetryBudgetConfig struct {
+	// the retries earned by a request, such as 0.2 for 20% extra load at most
+	Ratio float64
+	// the retries always allowed per second regardless of the ratio
+	MinPerSecond int64
 }
 
 type Capability struct {
+	// the name of the service requested, such as topo and coreservice
+	Service  string
 	Client   HttpClient
 	Discover discovery.Interface
 	Throttle flowctrl.RateLimiter
+	Balancer Balancer
 	Mock     MockInfo
 }
 
+// Balancer decide which server a request is sent to, and keep the health
+// of the servers from the results of the requests
+type Balancer interface {
+	// Pick returns the available servers of the service in the order to try, the
+	// key is used to choose the server by the consistent hash strategy. if the
+	// circuits of all the servers are open, the least recently opened one is returned.
+	Pick(service string, servers []string, key string) ([]string, error)
+	// Acquire is called before sending a request to the server, it returns
+	// false if the circuit of the server is open, release must be called with
+	// the outcome of the request after the response is received
+	Acquire(server string) (release func(outcome Outcome), ok bool)
+	// AllowRetry returns whether there's budget to retry a request
+	AllowRetry() bool
+}
+
+// Outcome is the outcome of a request sent to a server
+type Outcome int
+
+const (
+	// Succeeded the server responded the request
+	Succeeded Outcome = iota
+	// Failed the server is unavailable or the connection to it failed
+	Failed
+	// Abandoned the request is canceled or timed out by the caller before the
+	// server responded, which tells nothing about the health of the server
+	Abandoned
+)
+
 type MockInfo struct {
 	Mocked      bool
 	SetMockData bool
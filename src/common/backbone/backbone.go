@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/balance"
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/common"
@@ -28,6 +29,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
+	"configcenter/src/common/trace"
 	"configcenter/src/common/types"
 )
 
@@ -114,6 +116,15 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 		QPS:       1000,
 		Burst:     2000,
 		TLSConfig: nil,
+		Balance:   balance.LeastInflight,
+		CircuitBreaker: util.CircuitBreakerConfig{
+			FailureThreshold: 5,
+			OpenDuration:     10 * time.Second,
+		},
+		RetryBudget: util.RetryBudgetConfig{
+			Ratio:        0.2,
+			MinPerSecond: 10,
+		},
 	}
 	c, err := newConfig(ctx, input.SrvInfo, discoveryInterface, apiMachineryConfig)
 	if err != nil {
@@ -130,7 +141,7 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 	engine.srvInfo = input.SrvInfo
 
 	handler := &cc.CCHandler{
-		OnProcessUpdate:  input.ConfigUpdate,
+		OnProcessUpdate:  engine.onProcessUpdate(input.ConfigUpdate),
 		OnLanguageUpdate: engine.onLanguageUpdate,
 		OnErrorUpdate:    engine.onErrorUpdate,
 	}
@@ -200,6 +211,19 @@ func (e *Engine) ServiceManageClient() *zk.ZkClient {
 	return e.client
 }
 
+// onProcessUpdate start or stop the tracing with the [trace] config of the process,
+// then hand over the config to the process
+func (e *Engine) onProcessUpdate(handler cc.ProcHandlerFunc) cc.ProcHandlerFunc {
+	return func(previous, current cc.ProcessConfig) {
+		if err := trace.Init(common.GetIdentification(), trace.ParseConfigFromKV("trace", current.ConfigMap)); err != nil {
+			blog.Errorf("init trace failed, err: %v", err)
+		}
+		if handler != nil {
+			handler(previous, current)
+		}
+	}
+}
+
 func (e *Engine) onLanguageUpdate(previous, current map[string]language.LanguageMap) {
 	e.Lock()
 	defer e.Unlock()
@@ -0,0 +1,268 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cryptor implements the envelope encryption used to store secrets at rest.
+// every secret is encrypted with a random data key, and the data key is encrypted
+// with a master key from the config center. the master keys can be rotated by
+// adding a new key and making it active, the secrets encrypted with the old
+// master keys can still be decrypted until they are re-encrypted.
+package cryptor
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// cipherPrefix the prefix of the encrypted text, the format of encrypted text is
+	// cc-enc:v1:<master key id>:<base64 encrypted data key>:<base64 encrypted secret>
+	cipherPrefix = "cc-enc:v1:"
+	// dataKeyLength the data key use aes-256
+	dataKeyLength = 32
+)
+
+var (
+	// ErrNoMasterKey no master key is configured
+	ErrNoMasterKey = errors.New("no encryption master key configured")
+	// ErrInvalidCipherText the cipher text is broken
+	ErrInvalidCipherText = errors.New("invalid cipher text")
+)
+
+// Config define envelope encryption config
+type Config struct {
+	// MasterKeys the master keys, format: id1:base64key,id2:base64key,
+	// every key must be 16, 24 or 32 bytes after base64 decoded.
+	MasterKeys string
+	// ActiveKey the id of the master key used to encrypt new secrets
+	ActiveKey string
+}
+
+// ParseConfigFromKV returns new config
+func ParseConfigFromKV(prefix string, configmap map[string]string) Config {
+	return Config{
+		MasterKeys: configmap[prefix+".master_keys"],
+		ActiveKey:  configmap[prefix+".active_key"],
+	}
+}
+
+// Envelope encrypt and decrypt secrets with envelope encryption
+type Envelope struct {
+	masterKeys map[string][]byte
+	activeKey  string
+}
+
+// NewFromConfig returns new envelope from config, an envelope without any
+// master key can only decrypt the legacy base64 encoded secrets.
+func NewFromConfig(cfg Config) (*Envelope, error) {
+	envelope := &Envelope{
+		masterKeys: make(map[string][]byte),
+		activeKey:  strings.TrimSpace(cfg.ActiveKey),
+	}
+
+	for _, item := range strings.Split(cfg.MasterKeys, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pos := strings.Index(item, ":")
+		if pos <= 0 {
+			return nil, fmt.Errorf("invalid master key %s, should be id:base64key", item)
+		}
+		id := item[:pos]
+		key, err := base64.StdEncoding.DecodeString(item[pos+1:])
+		if err != nil {
+			return nil, fmt.Errorf("decode master key %s failed, err: %v", id, err)
+		}
+		if _, err := aes.NewCipher(key); err != nil {
+			return nil, fmt.Errorf("invalid master key %s, err: %v", id, err)
+		}
+		envelope.masterKeys[id] = key
+	}
+
+	if len(envelope.masterKeys) == 0 {
+		return envelope, nil
+	}
+	if envelope.activeKey == "" {
+		return nil, fmt.Errorf("active master key is not set")
+	}
+	if _, ok := envelope.masterKeys[envelope.activeKey]; !ok {
+		return nil, fmt.Errorf("active master key %s is not in master keys", envelope.activeKey)
+	}
+
+	return envelope, nil
+}
+
+// Holder holds the envelope of the latest config, so that the rotated master keys take effect
+// without a restart. it's safe for the concurrent use.
+type Holder struct {
+	envelope atomic.Value
+}
+
+// Update replace the envelope with the one of the config, the old one is kept if the config is invalid
+func (h *Holder) Update(cfg Config) error {
+	envelope, err := NewFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	h.envelope.Store(envelope)
+	return nil
+}
+
+// Load returns the envelope of the latest valid config, nil if no config is updated
+func (h *Holder) Load() *Envelope {
+	envelope, _ := h.envelope.Load().(*Envelope)
+	return envelope
+}
+
+// IsEncrypted check whether the text is encrypted by envelope
+func IsEncrypted(text string) bool {
+	return strings.HasPrefix(text, cipherPrefix)
+}
+
+// Encrypt encrypt the secret with a new data key, and encrypt the data key
+// with the active master key.
+func (e *Envelope) Encrypt(secret string) (string, error) {
+	if e == nil || len(e.masterKeys) == 0 {
+		return "", ErrNoMasterKey
+	}
+
+	dataKey := make([]byte, dataKeyLength)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+
+	encryptedKey, err := seal(e.masterKeys[e.activeKey], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	encryptedSecret, err := seal(dataKey, []byte(secret))
+	if err != nil {
+		return "", err
+	}
+
+	return cipherPrefix + e.activeKey + ":" +
+		base64.StdEncoding.EncodeToString(encryptedKey) + ":" +
+		base64.StdEncoding.EncodeToString(encryptedSecret), nil
+}
+
+// Decrypt decrypt the secret encrypted by Encrypt, the secret which is not
+// encrypted by envelope is taken as a legacy base64 encoded secret.
+func (e *Envelope) Decrypt(text string) (string, error) {
+	if !IsEncrypted(text) {
+		secret, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return "", err
+		}
+		return string(secret), nil
+	}
+
+	fields := strings.Split(strings.TrimPrefix(text, cipherPrefix), ":")
+	if len(fields) != 3 {
+		return "", ErrInvalidCipherText
+	}
+
+	if e == nil {
+		return "", ErrNoMasterKey
+	}
+	masterKey, ok := e.masterKeys[fields[0]]
+	if !ok {
+		return "", fmt.Errorf("master key %s not found", fields[0])
+	}
+
+	encryptedKey, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", ErrInvalidCipherText
+	}
+	dataKey, err := open(masterKey, encryptedKey)
+	if err != nil {
+		return "", err
+	}
+
+	encryptedSecret, err := base64.StdEncoding.DecodeString(fields[2])
+	if err != nil {
+		return "", ErrInvalidCipherText
+	}
+	secret, err := open(dataKey, encryptedSecret)
+	if err != nil {
+		return "", err
+	}
+
+	return string(secret), nil
+}
+
+// NeedReEncrypt check whether the text should be re-encrypted with the active master key,
+// which is true for the legacy base64 encoded secrets and secrets encrypted by old master keys.
+func (e *Envelope) NeedReEncrypt(text string) bool {
+	if !IsEncrypted(text) {
+		return true
+	}
+	if e == nil {
+		return false
+	}
+	return !strings.HasPrefix(text, cipherPrefix+e.activeKey+":")
+}
+
+// ReEncrypt decrypt the text and encrypt it again with the active master key
+func (e *Envelope) ReEncrypt(text string) (string, error) {
+	secret, err := e.Decrypt(text)
+	if err != nil {
+		return "", err
+	}
+	return e.Encrypt(secret)
+}
+
+// seal encrypt with aes-gcm, the nonce is put in front of the cipher text
+func seal(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrInvalidCipherText
+	}
+	nonce := ciphertext[:gcm.NonceSize()]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrInvalidCipherText
+	}
+	return plaintext, nil
+}
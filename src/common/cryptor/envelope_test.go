@@ -0,0 +1,125 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cryptor
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+var (
+	key1 = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	key2 = base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	envelope, err := NewFromConfig(Config{MasterKeys: "k1:" + key1, ActiveKey: "k1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := envelope.Encrypt("my secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(text) || strings.Contains(text, "my secret") {
+		t.Fatalf("unexpected cipher text %s", text)
+	}
+
+	secret, err := envelope.Decrypt(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret != "my secret" {
+		t.Errorf("decrypt got %s, but want my secret", secret)
+	}
+
+	if _, err := envelope.Decrypt(text[:len(text)-4]); err == nil {
+		t.Error("decrypt broken cipher text should fail")
+	}
+}
+
+func TestLegacySecret(t *testing.T) {
+	envelope, err := NewFromConfig(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy := base64.StdEncoding.EncodeToString([]byte("my secret"))
+	secret, err := envelope.Decrypt(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret != "my secret" {
+		t.Errorf("decrypt got %s, but want my secret", secret)
+	}
+
+	if _, err := envelope.Encrypt("my secret"); err != ErrNoMasterKey {
+		t.Errorf("encrypt without master key should fail with %v, but got %v", ErrNoMasterKey, err)
+	}
+}
+
+func TestRotateMasterKey(t *testing.T) {
+	old, err := NewFromConfig(Config{MasterKeys: "k1:" + key1, ActiveKey: "k1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := old.Encrypt("my secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := NewFromConfig(Config{MasterKeys: "k1:" + key1 + ",k2:" + key2, ActiveKey: "k2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rotated.NeedReEncrypt(text) {
+		t.Fatal("secret encrypted by old master key should be re-encrypted")
+	}
+
+	newText, err := rotated.ReEncrypt(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.NeedReEncrypt(newText) {
+		t.Error("re-encrypted secret should use the active master key")
+	}
+
+	secret, err := rotated.Decrypt(newText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret != "my secret" {
+		t.Errorf("decrypt got %s, but want my secret", secret)
+	}
+
+	if _, err := old.Decrypt(newText); err == nil {
+		t.Error("decrypt with missing master key should fail")
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	cases := []Config{
+		{MasterKeys: "k1:" + key1},
+		{MasterKeys: "k1:" + key1, ActiveKey: "k2"},
+		{MasterKeys: "k1:not-base64", ActiveKey: "k1"},
+		{MasterKeys: "k1:" + base64.StdEncoding.EncodeToString([]byte("short")), ActiveKey: "k1"},
+		{MasterKeys: key1, ActiveKey: "k1"},
+	}
+	for _, cfg := range cases {
+		if _, err := NewFromConfig(cfg); err == nil {
+			t.Errorf("config %+v should be invalid", cfg)
+		}
+	}
+}
@@ -187,6 +187,15 @@ const (
 	// BKDBUNSET the db opeartor
 	BKDBUNSET = "$unset"
 
+	// BKDBSet the db update operator
+	BKDBSet = "$set"
+
+	// BKDBInc the db update operator
+	BKDBInc = "$inc"
+
+	// BKDBSetOnInsert the db update operator
+	BKDBSetOnInsert = "$setOnInsert"
+
 	// BKDBSortFieldSep the db sort field split char
 	BKDBSortFieldSep = ","
 )
@@ -262,6 +271,9 @@ const (
 	// BKCloudSyncAccountAdmin the cloud sync account admin
 	BKCloudSyncAccountAdmin = "bk_account_admin"
 
+	// BKCloudSecretKey the cloud account secret key field
+	BKCloudSecretKey = "bk_secret_key"
+
 	// BKResourceType the cloud sync resource type
 	BKResourceType = "bk_resource_type"
 
@@ -293,6 +305,10 @@ const (
 	BKSubscriptionIDField = "subscription_id"
 	// BKSubscriptionNameField the subscription name field
 	BKSubscriptionNameField = "subscription_name"
+	// BKDeadLetterIDField the event dead letter id field
+	BKDeadLetterIDField = "dead_letter_id"
+	// BKCollectMappingNameField the data collection mapping name field
+	BKCollectMappingNameField = "mapping_name"
 
 	// BKOSTypeField the os type field
 	BKOSTypeField = "bk_os_type"
@@ -312,6 +328,12 @@ const (
 	// TencentCloudSignMethod the tencent cloud sign method
 	TencentCloudSignMethod = "HmacSHA1"
 
+	// BKCloudVendor the cloud vendor of the cloud sync task
+	BKCloudVendor = "bk_cloud_vendor"
+
+	// TencentCloudVendor the tencent cloud vendor
+	TencentCloudVendor = "tencent_cloud"
+
 	// BKCloudIDField the cloud id field
 	BKCloudIDField = "bk_cloud_id"
 
@@ -832,12 +854,26 @@ const (
 	BKHTTPOtherRequestID  = "X-Bkapi-Request-Id"
 	BKHTTPCCRequestTime   = "Cc_Request_Time"
 	BKHTTPCCTransactionID = "Cc_Txn_Id"
+	// BKHTTPSecondaryEligible the reads of the request may be served by the secondaries
+	// with the read preference configured by the service, the value is "true"
+	BKHTTPSecondaryEligible = "Cc_Secondary_Eligible"
+
+	// BKHTTPCCSignature the HMAC-SHA256 signature of the event callback
+	BKHTTPCCSignature = "X-CC-Signature"
+	// BKHTTPCCTimestamp the unix timestamp when the event callback is signed
+	BKHTTPCCTimestamp = "X-CC-Timestamp"
+	// BKHTTPCCNonce the random nonce of the event callback to prevent replay
+	BKHTTPCCNonce = "X-CC-Nonce"
 )
 
 type CCContextKey string
 
 const (
 	CCContextKeyJoinOption = CCContextKey("cc_context_joinoption")
+	// CCContextKeyReadOption the read option of the db reads in the context
+	CCContextKeyReadOption = CCContextKey("cc_context_readoption")
+	// CCContextKeySecondaryEligible the db reads in the context may be served by the secondaries
+	CCContextKeySecondaryEligible = CCContextKey("cc_context_secondaryeligible")
 )
 
 const (
@@ -1031,4 +1067,6 @@ const (
 
 	// SynchronizeAssociationTypeModelHost synchroneize model ggroup
 	SynchronizeAssociationTypeModelHost = "module_host"
+	// SynchronizeAssociationTypeInstAsst synchroneize instance association
+	SynchronizeAssociationTypeInstAsst = "inst_asst"
 )
@@ -359,6 +359,12 @@ const (
 	CCErrorTopoAssociationKindInconsistent = 1101083
 	// CCErrorTopoModleStopped means model have been stopped to use
 	CCErrorTopoModleStopped = 1101084
+	// CCErrorTopoCreateMultipleSourcesForOneToManyAssociation the destination instance of a 1:n association can be related with only one source instance
+	CCErrorTopoCreateMultipleSourcesForOneToManyAssociation = 1101085
+	// CCErrorTopoInstUniqueConflict the values of the unique keys, which contain the association keys, conflict with another instance
+	CCErrorTopoInstUniqueConflict = 1101086
+	// CCErrorTopoRecycleParentNotExist the parent of the archived instance in the recycle bin does not exist
+	CCErrorTopoRecycleParentNotExist = 1101087
 	// objectcontroller 1102XXX
 
 	// CCErrObjectPropertyGroupInsertFailed failed to save the property group
@@ -398,6 +404,16 @@ const (
 	CCErrEventSubscribeTelnetFailed = 1103005
 	// CCErrEventOperateSuccessBUtSentEventFailed failed to sent event
 	CCErrEventPushEventFailed = 1103006
+	// CCErrEventDeadLetterSelectFailed failed to query the dead letters
+	CCErrEventDeadLetterSelectFailed = 1103007
+	// CCErrEventDeadLetterReplayFailed failed to replay the dead letters
+	CCErrEventDeadLetterReplayFailed = 1103008
+	// CCErrEventDeadLetterPurgeFailed failed to purge the dead letters
+	CCErrEventDeadLetterPurgeFailed = 1103009
+	// CCErrEventWatchCursorExpired the events after the watch cursor have been cleaned
+	CCErrEventWatchCursorExpired = 1103010
+	// CCErrEventWatchFailed failed to watch the events
+	CCErrEventWatchFailed = 1103011
 
 	// host 1104XXX
 	CCErrHostModuleRelationAddFailed = 1104000
@@ -542,6 +558,10 @@ const (
 	CCErrHostModuleNotExist = 1110054
 	// CCErrDeleteHostFromBusiness Delete the host under the business
 	CCErrDeleteHostFromBusiness = 1110055
+	// CCErrCloudVendorNotSupport cloud vendor %s is not supported
+	CCErrCloudVendorNotSupport = 1110056
+	// CCErrCloudSecretEncryptFail failed to encrypt cloud account secret
+	CCErrCloudSecretEncryptFail = 1110057
 
 	//web  1111XXX
 	CCErrWebFileNoFound                 = 1111001
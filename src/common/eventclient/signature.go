@@ -0,0 +1,142 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eventclient
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+)
+
+// signaturePrefix the prefix of the signature header value, which names the hash algorithm
+const signaturePrefix = "sha256="
+
+var (
+	// ErrSignatureMissing the callback is not signed
+	ErrSignatureMissing = errors.New("event callback signature missing")
+	// ErrSignatureMismatch the signature is not signed by the secret, or the body is modified
+	ErrSignatureMismatch = errors.New("event callback signature mismatch")
+	// ErrSignatureExpired the timestamp of the callback is out of the tolerance
+	ErrSignatureExpired = errors.New("event callback signature expired")
+	// ErrNonceReplayed the nonce has been used by another callback
+	ErrNonceReplayed = errors.New("event callback nonce replayed")
+)
+
+// Sign returns the signature of the event callback, which is the hex encoded
+// HMAC-SHA256 of "timestamp\nnonce\nbody" keyed by the subscription secret.
+func Sign(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest set the timestamp, nonce and signature headers of the callback request
+func SignRequest(req *http.Request, secret string, body []byte) error {
+	nonce := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(common.BKHTTPCCTimestamp, timestamp)
+	req.Header.Set(common.BKHTTPCCNonce, hex.EncodeToString(nonce))
+	req.Header.Set(common.BKHTTPCCSignature, Sign(secret, timestamp, req.Header.Get(common.BKHTTPCCNonce), body))
+	return nil
+}
+
+// Verifier verify the signature of the event callbacks received by the
+// subscriber, it rejects the callbacks whose timestamp is out of the
+// tolerance, and the callbacks whose nonce has been seen in the tolerance.
+type Verifier struct {
+	secret    string
+	tolerance time.Duration
+	lock      sync.Mutex
+	nonces    map[string]time.Time
+}
+
+// NewVerifier returns a verifier of the subscription secret, the tolerance is
+// the max difference between the callback timestamp and the local time.
+func NewVerifier(secret string, tolerance time.Duration) *Verifier {
+	return &Verifier{
+		secret:    secret,
+		tolerance: tolerance,
+		nonces:    make(map[string]time.Time),
+	}
+}
+
+// Verify verify the signature headers with the callback body
+func (v *Verifier) Verify(header http.Header, body []byte) error {
+	signature := header.Get(common.BKHTTPCCSignature)
+	timestamp := header.Get(common.BKHTTPCCTimestamp)
+	nonce := header.Get(common.BKHTTPCCNonce)
+	if signature == "" || timestamp == "" || nonce == "" {
+		return ErrSignatureMissing
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(v.secret, timestamp, nonce, body))) {
+		return ErrSignatureMismatch
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignatureMismatch
+	}
+	now := time.Now()
+	signedAt := time.Unix(unix, 0)
+	if signedAt.Before(now.Add(-v.tolerance)) || signedAt.After(now.Add(v.tolerance)) {
+		return ErrSignatureExpired
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	for key, expire := range v.nonces {
+		if expire.Before(now) {
+			delete(v.nonces, key)
+		}
+	}
+	if _, exists := v.nonces[nonce]; exists {
+		return ErrNonceReplayed
+	}
+	v.nonces[nonce] = signedAt.Add(v.tolerance)
+	return nil
+}
+
+// VerifyRequest verify the callback request, the body is read and returned,
+// and it is also reset to the request so that it can be read again.
+func (v *Verifier) VerifyRequest(req *http.Request) ([]byte, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if err := v.Verify(req.Header, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eventclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"event_type":"instdata","action":"create"}`)
+	req, err := http.NewRequest(http.MethodPost, "http://127.0.0.1/callback", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SignRequest(req, "my secret", body); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := NewVerifier("my secret", time.Minute)
+	got, err := verifier.VerifyRequest(req)
+	if err != nil {
+		t.Fatalf("verify signed request failed, err: %v", err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Errorf("verify got body %s, but want %s", got, body)
+	}
+	if again, _ := ioutil.ReadAll(req.Body); !bytes.Equal(again, body) {
+		t.Errorf("request body should be readable after verify, but got %s", again)
+	}
+
+	if err := verifier.Verify(req.Header, body); err != ErrNonceReplayed {
+		t.Errorf("verify replayed request should fail with %v, but got %v", ErrNonceReplayed, err)
+	}
+
+	if err := NewVerifier("other secret", time.Minute).Verify(req.Header, body); err != ErrSignatureMismatch {
+		t.Errorf("verify with wrong secret should fail with %v, but got %v", ErrSignatureMismatch, err)
+	}
+	if err := NewVerifier("my secret", time.Minute).Verify(req.Header, []byte("{}")); err != ErrSignatureMismatch {
+		t.Errorf("verify modified body should fail with %v, but got %v", ErrSignatureMismatch, err)
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	body := []byte("{}")
+	timestamp := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	header := http.Header{}
+	header.Set(common.BKHTTPCCTimestamp, timestamp)
+	header.Set(common.BKHTTPCCNonce, "nonce")
+	header.Set(common.BKHTTPCCSignature, Sign("my secret", timestamp, "nonce", body))
+
+	if err := NewVerifier("my secret", time.Minute).Verify(header, body); err != ErrSignatureExpired {
+		t.Errorf("verify expired request should fail with %v, but got %v", ErrSignatureExpired, err)
+	}
+	if err := NewVerifier("my secret", time.Minute).Verify(http.Header{}, body); err != ErrSignatureMissing {
+		t.Errorf("verify unsigned request should fail with %v, but got %v", ErrSignatureMissing, err)
+	}
+}
@@ -0,0 +1,207 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+// Operation the request which changes the instances or the module host relations
+type Operation struct {
+	RequestID string
+	Operator  string
+	// OwnerID the owner of the histories whose data has no owner
+	OwnerID string
+}
+
+// NewOperation returns the operation of the request of the header
+func NewOperation(header http.Header) Operation {
+	return Operation{
+		RequestID: util.GetHTTPCCRequestID(header),
+		Operator:  util.GetUser(header),
+		OwnerID:   util.GetOwnerID(header),
+	}
+}
+
+// RecordInst record the versions of the instances of the object changed by the action. the instances changed
+// together share one version allocated from the sequence, which keeps the versions of an instance increasing.
+func RecordInst(ctx context.Context, db dal.RDB, op Operation, objID, action string, insts []mapstr.MapStr) error {
+	if 0 == len(insts) {
+		return nil
+	}
+
+	version, err := db.NextSequence(ctx, common.BKTableNameInstHistory)
+	if nil != err {
+		blog.Errorf("record the history of the instances of %s, but get the sequence failed, err: %v, rid: %s", objID, err, op.RequestID)
+		return err
+	}
+	now := time.Now()
+	histories := make([]metadata.InstHistory, 0, len(insts))
+	for _, inst := range insts {
+		history, err := metadata.NewInstHistory(objID, action, inst)
+		if nil != err {
+			blog.Errorf("record the history of the instance %#v of %s failed, err: %v, rid: %s", inst, objID, err, op.RequestID)
+			return err
+		}
+		history.Version = int64(version)
+		history.RequestID = op.RequestID
+		history.Operator = op.Operator
+		history.OpTime = now
+		if "" == history.OwnerID {
+			history.OwnerID = op.OwnerID
+		}
+		histories = append(histories, history)
+	}
+
+	if err := db.Table(common.BKTableNameInstHistory).Insert(ctx, histories); nil != err {
+		blog.Errorf("record the history of the instances of %s failed, err: %v, rid: %s", objID, err, op.RequestID)
+		return err
+	}
+	return nil
+}
+
+// RecordUpdatedInst record the versions of the updated instances of the object, which are read again by the
+// condition to get the whole data.
+func RecordUpdatedInst(ctx context.Context, db dal.RDB, op Operation, objID string, cond mapstr.MapStr) error {
+	insts := make([]mapstr.MapStr, 0)
+	if err := db.Table(common.GetInstTableName(objID)).Find(cond).All(ctx, &insts); nil != err {
+		blog.Errorf("record the history of the updated instances of %s, but get them failed, err: %v, rid: %s", objID, err, op.RequestID)
+		return err
+	}
+	return RecordInst(ctx, db, op, objID, metadata.InstHistoryActionUpdate, insts)
+}
+
+// RecordModuleHost record the changes of the module host relations, the relations changed together share
+// one version like the instances.
+func RecordModuleHost(ctx context.Context, db dal.RDB, op Operation, action string, relations []map[string]interface{}) error {
+	if 0 == len(relations) {
+		return nil
+	}
+
+	version, err := db.NextSequence(ctx, common.BKTableNameModuleHostHistory)
+	if nil != err {
+		blog.Errorf("record the history of the module host relations, but get the sequence failed, err: %v, rid: %s", err, op.RequestID)
+		return err
+	}
+	now := time.Now()
+	histories := make([]metadata.ModuleHostHistory, 0, len(relations))
+	for _, relation := range relations {
+		history, err := metadata.NewModuleHostHistory(action, relation)
+		if nil != err {
+			blog.Errorf("record the history of the module host relation %#v failed, err: %v, rid: %s", relation, err, op.RequestID)
+			return err
+		}
+		history.Version = int64(version)
+		history.RequestID = op.RequestID
+		history.OpTime = now
+		if "" == history.OwnerID {
+			history.OwnerID = op.OwnerID
+		}
+		histories = append(histories, history)
+	}
+
+	if err := db.Table(common.BKTableNameModuleHostHistory).Insert(ctx, histories); nil != err {
+		blog.Errorf("record the history of the module host relations failed, err: %v, rid: %s", err, op.RequestID)
+		return err
+	}
+	return nil
+}
+
+// Prune remove the histories which the queries as of the time no earlier than before don't need, which are the
+// versions of the instances and the changes of the module host relations superseded by the later ones before it,
+// and the whole history of the instances deleted and the relations removed before it.
+func Prune(ctx context.Context, db dal.RDB, before time.Time) error {
+	if err := prune(ctx, db, common.BKTableNameInstHistory, metadata.InstHistoryActionDelete, before,
+		common.BKObjIDField, common.BKInstIDField); nil != err {
+		return err
+	}
+	return prune(ctx, db, common.BKTableNameModuleHostHistory, metadata.ModuleHostHistoryActionDelete, before,
+		common.BKAppIDField, common.BKModuleIDField, common.BKHostIDField)
+}
+
+// prune remove the histories of the table before the time except the latest one of each key, which is removed
+// too if it's the delete action.
+func prune(ctx context.Context, db dal.RDB, tableName, deleteAction string, before time.Time, keys ...string) error {
+	id := mapstr.New()
+	for _, key := range keys {
+		id[key] = "$" + key
+	}
+	pipeline := []mapstr.MapStr{
+		{"$match": mapstr.MapStr{"op_time": mapstr.MapStr{common.BKDBLT: before}}},
+		{"$group": mapstr.MapStr{
+			"_id":     id,
+			"version": mapstr.MapStr{"$max": "$version"},
+			"count":   mapstr.MapStr{"$sum": 1},
+		}},
+		{"$match": mapstr.MapStr{"count": mapstr.MapStr{common.BKDBGT: 1}}},
+	}
+	latests := make([]struct {
+		ID      map[string]interface{} `bson:"_id"`
+		Version int64                  `bson:"version"`
+	}, 0)
+	if err := db.Table(tableName).AggregateAll(ctx, pipeline, &latests); nil != err {
+		blog.Errorf("prune the history of %s before %v, but get the latest versions failed, err: %v", tableName, before, err)
+		return err
+	}
+	for _, latest := range latests {
+		cond := mapstr.MapStr(latest.ID)
+		cond["version"] = mapstr.MapStr{common.BKDBLT: latest.Version}
+		if err := db.Table(tableName).Delete(ctx, cond); nil != err {
+			blog.Errorf("prune the history of %s by the condition %#v failed, err: %v", tableName, cond, err)
+			return err
+		}
+	}
+
+	cond := mapstr.MapStr{"op_time": mapstr.MapStr{common.BKDBLT: before}, "action": deleteAction}
+	deleted := make([]map[string]interface{}, 0)
+	if err := db.Table(tableName).Find(cond).Fields(keys...).All(ctx, &deleted); nil != err {
+		blog.Errorf("prune the history of %s before %v, but get the deleted ones failed, err: %v", tableName, before, err)
+		return err
+	}
+	for _, item := range deleted {
+		// the versions after the delete, such as the restore, are kept
+		cond := mapstr.New()
+		for _, key := range keys {
+			cond[key] = item[key]
+		}
+		cond["op_time"] = mapstr.MapStr{common.BKDBLT: before}
+		if err := db.Table(tableName).Delete(ctx, cond); nil != err {
+			blog.Errorf("prune the history of %s by the condition %#v failed, err: %v", tableName, cond, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Check returns the error of recording the history if the changes are made in a transaction, so that they are
+// rolled back together. otherwise the changes have been committed, failing the request can not undo them, so
+// the error is only logged.
+func Check(ctx context.Context, op Operation, err error) error {
+	if nil == err {
+		return nil
+	}
+	if opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption); ok && "" != opt.TxnID {
+		return err
+	}
+	blog.Errorf("the changes are committed without transaction, but their history is lost, err: %v, rid: %s", err, op.RequestID)
+	return nil
+}
@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/history"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+var op = history.Operation{RequestID: "test_req_id", Operator: "test_user", OwnerID: "test_owner"}
+
+func TestRecordInst(t *testing.T) {
+	ctx := context.Background()
+	db := memory.New()
+	hosts := []mapstr.MapStr{
+		{common.BKHostIDField: 1, common.BKHostInnerIPField: "10.0.0.1"},
+		{common.BKHostIDField: 2, common.BKHostInnerIPField: "10.0.0.2", common.BKOwnerIDField: "0"},
+	}
+	require.NoError(t, db.Table(common.BKTableNameBaseHost).Insert(ctx, hosts))
+	require.NoError(t, history.RecordInst(ctx, db, op, common.BKInnerObjIDHost, metadata.InstHistoryActionCreate, hosts))
+
+	// the instances changed together share one version
+	histories := make([]metadata.InstHistory, 0)
+	require.NoError(t, db.Table(common.BKTableNameInstHistory).Find(nil).Sort(common.BKInstIDField).All(ctx, &histories))
+	require.Len(t, histories, 2)
+	require.Equal(t, histories[0].Version, histories[1].Version)
+	require.Equal(t, "test_owner", histories[0].OwnerID)
+	require.Equal(t, "0", histories[1].OwnerID)
+	require.Equal(t, "test_user", histories[1].Operator)
+
+	require.NoError(t, db.Table(common.BKTableNameBaseHost).Update(ctx, mapstr.MapStr{common.BKHostIDField: 2}, mapstr.MapStr{common.BKHostInnerIPField: "10.0.0.3"}))
+	require.NoError(t, history.RecordUpdatedInst(ctx, db, op, common.BKInnerObjIDHost, mapstr.MapStr{common.BKHostIDField: 2}))
+	latest := metadata.InstHistory{}
+	require.NoError(t, db.Table(common.BKTableNameInstHistory).Find(mapstr.MapStr{"action": metadata.InstHistoryActionUpdate}).One(ctx, &latest))
+	require.Equal(t, int64(2), latest.InstID)
+	require.Equal(t, "10.0.0.3", latest.Data[common.BKHostInnerIPField])
+	require.True(t, latest.Version > histories[1].Version)
+
+	err := history.RecordInst(ctx, db, op, common.BKInnerObjIDHost, metadata.InstHistoryActionCreate, []mapstr.MapStr{{common.BKHostInnerIPField: "10.0.0.4"}})
+	require.Error(t, err)
+}
+
+func TestRecordModuleHost(t *testing.T) {
+	ctx := context.Background()
+	db := memory.New()
+	relations := []map[string]interface{}{
+		{common.BKAppIDField: 1, common.BKSetIDField: 2, common.BKModuleIDField: 3, common.BKHostIDField: 4},
+		{common.BKAppIDField: 1, common.BKSetIDField: 2, common.BKModuleIDField: 5, common.BKHostIDField: 4},
+	}
+	require.NoError(t, history.RecordModuleHost(ctx, db, op, metadata.ModuleHostHistoryActionAdd, relations))
+	require.NoError(t, history.RecordModuleHost(ctx, db, op, metadata.ModuleHostHistoryActionDelete, relations[1:]))
+
+	changes := make([]metadata.ModuleHostHistory, 0)
+	require.NoError(t, db.Table(common.BKTableNameModuleHostHistory).Find(nil).Sort("version").All(ctx, &changes))
+	require.Len(t, changes, 3)
+	require.Equal(t, changes[0].Version, changes[1].Version)
+	require.True(t, changes[2].Version > changes[1].Version)
+	require.Equal(t, metadata.ModuleHostHistoryActionDelete, changes[2].Action)
+	require.Equal(t, int64(5), changes[2].ModuleID)
+	require.Equal(t, "test_owner", changes[2].OwnerID)
+}
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	db := memory.New()
+	now := time.Now()
+	old, before := now.Add(-3*time.Hour), now.Add(-time.Hour)
+	versions := []metadata.InstHistory{
+		// the latest version before the time is kept
+		{Version: 1, Action: metadata.InstHistoryActionCreate, ObjectID: "switch", InstID: 1, OpTime: old},
+		{Version: 2, Action: metadata.InstHistoryActionUpdate, ObjectID: "switch", InstID: 1, OpTime: old},
+		{Version: 5, Action: metadata.InstHistoryActionUpdate, ObjectID: "switch", InstID: 1, OpTime: now},
+		// the instance deleted before the time
+		{Version: 3, Action: metadata.InstHistoryActionCreate, ObjectID: "switch", InstID: 2, OpTime: old},
+		{Version: 4, Action: metadata.InstHistoryActionDelete, ObjectID: "switch", InstID: 2, OpTime: old},
+		// the same instance id of another object
+		{Version: 1, Action: metadata.InstHistoryActionCreate, ObjectID: "router", InstID: 2, OpTime: old},
+	}
+	require.NoError(t, db.Table(common.BKTableNameInstHistory).Insert(ctx, versions))
+	changes := []metadata.ModuleHostHistory{
+		{Version: 1, Action: metadata.ModuleHostHistoryActionAdd, AppID: 1, ModuleID: 2, HostID: 3, OpTime: old},
+		{Version: 2, Action: metadata.ModuleHostHistoryActionDelete, AppID: 1, ModuleID: 2, HostID: 3, OpTime: old},
+		{Version: 2, Action: metadata.ModuleHostHistoryActionAdd, AppID: 1, ModuleID: 4, HostID: 3, OpTime: old},
+	}
+	require.NoError(t, db.Table(common.BKTableNameModuleHostHistory).Insert(ctx, changes))
+
+	require.NoError(t, history.Prune(ctx, db, before))
+	left := make([]metadata.InstHistory, 0)
+	require.NoError(t, db.Table(common.BKTableNameInstHistory).Find(nil).Sort("version").All(ctx, &left))
+	require.Len(t, left, 3)
+	require.Equal(t, "router", left[0].ObjectID)
+	require.Equal(t, []int64{2, 5}, []int64{left[1].Version, left[2].Version})
+	leftChanges := make([]metadata.ModuleHostHistory, 0)
+	require.NoError(t, db.Table(common.BKTableNameModuleHostHistory).Find(nil).All(ctx, &leftChanges))
+	require.Len(t, leftChanges, 1)
+	require.Equal(t, int64(4), leftChanges[0].ModuleID)
+}
+
+func TestCheck(t *testing.T) {
+	lost := errors.New("lost")
+	ctx := context.Background()
+	require.NoError(t, history.Check(ctx, op, nil))
+	require.NoError(t, history.Check(ctx, op, lost))
+
+	txnCtx := context.WithValue(ctx, common.CCContextKeyJoinOption, dal.JoinOption{RequestID: op.RequestID, TxnID: "txn"})
+	require.Equal(t, lost, history.Check(txnCtx, op, lost))
+}
@@ -34,6 +34,8 @@ const (
 	// AssociationFieldAssociationId auto incr id
 	AssociationFieldAssociationId   = "id"
 	AssociationFieldAssociationKind = "bk_asst_id"
+	// AssociationFieldMapping the association data field mapping
+	AssociationFieldMapping = "mapping"
 )
 
 type SearchAssociationTypeRequest struct {
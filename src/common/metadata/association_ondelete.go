@@ -0,0 +1,212 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/util"
+)
+
+// InstDeleteNode an instance which is going to be deleted.
+type InstDeleteNode struct {
+	ObjectID string `json:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id"`
+	// the model association and its on delete action which lead to delete this instance,
+	// both are empty for the instances which are asked to be deleted directly.
+	ObjectAsstID string                    `json:"bk_obj_asst_id,omitempty"`
+	OnDelete     AssociationOnDeleteAction `json:"on_delete,omitempty"`
+	// the length of the cascade chain, 0 means the instance is asked to be deleted directly.
+	Depth int `json:"depth"`
+}
+
+// InstDeletePlan describe what will be deleted when delete instances with the
+// on delete actions of the model associations.
+type InstDeletePlan struct {
+	// the instances to be deleted, in the order they are reached.
+	Instances []InstDeleteNode `json:"instances"`
+	// the instance associations to be deleted together with the instances.
+	Associations []InstAsst `json:"associations"`
+	// the instance associations which forbid the deletion.
+	Restricted []InstAsst `json:"restricted"`
+	// the instance associations which point back into the cascade chain, they are not followed again.
+	Cycles []InstAsst `json:"cycles"`
+}
+
+// InstDeleteLookup looks up the associations when plan an instance deletion.
+type InstDeleteLookup interface {
+	// InstAssociations returns the instance associations which the instance is the source or the destination of.
+	InstAssociations(objID string, instID int64) ([]InstAsst, error)
+	// ObjectAssociation returns the model association with the bk_obj_asst_id, nil if not exist.
+	ObjectAssociation(objAsstID string) (*Association, error)
+	// IsMainlineObject returns whether the object is a node of the mainline topology.
+	IsMainlineObject(objID string) (bool, error)
+}
+
+// PlanInstDelete walks the instance associations from the roots and works out which instances
+// should be deleted together with them:
+//  1. deleting the destination of an association with delete_src deletes the source instance.
+//  2. deleting the source of an association with delete_dest deletes the destination instance.
+//  3. an instance can not be deleted while it's the destination of an association with none
+//     and the source instance is kept.
+//  4. the inner objects' and the mainline objects' instances are never deleted by cascade, such an
+//     association restricts the deletion, as their children and hosts are not checked by the plan.
+//
+// the mainline associations are managed by the topology, so they are skipped.
+func PlanInstDelete(roots []InstDeleteNode, lookup InstDeleteLookup) (*InstDeletePlan, error) {
+	p := &instDeletePlanner{
+		lookup:   lookup,
+		plan:     &InstDeletePlan{Instances: []InstDeleteNode{}, Associations: []InstAsst{}, Restricted: []InstAsst{}, Cycles: []InstAsst{}},
+		policies: make(map[string]AssociationOnDeleteAction),
+		mainline: make(map[string]bool),
+		visited:  make(map[instDeleteKey]bool),
+		onPath:   make(map[instDeleteKey]bool),
+		assts:    make(map[int64]bool),
+		pending:  make(map[int64]instDeleteKey),
+	}
+
+	for _, root := range roots {
+		if err := p.visit(root); nil != err {
+			return nil, err
+		}
+	}
+
+	for _, asst := range p.plan.Associations {
+		if key, exists := p.pending[asst.ID]; exists && !p.visited[key] {
+			p.plan.Restricted = append(p.plan.Restricted, asst)
+		}
+	}
+	return p.plan, nil
+}
+
+type instDeleteKey struct {
+	objID  string
+	instID int64
+}
+
+type instDeletePlanner struct {
+	lookup   InstDeleteLookup
+	plan     *InstDeletePlan
+	policies map[string]AssociationOnDeleteAction
+	mainline map[string]bool
+	visited  map[instDeleteKey]bool
+	onPath   map[instDeleteKey]bool
+	assts    map[int64]bool
+	// the association restricts the deletion if the instance is kept.
+	pending map[int64]instDeleteKey
+}
+
+func (p *instDeletePlanner) policy(objAsstID string) (AssociationOnDeleteAction, error) {
+	if policy, exists := p.policies[objAsstID]; exists {
+		return policy, nil
+	}
+
+	policy := NoAction
+	asst, err := p.lookup.ObjectAssociation(objAsstID)
+	if nil != err {
+		return policy, err
+	}
+	if nil != asst && len(asst.OnDelete) != 0 {
+		policy = asst.OnDelete
+	}
+	p.policies[objAsstID] = policy
+	return policy, nil
+}
+
+func (p *instDeletePlanner) isMainline(objID string) (bool, error) {
+	if mainline, exists := p.mainline[objID]; exists {
+		return mainline, nil
+	}
+
+	mainline, err := p.lookup.IsMainlineObject(objID)
+	if nil != err {
+		return false, err
+	}
+	p.mainline[objID] = mainline
+	return mainline, nil
+}
+
+func (p *instDeletePlanner) visit(node InstDeleteNode) error {
+	key := instDeleteKey{objID: node.ObjectID, instID: node.InstID}
+	if p.visited[key] {
+		return nil
+	}
+	p.visited[key] = true
+	p.onPath[key] = true
+	defer delete(p.onPath, key)
+	p.plan.Instances = append(p.plan.Instances, node)
+
+	assts, err := p.lookup.InstAssociations(node.ObjectID, node.InstID)
+	if nil != err {
+		return err
+	}
+
+	for _, asst := range assts {
+		if asst.AssociationKindID == common.AssociationKindMainline {
+			continue
+		}
+		if p.assts[asst.ID] {
+			continue
+		}
+		p.assts[asst.ID] = true
+		p.plan.Associations = append(p.plan.Associations, asst)
+
+		policy, err := p.policy(asst.ObjectAsstID)
+		if nil != err {
+			return err
+		}
+
+		src := instDeleteKey{objID: asst.ObjectID, instID: asst.InstID}
+		dest := instDeleteKey{objID: asst.AsstObjectID, instID: asst.AsstInstID}
+		var next instDeleteKey
+		switch {
+		case key == dest && policy == DeleteSource:
+			next = src
+		case key == src && policy == DeleteDestinatioin:
+			next = dest
+		case key == dest && policy == NoAction:
+			p.pending[asst.ID] = src
+			continue
+		default:
+			continue
+		}
+
+		if p.onPath[next] {
+			p.plan.Cycles = append(p.plan.Cycles, asst)
+			continue
+		}
+		if util.IsInnerObject(next.objID) {
+			p.pending[asst.ID] = next
+			continue
+		}
+		mainline, err := p.isMainline(next.objID)
+		if nil != err {
+			return err
+		}
+		if mainline {
+			p.pending[asst.ID] = next
+			continue
+		}
+
+		err = p.visit(InstDeleteNode{
+			ObjectID:     next.objID,
+			InstID:       next.instID,
+			ObjectAsstID: asst.ObjectAsstID,
+			OnDelete:     policy,
+			Depth:        node.Depth + 1,
+		})
+		if nil != err {
+			return err
+		}
+	}
+	return nil
+}
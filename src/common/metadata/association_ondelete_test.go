@@ -0,0 +1,180 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+type mockInstDeleteLookup struct {
+	objAssts  map[string]*Association
+	instAssts []InstAsst
+	mainline  map[string]bool
+}
+
+func (m *mockInstDeleteLookup) InstAssociations(objID string, instID int64) ([]InstAsst, error) {
+	result := []InstAsst{}
+	for _, asst := range m.instAssts {
+		if (asst.ObjectID == objID && asst.InstID == instID) || (asst.AsstObjectID == objID && asst.AsstInstID == instID) {
+			result = append(result, asst)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockInstDeleteLookup) ObjectAssociation(objAsstID string) (*Association, error) {
+	return m.objAssts[objAsstID], nil
+}
+
+func (m *mockInstDeleteLookup) IsMainlineObject(objID string) (bool, error) {
+	return m.mainline[objID], nil
+}
+
+func newMockInstDeleteLookup() *mockInstDeleteLookup {
+	return &mockInstDeleteLookup{
+		objAssts: map[string]*Association{
+			"a_belong_b":    {AssociationName: "a_belong_b", ObjectID: "a", AsstObjID: "b", OnDelete: DeleteSource},
+			"b_run_c":       {AssociationName: "b_run_c", ObjectID: "b", AsstObjID: "c", OnDelete: DeleteDestinatioin},
+			"c_connect_a":   {AssociationName: "c_connect_a", ObjectID: "c", AsstObjID: "a", OnDelete: DeleteDestinatioin},
+			"d_connect_b":   {AssociationName: "d_connect_b", ObjectID: "d", AsstObjID: "b", OnDelete: NoAction},
+			"c_belong_host": {AssociationName: "c_belong_host", ObjectID: "c", AsstObjID: "host", OnDelete: DeleteDestinatioin},
+			"c_belong_city": {AssociationName: "c_belong_city", ObjectID: "c", AsstObjID: "city", OnDelete: DeleteDestinatioin},
+		},
+		mainline: map[string]bool{"city": true},
+	}
+}
+
+func instDeleteKeys(nodes []InstDeleteNode) []string {
+	keys := []string{}
+	for _, node := range nodes {
+		keys = append(keys, node.ObjectID)
+	}
+	return keys
+}
+
+func asstIDs(assts []InstAsst) []int64 {
+	ids := []int64{}
+	for _, asst := range assts {
+		ids = append(ids, asst.ID)
+	}
+	return ids
+}
+
+func TestPlanInstDeleteCascade(t *testing.T) {
+	lookup := newMockInstDeleteLookup()
+	lookup.instAssts = []InstAsst{
+		{ID: 1, ObjectAsstID: "a_belong_b", ObjectID: "a", InstID: 1, AsstObjectID: "b", AsstInstID: 1},
+		{ID: 2, ObjectAsstID: "b_run_c", ObjectID: "b", InstID: 1, AsstObjectID: "c", AsstInstID: 1},
+	}
+
+	plan, err := PlanInstDelete([]InstDeleteNode{{ObjectID: "b", InstID: 1}}, lookup)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if got := instDeleteKeys(plan.Instances); !reflect.DeepEqual(got, []string{"b", "a", "c"}) {
+		t.Errorf("instances = %v", got)
+	}
+	if plan.Instances[1].OnDelete != DeleteSource || plan.Instances[1].ObjectAsstID != "a_belong_b" || plan.Instances[1].Depth != 1 {
+		t.Errorf("unexpected cascade node %#v", plan.Instances[1])
+	}
+	if got := asstIDs(plan.Associations); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("associations = %v", got)
+	}
+	if len(plan.Restricted) != 0 || len(plan.Cycles) != 0 {
+		t.Errorf("unexpected restricted %v or cycles %v", plan.Restricted, plan.Cycles)
+	}
+}
+
+func TestPlanInstDeleteCycle(t *testing.T) {
+	lookup := newMockInstDeleteLookup()
+	lookup.instAssts = []InstAsst{
+		{ID: 1, ObjectAsstID: "a_belong_b", ObjectID: "a", InstID: 1, AsstObjectID: "b", AsstInstID: 1},
+		{ID: 2, ObjectAsstID: "b_run_c", ObjectID: "b", InstID: 1, AsstObjectID: "c", AsstInstID: 1},
+		{ID: 3, ObjectAsstID: "c_connect_a", ObjectID: "c", InstID: 1, AsstObjectID: "a", AsstInstID: 1},
+	}
+
+	plan, err := PlanInstDelete([]InstDeleteNode{{ObjectID: "c", InstID: 1}}, lookup)
+	if nil != err {
+		t.Fatal(err)
+	}
+	// a is the source of a_belong_b, which only deletes the source when b is deleted.
+	if got := instDeleteKeys(plan.Instances); !reflect.DeepEqual(got, []string{"c", "a"}) {
+		t.Errorf("instances = %v", got)
+	}
+	if got := asstIDs(plan.Cycles); !reflect.DeepEqual(got, []int64{}) {
+		t.Errorf("cycles = %v", got)
+	}
+
+	// a->b->c->a, the association from c closes the cycle.
+	lookup.objAssts["a_belong_b"].OnDelete = DeleteDestinatioin
+	plan, err = PlanInstDelete([]InstDeleteNode{{ObjectID: "a", InstID: 1}}, lookup)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if got := instDeleteKeys(plan.Instances); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("instances = %v", got)
+	}
+	if got := asstIDs(plan.Cycles); !reflect.DeepEqual(got, []int64{3}) {
+		t.Errorf("cycles = %v", got)
+	}
+}
+
+func TestPlanInstDeleteRestrict(t *testing.T) {
+	lookup := newMockInstDeleteLookup()
+	lookup.instAssts = []InstAsst{
+		{ID: 1, ObjectAsstID: "d_connect_b", ObjectID: "d", InstID: 1, AsstObjectID: "b", AsstInstID: 1},
+		{ID: 2, ObjectAsstID: "b_run_c", ObjectID: "b", InstID: 1, AsstObjectID: "c", AsstInstID: 1},
+		{ID: 3, ObjectAsstID: "c_belong_host", ObjectID: "c", InstID: 1, AsstObjectID: "host", AsstInstID: 1},
+	}
+
+	plan, err := PlanInstDelete([]InstDeleteNode{{ObjectID: "b", InstID: 1}}, lookup)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if got := instDeleteKeys(plan.Instances); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("instances = %v", got)
+	}
+	if got := asstIDs(plan.Restricted); !reflect.DeepEqual(got, []int64{1, 3}) {
+		t.Errorf("restricted = %v", got)
+	}
+
+	// the source instance is deleted too, so the association doesn't restrict any more.
+	plan, err = PlanInstDelete([]InstDeleteNode{{ObjectID: "b", InstID: 1}, {ObjectID: "d", InstID: 1}}, lookup)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if got := asstIDs(plan.Restricted); !reflect.DeepEqual(got, []int64{3}) {
+		t.Errorf("restricted = %v", got)
+	}
+}
+
+func TestPlanInstDeleteMainline(t *testing.T) {
+	lookup := newMockInstDeleteLookup()
+	lookup.instAssts = []InstAsst{
+		{ID: 1, ObjectAsstID: "b_run_c", ObjectID: "b", InstID: 1, AsstObjectID: "c", AsstInstID: 1},
+		{ID: 2, ObjectAsstID: "c_belong_city", ObjectID: "c", InstID: 1, AsstObjectID: "city", AsstInstID: 1},
+	}
+
+	// the custom mainline object's instance is not deleted by cascade, it restricts the deletion instead.
+	plan, err := PlanInstDelete([]InstDeleteNode{{ObjectID: "b", InstID: 1}}, lookup)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if got := instDeleteKeys(plan.Instances); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("instances = %v", got)
+	}
+	if got := asstIDs(plan.Restricted); !reflect.DeepEqual(got, []int64{2}) {
+		t.Errorf("restricted = %v", got)
+	}
+}
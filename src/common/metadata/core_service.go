@@ -84,6 +84,18 @@ type CreateManyModelInstance struct {
 	Datas []mapstr.MapStr `json:"datas"`
 }
 
+// UpdateManyModelInstance update many instances in one request, the updates are applied independently
+type UpdateManyModelInstance struct {
+	Updates []UpdateOption `json:"updates"`
+}
+
+// UpsertModelInstance update the instances matched by the condition with the data, or create
+// the instance with the data and the equal conditions if none matches.
+type UpsertModelInstance struct {
+	Condition mapstr.MapStr `json:"condition"`
+	Data      mapstr.MapStr `json:"data"`
+}
+
 type SetModelInstance CreateModelInstance
 type SetManyModelInstance CreateManyModelInstance
 
@@ -73,3 +73,60 @@ type SearchNetPropertyResult struct {
 type DeleteNetPropertyBatchOpt struct {
 	NetcollectPropertyIDs []uint64 `json:"netcollect_property_id"`
 }
+
+// CollectMapping define how to map the fields of the collected messages to the
+// attributes of a model, so that a new report format can be ingested without
+// writing an analyzer. the mappings are stored in cc_CollectMapping.
+type CollectMapping struct {
+	Name     string `bson:"mapping_name" json:"mapping_name"`
+	OwnerID  string `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	ObjectID string `bson:"bk_obj_id" json:"bk_obj_id"`
+	// Root the path of the items in the message, the whole message is an item if it's empty,
+	// the items are collected from all the elements if the path points to an array.
+	Root string `bson:"root" json:"root"`
+	// Keys the attributes to find the instance of an item, they must be in the fields
+	Keys   []string              `bson:"keys" json:"keys"`
+	Fields []CollectFieldMapping `bson:"fields" json:"fields"`
+	// Create create the instance if it's not found by the keys
+	Create bool `bson:"create" json:"create"`
+}
+
+// CollectFieldMapping map the value at the path of an item to the attribute
+type CollectFieldMapping struct {
+	PropertyID string `bson:"bk_property_id" json:"bk_property_id"`
+	// Path the JSONPath of the value, such as $.data.system.hostname, or
+	// $.data.cpu.cpuinfo[0].modelName, relative to the item
+	Path string `bson:"path" json:"path"`
+}
+
+const (
+	// HostSnapTransformFirst take the first value at the path, it's the default transform
+	HostSnapTransformFirst = "first"
+	// HostSnapTransformSum take the sum of the numbers at the path
+	HostSnapTransformSum = "sum"
+	// HostSnapTransformCount take the count of the values at the path
+	HostSnapTransformCount = "count"
+	// HostSnapTransformJoin join the values at the path with comma
+	HostSnapTransformJoin = "join"
+)
+
+// HostSnapMapping map a value of the host snapshot reported by the agent to a host
+// attribute of an owner, the mappings are stored in cc_HostSnapMapping and applied
+// after the built-in fields, so they can fill the custom attributes, such as the
+// gpu count from $.data.gpu[*].id with count, or override a built-in one.
+type HostSnapMapping struct {
+	OwnerID    string `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	PropertyID string `bson:"bk_property_id" json:"bk_property_id"`
+	// Path the JSONPath of the value in the snapshot, such as $.data.system.info.kernelVersion
+	Path string `bson:"path" json:"path"`
+	// Transform how to reduce the values at the path, first, sum, count or join
+	Transform string `bson:"transform" json:"transform"`
+	// Divisor the numeric value is divided by it when it's positive, for unit
+	// conversion, such as 1073741824 for bytes to GB
+	Divisor float64 `bson:"divisor" json:"divisor"`
+}
+
+// DeleteHostSnapMappingOption the properties whose host snapshot mappings are deleted
+type DeleteHostSnapMappingOption struct {
+	PropertyIDs []string `json:"bk_property_ids"`
+}
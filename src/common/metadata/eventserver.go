@@ -16,9 +16,15 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"reflect"
 	"sort"
 	"strings"
 	"time"
+
+	mgobson "gopkg.in/mgo.v2/bson"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/universalsql/mongo"
 )
 
 type RspSubscriptionCreate struct {
@@ -54,18 +60,22 @@ type RspSubscriptionTestCallback struct {
 
 // Subscription define
 type Subscription struct {
-	SubscriptionID   int64       `bson:"subscription_id" json:"subscription_id"`
-	SubscriptionName string      `bson:"subscription_name" json:"subscription_name"`
-	SystemName       string      `bson:"system_name" json:"system_name"`
-	CallbackURL      string      `bson:"callback_url" json:"callback_url"`
-	ConfirmMode      string      `bson:"confirm_mode" json:"confirm_mode"`
-	ConfirmPattern   string      `bson:"confirm_pattern" json:"confirm_pattern"`
-	TimeOut          int64       `bson:"time_out" json:"time_out"`                   // second
-	SubscriptionForm string      `bson:"subscription_form" json:"subscription_form"` // json format
-	Operator         string      `bson:"operator" json:"operator"`
-	OwnerID          string      `bson:"bk_supplier_account" json:"bk_supplier_account"`
-	LastTime         Time        `bson:"last_time" json:"last_time"`
-	Statistics       *Statistics `bson:"-" json:"statistics"`
+	SubscriptionID   int64        `bson:"subscription_id" json:"subscription_id"`
+	SubscriptionName string       `bson:"subscription_name" json:"subscription_name"`
+	SystemName       string       `bson:"system_name" json:"system_name"`
+	CallbackURL      string       `bson:"callback_url" json:"callback_url"`
+	ConfirmMode      string       `bson:"confirm_mode" json:"confirm_mode"`
+	ConfirmPattern   string       `bson:"confirm_pattern" json:"confirm_pattern"`
+	Secret           string       `bson:"secret" json:"secret,omitempty"`             // HMAC key to sign the callbacks, not signed if empty
+	Filter           *EventFilter `bson:"filter" json:"filter,omitempty"`             // only the matched events are sent if set
+	PayloadFormat    string       `bson:"payload_format" json:"payload_format"`       // the format of the callback body, raw if empty
+	PayloadTemplate  string       `bson:"payload_template" json:"payload_template"`   // go text/template of the callback body, used by the template format
+	TimeOut          int64        `bson:"time_out" json:"time_out"`                   // second
+	SubscriptionForm string       `bson:"subscription_form" json:"subscription_form"` // json format
+	Operator         string       `bson:"operator" json:"operator"`
+	OwnerID          string       `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	LastTime         Time         `bson:"last_time" json:"last_time"`
+	Statistics       *Statistics  `bson:"-" json:"statistics"`
 }
 
 // Report define sending statistic
@@ -78,6 +88,87 @@ func (Subscription) TableName() string {
 	return "cc_Subscription"
 }
 
+// EventFilter define the filter of the subscription, an event matches the
+// filter if any of its data matches all the set predicates. the conditions
+// are in the format of universalsql, such as {"bk_cloud_id": {"$in": [0, 1]}}.
+type EventFilter struct {
+	// CurData the condition of the data after the event
+	CurData mapstr.MapStr `json:"cur_data,omitempty"`
+	// PreData the condition of the data before the event
+	PreData mapstr.MapStr `json:"pre_data,omitempty"`
+	// ChangedFields any of the fields is changed by the event
+	ChangedFields []string `json:"changed_fields,omitempty"`
+}
+
+// GetBSON implements bson.GetBSON interface, the filter is saved as json string,
+// because mongodb does not allow the field name starting with $
+func (f *EventFilter) GetBSON() (interface{}, error) {
+	if f == nil {
+		return nil, nil
+	}
+	out, err := json.Marshal(f)
+	return string(out), err
+}
+
+// SetBSON implements bson.SetBSON interface
+func (f *EventFilter) SetBSON(raw mgobson.Raw) error {
+	if raw.Kind == 0x0A {
+		// 0x0A null
+		return mgobson.SetZero
+	}
+	var out string
+	if err := raw.Unmarshal(&out); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(out), f)
+}
+
+// Validate check whether the conditions of the filter are supported
+func (f *EventFilter) Validate() error {
+	for _, cond := range []mapstr.MapStr{f.CurData, f.PreData} {
+		if _, err := mongo.Match(cond, mapstr.MapStr{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match check whether the event matches the filter
+func (f *EventFilter) Match(event *EventInst) (bool, error) {
+	for _, data := range event.Data {
+		matched, err := f.matchData(data)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+func (f *EventFilter) matchData(data EventData) (bool, error) {
+	curData, _ := data.CurData.(map[string]interface{})
+	preData, _ := data.PreData.(map[string]interface{})
+
+	if len(f.CurData) > 0 {
+		if matched, err := mongo.Match(f.CurData, curData); err != nil || !matched {
+			return false, err
+		}
+	}
+	if len(f.PreData) > 0 {
+		if matched, err := mongo.Match(f.PreData, preData); err != nil || !matched {
+			return false, err
+		}
+	}
+	if len(f.ChangedFields) == 0 {
+		return true, nil
+	}
+	for _, field := range f.ChangedFields {
+		if !reflect.DeepEqual(curData[field], preData[field]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (s Subscription) GetCacheKey() string {
 	eventnames := strings.Split(s.SubscriptionForm, ",")
 	sort.Strings(eventnames)
@@ -87,6 +178,9 @@ func (s Subscription) GetCacheKey() string {
 		CallbackURL:      s.CallbackURL,
 		ConfirmMode:      s.ConfirmMode,
 		ConfirmPattern:   s.ConfirmPattern,
+		Secret:           s.Secret,
+		PayloadFormat:    s.PayloadFormat,
+		PayloadTemplate:  s.PayloadTemplate,
 		SubscriptionForm: s.SubscriptionForm,
 		TimeOut:          s.TimeOut,
 	}
@@ -146,6 +240,61 @@ type DistInstCtx struct {
 	Raw string
 }
 
+// DistRetry the distribution waiting to be sent again after callback failed
+type DistRetry struct {
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error"`
+	Raw       string `json:"raw"`
+}
+
+// EventDeadLetter the distribution which still failed after all the retries
+type EventDeadLetter struct {
+	ID             int64  `bson:"dead_letter_id" json:"dead_letter_id"`
+	SubscriptionID int64  `bson:"subscription_id" json:"subscription_id"`
+	OwnerID        string `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	DstbID         int64  `bson:"distribution_id" json:"distribution_id"`
+	EventType      string `bson:"event_type" json:"event_type"`
+	Action         string `bson:"action" json:"action"`
+	ObjType        string `bson:"obj_type" json:"obj_type"`
+	Attempts       int    `bson:"attempts" json:"attempts"`
+	LastError      string `bson:"last_error" json:"last_error"`
+	Event          string `bson:"event" json:"event"`
+	CreateTime     Time   `bson:"create_time" json:"create_time"`
+}
+
+type ParamDeadLetterSearch struct {
+	Page BasePage `json:"page"`
+}
+
+type RspDeadLetterSearch struct {
+	Count uint64            `json:"count"`
+	Info  []EventDeadLetter `json:"info"`
+}
+
+// ParamDeadLetterOperate select the dead letters to replay or purge,
+// all the dead letters of the subscription are selected if ids is empty
+type ParamDeadLetterOperate struct {
+	IDs []int64 `json:"dead_letter_ids"`
+}
+
+type RspDeadLetterOperate struct {
+	Count int `json:"count"`
+}
+
+// RspWatchEvent the events after the cursor, and the cursor to continue watching
+type RspWatchEvent struct {
+	Cursor int64       `json:"cursor"`
+	Events []EventInst `json:"events"`
+}
+
+// PayloadFormat the format of the callback body
+const (
+	PayloadFormatRaw         = "raw"
+	PayloadFormatCloudEvents = "cloudevents"
+	PayloadFormatDiff        = "diff"
+	PayloadFormatTemplate    = "template"
+)
+
 // EventAction
 const (
 	EventActionCreate = "create"
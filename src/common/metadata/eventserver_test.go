@@ -0,0 +1,91 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"encoding/json"
+	"testing"
+
+	mgobson "gopkg.in/mgo.v2/bson"
+)
+
+func TestEventFilterMatch(t *testing.T) {
+	filter := EventFilter{}
+	if err := json.Unmarshal([]byte(`{
+		"cur_data": {"bk_os_type": {"$in": ["1", "2"]}},
+		"changed_fields": ["bk_host_innerip", "bk_cloud_id"]
+	}`), &filter); err != nil {
+		t.Fatal(err)
+	}
+	if err := filter.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	event := EventInst{}
+	if err := json.Unmarshal([]byte(`{"data": [
+		{"pre_data": {"bk_host_innerip": "10.0.0.1", "bk_cloud_id": 0, "bk_os_type": "1"},
+		 "cur_data": {"bk_host_innerip": "10.0.0.1", "bk_cloud_id": 0, "bk_os_type": "1", "bk_comment": "changed"}}
+	]}`), &event); err != nil {
+		t.Fatal(err)
+	}
+	if matched, err := filter.Match(&event); err != nil || matched {
+		t.Errorf("event without the changed fields should not match, matched: %v, err: %v", matched, err)
+	}
+
+	event.Data[0].CurData.(map[string]interface{})["bk_cloud_id"] = float64(1)
+	if matched, err := filter.Match(&event); err != nil || !matched {
+		t.Errorf("event with the changed fields should match, matched: %v, err: %v", matched, err)
+	}
+
+	event.Data[0].CurData.(map[string]interface{})["bk_os_type"] = "3"
+	if matched, err := filter.Match(&event); err != nil || matched {
+		t.Errorf("event not matching cur data should not match, matched: %v, err: %v", matched, err)
+	}
+}
+
+func TestEventFilterBSON(t *testing.T) {
+	sub := Subscription{SubscriptionID: 1, Filter: &EventFilter{
+		CurData:       map[string]interface{}{"bk_cloud_id": map[string]interface{}{"$ne": 0}},
+		ChangedFields: []string{"bk_host_innerip"},
+	}}
+	out, err := mgobson.Marshal(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := Subscription{}
+	if err := mgobson.Unmarshal(out, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Filter == nil || len(result.Filter.ChangedFields) != 1 || result.Filter.CurData["bk_cloud_id"] == nil {
+		t.Errorf("unexpected filter %+v after bson round trip", result.Filter)
+	}
+
+	out, err = mgobson.Marshal(Subscription{SubscriptionID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = Subscription{}
+	if err := mgobson.Unmarshal(out, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Filter != nil {
+		t.Errorf("nil filter should be kept nil after bson round trip, but got %+v", result.Filter)
+	}
+}
+
+func TestEventFilterInvalid(t *testing.T) {
+	filter := EventFilter{CurData: map[string]interface{}{"$where": "1"}}
+	if err := filter.Validate(); err == nil {
+		t.Error("filter with unsupported operator should be invalid")
+	}
+}
@@ -0,0 +1,237 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+)
+
+// the actions recorded in the instance history
+const (
+	// InstHistoryActionBaseline the version recorded for the instances existing before the history is enabled
+	InstHistoryActionBaseline = "baseline"
+	InstHistoryActionCreate   = "create"
+	InstHistoryActionUpdate   = "update"
+	InstHistoryActionDelete   = "delete"
+	// InstHistoryActionRestore the instance is restored from the recycle bin
+	InstHistoryActionRestore = "restore"
+)
+
+// the actions recorded in the module host relation history
+const (
+	ModuleHostHistoryActionAdd    = "add"
+	ModuleHostHistoryActionDelete = "delete"
+)
+
+// InstHistory one version of an instance, the data is the whole instance after the change, or the last data of
+// the instance for the delete action. the version is allocated from a sequence shared by all the instances, the
+// instances changed together share one version, so the versions of an instance are increasing but not continuous.
+type InstHistory struct {
+	Version   int64         `json:"version" bson:"version"`
+	Action    string        `json:"action" bson:"action"`
+	ObjectID  string        `json:"bk_obj_id" bson:"bk_obj_id"`
+	InstID    int64         `json:"bk_inst_id" bson:"bk_inst_id"`
+	Data      mapstr.MapStr `json:"data" bson:"data"`
+	RequestID string        `json:"rid" bson:"rid"`
+	OwnerID   string        `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Operator  string        `json:"operator" bson:"operator"`
+	OpTime    time.Time     `json:"op_time" bson:"op_time"`
+}
+
+// NewInstHistory returns the version of the instance of the object recorded for the action
+func NewInstHistory(objID, action string, inst mapstr.MapStr) (InstHistory, error) {
+	instID, err := util.GetInt64ByInterface(inst[common.GetInstIDField(objID)])
+	if nil != err {
+		return InstHistory{}, err
+	}
+	data := make(mapstr.MapStr, len(inst))
+	for key, val := range inst {
+		if "_id" == key {
+			continue
+		}
+		data[key] = val
+	}
+	return InstHistory{
+		Action:   action,
+		ObjectID: objID,
+		InstID:   instID,
+		Data:     data,
+		OwnerID:  util.GetStrByInterface(inst[common.BKOwnerIDField]),
+	}, nil
+}
+
+// ModuleHostHistory a change of the module host relations, the relations changed together share one version
+type ModuleHostHistory struct {
+	Version   int64     `json:"version" bson:"version"`
+	Action    string    `json:"action" bson:"action"`
+	AppID     int64     `json:"bk_biz_id" bson:"bk_biz_id"`
+	SetID     int64     `json:"bk_set_id" bson:"bk_set_id"`
+	ModuleID  int64     `json:"bk_module_id" bson:"bk_module_id"`
+	HostID    int64     `json:"bk_host_id" bson:"bk_host_id"`
+	RequestID string    `json:"rid" bson:"rid"`
+	OwnerID   string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	OpTime    time.Time `json:"op_time" bson:"op_time"`
+}
+
+// NewModuleHostHistory returns the change of the module host relation recorded for the action
+func NewModuleHostHistory(action string, relation map[string]interface{}) (ModuleHostHistory, error) {
+	history := ModuleHostHistory{Action: action}
+	fields := []struct {
+		key string
+		val *int64
+	}{
+		{common.BKAppIDField, &history.AppID},
+		{common.BKSetIDField, &history.SetID},
+		{common.BKModuleIDField, &history.ModuleID},
+		{common.BKHostIDField, &history.HostID},
+	}
+	for _, field := range fields {
+		val, err := util.GetInt64ByInterface(relation[field.key])
+		if nil != err {
+			return ModuleHostHistory{}, err
+		}
+		*field.val = val
+	}
+	history.OwnerID = util.GetStrByInterface(relation[common.BKOwnerIDField])
+	return history, nil
+}
+
+// InstFieldDiff the values of a field in two versions of an instance, the value is nil if the field is absent
+type InstFieldDiff struct {
+	PropertyID string      `json:"bk_property_id"`
+	From       interface{} `json:"from"`
+	To         interface{} `json:"to"`
+}
+
+// DiffInstData returns the fields whose values differ between the two versions of an instance, sorted by the
+// property id. the last_time field is skipped, as it changes with every update.
+func DiffInstData(from, to mapstr.MapStr) []InstFieldDiff {
+	keys := make([]string, 0, len(from)+len(to))
+	for key := range from {
+		keys = append(keys, key)
+	}
+	for key := range to {
+		if _, exists := from[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	diffs := make([]InstFieldDiff, 0)
+	for _, key := range keys {
+		if "_id" == key || common.LastTimeField == key {
+			continue
+		}
+		if reflect.DeepEqual(from[key], to[key]) {
+			continue
+		}
+		diffs = append(diffs, InstFieldDiff{PropertyID: key, From: from[key], To: to[key]})
+	}
+	return diffs
+}
+
+// PrefixConditionField returns the condition whose fields are prefixed, so that the condition on an instance can
+// be applied to the instance embedded in another document. the operators like $or and $and are kept, and the
+// conditions in them are prefixed too.
+func PrefixConditionField(cond mapstr.MapStr, prefix string) mapstr.MapStr {
+	result := make(mapstr.MapStr, len(cond))
+	for key, val := range cond {
+		if !strings.HasPrefix(key, "$") {
+			result[prefix+key] = val
+			continue
+		}
+
+		items, ok := val.([]interface{})
+		if !ok {
+			if subConds, isMapArr := val.([]mapstr.MapStr); isMapArr {
+				items = make([]interface{}, 0, len(subConds))
+				for _, subCond := range subConds {
+					items = append(items, subCond)
+				}
+			}
+		}
+		if nil == items {
+			result[key] = val
+			continue
+		}
+		prefixed := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			subCond, err := mapstr.NewFromInterface(item)
+			if nil != err {
+				prefixed = append(prefixed, item)
+				continue
+			}
+			prefixed = append(prefixed, PrefixConditionField(subCond, prefix))
+		}
+		result[key] = prefixed
+	}
+	return result
+}
+
+// InstHistoryDiff the differences between two versions of an instance
+type InstHistoryDiff struct {
+	ObjectID    string          `json:"bk_obj_id"`
+	InstID      int64           `json:"bk_inst_id"`
+	FromVersion int64           `json:"from_version"`
+	ToVersion   int64           `json:"to_version"`
+	Diffs       []InstFieldDiff `json:"diffs"`
+}
+
+// AsOfQueryCondition the query condition on the instances as they were at a point of time
+type AsOfQueryCondition struct {
+	QueryCondition `json:",inline"`
+	AsOf           Time `json:"as_of"`
+}
+
+// PageSortToSearchSort converts the page sort, which is in the form of "-field1,field2", into the search sort
+func PageSortToSearchSort(sort string) []SearchSort {
+	sorts := make([]SearchSort, 0)
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if "" == field {
+			continue
+		}
+		if strings.HasPrefix(field, "-") {
+			sorts = append(sorts, SearchSort{Field: strings.TrimPrefix(field, "-"), IsDsc: true})
+			continue
+		}
+		sorts = append(sorts, SearchSort{Field: field})
+	}
+	return sorts
+}
+
+// ModuleHostAsOfCondition the condition on the module host relations as they were at a point of time,
+// the condition is in the same form as searching the current module host relations.
+type ModuleHostAsOfCondition struct {
+	Condition map[string][]int64 `json:"condition"`
+	AsOf      Time               `json:"as_of"`
+}
+
+// InstHistoryQueryResult the versions of an instance
+type InstHistoryQueryResult struct {
+	Count uint64        `json:"count"`
+	Info  []InstHistory `json:"info"`
+}
+
+// SearchInstHistoryResult the result of searching the versions of an instance
+type SearchInstHistoryResult struct {
+	BaseResp `json:",inline"`
+	Data     InstHistoryQueryResult `json:"data"`
+}
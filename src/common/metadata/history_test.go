@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+func TestNewInstHistory(t *testing.T) {
+	inst := mapstr.MapStr{"_id": "5c8a", common.BKHostIDField: int64(7), common.BKHostInnerIPField: "10.0.0.1", common.BKOwnerIDField: "0"}
+	history, err := NewInstHistory(common.BKInnerObjIDHost, InstHistoryActionUpdate, inst)
+	if nil != err {
+		t.Fatalf("new the history of the host failed, err: %v", err)
+	}
+	if history.ObjectID != common.BKInnerObjIDHost || history.InstID != 7 || history.Action != InstHistoryActionUpdate ||
+		history.OwnerID != "0" {
+		t.Errorf("unexpected history %#v", history)
+	}
+	if history.Data.Exists("_id") || !inst.Exists("_id") {
+		t.Errorf("the _id should be removed from the history only, got %#v", history.Data)
+	}
+
+	// the id allocated by the sequence is an uint64 before the instance is read back from the db
+	if history, err := NewInstHistory("switch", InstHistoryActionCreate, mapstr.MapStr{common.BKInstIDField: uint64(3)}); nil != err || 3 != history.InstID {
+		t.Errorf("the history of the new instance should be recorded, got %#v, err: %v", history, err)
+	}
+	if _, err := NewInstHistory("switch", InstHistoryActionCreate, mapstr.MapStr{common.BKHostIDField: 1}); nil == err {
+		t.Errorf("the instance without bk_inst_id should not be recorded")
+	}
+}
+
+func TestNewModuleHostHistory(t *testing.T) {
+	relation := map[string]interface{}{
+		common.BKAppIDField:    2,
+		common.BKSetIDField:    int64(3),
+		common.BKModuleIDField: float64(4),
+		common.BKHostIDField:   5,
+		common.BKOwnerIDField:  "0",
+	}
+	history, err := NewModuleHostHistory(ModuleHostHistoryActionAdd, relation)
+	if nil != err {
+		t.Fatalf("new the history of the relation failed, err: %v", err)
+	}
+	if history.AppID != 2 || history.SetID != 3 || history.ModuleID != 4 || history.HostID != 5 || history.OwnerID != "0" {
+		t.Errorf("unexpected history %#v", history)
+	}
+
+	delete(relation, common.BKModuleIDField)
+	if _, err := NewModuleHostHistory(ModuleHostHistoryActionDelete, relation); nil == err {
+		t.Errorf("the relation without bk_module_id should not be recorded")
+	}
+}
+
+func TestDiffInstData(t *testing.T) {
+	from := mapstr.MapStr{
+		common.BKHostIDField:      1,
+		common.BKHostInnerIPField: "10.0.0.1",
+		"bk_os_name":              "linux",
+		"bk_comment":              "old",
+		common.LastTimeField:      time.Unix(1, 0),
+	}
+	to := mapstr.MapStr{
+		common.BKHostIDField:      1,
+		common.BKHostInnerIPField: "10.0.0.2",
+		"bk_os_name":              "linux",
+		"bk_cpu":                  8,
+		common.LastTimeField:      time.Unix(2, 0),
+	}
+	want := []InstFieldDiff{
+		{PropertyID: "bk_comment", From: "old", To: nil},
+		{PropertyID: "bk_cpu", From: nil, To: 8},
+		{PropertyID: common.BKHostInnerIPField, From: "10.0.0.1", To: "10.0.0.2"},
+	}
+	if got := DiffInstData(from, to); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffInstData() = %#v, want %#v", got, want)
+	}
+	if got := DiffInstData(from, from); 0 != len(got) {
+		t.Errorf("the same versions should not differ, got %#v", got)
+	}
+}
+
+func TestPrefixConditionField(t *testing.T) {
+	cond := mapstr.MapStr{
+		common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: []string{"10.0.0.1"}},
+		common.BKDBOR: []interface{}{
+			map[string]interface{}{"bk_os_name": "linux"},
+			mapstr.MapStr{common.BKDBAND: []mapstr.MapStr{{"bk_cpu": 8}}},
+		},
+	}
+	want := mapstr.MapStr{
+		"doc.data." + common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: []string{"10.0.0.1"}},
+		common.BKDBOR: []interface{}{
+			mapstr.MapStr{"doc.data.bk_os_name": "linux"},
+			mapstr.MapStr{common.BKDBAND: []interface{}{mapstr.MapStr{"doc.data.bk_cpu": 8}}},
+		},
+	}
+	if got := PrefixConditionField(cond, "doc.data."); !reflect.DeepEqual(got, want) {
+		t.Errorf("PrefixConditionField() = %#v, want %#v", got, want)
+	}
+}
+
+func TestPageSortToSearchSort(t *testing.T) {
+	want := []SearchSort{
+		{Field: common.BKHostInnerIPField, IsDsc: true},
+		{Field: common.BKHostIDField},
+	}
+	if got := PageSortToSearchSort("-bk_host_innerip, bk_host_id"); !reflect.DeepEqual(got, want) {
+		t.Errorf("PageSortToSearchSort() = %#v, want %#v", got, want)
+	}
+	if got := PageSortToSearchSort(""); 0 != len(got) {
+		t.Errorf("the empty sort should not be parsed, got %#v", got)
+	}
+}
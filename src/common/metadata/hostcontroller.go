@@ -229,6 +229,7 @@ type CloudTaskInfo struct {
 	NewAdd          int64  `json:"new_add" bson:"new_add"`
 	AttrChanged     int64  `json:"attr_changed" bson:"attr_changed"`
 	OwnerID         string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CloudVendor     string `json:"bk_cloud_vendor" bson:"bk_cloud_vendor"`
 }
 
 // TransferHostToDefaultModuleConfig transfer host to default module
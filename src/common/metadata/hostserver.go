@@ -94,6 +94,8 @@ type HostCommonSearch struct {
 	Condition []SearchCondition `json:"condition"`
 	Page      BasePage          `json:"page"`
 	Pattern   string            `json:"pattern,omitempty"`
+	// AsOf search the hosts and their topology as they were at the point of time
+	AsOf *Time `json:"as_of,omitempty"`
 }
 
 type HostModuleFind struct {
@@ -161,6 +163,7 @@ type CloudTaskList struct {
 	AttrConfirm     bool   `json:"bk_attr_confirm"`
 	SecretID        string `json:"bk_secret_id"`
 	SecretKey       string `json:"bk_secret_key"`
+	CloudVendor     string `json:"bk_cloud_vendor"`
 }
 
 type ResourceConfirm struct {
@@ -185,6 +188,7 @@ type CloudHistory struct {
 	TaskID      int64  `json:"bk_task_id"`
 	HistoryID   int64  `json:"bk_history_id"`
 	FailReason  string `json:"fail_reason"`
+	CloudVendor string `json:"bk_cloud_vendor"`
 }
 
 type DeleteCloudTask struct {
@@ -27,6 +27,7 @@ type SetInst struct {
 type ModuleInst struct {
 	BizID      int64  `bson:"bk_biz_id"`
 	ModuleID   int64  `bson:"bk_module_id"`
+	SetID      int64  `bson:"bk_set_id"`
 	ModuleName string `bson:"bk_module_name"`
 }
 type BizInst struct {
@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+)
+
+// the kinds of the archives in the recycle bin
+const (
+	// DelArchiveKindInstance the archive of a deleted instance, set, module or host
+	DelArchiveKindInstance = "instance"
+	// DelArchiveKindInstAsst the archive of a deleted instance association
+	DelArchiveKindInstAsst = "inst_asst"
+)
+
+// DelArchive the archive of a deleted instance or instance association in the recycle bin, it is removed by
+// the ttl index of expire_at. the archives written by one request share the rid, so that the instance associations
+// deleted with an instance can be restored with it.
+type DelArchive struct {
+	ID       int64  `json:"id" bson:"id"`
+	Kind     string `json:"kind" bson:"kind"`
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id" bson:"bk_inst_id"`
+	// AsstObjectID and AsstInstID the destination of the instance association, empty for the instance
+	AsstObjectID string `json:"bk_asst_obj_id,omitempty" bson:"bk_asst_obj_id,omitempty"`
+	AsstInstID   int64  `json:"bk_asst_inst_id,omitempty" bson:"bk_asst_inst_id,omitempty"`
+	// Detail the deleted instance
+	Detail mapstr.MapStr `json:"detail,omitempty" bson:"detail,omitempty"`
+	// InstAsst the deleted instance association
+	InstAsst   *InstAsst `json:"inst_asst,omitempty" bson:"inst_asst,omitempty"`
+	RequestID  string    `json:"rid" bson:"rid"`
+	OwnerID    string    `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Operator   string    `json:"operator" bson:"operator"`
+	DeleteTime time.Time `json:"delete_time" bson:"delete_time"`
+	ExpireAt   time.Time `json:"expire_at" bson:"expire_at"`
+}
+
+// NewInstDelArchive returns the archive of the deleted instance of the object
+func NewInstDelArchive(objID string, inst mapstr.MapStr) (DelArchive, error) {
+	instID, err := util.GetInt64ByInterface(inst[common.GetInstIDField(objID)])
+	if nil != err {
+		return DelArchive{}, err
+	}
+	return DelArchive{
+		Kind:     DelArchiveKindInstance,
+		ObjectID: objID,
+		InstID:   instID,
+		Detail:   inst,
+	}, nil
+}
+
+// NewInstAsstDelArchive returns the archive of the deleted instance association
+func NewInstAsstDelArchive(asst InstAsst) DelArchive {
+	return DelArchive{
+		Kind:         DelArchiveKindInstAsst,
+		ObjectID:     asst.ObjectID,
+		InstID:       asst.InstID,
+		AsstObjectID: asst.AsstObjectID,
+		AsstInstID:   asst.AsstInstID,
+		InstAsst:     &asst,
+	}
+}
+
+// DelArchiveQueryResult the archives in the recycle bin
+type DelArchiveQueryResult struct {
+	Count uint64       `json:"count"`
+	Info  []DelArchive `json:"info"`
+}
+
+// SearchDelArchiveResult the result of searching the archives
+type SearchDelArchiveResult struct {
+	BaseResp `json:",inline"`
+	Data     DelArchiveQueryResult `json:"data"`
+}
+
+// RestoredDelArchive the restored instance and the instance associations restored with it, the instance
+// associations whose other side instance is not restored yet are kept in the recycle bin.
+type RestoredDelArchive struct {
+	ObjectID     string     `json:"bk_obj_id"`
+	InstID       int64      `json:"bk_inst_id"`
+	Associations []InstAsst `json:"associations"`
+}
+
+// RestoreDelArchiveResult the result of restoring an archive
+type RestoreDelArchiveResult struct {
+	BaseResp `json:",inline"`
+	Data     RestoredDelArchive `json:"data"`
+}
@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+func TestNewInstDelArchive(t *testing.T) {
+	tests := []struct {
+		objID string
+		inst  mapstr.MapStr
+		want  int64
+	}{
+		{common.BKInnerObjIDSet, mapstr.MapStr{common.BKSetIDField: 3, common.BKInstIDField: 5}, 3},
+		{common.BKInnerObjIDHost, mapstr.MapStr{common.BKHostIDField: int64(4)}, 4},
+		{"switch", mapstr.MapStr{common.BKInstIDField: float64(6)}, 6},
+	}
+	for _, tt := range tests {
+		archive, err := NewInstDelArchive(tt.objID, tt.inst)
+		if nil != err {
+			t.Fatalf("new the archive of %s failed, err: %v", tt.objID, err)
+		}
+		if archive.Kind != DelArchiveKindInstance || archive.ObjectID != tt.objID || archive.InstID != tt.want {
+			t.Errorf("the archive of %s is %#v, want instance %d", tt.objID, archive, tt.want)
+		}
+	}
+
+	if _, err := NewInstDelArchive(common.BKInnerObjIDModule, mapstr.MapStr{common.BKInstIDField: 1}); nil == err {
+		t.Errorf("the module without bk_module_id should not be archived")
+	}
+}
+
+func TestNewInstAsstDelArchive(t *testing.T) {
+	asst := InstAsst{ID: 1, ObjectID: "server", InstID: 2, AsstObjectID: "rack", AsstInstID: 3, ObjectAsstID: "server_belong_rack"}
+	archive := NewInstAsstDelArchive(asst)
+	if archive.Kind != DelArchiveKindInstAsst || archive.ObjectID != "server" || archive.InstID != 2 ||
+		archive.AsstObjectID != "rack" || archive.AsstInstID != 3 {
+		t.Errorf("unexpected archive %#v", archive)
+	}
+	if nil == archive.InstAsst || archive.InstAsst.ID != asst.ID || archive.InstAsst.ObjectAsstID != asst.ObjectAsstID {
+		t.Errorf("the archive should keep the instance association, got %#v", archive.InstAsst)
+	}
+}
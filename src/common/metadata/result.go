@@ -84,6 +84,13 @@ type CreateManyInfoResult struct {
 	Exceptions []ExceptionResult    `json:"exception"`
 }
 
+// UpdateManyDataResult the data struct definition in update many function result
+type UpdateManyDataResult struct {
+	UpdatedCount `json:",inline"`
+	Updated      []UpdatedDataResult `json:"updated"`
+	Exceptions   []ExceptionResult   `json:"exception"`
+}
+
 // CreateManyDataResult the data struct definition in create many function result
 type CreateManyDataResult struct {
 	CreateManyInfoResult `json:",inline"`
@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+// SlowQuery the stats of a slow query shape recorded by a process, the shape is the query
+// with the filter values erased, so that the queries differing only in values are counted together
+type SlowQuery struct {
+	ShapeID     string    `json:"shape_id" bson:"shape_id"`
+	Process     string    `json:"process" bson:"process"`
+	Table       string    `json:"table" bson:"table"`
+	Operation   string    `json:"operation" bson:"operation"`
+	Filter      string    `json:"filter" bson:"filter"`
+	Sort        string    `json:"sort" bson:"sort"`
+	EqualFields []string  `json:"equal_fields" bson:"equal_fields"`
+	RangeFields []string  `json:"range_fields" bson:"range_fields"`
+	Count       int64     `json:"count" bson:"count"`
+	TotalMillis int64     `json:"total_ms" bson:"total_ms"`
+	MaxMillis   int64     `json:"max_ms" bson:"max_ms"`
+	LastCaller  string    `json:"last_caller" bson:"last_caller"`
+	LastRid     string    `json:"last_rid" bson:"last_rid"`
+	LastTime    time.Time `json:"last_time" bson:"last_time"`
+}
+
+// SlowQueryShape the stats of a slow query shape of all the processes
+type SlowQueryShape struct {
+	SlowQuery `json:",inline" bson:",inline"`
+	AvgMillis int64 `json:"avg_ms"`
+	// SuggestedIndex the keys of the suggested index in order, empty if the query may be served by an existing index
+	SuggestedIndex []string `json:"suggested_index"`
+}
+
+// SlowQueryResult the slowest query shapes
+type SlowQueryResult struct {
+	BaseResp `json:",inline"`
+	Data     []SlowQueryShape `json:"data"`
+}
+
+// ExplainQueryOption the query to explain
+type ExplainQueryOption struct {
+	Table  string                 `json:"table"`
+	Filter map[string]interface{} `json:"filter"`
+	Sort   string                 `json:"sort"`
+}
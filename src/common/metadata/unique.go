@@ -13,6 +13,7 @@
 package metadata
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -48,6 +49,59 @@ const (
 	UniqueKeyKindAssociation = "association"
 )
 
+// HasAssociationKey returns whether the unique contains association keys, the id of an association key
+// is the id of the model association, and the value of it is the ids of the instances associated by it.
+func (u ObjectUnique) HasAssociationKey() bool {
+	for _, key := range u.Keys {
+		if key.Kind == UniqueKeyKindAssociation {
+			return true
+		}
+	}
+	return false
+}
+
+// SameInstIDs returns whether the two groups of instance ids are the same, the order and duplication are ignored.
+func SameInstIDs(a, b []int64) bool {
+	set := make(map[int64]bool, len(a))
+	for _, id := range a {
+		set[id] = true
+	}
+	other := make(map[int64]bool, len(b))
+	for _, id := range b {
+		if !set[id] {
+			return false
+		}
+		other[id] = true
+	}
+	return len(set) == len(other)
+}
+
+// UniqueValueHash returns the hash of the unique value of an instance, which is made of the values of the property
+// keys and the ids of the instances associated by the association keys, the instances with the same hash conflict.
+// the values are json encoded, so that the values of different types such as "1" and 1 are not the same, while
+// the numbers of different types such as int64(1) and float64(1) are.
+func UniqueValueHash(values []interface{}, associated [][]int64) string {
+	sortedIDs := make([][]int64, 0, len(associated))
+	for _, ids := range associated {
+		set := map[int64]bool{}
+		sorted := make([]int64, 0, len(ids))
+		for _, id := range ids {
+			if !set[id] {
+				set[id] = true
+				sorted = append(sorted, id)
+			}
+		}
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		sortedIDs = append(sortedIDs, sorted)
+	}
+	hash, err := json.Marshal([]interface{}{values, sortedIDs})
+	if err != nil {
+		// the values read from the db are always json encodable, the go syntax keeps the types either
+		return fmt.Sprintf("%#v%v", values, sortedIDs)
+	}
+	return string(hash)
+}
+
 type CreateUniqueRequest struct {
 	ObjID     string      `json:"bk_obj_id" bson:"bk_obj_id"`
 	MustCheck bool        `json:"must_check" bson:"must_check"`
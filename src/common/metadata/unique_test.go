@@ -0,0 +1,72 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"testing"
+)
+
+func TestObjectUniqueHasAssociationKey(t *testing.T) {
+	u := ObjectUnique{Keys: []UniqueKey{{Kind: UniqueKeyKindProperty, ID: 1}}}
+	if u.HasAssociationKey() {
+		t.Errorf("the unique has no association key")
+	}
+	u.Keys = append(u.Keys, UniqueKey{Kind: UniqueKeyKindAssociation, ID: 2})
+	if !u.HasAssociationKey() {
+		t.Errorf("the unique has an association key")
+	}
+}
+
+func TestSameInstIDs(t *testing.T) {
+	tests := []struct {
+		a, b []int64
+		want bool
+	}{
+		{nil, nil, true},
+		{[]int64{1, 2}, []int64{2, 1}, true},
+		{[]int64{1, 1, 2}, []int64{2, 1}, true},
+		{[]int64{1}, []int64{1, 2}, false},
+		{[]int64{1, 2}, []int64{1}, false},
+		{[]int64{1}, nil, false},
+	}
+	for _, tt := range tests {
+		if got := SameInstIDs(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameInstIDs(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestUniqueValueHash(t *testing.T) {
+	rack1 := UniqueValueHash([]interface{}{"u1"}, [][]int64{{3, 1}})
+	if rack1 != UniqueValueHash([]interface{}{"u1"}, [][]int64{{1, 3, 3}}) {
+		t.Errorf("the same values should have the same hash")
+	}
+	if rack1 == UniqueValueHash([]interface{}{"u1"}, [][]int64{{1}}) {
+		t.Errorf("different associated instances should have different hashes")
+	}
+	if rack1 == UniqueValueHash([]interface{}{"u2"}, [][]int64{{1, 3}}) {
+		t.Errorf("different property values should have different hashes")
+	}
+	if UniqueValueHash([]interface{}{"1"}, nil) == UniqueValueHash([]interface{}{1}, nil) {
+		t.Errorf("the values of different types should have different hashes")
+	}
+	if UniqueValueHash([]interface{}{int64(1)}, nil) != UniqueValueHash([]interface{}{float64(1)}, nil) {
+		t.Errorf("the same numbers of different types should have the same hash")
+	}
+	if UniqueValueHash([]interface{}{"a#b", "c"}, nil) == UniqueValueHash([]interface{}{"a", "b#c"}, nil) {
+		t.Errorf("the values containing the separator should have different hashes")
+	}
+	if UniqueValueHash([]interface{}{"[1]"}, nil) == UniqueValueHash(nil, [][]int64{{1}}) {
+		t.Errorf("the property values should not be taken as the associated instances")
+	}
+}
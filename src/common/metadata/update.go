@@ -24,3 +24,9 @@ type UpdatedOptionResult struct {
 	BaseResp `json:",inline"`
 	Data     UpdatedCount `json:"data"`
 }
+
+// UpdatedManyOptionResult update many api http response return this result struct
+type UpdatedManyOptionResult struct {
+	BaseResp `json:",inline"`
+	Data     UpdateManyDataResult `json:"data"`
+}
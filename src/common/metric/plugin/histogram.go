@@ -0,0 +1,97 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+
+	"configcenter/src/common/metric"
+)
+
+// NewHistogramMetric returns a histogram whose cumulative buckets are bounded by the upper bounds,
+// an implicit +Inf bucket is always added.
+func NewHistogramMetric(name, help string, upperBounds []float64) *HistogramMetric {
+	bounds := append([]float64{}, upperBounds...)
+	sort.Float64s(bounds)
+	return &HistogramMetric{
+		name:   name,
+		help:   help,
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// HistogramMetric counts the observed values in buckets, like the prometheus histogram
+type HistogramMetric struct {
+	name   string
+	help   string
+	bounds []float64
+
+	locker sync.RWMutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Observe add a value into the histogram
+func (h *HistogramMetric) Observe(v float64) {
+	idx := sort.SearchFloat64s(h.bounds, v)
+	h.locker.Lock()
+	defer h.locker.Unlock()
+	h.counts[idx]++
+	h.sum += v
+	h.count++
+}
+
+// Metrics returns the metrics of the histogram, which are the cumulative buckets named
+// name_bucket{le="bound"}, and name_sum, name_count.
+func (h *HistogramMetric) Metrics() []metric.MetricInterf {
+	h.locker.RLock()
+	defer h.locker.RUnlock()
+
+	metrics := make([]metric.MetricInterf, 0, len(h.counts)+2)
+	var cumulative uint64
+	for idx, count := range h.counts {
+		cumulative += count
+		bound := math.Inf(1)
+		if idx < len(h.bounds) {
+			bound = h.bounds[idx]
+		}
+		metrics = append(metrics, &valueMetric{
+			name:  fmt.Sprintf(`%s_bucket{le="%s"}`, h.name, strconv.FormatFloat(bound, 'g', -1, 64)),
+			help:  h.help,
+			value: float64(cumulative),
+		})
+	}
+	metrics = append(metrics,
+		&valueMetric{name: h.name + "_sum", help: h.help, value: h.sum},
+		&valueMetric{name: h.name + "_count", help: h.help, value: float64(h.count)},
+	)
+	return metrics
+}
+
+var _ metric.MetricInterf = &valueMetric{}
+
+// valueMetric a snapshot value of a metric
+type valueMetric struct {
+	name  string
+	help  string
+	value float64
+}
+
+func (v *valueMetric) GetMeta() *metric.MetricMeta {
+	return &metric.MetricMeta{
+		Name: v.name,
+		Help: v.help,
+	}
+}
+
+func (v *valueMetric) GetValue() (*metric.FloatOrString, error) {
+	return metric.FormFloatOrString(v.value)
+}
+
+func (v *valueMetric) GetExtension() (*metric.MetricExtension, error) {
+	return nil, nil
+}
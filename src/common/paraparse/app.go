@@ -28,6 +28,8 @@ type SearchParams struct {
 	Page      map[string]interface{} `json:"page,omitempty"`
 	Fields    []string               `json:"fields,omitempty"`
 	Native    int                    `json:"native,omitempty"`
+	// AsOf search the instances as they were at the point of time
+	AsOf *metadata.Time `json:"as_of,omitempty"`
 }
 
 //common result struct
@@ -17,12 +17,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/trace"
 	"configcenter/src/common/util"
 
 	restful "github.com/emicklei/go-restful"
@@ -45,6 +47,8 @@ func checkHTTPAuth(req *restful.Request, defErr errors.DefaultCCErrorIf) (int, s
 func AllGlobalFilter(errFunc func() errors.CCErrorIf) func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
 	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
 		generateHttpHeaderRID(req, resp)
+		span := startHTTPSpan(req)
+		defer finishHTTPSpan(span, resp)
 
 		whilteListSuffix := strings.Split(common.URLFilterWhiteListSuffix, common.URLFilterWhiteListSepareteChar)
 		for _, url := range whilteListSuffix {
@@ -78,6 +82,9 @@ func AllGlobalFilter(errFunc func() errors.CCErrorIf) func(req *restful.Request,
 func HTTPRequestIDFilter(errFunc func() errors.CCErrorIf) func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
 	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
 		generateHttpHeaderRID(req, resp)
+		span := startHTTPSpan(req)
+		defer finishHTTPSpan(span, resp)
+
 		if 1 < len(fchain.Filters) {
 			fchain.ProcessFilter(req, resp)
 			return
@@ -119,6 +126,33 @@ func generateHttpHeaderRID(req *restful.Request, resp *restful.Response) {
 	resp.Header().Set(common.BKHTTPCCRequestID, cid)
 }
 
+// startHTTPSpan start a server span of the request, the span replaces the traceparent
+// in the request header, so that the calls forwarding the header are its children.
+func startHTTPSpan(req *restful.Request) *trace.Span {
+	span := trace.StartSpan(req.Request.Method+" "+req.Request.URL.Path, trace.SpanKindServer, trace.Extract(req.Request.Header))
+	if span == nil {
+		return nil
+	}
+	span.SetTag("http.method", req.Request.Method)
+	span.SetTag("http.url", req.Request.URL.String())
+	span.SetTag("rid", util.GetHTTPCCRequestID(req.Request.Header))
+	trace.Inject(req.Request.Header, span.Context())
+	req.Request = req.Request.WithContext(trace.ContextWithSpan(req.Request.Context(), span))
+	return span
+}
+
+func finishHTTPSpan(span *trace.Span, resp *restful.Response) {
+	if span == nil {
+		return
+	}
+	status := resp.StatusCode()
+	span.SetTag("http.status_code", strconv.Itoa(status))
+	if status >= http.StatusInternalServerError {
+		span.SetError(fmt.Errorf("http status %d", status))
+	}
+	span.Finish()
+}
+
 func ServiceErrorHandler(err restful.ServiceError, req *restful.Request, resp *restful.Response) {
 	blog.Errorf("HTTP ERROR: %v, HTTP MESSAGE: %v, RequestURI: %s %s", err.Code, err.Message, req.Request.Method, req.Request.RequestURI)
 	ret := metadata.BaseResp{
@@ -59,6 +59,21 @@ func ClientTLSConfVerity(caFile, certFile, keyFile, passwd string) (*tls.Config,
 	return conf, nil
 }
 
+// ClientTLSConfCertificate returns the client tls config which sends the client
+// certificate, and verifies the server with the system root CAs.
+func ClientTLSConfCertificate(certFile, keyFile, passwd string) (*tls.Config, error) {
+	cert, err := loadCertificates(certFile, keyFile, passwd)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &tls.Config{
+		Certificates: []tls.Certificate{*cert},
+	}
+
+	return conf, nil
+}
+
 func ServerTslConf(caFile, certFile, keyFile, passwd string) (*tls.Config, error) {
 	if "" == caFile {
 		return ServerTslConfVerity(certFile, keyFile, passwd)
@@ -73,6 +73,9 @@ const (
 	BKTableNameHostFavorite     = "cc_HostFavourite"
 	BKTableNameOperationLog     = "cc_OperationLog"
 	BKTableNameSubscription     = "cc_Subscription"
+	BKTableNameEventDeadLetter  = "cc_EventDeadLetter"
+	BKTableNameCollectMapping   = "cc_CollectMapping"
+	BKTableNameHostSnapMapping  = "cc_HostSnapMapping"
 	BKTableNameUserAPI          = "cc_UserAPI"
 	BKTableNameUserCustom       = "cc_UserCustom"
 	BKTableNameObjAsst          = "cc_ObjAsst"
@@ -89,6 +92,17 @@ const (
 
 	BKTableNameHostLock = "cc_HostLock"
 
+	// BKTableNameSlowQuery the stats of the slow query shapes recorded by the dal instrumentation
+	BKTableNameSlowQuery = "cc_SlowQuery"
+
+	// BKTableNameDelArchive the recycle bin, the archives of the deleted instances and instance associations
+	BKTableNameDelArchive = "cc_DelArchive"
+
+	// BKTableNameInstHistory the versions of the instances, sets, modules and hosts
+	BKTableNameInstHistory = "cc_InstHistory"
+	// BKTableNameModuleHostHistory the changes of the module host relations
+	BKTableNameModuleHostHistory = "cc_ModuleHostHistory"
+
 	// Cloud sync tables
 	BKTableNameCloudTask              = "cc_CloudTask"
 	BKTableNameCloudSyncHistory       = "cc_CloudSyncHistory"
@@ -124,6 +138,9 @@ var AllTables = []string{
 	BKTableNameHostFavorite,
 	BKTableNameOperationLog,
 	BKTableNameSubscription,
+	BKTableNameEventDeadLetter,
+	BKTableNameCollectMapping,
+	BKTableNameHostSnapMapping,
 	BKTableNameUserAPI,
 	BKTableNameUserCustom,
 	BKTableNameObjAsst,
@@ -142,6 +159,186 @@ var AllTables = []string{
 	BKTableNameResourceConfirmHistory,
 	BKTableNameObjUnique,
 	BKTableNameAsstDes,
+	BKTableNameSlowQuery,
+	BKTableNameDelArchive,
+	BKTableNameInstHistory,
+	BKTableNameModuleHostHistory,
+}
+
+// IndexKey a key of the declared index, Direction is 1 for the ascending and -1 for the descending
+type IndexKey struct {
+	Key       string
+	Direction int32
+}
+
+// TableIndex the declared index of a table
+type TableIndex struct {
+	// Name the name of the index, the default name built by mongodb is used if empty
+	Name string
+	// Keys the keys of the index, the order of the compound index matters
+	Keys   []IndexKey
+	Unique bool
+	// ExpireAfterSeconds the ttl of the documents by the date of the key, 0 means never
+	ExpireAfterSeconds int32
+}
+
+// TableIndexes the indexes which should exist on the tables, the indexes of the unique
+// constraints of the models are derived from the cc_ObjectUnique table and not listed here
+var TableIndexes = map[string][]TableIndex{
+	BKTableNameBaseApp: {
+		{Keys: []IndexKey{{BKAppIDField, 1}}},
+		{Keys: []IndexKey{{BKAppNameField, 1}}},
+		{Keys: []IndexKey{{BKDefaultField, 1}}},
+	},
+	BKTableNameBaseHost: {
+		{Keys: []IndexKey{{BKHostIDField, 1}}},
+		{Keys: []IndexKey{{BKHostNameField, 1}}},
+		{Keys: []IndexKey{{BKHostInnerIPField, 1}}},
+		{Keys: []IndexKey{{BKHostOuterIPField, 1}}},
+	},
+	BKTableNameBaseModule: {
+		{Keys: []IndexKey{{BKModuleIDField, 1}}},
+		{Keys: []IndexKey{{BKModuleNameField, 1}}},
+		{Keys: []IndexKey{{BKDefaultField, 1}}},
+		{Keys: []IndexKey{{BKAppIDField, 1}}},
+		{Keys: []IndexKey{{BKOwnerIDField, 1}}},
+		{Keys: []IndexKey{{BKSetIDField, 1}}},
+		{Keys: []IndexKey{{BKInstParentStr, 1}}},
+	},
+	BKTableNameModuleHostConfig: {
+		{Keys: []IndexKey{{BKAppIDField, 1}}},
+		{Keys: []IndexKey{{BKHostIDField, 1}}},
+		{Keys: []IndexKey{{BKModuleIDField, 1}}},
+		{Keys: []IndexKey{{BKSetIDField, 1}}},
+	},
+	BKTableNameObjAsst: {
+		{Keys: []IndexKey{{BKObjIDField, 1}}},
+		{Keys: []IndexKey{{BKAsstObjIDField, 1}}},
+		{Keys: []IndexKey{{BKOwnerIDField, 1}}},
+	},
+	BKTableNameObjAttDes: {
+		{Keys: []IndexKey{{BKObjIDField, 1}}},
+		{Keys: []IndexKey{{BKOwnerIDField, 1}}},
+		{Keys: []IndexKey{{BKFieldID, 1}}},
+	},
+	BKTableNameObjClassifiction: {
+		{Keys: []IndexKey{{BKClassificationIDField, 1}}},
+		{Keys: []IndexKey{{BKClassificationNameField, 1}}},
+	},
+	BKTableNameObjDes: {
+		{Keys: []IndexKey{{BKObjIDField, 1}}},
+		{Keys: []IndexKey{{BKClassificationIDField, 1}}},
+		{Keys: []IndexKey{{BKObjNameField, 1}}},
+		{Keys: []IndexKey{{BKOwnerIDField, 1}}},
+	},
+	BKTableNameBaseInst: {
+		{Keys: []IndexKey{{BKObjIDField, 1}}},
+		{Keys: []IndexKey{{BKOwnerIDField, 1}}},
+		{Keys: []IndexKey{{BKInstIDField, 1}}},
+	},
+	BKTableNameOperationLog: {
+		{Name: "op_target_1_inst_id_1_op_time_-1", Keys: []IndexKey{{"op_target", 1}, {"inst_id", 1}, {"op_time", -1}}},
+		{Name: "bk_supplier_account_1_op_time_-1", Keys: []IndexKey{{BKOwnerIDField, 1}, {"op_time", -1}}},
+		{Name: "bk_biz_id_1_bk_supplier_account_1_op_time_-1", Keys: []IndexKey{{BKAppIDField, 1}, {BKOwnerIDField, 1}, {"op_time", -1}}},
+		{Name: "ext_key_1_bk_supplier_account_1_op_time_-1", Keys: []IndexKey{{"ext_key", 1}, {BKOwnerIDField, 1}, {"op_time", -1}}},
+	},
+	BKTableNameBasePlat: {
+		{Keys: []IndexKey{{BKOwnerIDField, 1}}},
+	},
+	BKTableNameProcModule: {
+		{Keys: []IndexKey{{BKAppIDField, 1}}},
+		{Keys: []IndexKey{{BKProcessIDField, 1}}},
+	},
+	BKTableNameBaseProcess: {
+		{Keys: []IndexKey{{BKProcessIDField, 1}}},
+		{Keys: []IndexKey{{BKAppIDField, 1}}},
+		{Keys: []IndexKey{{BKOwnerIDField, 1}}},
+	},
+	BKTableNamePropertyGroup: {
+		{Keys: []IndexKey{{BKObjIDField, 1}}},
+		{Keys: []IndexKey{{BKOwnerIDField, 1}}},
+		{Keys: []IndexKey{{BKPropertyGroupIDField, 1}}},
+	},
+	BKTableNameBaseSet: {
+		{Keys: []IndexKey{{BKSetIDField, 1}}},
+		{Keys: []IndexKey{{BKInstParentStr, 1}}},
+		{Keys: []IndexKey{{BKAppIDField, 1}}},
+		{Keys: []IndexKey{{BKOwnerIDField, 1}}},
+		{Keys: []IndexKey{{BKSetNameField, 1}}},
+	},
+	BKTableNameSubscription: {
+		{Keys: []IndexKey{{BKSubscriptionIDField, 1}}},
+	},
+	BKTableNameTopoGraphics: {
+		{Keys: []IndexKey{{"scope_type", 1}, {"scope_id", 1}, {"node_type", 1}, {BKObjIDField, 1}, {BKInstIDField, 1}}, Unique: true},
+	},
+	BKTableNameInstAsst: {
+		{Keys: []IndexKey{{BKObjIDField, 1}, {BKInstIDField, 1}}},
+	},
+	BKTableNameDelArchive: {
+		{Keys: []IndexKey{{BKFieldID, 1}}, Unique: true},
+		{Keys: []IndexKey{{BKObjIDField, 1}, {BKInstIDField, 1}}},
+		{Keys: []IndexKey{{"rid", 1}}},
+		// the archives are removed at expire_at, the mgo driver omits the zero ttl so one second is used
+		{Keys: []IndexKey{{"expire_at", 1}}, ExpireAfterSeconds: 1},
+	},
+	BKTableNameInstHistory: {
+		{Keys: []IndexKey{{BKObjIDField, 1}, {BKInstIDField, 1}, {"version", -1}}, Unique: true},
+		{Keys: []IndexKey{{BKObjIDField, 1}, {"op_time", -1}}},
+	},
+	BKTableNameModuleHostHistory: {
+		{Keys: []IndexKey{{"version", 1}}},
+		{Keys: []IndexKey{{BKHostIDField, 1}, {"op_time", -1}}},
+		{Keys: []IndexKey{{BKModuleIDField, 1}, {"op_time", -1}}},
+	},
+	BKTableNameNetcollectDevice: {
+		{Keys: []IndexKey{{BKDeviceIDField, 1}}},
+		{Keys: []IndexKey{{BKDeviceNameField, 1}}},
+		{Keys: []IndexKey{{BKOwnerIDField, 1}}},
+	},
+	BKTableNameNetcollectProperty: {
+		{Keys: []IndexKey{{BKNetcollectPropertyIDField, 1}}},
+		{Keys: []IndexKey{{BKOwnerIDField, 1}}},
+	},
+	BKTableNameProcOperateTask: {
+		{Name: "idx_taskID_gseTaskID", Keys: []IndexKey{{BKTaskIDField, 1}, {BKGseOpTaskIDField, 1}}},
+	},
+	BKTableNameProcInstanceModel: {
+		{Name: "idx_bkBizID_bkSetID_bkModuleID_bkHostInstanceID", Keys: []IndexKey{{BKAppIDField, 1}, {BKSetIDField, 1}, {BKModuleIDField, 1}, {"bk_host_instance_id", 1}}},
+		{Name: "idx_bkBizID_bkHostID", Keys: []IndexKey{{BKAppIDField, 1}, {BKHostIDField, 1}}},
+		{Name: "idx_bkBizID_bkProcessID", Keys: []IndexKey{{BKAppIDField, 1}, {BKProcessIDField, 1}}},
+	},
+	BKTableNameProcInstaceDetail: {
+		{Name: "idx_bkBizID_bkModuleID_bkProcessID", Keys: []IndexKey{{BKAppIDField, 1}, {BKModuleIDField, 1}, {BKProcessIDField, 1}}},
+		{Name: "idx_bkBizID_status", Keys: []IndexKey{{BKAppIDField, 1}, {BKStatusField, 1}}},
+		{Name: "idx_bkBizID_bkHostID", Keys: []IndexKey{{BKAppIDField, 1}, {BKHostIDField, 1}}},
+	},
+	BKTableNameCloudTask: {
+		{Keys: []IndexKey{{BKCloudTaskID, 1}}},
+		{Keys: []IndexKey{{BKCloudSyncTaskName, 1}}},
+	},
+	BKTableNameCloudResourceConfirm: {
+		{Keys: []IndexKey{{"bk_resource_id", 1}}},
+		{Keys: []IndexKey{{BKObjIDField, 1}}},
+	},
+	BKTableNameCloudSyncHistory: {
+		{Keys: []IndexKey{{BKCloudTaskID, 1}}},
+		{Keys: []IndexKey{{"bk_history_id", 1}}},
+	},
+	BKTableNameResourceConfirmHistory: {
+		{Keys: []IndexKey{{"bk_resource_id", 1}}},
+		{Keys: []IndexKey{{"confirm_history_id", 1}}},
+	},
+	BKTableNameEventDeadLetter: {
+		{Keys: []IndexKey{{BKDeadLetterIDField, 1}}},
+		{Keys: []IndexKey{{BKSubscriptionIDField, 1}, {BKOwnerIDField, 1}}},
+	},
+	BKTableNameCollectMapping: {
+		{Keys: []IndexKey{{BKCollectMappingNameField, 1}, {BKOwnerIDField, 1}}, Unique: true},
+	},
+	BKTableNameHostSnapMapping: {
+		{Keys: []IndexKey{{BKOwnerIDField, 1}, {BKPropertyIDField, 1}}, Unique: true},
+	},
 }
 
 // GetInstTableName returns inst data table name
@@ -0,0 +1,107 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Exporter export the finished spans
+type Exporter interface {
+	Export(spans []*Span) error
+	Close() error
+}
+
+// ExporterFactory create an exporter with the config under the prefix
+type ExporterFactory func(prefix string, configmap map[string]string) (Exporter, error)
+
+var (
+	exporterLock sync.RWMutex
+	exporters    = make(map[string]ExporterFactory)
+)
+
+// RegisterExporter register an exporter with the name
+func RegisterExporter(name string, factory ExporterFactory) {
+	exporterLock.Lock()
+	defer exporterLock.Unlock()
+	exporters[name] = factory
+}
+
+func newExporter(name, prefix string, configmap map[string]string) (Exporter, error) {
+	exporterLock.RLock()
+	factory, ok := exporters[name]
+	exporterLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown trace exporter %s", name)
+	}
+	return factory(prefix, configmap)
+}
+
+// FileExporterName the name of the JSON file exporter
+const FileExporterName = "file"
+
+const defaultTraceFile = "trace.json"
+
+func init() {
+	RegisterExporter(FileExporterName, func(prefix string, configmap map[string]string) (Exporter, error) {
+		path := configmap[prefix+".file"]
+		if path == "" {
+			path = defaultTraceFile
+		}
+		return NewFileExporter(path)
+	})
+}
+
+// FileExporter write the spans to a file, one JSON object per line
+type FileExporter struct {
+	lock sync.Mutex
+	file *os.File
+}
+
+// NewFileExporter returns an exporter appending to the file
+func NewFileExporter(path string) (*FileExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open trace file %s failed, err: %v", path, err)
+	}
+	return &FileExporter{file: file}, nil
+}
+
+// Export write the spans to the file
+func (e *FileExporter) Export(spans []*Span) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	writer := bufio.NewWriter(e.file)
+	encoder := json.NewEncoder(writer)
+	for _, span := range spans {
+		span.lock.Lock()
+		err := encoder.Encode(span)
+		span.lock.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// Close close the file
+func (e *FileExporter) Close() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.file.Close()
+}
@@ -0,0 +1,324 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package trace propagate the spans of a request across the servers by the W3C
+// traceparent header, the sampled spans are exported by the configured exporter,
+// so that a slow request can be broken down into the calls to the other servers
+// and the database. the tracing is enabled by the process config:
+//
+//	[trace]
+//	enable=true
+//	sample_rate=0.1
+//	exporter=file
+//	file=trace.json
+package trace
+
+import (
+	"crypto/rand"
+	"fmt"
+	mrand "math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+)
+
+// the kinds of the span
+const (
+	SpanKindServer = "server"
+	SpanKindClient = "client"
+)
+
+// Span a timed operation of a request
+type Span struct {
+	TraceID  string            `json:"trace_id"`
+	SpanID   string            `json:"span_id"`
+	ParentID string            `json:"parent_span_id,omitempty"`
+	Name     string            `json:"name"`
+	Kind     string            `json:"kind"`
+	Service  string            `json:"service"`
+	Start    time.Time         `json:"start_time"`
+	Duration int64             `json:"duration_us"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Error    string            `json:"error,omitempty"`
+
+	ctx    SpanContext
+	tracer *tracer
+	lock   sync.Mutex
+	done   bool
+}
+
+// Context returns the span context, the span is nil safe
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.ctx
+}
+
+// SetTag set a tag of the span
+func (s *Span) SetTag(key, value string) {
+	if s == nil || !s.ctx.Sampled {
+		return
+	}
+	s.lock.Lock()
+	if s.Tags == nil {
+		s.Tags = make(map[string]string)
+	}
+	s.Tags[key] = value
+	s.lock.Unlock()
+}
+
+// SetError mark the span failed by the error, nil error is ignored
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil || !s.ctx.Sampled {
+		return
+	}
+	s.lock.Lock()
+	s.Error = err.Error()
+	s.lock.Unlock()
+}
+
+// Finish end the span and export it if it's sampled, only the first call takes effect
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.done {
+		s.lock.Unlock()
+		return
+	}
+	s.done = true
+	s.Duration = int64(time.Since(s.Start) / time.Microsecond)
+	s.lock.Unlock()
+
+	if s.ctx.Sampled {
+		s.tracer.push(s)
+	}
+}
+
+// StartSpan start a span of the parent, it returns nil if the tracing is not enabled.
+// a server span without parent starts a new trace which is sampled by the sample rate,
+// while the client span is only started within a trace, so the background jobs don't
+// produce traces. the span of an unsampled trace is propagated but not exported.
+func StartSpan(name, kind string, parent SpanContext) *Span {
+	t := getTracer()
+	if t == nil {
+		return nil
+	}
+	if !parent.IsValid() && kind != SpanKindServer {
+		return nil
+	}
+
+	ctx := SpanContext{}
+	if parent.IsValid() {
+		ctx.TraceID = parent.TraceID
+		ctx.Sampled = parent.Sampled
+	} else {
+		rand.Read(ctx.TraceID[:])
+		ctx.Sampled = t.sample()
+	}
+	rand.Read(ctx.SpanID[:])
+
+	span := &Span{Name: name, Kind: kind, ctx: ctx, tracer: t, Start: time.Now()}
+	if ctx.Sampled {
+		span.TraceID = fmt.Sprintf("%x", ctx.TraceID)
+		span.SpanID = fmt.Sprintf("%x", ctx.SpanID)
+		if parent.IsValid() {
+			span.ParentID = fmt.Sprintf("%x", parent.SpanID)
+		}
+		span.Service = t.service
+	}
+	return span
+}
+
+// Config the tracing config
+type Config struct {
+	Enable bool
+	// the ratio of the new traces to be sampled, from 0 to 1
+	SampleRate float64
+	// the name of the exporter, file by default
+	Exporter  string
+	ConfigMap map[string]string
+
+	prefix string
+}
+
+func (c Config) keyPrefix() string {
+	if c.prefix == "" {
+		return "trace."
+	}
+	return c.prefix + "."
+}
+
+// ParseConfigFromKV returns a new config
+func ParseConfigFromKV(prefix string, configmap map[string]string) Config {
+	c := Config{
+		Enable:     configmap[prefix+".enable"] == "true",
+		SampleRate: 1,
+		Exporter:   configmap[prefix+".exporter"],
+		ConfigMap:  configmap,
+		prefix:     prefix,
+	}
+	if rate, err := strconv.ParseFloat(configmap[prefix+".sample_rate"], 64); err == nil {
+		c.SampleRate = rate
+	}
+	if c.Exporter == "" {
+		c.Exporter = FileExporterName
+	}
+	return c
+}
+
+const (
+	exportBatch    = 100
+	exportInterval = time.Second
+	bufferedSpans  = 4096
+)
+
+type tracer struct {
+	service    string
+	sampleRate float64
+	exporter   Exporter
+
+	lock   sync.RWMutex
+	closed bool
+	spans  chan *Span
+}
+
+var (
+	tracerLock sync.RWMutex
+	global     *tracer
+	globalConf Config
+)
+
+func getTracer() *tracer {
+	tracerLock.RLock()
+	defer tracerLock.RUnlock()
+	return global
+}
+
+// Init start or stop tracing with the config, the previous exporter is flushed and closed
+// if the config is changed, it's safe to be called on every process config update.
+func Init(service string, c Config) error {
+	tracerLock.Lock()
+	defer tracerLock.Unlock()
+
+	if global != nil && global.service == service && sameConfig(c, globalConf) {
+		return nil
+	}
+
+	var t *tracer
+	if c.Enable {
+		exporter, err := newExporter(c.Exporter, strings.TrimSuffix(c.keyPrefix(), "."), c.ConfigMap)
+		if err != nil {
+			return err
+		}
+		t = &tracer{
+			service:    service,
+			sampleRate: c.SampleRate,
+			exporter:   exporter,
+			spans:      make(chan *Span, bufferedSpans),
+		}
+		go t.run()
+		blog.Infof("[trace] tracing is enabled, sample rate: %v, exporter: %s", c.SampleRate, c.Exporter)
+	}
+
+	if global != nil {
+		global.close()
+	}
+	global = t
+	globalConf = c
+	return nil
+}
+
+func sameConfig(a, b Config) bool {
+	if a.Enable != b.Enable || a.SampleRate != b.SampleRate || a.Exporter != b.Exporter ||
+		a.keyPrefix() != b.keyPrefix() {
+		return false
+	}
+	prefix := a.keyPrefix()
+	for key, value := range a.ConfigMap {
+		if strings.HasPrefix(key, prefix) && b.ConfigMap[key] != value {
+			return false
+		}
+	}
+	for key, value := range b.ConfigMap {
+		if strings.HasPrefix(key, prefix) && a.ConfigMap[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *tracer) sample() bool {
+	return t.sampleRate >= 1 || mrand.Float64() < t.sampleRate
+}
+
+func (t *tracer) push(span *Span) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if t.closed {
+		return
+	}
+	select {
+	case t.spans <- span:
+	default:
+		blog.V(4).Infof("[trace] too many spans buffered, drop span %s of trace %s", span.Name, span.TraceID)
+	}
+}
+
+func (t *tracer) close() {
+	t.lock.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.spans)
+	}
+	t.lock.Unlock()
+}
+
+func (t *tracer) run() {
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, exportBatch)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.exporter.Export(batch); err != nil {
+			blog.Errorf("[trace] export %d spans failed, err: %v", len(batch), err)
+		}
+		batch = make([]*Span, 0, exportBatch)
+	}
+
+	for {
+		select {
+		case span, ok := <-t.spans:
+			if !ok {
+				flush()
+				if err := t.exporter.Close(); err != nil {
+					blog.Errorf("[trace] close exporter failed, err: %v", err)
+				}
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= exportBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,156 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTraceparent(t *testing.T) {
+	value := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := ParseTraceparent(value)
+	if !ok || !sc.Sampled {
+		t.Fatalf("parse %s failed, got %+v", value, sc)
+	}
+	if sc.Traceparent() != value {
+		t.Errorf("expect %s, got %s", value, sc.Traceparent())
+	}
+
+	invalids := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	}
+	for _, value := range invalids {
+		if _, ok := ParseTraceparent(value); ok {
+			t.Errorf("expect %s invalid", value)
+		}
+	}
+	if _, ok := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra"); !ok {
+		t.Error("expect the fields of the future version ignored")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	if err := Init("test", Config{}); err != nil {
+		t.Fatal(err)
+	}
+	span := StartSpan("GET /", SpanKindServer, SpanContext{})
+	if span != nil {
+		t.Fatal("expect no span when tracing is disabled")
+	}
+	// the nil span is safe
+	span.SetTag("key", "value")
+	span.SetError(errors.New("error"))
+	span.Finish()
+}
+
+func TestExportSpans(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.json")
+	conf := ParseConfigFromKV("trace", map[string]string{
+		"trace.enable":      "true",
+		"trace.sample_rate": "1",
+		"trace.exporter":    FileExporterName,
+		"trace.file":        path,
+	})
+	if err := Init("coreservice", conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if span := StartSpan("mongo find", SpanKindClient, SpanContext{}); span != nil {
+		t.Fatal("expect no client span without parent")
+	}
+
+	root := StartSpan("POST /hosts/search", SpanKindServer, SpanContext{})
+	header := http.Header{}
+	Inject(header, root.Context())
+	child := StartSpan("POST /api/v3/read/instance", SpanKindClient, Extract(header))
+	child.SetTag("peer.host", "127.0.0.1")
+	child.SetError(errors.New("timeout"))
+	child.Finish()
+	child.Finish()
+	root.Finish()
+
+	// flush and close the exporter
+	if err := Init("coreservice", Config{}); err != nil {
+		t.Fatal(err)
+	}
+	var spans []*Span
+	for i := 0; i < 50 && len(spans) < 2; i++ {
+		time.Sleep(20 * time.Millisecond)
+		spans = readSpans(t, path)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("expect 2 spans exported, got %d", len(spans))
+	}
+	if spans[0].TraceID != spans[1].TraceID || spans[0].ParentID != spans[1].SpanID || spans[1].ParentID != "" {
+		t.Errorf("expect the client span is the child of the server span, got %+v", spans)
+	}
+	if spans[0].Error != "timeout" || spans[0].Tags["peer.host"] != "127.0.0.1" || spans[0].Service != "coreservice" {
+		t.Errorf("unexpected client span %+v", spans[0])
+	}
+}
+
+func TestUnsampled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.json")
+	conf := ParseConfigFromKV("trace", map[string]string{"trace.enable": "true", "trace.sample_rate": "0", "trace.file": path})
+	if err := Init("test", conf); err != nil {
+		t.Fatal(err)
+	}
+	root := StartSpan("GET /", SpanKindServer, SpanContext{})
+	if root == nil || !root.Context().IsValid() || root.Context().Sampled {
+		t.Fatalf("expect an unsampled span to propagate, got %+v", root)
+	}
+	child := StartSpan("GET /", SpanKindClient, root.Context())
+	if child.Context().Sampled || child.Context().TraceID != root.Context().TraceID {
+		t.Errorf("expect the child follows the sampling of the parent")
+	}
+	child.Finish()
+	root.Finish()
+	if err := Init("test", Config{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if spans := readSpans(t, path); len(spans) != 0 {
+		t.Errorf("expect no span exported, got %v", spans)
+	}
+}
+
+func readSpans(t *testing.T, path string) []*Span {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	spans := make([]*Span, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		span := new(Span)
+		if err := json.Unmarshal(scanner.Bytes(), span); err != nil {
+			t.Fatal(err)
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
@@ -0,0 +1,375 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongo
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/universalsql"
+)
+
+// Match check whether the data matches the condition in memory, the condition
+// is in the same format as the result of ToMapStr, it supports the logic
+// operators $and $or $nor $not, the comparison operators $eq $ne $gt $gte $lt $lte
+// $in $nin $regex $exists, the array operators $all $size $elemMatch, and the
+// dotted key of the embedded field.
+func Match(cond mapstr.MapStr, data mapstr.MapStr) (bool, error) {
+	for key, val := range cond {
+		var matched bool
+		var err error
+		switch key {
+		case universalsql.AND, universalsql.OR, universalsql.NOR:
+			matched, err = matchLogic(key, val, data)
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, fmt.Errorf("not support the operator '%s'", key)
+			}
+			matched, err = matchField(lookupField(data, key), val)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchLogic(operator string, val interface{}, data mapstr.MapStr) (bool, error) {
+	items, ok := val.([]interface{})
+	if !ok {
+		conds, isConds := val.([]mapstr.MapStr)
+		if !isConds {
+			return false, fmt.Errorf("the value of operator '%s' should be an array", operator)
+		}
+		for _, cond := range conds {
+			items = append(items, cond)
+		}
+	}
+
+	for _, item := range items {
+		cond, ok := toMapStr(item)
+		if !ok {
+			return false, fmt.Errorf("the element of operator '%s' should be a condition", operator)
+		}
+		matched, err := Match(cond, data)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case operator == universalsql.AND && !matched:
+			return false, nil
+		case operator == universalsql.OR && matched:
+			return true, nil
+		case operator == universalsql.NOR && matched:
+			return false, nil
+		}
+	}
+	return operator != universalsql.OR, nil
+}
+
+// matchField check the field value with the condition value, which is either
+// the operators of the field or the value to be equal to.
+func matchField(fieldVal, condVal interface{}) (bool, error) {
+	operators, ok := toMapStr(condVal)
+	if !ok || !isOperators(operators) {
+		return matchEqual(fieldVal, condVal), nil
+	}
+
+	for operator, val := range operators {
+		var matched bool
+		switch operator {
+		case universalsql.NOT:
+			notMatched, err := matchField(fieldVal, val)
+			if err != nil {
+				return false, err
+			}
+			matched = !notMatched
+		case universalsql.EQ:
+			matched = matchEqual(fieldVal, val)
+		case universalsql.NEQ:
+			matched = !matchEqual(fieldVal, val)
+		case universalsql.GT, universalsql.GTE, universalsql.LT, universalsql.LTE:
+			matched = matchCompare(operator, fieldVal, val)
+		case universalsql.IN, universalsql.NIN:
+			items, ok := toSlice(val)
+			if !ok {
+				return false, fmt.Errorf("the value of operator '%s' should be an array", operator)
+			}
+			for _, item := range items {
+				if matchEqual(fieldVal, item) {
+					matched = true
+					break
+				}
+			}
+			if operator == universalsql.NIN {
+				matched = !matched
+			}
+		case universalsql.REGEX:
+			pattern, ok := val.(string)
+			if !ok {
+				return false, fmt.Errorf("the value of operator '%s' should be a string", operator)
+			}
+			if options, ok := operators[universalsql.OPTIONS].(string); ok && options != "" {
+				pattern = "(?" + options + ")" + pattern
+			}
+			reg, err := regexp.Compile(pattern)
+			if err != nil {
+				return false, err
+			}
+			matched = matchRegex(fieldVal, reg)
+		case universalsql.OPTIONS:
+			// used by $regex
+			matched = true
+		case universalsql.EXISTS:
+			exists, ok := val.(bool)
+			if !ok {
+				return false, fmt.Errorf("the value of operator '%s' should be a bool", operator)
+			}
+			matched = (fieldVal != nil) == exists
+		case universalsql.ALL:
+			items, ok := toSlice(val)
+			if !ok {
+				return false, fmt.Errorf("the value of operator '%s' should be an array", operator)
+			}
+			matched = len(items) > 0
+			for _, item := range items {
+				if !matchEqual(fieldVal, item) {
+					matched = false
+					break
+				}
+			}
+		case universalsql.SIZE:
+			size, ok := toFloat(val)
+			if !ok {
+				return false, fmt.Errorf("the value of operator '%s' should be a number", operator)
+			}
+			items, ok := toSlice(fieldVal)
+			matched = ok && float64(len(items)) == size
+		case universalsql.ELEMMATCH:
+			cond, ok := toMapStr(val)
+			if !ok {
+				return false, fmt.Errorf("the value of operator '%s' should be a condition", operator)
+			}
+			items, _ := toSlice(fieldVal)
+			for _, item := range items {
+				var err error
+				if elem, isMap := toMapStr(item); isMap && !isOperators(cond) {
+					matched, err = Match(cond, elem)
+				} else {
+					matched, err = matchField(item, cond)
+				}
+				if err != nil {
+					return false, err
+				}
+				if matched {
+					break
+				}
+			}
+		default:
+			return false, fmt.Errorf("not support the operator '%s'", operator)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func isOperators(cond mapstr.MapStr) bool {
+	if len(cond) == 0 {
+		return false
+	}
+	for key := range cond {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupField returns the field value by the dotted key, returns nil if not exists.
+// the values of the embedded field in the array elements are returned as an array.
+func lookupField(data mapstr.MapStr, key string) interface{} {
+	return lookupPath(data, strings.Split(key, "."))
+}
+
+func lookupPath(current interface{}, names []string) interface{} {
+	if len(names) == 0 {
+		return current
+	}
+	if m, ok := toMapStr(current); ok {
+		return lookupPath(m[names[0]], names[1:])
+	}
+
+	items, ok := toSlice(current)
+	if !ok {
+		return nil
+	}
+	if index, err := strconv.Atoi(names[0]); err == nil {
+		if index < 0 || index >= len(items) {
+			return nil
+		}
+		return lookupPath(items[index], names[1:])
+	}
+
+	values := make([]interface{}, 0)
+	for _, item := range items {
+		val := lookupPath(item, names)
+		if val == nil {
+			continue
+		}
+		if subItems, ok := toSlice(val); ok {
+			values = append(values, subItems...)
+		} else {
+			values = append(values, val)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// matchRegex check the string field, the array field matches if any element matches
+func matchRegex(fieldVal interface{}, reg *regexp.Regexp) bool {
+	if str, ok := fieldVal.(string); ok {
+		return reg.MatchString(str)
+	}
+	items, _ := toSlice(fieldVal)
+	for _, item := range items {
+		if str, ok := item.(string); ok && reg.MatchString(str) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchEqual check equality like mongodb, the array field matches if any element equals
+func matchEqual(fieldVal, condVal interface{}) bool {
+	if equal(fieldVal, condVal) {
+		return true
+	}
+	if items, ok := toSlice(fieldVal); ok {
+		for _, item := range items {
+			if equal(item, condVal) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func equal(a, b interface{}) bool {
+	fa, aok := toFloat(a)
+	fb, bok := toFloat(b)
+	if aok && bok {
+		return fa == fb
+	}
+	ta, taok := a.(time.Time)
+	tb, tbok := b.(time.Time)
+	if taok && tbok {
+		return ta.Equal(tb)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// matchCompare compare the numbers, strings or times, the values of different types never match,
+// the array field matches if any element matches
+func matchCompare(operator string, fieldVal, condVal interface{}) bool {
+	if items, ok := toSlice(fieldVal); ok {
+		for _, item := range items {
+			if matchCompare(operator, item, condVal) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var result int
+	fa, aok := toFloat(fieldVal)
+	fb, bok := toFloat(condVal)
+	sa, saok := fieldVal.(string)
+	sb, sbok := condVal.(string)
+	switch {
+	case aok && bok:
+		if fa < fb {
+			result = -1
+		} else if fa > fb {
+			result = 1
+		}
+	case saok && sbok:
+		result = strings.Compare(sa, sb)
+	default:
+		ta, taok := fieldVal.(time.Time)
+		tb, tbok := condVal.(time.Time)
+		if !taok || !tbok {
+			return false
+		}
+		if ta.Before(tb) {
+			result = -1
+		} else if ta.After(tb) {
+			result = 1
+		}
+	}
+
+	switch operator {
+	case universalsql.GT:
+		return result > 0
+	case universalsql.GTE:
+		return result >= 0
+	case universalsql.LT:
+		return result < 0
+	default:
+		return result <= 0
+	}
+}
+
+func toFloat(val interface{}) (float64, bool) {
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func toSlice(val interface{}) ([]interface{}, bool) {
+	v := reflect.ValueOf(val)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	items := make([]interface{}, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, true
+}
+
+func toMapStr(val interface{}) (mapstr.MapStr, bool) {
+	switch m := val.(type) {
+	case mapstr.MapStr:
+		return m, true
+	case map[string]interface{}:
+		return mapstr.MapStr(m), true
+	}
+	return nil, false
+}
@@ -0,0 +1,89 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongo_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/universalsql/mongo"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	data := mapstr.MapStr{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"bk_host_id": 1,
+		"bk_host_innerip": "10.0.0.1",
+		"bk_cloud_id": 0,
+		"bk_os_type": "1",
+		"tags": ["db", "prod"],
+		"detail": {"bk_biz_id": 3},
+		"modules": [{"bk_module_id": 5, "name": "gse"}, {"bk_module_id": 6, "name": "job"}]
+	}`), &data))
+
+	cases := []struct {
+		cond    string
+		matched bool
+	}{
+		{`{}`, true},
+		{`{"bk_host_innerip": "10.0.0.1"}`, true},
+		{`{"bk_host_innerip": "10.0.0.2"}`, false},
+		{`{"bk_host_id": {"$gte": 1, "$lt": 2}}`, true},
+		{`{"bk_host_id": {"$gt": 1}}`, false},
+		{`{"bk_cloud_id": {"$in": [0, 1]}}`, true},
+		{`{"bk_cloud_id": {"$nin": [0, 1]}}`, false},
+		{`{"bk_host_innerip": {"$regex": "^10\\."}}`, true},
+		{`{"bk_host_name": {"$exists": false}}`, true},
+		{`{"bk_os_type": {"$ne": "2"}}`, true},
+		{`{"tags": "db"}`, true},
+		{`{"detail.bk_biz_id": 3}`, true},
+		{`{"$or": [{"bk_cloud_id": 1}, {"bk_host_id": 1}]}`, true},
+		{`{"$and": [{"bk_cloud_id": 0}, {"bk_host_id": 2}]}`, false},
+		{`{"$nor": [{"bk_cloud_id": 1}]}`, true},
+		{`{"bk_host_innerip": {"$not": {"$regex": "^10\\."}}}`, false},
+		{`{"bk_os_type": {"$regex": "^L", "$options": "i"}}`, false},
+		{`{"tags": {"$regex": "^PRO", "$options": "i"}}`, true},
+		{`{"tags": {"$all": ["db", "prod"]}}`, true},
+		{`{"tags": {"$all": ["db", "test"]}}`, false},
+		{`{"tags": {"$size": 2}}`, true},
+		{`{"modules.bk_module_id": 6}`, true},
+		{`{"modules.1.name": "job"}`, true},
+		{`{"modules.bk_module_id": {"$gt": 5}}`, true},
+		{`{"modules": {"$elemMatch": {"bk_module_id": 5, "name": "job"}}}`, false},
+		{`{"modules": {"$elemMatch": {"bk_module_id": 6, "name": "job"}}}`, true},
+	}
+
+	for _, c := range cases {
+		cond := mapstr.MapStr{}
+		require.NoError(t, json.Unmarshal([]byte(c.cond), &cond))
+		matched, err := mongo.Match(cond, data)
+		require.NoError(t, err, c.cond)
+		require.Equal(t, c.matched, matched, c.cond)
+	}
+}
+
+func TestMatchInvalidCondition(t *testing.T) {
+	cases := []mapstr.MapStr{
+		{"$where": "1"},
+		{"bk_host_id": mapstr.MapStr{"$type": 1}},
+		{"bk_host_id": mapstr.MapStr{"$in": 1}},
+		{"$or": "bk_host_id"},
+	}
+	for _, cond := range cases {
+		_, err := mongo.Match(cond, mapstr.MapStr{"bk_host_id": 1})
+		require.Error(t, err, cond)
+	}
+}
@@ -23,6 +23,8 @@ const (
 	EQ    string = "$eq"
 	NEQ   string = "$ne"
 	REGEX string = "$regex"
+	// OPTIONS the options of $regex
+	OPTIONS string = "$options"
 
 	//Logic Operator
 	AND string = "$and"
@@ -67,6 +67,8 @@ func GetInt64ByInterface(a interface{}) (int64, error) {
 		id = int64(a.(int32))
 	case int64:
 		id = int64(a.(int64))
+	case uint64:
+		id = int64(a.(uint64))
 	case json.Number:
 		var tmpID int64
 		tmpID, err = a.(json.Number).Int64()
@@ -119,6 +119,12 @@ func TestGetInt64ByInterface(t *testing.T) {
 			},
 			want: 1,
 		},
+		{
+			args: args{
+				a: uint64(1),
+			},
+			want: 1,
+		},
 		{
 			args: args{
 				a: float32(1.01),
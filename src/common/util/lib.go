@@ -23,6 +23,7 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/trace"
 	"configcenter/src/storage/dal"
 
 	restful "github.com/emicklei/go-restful"
@@ -132,8 +133,26 @@ func GetHTTPCCTransaction(header http.Header) string {
 	return rid
 }
 
+// SecondaryEligibleHeader returns a copy of the header which marks the reads of the request
+// can be served by the secondaries, only for the queries tolerant of stale data
+func SecondaryEligibleHeader(header http.Header) http.Header {
+	newHeader := CopyHeader(header)
+	newHeader.Set(common.BKHTTPSecondaryEligible, "true")
+	return newHeader
+}
+
+// IsSecondaryEligible returns whether the reads of the request can be served by the secondaries
+func IsSecondaryEligible(header http.Header) bool {
+	return header.Get(common.BKHTTPSecondaryEligible) == "true"
+}
+
 // GetDBContext returns a new context that contains JoinOption
 func GetDBContext(parent context.Context, header http.Header) context.Context {
+	// the span of the request is the parent of the spans of the db operations
+	parent = trace.ContextWithSpanContext(parent, trace.Extract(header))
+	if IsSecondaryEligible(header) {
+		parent = dal.WithSecondaryEligible(parent)
+	}
 	return context.WithValue(parent, common.CCContextKeyJoinOption, dal.JoinOption{
 		RequestID: header.Get(common.BKHTTPCCRequestID),
 		TxnID:     header.Get(common.BKHTTPCCTransactionID),
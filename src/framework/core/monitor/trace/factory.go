@@ -1,14 +1,27 @@
 /*
  * Tencent is pleased to support the open source community by making 蓝鲸 available.
  * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
- * Licensed under the MIT License (the "License"); you may not use this file except 
+ * Licensed under the MIT License (the "License"); you may not use this file except
  * in compliance with the License. You may obtain a copy of the License at
  * http://opensource.org/licenses/MIT
  * Unless required by applicable law or agreed to in writing, software distributed under
  * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
- * either express or implied. See the License for the specific language governing permissions and 
+ * either express or implied. See the License for the specific language governing permissions and
  * limitations under the License.
  */
- 
+
 package trace
 
+import (
+	cctrace "configcenter/src/common/trace"
+	"configcenter/src/framework/core/log"
+	"configcenter/src/framework/core/option"
+)
+
+// NewManager start the tracing with the [trace] config of the framework
+func NewManager(opt *option.Options, conf map[string]string) Trace {
+	if err := cctrace.Init(opt.AppName, cctrace.ParseConfigFromKV("trace", conf)); err != nil {
+		log.Errorf("init trace failed, err: %v", err)
+	}
+	return &Manager{}
+}
@@ -1,14 +1,31 @@
 /*
  * Tencent is pleased to support the open source community by making 蓝鲸 available.
  * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
- * Licensed under the MIT License (the "License"); you may not use this file except 
+ * Licensed under the MIT License (the "License"); you may not use this file except
  * in compliance with the License. You may obtain a copy of the License at
  * http://opensource.org/licenses/MIT
  * Unless required by applicable law or agreed to in writing, software distributed under
  * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
- * either express or implied. See the License for the specific language governing permissions and 
+ * either express or implied. See the License for the specific language governing permissions and
  * limitations under the License.
  */
- 
+
 package trace
 
+import (
+	"net/http"
+
+	cctrace "configcenter/src/common/trace"
+)
+
+// Manager the tracing manager
+type Manager struct{}
+
+var _ Trace = &Manager{}
+
+// StartSpan start a span in the trace of the header, or a new trace
+func (m *Manager) StartSpan(name string, header http.Header) *cctrace.Span {
+	span := cctrace.StartSpan(name, cctrace.SpanKindServer, cctrace.Extract(header))
+	cctrace.Inject(header, span.Context())
+	return span
+}
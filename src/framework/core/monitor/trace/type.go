@@ -1,14 +1,26 @@
 /*
  * Tencent is pleased to support the open source community by making 蓝鲸 available.
  * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
- * Licensed under the MIT License (the "License"); you may not use this file except 
+ * Licensed under the MIT License (the "License"); you may not use this file except
  * in compliance with the License. You may obtain a copy of the License at
  * http://opensource.org/licenses/MIT
  * Unless required by applicable law or agreed to in writing, software distributed under
  * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
- * either express or implied. See the License for the specific language governing permissions and 
+ * either express or implied. See the License for the specific language governing permissions and
  * limitations under the License.
  */
- 
+
 package trace
 
+import (
+	"net/http"
+
+	cctrace "configcenter/src/common/trace"
+)
+
+// Trace interface
+type Trace interface {
+	// StartSpan start a span of the operation, the span is carried by the
+	// header to the cmdb servers as the parent of their spans
+	StartSpan(name string, header http.Header) *cctrace.Span
+}
@@ -30,6 +30,7 @@ import (
 	"configcenter/src/framework/core/httpserver"
 	"configcenter/src/framework/core/log"
 	"configcenter/src/framework/core/monitor/metric"
+	"configcenter/src/framework/core/monitor/trace"
 	"configcenter/src/framework/core/option"
 	"configcenter/src/framework/core/output/module/client"
 	_ "configcenter/src/framework/plugins"
@@ -108,6 +109,7 @@ func main() {
 	}
 
 	metricManager := metric.NewManager(opt)
+	trace.NewManager(opt, config.Get())
 
 	server.RegisterActions(api.Actions()...)
 	server.RegisterActions(metricManager.Actions()...)
@@ -31,6 +31,7 @@ import (
 	"configcenter/src/scene_server/admin_server/app/options"
 	"configcenter/src/scene_server/admin_server/configures"
 	svc "configcenter/src/scene_server/admin_server/service"
+	"configcenter/src/storage/dal/instrument"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
 )
@@ -70,11 +71,18 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 			blog.V(3).Info("config not found, retry 2s later")
 			continue
 		}
-		db, err := local.NewMgo(process.Config.MongoDB.BuildURI(), 0)
+		mgo, err := local.NewMgo(process.Config.MongoDB.BuildURI(), 0)
 		if err != nil {
 			return fmt.Errorf("connect mongo server failed %s", err.Error())
 		}
+		// record the latencies and the slow queries of the db operations
+		db := instrument.New(mgo, instrument.Options{
+			SlowThreshold: process.Config.MongoDB.GetSlowQueryThreshold(),
+			Process:       instrument.ProcessName(types.CC_MODULE_MIGRATE, svrInfo),
+			FlushInterval: time.Minute,
+		})
 		process.Service.SetDB(db)
+		process.Service.DBMetric.Swap(db)
 		process.Service.SetApiSrvAddr(process.Config.ProcSrvConfig.CCApiSrvAddr)
 		err = process.ConfigCenter.Start(
 			process.Config.Configures.Dir,
@@ -0,0 +1,111 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package asstmapping scans the instance associations which break the mapping(1:1, 1:n) of their model
+// associations, they may be written before the mapping is enforced by the core service.
+package asstmapping
+
+import (
+	"context"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+)
+
+// the side of the instance association which the instance is
+const (
+	SideSource      = "source"
+	SideDestination = "destination"
+)
+
+// Violation an instance which is related by more instance associations of a model association than its mapping allows
+type Violation struct {
+	ObjectAsstID string                      `json:"bk_obj_asst_id"`
+	Mapping      metadata.AssociationMapping `json:"mapping"`
+	Side         string                      `json:"side"`
+	ObjectID     string                      `json:"bk_obj_id"`
+	InstID       int64                       `json:"bk_inst_id"`
+	// the ids of the instance associations which relate the instance
+	InstAsstIDs []int64 `json:"inst_asst_ids"`
+}
+
+// Scan returns the violations of all the 1:1 and 1:n model associations,
+// for 1:1 both the source and destination instances are checked, for 1:n only the destination ones.
+func Scan(ctx context.Context, db dal.RDB) ([]Violation, error) {
+	cond := mapstr.MapStr{
+		metadata.AssociationFieldMapping: mapstr.MapStr{common.BKDBIN: []metadata.AssociationMapping{metadata.OneToOneMapping, metadata.OneToManyMapping}},
+	}
+	assts := make([]metadata.Association, 0)
+	if err := db.Table(common.BKTableNameObjAsst).Find(cond).All(ctx, &assts); err != nil {
+		blog.Errorf("scan association mapping, find the associations failed, err: %v", err)
+		return nil, err
+	}
+
+	violations := make([]Violation, 0)
+	for _, asst := range assts {
+		if asst.Mapping == metadata.OneToOneMapping {
+			found, err := scanSide(ctx, db, asst, SideSource, common.BKInstIDField, asst.ObjectID)
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, found...)
+		}
+
+		found, err := scanSide(ctx, db, asst, SideDestination, common.BKAsstInstIDField, asst.AsstObjID)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, found...)
+	}
+	return violations, nil
+}
+
+// scanSide groups the instance associations of the association by the instance on the side
+func scanSide(ctx context.Context, db dal.RDB, asst metadata.Association, side, instField, objID string) ([]Violation, error) {
+	pipeline := []mapstr.MapStr{
+		{common.BKDBMatch: mapstr.MapStr{common.AssociationObjAsstIDField: asst.AssociationName}},
+		{common.BKDBGroup: mapstr.MapStr{
+			"_id":   "$" + instField,
+			"count": mapstr.MapStr{common.BKDBSum: 1},
+			"ids":   mapstr.MapStr{"$push": "$" + common.BKFieldID},
+		}},
+		{common.BKDBMatch: mapstr.MapStr{"count": mapstr.MapStr{common.BKDBGT: 1}}},
+	}
+
+	groups := make([]struct {
+		InstID int64   `bson:"_id"`
+		IDs    []int64 `bson:"ids"`
+	}, 0)
+	if err := db.Table(common.BKTableNameInstAsst).AggregateAll(ctx, pipeline, &groups); err != nil {
+		blog.Errorf("scan association mapping, group the instance associations of %s failed, err: %v", asst.AssociationName, err)
+		return nil, err
+	}
+
+	violations := make([]Violation, 0, len(groups))
+	for _, group := range groups {
+		sort.Slice(group.IDs, func(i, j int) bool { return group.IDs[i] < group.IDs[j] })
+		violations = append(violations, Violation{
+			ObjectAsstID: asst.AssociationName,
+			Mapping:      asst.Mapping,
+			Side:         side,
+			ObjectID:     objID,
+			InstID:       group.InstID,
+			InstAsstIDs:  group.IDs,
+		})
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].InstID < violations[j].InstID })
+	return violations, nil
+}
@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package asstmapping
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan(t *testing.T) {
+	ctx := context.Background()
+	db := memory.New()
+
+	require.NoError(t, db.Table(common.BKTableNameObjAsst).Insert(ctx, []mapstr.MapStr{
+		{"id": 1, "bk_obj_asst_id": "a_connect_b", "bk_obj_id": "a", "bk_asst_obj_id": "b", "mapping": metadata.OneToOneMapping},
+		{"id": 2, "bk_obj_asst_id": "a_contain_c", "bk_obj_id": "a", "bk_asst_obj_id": "c", "mapping": metadata.OneToManyMapping},
+		{"id": 3, "bk_obj_asst_id": "a_run_d", "bk_obj_id": "a", "bk_asst_obj_id": "d", "mapping": metadata.ManyToManyMapping},
+	}))
+	require.NoError(t, db.Table(common.BKTableNameInstAsst).Insert(ctx, []mapstr.MapStr{
+		// a 1 relates two b instances, and b 2 is related by two a instances
+		{"id": 10, "bk_obj_asst_id": "a_connect_b", "bk_inst_id": 1, "bk_asst_inst_id": 1},
+		{"id": 11, "bk_obj_asst_id": "a_connect_b", "bk_inst_id": 1, "bk_asst_inst_id": 2},
+		{"id": 12, "bk_obj_asst_id": "a_connect_b", "bk_inst_id": 3, "bk_asst_inst_id": 2},
+		// a 1 relates many c instances, which is allowed, but c 2 is related by two a instances
+		{"id": 20, "bk_obj_asst_id": "a_contain_c", "bk_inst_id": 1, "bk_asst_inst_id": 1},
+		{"id": 21, "bk_obj_asst_id": "a_contain_c", "bk_inst_id": 1, "bk_asst_inst_id": 2},
+		{"id": 22, "bk_obj_asst_id": "a_contain_c", "bk_inst_id": 2, "bk_asst_inst_id": 2},
+		// n:n has no limit
+		{"id": 30, "bk_obj_asst_id": "a_run_d", "bk_inst_id": 1, "bk_asst_inst_id": 1},
+		{"id": 31, "bk_obj_asst_id": "a_run_d", "bk_inst_id": 2, "bk_asst_inst_id": 1},
+	}))
+
+	violations, err := Scan(ctx, db)
+	require.NoError(t, err)
+	require.Equal(t, []Violation{
+		{ObjectAsstID: "a_connect_b", Mapping: metadata.OneToOneMapping, Side: SideSource, ObjectID: "a", InstID: 1, InstAsstIDs: []int64{10, 11}},
+		{ObjectAsstID: "a_connect_b", Mapping: metadata.OneToOneMapping, Side: SideDestination, ObjectID: "b", InstID: 2, InstAsstIDs: []int64{11, 12}},
+		{ObjectAsstID: "a_contain_c", Mapping: metadata.OneToManyMapping, Side: SideDestination, ObjectID: "c", InstID: 2, InstAsstIDs: []int64{21, 22}},
+	}, violations)
+}
@@ -33,10 +33,22 @@ const (
 
 // Parse run app command
 func Parse(args []string) error {
-	ctx := context.Background()
-	if len(args) <= 1 || args[1] != bkbizCmdName {
+	if len(args) <= 1 {
 		return nil
 	}
+	switch args[1] {
+	case bkbizCmdName:
+		return parseBKBiz(args)
+	case reencryptCmdName:
+		return parseReEncrypt(args)
+	case indexCmdName:
+		return parseIndex(args)
+	}
+	return nil
+}
+
+func parseBKBiz(args []string) error {
+	ctx := context.Background()
 
 	var (
 		exportflag     bool
@@ -0,0 +1,75 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"configcenter/src/common/backbone/configcenter"
+	"configcenter/src/scene_server/admin_server/dbindex"
+	"configcenter/src/storage/dal/mongo"
+	"configcenter/src/storage/dal/mongo/local"
+)
+
+const indexCmdName = "index"
+
+// parseIndex diff the declared indexes with the existing ones, create the missing indexes
+// and drop the undeclared ones of the tables in the drop flag
+func parseIndex(args []string) error {
+	ctx := context.Background()
+
+	var (
+		dryrunflag     bool
+		droptables     []string
+		configposition string
+	)
+
+	fs := pflag.NewFlagSet(indexCmdName, pflag.ExitOnError)
+	fs.BoolVar(&dryrunflag, "dryrun", false, "dryrun flag, if this flag seted, we will just print the drift but not execute to db")
+	fs.StringSliceVar(&droptables, "drop", nil, "the tables whose indexes which are not declared are dropped, they are only reported by default. e.g cc_HostBase,cc_OperationLog")
+	fs.StringVar(&configposition, "config", "conf/api.conf", "The config path. e.g conf/api.conf")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	pconfig, err := configcenter.ParseConfigWithFile(configposition)
+	if nil != err {
+		return fmt.Errorf("parse config file error %s", err.Error())
+	}
+	config := mongo.ParseConfigFromKV("mongodb", pconfig.ConfigMap)
+	db, err := local.NewMgo(config.BuildURI(), 0)
+	if err != nil {
+		return fmt.Errorf("connect mongo server failed %s", err.Error())
+	}
+
+	drifts, err := dbindex.Reconcile(ctx, db, dbindex.Option{DryRun: dryrunflag, DropTables: droptables})
+	for _, drift := range drifts {
+		state := "pending"
+		if drift.Applied {
+			state = "applied"
+		}
+		fmt.Printf("[%s] %s index %s %v of %s: %s\n", state, drift.Action, drift.Index.Name, drift.Index.Keys, drift.Table, drift.Reason)
+	}
+	if err != nil {
+		fmt.Printf("reconcile index error: %s\n", err.Error())
+		os.Exit(2)
+	}
+	fmt.Printf("%d index drifts found\n", len(drifts))
+
+	os.Exit(0)
+	return nil
+}
@@ -0,0 +1,166 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/cryptor"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/types"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/mongo"
+	"configcenter/src/storage/dal/mongo/local"
+)
+
+const reencryptCmdName = "reencrypt"
+
+// parseReEncrypt re-encrypt the cloud account and subscription secrets with the active master key,
+// the master keys are read from the host server configure which is written to the
+// config center, so it should be run after the master keys are rotated.
+func parseReEncrypt(args []string) error {
+	ctx := context.Background()
+
+	var (
+		dryrunflag     bool
+		configposition string
+	)
+
+	fs := pflag.NewFlagSet(reencryptCmdName, pflag.ExitOnError)
+	fs.BoolVar(&dryrunflag, "dryrun", false, "dryrun flag, if this flag seted, we will just print what we will do but not execute to db")
+	fs.StringVar(&configposition, "config", "conf/api.conf", "The config path. e.g conf/api.conf")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	pconfig, err := configcenter.ParseConfigWithFile(configposition)
+	if nil != err {
+		return fmt.Errorf("parse config file error %s", err.Error())
+	}
+
+	hostConfPath := filepath.Join(pconfig.ConfigMap["confs.dir"], types.CC_MODULE_HOST+".conf")
+	hostConfig, err := configcenter.ParseConfigWithFile(hostConfPath)
+	if nil != err {
+		return fmt.Errorf("parse host server config file %s error %s", hostConfPath, err.Error())
+	}
+	envelope, err := cryptor.NewFromConfig(cryptor.ParseConfigFromKV("encryption", hostConfig.ConfigMap))
+	if err != nil {
+		return fmt.Errorf("invalid encryption config in %s, err: %v", hostConfPath, err)
+	}
+
+	config := mongo.ParseConfigFromKV("mongodb", pconfig.ConfigMap)
+	db, err := local.NewMgo(config.BuildURI(), 0)
+	if err != nil {
+		return fmt.Errorf("connect mongo server failed %s", err.Error())
+	}
+
+	if dryrunflag {
+		fmt.Printf("dryrun re-encrypt the cloud account and subscription secrets\n")
+	} else {
+		fmt.Printf("re-encrypting the cloud account and subscription secrets\n")
+	}
+	count, err := reEncryptCloudTaskSecret(ctx, db, envelope, dryrunflag)
+	if err != nil {
+		fmt.Printf("re-encrypt error: %s\n", err.Error())
+		os.Exit(2)
+	}
+	fmt.Printf("%d cloud account secrets has been re-encrypted\n", count)
+
+	count, err = reEncryptSubscriptionSecret(ctx, db, envelope, dryrunflag)
+	if err != nil {
+		fmt.Printf("re-encrypt error: %s\n", err.Error())
+		os.Exit(2)
+	}
+	fmt.Printf("%d subscription secrets has been re-encrypted\n", count)
+
+	os.Exit(0)
+	return nil
+}
+
+func reEncryptCloudTaskSecret(ctx context.Context, db dal.RDB, envelope *cryptor.Envelope, dryrun bool) (int, error) {
+	tasks := make([]map[string]interface{}, 0)
+	if err := db.Table(common.BKTableNameCloudTask).Find(nil).Fields(common.BKCloudTaskID, common.BKCloudSecretKey).All(ctx, &tasks); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, task := range tasks {
+		secretKey, ok := task[common.BKCloudSecretKey].(string)
+		if !ok || secretKey == "" || !envelope.NeedReEncrypt(secretKey) {
+			continue
+		}
+
+		encrypted, err := envelope.ReEncrypt(secretKey)
+		if err != nil {
+			return count, fmt.Errorf("re-encrypt secret of task %v failed, err: %v", task[common.BKCloudTaskID], err)
+		}
+
+		fmt.Printf("re-encrypt secret of task %v\n", task[common.BKCloudTaskID])
+		if !dryrun {
+			cond := map[string]interface{}{common.BKCloudTaskID: task[common.BKCloudTaskID]}
+			data := map[string]interface{}{common.BKCloudSecretKey: encrypted}
+			if err := db.Table(common.BKTableNameCloudTask).Update(ctx, cond, data); err != nil {
+				return count, fmt.Errorf("update secret of task %v failed, err: %v", task[common.BKCloudTaskID], err)
+			}
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// reEncryptSubscriptionSecret seal the subscription secrets with the active master key,
+// the secrets saved before they are sealed are plain text rather than base64 encoded.
+func reEncryptSubscriptionSecret(ctx context.Context, db dal.RDB, envelope *cryptor.Envelope, dryrun bool) (int, error) {
+	subs := make([]metadata.Subscription, 0)
+	if err := db.Table(common.BKTableNameSubscription).Find(nil).Fields(common.BKSubscriptionIDField, "secret").All(ctx, &subs); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, sub := range subs {
+		if sub.Secret == "" || !envelope.NeedReEncrypt(sub.Secret) {
+			continue
+		}
+
+		var encrypted string
+		var err error
+		if cryptor.IsEncrypted(sub.Secret) {
+			encrypted, err = envelope.ReEncrypt(sub.Secret)
+		} else {
+			encrypted, err = envelope.Encrypt(sub.Secret)
+		}
+		if err != nil {
+			return count, fmt.Errorf("re-encrypt secret of subscription %d failed, err: %v", sub.SubscriptionID, err)
+		}
+
+		fmt.Printf("re-encrypt secret of subscription %d\n", sub.SubscriptionID)
+		if !dryrun {
+			cond := map[string]interface{}{common.BKSubscriptionIDField: sub.SubscriptionID}
+			data := map[string]interface{}{"secret": encrypted}
+			if err := db.Table(common.BKTableNameSubscription).Update(ctx, cond, data); err != nil {
+				return count, fmt.Errorf("update secret of subscription %d failed, err: %v", sub.SubscriptionID, err)
+			}
+		}
+		count++
+	}
+
+	return count, nil
+}
@@ -0,0 +1,294 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dbindex compares the indexes declared in common.TableIndexes and derived from the
+// unique constraints of the models with the existing ones, and reconciles the drift.
+package dbindex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+)
+
+// uniqueIndexPrefix the name prefix of the indexes derived from the unique constraints
+const uniqueIndexPrefix = "bkcc_unique_"
+
+// the actions to reconcile the drift
+const (
+	// ActionCreate the declared index is missing
+	ActionCreate = "create"
+	// ActionDrop the existing index is not declared
+	ActionDrop = "drop"
+	// ActionReport the existing index has the declared keys but differs in uniqueness,
+	// which can't be fixed automatically as rebuilding a unique index may fail
+	ActionReport = "report"
+)
+
+// Drift the difference between an index declared and the existing one
+type Drift struct {
+	Table  string    `json:"table"`
+	Action string    `json:"action"`
+	Index  dal.Index `json:"index"`
+	Reason string    `json:"reason"`
+	// Applied whether the action has been applied, always false in dry run mode
+	Applied bool `json:"applied"`
+}
+
+// Option the option of the reconciliation
+type Option struct {
+	// DryRun only report the drift
+	DryRun bool `json:"dry_run"`
+	// DropTables the tables whose existing indexes which are not declared are dropped,
+	// the undeclared indexes of the other tables are only reported
+	DropTables []string `json:"drop_tables"`
+}
+
+// Expected returns the indexes which should exist on the tables
+func Expected(ctx context.Context, db dal.RDB) (map[string][]dal.Index, error) {
+	expected := make(map[string][]dal.Index)
+	for table, indexes := range common.TableIndexes {
+		for _, index := range indexes {
+			keys := make([]dal.IndexKey, 0, len(index.Keys))
+			for _, key := range index.Keys {
+				keys = append(keys, dal.IndexKey(key))
+			}
+			name := index.Name
+			if name == "" {
+				name = defaultIndexName(keys)
+			}
+			expected[table] = append(expected[table], dal.Index{
+				Name:               name,
+				Keys:               keys,
+				Unique:             index.Unique,
+				Background:         true,
+				ExpireAfterSeconds: index.ExpireAfterSeconds,
+			})
+		}
+	}
+
+	uniqueIndexes, err := uniqueDerivedIndexes(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	for table, indexes := range uniqueIndexes {
+		expected[table] = append(expected[table], indexes...)
+	}
+	return expected, nil
+}
+
+// uniqueDerivedIndexes returns the indexes of the unique constraints of the models, so that the
+// uniqueness check of the instances can be served by the index. the indexes are not unique,
+// because the instances of all the custom models share one table and the empty values are allowed
+// by the constraints which are not must check. the constraints with association keys are skipped.
+func uniqueDerivedIndexes(ctx context.Context, db dal.RDB) (map[string][]dal.Index, error) {
+	uniques := make([]metadata.ObjectUnique, 0)
+	if err := db.Table(common.BKTableNameObjUnique).Find(nil).All(ctx, &uniques); err != nil {
+		return nil, fmt.Errorf("get the unique constraints failed, err: %v", err)
+	}
+
+	attrIDs := make([]uint64, 0)
+	for _, unique := range uniques {
+		for _, key := range unique.Keys {
+			if key.Kind == metadata.UniqueKeyKindProperty {
+				attrIDs = append(attrIDs, key.ID)
+			}
+		}
+	}
+	attrs := make([]metadata.Attribute, 0)
+	if len(attrIDs) > 0 {
+		cond := map[string]interface{}{common.BKFieldID: map[string]interface{}{common.BKDBIN: attrIDs}}
+		if err := db.Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &attrs); err != nil {
+			return nil, fmt.Errorf("get the attributes of the unique constraints failed, err: %v", err)
+		}
+	}
+	propertyIDs := make(map[uint64]string, len(attrs))
+	for _, attr := range attrs {
+		propertyIDs[uint64(attr.ID)] = attr.PropertyID
+	}
+
+	indexes := make(map[string][]dal.Index)
+	for _, unique := range uniques {
+		table := common.GetInstTableName(unique.ObjID)
+		keys := make([]dal.IndexKey, 0, len(unique.Keys)+1)
+		if table == common.BKTableNameBaseInst {
+			keys = append(keys, dal.IndexKey{Key: common.BKObjIDField, Direction: 1})
+		}
+		derivable := len(unique.Keys) > 0
+		for _, key := range unique.Keys {
+			propertyID, ok := propertyIDs[key.ID]
+			if key.Kind != metadata.UniqueKeyKindProperty || !ok {
+				derivable = false
+				break
+			}
+			keys = append(keys, dal.IndexKey{Key: propertyID, Direction: 1})
+		}
+		if !derivable {
+			blog.V(3).Infof("skip the index of the unique constraint %d of %s", unique.ID, unique.ObjID)
+			continue
+		}
+		indexes[table] = append(indexes[table], dal.Index{
+			Name:       fmt.Sprintf("%s%d", uniqueIndexPrefix, unique.ID),
+			Keys:       keys,
+			Background: true,
+		})
+	}
+	return indexes, nil
+}
+
+// Diff compare the expected indexes with the existing ones, the indexes are matched by the keys
+// in order, so an existing compound index with the keys in another order is not the declared one
+func Diff(ctx context.Context, db dal.RDB, expected map[string][]dal.Index) ([]Drift, error) {
+	tables := make([]string, 0, len(expected))
+	for table := range expected {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	drifts := make([]Drift, 0)
+	for _, table := range tables {
+		existing, err := existingIndexes(ctx, db, table)
+		if err != nil {
+			return nil, err
+		}
+		existingByKeys := make(map[string]dal.Index, len(existing))
+		for _, index := range existing {
+			existingByKeys[keysSignature(index.Keys)] = index
+		}
+
+		declared := make(map[string]bool)
+		for _, index := range expected[table] {
+			signature := keysSignature(index.Keys)
+			if declared[signature] {
+				// declared by the table spec and derived from an unique constraint either
+				continue
+			}
+			declared[signature] = true
+			exist, ok := existingByKeys[signature]
+			switch {
+			case !ok:
+				drifts = append(drifts, Drift{Table: table, Action: ActionCreate, Index: index, Reason: "missing"})
+			case exist.Unique != index.Unique:
+				drifts = append(drifts, Drift{Table: table, Action: ActionReport, Index: exist,
+					Reason: fmt.Sprintf("unique is %v, but %v is declared", exist.Unique, index.Unique)})
+			case exist.ExpireAfterSeconds != index.ExpireAfterSeconds:
+				drifts = append(drifts, Drift{Table: table, Action: ActionReport, Index: exist,
+					Reason: fmt.Sprintf("ttl is %ds, but %ds is declared", exist.ExpireAfterSeconds, index.ExpireAfterSeconds)})
+			}
+		}
+
+		for _, index := range existing {
+			if index.Name == "_id_" || declared[keysSignature(index.Keys)] {
+				continue
+			}
+			drifts = append(drifts, Drift{Table: table, Action: ActionDrop, Index: index, Reason: "not declared"})
+		}
+	}
+	return drifts, nil
+}
+
+func existingIndexes(ctx context.Context, db dal.RDB, table string) ([]dal.Index, error) {
+	exists, err := db.HasTable(table)
+	if err != nil {
+		return nil, fmt.Errorf("check table %s failed, err: %v", table, err)
+	}
+	if !exists {
+		return []dal.Index{}, nil
+	}
+	indexes, err := db.Table(table).Indexes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get the indexes of %s failed, err: %v", table, err)
+	}
+	return indexes, nil
+}
+
+// keysSignature returns the keys with the directions in order, such as "bk_biz_id:1,op_time:-1"
+func keysSignature(keys []dal.IndexKey) string {
+	items := make([]string, 0, len(keys))
+	for _, key := range keys {
+		direction := 1
+		if key.Direction < 0 {
+			direction = -1
+		}
+		items = append(items, fmt.Sprintf("%s:%d", key.Key, direction))
+	}
+	return strings.Join(items, ",")
+}
+
+// defaultIndexName returns the name built by mongodb, such as "bk_biz_id_1_op_time_-1"
+func defaultIndexName(keys []dal.IndexKey) string {
+	return strings.NewReplacer(":", "_", ",", "_").Replace(keysSignature(keys))
+}
+
+// Reconcile diff the indexes and apply the create and drop actions, the drop actions are only
+// applied to the tables in opt.DropTables. nothing is changed in dry run mode.
+func Reconcile(ctx context.Context, db dal.RDB, opt Option) ([]Drift, error) {
+	expected, err := Expected(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	drifts, err := Diff(ctx, db, expected)
+	if err != nil {
+		return nil, err
+	}
+	if opt.DryRun {
+		return drifts, nil
+	}
+
+	dropTables := make(map[string]bool, len(opt.DropTables))
+	for _, table := range opt.DropTables {
+		dropTables[table] = true
+	}
+	for idx := range drifts {
+		drift := &drifts[idx]
+		switch drift.Action {
+		case ActionCreate:
+			if err := createIndex(ctx, db, drift.Table, drift.Index); err != nil {
+				return drifts, err
+			}
+		case ActionDrop:
+			if !dropTables[drift.Table] {
+				continue
+			}
+			if err := db.Table(drift.Table).DropIndex(ctx, drift.Index.Name); err != nil {
+				return drifts, fmt.Errorf("drop index %s of %s failed, err: %v", drift.Index.Name, drift.Table, err)
+			}
+		default:
+			continue
+		}
+		blog.Infof("%s index %s %v of %s", drift.Action, drift.Index.Name, drift.Index.Keys, drift.Table)
+		drift.Applied = true
+	}
+	return drifts, nil
+}
+
+func createIndex(ctx context.Context, db dal.RDB, table string, index dal.Index) error {
+	exists, err := db.HasTable(table)
+	if err != nil {
+		return fmt.Errorf("check table %s failed, err: %v", table, err)
+	}
+	if !exists {
+		if err := db.CreateTable(table); err != nil && !db.IsDuplicatedError(err) {
+			return fmt.Errorf("create table %s failed, err: %v", table, err)
+		}
+	}
+	if err := db.Table(table).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+		return fmt.Errorf("create index %v of %s failed, err: %v", index.Keys, table, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dbindex
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	db := memory.New()
+
+	require.NoError(t, db.Table(common.BKTableNameObjAttDes).Insert(ctx, mapstr.MapStr{
+		common.BKFieldID: 1, common.BKObjIDField: "switch", common.BKPropertyIDField: "serial",
+	}))
+	require.NoError(t, db.Table(common.BKTableNameObjUnique).Insert(ctx, []mapstr.MapStr{
+		{"id": 7, common.BKObjIDField: "switch", "keys": []metadata.UniqueKey{{Kind: metadata.UniqueKeyKindProperty, ID: 1}}},
+		{"id": 8, common.BKObjIDField: "switch", "keys": []metadata.UniqueKey{{Kind: metadata.UniqueKeyKindAssociation, ID: 1}}},
+	}))
+
+	hostTable := db.Table(common.BKTableNameBaseHost)
+	require.NoError(t, db.CreateTable(common.BKTableNameBaseHost))
+	require.NoError(t, hostTable.CreateIndex(ctx, dal.Index{Name: "bk_host_id_1", Keys: []dal.IndexKey{{Key: common.BKHostIDField, Direction: 1}}}))
+	require.NoError(t, hostTable.CreateIndex(ctx, dal.Index{Name: "bk_os_type_1", Keys: []dal.IndexKey{{Key: "bk_os_type", Direction: 1}}}))
+	// the compound index with the keys in another order is not the declared one
+	opTable := db.Table(common.BKTableNameOperationLog)
+	require.NoError(t, db.CreateTable(common.BKTableNameOperationLog))
+	require.NoError(t, opTable.CreateIndex(ctx, dal.Index{Name: "op_time_-1_bk_supplier_account_1",
+		Keys: []dal.IndexKey{{Key: "op_time", Direction: -1}, {Key: common.BKOwnerIDField, Direction: 1}}}))
+
+	drifts, err := Reconcile(ctx, db, Option{DryRun: true})
+	require.NoError(t, err)
+	actions := map[string]map[string]string{}
+	for _, drift := range drifts {
+		require.False(t, drift.Applied)
+		if actions[drift.Table] == nil {
+			actions[drift.Table] = map[string]string{}
+		}
+		actions[drift.Table][keysSignature(drift.Index.Keys)] = drift.Action
+	}
+	require.Equal(t, ActionDrop, actions[common.BKTableNameBaseHost]["bk_os_type:1"])
+	require.Equal(t, ActionCreate, actions[common.BKTableNameBaseHost]["bk_host_innerip:1"])
+	require.NotContains(t, actions[common.BKTableNameBaseHost], "bk_host_id:1")
+	// the index of the unique constraint with the association key is not derived
+	require.Equal(t, ActionCreate, actions[common.BKTableNameBaseInst]["bk_obj_id:1,serial:1"])
+	require.Len(t, actions[common.BKTableNameBaseInst], len(common.TableIndexes[common.BKTableNameBaseInst])+1)
+	require.Equal(t, ActionDrop, actions[common.BKTableNameOperationLog]["op_time:-1,bk_supplier_account:1"])
+	require.Equal(t, ActionCreate, actions[common.BKTableNameOperationLog]["bk_supplier_account:1,op_time:-1"])
+
+	// nothing changed in dry run mode
+	indexes, err := hostTable.Indexes(ctx)
+	require.NoError(t, err)
+	require.Len(t, indexes, 3)
+
+	// the undeclared indexes are kept without the drop option
+	drifts, err = Reconcile(ctx, db, Option{})
+	require.NoError(t, err)
+	drifts, err = Reconcile(ctx, db, Option{DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, drifts, 2)
+	for _, drift := range drifts {
+		require.Equal(t, ActionDrop, drift.Action)
+	}
+
+	// the drop is only applied to the tables opted in
+	_, err = Reconcile(ctx, db, Option{DropTables: []string{common.BKTableNameBaseHost}})
+	require.NoError(t, err)
+	drifts, err = Reconcile(ctx, db, Option{DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, drifts, 1)
+	require.Equal(t, common.BKTableNameOperationLog, drifts[0].Table)
+	require.Equal(t, "op_time_-1_bk_supplier_account_1", drifts[0].Index.Name)
+}
@@ -35,4 +35,8 @@ import (
 	_ "configcenter/src/scene_server/admin_server/upgrader/x18.12.12.03"
 	_ "configcenter/src/scene_server/admin_server/upgrader/x19.01.18.01"
 	_ "configcenter/src/scene_server/admin_server/upgrader/x19.02.15.10"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.03.05.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.03.05.02"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.03.05.03"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.03.05.04"
 )
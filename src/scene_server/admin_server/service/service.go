@@ -25,13 +25,16 @@ import (
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
 	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/instrument"
 )
 
 type Service struct {
 	*backbone.Engine
 	db           dal.RDB
 	ccApiSrvAddr string
-	ctx          context.Context
+	// DBMetric exports the latency histograms of the db operations
+	DBMetric instrument.Collector
+	ctx      context.Context
 }
 
 func NewService(ctx context.Context) *Service {
@@ -58,7 +61,12 @@ func (s *Service) WebService() *restful.WebService {
 	ws.Route(ws.POST("/migrate/{distribution}/{ownerID}").To(s.migrate))
 	ws.Route(ws.POST("/migrate/system/hostcrossbiz/{ownerID}").To(s.Set))
 	ws.Route(ws.POST("/clear").To(s.clear))
+	ws.Route(ws.GET("/slowquery").To(s.searchSlowQuery))
+	ws.Route(ws.POST("/slowquery/explain").To(s.explainQuery))
+	ws.Route(ws.POST("/index/reconcile").To(s.reconcileIndex))
+	ws.Route(ws.GET("/association/mapping/violations").To(s.scanAssociationMapping))
 	ws.Route(ws.GET("/healthz").To(s.Healthz))
+	s.DBMetric.Register(ws, types.CC_MODULE_MIGRATE)
 
 	return ws
 }
@@ -0,0 +1,163 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/instrument"
+)
+
+// defaultSlowQueryLimit the default number of the slowest query shapes to list
+const defaultSlowQueryLimit = 20
+
+// searchSlowQuery list the slowest query shapes recorded by all the processes, and suggest the missing indexes
+func (s *Service) searchSlowQuery(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ctx := util.GetDBContext(context.Background(), pheader)
+
+	limit := defaultSlowQueryLimit
+	if limitStr := req.QueryParameter("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "limit")})
+			return
+		}
+	}
+
+	records := make([]metadata.SlowQuery, 0)
+	if err := s.db.Table(common.BKTableNameSlowQuery).Find(nil).All(ctx, &records); err != nil {
+		blog.Errorf("search slow queries failed, err: %v", err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	shapes := summarizeSlowQueries(records, limit)
+	tableIndexes := map[string][][]string{}
+	for idx := range shapes {
+		table := shapes[idx].Table
+		if _, ok := tableIndexes[table]; !ok {
+			tableIndexes[table] = indexKeys(ctx, s.db, table)
+		}
+		shapes[idx].SuggestedIndex = instrument.SuggestIndex(shapes[idx].EqualFields, shapes[idx].RangeFields,
+			shapes[idx].Sort, tableIndexes[table])
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(shapes))
+}
+
+// explainQuery returns the execution plan of the query
+func (s *Service) explainQuery(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ctx := util.GetDBContext(context.Background(), pheader)
+
+	option := metadata.ExplainQueryOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&option); err != nil {
+		blog.Errorf("explain query, decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if option.Table == "" {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, "table")})
+		return
+	}
+
+	explainer, ok := s.db.(dal.Explainer)
+	if !ok {
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: dal.ErrNotImplemented})
+		return
+	}
+	plan := mapstr.MapStr{}
+	if err := explainer.Explain(ctx, option.Table, option.Filter, option.Sort, &plan); err != nil {
+		blog.Errorf("explain query %+v failed, err: %v", option, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(plan))
+}
+
+// summarizeSlowQueries merge the records of the same shape from the processes, returns the
+// slowest shapes by the average latency
+func summarizeSlowQueries(records []metadata.SlowQuery, limit int) []metadata.SlowQueryShape {
+	merged := make(map[string]*metadata.SlowQueryShape)
+	for _, record := range records {
+		shape, ok := merged[record.ShapeID]
+		if !ok {
+			shape = &metadata.SlowQueryShape{SlowQuery: record}
+			shape.Process = ""
+			merged[record.ShapeID] = shape
+			continue
+		}
+		shape.Count += record.Count
+		shape.TotalMillis += record.TotalMillis
+		if record.MaxMillis > shape.MaxMillis {
+			shape.MaxMillis = record.MaxMillis
+		}
+		if record.LastTime.After(shape.LastTime) {
+			shape.LastCaller = record.LastCaller
+			shape.LastRid = record.LastRid
+			shape.LastTime = record.LastTime
+		}
+	}
+
+	shapes := make([]metadata.SlowQueryShape, 0, len(merged))
+	for _, shape := range merged {
+		if shape.Count > 0 {
+			shape.AvgMillis = shape.TotalMillis / shape.Count
+		}
+		shapes = append(shapes, *shape)
+	}
+	sort.Slice(shapes, func(i, j int) bool {
+		if shapes[i].AvgMillis != shapes[j].AvgMillis {
+			return shapes[i].AvgMillis > shapes[j].AvgMillis
+		}
+		return shapes[i].ShapeID < shapes[j].ShapeID
+	})
+	if len(shapes) > limit {
+		shapes = shapes[:limit]
+	}
+	return shapes
+}
+
+// indexKeys returns the keys of the indexes of the table, in the order of the index keys
+func indexKeys(ctx context.Context, db dal.RDB, table string) [][]string {
+	indexes, err := db.Table(table).Indexes(ctx)
+	if err != nil {
+		blog.Warnf("get the indexes of %s failed, err: %v", table, err)
+		return nil
+	}
+	keys := make([][]string, 0, len(indexes))
+	for _, index := range indexes {
+		indexKeys := make([]string, 0, len(index.Keys))
+		for _, key := range index.Keys {
+			indexKeys = append(indexKeys, key.Key)
+		}
+		keys = append(keys, indexKeys)
+	}
+	return keys
+}
@@ -43,95 +43,95 @@ func createTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) (err er
 
 var tables = map[string][]dal.Index{
 	"cc_ApplicationBase": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_biz_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_biz_name": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"default": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_biz_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_biz_name", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "default", Direction: 1}}, Background: true},
 	},
 
 	"cc_HostBase": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_host_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_host_name": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_host_innerip": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_host_outerip": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_host_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_host_name", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_host_innerip", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_host_outerip", Direction: 1}}, Background: true},
 	},
 	"cc_ModuleBase": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_module_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_module_name": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"default": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_biz_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_supplier_account": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_set_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_parent_id": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_module_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_module_name", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "default", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_biz_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_supplier_account", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_set_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_parent_id", Direction: 1}}, Background: true},
 	},
 	"cc_ModuleHostConfig": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_biz_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_host_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_module_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_set_id": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_biz_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_host_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_module_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_set_id", Direction: 1}}, Background: true},
 	},
 	"cc_ObjAsst": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_obj_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_asst_obj_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_supplier_account": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_obj_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_asst_obj_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_supplier_account", Direction: 1}}, Background: true},
 	},
 	"cc_ObjAttDes": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_obj_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_supplier_account": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"id": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_obj_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_supplier_account", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "id", Direction: 1}}, Background: true},
 	},
 	"cc_ObjClassification": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_classification_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_classification_name": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_classification_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_classification_name", Direction: 1}}, Background: true},
 	},
 	"cc_ObjDes": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_obj_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_classification_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_obj_name": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_supplier_account": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_obj_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_classification_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_obj_name", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_supplier_account", Direction: 1}}, Background: true},
 	},
 	"cc_ObjectBase": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_obj_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_supplier_account": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_inst_id": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_obj_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_supplier_account", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_inst_id", Direction: 1}}, Background: true},
 	},
 	"cc_OperationLog": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"op_target": 1, "inst_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_supplier_account": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_biz_id": 1, "bk_supplier_account": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"ext_key": 1, "bk_supplier_account": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "op_target", Direction: 1}, {Key: "inst_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_supplier_account", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_biz_id", Direction: 1}, {Key: "bk_supplier_account", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "ext_key", Direction: 1}, {Key: "bk_supplier_account", Direction: 1}}, Background: true},
 	},
 	"cc_PlatBase": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_supplier_account": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_supplier_account", Direction: 1}}, Background: true},
 	},
 	"cc_Proc2Module": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_biz_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_process_id": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_biz_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_process_id", Direction: 1}}, Background: true},
 	},
 	"cc_Process": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_process_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_biz_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_supplier_account": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_process_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_biz_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_supplier_account", Direction: 1}}, Background: true},
 	},
 	"cc_PropertyGroup": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_obj_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_supplier_account": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_group_id": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_obj_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_supplier_account", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_group_id", Direction: 1}}, Background: true},
 	},
 	"cc_SetBase": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_set_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_parent_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_biz_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_supplier_account": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_set_name": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_set_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_parent_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_biz_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_supplier_account", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_set_name", Direction: 1}}, Background: true},
 	},
 	"cc_Subscription": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"subscription_id": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "subscription_id", Direction: 1}}, Background: true},
 	},
 	"cc_TopoGraphics": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"scope_type": 1, "scope_id": 1, "node_type": 1, "bk_obj_id": 1, "bk_inst_id": 1}, Background: true, Unique: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "scope_type", Direction: 1}, {Key: "scope_id", Direction: 1}, {Key: "node_type", Direction: 1}, {Key: "bk_obj_id", Direction: 1}, {Key: "bk_inst_id", Direction: 1}}, Background: true, Unique: true},
 	},
 	"cc_InstAsst": []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_obj_id": 1, "bk_inst_id": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_obj_id", Direction: 1}, {Key: "bk_inst_id", Direction: 1}}, Background: true},
 	},
 
 	"cc_Privilege":          []dal.Index{},
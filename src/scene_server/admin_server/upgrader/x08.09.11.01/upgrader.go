@@ -22,7 +22,7 @@ import (
 
 func addOperationLogIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) (err error) {
 
-	index := dal.Index{Name: "", Keys: map[string]int32{"opt_time": 1}, Background: true}
+	index := dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "opt_time", Direction: 1}}, Background: true}
 
 	if err = db.Table(common.BKTableNameOperationLog).CreateIndex(ctx, index); err != nil && db.IsDuplicatedError(err) {
 		return err
@@ -42,13 +42,13 @@ func createTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) (err er
 
 var tables = map[string][]dal.Index{
 	common.BKTableNameNetcollectDevice: []dal.Index{
-		{Keys: map[string]int32{"device_id": 1}, Background: true},
-		{Keys: map[string]int32{"device_name": 1}, Background: true},
-		{Keys: map[string]int32{"bk_supplier_account": 1}, Background: true},
+		{Keys: []dal.IndexKey{{Key: "device_id", Direction: 1}}, Background: true},
+		{Keys: []dal.IndexKey{{Key: "device_name", Direction: 1}}, Background: true},
+		{Keys: []dal.IndexKey{{Key: "bk_supplier_account", Direction: 1}}, Background: true},
 	},
 
 	common.BKTableNameNetcollectProperty: []dal.Index{
-		{Keys: map[string]int32{"netcollect_property_id": 1}, Background: true},
-		{Keys: map[string]int32{"bk_supplier_account": 1}, Background: true},
+		{Keys: []dal.IndexKey{{Key: "netcollect_property_id", Direction: 1}}, Background: true},
+		{Keys: []dal.IndexKey{{Key: "bk_supplier_account", Direction: 1}}, Background: true},
 	},
 }
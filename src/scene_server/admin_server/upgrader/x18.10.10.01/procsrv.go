@@ -37,7 +37,7 @@ func addProcOpTaskTable(ctx context.Context, db dal.RDB, conf *upgrader.Config)
 		}
 	}
 	indexs := []dal.Index{
-		dal.Index{Name: "idx_taskID_gseTaskID", Keys: map[string]int32{common.BKTaskIDField: 1, common.BKGseOpTaskIDField: 1}, Background: true},
+		dal.Index{Name: "idx_taskID_gseTaskID", Keys: []dal.IndexKey{{Key: common.BKTaskIDField, Direction: 1}, {Key: common.BKGseOpTaskIDField, Direction: 1}}, Background: true},
 	}
 	for _, index := range indexs {
 
@@ -60,9 +60,9 @@ func addProcInstanceModelTable(ctx context.Context, db dal.RDB, conf *upgrader.C
 		}
 	}
 	indexs := []dal.Index{
-		dal.Index{Name: "idx_bkBizID_bkSetID_bkModuleID_bkHostInstanceID", Keys: map[string]int32{common.BKAppIDField: 1, common.BKSetIDField: 1, common.BKModuleIDField: 1, "bk_host_instance_id": 1}, Background: true},
-		dal.Index{Name: "idx_bkBizID_bkHostID", Keys: map[string]int32{common.BKAppIDField: 1, common.BKHostIDField: 1}, Background: true},
-		dal.Index{Name: "idx_bkBizID_bkProcessID", Keys: map[string]int32{common.BKAppIDField: 1, common.BKProcessIDField: 1}, Background: true},
+		dal.Index{Name: "idx_bkBizID_bkSetID_bkModuleID_bkHostInstanceID", Keys: []dal.IndexKey{{Key: common.BKAppIDField, Direction: 1}, {Key: common.BKSetIDField, Direction: 1}, {Key: common.BKModuleIDField, Direction: 1}, {Key: "bk_host_instance_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "idx_bkBizID_bkHostID", Keys: []dal.IndexKey{{Key: common.BKAppIDField, Direction: 1}, {Key: common.BKHostIDField, Direction: 1}}, Background: true},
+		dal.Index{Name: "idx_bkBizID_bkProcessID", Keys: []dal.IndexKey{{Key: common.BKAppIDField, Direction: 1}, {Key: common.BKProcessIDField, Direction: 1}}, Background: true},
 	}
 	for _, index := range indexs {
 		if err = db.Table(tableName).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
@@ -83,9 +83,9 @@ func addProcInstanceDetailTable(ctx context.Context, db dal.RDB, conf *upgrader.
 		}
 	}
 	indexs := []dal.Index{
-		dal.Index{Name: "idx_bkBizID_bkModuleID_bkProcessID", Keys: map[string]int32{common.BKAppIDField: 1, common.BKModuleIDField: 1, common.BKProcessIDField: 1}, Background: true},
-		dal.Index{Name: "idx_bkBizID_status", Keys: map[string]int32{common.BKAppIDField: 1, common.BKStatusField: 1}, Background: true},
-		dal.Index{Name: "idx_bkBizID_bkHostID", Keys: map[string]int32{common.BKAppIDField: 1, common.BKHostIDField: 1}, Background: true},
+		dal.Index{Name: "idx_bkBizID_bkModuleID_bkProcessID", Keys: []dal.IndexKey{{Key: common.BKAppIDField, Direction: 1}, {Key: common.BKModuleIDField, Direction: 1}, {Key: common.BKProcessIDField, Direction: 1}}, Background: true},
+		dal.Index{Name: "idx_bkBizID_status", Keys: []dal.IndexKey{{Key: common.BKAppIDField, Direction: 1}, {Key: common.BKStatusField, Direction: 1}}, Background: true},
+		dal.Index{Name: "idx_bkBizID_bkHostID", Keys: []dal.IndexKey{{Key: common.BKAppIDField, Direction: 1}, {Key: common.BKHostIDField, Direction: 1}}, Background: true},
 	}
 	for _, index := range indexs {
 		if err = db.Table(tableName).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
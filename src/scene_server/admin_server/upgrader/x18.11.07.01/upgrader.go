@@ -23,8 +23,8 @@ func addCloudTaskTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) e
 	}
 
 	indexs := []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_task_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_task_name": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_task_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_task_name", Direction: 1}}, Background: true},
 	}
 
 	for _, index := range indexs {
@@ -49,8 +49,8 @@ func addCloudResourceConfirmTable(ctx context.Context, db dal.RDB, conf *upgrade
 	}
 
 	indexs := []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_resource_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_obj_id": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_resource_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_obj_id", Direction: 1}}, Background: true},
 	}
 
 	for _, index := range indexs {
@@ -76,8 +76,8 @@ func addCloudSyncHistoryTable(ctx context.Context, db dal.RDB, conf *upgrader.Co
 	}
 
 	indexs := []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_task_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"bk_history_id": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_task_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_history_id", Direction: 1}}, Background: true},
 	}
 
 	for _, index := range indexs {
@@ -103,8 +103,8 @@ func addCloudConfirmHistoryTable(ctx context.Context, db dal.RDB, conf *upgrader
 	}
 
 	indexs := []dal.Index{
-		dal.Index{Name: "", Keys: map[string]int32{"bk_resource_id": 1}, Background: true},
-		dal.Index{Name: "", Keys: map[string]int32{"confirm_history_id": 1}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "bk_resource_id", Direction: 1}}, Background: true},
+		dal.Index{Name: "", Keys: []dal.IndexKey{{Key: "confirm_history_id", Direction: 1}}, Background: true},
 	}
 
 	for _, index := range indexs {
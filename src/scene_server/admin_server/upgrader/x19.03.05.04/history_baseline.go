@@ -0,0 +1,171 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package x19_03_05_04
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/history"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+const baselineBatchSize = 500
+
+// addInstHistoryBaseline record a baseline version for each existing instance, the history starts from the upgrade.
+// the instances having the baseline are skipped, so that the upgrade can be run again after it failed halfway.
+func addInstHistoryBaseline(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	op := history.Operation{Operator: conf.User}
+	objIDs := []string{
+		common.BKInnerObjIDApp,
+		common.BKInnerObjIDSet,
+		common.BKInnerObjIDModule,
+		common.BKInnerObjIDHost,
+		common.BKInnerObjIDProc,
+		common.BKInnerObjIDPlat,
+		common.BKInnerObjIDObject,
+	}
+	for _, objID := range objIDs {
+		tableName := common.GetInstTableName(objID)
+		insts := make([]mapstr.MapStr, 0)
+		err := dal.IterateBatch(ctx, db.Table(tableName).Find(nil), baselineBatchSize, &insts, func() error {
+			instObjIDs := make([]string, 0)
+			objInsts := make(map[string][]mapstr.MapStr)
+			instIDs := make([]int64, 0, len(insts))
+			keys := make([]instKey, 0, len(insts))
+			for _, inst := range insts {
+				instObjID := objID
+				if common.BKInnerObjIDObject == objID {
+					instObjID = util.GetStrByInterface(inst[common.BKObjIDField])
+				}
+				instID, err := util.GetInt64ByInterface(inst[common.GetInstIDField(instObjID)])
+				if err != nil {
+					blog.Warnf("add the baseline history of the instance %#v in %s failed, skip it, err: %v", inst, tableName, err)
+					keys = append(keys, instKey{})
+					continue
+				}
+				instIDs = append(instIDs, instID)
+				keys = append(keys, instKey{objID: instObjID, instID: instID})
+			}
+
+			recorded, err := getRecordedBaselines(ctx, db, instIDs)
+			if err != nil {
+				return err
+			}
+			for idx, inst := range insts {
+				key := keys[idx]
+				if "" == key.objID || recorded[key] {
+					continue
+				}
+				if _, ok := objInsts[key.objID]; !ok {
+					instObjIDs = append(instObjIDs, key.objID)
+				}
+				objInsts[key.objID] = append(objInsts[key.objID], inst)
+			}
+			for _, instObjID := range instObjIDs {
+				err := history.RecordInst(ctx, db, op, instObjID, metadata.InstHistoryActionBaseline, objInsts[instObjID])
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			blog.Errorf("add the baseline history of the instances in %s failed, err: %v", tableName, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// instKey the key of an instance in the history
+type instKey struct {
+	objID  string
+	instID int64
+}
+
+// getRecordedBaselines returns the instances whose baseline has been recorded
+func getRecordedBaselines(ctx context.Context, db dal.RDB, instIDs []int64) (map[instKey]bool, error) {
+	recorded := make(map[instKey]bool)
+	if 0 == len(instIDs) {
+		return recorded, nil
+	}
+	cond := mapstr.MapStr{
+		"action":             metadata.InstHistoryActionBaseline,
+		common.BKInstIDField: mapstr.MapStr{common.BKDBIN: instIDs},
+	}
+	histories := make([]metadata.InstHistory, 0)
+	err := db.Table(common.BKTableNameInstHistory).Find(cond).Fields(common.BKObjIDField, common.BKInstIDField).All(ctx, &histories)
+	if err != nil {
+		blog.Errorf("get the recorded baseline history of the instances failed, err: %v", err)
+		return nil, err
+	}
+	for _, item := range histories {
+		recorded[instKey{objID: item.ObjectID, instID: item.InstID}] = true
+	}
+	return recorded, nil
+}
+
+// relationKey the key of a module host relation in the history
+type relationKey struct {
+	appID    int64
+	moduleID int64
+	hostID   int64
+}
+
+// addModuleHostHistoryBaseline record an add change for each existing module host relation, the relations
+// having the history are skipped like the instances.
+func addModuleHostHistoryBaseline(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	op := history.Operation{Operator: conf.User}
+	relations := make([]map[string]interface{}, 0)
+	err := dal.IterateBatch(ctx, db.Table(common.BKTableNameModuleHostConfig).Find(nil), baselineBatchSize, &relations, func() error {
+		hostIDs := make([]interface{}, 0, len(relations))
+		for _, relation := range relations {
+			hostIDs = append(hostIDs, relation[common.BKHostIDField])
+		}
+		cond := mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs}}
+		histories := make([]metadata.ModuleHostHistory, 0)
+		if err := db.Table(common.BKTableNameModuleHostHistory).Find(cond).All(ctx, &histories); err != nil {
+			blog.Errorf("get the recorded history of the module host relations failed, err: %v", err)
+			return err
+		}
+		recorded := make(map[relationKey]bool)
+		for _, item := range histories {
+			recorded[relationKey{appID: item.AppID, moduleID: item.ModuleID, hostID: item.HostID}] = true
+		}
+
+		added := make([]map[string]interface{}, 0, len(relations))
+		for _, relation := range relations {
+			item, err := metadata.NewModuleHostHistory(metadata.ModuleHostHistoryActionAdd, relation)
+			if err != nil {
+				blog.Warnf("add the baseline history of the module host relation %#v failed, skip it, err: %v", relation, err)
+				continue
+			}
+			if recorded[relationKey{appID: item.AppID, moduleID: item.ModuleID, hostID: item.HostID}] {
+				continue
+			}
+			added = append(added, relation)
+		}
+		return history.RecordModuleHost(ctx, db, op, metadata.ModuleHostHistoryActionAdd, added)
+	})
+	if err != nil {
+		blog.Errorf("add the baseline history of the module host relations failed, err: %v", err)
+		return err
+	}
+	return nil
+}
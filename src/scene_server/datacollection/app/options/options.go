@@ -13,6 +13,8 @@
 package options
 
 import (
+	"strings"
+
 	"configcenter/src/common/core/cc/config"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
@@ -47,9 +49,69 @@ type Config struct {
 	DiscoverRedis   SnapRedis
 	NetcollectRedis SnapRedis
 	Esb             esbutil.EsbConfig
+	Analyzers       []AnalyzerConfig
 }
 
 type SnapRedis struct {
 	redis.Config
 	Enable string
 }
+
+// AnalyzerConfig define an analyzer declared in the config, which analyze the
+// messages of the channels in the redis section, such as:
+// [analyzers]
+// names=switch
+// [analyzer-switch]
+// type=jsonmapping
+// channels=switch_report
+// redis=snap-redis
+// mapping=switch
+// the keys except type, channels and redis are the options of the analyzer.
+type AnalyzerConfig struct {
+	Name     string
+	Type     string
+	Channels []string
+	Redis    redis.Config
+	Options  map[string]string
+}
+
+// ParseAnalyzerConfigFromKV returns the analyzers declared in the config
+func ParseAnalyzerConfigFromKV(prefix string, configmap map[string]string) []AnalyzerConfig {
+	cfgs := make([]AnalyzerConfig, 0)
+	for _, name := range strings.Split(configmap[prefix+".names"], ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		section := "analyzer-" + name + "."
+		cfg := AnalyzerConfig{
+			Name:    name,
+			Type:    configmap[section+"type"],
+			Options: make(map[string]string),
+		}
+		for _, channel := range strings.Split(configmap[section+"channels"], ",") {
+			if channel = strings.TrimSpace(channel); channel != "" {
+				cfg.Channels = append(cfg.Channels, channel)
+			}
+		}
+		redisSection := configmap[section+"redis"]
+		if redisSection == "" {
+			redisSection = "snap-redis"
+		}
+		cfg.Redis = redis.ParseConfigFromKV(redisSection, configmap)
+
+		for key, value := range configmap {
+			if !strings.HasPrefix(key, section) {
+				continue
+			}
+			switch option := strings.TrimPrefix(key, section); option {
+			case "type", "channels", "redis":
+			default:
+				cfg.Options[option] = value
+			}
+		}
+		cfgs = append(cfgs, cfg)
+	}
+	return cfgs
+}
@@ -31,6 +31,7 @@ import (
 	"configcenter/src/scene_server/datacollection/datacollection"
 	"configcenter/src/scene_server/datacollection/logics"
 	svc "configcenter/src/scene_server/datacollection/service"
+	"configcenter/src/storage/dal/instrument"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/dal/redis"
@@ -68,10 +69,16 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 			continue
 		}
 
-		instance, err := local.NewMgo(process.Config.MongoDB.BuildURI(), time.Minute)
+		db, err := local.NewMgo(process.Config.MongoDB.BuildURI(), time.Minute)
 		if err != nil {
 			return fmt.Errorf("new mongo client failed, err: %s", err.Error())
 		}
+		// record the latencies and the slow queries of the db operations
+		instance := instrument.New(db, instrument.Options{
+			SlowThreshold: process.Config.MongoDB.GetSlowQueryThreshold(),
+			Process:       instrument.ProcessName(types.CC_MODULE_DATACOLLECTION, svrInfo),
+			FlushInterval: time.Minute,
+		})
 
 		esbChan := make(chan esbutil.EsbConfig, 1)
 		esbChan <- process.Config.Esb
@@ -81,8 +88,9 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		}
 
 		process.Service.Logics = logics.NewLogics(ctx, service.Engine, instance, esb)
+		process.Service.DBMetric.Swap(instance)
 
-		err = datacollection.NewDataCollection(ctx, process.Config, process.Core).Run()
+		err = datacollection.NewDataCollection(ctx, process.Config, process.Core, instance).Run()
 		if err != nil {
 			return fmt.Errorf("run datacollection routine failed %s", err.Error())
 		}
@@ -140,6 +148,8 @@ func (h *DCServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 		h.Config.NetcollectRedis.Config = netcollectRedisConf
 		h.Config.SnapRedis.Enable = current.ConfigMap[netcollectPrefix+".enable"]
 
+		h.Config.Analyzers = options.ParseAnalyzerConfigFromKV("analyzers", current.ConfigMap)
+
 		esbPrefix := "esb"
 		h.Config.Esb.Addrs = current.ConfigMap[esbPrefix+".addr"]
 		h.Config.Esb.AppCode = current.ConfigMap[esbPrefix+".appCode"]
@@ -23,11 +23,7 @@ import (
 	"configcenter/src/common/backbone"
 	"configcenter/src/common/blog"
 	"configcenter/src/scene_server/datacollection/app/options"
-	"configcenter/src/scene_server/datacollection/datacollection/hostsnap"
-	"configcenter/src/scene_server/datacollection/datacollection/middleware"
-	"configcenter/src/scene_server/datacollection/datacollection/netcollect"
 	"configcenter/src/storage/dal"
-	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/dal/redis"
 )
 
@@ -38,8 +34,8 @@ type DataCollection struct {
 	ctx context.Context
 }
 
-func NewDataCollection(ctx context.Context, config *options.Config, backbone *backbone.Engine) *DataCollection {
-	return &DataCollection{ctx: ctx, Config: config, Engine: backbone}
+func NewDataCollection(ctx context.Context, config *options.Config, backbone *backbone.Engine, db dal.RDB) *DataCollection {
+	return &DataCollection{ctx: ctx, Config: config, Engine: backbone, db: db}
 }
 
 func (d *DataCollection) Run() error {
@@ -53,13 +49,6 @@ func (d *DataCollection) Run() error {
 	}
 	blog.Infof("[datacollect][RUN]connected to cc redis %+v", d.Config.CCRedis)
 
-	db, err := local.NewMgo(d.Config.MongoDB.BuildURI(), time.Minute)
-	if err != nil {
-		blog.Errorf("[datacollection][RUN] connect mongo failed: %v", err)
-		return fmt.Errorf("connect mongo server failed %s", err.Error())
-	}
-	d.db = db
-
 	var defaultAppID string
 	for {
 		defaultAppID, err = d.getDefaultAppID(d.ctx)
@@ -81,8 +70,11 @@ func (d *DataCollection) Run() error {
 		}
 		blog.Infof("[datacollect][RUN]connected to snap-redis %+v", d.Config.SnapRedis.Config)
 		snapChanName := d.getSnapChanName(defaultAppID)
-		hostsnapCollector := hostsnap.NewHostSnap(d.ctx, rediscli, db)
-		snapPorter := BuildChanPorter("hostsnap", hostsnapCollector, rediscli, snapcli, snapChanName, hostsnap.MockMessage)
+		hostsnapCollector, mockMessage, err := NewAnalyzer(AnalyzerHostSnap, d.analyzerContext(rediscli, nil))
+		if err != nil {
+			return err
+		}
+		snapPorter := BuildChanPorter("hostsnap", hostsnapCollector, rediscli, snapcli, snapChanName, mockMessage)
 		man.AddPorter(snapPorter)
 	}
 
@@ -95,8 +87,11 @@ func (d *DataCollection) Run() error {
 		}
 		blog.Infof("[datacollect][RUN]connected to discover-redis %+v", d.Config.DiscoverRedis.Config)
 		discoverChanName := d.getDiscoverChanName(defaultAppID)
-		middlewareCollector := middleware.NewDiscover(d.ctx, rediscli, d.Engine)
-		middlewarePorter := BuildChanPorter("middleware", middlewareCollector, rediscli, discli, discoverChanName, middleware.MockMessage)
+		middlewareCollector, mockMessage, err := NewAnalyzer(AnalyzerMiddleware, d.analyzerContext(rediscli, nil))
+		if err != nil {
+			return err
+		}
+		middlewarePorter := BuildChanPorter("middleware", middlewareCollector, rediscli, discli, discoverChanName, mockMessage)
 		man.AddPorter(middlewarePorter)
 	}
 
@@ -109,11 +104,40 @@ func (d *DataCollection) Run() error {
 		}
 		blog.Infof("[datacollect][RUN]connected to netcollect-redis %+v", d.Config.NetcollectRedis.Config)
 		netdevChanName := d.getNetcollectChanName(defaultAppID)
-		netcollector := netcollect.NewNetcollect(d.ctx, db)
-		netcollectPorter := BuildChanPorter("netcollect", netcollector, rediscli, netcli, netdevChanName, netcollect.MockMessage)
+		netcollector, mockMessage, err := NewAnalyzer(AnalyzerNetcollect, d.analyzerContext(rediscli, nil))
+		if err != nil {
+			return err
+		}
+		netcollectPorter := BuildChanPorter("netcollect", netcollector, rediscli, netcli, netdevChanName, mockMessage)
 		man.AddPorter(netcollectPorter)
 	}
 
+	// the analyzers declared in the config
+	porterNames := map[string]bool{"hostsnap": true, "middleware": true, "netcollect": true}
+	for _, cfg := range d.Config.Analyzers {
+		if porterNames[cfg.Name] {
+			return fmt.Errorf("duplicate analyzer name %s", cfg.Name)
+		}
+		porterNames[cfg.Name] = true
+		if len(cfg.Channels) == 0 {
+			return fmt.Errorf("the channels of analyzer %s is not set", cfg.Name)
+		}
+
+		blog.Infof("[datacollect][RUN]connecting to redis of analyzer %s %+v", cfg.Name, cfg.Redis)
+		chancli, err := redis.NewFromConfig(cfg.Redis)
+		if nil != err {
+			blog.Errorf("[datacollection][RUN] connect redis of analyzer %s failed: %v", cfg.Name, err)
+			return err
+		}
+		analyzer, mockMessage, err := NewAnalyzer(cfg.Type, d.analyzerContext(rediscli, cfg.Options))
+		if err != nil {
+			blog.Errorf("[datacollection][RUN] create analyzer %s failed: %v", cfg.Name, err)
+			return fmt.Errorf("create analyzer %s failed, err: %v", cfg.Name, err)
+		}
+		man.AddPorter(BuildChanPorter(cfg.Name, analyzer, rediscli, chancli, cfg.Channels, mockMessage))
+		blog.Infof("[datacollect][RUN]analyzer %s of type %s started on channels %v", cfg.Name, cfg.Type, cfg.Channels)
+	}
+
 	blog.Infof("datacollection started")
 	return nil
 }
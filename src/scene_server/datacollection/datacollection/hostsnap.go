@@ -26,6 +26,7 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/history"
 	"configcenter/src/storage/dal"
 
 	"github.com/rs/xid"
@@ -265,6 +266,12 @@ func (h *HostSnap) handleMsg(msgs []string, resetHandle chan struct{}) error {
 					blog.Error("update host error:", err.Error())
 					continue
 				}
+				// the snapshot has no transaction, the lost history is only logged
+				ownerID, _ := host.get(common.BKOwnerIDField).(string)
+				op := history.Operation{Operator: common.CCSystemCollectorUserName, OwnerID: ownerID}
+				if err := history.RecordUpdatedInst(h.ctx, h.db, op, common.BKInnerObjIDHost, condition); err != nil {
+					blog.Errorf("update host by %v, but record the history failed, err: %v", condition, err)
+				}
 				copyVal(setter, host)
 			}
 		}
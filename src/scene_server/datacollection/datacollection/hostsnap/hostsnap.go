@@ -22,6 +22,8 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/history"
+	"configcenter/src/common/metadata"
 	"configcenter/src/storage/dal"
 
 	"github.com/tidwall/gjson"
@@ -39,6 +41,10 @@ type HostSnap struct {
 	cachelock sync.RWMutex
 	ctx       context.Context
 	db        dal.RDB
+
+	// mappings the host snapshot mappings of the owners
+	mappings    map[string][]metadata.HostSnapMapping
+	mappinglock sync.RWMutex
 }
 
 type Cache struct {
@@ -57,6 +63,7 @@ func NewHostSnap(ctx context.Context, redisCli *redis.Client, db dal.RDB) *HostS
 		},
 	}
 	go h.fetchDBLoop()
+	go h.fetchMappingLoop()
 	return h
 }
 
@@ -92,11 +99,18 @@ func (h *HostSnap) Analyze(mesg string) error {
 		blog.Warnf("[datacollect][hostsnap] outip is not string, %s", val.String())
 	}
 	setter := parseSetter(&val, innerip, outip)
+	ownerID, _ := host.get(common.BKOwnerIDField).(string)
+	applyMappings(&val, h.getMappings(ownerID), setter)
 	if needToUpdate(setter, host) {
 		blog.Infof("[datacollect][hostsnap] update host by %v, to %v", condition, setter)
 		if err := h.db.Table(common.BKTableNameBaseHost).Update(h.ctx, condition, setter); err != nil {
 			return fmt.Errorf("update host error: %v", err)
 		}
+		// the snapshot has no transaction, the lost history is only logged
+		op := history.Operation{Operator: common.CCSystemCollectorUserName, OwnerID: ownerID}
+		if err := history.RecordUpdatedInst(h.ctx, h.db, op, common.BKInnerObjIDHost, condition); err != nil {
+			blog.Errorf("[datacollect][hostsnap] update host by %v, but record the history failed, err: %v", condition, err)
+		}
 		copyVal(setter, host)
 	}
 	return nil
@@ -0,0 +1,216 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/datacollection/datacollection/jsonmapping"
+	"configcenter/src/storage/dal"
+
+	"github.com/tidwall/gjson"
+)
+
+var (
+	fetchMappingInterval = time.Minute
+
+	// identityFields the host attributes which identify the host, they are never mapped
+	identityFields = map[string]bool{
+		common.BKHostIDField:      true,
+		common.BKHostInnerIPField: true,
+		common.BKCloudIDField:     true,
+		common.BKOwnerIDField:     true,
+	}
+)
+
+func (h *HostSnap) getMappings(ownerID string) []metadata.HostSnapMapping {
+	h.mappinglock.RLock()
+	defer h.mappinglock.RUnlock()
+	return h.mappings[ownerID]
+}
+
+func (h *HostSnap) fetchMappingLoop() {
+	for {
+		if mappings, err := h.fetchMappings(); err != nil {
+			blog.Errorf("[datacollect][hostsnap] fetch mappings failed, keep the old ones, err: %v", err)
+		} else {
+			h.mappinglock.Lock()
+			h.mappings = mappings
+			h.mappinglock.Unlock()
+		}
+		time.Sleep(fetchMappingInterval)
+	}
+}
+
+func (h *HostSnap) fetchMappings() (map[string][]metadata.HostSnapMapping, error) {
+	result := make([]metadata.HostSnapMapping, 0)
+	if err := h.db.Table(common.BKTableNameHostSnapMapping).Find(nil).All(h.ctx, &result); err != nil {
+		return nil, err
+	}
+	attributes, err := HostAttributes(h.ctx, h.db, "")
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := make(map[string][]metadata.HostSnapMapping)
+	for _, mapping := range result {
+		if err := ValidateMapping(&mapping, attributes[mapping.OwnerID]); err != nil {
+			blog.Warnf("[datacollect][hostsnap] skip the invalid mapping of %s, err: %v", mapping.OwnerID, err)
+			continue
+		}
+		mappings[mapping.OwnerID] = append(mappings[mapping.OwnerID], mapping)
+	}
+	return mappings, nil
+}
+
+// HostAttributes returns the host attributes of the owner by property id, or of all
+// the owners by owner id if the owner is empty
+func HostAttributes(ctx context.Context, db dal.RDB, ownerID string) (map[string]map[string]metadata.Attribute, error) {
+	cond := map[string]interface{}{common.BKObjIDField: common.BKInnerObjIDHost}
+	if ownerID != "" {
+		cond[common.BKOwnerIDField] = ownerID
+	}
+	result := make([]metadata.Attribute, 0)
+	if err := db.Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	attributes := make(map[string]map[string]metadata.Attribute)
+	for _, attribute := range result {
+		if _, ok := attributes[attribute.OwnerID]; !ok {
+			attributes[attribute.OwnerID] = make(map[string]metadata.Attribute)
+		}
+		attributes[attribute.OwnerID][attribute.PropertyID] = attribute
+	}
+	return attributes, nil
+}
+
+// ValidateMapping check the mapping, its property must be a host attribute in the
+// attributes, and must not be the one identifying the host. The non-editable built-in
+// attributes such as bk_cpu are collected too, so they can be remapped.
+func ValidateMapping(mapping *metadata.HostSnapMapping, attributes map[string]metadata.Attribute) error {
+	if mapping.PropertyID == "" || mapping.Path == "" {
+		return fmt.Errorf("the property id or path of mapping is not set")
+	}
+	if identityFields[mapping.PropertyID] {
+		return fmt.Errorf("the host identity %s can not be mapped", mapping.PropertyID)
+	}
+	if _, ok := attributes[mapping.PropertyID]; !ok {
+		return fmt.Errorf("%s is not a host attribute", mapping.PropertyID)
+	}
+	switch mapping.Transform {
+	case "", metadata.HostSnapTransformFirst, metadata.HostSnapTransformSum,
+		metadata.HostSnapTransformCount, metadata.HostSnapTransformJoin:
+	default:
+		return fmt.Errorf("unknown transform %s of %s", mapping.Transform, mapping.PropertyID)
+	}
+	if mapping.Divisor < 0 {
+		return fmt.Errorf("the divisor of %s is negative", mapping.PropertyID)
+	}
+	return nil
+}
+
+// applyMappings set the values of the mappings to the setter, the value which is
+// not found in the snapshot is skipped, so the built-in one is kept. The mappings
+// are validated against the host attributes when they are fetched.
+func applyMappings(val *gjson.Result, mappings []metadata.HostSnapMapping, setter map[string]interface{}) {
+	for _, mapping := range mappings {
+		if identityFields[mapping.PropertyID] {
+			blog.Warnf("[datacollect][hostsnap] skip the mapping of the host identity %s", mapping.PropertyID)
+			continue
+		}
+		value, ok := mapValue(val, &mapping)
+		if !ok {
+			blog.V(4).Infof("[datacollect][hostsnap] %s not found in message by path %s", mapping.PropertyID, mapping.Path)
+			continue
+		}
+		setter[mapping.PropertyID] = value
+	}
+}
+
+func mapValue(val *gjson.Result, mapping *metadata.HostSnapMapping) (interface{}, bool) {
+	values := flatten(val.Get(jsonmapping.ToGJSONPath(mapping.Path)), nil)
+
+	switch mapping.Transform {
+	case metadata.HostSnapTransformCount:
+		return int64(len(values)), true
+	case metadata.HostSnapTransformSum:
+		if len(values) == 0 {
+			return nil, false
+		}
+		var sum float64
+		for _, value := range values {
+			sum += value.Float()
+		}
+		return number(sum, mapping.Divisor), true
+	case metadata.HostSnapTransformJoin:
+		if len(values) == 0 {
+			return nil, false
+		}
+		items := make([]string, 0, len(values))
+		for _, value := range values {
+			items = append(items, value.String())
+		}
+		return strings.Join(items, ","), true
+	default:
+		if len(values) == 0 {
+			return nil, false
+		}
+		switch value := values[0]; value.Type {
+		case gjson.Number:
+			return number(value.Float(), mapping.Divisor), true
+		case gjson.True, gjson.False:
+			return value.Bool(), true
+		default:
+			return value.String(), true
+		}
+	}
+}
+
+// flatten collect the values of the nested arrays, such as the result of
+// data.net.interface.#.addrs.#.addr
+func flatten(result gjson.Result, values []gjson.Result) []gjson.Result {
+	if !result.Exists() || result.Type == gjson.Null {
+		return values
+	}
+	if !result.IsArray() {
+		return append(values, result)
+	}
+	for _, item := range result.Array() {
+		values = flatten(item, values)
+	}
+	return values
+}
+
+// number divide the value by the divisor, and returns an integer if the result
+// is integral, the fraction is dropped when the divisor is integral, just like
+// the built-in bk_disk and bk_mem
+func number(value, divisor float64) interface{} {
+	if divisor > 0 {
+		value = value / divisor
+		if divisor == math.Trunc(divisor) {
+			value = math.Trunc(value)
+		}
+	}
+	if value == math.Trunc(value) && math.Abs(value) < math.MaxInt64 {
+		return int64(value)
+	}
+	return value
+}
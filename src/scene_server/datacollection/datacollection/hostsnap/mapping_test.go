@@ -0,0 +1,158 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/tidwall/gjson"
+)
+
+const snapshot = `{
+	"data": {
+		"system": {"info": {"kernelVersion": "3.10.0-693.el7.x86_64", "virtual": false}},
+		"gpu": [{"id": 0, "memory": 17179869184}, {"id": 1, "memory": 17179869184}],
+		"disk": {"usage": [{"path": "/", "total": 1610612736}, {"path": "/data", "total": 1073741824}]},
+		"net": {"interface": [{"addrs": [{"addr": "10.0.0.1/24"}]}, {"addrs": [{"addr": "10.0.0.2/24"}, {"addr": "fe80::1/64"}]}]},
+		"load": {"load1": 0.5}
+	}
+}`
+
+func TestApplyMappings(t *testing.T) {
+	val := gjson.Parse(snapshot)
+	mappings := []metadata.HostSnapMapping{
+		{PropertyID: "kernel_version", Path: "$.data.system.info.kernelVersion"},
+		{PropertyID: "virtual", Path: "$.data.system.info.virtual"},
+		{PropertyID: "gpu_count", Path: "$.data.gpu[*].id", Transform: metadata.HostSnapTransformCount},
+		{PropertyID: "gpu_mem", Path: "$.data.gpu[*].memory", Transform: metadata.HostSnapTransformSum, Divisor: 1024 * 1024 * 1024},
+		{PropertyID: "bk_disk", Path: "$.data.disk.usage[*].total", Transform: metadata.HostSnapTransformSum, Divisor: 1024 * 1024 * 1024},
+		{PropertyID: "addrs", Path: "$.data.net.interface[*].addrs[*].addr", Transform: metadata.HostSnapTransformJoin},
+		{PropertyID: "load", Path: "data.load.load1"},
+		{PropertyID: "bk_cpu", Path: "$.data.cpu.cpuinfo[*].cores", Transform: metadata.HostSnapTransformSum},
+		{PropertyID: "nic_count", Path: "$.data.nic[*]", Transform: metadata.HostSnapTransformCount},
+	}
+	// the built-in agent fields are not editable, they can be remapped too
+	attributes := map[string]metadata.Attribute{
+		"bk_cpu":  {PropertyID: "bk_cpu", IsEditable: false},
+		"bk_disk": {PropertyID: "bk_disk", IsEditable: false},
+	}
+	for _, mapping := range mappings {
+		if _, ok := attributes[mapping.PropertyID]; !ok {
+			attributes[mapping.PropertyID] = metadata.Attribute{PropertyID: mapping.PropertyID, IsEditable: true}
+		}
+	}
+	for _, mapping := range mappings {
+		if err := ValidateMapping(&mapping, attributes); err != nil {
+			t.Fatalf("expect %+v valid, got %v", mapping, err)
+		}
+	}
+
+	setter := map[string]interface{}{"bk_cpu": int64(8)}
+	applyMappings(&val, mappings, setter)
+
+	expects := map[string]interface{}{
+		"kernel_version": "3.10.0-693.el7.x86_64",
+		"virtual":        false,
+		"gpu_count":      int64(2),
+		"gpu_mem":        int64(32),
+		"bk_disk":        int64(2),
+		"addrs":          "10.0.0.1/24,10.0.0.2/24,fe80::1/64",
+		"load":           0.5,
+		"bk_cpu":         int64(8),
+		"nic_count":      int64(0),
+	}
+	if len(setter) != len(expects) {
+		t.Fatalf("expect %d fields, got %v", len(expects), setter)
+	}
+	for key, expect := range expects {
+		if setter[key] != expect {
+			t.Errorf("%s: expect %#v, got %#v", key, expect, setter[key])
+		}
+	}
+}
+
+func TestValidateMapping(t *testing.T) {
+	attributes := map[string]metadata.Attribute{
+		"gpu":                     {PropertyID: "gpu", IsEditable: true},
+		"bk_os_name":              {PropertyID: "bk_os_name", IsEditable: false},
+		common.BKHostInnerIPField: {PropertyID: common.BKHostInnerIPField, IsEditable: true},
+		common.BKCloudIDField:     {PropertyID: common.BKCloudIDField, IsEditable: true},
+	}
+	invalids := []metadata.HostSnapMapping{
+		{Path: "$.data.gpu"},
+		{PropertyID: "gpu"},
+		{PropertyID: "gpu", Path: "$.data.gpu", Transform: "avg"},
+		{PropertyID: "gpu", Path: "$.data.gpu", Divisor: -1},
+		{PropertyID: "nic", Path: "$.data.nic"},
+		{PropertyID: common.BKHostInnerIPField, Path: "$.ip"},
+		{PropertyID: common.BKCloudIDField, Path: "$.cloudid"},
+		{PropertyID: common.BKHostIDField, Path: "$.hostid"},
+		{PropertyID: common.BKOwnerIDField, Path: "$.owner"},
+	}
+	for _, mapping := range invalids {
+		if err := ValidateMapping(&mapping, attributes); err == nil {
+			t.Errorf("expect %+v invalid", mapping)
+		}
+	}
+	if err := ValidateMapping(&metadata.HostSnapMapping{PropertyID: "gpu", Path: "$.data.gpu", Transform: metadata.HostSnapTransformCount}, attributes); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateMapping(&metadata.HostSnapMapping{PropertyID: "bk_os_name", Path: "$.data.system.info.os"}, attributes); err != nil {
+		t.Errorf("expect the non-editable built-in attribute mapped, got %v", err)
+	}
+}
+
+func TestFetchMappings(t *testing.T) {
+	ctx := context.Background()
+	db := memory.New()
+	attributes := []map[string]interface{}{
+		{common.BKOwnerIDField: "0", common.BKObjIDField: common.BKInnerObjIDHost, common.BKPropertyIDField: "gpu", metadata.AttributeFieldIsEditable: true},
+		{common.BKOwnerIDField: "0", common.BKObjIDField: common.BKInnerObjIDHost, common.BKPropertyIDField: "bk_os_name", metadata.AttributeFieldIsEditable: false},
+		{common.BKOwnerIDField: "1", common.BKObjIDField: common.BKInnerObjIDHost, common.BKPropertyIDField: "nic", metadata.AttributeFieldIsEditable: true},
+		{common.BKOwnerIDField: "0", common.BKObjIDField: "bk_switch", common.BKPropertyIDField: "nic", metadata.AttributeFieldIsEditable: true},
+	}
+	for _, attribute := range attributes {
+		if err := db.Table(common.BKTableNameObjAttDes).Insert(ctx, attribute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mappings := []metadata.HostSnapMapping{
+		{OwnerID: "0", PropertyID: "gpu", Path: "$.data.gpu[*].id", Transform: metadata.HostSnapTransformCount},
+		{OwnerID: "0", PropertyID: "bk_os_name", Path: "$.data.system.info.os"},
+		{OwnerID: "0", PropertyID: "nic", Path: "$.data.nic[*]", Transform: metadata.HostSnapTransformCount},
+		{OwnerID: "0", PropertyID: common.BKHostInnerIPField, Path: "$.ip"},
+		{OwnerID: "1", PropertyID: "nic", Path: "$.data.nic[*]", Transform: metadata.HostSnapTransformCount},
+	}
+	for _, mapping := range mappings {
+		if err := db.Table(common.BKTableNameHostSnapMapping).Insert(ctx, mapping); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := &HostSnap{ctx: ctx, db: db}
+	fetched, err := h.fetchMappings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fetched["0"]) != 2 || fetched["0"][0].PropertyID != "gpu" || fetched["0"][1].PropertyID != "bk_os_name" {
+		t.Errorf("expect the gpu and bk_os_name mappings of owner 0, got %+v", fetched["0"])
+	}
+	if len(fetched["1"]) != 1 || fetched["1"][0].PropertyID != "nic" {
+		t.Errorf("expect only the nic mapping of owner 1, got %+v", fetched["1"])
+	}
+}
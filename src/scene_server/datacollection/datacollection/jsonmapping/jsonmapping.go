@@ -0,0 +1,310 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package jsonmapping is a generic analyzer which maps the fields of the collected
+// messages to the attributes of a model by the JSONPath in a mapping document,
+// the document is read from the database and reloaded periodically, so that a
+// new report format can be ingested by adding a mapping.
+package jsonmapping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+var reloadInterval = time.Minute
+
+// Mapping analyze the messages with the mapping document
+type Mapping struct {
+	ctx     context.Context
+	db      dal.RDB
+	engine  *backbone.Engine
+	name    string
+	ownerID string
+
+	lock    sync.RWMutex
+	mapping *metadata.CollectMapping
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewMapping returns the mapping analyzer of the named mapping document, the
+// analyzer maps nothing until the document is added if it does not exist yet.
+func NewMapping(ctx context.Context, db dal.RDB, engine *backbone.Engine, name, ownerID string) (*Mapping, error) {
+	if name == "" {
+		return nil, errors.New("mapping name is not set")
+	}
+	if ownerID == "" {
+		ownerID = common.BKDefaultOwnerID
+	}
+
+	m := &Mapping{ctx: ctx, db: db, engine: engine, name: name, ownerID: ownerID, done: make(chan struct{})}
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	go m.reloadLoop()
+	return m, nil
+}
+
+// Close stops reloading the mapping document
+func (m *Mapping) Close() {
+	m.closeOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+func (m *Mapping) reloadLoop() {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.reload(); err != nil {
+				blog.Errorf("[datacollect][jsonmapping] reload mapping %s failed, keep the old one, err: %v", m.name, err)
+			}
+		}
+	}
+}
+
+func (m *Mapping) reload() error {
+	mapping := new(metadata.CollectMapping)
+	cond := map[string]interface{}{common.BKCollectMappingNameField: m.name, common.BKOwnerIDField: m.ownerID}
+	err := m.db.Table(common.BKTableNameCollectMapping).Find(cond).One(m.ctx, mapping)
+	switch {
+	case m.db.IsNotFoundError(err):
+		// an empty mapping maps nothing
+		blog.V(4).Infof("[datacollect][jsonmapping] mapping %s not found, map nothing", m.name)
+		mapping = &metadata.CollectMapping{Name: m.name, OwnerID: m.ownerID}
+	case err != nil:
+		return fmt.Errorf("get mapping %s failed, err: %v", m.name, err)
+	default:
+		if err := Validate(mapping); err != nil {
+			return err
+		}
+	}
+
+	m.lock.Lock()
+	m.mapping = mapping
+	m.lock.Unlock()
+	return nil
+}
+
+// Validate check the mapping document
+func Validate(mapping *metadata.CollectMapping) error {
+	if mapping.ObjectID == "" {
+		return fmt.Errorf("the object of mapping %s is not set", mapping.Name)
+	}
+	if len(mapping.Keys) == 0 {
+		return fmt.Errorf("the keys of mapping %s is not set", mapping.Name)
+	}
+	fields := make(map[string]bool)
+	for _, field := range mapping.Fields {
+		if field.PropertyID == "" || field.Path == "" {
+			return fmt.Errorf("mapping %s has a field without property id or path", mapping.Name)
+		}
+		fields[field.PropertyID] = true
+	}
+	for _, key := range mapping.Keys {
+		if !fields[key] {
+			return fmt.Errorf("the key %s of mapping %s is not in the fields", key, mapping.Name)
+		}
+	}
+	return nil
+}
+
+// Analyze map the items in the message, and update or create the instances
+func (m *Mapping) Analyze(mesg string) error {
+	m.lock.RLock()
+	mapping := m.mapping
+	m.lock.RUnlock()
+	if len(mapping.Fields) == 0 {
+		return nil
+	}
+
+	items, err := Extract(mapping, mesg)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Add(common.BKHTTPOwnerID, mapping.OwnerID)
+	header.Add(common.BKHTTPHeaderUser, common.CCSystemCollectorUserName)
+	for _, item := range items {
+		if err := m.save(header, mapping, item); err != nil {
+			blog.Errorf("[datacollect][jsonmapping] save %s item %v failed, err: %v", mapping.ObjectID, item, err)
+		}
+	}
+	return nil
+}
+
+func (m *Mapping) save(header http.Header, mapping *metadata.CollectMapping, item mapstr.MapStr) error {
+	cond := condition.CreateCondition()
+	for _, key := range mapping.Keys {
+		value, ok := item[key]
+		if !ok || value == nil || value == "" {
+			return fmt.Errorf("the key %s is empty", key)
+		}
+		cond.Field(key).Eq(value)
+	}
+	if common.GetInstTableName(mapping.ObjectID) == common.BKTableNameBaseInst {
+		cond.Field(common.BKObjIDField).Eq(mapping.ObjectID)
+	}
+
+	instAPI := m.engine.CoreAPI.CoreService().Instance()
+	resp, err := instAPI.ReadInstance(m.ctx, header, mapping.ObjectID, &metadata.QueryCondition{Condition: cond.ToMapStr()})
+	if err != nil {
+		return err
+	}
+	if !resp.Result {
+		return errors.New(resp.ErrMsg)
+	}
+
+	if len(resp.Data.Info) == 0 {
+		if !mapping.Create {
+			blog.V(4).Infof("[datacollect][jsonmapping] %s instance of %v not found, skip", mapping.ObjectID, cond.ToMapStr())
+			return nil
+		}
+		// upsert by the keys, the instance is created once even if the item is saved by others at the same time
+		upsertResp, err := instAPI.UpsertInstance(m.ctx, header, mapping.ObjectID, &metadata.UpsertModelInstance{Condition: cond.ToMapStr(), Data: item})
+		if err != nil {
+			return err
+		}
+		if !upsertResp.Result {
+			return errors.New(upsertResp.ErrMsg)
+		}
+		return nil
+	}
+
+	inst := resp.Data.Info[0]
+	changed := mapstr.New()
+	for field, value := range item {
+		if !reflect.DeepEqual(inst[field], value) {
+			changed[field] = value
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	instIDField := common.GetInstIDField(mapping.ObjectID)
+	instID, err := util.GetInt64ByInterface(inst[instIDField])
+	if err != nil {
+		return fmt.Errorf("get %s of %v failed, err: %v", instIDField, inst, err)
+	}
+	input := metadata.UpdateOption{
+		Data:      changed,
+		Condition: condition.CreateCondition().Field(instIDField).Eq(instID).ToMapStr(),
+	}
+	updateResp, err := instAPI.UpdateInstance(m.ctx, header, mapping.ObjectID, &input)
+	if err != nil {
+		return err
+	}
+	if !updateResp.Result {
+		return errors.New(updateResp.ErrMsg)
+	}
+	return nil
+}
+
+// Extract returns the mapped attributes of the items in the message, the field
+// not found in an item is omitted.
+func Extract(mapping *metadata.CollectMapping, mesg string) ([]mapstr.MapStr, error) {
+	if !gjson.Valid(mesg) {
+		return nil, errors.New("message is not valid json")
+	}
+
+	root := gjson.Parse(mesg)
+	if mapping.Root != "" {
+		root = root.Get(ToGJSONPath(mapping.Root))
+	}
+
+	var raws []gjson.Result
+	switch {
+	case !root.Exists():
+		return nil, nil
+	case root.IsArray():
+		raws = root.Array()
+	default:
+		raws = []gjson.Result{root}
+	}
+
+	items := make([]mapstr.MapStr, 0, len(raws))
+	for _, raw := range raws {
+		item := mapstr.New()
+		for _, field := range mapping.Fields {
+			value := raw.Get(ToGJSONPath(field.Path))
+			if !value.Exists() {
+				continue
+			}
+			item[field.PropertyID] = value.Value()
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// ToGJSONPath convert the JSONPath to the path of gjson, such as
+// $.data.cpu.cpuinfo[0].modelName to data.cpu.cpuinfo.0.modelName,
+// $.data.disk.usage[*].total to data.disk.usage.#.total, the path
+// not starts with $ is taken as a gjson path.
+func ToGJSONPath(path string) string {
+	if !strings.HasPrefix(path, "$") {
+		return path
+	}
+
+	path = strings.TrimPrefix(path, "$")
+	path = strings.TrimPrefix(path, ".")
+	builder := strings.Builder{}
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				builder.WriteString(path[i:])
+				return builder.String()
+			}
+			index := strings.Trim(path[i+1:i+end], `'"`)
+			if index == "*" {
+				index = "#"
+			}
+			if builder.Len() > 0 {
+				builder.WriteByte('.')
+			}
+			builder.WriteString(index)
+			i += end
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
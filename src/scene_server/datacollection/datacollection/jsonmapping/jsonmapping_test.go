@@ -0,0 +1,152 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jsonmapping
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/memory"
+)
+
+func TestToGJSONPath(t *testing.T) {
+	cases := map[string]string{
+		"$.data.system.hostname":          "data.system.hostname",
+		"$.data.cpu.cpuinfo[0].modelName": "data.cpu.cpuinfo.0.modelName",
+		"$.data.disk.usage[*].total":      "data.disk.usage.#.total",
+		"$['data']['ip']":                 "data.ip",
+		"$[0].ip":                         "0.ip",
+		"data.ip":                         "data.ip",
+	}
+	for path, expect := range cases {
+		if got := ToGJSONPath(path); got != expect {
+			t.Errorf("convert %s should get %s, but got %s", path, expect, got)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	mapping := &metadata.CollectMapping{
+		Name:     "switch",
+		ObjectID: "bk_switch",
+		Root:     "$.data.devices",
+		Keys:     []string{"bk_inst_name"},
+		Fields: []metadata.CollectFieldMapping{
+			{PropertyID: "bk_inst_name", Path: "$.name"},
+			{PropertyID: "bk_ip", Path: "$.addrs[0]"},
+			{PropertyID: "bk_port_count", Path: "$.ports"},
+		},
+	}
+	if err := Validate(mapping); err != nil {
+		t.Fatal(err)
+	}
+
+	mesg := `{"data": {"devices": [
+		{"name": "sw1", "addrs": ["10.0.0.1", "10.0.0.2"], "ports": 48},
+		{"name": "sw2"}
+	]}}`
+	items, err := Extract(mapping, mesg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("should get 2 items, but got %d", len(items))
+	}
+	if items[0]["bk_inst_name"] != "sw1" || items[0]["bk_ip"] != "10.0.0.1" || items[0]["bk_port_count"] != float64(48) {
+		t.Errorf("unexpected item %v", items[0])
+	}
+	if _, ok := items[1]["bk_ip"]; ok || items[1]["bk_inst_name"] != "sw2" {
+		t.Errorf("the missing fields should be omitted, but got %v", items[1])
+	}
+
+	mapping.Root = ""
+	items, err = Extract(mapping, `{"name": "sw3"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0]["bk_inst_name"] != "sw3" {
+		t.Errorf("the whole message should be an item, but got %v", items)
+	}
+
+	if _, err := Extract(mapping, `not json`); err == nil {
+		t.Error("invalid message should fail")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []*metadata.CollectMapping{
+		{Name: "no_object", Keys: []string{"a"}, Fields: []metadata.CollectFieldMapping{{PropertyID: "a", Path: "a"}}},
+		{Name: "no_keys", ObjectID: "o", Fields: []metadata.CollectFieldMapping{{PropertyID: "a", Path: "a"}}},
+		{Name: "key_not_mapped", ObjectID: "o", Keys: []string{"b"}, Fields: []metadata.CollectFieldMapping{{PropertyID: "a", Path: "a"}}},
+		{Name: "no_path", ObjectID: "o", Keys: []string{"a"}, Fields: []metadata.CollectFieldMapping{{PropertyID: "a"}}},
+	}
+	for _, mapping := range cases {
+		if err := Validate(mapping); err == nil {
+			t.Errorf("mapping %s should be invalid", mapping.Name)
+		}
+	}
+}
+
+func TestNewMapping(t *testing.T) {
+	interval := reloadInterval
+	reloadInterval = 10 * time.Millisecond
+	defer func() { reloadInterval = interval }()
+
+	ctx := context.Background()
+	db := memory.New()
+	m, err := NewMapping(ctx, db, nil, "switch", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// the mapping document does not exist yet, nothing is mapped
+	if err := m.Analyze(`{"name": "sw1"}`); err != nil {
+		t.Fatalf("analyze with the empty mapping failed, err: %v", err)
+	}
+
+	mapping := metadata.CollectMapping{
+		Name:     "switch",
+		OwnerID:  common.BKDefaultOwnerID,
+		ObjectID: "bk_switch",
+		Keys:     []string{"bk_inst_name"},
+		Fields:   []metadata.CollectFieldMapping{{PropertyID: "bk_inst_name", Path: "$.name"}},
+	}
+	if err := db.Table(common.BKTableNameCollectMapping).Insert(ctx, mapping); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		m.lock.RLock()
+		loaded := m.mapping.ObjectID == "bk_switch"
+		m.lock.RUnlock()
+		if loaded {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the added mapping document is not reloaded")
+		}
+		time.Sleep(reloadInterval)
+	}
+
+	m.Close()
+	m.Close()
+	select {
+	case <-m.done:
+	default:
+		t.Error("the mapping is not closed")
+	}
+}
@@ -73,16 +73,7 @@ func (h *Netcollect) upsertReport(report *metadata.NetcollectReport) error {
 	existCond.Field(common.BKObjIDField).Eq(report.ObjectID)
 	existCond.Field(common.BKInstKeyField).Eq(report.InstKey)
 
-	count, err := h.db.Table(common.BKTableNameNetcollectReport).Find(existCond.ToMapStr()).Count(h.ctx)
-	if err != nil {
-		return err
-	}
-	if count <= 0 {
-		err = h.db.Table(common.BKTableNameNetcollectReport).Insert(h.ctx, report)
-		return err
-	}
-
-	return h.db.Table(common.BKTableNameNetcollectReport).Update(h.ctx, existCond.ToMapStr(), report)
+	return h.db.Table(common.BKTableNameNetcollectReport).Upsert(h.ctx, existCond.ToMapStr(), report)
 }
 
 // ReportMessage define a netcollect message
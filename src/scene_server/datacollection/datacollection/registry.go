@@ -0,0 +1,120 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datacollection
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common/backbone"
+	"configcenter/src/scene_server/datacollection/datacollection/hostsnap"
+	"configcenter/src/scene_server/datacollection/datacollection/jsonmapping"
+	"configcenter/src/scene_server/datacollection/datacollection/middleware"
+	"configcenter/src/scene_server/datacollection/datacollection/netcollect"
+	"configcenter/src/storage/dal"
+)
+
+// the analyzer types registered by default
+const (
+	AnalyzerHostSnap    = "hostsnap"
+	AnalyzerMiddleware  = "middleware"
+	AnalyzerNetcollect  = "netcollect"
+	AnalyzerJSONMapping = "jsonmapping"
+)
+
+// AnalyzerContext the dependencies to create the analyzers
+type AnalyzerContext struct {
+	Ctx    context.Context
+	Engine *backbone.Engine
+	// Redis the cc redis
+	Redis *redis.Client
+	DB    dal.RDB
+	// Options the options of the analyzer in the config
+	Options map[string]string
+}
+
+// AnalyzerFactory create an analyzer
+type AnalyzerFactory func(ac AnalyzerContext) (Analyzer, error)
+
+type analyzerEntry struct {
+	factory     AnalyzerFactory
+	mockMessage string
+}
+
+var (
+	analyzerLock sync.RWMutex
+	analyzers    = make(map[string]analyzerEntry)
+)
+
+// RegisterAnalyzer register an analyzer type, the mock message is sent to the
+// analyzer by the mock server in the none product mode.
+func RegisterAnalyzer(analyzerType string, factory AnalyzerFactory, mockMessage string) {
+	analyzerLock.Lock()
+	defer analyzerLock.Unlock()
+	analyzers[analyzerType] = analyzerEntry{factory: factory, mockMessage: mockMessage}
+}
+
+// NewAnalyzer create the analyzer of the type, and returns its mock message
+func NewAnalyzer(analyzerType string, ac AnalyzerContext) (Analyzer, string, error) {
+	analyzerLock.RLock()
+	entry, ok := analyzers[analyzerType]
+	analyzerLock.RUnlock()
+	if !ok {
+		return nil, "", fmt.Errorf("analyzer type %s is not supported", analyzerType)
+	}
+
+	analyzer, err := entry.factory(ac)
+	if err != nil {
+		return nil, "", err
+	}
+	return analyzer, entry.mockMessage, nil
+}
+
+// AnalyzerTypes returns all the registered analyzer types
+func AnalyzerTypes() []string {
+	analyzerLock.RLock()
+	defer analyzerLock.RUnlock()
+	types := make([]string, 0, len(analyzers))
+	for analyzerType := range analyzers {
+		types = append(types, analyzerType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func (d *DataCollection) analyzerContext(rediscli *redis.Client, options map[string]string) AnalyzerContext {
+	return AnalyzerContext{Ctx: d.ctx, Engine: d.Engine, Redis: rediscli, DB: d.db, Options: options}
+}
+
+func init() {
+	RegisterAnalyzer(AnalyzerHostSnap, func(ac AnalyzerContext) (Analyzer, error) {
+		return hostsnap.NewHostSnap(ac.Ctx, ac.Redis, ac.DB), nil
+	}, hostsnap.MockMessage)
+
+	RegisterAnalyzer(AnalyzerMiddleware, func(ac AnalyzerContext) (Analyzer, error) {
+		return middleware.NewDiscover(ac.Ctx, ac.Redis, ac.Engine), nil
+	}, middleware.MockMessage)
+
+	RegisterAnalyzer(AnalyzerNetcollect, func(ac AnalyzerContext) (Analyzer, error) {
+		return netcollect.NewNetcollect(ac.Ctx, ac.DB), nil
+	}, netcollect.MockMessage)
+
+	// options: mapping, the name of the mapping document; owner, the owner of the mapping
+	RegisterAnalyzer(AnalyzerJSONMapping, func(ac AnalyzerContext) (Analyzer, error) {
+		return jsonmapping.NewMapping(ac.Ctx, ac.DB, ac.Engine, ac.Options["mapping"], ac.Options["owner"])
+	}, "")
+}
@@ -202,23 +202,9 @@ func (lgc *Logics) UpdateCollector(header http.Header, config metadata.Netcollec
 	cond.Field(common.BKCloudIDField).Eq(config.CloudID)
 	cond.Field(common.BKHostInnerIPField).Eq(config.InnerIP)
 
-	count, err := lgc.Instance.Table(common.BKTableNameNetcollectConfig).Find(cond.ToMapStr()).Count(lgc.ctx)
+	err := lgc.Instance.Table(common.BKTableNameNetcollectConfig).Upsert(lgc.ctx, cond.ToMapStr(), config)
 	if err != nil {
-		blog.Errorf("[UpdateCollector] count by %+v error: %v", cond.ToMapStr(), err)
-		return err
-	}
-	if count > 0 {
-		err = lgc.Instance.Table(common.BKTableNameNetcollectConfig).Update(lgc.ctx, cond, config)
-		if err != nil {
-			blog.Errorf("[UpdateCollector] UpdateByCondition by %+v to %+v error: %v", cond.ToMapStr(), config, err)
-			return err
-		}
-		return lgc.DiscoverNetDevice(header, []metadata.Netcollector{config})
-	}
-
-	err = lgc.Instance.Table(common.BKTableNameNetcollectConfig).Insert(lgc.ctx, config)
-	if err != nil {
-		blog.Errorf("[UpdateCollector] Insert %+v error: %v", config, err)
+		blog.Errorf("[UpdateCollector] Upsert by %+v to %+v error: %v", cond.ToMapStr(), config, err)
 		return err
 	}
 
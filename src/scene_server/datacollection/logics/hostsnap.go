@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/datacollection/datacollection/hostsnap"
+)
+
+// SearchHostSnapMapping returns the host snapshot mappings of the owner
+func (lgc *Logics) SearchHostSnapMapping(header http.Header) ([]metadata.HostSnapMapping, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	cond := map[string]interface{}{common.BKOwnerIDField: util.GetOwnerID(header)}
+	mappings := make([]metadata.HostSnapMapping, 0)
+	if err := lgc.Instance.Table(common.BKTableNameHostSnapMapping).Find(cond).All(lgc.ctx, &mappings); err != nil {
+		blog.Errorf("[HostSnapMapping] search mappings failed, err: %v, condition: %#v", err, cond)
+		return nil, defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	return mappings, nil
+}
+
+// SetHostSnapMapping create the host snapshot mapping of the owner, or replace the one of the same property
+func (lgc *Logics) SetHostSnapMapping(header http.Header, mapping metadata.HostSnapMapping) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	mapping.OwnerID = util.GetOwnerID(header)
+	attributes, err := hostsnap.HostAttributes(lgc.ctx, lgc.Instance, mapping.OwnerID)
+	if err != nil {
+		blog.Errorf("[HostSnapMapping] get the host attributes of %s failed, err: %v", mapping.OwnerID, err)
+		return defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if err := hostsnap.ValidateMapping(&mapping, attributes[mapping.OwnerID]); err != nil {
+		blog.Errorf("[HostSnapMapping] mapping %+v is invalid, err: %v", mapping, err)
+		return defErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
+	}
+
+	cond := map[string]interface{}{common.BKOwnerIDField: mapping.OwnerID, common.BKPropertyIDField: mapping.PropertyID}
+	if err := lgc.Instance.Table(common.BKTableNameHostSnapMapping).Upsert(lgc.ctx, cond, mapping); err != nil {
+		blog.Errorf("[HostSnapMapping] save mapping %+v failed, err: %v", mapping, err)
+		return defErr.Error(common.CCErrCommDBInsertFailed)
+	}
+	return nil
+}
+
+// DeleteHostSnapMapping delete the host snapshot mappings of the properties of the owner
+func (lgc *Logics) DeleteHostSnapMapping(header http.Header, propertyIDs []string) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	if len(propertyIDs) == 0 {
+		return defErr.Errorf(common.CCErrCommParamsNeedSet, common.BKPropertyIDField)
+	}
+	cond := map[string]interface{}{
+		common.BKOwnerIDField:    util.GetOwnerID(header),
+		common.BKPropertyIDField: map[string]interface{}{common.BKDBIN: propertyIDs},
+	}
+	if err := lgc.Instance.Table(common.BKTableNameHostSnapMapping).Delete(lgc.ctx, cond); err != nil {
+		blog.Errorf("[HostSnapMapping] delete mappings failed, err: %v, condition: %#v", err, cond)
+		return defErr.Error(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
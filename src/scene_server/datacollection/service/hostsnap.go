@@ -0,0 +1,89 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	restful "github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// SearchHostSnapMapping search the host snapshot mappings
+func (s *Service) SearchHostSnapMapping(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+
+	mappings, err := s.Logics.SearchHostSnapMapping(pheader)
+	if nil != err {
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(mappings))
+}
+
+// SetHostSnapMapping create or replace a host snapshot mapping
+func (s *Service) SetHostSnapMapping(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	mapping := meta.HostSnapMapping{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&mapping); nil != err {
+		blog.Errorf("[HostSnapMapping] set mapping failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := s.Logics.SetHostSnapMapping(pheader, mapping); nil != err {
+		if err.Error() == defErr.Error(common.CCErrCommDBInsertFailed).Error() ||
+			err.Error() == defErr.Error(common.CCErrCommDBSelectFailed).Error() {
+			resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+			return
+		}
+
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// DeleteHostSnapMapping delete the host snapshot mappings of the properties
+func (s *Service) DeleteHostSnapMapping(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	opt := meta.DeleteHostSnapMappingOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&opt); nil != err {
+		blog.Errorf("[HostSnapMapping] delete mapping failed with decode body err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := s.Logics.DeleteHostSnapMapping(pheader, opt.PropertyIDs); nil != err {
+		if err.Error() == defErr.Error(common.CCErrCommDBDeleteFailed).Error() {
+			resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+			return
+		}
+
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(nil))
+}
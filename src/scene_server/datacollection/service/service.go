@@ -22,6 +22,7 @@ import (
 	"configcenter/src/common/types"
     "configcenter/src/scene_server/datacollection/logics"
     "configcenter/src/storage/dal"
+    "configcenter/src/storage/dal/instrument"
 
     "github.com/emicklei/go-restful"
     redis "gopkg.in/redis.v5"
@@ -32,6 +33,8 @@ type Service struct {
 	*backbone.Engine
 	db    dal.RDB
 	cache *redis.Client
+	// DBMetric exports the latency histograms of the db operations
+	DBMetric instrument.Collector
 	*logics.Logics
 }
 
@@ -72,8 +75,13 @@ func (s *Service) WebService() *restful.WebService {
 	ws.Route(ws.POST("/netcollect/collector/action/update").To(s.UpdateCollector))
 	ws.Route(ws.POST("/netcollect/collector/action/discover").To(s.DiscoverNetDevice))
 
+	ws.Route(ws.POST("/hostsnap/mapping/action/search").To(s.SearchHostSnapMapping))
+	ws.Route(ws.POST("/hostsnap/mapping/action/set").To(s.SetHostSnapMapping))
+	ws.Route(ws.DELETE("/hostsnap/mapping/action/delete").To(s.DeleteHostSnapMapping))
+
 	ws.Path("/collector/v3").Filter(rdapi.AllGlobalFilter(getErrFunc)).Produces(restful.MIME_JSON).Consumes(restful.MIME_JSON)
 	ws.Route(ws.GET("/healthz").To(s.Healthz))
+	s.DBMetric.Register(ws, types.CC_MODULE_DATACOLLECTION)
 
 	return ws
 }
@@ -14,6 +14,9 @@ package options
 
 import (
 	"configcenter/src/common/core/cc/config"
+	"configcenter/src/common/cryptor"
+	"configcenter/src/scene_server/event_server/distribution"
+	"configcenter/src/scene_server/event_server/sink"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
 	"configcenter/src/storage/rpc"
@@ -21,12 +24,12 @@ import (
 	"github.com/spf13/pflag"
 )
 
-//ServerOption define option of server in flags
+// ServerOption define option of server in flags
 type ServerOption struct {
 	ServConf *config.CCAPIConfig
 }
 
-//NewServerOption create a ServerOption object
+// NewServerOption create a ServerOption object
 func NewServerOption() *ServerOption {
 	s := ServerOption{
 		ServConf: config.NewCCAPIConfig(),
@@ -35,7 +38,7 @@ func NewServerOption() *ServerOption {
 	return &s
 }
 
-//AddFlags add flags
+// AddFlags add flags
 func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ServConf.AddrPort, "addrport", "127.0.0.1:60009", "The ip address and port for the serve on")
 	//fs.UintVar(&s.ServConf.Port, "port", 60009, "The port for the serve on")
@@ -44,7 +47,12 @@ func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 }
 
 type Config struct {
-	MongoDB mongo.Config
-	Redis   redis.Config
-	RPC     rpc.ClientConfig
+	MongoDB     mongo.Config
+	Redis       redis.Config
+	RPC         rpc.ClientConfig
+	Delivery    distribution.DeliveryConfig
+	CallbackTLS distribution.CallbackTLSConfig
+	Watch       distribution.WatchConfig
+	Sink        sink.Config
+	Encryption  cryptor.Config
 }
@@ -23,11 +23,15 @@ import (
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/cryptor"
 	"configcenter/src/common/types"
 	"configcenter/src/common/version"
 	"configcenter/src/scene_server/event_server/app/options"
 	"configcenter/src/scene_server/event_server/distribution"
 	svc "configcenter/src/scene_server/event_server/service"
+	"configcenter/src/scene_server/event_server/sink"
+	_ "configcenter/src/scene_server/event_server/sink/kafka"
+	"configcenter/src/storage/dal/instrument"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/dal/redis"
@@ -44,7 +48,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 
 	service := svc.NewService(ctx)
 
-	process := new(EventServer)
+	process := &EventServer{Cryptor: new(cryptor.Holder)}
 	input := &backbone.BackboneParameter{
 		ConfigUpdate: process.onHostConfigUpdate,
 		ConfigPath:   op.ServConf.ExConfig,
@@ -57,6 +61,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 	}
 
 	service.Engine = engine
+	service.Cryptor = process.Cryptor
 	process.Core = engine
 	process.Service = service
 	errCh := make(chan error, 1)
@@ -66,11 +71,23 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 			blog.V(3).Info("config not found, retry 2s later")
 			continue
 		}
-		db, err := local.NewMgo(process.Config.MongoDB.BuildURI(), time.Minute)
+		if process.Cryptor.Load() == nil {
+			return fmt.Errorf("new encryption envelope failed, the encryption config is invalid")
+		}
+		distribution.SetCallbackCryptor(process.Cryptor)
+
+		mgo, err := local.NewMgo(process.Config.MongoDB.BuildURI(), time.Minute)
 		if err != nil {
 			return fmt.Errorf("connect mongo server failed %s", err.Error())
 		}
+		// record the latencies and the slow queries of the db operations
+		db := instrument.New(mgo, instrument.Options{
+			SlowThreshold: process.Config.MongoDB.GetSlowQueryThreshold(),
+			Process:       instrument.ProcessName(types.CC_MODULE_EVENTSERVER, svrInfo),
+			FlushInterval: time.Minute,
+		})
 		process.Service.SetDB(db)
+		process.Service.DBMetric.Swap(db)
 
 		var rpccli rpc.Client
 		if process.Config.MongoDB.Transaction == "enable" {
@@ -95,8 +112,17 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 			errCh <- distribution.SubscribeChannel(subcli)
 		}()
 
+		if err := distribution.SetCallbackTLS(process.Config.CallbackTLS); err != nil {
+			return fmt.Errorf("set callback tls config failed %s", err.Error())
+		}
+
+		sinks, err := sink.NewFromConfig(process.Config.Sink)
+		if err != nil {
+			return fmt.Errorf("create event sinks failed %s", err.Error())
+		}
+
 		go func() {
-			errCh <- distribution.Start(ctx, cache, db, rpccli)
+			errCh <- distribution.Start(ctx, cache, db, rpccli, process.Config.Delivery, process.Config.Watch, sinks)
 		}()
 		break
 	}
@@ -117,6 +143,8 @@ type EventServer struct {
 	Core    *backbone.Engine
 	Config  *options.Config
 	Service *svc.Service
+	// Cryptor the envelope of the latest encryption config, which seals the subscription secrets
+	Cryptor *cryptor.Holder
 }
 
 var configLock sync.Mutex
@@ -137,6 +165,14 @@ func (h *EventServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 		h.Config.Redis = redisConf
 
 		h.Config.RPC.Address = current.ConfigMap["rpc.address"]
+		h.Config.Delivery = distribution.ParseDeliveryConfigFromKV("delivery", current.ConfigMap)
+		h.Config.CallbackTLS = distribution.ParseCallbackTLSConfigFromKV("callback", current.ConfigMap)
+		h.Config.Watch = distribution.ParseWatchConfigFromKV("watch", current.ConfigMap)
+		h.Config.Sink = sink.ParseConfigFromKV("sink", current.ConfigMap)
+		h.Config.Encryption = cryptor.ParseConfigFromKV("encryption", current.ConfigMap)
+		if err := h.Cryptor.Update(h.Config.Encryption); err != nil {
+			blog.Errorf("new encryption envelope failed, keep the old master keys, err: %v", err)
+		}
 	}
 }
 
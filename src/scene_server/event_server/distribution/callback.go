@@ -14,6 +14,7 @@ package distribution
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -24,20 +25,41 @@ import (
 	redis "gopkg.in/redis.v5"
 
 	"configcenter/src/common/blog"
+	"configcenter/src/common/cryptor"
+	"configcenter/src/common/eventclient"
 	"configcenter/src/common/http/httpclient"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/ssl"
+	"configcenter/src/scene_server/event_server/payload"
 	"configcenter/src/scene_server/event_server/types"
 )
 
 func (dh *DistHandler) SendCallback(receiver *metadata.Subscription, event string) (err error) {
 	increaseTotal(dh.cache, receiver.SubscriptionID)
 
-	body := bytes.NewBufferString(event)
+	payloadBody, contentType, err := formatPayload(receiver, event)
+	if err != nil {
+		increaseFailue(dh.cache, receiver.SubscriptionID)
+		return fmt.Errorf("event distribute fail, format payload error: %v, date=[%s]", err, event)
+	}
+	body := bytes.NewBuffer(payloadBody)
 	req, err := http.NewRequest("POST", receiver.CallbackURL, body)
 	if err != nil {
 		increaseFailue(dh.cache, receiver.SubscriptionID)
 		return fmt.Errorf("event distribute fail, build request error: %v, date=[%s]", err, event)
 	}
+	req.Header.Set("Content-Type", contentType)
+	if receiver.Secret != "" {
+		secret, err := openSecret(receiver.Secret)
+		if err != nil {
+			increaseFailue(dh.cache, receiver.SubscriptionID)
+			return fmt.Errorf("event distribute fail, open secret error: %v, date=[%s]", err, event)
+		}
+		if err = eventclient.SignRequest(req, secret, payloadBody); err != nil {
+			increaseFailue(dh.cache, receiver.SubscriptionID)
+			return fmt.Errorf("event distribute fail, sign request error: %v, date=[%s]", err, event)
+		}
+	}
 	var duration time.Duration
 	if receiver.TimeOut == 0 {
 		duration = timeout
@@ -77,6 +99,77 @@ func (dh *DistHandler) SendCallback(receiver *metadata.Subscription, event strin
 
 var httpCli = httpclient.NewHttpClient()
 
+// callbackCryptor opens the sealed subscription secrets to sign the callbacks
+var callbackCryptor = new(cryptor.Holder)
+
+// SetCallbackCryptor set the cryptor which opens the sealed subscription secrets
+func SetCallbackCryptor(holder *cryptor.Holder) {
+	callbackCryptor = holder
+}
+
+// openSecret decrypt the sealed subscription secret, the secrets saved before
+// they are sealed are taken as plain text.
+func openSecret(secret string) (string, error) {
+	if !cryptor.IsEncrypted(secret) {
+		return secret, nil
+	}
+	return callbackCryptor.Load().Decrypt(secret)
+}
+
+// formatPayload render the callback body with the payload format of the subscription,
+// the retries and dead letters always keep the raw event, so they are formatted again
+// when they are sent.
+func formatPayload(receiver *metadata.Subscription, event string) ([]byte, string, error) {
+	formatter, err := payload.GetFormatter(receiver.PayloadFormat)
+	if err != nil {
+		return nil, "", err
+	}
+	if formatter.Name() == metadata.PayloadFormatRaw {
+		return formatter.Format(receiver, nil, event)
+	}
+	dist := metadata.DistInst{}
+	if err := json.Unmarshal([]byte(event), &dist); err != nil {
+		return nil, "", err
+	}
+	return formatter.Format(receiver, &dist, event)
+}
+
+// CallbackTLSConfig define the tls config used to send the callbacks, the
+// client certificate is sent to the subscribers which require mutual tls.
+type CallbackTLSConfig struct {
+	CAFile   string
+	CertFile string
+	KeyFile  string
+	Password string
+}
+
+// ParseCallbackTLSConfigFromKV returns new callback tls config
+func ParseCallbackTLSConfigFromKV(prefix string, configmap map[string]string) CallbackTLSConfig {
+	return CallbackTLSConfig{
+		CAFile:   configmap[prefix+".ca_file"],
+		CertFile: configmap[prefix+".cert_file"],
+		KeyFile:  configmap[prefix+".key_file"],
+		Password: configmap[prefix+".password"],
+	}
+}
+
+// SetCallbackTLS set the tls config of the callback http client
+func SetCallbackTLS(cfg CallbackTLSConfig) error {
+	switch {
+	case cfg.CertFile != "" && cfg.CAFile != "":
+		return httpCli.SetTlsVerity(cfg.CAFile, cfg.CertFile, cfg.KeyFile, cfg.Password)
+	case cfg.CertFile != "":
+		tlsConf, err := ssl.ClientTLSConfCertificate(cfg.CertFile, cfg.KeyFile, cfg.Password)
+		if err != nil {
+			return err
+		}
+		httpCli.SetTlsVerityConfig(tlsConf)
+	case cfg.CAFile != "":
+		return httpCli.SetTlsVerityServer(cfg.CAFile)
+	}
+	return nil
+}
+
 func increaseTotal(cache *redis.Client, subscriptionID int64) error {
 	return increase(cache, subscriptionID, "total")
 }
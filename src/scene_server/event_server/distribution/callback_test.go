@@ -0,0 +1,80 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"configcenter/src/common/cryptor"
+	"configcenter/src/common/eventclient"
+	"configcenter/src/common/metadata"
+)
+
+func TestSendCallbackWithSealedSecret(t *testing.T) {
+	dh, server := newTestDistHandler(t)
+	defer server.Close()
+
+	holder := new(cryptor.Holder)
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	if err := holder.Update(cryptor.Config{MasterKeys: "k1:" + key, ActiveKey: "k1"}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetCallbackCryptor(callbackCryptor)
+	SetCallbackCryptor(holder)
+
+	verifier := eventclient.NewVerifier("my secret", time.Minute)
+	verifyErrs := make(chan error, 2)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := verifier.VerifyRequest(r)
+		verifyErrs <- err
+	}))
+	defer receiver.Close()
+
+	sealed, err := holder.Load().Encrypt("my secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := &metadata.Subscription{
+		SubscriptionID: 3,
+		CallbackURL:    receiver.URL,
+		ConfirmMode:    metadata.ConfirmmodeHttpstatus,
+		ConfirmPattern: "200",
+		Secret:         sealed,
+	}
+	if err := dh.SendCallback(sub, newTestDistRetry(t, 0).Raw); err != nil {
+		t.Fatalf("send callback failed, err: %v", err)
+	}
+	if err := <-verifyErrs; err != nil {
+		t.Errorf("the callback should be signed with the opened secret, err: %v", err)
+	}
+
+	// the secrets saved before they are sealed are still used as plain text
+	sub.Secret = "my secret"
+	if err := dh.SendCallback(sub, newTestDistRetry(t, 0).Raw); err != nil {
+		t.Fatalf("send callback failed, err: %v", err)
+	}
+	if err := <-verifyErrs; err != nil {
+		t.Errorf("the callback should be signed with the plain text secret, err: %v", err)
+	}
+
+	// the sealed secret can not be opened without the master key
+	SetCallbackCryptor(new(cryptor.Holder))
+	sub.Secret = sealed
+	if err := dh.SendCallback(sub, newTestDistRetry(t, 0).Raw); err == nil {
+		t.Error("send callback should fail if the secret can not be opened")
+	}
+}
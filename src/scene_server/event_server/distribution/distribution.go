@@ -165,6 +165,12 @@ func (dh *DistHandler) distToSubscribe(param metadata.Subscription, chNew chan m
 		case <-done:
 			return
 		default:
+			if retry := dh.popRetryInst(sub.SubscriptionID); retry != nil {
+				if err = dh.handleRetry(&sub, retry); err != nil {
+					blog.Errorf("error handle retry dist: %v, %v", err, retry.Raw)
+				}
+				continue
+			}
 			dist := dh.popDistInst(sub.SubscriptionID)
 			if dist == nil {
 				continue
@@ -230,6 +236,9 @@ func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.Dis
 
 	if err = dh.SendCallback(sub, dist.Raw); err != nil {
 		blog.Errorf("send callback error: %v", err)
+		if err = dh.failDist(sub, &metadata.DistRetry{Raw: dist.Raw}, err); err != nil {
+			blog.Errorf("schedule retry of dist %d failed, err: %v", dist.DstbID, err)
+		}
 		return
 	}
 
@@ -113,6 +113,9 @@ func (eh *EventHandler) handleInst(event *metadata.EventInstCtx) (err error) {
 		err = eh.SaveEventDone(event)
 	}()
 
+	eh.pushToWatch(event)
+	eh.pushToSinks(event)
+
 	origindists := eh.GetDistInst(&event.EventInst)
 
 	for _, origindist := range origindists {
@@ -125,6 +128,10 @@ func (eh *EventHandler) handleInst(event *metadata.EventInstCtx) (err error) {
 		for _, subscriber := range subscribers {
 			var dstbID, subscribeID int64
 			distinst := origindist
+			if !eh.matchFilter(subscriber, &distinst.EventInst) {
+				blog.V(4).Infof("event %d not match the filter of subscription %s, skip", event.ID, subscriber)
+				continue
+			}
 			dstbID, err = eh.nextDistID(subscriber)
 			if err != nil {
 				return err
@@ -239,6 +246,31 @@ func saveRunning(cache *redis.Client, key string, timeout time.Duration) (err er
 	return err
 }
 
+// matchFilter check whether the event matches the filter of the subscriber, the event
+// is sent if the subscriber has no filter or the filter can not be evaluated.
+func (eh *EventHandler) matchFilter(subscriber string, event *metadata.EventInst) bool {
+	val, err := eh.cache.HGet(types.EventCacheSubscribeFilterKey, subscriber).Result()
+	if err == redis.Nil || val == "" {
+		return true
+	}
+	if err != nil {
+		blog.Errorf("get filter of subscription %s failed, err: %v", subscriber, err)
+		return true
+	}
+
+	filter := metadata.EventFilter{}
+	if err := json.Unmarshal([]byte(val), &filter); err != nil {
+		blog.Errorf("unmarshal filter of subscription %s failed, err: %v, filter: %s", subscriber, err, val)
+		return true
+	}
+	matched, err := filter.Match(event)
+	if err != nil {
+		blog.Errorf("match filter of subscription %s failed, err: %v, filter: %s", subscriber, err, val)
+		return true
+	}
+	return matched
+}
+
 func (eh *EventHandler) findEventTypeSubscribers(eventtype, ownerID string) []string {
 	return eh.cache.SMembers(types.EventSubscriberCacheKey(ownerID, eventtype)).Val()
 }
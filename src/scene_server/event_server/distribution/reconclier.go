@@ -14,6 +14,7 @@ package distribution
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
@@ -38,6 +39,7 @@ type reconciler struct {
 	persisted            map[string][]string
 	cachedSubscribers    []string
 	persistedSubscribers []string
+	persistedFilters     map[string]string
 	processID            string
 	ctx                  context.Context
 }
@@ -50,6 +52,7 @@ func newReconciler(ctx context.Context, cache *redis.Client, db dal.RDB) *reconc
 		cached:               map[string][]string{},
 		persisted:            map[string][]string{},
 		persistedSubscribers: []string{},
+		persistedFilters:     map[string]string{},
 	}
 }
 
@@ -75,6 +78,7 @@ func (r *reconciler) loadAllCached() {
 func (r *reconciler) loadAllPersisted() {
 	r.persisted = map[string][]string{}
 	r.persistedSubscribers = []string{}
+	r.persistedFilters = map[string]string{}
 	subscriptions := []metadata.Subscription{}
 	if err := r.db.Table(common.BKTableNameSubscription).Find(nil).All(r.ctx, &subscriptions); err != nil {
 		blog.Errorf("reconcile err: %v", err)
@@ -83,6 +87,10 @@ func (r *reconciler) loadAllPersisted() {
 	for _, sub := range subscriptions {
 		eventnames := strings.Split(sub.SubscriptionForm, ",")
 		r.persistedSubscribers = append(r.persistedSubscribers, sub.GetCacheKey())
+		if sub.Filter != nil {
+			filter, _ := json.Marshal(sub.Filter)
+			r.persistedFilters[fmt.Sprint(sub.SubscriptionID)] = string(filter)
+		}
 		for _, eventname := range eventnames {
 			eventname = sub.OwnerID + ":" + eventname
 			r.persisted[eventname] = append(r.persisted[eventname], fmt.Sprint(sub.SubscriptionID))
@@ -113,6 +121,26 @@ func (r *reconciler) reconcile() {
 		r.cache.Del(types.EventCacheSubscribeformKey + k)
 	}
 
+	cachedFilters, err := r.cache.HGetAll(types.EventCacheSubscribeFilterKey).Result()
+	if err != nil {
+		blog.Errorf("reconcile err: %v", err)
+		return
+	}
+	for id, filter := range r.persistedFilters {
+		if cachedFilters[id] != filter {
+			if err := r.cache.HSet(types.EventCacheSubscribeFilterKey, id, filter).Err(); err != nil {
+				blog.Errorf("reconcile err: %v", err)
+			}
+		}
+	}
+	for id := range cachedFilters {
+		if _, ok := r.persistedFilters[id]; !ok {
+			if err := r.cache.HDel(types.EventCacheSubscribeFilterKey, id).Err(); err != nil {
+				blog.Errorf("reconcile err: %v", err)
+			}
+		}
+	}
+
 }
 
 func SubscribeChannel(redisCli *redis.Client) (err error) {
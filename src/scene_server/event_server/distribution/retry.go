@@ -0,0 +1,156 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+// DeliveryConfig define the retry policy of the failed callbacks, the failed
+// distribution is retried with exponential backoff, and it is moved to the
+// dead letter table after MaxAttempts times failure.
+type DeliveryConfig struct {
+	// MaxAttempts the max times to send the callback, including the first one
+	MaxAttempts int
+	// BackoffBase the delay before the first retry, it doubles on every retry
+	BackoffBase time.Duration
+	// BackoffMax the max delay between two retries
+	BackoffMax time.Duration
+}
+
+const (
+	defaultMaxAttempts = 6
+	defaultBackoffBase = 10 * time.Second
+	defaultBackoffMax  = 10 * time.Minute
+)
+
+// ParseDeliveryConfigFromKV returns new delivery config, the backoff is configured in seconds
+func ParseDeliveryConfigFromKV(prefix string, configmap map[string]string) DeliveryConfig {
+	cfg := DeliveryConfig{
+		MaxAttempts: defaultMaxAttempts,
+		BackoffBase: defaultBackoffBase,
+		BackoffMax:  defaultBackoffMax,
+	}
+	if attempts, err := strconv.Atoi(configmap[prefix+".max_attempts"]); err == nil && attempts > 0 {
+		cfg.MaxAttempts = attempts
+	}
+	if base, err := strconv.Atoi(configmap[prefix+".backoff_base"]); err == nil && base > 0 {
+		cfg.BackoffBase = time.Duration(base) * time.Second
+	}
+	if max, err := strconv.Atoi(configmap[prefix+".backoff_max"]); err == nil && max > 0 {
+		cfg.BackoffMax = time.Duration(max) * time.Second
+	}
+	return cfg
+}
+
+// backoff returns the delay before the next retry after attempts times failure
+func (c DeliveryConfig) backoff(attempts int) time.Duration {
+	delay := c.BackoffBase
+	for i := 1; i < attempts && delay < c.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > c.BackoffMax {
+		delay = c.BackoffMax
+	}
+	return delay
+}
+
+// handleRetry send the callback of the retry distribution again
+func (dh *DistHandler) handleRetry(sub *metadata.Subscription, retry *metadata.DistRetry) error {
+	blog.Infof("retry dist of subscription %d, attempts: %d", sub.SubscriptionID, retry.Attempts)
+	if err := dh.SendCallback(sub, retry.Raw); err != nil {
+		blog.Errorf("retry send callback error: %v", err)
+		return dh.failDist(sub, retry, err)
+	}
+	return nil
+}
+
+// failDist schedule the next retry of the failed distribution,
+// or move it to the dead letter table if it has been retried too many times
+func (dh *DistHandler) failDist(sub *metadata.Subscription, retry *metadata.DistRetry, cause error) error {
+	retry.Attempts++
+	retry.LastError = cause.Error()
+	if retry.Attempts >= dh.delivery.MaxAttempts {
+		return dh.saveDeadLetter(sub, retry)
+	}
+
+	member, err := json.Marshal(retry)
+	if err != nil {
+		return err
+	}
+	score := float64(time.Now().Add(dh.delivery.backoff(retry.Attempts)).Unix())
+	return dh.cache.ZAdd(types.EventCacheDistRetryPrefix+fmt.Sprint(sub.SubscriptionID), redis.Z{Score: score, Member: string(member)}).Err()
+}
+
+// popRetryInst pop one retry distribution which is due, returns nil if there is none
+func (dh *DistHandler) popRetryInst(subID int64) *metadata.DistRetry {
+	key := types.EventCacheDistRetryPrefix + fmt.Sprint(subID)
+	members, err := dh.cache.ZRangeByScore(key, redis.ZRangeBy{Min: "-inf", Max: fmt.Sprint(time.Now().Unix()), Count: 1}).Result()
+	if err != nil {
+		blog.Errorf("get retry dist of subscription %d failed, err: %v", subID, err)
+		return nil
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	// the member may be taken by another event server at the same time
+	removed, err := dh.cache.ZRem(key, members[0]).Result()
+	if err != nil || removed == 0 {
+		return nil
+	}
+
+	retry := metadata.DistRetry{}
+	if err := json.Unmarshal([]byte(members[0]), &retry); err != nil {
+		blog.Errorf("retry dist fail, unmarshal error: %v, date=[%s]", err, members[0])
+		return nil
+	}
+	return &retry
+}
+
+func (dh *DistHandler) saveDeadLetter(sub *metadata.Subscription, retry *metadata.DistRetry) error {
+	dist := metadata.DistInst{}
+	if err := json.Unmarshal([]byte(retry.Raw), &dist); err != nil {
+		return fmt.Errorf("save dead letter fail, unmarshal error: %v, date=[%s]", err, retry.Raw)
+	}
+
+	id, err := dh.db.NextSequence(dh.ctx, common.BKTableNameEventDeadLetter)
+	if err != nil {
+		return err
+	}
+	letter := metadata.EventDeadLetter{
+		ID:             int64(id),
+		SubscriptionID: sub.SubscriptionID,
+		OwnerID:        dist.OwnerID,
+		DstbID:         dist.DstbID,
+		EventType:      dist.EventType,
+		Action:         dist.Action,
+		ObjType:        dist.ObjType,
+		Attempts:       retry.Attempts,
+		LastError:      retry.LastError,
+		Event:          retry.Raw,
+		CreateTime:     metadata.Now(),
+	}
+	blog.Warnf("dist %d of subscription %d failed after %d attempts, moved to dead letter %d", dist.DstbID, sub.SubscriptionID, retry.Attempts, letter.ID)
+	return dh.db.Table(common.BKTableNameEventDeadLetter).Insert(dh.ctx, letter)
+}
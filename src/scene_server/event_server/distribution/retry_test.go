@@ -0,0 +1,213 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+	"configcenter/src/storage/dal/memory"
+)
+
+func TestParseDeliveryConfig(t *testing.T) {
+	cfg := ParseDeliveryConfigFromKV("delivery", map[string]string{})
+	if cfg.MaxAttempts != defaultMaxAttempts || cfg.BackoffBase != defaultBackoffBase || cfg.BackoffMax != defaultBackoffMax {
+		t.Errorf("empty config should use the default values, but got %+v", cfg)
+	}
+
+	cfg = ParseDeliveryConfigFromKV("delivery", map[string]string{
+		"delivery.max_attempts": "3",
+		"delivery.backoff_base": "1",
+		"delivery.backoff_max":  "abc",
+	})
+	if cfg.MaxAttempts != 3 || cfg.BackoffBase != time.Second || cfg.BackoffMax != defaultBackoffMax {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
+
+func TestDeliveryBackoff(t *testing.T) {
+	cfg := DeliveryConfig{MaxAttempts: 10, BackoffBase: time.Second, BackoffMax: 10 * time.Second}
+	expects := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for idx, expect := range expects {
+		if delay := cfg.backoff(idx + 1); delay != expect {
+			t.Errorf("backoff after %d attempts should be %v, but got %v", idx+1, expect, delay)
+		}
+	}
+}
+
+func newTestDistHandler(t *testing.T) (*DistHandler, *miniredis.Miniredis) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run redis failed, err: %v", err)
+	}
+	return &DistHandler{
+		cache:    redis.NewClient(&redis.Options{Addr: server.Addr()}),
+		db:       memory.New(),
+		ctx:      context.Background(),
+		delivery: DeliveryConfig{MaxAttempts: 3, BackoffBase: time.Minute, BackoffMax: 10 * time.Minute},
+	}, server
+}
+
+func newTestDistRetry(t *testing.T, attempts int) *metadata.DistRetry {
+	dist := metadata.DistInst{
+		EventInst: metadata.EventInst{
+			ID:        1,
+			EventType: metadata.EventTypeInstData,
+			Action:    metadata.EventActionCreate,
+			ObjType:   common.BKInnerObjIDHost,
+			OwnerID:   common.BKDefaultOwnerID,
+		},
+		DstbID:         2,
+		SubscriptionID: 3,
+	}
+	raw, err := json.Marshal(dist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &metadata.DistRetry{Attempts: attempts, Raw: string(raw)}
+}
+
+func TestFailDistScheduleRetry(t *testing.T) {
+	dh, server := newTestDistHandler(t)
+	defer server.Close()
+	sub := &metadata.Subscription{SubscriptionID: 3, OwnerID: common.BKDefaultOwnerID}
+
+	before := time.Now()
+	if err := dh.failDist(sub, newTestDistRetry(t, 0), fmt.Errorf("connection refused")); err != nil {
+		t.Fatalf("fail dist failed, err: %v", err)
+	}
+
+	key := types.EventCacheDistRetryPrefix + "3"
+	members, err := server.ZMembers(key)
+	if err != nil || len(members) != 1 {
+		t.Fatalf("the retry should be scheduled, members: %v, err: %v", members, err)
+	}
+	retry := metadata.DistRetry{}
+	if err := json.Unmarshal([]byte(members[0]), &retry); err != nil {
+		t.Fatal(err)
+	}
+	if retry.Attempts != 1 || retry.LastError != "connection refused" {
+		t.Errorf("unexpected retry %+v", retry)
+	}
+	score, _ := server.ZScore(key, members[0])
+	if due := before.Add(dh.delivery.BackoffBase).Unix(); int64(score) < due {
+		t.Errorf("the retry should be due after the backoff, score: %v, expect no earlier than %d", score, due)
+	}
+
+	// the retry is not due yet
+	if retry := dh.popRetryInst(3); retry != nil {
+		t.Errorf("the retry should not be popped before it is due, but got %+v", retry)
+	}
+}
+
+func TestPopRetryInst(t *testing.T) {
+	dh, server := newTestDistHandler(t)
+	defer server.Close()
+
+	if retry := dh.popRetryInst(3); retry != nil {
+		t.Errorf("pop from empty retry queue should get nil, but got %+v", retry)
+	}
+
+	key := types.EventCacheDistRetryPrefix + "3"
+	due, _ := json.Marshal(newTestDistRetry(t, 1))
+	later, _ := json.Marshal(newTestDistRetry(t, 2))
+	server.ZAdd(key, float64(time.Now().Add(-time.Second).Unix()), string(due))
+	server.ZAdd(key, float64(time.Now().Add(time.Hour).Unix()), string(later))
+
+	retry := dh.popRetryInst(3)
+	if retry == nil || retry.Attempts != 1 {
+		t.Fatalf("the due retry should be popped, but got %+v", retry)
+	}
+	if members, _ := server.ZMembers(key); len(members) != 1 || members[0] != string(later) {
+		t.Errorf("only the popped retry should be removed, remains: %v", members)
+	}
+	if retry := dh.popRetryInst(3); retry != nil {
+		t.Errorf("the retry which is not due should not be popped, but got %+v", retry)
+	}
+
+	// the invalid member is dropped
+	server.ZAdd(key, 0, "not json")
+	if retry := dh.popRetryInst(3); retry != nil {
+		t.Errorf("the invalid retry should not be returned, but got %+v", retry)
+	}
+	if members, _ := server.ZMembers(key); len(members) != 1 {
+		t.Errorf("the invalid retry should be removed, remains: %v", members)
+	}
+}
+
+func TestRetryToDeadLetter(t *testing.T) {
+	dh, server := newTestDistHandler(t)
+	defer server.Close()
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+	// the subscription is restored from the cache key, which has no owner
+	sub := &metadata.Subscription{
+		SubscriptionID: 3,
+		CallbackURL:    receiver.URL,
+		ConfirmMode:    metadata.ConfirmmodeHttpstatus,
+		ConfirmPattern: "200",
+	}
+
+	// the second retry fails and is scheduled again
+	if err := dh.handleRetry(sub, newTestDistRetry(t, 1)); err != nil {
+		t.Fatalf("handle retry failed, err: %v", err)
+	}
+	key := types.EventCacheDistRetryPrefix + "3"
+	members, _ := server.ZMembers(key)
+	if len(members) != 1 {
+		t.Fatalf("the failed retry should be scheduled again, members: %v", members)
+	}
+	server.ZAdd(key, 0, members[0])
+
+	// the last attempt fails and the distribution is moved to the dead letters
+	retry := dh.popRetryInst(3)
+	if retry == nil || retry.Attempts != 2 {
+		t.Fatalf("the retry should be popped, but got %+v", retry)
+	}
+	if err := dh.handleRetry(sub, retry); err != nil {
+		t.Fatalf("handle retry failed, err: %v", err)
+	}
+	if members, _ := server.ZMembers(key); len(members) != 0 {
+		t.Errorf("the dead letter should not be retried, members: %v", members)
+	}
+
+	letters := make([]metadata.EventDeadLetter, 0)
+	if err := dh.db.Table(common.BKTableNameEventDeadLetter).Find(nil).All(dh.ctx, &letters); err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("should save 1 dead letter, but got %d", len(letters))
+	}
+	letter := letters[0]
+	if letter.SubscriptionID != 3 || letter.DstbID != 2 || letter.Attempts != 3 || letter.ObjType != common.BKInnerObjIDHost ||
+		letter.OwnerID != common.BKDefaultOwnerID {
+		t.Errorf("unexpected dead letter %+v", letter)
+	}
+	if letter.LastError == "" || letter.Event != retry.Raw {
+		t.Errorf("the dead letter should keep the error and the event, got %+v", letter)
+	}
+}
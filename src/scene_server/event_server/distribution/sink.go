@@ -0,0 +1,208 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/sink"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+const (
+	sinkBatchSize   = 100
+	sinkLockTTL     = 30 * time.Second
+	sinkSendTimeout = 30 * time.Second
+)
+
+// sinkLockRenewInterval how often the lock of the sink is renewed while sending,
+// the sending and the backoff may take longer than the ttl of the lock.
+var sinkLockRenewInterval = sinkLockTTL / 3
+
+// sinkBackoff the delay before sending to the sink again after failed
+var sinkBackoff = DeliveryConfig{BackoffBase: time.Second, BackoffMax: time.Minute}
+
+// pushToSinks save the event to the queues of the sinks
+func (eh *EventHandler) pushToSinks(event *metadata.EventInstCtx) {
+	if len(eh.sinks) == 0 {
+		return
+	}
+	member, err := json.Marshal(eh.outboundEvent(event))
+	if err != nil {
+		blog.Errorf("marshal event %d for sinks failed, err: %v", event.ID, err)
+		return
+	}
+	for _, name := range eh.sinks {
+		if err := eh.cache.LPush(types.EventCacheSinkQueuePrefix+name, string(member)).Err(); err != nil {
+			blog.Errorf("push event %d to sink %s failed, err: %v", event.ID, name, err)
+		}
+	}
+}
+
+// StartSink send the events in the queue of the sink, only one event server sends
+// to a sink at the same time to keep the events in order. the events are moved to
+// the processing list before sending and removed after sent, so the queue works
+// as the buffer when the sink is unavailable, and the events being sent are not
+// lost if the event server exits.
+func (dh *DistHandler) StartSink(s sink.Sink) error {
+	defer s.Close()
+	blog.Infof("event sink %s started", s.Name())
+
+	hostname, _ := os.Hostname()
+	token := fmt.Sprintf("%s_%d_%d", hostname, os.Getpid(), time.Now().UnixNano())
+	processing := types.EventCacheSinkProcessingPrefix + s.Name()
+
+	attempts := 0
+	for {
+		if !dh.holdSinkLock(s.Name(), token) {
+			time.Sleep(sinkLockRenewInterval)
+			continue
+		}
+
+		raws, err := dh.popSinkEvents(s.Name())
+		if err != nil {
+			blog.Errorf("pop events of sink %s failed, err: %v", s.Name(), err)
+			time.Sleep(time.Second)
+			continue
+		}
+		if len(raws) == 0 {
+			continue
+		}
+
+		events := make([]*metadata.EventInst, 0, len(raws))
+		for _, raw := range raws {
+			event := new(metadata.EventInst)
+			if err := json.Unmarshal([]byte(raw), event); err != nil {
+				blog.Errorf("drop the invalid event of sink %s, err: %v, raw: %s", s.Name(), err, raw)
+				continue
+			}
+			events = append(events, event)
+		}
+
+		ctx, cancel := context.WithTimeout(dh.ctx, sinkSendTimeout)
+		stopKeeping := dh.keepSinkLock(s.Name(), token, cancel)
+		err = s.Send(ctx, events)
+		cancel()
+		if err != nil && !sink.IsPermanent(err) {
+			attempts++
+			delay := sinkBackoff.backoff(attempts)
+			blog.Warnf("send %d events to sink %s failed %d times, retry after %v, err: %v", len(events), s.Name(), attempts, delay, err)
+			time.Sleep(delay)
+			stopKeeping()
+			continue
+		}
+		stopKeeping()
+		if err != nil {
+			blog.Errorf("drop %d events of sink %s, err: %v", len(events), s.Name(), err)
+		}
+
+		attempts = 0
+		if err := dh.cache.Del(processing).Err(); err != nil {
+			blog.Errorf("clean the processing events of sink %s failed, err: %v", s.Name(), err)
+		}
+	}
+}
+
+// popSinkEvents returns the events being sent if there are, which are left by
+// the failed sending or the exited event server, otherwise move a batch of
+// events from the queue to the processing list.
+func (dh *DistHandler) popSinkEvents(name string) ([]string, error) {
+	queue := types.EventCacheSinkQueuePrefix + name
+	processing := types.EventCacheSinkProcessingPrefix + name
+
+	raws, err := dh.cache.LRange(processing, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(raws) == 0 {
+		first, err := dh.cache.BRPopLPush(queue, processing, time.Second*10).Result()
+		if err == redis.Nil {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		raws = append(raws, first)
+		for len(raws) < sinkBatchSize {
+			raw, err := dh.cache.RPopLPush(queue, processing).Result()
+			if err == redis.Nil {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			raws = append(raws, raw)
+		}
+		return raws, nil
+	}
+
+	// the processing list is pushed from the left, so the oldest is at the right
+	for i, j := 0, len(raws)-1; i < j; i, j = i+1, j-1 {
+		raws[i], raws[j] = raws[j], raws[i]
+	}
+	return raws, nil
+}
+
+// holdSinkLock try to get or renew the lock of the sink
+func (dh *DistHandler) holdSinkLock(name, token string) bool {
+	key := types.EventCacheSinkLockPrefix + name
+	ok, err := dh.cache.SetNX(key, token, sinkLockTTL).Result()
+	if err != nil {
+		blog.Errorf("get the lock of sink %s failed, err: %v", name, err)
+		return false
+	}
+	if ok {
+		return true
+	}
+
+	holder, err := dh.cache.Get(key).Result()
+	if err != nil || holder != token {
+		return false
+	}
+	if err := dh.cache.Expire(key, sinkLockTTL).Err(); err != nil {
+		blog.Errorf("renew the lock of sink %s failed, err: %v", name, err)
+	}
+	return true
+}
+
+// keepSinkLock renew the lock of the sink until the returned stop is called, the
+// sending is canceled if the lock is lost, so that only one event server sends
+// to the sink at the same time.
+func (dh *DistHandler) keepSinkLock(name, token string, cancel context.CancelFunc) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(sinkLockRenewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if !dh.holdSinkLock(name, token) {
+					blog.Errorf("lost the lock of sink %s, cancel sending", name)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,59 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/scene_server/event_server/types"
+)
+
+func TestKeepSinkLock(t *testing.T) {
+	dh, server := newTestDistHandler(t)
+	defer server.Close()
+
+	interval := sinkLockRenewInterval
+	sinkLockRenewInterval = 10 * time.Millisecond
+	defer func() { sinkLockRenewInterval = interval }()
+
+	if !dh.holdSinkLock("kafka", "token") || dh.holdSinkLock("kafka", "other") {
+		t.Fatalf("only the first token should hold the lock")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := dh.keepSinkLock("kafka", "token", cancel)
+
+	// the lock is renewed while sending longer than its ttl
+	for i := 0; i < 3; i++ {
+		server.FastForward(sinkLockTTL / 2)
+		time.Sleep(5 * sinkLockRenewInterval)
+	}
+	if holder, _ := server.Get(types.EventCacheSinkLockPrefix + "kafka"); holder != "token" {
+		t.Fatalf("the lock should be kept by the token, but held by %s", holder)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("the sending should not be canceled while the lock is kept")
+	}
+
+	// the sending is canceled once the lock is taken by another event server
+	server.Set(types.EventCacheSinkLockPrefix+"kafka", "other")
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Errorf("the sending should be canceled after the lock is lost")
+	}
+	stop()
+}
@@ -26,27 +26,38 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/util"
 	"configcenter/src/scene_server/event_server/identifier"
+	"configcenter/src/scene_server/event_server/sink"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/rpc"
 )
 
-func Start(ctx context.Context, cache *redis.Client, db dal.RDB, rc rpc.Client) error {
+func Start(ctx context.Context, cache *redis.Client, db dal.RDB, rc rpc.Client, delivery DeliveryConfig, watch WatchConfig, sinks []sink.Sink) error {
 	chErr := make(chan error, 1)
 	err := migrateIDToMongo(ctx, cache, db)
 	if err != nil {
 		return fmt.Errorf("migrateIDToMongo failed: %v", err)
 	}
 
-	eh := &EventHandler{cache: cache}
+	sinkNames := make([]string, 0, len(sinks))
+	for _, s := range sinks {
+		sinkNames = append(sinkNames, s.Name())
+	}
+	eh := &EventHandler{cache: cache, watch: watch, sinks: sinkNames}
 	go func() {
 		chErr <- eh.StartHandleInsts()
 	}()
 
-	dh := &DistHandler{cache: cache, db: db, ctx: ctx}
+	dh := &DistHandler{cache: cache, db: db, ctx: ctx, delivery: delivery}
 	go func() {
 		chErr <- dh.StartDistribute()
 	}()
 
+	for _, s := range sinks {
+		go func(s sink.Sink) {
+			chErr <- dh.StartSink(s)
+		}(s)
+	}
+
 	ih := identifier.NewIdentifierHandler(ctx, cache, db)
 	go func() {
 		chErr <- ih.StartHandleInsts()
@@ -99,11 +110,16 @@ func migrateIDToMongo(ctx context.Context, cache *redis.Client, db dal.RDB) erro
 	return cache.Del(common.EventCacheEventIDKey).Err()
 }
 
-type EventHandler struct{ cache *redis.Client }
-type DistHandler struct {
+type EventHandler struct {
 	cache *redis.Client
-	db    dal.RDB
-	ctx   context.Context
+	watch WatchConfig
+	sinks []string
+}
+type DistHandler struct {
+	cache    *redis.Client
+	db       dal.RDB
+	ctx      context.Context
+	delivery DeliveryConfig
 }
 
 type TxnHandler struct {
@@ -0,0 +1,76 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/json"
+	"strconv"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+// WatchConfig define how many latest events are kept for the watchers,
+// the watcher whose cursor is older than the kept events has to re-watch
+// from the latest cursor.
+type WatchConfig struct {
+	MaxEvents int64
+}
+
+const defaultWatchMaxEvents = 100000
+
+// ParseWatchConfigFromKV returns new watch config
+func ParseWatchConfigFromKV(prefix string, configmap map[string]string) WatchConfig {
+	cfg := WatchConfig{MaxEvents: defaultWatchMaxEvents}
+	if max, err := strconv.ParseInt(configmap[prefix+".max_events"], 10, 64); err == nil && max > 0 {
+		cfg.MaxEvents = max
+	}
+	return cfg
+}
+
+// outboundEvent returns the event with the same object type as the distributions,
+// so that the watchers and sinks can filter the events with the subscription event types
+func (eh *EventHandler) outboundEvent(event *metadata.EventInstCtx) metadata.EventInst {
+	inst := event.EventInst
+	if dists := eh.GetDistInst(&event.EventInst); len(dists) > 0 {
+		inst.ObjType = dists[0].ObjType
+	}
+	return inst
+}
+
+// pushWatchScript save the event with the next watch sequence as the score and remove the outdated events,
+// the sequence is assigned and the event is saved atomically, so the events are added in the order of the
+// scores and the watchers never skip an event which is added later with a smaller score.
+var pushWatchScript = redis.NewScript(`
+local seq = redis.call('INCR', KEYS[2])
+redis.call('ZADD', KEYS[1], seq, ARGV[1])
+redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -tonumber(ARGV[2]) - 1)
+return seq
+`)
+
+// pushToWatch save the event for the watchers, the watch sequence of the event is used as the cursor
+func (eh *EventHandler) pushToWatch(event *metadata.EventInstCtx) {
+	member, err := json.Marshal(eh.outboundEvent(event))
+	if err != nil {
+		blog.Errorf("marshal event %d for watching failed, err: %v", event.ID, err)
+		return
+	}
+
+	keys := []string{types.EventCacheWatchKey, types.EventCacheWatchSeqKey}
+	if err := pushWatchScript.Run(eh.cache, keys, string(member), eh.watch.MaxEvents).Err(); err != nil {
+		blog.Errorf("save event %d for watching failed, err: %v", event.ID, err)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+func TestParseWatchConfig(t *testing.T) {
+	if cfg := ParseWatchConfigFromKV("watch", map[string]string{}); cfg.MaxEvents != defaultWatchMaxEvents {
+		t.Errorf("empty config should use the default max events, but got %d", cfg.MaxEvents)
+	}
+	if cfg := ParseWatchConfigFromKV("watch", map[string]string{"watch.max_events": "-1"}); cfg.MaxEvents != defaultWatchMaxEvents {
+		t.Errorf("invalid config should use the default max events, but got %d", cfg.MaxEvents)
+	}
+	if cfg := ParseWatchConfigFromKV("watch", map[string]string{"watch.max_events": "10"}); cfg.MaxEvents != 10 {
+		t.Errorf("max events should be 10, but got %d", cfg.MaxEvents)
+	}
+}
+
+func TestPushToWatch(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run redis failed, err: %v", err)
+	}
+	defer server.Close()
+
+	eh := &EventHandler{
+		cache: redis.NewClient(&redis.Options{Addr: server.Addr()}),
+		watch: WatchConfig{MaxEvents: 3},
+	}
+
+	// the events are pushed in a different order from their ids, the
+	// watch sequence keeps the order in which they are pushed
+	for _, id := range []int64{5, 2, 4, 1, 3} {
+		eh.pushToWatch(&metadata.EventInstCtx{EventInst: metadata.EventInst{
+			ID:        id,
+			EventType: metadata.EventTypeInstData,
+			Action:    metadata.EventActionCreate,
+			ObjType:   common.BKInnerObjIDHost,
+			OwnerID:   common.BKDefaultOwnerID,
+		}})
+	}
+
+	members, err := eh.cache.ZRangeWithScores(types.EventCacheWatchKey, 0, -1).Result()
+	if err != nil {
+		t.Fatalf("read watching events failed, err: %v", err)
+	}
+	expects := []struct {
+		seq int64
+		id  int64
+	}{{3, 4}, {4, 1}, {5, 3}}
+	if len(members) != len(expects) {
+		t.Fatalf("only the latest %d events should be kept, but got %d", len(expects), len(members))
+	}
+	for idx, member := range members {
+		event := metadata.EventInst{}
+		if err := json.Unmarshal([]byte(member.Member.(string)), &event); err != nil {
+			t.Fatalf("unmarshal watching event failed, err: %v", err)
+		}
+		if int64(member.Score) != expects[idx].seq || event.ID != expects[idx].id {
+			t.Errorf("event %d should be event %d with sequence %d, but got event %d with sequence %v",
+				idx, expects[idx].id, expects[idx].seq, event.ID, member.Score)
+		}
+	}
+}
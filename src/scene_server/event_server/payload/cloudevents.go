@@ -0,0 +1,91 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package payload
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsContentType = "application/cloudevents+json"
+	cloudEventsTypePrefix  = "com.tencent.bkcmdb."
+)
+
+func init() {
+	Register(&cloudEvents{})
+}
+
+// cloudEvents render the distribution as a CloudEvents 1.0 event in structured
+// content mode, the id is unique for every subscription and keeps the same
+// between retries, so that the receiver can drop the duplicated events.
+type cloudEvents struct{}
+
+type cloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            string      `json:"time,omitempty"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+}
+
+type cloudEventData struct {
+	EventType string               `json:"event_type"`
+	ObjType   string               `json:"obj_type"`
+	Action    string               `json:"action"`
+	TxnID     string               `json:"txn_id,omitempty"`
+	RequestID string               `json:"request_id,omitempty"`
+	Data      []metadata.EventData `json:"data"`
+}
+
+func (c *cloudEvents) Name() string {
+	return metadata.PayloadFormatCloudEvents
+}
+
+func (c *cloudEvents) Validate(sub *metadata.Subscription) error {
+	return nil
+}
+
+func (c *cloudEvents) Format(sub *metadata.Subscription, dist *metadata.DistInst, raw string) ([]byte, string, error) {
+	event := cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              fmt.Sprintf("%d-%d", dist.SubscriptionID, dist.DstbID),
+		Source:          fmt.Sprintf("/bkcmdb/%s/%s", dist.OwnerID, dist.ObjType),
+		Type:            cloudEventsTypePrefix + dist.ObjType + "." + dist.Action,
+		DataContentType: contentTypeJSON,
+		Data: cloudEventData{
+			EventType: dist.EventType,
+			ObjType:   dist.ObjType,
+			Action:    dist.Action,
+			TxnID:     dist.TxnID,
+			RequestID: dist.RequestID,
+			Data:      dist.Data,
+		},
+	}
+	if !dist.ActionTime.IsZero() {
+		event.Time = dist.ActionTime.UTC().Format(time.RFC3339Nano)
+	}
+	if len(dist.Data) == 1 {
+		event.Subject = InstID(dist.ObjType, dist.Data[0])
+	}
+
+	body, err := json.Marshal(event)
+	return body, cloudEventsContentType, err
+}
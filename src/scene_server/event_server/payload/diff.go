@@ -0,0 +1,108 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package payload
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func init() {
+	Register(&diff{})
+}
+
+// diff render only the changed fields of the distribution, all the fields of
+// the created or deleted instances are taken as changed.
+type diff struct{}
+
+type diffPayload struct {
+	EventType      string        `json:"event_type"`
+	ObjType        string        `json:"obj_type"`
+	Action         string        `json:"action"`
+	ActionTime     metadata.Time `json:"action_time"`
+	OwnerID        string        `json:"bk_supplier_account"`
+	DstbID         int64         `json:"distribution_id"`
+	SubscriptionID int64         `json:"subscription_id"`
+	Data           []diffData    `json:"data"`
+}
+
+type diffData struct {
+	InstID  string                 `json:"inst_id,omitempty"`
+	Changed map[string]diffedField `json:"changed"`
+}
+
+type diffedField struct {
+	Pre interface{} `json:"pre"`
+	Cur interface{} `json:"cur"`
+}
+
+func (d *diff) Name() string {
+	return metadata.PayloadFormatDiff
+}
+
+func (d *diff) Validate(sub *metadata.Subscription) error {
+	return nil
+}
+
+func (d *diff) Format(sub *metadata.Subscription, dist *metadata.DistInst, raw string) ([]byte, string, error) {
+	payload := diffPayload{
+		EventType:      dist.EventType,
+		ObjType:        dist.ObjType,
+		Action:         dist.Action,
+		ActionTime:     dist.ActionTime,
+		OwnerID:        dist.OwnerID,
+		DstbID:         dist.DstbID,
+		SubscriptionID: dist.SubscriptionID,
+		Data:           make([]diffData, 0, len(dist.Data)),
+	}
+
+	for _, data := range dist.Data {
+		curData, _ := data.CurData.(map[string]interface{})
+		preData, _ := data.PreData.(map[string]interface{})
+		changed := make(map[string]diffedField)
+		for field, cur := range curData {
+			if pre, ok := preData[field]; !ok || !reflect.DeepEqual(pre, cur) {
+				changed[field] = diffedField{Pre: preData[field], Cur: cur}
+			}
+		}
+		for field, pre := range preData {
+			if _, ok := curData[field]; !ok {
+				changed[field] = diffedField{Pre: pre}
+			}
+		}
+		// the update time is always changed, which is meaningless to the receiver
+		if dist.Action == metadata.EventActionUpdate {
+			delete(changed, common.LastTimeField)
+		}
+		payload.Data = append(payload.Data, diffData{InstID: InstID(dist.ObjType, data), Changed: changed})
+	}
+
+	body, err := json.Marshal(payload)
+	return body, contentTypeJSON, err
+}
+
+// InstID returns the id of the instance in the event data
+func InstID(objType string, data metadata.EventData) string {
+	for _, item := range []interface{}{data.CurData, data.PreData} {
+		if m, ok := item.(map[string]interface{}); ok {
+			if id, ok := m[common.GetInstIDField(objType)]; ok && id != nil {
+				return fmt.Sprint(id)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package payload renders the body of the event callbacks, every subscription
+// chooses a formatter by its payload_format, the raw distribution json is sent
+// if the payload_format is not set.
+package payload
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"configcenter/src/common/metadata"
+)
+
+const (
+	contentTypeJSON = "application/json"
+	contentTypeText = "text/plain"
+)
+
+// Formatter render the callback body of the distribution
+type Formatter interface {
+	// Name the name the formatter registered with, same as payload_format
+	Name() string
+	// Validate check whether the subscription settings are valid for the formatter
+	Validate(sub *metadata.Subscription) error
+	// Format returns the callback body and its content type, the raw is the
+	// json of the distribution
+	Format(sub *metadata.Subscription, dist *metadata.DistInst, raw string) ([]byte, string, error)
+}
+
+var (
+	lock       sync.RWMutex
+	formatters = make(map[string]Formatter)
+)
+
+// Register register a formatter, the formatter with the same name
+// registered before will be replaced.
+func Register(formatter Formatter) {
+	lock.Lock()
+	defer lock.Unlock()
+	formatters[formatter.Name()] = formatter
+}
+
+// GetFormatter get the formatter by the payload format, empty format means
+// the raw format which is the only format supported before.
+func GetFormatter(format string) (Formatter, error) {
+	if format == "" {
+		format = metadata.PayloadFormatRaw
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	formatter, ok := formatters[format]
+	if !ok {
+		return nil, fmt.Errorf("payload format %s is not supported", format)
+	}
+	return formatter, nil
+}
+
+// Formats returns all the registered payload formats
+func Formats() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	formats := make([]string, 0, len(formatters))
+	for format := range formatters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Validate check the payload settings of the subscription
+func Validate(sub *metadata.Subscription) error {
+	formatter, err := GetFormatter(sub.PayloadFormat)
+	if err != nil {
+		return err
+	}
+	return formatter.Validate(sub)
+}
+
+func init() {
+	Register(&raw{})
+}
+
+// raw send the distribution json as it is
+type raw struct{}
+
+func (r *raw) Name() string {
+	return metadata.PayloadFormatRaw
+}
+
+func (r *raw) Validate(sub *metadata.Subscription) error {
+	return nil
+}
+
+func (r *raw) Format(sub *metadata.Subscription, dist *metadata.DistInst, raw string) ([]byte, string, error) {
+	return []byte(raw), contentTypeJSON, nil
+}
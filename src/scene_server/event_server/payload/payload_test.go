@@ -0,0 +1,142 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package payload
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+func newDist() *metadata.DistInst {
+	return &metadata.DistInst{
+		EventInst: metadata.EventInst{
+			EventType:  metadata.EventTypeInstData,
+			Action:     metadata.EventActionUpdate,
+			ActionTime: metadata.Time{Time: time.Date(2019, 3, 5, 10, 0, 0, 0, time.UTC)},
+			ObjType:    "host",
+			OwnerID:    "0",
+			Data: []metadata.EventData{{
+				PreData: map[string]interface{}{"bk_host_id": float64(1), "bk_host_name": "a", "bk_os_name": "linux", "last_time": "t1"},
+				CurData: map[string]interface{}{"bk_host_id": float64(1), "bk_host_name": "b", "bk_os_name": "linux", "last_time": "t2"},
+			}},
+		},
+		DstbID:         7,
+		SubscriptionID: 3,
+	}
+}
+
+func TestGetFormatter(t *testing.T) {
+	formatter, err := GetFormatter("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formatter.Name() != metadata.PayloadFormatRaw {
+		t.Errorf("default format should be %s, but got %s", metadata.PayloadFormatRaw, formatter.Name())
+	}
+	if _, err := GetFormatter("not_exist"); err == nil {
+		t.Error("get not registered format should fail")
+	}
+}
+
+func TestCloudEvents(t *testing.T) {
+	formatter, _ := GetFormatter(metadata.PayloadFormatCloudEvents)
+	body, contentType, err := formatter.Format(&metadata.Subscription{}, newDist(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != cloudEventsContentType {
+		t.Errorf("unexpected content type %s", contentType)
+	}
+
+	event := map[string]interface{}{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"specversion": "1.0",
+		"id":          "3-7",
+		"source":      "/bkcmdb/0/host",
+		"type":        "com.tencent.bkcmdb.host.update",
+		"subject":     "1",
+		"time":        "2019-03-05T10:00:00Z",
+	}
+	for key, value := range expect {
+		if event[key] != value {
+			t.Errorf("%s should be %v, but got %v", key, value, event[key])
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	formatter, _ := GetFormatter(metadata.PayloadFormatDiff)
+	body, _, err := formatter.Format(&metadata.Subscription{}, newDist(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := diffPayload{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Data) != 1 || result.Data[0].InstID != "1" {
+		t.Fatalf("unexpected diff data %s", body)
+	}
+	changed := result.Data[0].Changed
+	if len(changed) != 1 || changed["bk_host_name"].Pre != "a" || changed["bk_host_name"].Cur != "b" {
+		t.Errorf("only bk_host_name should be changed, but got %s", body)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	formatter, _ := GetFormatter(metadata.PayloadFormatTemplate)
+
+	sub := &metadata.Subscription{PayloadFormat: metadata.PayloadFormatTemplate}
+	if err := Validate(sub); err == nil {
+		t.Error("template format without template should be invalid")
+	}
+	sub.PayloadTemplate = "{{.ObjType"
+	if err := Validate(sub); err == nil {
+		t.Error("broken template should be invalid")
+	}
+
+	sub.PayloadTemplate = `{"text": "{{.ObjType}} {{.Action}} {{range .Data}}{{instid "host" .}}{{end}}", "data": {{json .Data}}}`
+	if err := Validate(sub); err != nil {
+		t.Fatal(err)
+	}
+	body, contentType, err := formatter.Format(sub, newDist(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != contentTypeJSON {
+		t.Errorf("json output should be sent as %s, but got %s", contentTypeJSON, contentType)
+	}
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result["text"] != "host update 1" {
+		t.Errorf("unexpected text %v", result["text"])
+	}
+
+	sub.PayloadTemplate = "{{.ObjType}} is changed"
+	body, contentType, err = formatter.Format(sub, newDist(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != contentTypeText || string(body) != "host is changed" {
+		t.Errorf("unexpected output %s with content type %s", body, contentType)
+	}
+}
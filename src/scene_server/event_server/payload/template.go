@@ -0,0 +1,75 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/template"
+
+	"configcenter/src/common/metadata"
+)
+
+func init() {
+	Register(&textTemplate{})
+}
+
+// textTemplate render the payload_template of the subscription with the
+// distribution, which is used to send the events to the chat-ops webhooks
+// directly, such as {"text": "{{.ObjType}} {{.Action}} at {{.ActionTime}}"}.
+// the json function quotes the value as json, such as {{json .Data}}.
+type textTemplate struct{}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+	"instid": InstID,
+}
+
+func (t *textTemplate) Name() string {
+	return metadata.PayloadFormatTemplate
+}
+
+func (t *textTemplate) Validate(sub *metadata.Subscription) error {
+	if sub.PayloadTemplate == "" {
+		return errors.New("payload template is not set")
+	}
+	_, err := parseTemplate(sub.PayloadTemplate)
+	return err
+}
+
+func (t *textTemplate) Format(sub *metadata.Subscription, dist *metadata.DistInst, raw string) ([]byte, string, error) {
+	tpl, err := parseTemplate(sub.PayloadTemplate)
+	if err != nil {
+		return nil, "", err
+	}
+
+	buf := bytes.Buffer{}
+	if err := tpl.Execute(&buf, dist); err != nil {
+		return nil, "", fmt.Errorf("render payload template failed, err: %v", err)
+	}
+
+	body := buf.Bytes()
+	if json.Valid(body) {
+		return body, contentTypeJSON, nil
+	}
+	return body, contentTypeText, nil
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("payload").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
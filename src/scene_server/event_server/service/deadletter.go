@@ -0,0 +1,171 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+// SearchDeadLetter list the events which failed to be delivered after all the retries
+func (s *Service) SearchDeadLetter(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	id, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedInt, "subscribeID")})
+		return
+	}
+
+	dat := metadata.ParamDeadLetterSearch{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil {
+		blog.Errorf("search dead letter, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	limit := dat.Page.Limit
+	if limit <= 0 {
+		limit = common.BKNoLimit
+	}
+	sort := dat.Page.Sort
+	if sort == "" {
+		sort = common.BKDeadLetterIDField
+	}
+
+	condition := util.NewMapBuilder(common.BKSubscriptionIDField, id, common.BKOwnerIDField, ownerID).Build()
+	count, err := s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("get dead letter count of subscription %d failed, err: %v", id, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterSelectFailed)})
+		return
+	}
+
+	results := make([]metadata.EventDeadLetter, 0)
+	err = s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).Sort(sort).Start(uint64(dat.Page.Start)).Limit(uint64(limit)).All(s.ctx, &results)
+	if err != nil {
+		blog.Errorf("get dead letter of subscription %d failed, err: %v", id, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.RspDeadLetterSearch{Count: count, Info: results}))
+}
+
+// ReplayDeadLetter send the dead letters to the subscriber again, the replayed
+// dead letters are removed, and they come back if they failed again.
+func (s *Service) ReplayDeadLetter(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	id, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedInt, "subscribeID")})
+		return
+	}
+
+	dat := metadata.ParamDeadLetterOperate{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil && err != io.EOF {
+		blog.Errorf("replay dead letter, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	condition := deadLetterCondition(id, ownerID, dat.IDs)
+	letters := make([]metadata.EventDeadLetter, 0)
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).All(s.ctx, &letters); err != nil {
+		blog.Errorf("get dead letter of subscription %d failed, err: %v", id, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+		return
+	}
+
+	// push the dead letters to the retry queue which will be sent as soon as possible
+	now := float64(time.Now().Unix())
+	retryKey := types.EventCacheDistRetryPrefix + fmt.Sprint(id)
+	for _, letter := range letters {
+		member, _ := json.Marshal(metadata.DistRetry{Raw: letter.Event})
+		if err := s.cache.ZAdd(retryKey, redis.Z{Score: now, Member: string(member)}).Err(); err != nil {
+			blog.Errorf("replay dead letter %d failed, err: %v", letter.ID, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+			return
+		}
+
+		letterCond := util.NewMapBuilder(common.BKDeadLetterIDField, letter.ID).Build()
+		if err := s.db.Table(common.BKTableNameEventDeadLetter).Delete(s.ctx, letterCond); err != nil {
+			blog.Errorf("delete replayed dead letter %d failed, err: %v", letter.ID, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+			return
+		}
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.RspDeadLetterOperate{Count: len(letters)}))
+}
+
+// PurgeDeadLetter delete the dead letters without sending them
+func (s *Service) PurgeDeadLetter(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	id, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedInt, "subscribeID")})
+		return
+	}
+
+	dat := metadata.ParamDeadLetterOperate{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil && err != io.EOF {
+		blog.Errorf("purge dead letter, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	condition := deadLetterCondition(id, ownerID, dat.IDs)
+	count, err := s.db.Table(common.BKTableNameEventDeadLetter).Find(condition).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("get dead letter count of subscription %d failed, err: %v", id, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterPurgeFailed)})
+		return
+	}
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Delete(s.ctx, condition); err != nil {
+		blog.Errorf("purge dead letter of subscription %d failed, err: %v", id, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterPurgeFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.RspDeadLetterOperate{Count: int(count)}))
+}
+
+func deadLetterCondition(subscriptionID int64, ownerID string, ids []int64) map[string]interface{} {
+	condition := util.NewMapBuilder(common.BKSubscriptionIDField, subscriptionID, common.BKOwnerIDField, ownerID).Build()
+	if len(ids) > 0 {
+		condition[common.BKDeadLetterIDField] = map[string]interface{}{common.BKDBIN: ids}
+	}
+	return condition
+}
@@ -20,19 +20,25 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/backbone"
+	"configcenter/src/common/cryptor"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/metric"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
 	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/instrument"
 )
 
 type Service struct {
 	*backbone.Engine
-	db    dal.RDB
-	cache *redis.Client
-	ctx   context.Context
+	// Cryptor seals the subscription secrets before they are saved
+	Cryptor *cryptor.Holder
+	db      dal.RDB
+	cache   *redis.Client
+	// DBMetric exports the latency histograms of the db operations
+	DBMetric instrument.Collector
+	ctx      context.Context
 }
 
 func NewService(ctx context.Context) *Service {
@@ -62,8 +68,14 @@ func (s *Service) WebService() *restful.WebService {
 	ws.Route(ws.POST("/subscribe/{ownerID}/{appID}").To(s.Subscribe))
 	ws.Route(ws.DELETE("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.UnSubscribe))
 	ws.Route(ws.PUT("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.Rebook))
+	ws.Route(ws.POST("/subscribe/{ownerID}/{appID}/{subscribeID}/deadletter/search").To(s.SearchDeadLetter))
+	ws.Route(ws.POST("/subscribe/{ownerID}/{appID}/{subscribeID}/deadletter/replay").To(s.ReplayDeadLetter))
+	ws.Route(ws.DELETE("/subscribe/{ownerID}/{appID}/{subscribeID}/deadletter").To(s.PurgeDeadLetter))
+
+	ws.Route(ws.GET("/watch").To(s.Watch).Produces(restful.MIME_JSON, mimeEventStream))
 
 	ws.Route(ws.GET("/healthz").To(s.Healthz))
+	s.DBMetric.Register(ws, types.CC_MODULE_EVENTSERVER)
 
 	return ws
 }
@@ -27,6 +27,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/payload"
 	"configcenter/src/scene_server/event_server/types"
 
 	"github.com/emicklei/go-restful"
@@ -45,6 +46,18 @@ func (s *Service) Subscribe(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
 		return
 	}
+	if sub.Filter != nil {
+		if err = sub.Filter.Validate(); err != nil {
+			blog.Errorf("add subscription, but filter is invalid, err: %v", err)
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "filter")})
+			return
+		}
+	}
+	if err = payload.Validate(sub); err != nil {
+		blog.Errorf("add subscription, but payload format is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "payload_format")})
+		return
+	}
 	now := metadata.Now()
 	sub.Operator = util.GetUser(req.Request.Header)
 	if sub.TimeOut <= 0 {
@@ -75,6 +88,11 @@ func (s *Service) Subscribe(req *restful.Request, resp *restful.Response) {
 			return
 		}
 	} else {
+		if sub.Secret, err = s.sealSecret(sub.Secret); err != nil {
+			blog.Errorf("create subscription, but seal the secret failed, err: %v", err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeInsertFailed)})
+			return
+		}
 		nid, err := s.db.NextSequence(s.ctx, common.BKTableNameSubscription)
 		sub.SubscriptionID = int64(nid)
 		if nil != err {
@@ -97,6 +115,12 @@ func (s *Service) Subscribe(req *restful.Request, resp *restful.Response) {
 			}
 		}
 
+		if err := s.saveFilterCache(sub); err != nil {
+			blog.Errorf("create subscription failed, error:%s", err.Error())
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeInsertFailed)})
+			return
+		}
+
 		mesg, _ := json.Marshal(&sub)
 		s.cache.Publish(types.EventCacheProcessChannel, "create"+string(mesg))
 		s.cache.Del(types.EventCacheDistCallBackCountPrefix + fmt.Sprint(sub.SubscriptionID))
@@ -150,7 +174,13 @@ func (s *Service) UnSubscribe(req *restful.Request, resp *restful.Response) {
 
 	s.cache.Del(types.EventCacheDistIDPrefix+subID,
 		types.EventCacheDistQueuePrefix+subID,
-		types.EventCacheDistDonePrefix+subID)
+		types.EventCacheDistDonePrefix+subID,
+		types.EventCacheDistRetryPrefix+subID)
+	s.cache.HDel(types.EventCacheSubscribeFilterKey, subID)
+
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Delete(s.ctx, condiction); err != nil {
+		blog.Errorf("fail to delete dead letters of subscription %v, error information is %v", id, err)
+	}
 
 	mesg, _ := json.Marshal(&sub)
 	s.cache.Publish(types.EventCacheProcessChannel, "delete"+string(mesg))
@@ -176,6 +206,18 @@ func (s *Service) Rebook(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
 		return
 	}
+	if sub.Filter != nil {
+		if err = sub.Filter.Validate(); err != nil {
+			blog.Errorf("update subscription, but filter is invalid, err: %v", err)
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "filter")})
+			return
+		}
+	}
+	if err = payload.Validate(sub); err != nil {
+		blog.Errorf("update subscription, but payload format is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, "payload_format")})
+		return
+	}
 	sub.Operator = util.GetUser(req.Request.Header)
 	if err = s.rebook(id, ownerID, sub); err != nil {
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeUpdateFailed)})
@@ -184,6 +226,15 @@ func (s *Service) Rebook(req *restful.Request, resp *restful.Response) {
 	resp.WriteEntity(metadata.NewSuccessResp(nil))
 }
 
+// sealSecret encrypt the callback secret with the active master key, so that it's
+// never saved or published to the cache in plain text.
+func (s *Service) sealSecret(secret string) (string, error) {
+	if secret == "" {
+		return "", nil
+	}
+	return s.Cryptor.Load().Encrypt(secret)
+}
+
 func (s *Service) rebook(id int64, ownerID string, sub *metadata.Subscription) error {
 	// query old Subscription
 	oldsub := metadata.Subscription{}
@@ -205,6 +256,17 @@ func (s *Service) rebook(id int64, ownerID string, sub *metadata.Subscription) e
 	}
 
 	sub.SubscriptionID = oldsub.SubscriptionID
+	// the secret is not returned by query, so keep it if it is not changed
+	if sub.Secret == "" {
+		sub.Secret = oldsub.Secret
+	} else {
+		secret, err := s.sealSecret(sub.Secret)
+		if err != nil {
+			blog.Errorf("update subscription, but seal the secret failed, err: %v", err)
+			return err
+		}
+		sub.Secret = secret
+	}
 	if sub.TimeOut <= 0 {
 		sub.TimeOut = 10
 	}
@@ -241,6 +303,11 @@ func (s *Service) rebook(id int64, ownerID string, sub *metadata.Subscription) e
 		}
 	}
 
+	if err := s.saveFilterCache(sub); err != nil {
+		blog.Errorf("update subscription filter failed, error:%s", err.Error())
+		return err
+	}
+
 	mesg, err := json.Marshal(&sub)
 	if err != nil {
 		return err
@@ -248,6 +315,20 @@ func (s *Service) rebook(id int64, ownerID string, sub *metadata.Subscription) e
 	return s.cache.Publish(types.EventCacheProcessChannel, "update"+string(mesg)).Err()
 }
 
+// saveFilterCache save the filter of the subscription to cache, which is used to
+// filter the events before they are pushed to the distribution queue
+func (s *Service) saveFilterCache(sub *metadata.Subscription) error {
+	subID := fmt.Sprint(sub.SubscriptionID)
+	if sub.Filter == nil {
+		return s.cache.HDel(types.EventCacheSubscribeFilterKey, subID).Err()
+	}
+	filter, err := json.Marshal(sub.Filter)
+	if err != nil {
+		return err
+	}
+	return s.cache.HSet(types.EventCacheSubscribeFilterKey, subID, string(filter)).Err()
+}
+
 func (s *Service) Query(req *restful.Request, resp *restful.Response) {
 	pheader := req.Request.Header
 	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
@@ -303,6 +384,7 @@ func (s *Service) Query(req *restful.Request, resp *restful.Response) {
 			Total:   total,
 			Failure: failue,
 		}
+		results[index].Secret = ""
 	}
 
 	info := make(map[string]interface{})
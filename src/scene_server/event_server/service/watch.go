@@ -0,0 +1,287 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+const (
+	mimeEventStream = "text/event-stream"
+
+	defaultWatchLimit   = 100
+	maxWatchLimit       = 1000
+	defaultWatchTimeout = 30 * time.Second
+	maxWatchTimeout     = 5 * time.Minute
+	watchPollInterval   = time.Second
+	watchHeartbeat      = 15 * time.Second
+	watchRetry          = 3 * time.Second
+)
+
+var errWatchCursorExpired = errors.New("watch cursor expired")
+
+// watchEvent the event with its watch sequence, which is the cursor after the event
+type watchEvent struct {
+	cursor int64
+	event  metadata.EventInst
+}
+
+type watchOption struct {
+	ownerID    string
+	cursor     int64
+	eventTypes map[string]bool
+	limit      int64
+	timeout    time.Duration
+}
+
+// Watch returns the events after the cursor, it works in two modes:
+// the long poll mode waits until there are new events or timeout, and returns
+// them with the cursor to continue watching, which is the default mode.
+// the server-sent events mode streams the events until the client disconnects,
+// which is used if the request accepts text/event-stream, the id of every
+// event is the cursor, and the Last-Event-ID header is used to resume.
+// the cursor is the watch sequence assigned when the event is saved for
+// watching, and cursor 0 watches from the oldest kept event.
+func (s *Service) Watch(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	opt, err := s.parseWatchOption(req)
+	if err != nil {
+		blog.Errorf("watch events, but parse option failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())})
+		return
+	}
+
+	if strings.Contains(pheader.Get("Accept"), mimeEventStream) {
+		s.watchStream(req, resp, opt)
+		return
+	}
+
+	deadline := time.Now().Add(opt.timeout)
+	for {
+		events, err := s.readWatchEvents(opt)
+		if err == errWatchCursorExpired {
+			resp.WriteError(http.StatusGone, &metadata.RespError{Msg: defErr.Error(common.CCErrEventWatchCursorExpired)})
+			return
+		}
+		if err != nil {
+			blog.Errorf("watch events from cursor %d failed, err: %v", opt.cursor, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventWatchFailed)})
+			return
+		}
+
+		if len(events) > 0 || !time.Now().Before(deadline) {
+			result := metadata.RspWatchEvent{Cursor: opt.cursor, Events: make([]metadata.EventInst, 0, len(events))}
+			for _, watched := range events {
+				result.Events = append(result.Events, watched.event)
+			}
+			resp.WriteEntity(metadata.NewSuccessResp(result))
+			return
+		}
+
+		select {
+		case <-req.Request.Context().Done():
+			return
+		case <-time.After(watchPollInterval):
+		}
+	}
+}
+
+// watchStream write the events as server-sent events until the client disconnects
+func (s *Service) watchStream(req *restful.Request, resp *restful.Response, opt *watchOption) {
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+	flusher, ok := resp.ResponseWriter.(http.Flusher)
+	if !ok {
+		resp.WriteError(http.StatusNotAcceptable, &metadata.RespError{Msg: defErr.Error(common.CCErrEventWatchFailed)})
+		return
+	}
+
+	// check the cursor before the stream is started, so that the client gets the status code
+	events, err := s.readWatchEvents(opt)
+	if err == errWatchCursorExpired {
+		resp.WriteError(http.StatusGone, &metadata.RespError{Msg: defErr.Error(common.CCErrEventWatchCursorExpired)})
+		return
+	}
+	if err != nil {
+		blog.Errorf("watch events from cursor %d failed, err: %v", opt.cursor, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventWatchFailed)})
+		return
+	}
+
+	header := resp.Header()
+	header.Set("Content-Type", mimeEventStream)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	resp.WriteHeader(http.StatusOK)
+	fmt.Fprintf(resp, "retry: %d\n\n", int64(watchRetry/time.Millisecond))
+	flusher.Flush()
+
+	lastWrite := time.Now()
+	for {
+		for _, watched := range events {
+			data, _ := json.Marshal(watched.event)
+			if _, err := fmt.Fprintf(resp, "id: %d\nevent: %s\ndata: %s\n\n", watched.cursor, watched.event.GetType(), data); err != nil {
+				return
+			}
+			lastWrite = time.Now()
+		}
+		if len(events) == 0 && time.Since(lastWrite) >= watchHeartbeat {
+			if _, err := fmt.Fprint(resp, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			lastWrite = time.Now()
+		}
+		flusher.Flush()
+
+		select {
+		case <-req.Request.Context().Done():
+			return
+		case <-time.After(watchPollInterval):
+		}
+
+		events, err = s.readWatchEvents(opt)
+		if err != nil {
+			blog.Errorf("watch events from cursor %d failed, err: %v", opt.cursor, err)
+			code := common.CCErrEventWatchFailed
+			if err == errWatchCursorExpired {
+				code = common.CCErrEventWatchCursorExpired
+			}
+			data, _ := json.Marshal(metadata.RespError{Msg: defErr.Error(code)})
+			fmt.Fprintf(resp, "event: error\ndata: %s\n\n", data)
+			flusher.Flush()
+			return
+		}
+	}
+}
+
+func (s *Service) parseWatchOption(req *restful.Request) (*watchOption, error) {
+	opt := &watchOption{
+		ownerID:    util.GetOwnerID(req.Request.Header),
+		eventTypes: make(map[string]bool),
+		limit:      defaultWatchLimit,
+		timeout:    defaultWatchTimeout,
+	}
+
+	cursor := req.QueryParameter("cursor")
+	if cursor == "" {
+		cursor = req.Request.Header.Get("Last-Event-ID")
+	}
+	if cursor == "" {
+		// watch from the latest event
+		latest, err := s.cache.ZRevRangeWithScores(types.EventCacheWatchKey, 0, 0).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(latest) > 0 {
+			opt.cursor = int64(latest[0].Score)
+		}
+	} else {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("invalid cursor %s", cursor)
+		}
+		opt.cursor = id
+	}
+
+	for _, eventType := range strings.Split(req.QueryParameter("event_types"), ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			opt.eventTypes[eventType] = true
+		}
+	}
+
+	if limit := req.QueryParameter("limit"); limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid limit %s", limit)
+		}
+		if n > maxWatchLimit {
+			n = maxWatchLimit
+		}
+		opt.limit = n
+	}
+
+	if timeout := req.QueryParameter("timeout"); timeout != "" {
+		seconds, err := strconv.ParseInt(timeout, 10, 64)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid timeout %s", timeout)
+		}
+		opt.timeout = time.Duration(seconds) * time.Second
+		if opt.timeout > maxWatchTimeout {
+			opt.timeout = maxWatchTimeout
+		}
+	}
+
+	return opt, nil
+}
+
+// readWatchEvents read the events after the cursor of the owner, and move the
+// cursor forward, the cursor moves over the events which are filtered out.
+// the events are scored by the watch sequence without gaps, so the cursor is
+// expired if the event right after it has been removed, except cursor 0 which
+// reads from the oldest kept event.
+func (s *Service) readWatchEvents(opt *watchOption) ([]watchEvent, error) {
+	if opt.cursor > 0 {
+		oldest, err := s.cache.ZRangeWithScores(types.EventCacheWatchKey, 0, 0).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(oldest) > 0 && int64(oldest[0].Score) > opt.cursor+1 {
+			return nil, errWatchCursorExpired
+		}
+	}
+
+	members, err := s.cache.ZRangeByScoreWithScores(types.EventCacheWatchKey, redis.ZRangeBy{
+		Min:   "(" + strconv.FormatInt(opt.cursor, 10),
+		Max:   "+inf",
+		Count: opt.limit,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]watchEvent, 0)
+	for _, member := range members {
+		opt.cursor = int64(member.Score)
+		raw, _ := member.Member.(string)
+		event := metadata.EventInst{}
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			blog.Errorf("unmarshal watching event failed, err: %v, data: %s", err, raw)
+			continue
+		}
+		if event.OwnerID != opt.ownerID {
+			continue
+		}
+		if len(opt.eventTypes) > 0 && !opt.eventTypes[event.GetType()] {
+			continue
+		}
+		events = append(events, watchEvent{cursor: opt.cursor, event: event})
+	}
+	return events, nil
+}
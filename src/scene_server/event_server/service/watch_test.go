@@ -0,0 +1,204 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	"github.com/emicklei/go-restful"
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+func newTestWatchService(t *testing.T) (*Service, *miniredis.Miniredis) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run redis failed, err: %v", err)
+	}
+	s := &Service{Engine: &backbone.Engine{CCErr: errors.NewFromCtx(map[string]errors.ErrorCode{})}}
+	s.SetCache(redis.NewClient(&redis.Options{Addr: server.Addr()}))
+	return s, server
+}
+
+// addWatchEvents save the events for watching with the watch sequence from 1
+func addWatchEvents(t *testing.T, s *Service, events ...metadata.EventInst) {
+	for idx, event := range events {
+		member, _ := json.Marshal(event)
+		if err := s.cache.ZAdd(types.EventCacheWatchKey, redis.Z{Score: float64(idx + 1), Member: string(member)}).Err(); err != nil {
+			t.Fatalf("save watching event failed, err: %v", err)
+		}
+	}
+}
+
+func newWatchEvent(id int64, ownerID, objType, action string) metadata.EventInst {
+	return metadata.EventInst{
+		ID:        id,
+		EventType: metadata.EventTypeInstData,
+		Action:    action,
+		ObjType:   objType,
+		OwnerID:   ownerID,
+	}
+}
+
+func newWatchRequest(target string, header http.Header) *restful.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for key := range header {
+		req.Header.Set(key, header.Get(key))
+	}
+	req.Header.Set(common.BKHTTPOwnerID, common.BKDefaultOwnerID)
+	return restful.NewRequest(req)
+}
+
+func newWatchResponse(recorder *httptest.ResponseRecorder) *restful.Response {
+	resp := restful.NewResponse(recorder)
+	resp.SetRequestAccepts(restful.MIME_JSON)
+	return resp
+}
+
+func TestParseWatchOption(t *testing.T) {
+	s, server := newTestWatchService(t)
+	defer server.Close()
+
+	opt, err := s.parseWatchOption(newWatchRequest("/watch", nil))
+	if err != nil {
+		t.Fatalf("parse watch option failed, err: %v", err)
+	}
+	if opt.cursor != 0 || opt.limit != defaultWatchLimit || opt.timeout != defaultWatchTimeout || opt.ownerID != common.BKDefaultOwnerID {
+		t.Errorf("unexpected default watch option %+v", opt)
+	}
+
+	addWatchEvents(t, s,
+		newWatchEvent(10, common.BKDefaultOwnerID, common.BKInnerObjIDHost, metadata.EventActionCreate),
+		newWatchEvent(11, common.BKDefaultOwnerID, common.BKInnerObjIDHost, metadata.EventActionUpdate),
+	)
+	if opt, err = s.parseWatchOption(newWatchRequest("/watch", nil)); err != nil || opt.cursor != 2 {
+		t.Errorf("watch without cursor should start from the latest sequence 2, but got %+v, err: %v", opt, err)
+	}
+
+	header := http.Header{}
+	header.Set("Last-Event-ID", "1")
+	opt, err = s.parseWatchOption(newWatchRequest("/watch?event_types=hostcreate,+hostupdate&limit=5000&timeout=1", header))
+	if err != nil {
+		t.Fatalf("parse watch option failed, err: %v", err)
+	}
+	if opt.cursor != 1 || opt.limit != maxWatchLimit || opt.timeout.Seconds() != 1 ||
+		len(opt.eventTypes) != 2 || !opt.eventTypes["hostcreate"] || !opt.eventTypes["hostupdate"] {
+		t.Errorf("unexpected watch option %+v", opt)
+	}
+
+	for _, target := range []string{"/watch?cursor=-1", "/watch?cursor=abc", "/watch?limit=0", "/watch?timeout=-1"} {
+		if _, err := s.parseWatchOption(newWatchRequest(target, nil)); err == nil {
+			t.Errorf("parse watch option %s should fail", target)
+		}
+	}
+}
+
+func TestReadWatchEvents(t *testing.T) {
+	s, server := newTestWatchService(t)
+	defer server.Close()
+
+	addWatchEvents(t, s,
+		newWatchEvent(4, common.BKDefaultOwnerID, common.BKInnerObjIDHost, metadata.EventActionCreate),
+		newWatchEvent(2, "other", common.BKInnerObjIDHost, metadata.EventActionCreate),
+		newWatchEvent(3, common.BKDefaultOwnerID, common.BKInnerObjIDModule, metadata.EventActionCreate),
+		newWatchEvent(1, common.BKDefaultOwnerID, common.BKInnerObjIDHost, metadata.EventActionUpdate),
+	)
+
+	// the other owner's events are skipped, and the cursor moves over them
+	opt := &watchOption{ownerID: common.BKDefaultOwnerID, cursor: 0, limit: 2}
+	events, err := s.readWatchEvents(opt)
+	if err != nil {
+		t.Fatalf("read watching events failed, err: %v", err)
+	}
+	if len(events) != 1 || events[0].event.ID != 4 || events[0].cursor != 1 || opt.cursor != 2 {
+		t.Errorf("unexpected events %+v, cursor %d", events, opt.cursor)
+	}
+
+	events, err = s.readWatchEvents(opt)
+	if err != nil {
+		t.Fatalf("read watching events failed, err: %v", err)
+	}
+	if len(events) != 2 || events[0].event.ID != 3 || events[0].cursor != 3 || events[1].event.ID != 1 || events[1].cursor != 4 || opt.cursor != 4 {
+		t.Errorf("unexpected events %+v, cursor %d", events, opt.cursor)
+	}
+
+	if events, err = s.readWatchEvents(opt); err != nil || len(events) != 0 || opt.cursor != 4 {
+		t.Errorf("there should be no events after the latest cursor, but got %+v, err: %v", events, err)
+	}
+
+	// the event types filter
+	opt = &watchOption{ownerID: common.BKDefaultOwnerID, cursor: 1, limit: 10, eventTypes: map[string]bool{"hostupdate": true}}
+	if events, err = s.readWatchEvents(opt); err != nil || len(events) != 1 || events[0].event.ID != 1 || opt.cursor != 4 {
+		t.Errorf("only the host update event should be returned, but got %+v, err: %v", events, err)
+	}
+
+	// remove the oldest two events, the cursor right before the oldest kept event is still valid,
+	// the older cursors are expired, and cursor 0 still reads from the oldest kept event
+	if err := s.cache.ZRemRangeByRank(types.EventCacheWatchKey, 0, 1).Err(); err != nil {
+		t.Fatalf("remove watching events failed, err: %v", err)
+	}
+	if _, err := s.readWatchEvents(&watchOption{ownerID: common.BKDefaultOwnerID, cursor: 2, limit: 10}); err != nil {
+		t.Errorf("cursor 2 should be valid, but got err: %v", err)
+	}
+	if _, err := s.readWatchEvents(&watchOption{ownerID: common.BKDefaultOwnerID, cursor: 1, limit: 10}); err != errWatchCursorExpired {
+		t.Errorf("cursor 1 should be expired, but got err: %v", err)
+	}
+	opt = &watchOption{ownerID: common.BKDefaultOwnerID, cursor: 0, limit: 10}
+	if events, err = s.readWatchEvents(opt); err != nil || len(events) != 2 || events[0].cursor != 3 || opt.cursor != 4 {
+		t.Errorf("cursor 0 should read from the oldest kept event, but got %+v, err: %v", events, err)
+	}
+}
+
+func TestWatchLongPoll(t *testing.T) {
+	s, server := newTestWatchService(t)
+	defer server.Close()
+
+	addWatchEvents(t, s,
+		newWatchEvent(7, common.BKDefaultOwnerID, common.BKInnerObjIDHost, metadata.EventActionCreate),
+		newWatchEvent(8, common.BKDefaultOwnerID, common.BKInnerObjIDHost, metadata.EventActionDelete),
+	)
+
+	recorder := httptest.NewRecorder()
+	s.Watch(newWatchRequest("/watch?cursor=0&timeout=0", nil), newWatchResponse(recorder))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("watch from cursor 0 should succeed, but got status %d, body: %s", recorder.Code, recorder.Body.String())
+	}
+	result := struct {
+		metadata.BaseResp
+		Data metadata.RspWatchEvent `json:"data"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatalf("unmarshal watch response failed, err: %v", err)
+	}
+	if !result.Result || result.Data.Cursor != 2 || len(result.Data.Events) != 2 || result.Data.Events[1].ID != 8 {
+		t.Errorf("unexpected watch response %s", recorder.Body.String())
+	}
+
+	if err := s.cache.ZRemRangeByRank(types.EventCacheWatchKey, 0, 0).Err(); err != nil {
+		t.Fatalf("remove watching events failed, err: %v", err)
+	}
+	recorder = httptest.NewRecorder()
+	s.Watch(newWatchRequest("/watch?cursor=0&timeout=0", nil), newWatchResponse(recorder))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("watch from cursor 0 should not expire, but got status %d", recorder.Code)
+	}
+}
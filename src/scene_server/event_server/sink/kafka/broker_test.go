@@ -0,0 +1,300 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// the apis and the versions the fake broker supports
+const (
+	apiProduce     int16 = 0
+	apiMetadata    int16 = 3
+	apiApiVersions int16 = 18
+
+	errLeaderNotAvailable int16 = 5
+)
+
+type fakeRecord struct {
+	key     string
+	value   string
+	headers map[string]string
+}
+
+// fakeBroker is an in-process kafka broker with one partition for every topic,
+// it supports the apis used by the kafka-go writer and keeps the records in memory.
+type fakeBroker struct {
+	t    *testing.T
+	ln   net.Listener
+	host string
+	port int32
+
+	lock    sync.Mutex
+	records map[string][]fakeRecord
+	// failing the topics whose produce requests are failed
+	failing map[string]bool
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("start fake broker failed, err: %v", err)
+	}
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	b := &fakeBroker{
+		t:       t,
+		ln:      ln,
+		host:    host,
+		port:    int32(portNum),
+		records: make(map[string][]fakeRecord),
+		failing: make(map[string]bool),
+	}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(c)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) addr() string {
+	return b.ln.Addr().String()
+}
+
+func (b *fakeBroker) close() {
+	b.ln.Close()
+}
+
+func (b *fakeBroker) setFailing(topic string, failing bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.failing[topic] = failing
+}
+
+func (b *fakeBroker) topicRecords(topic string) []fakeRecord {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]fakeRecord{}, b.records[topic]...)
+}
+
+func (b *fakeBroker) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	for {
+		var size int32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return
+		}
+		req := make([]byte, size)
+		if _, err := io.ReadFull(r, req); err != nil {
+			return
+		}
+
+		d := &decoder{b: req}
+		apiKey, _, correlationID := d.int16(), d.int16(), d.int32()
+		d.string() // client id
+
+		body := new(encoder)
+		switch apiKey {
+		case apiApiVersions:
+			body.int16(0)
+			body.int32(3)
+			for _, v := range [][3]int16{{apiProduce, 0, 3}, {apiMetadata, 0, 1}, {apiApiVersions, 0, 0}} {
+				body.int16(v[0])
+				body.int16(v[1])
+				body.int16(v[2])
+			}
+		case apiMetadata:
+			b.metadata(d, body)
+		case apiProduce:
+			b.produce(d, body)
+		default:
+			b.t.Errorf("unsupported api %d", apiKey)
+			return
+		}
+
+		resp := new(encoder)
+		resp.int32(int32(4 + body.Len()))
+		resp.int32(correlationID)
+		resp.Write(body.Bytes())
+		if _, err := c.Write(resp.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+// metadata handle the metadata v1 request, every topic has one partition on the broker
+func (b *fakeBroker) metadata(d *decoder, body *encoder) {
+	topics := make([]string, d.int32())
+	for idx := range topics {
+		topics[idx] = d.string()
+	}
+
+	body.int32(1)
+	body.int32(1)
+	body.string(b.host)
+	body.int32(b.port)
+	body.int16(-1) // rack
+	body.int32(1)  // controller
+
+	body.int32(int32(len(topics)))
+	for _, topic := range topics {
+		body.int16(0)
+		body.string(topic)
+		body.int8(0)
+		body.int32(1)
+		body.int16(0)
+		body.int32(0) // partition
+		body.int32(1) // leader
+		body.int32(1) // replicas
+		body.int32(1)
+		body.int32(1) // isr
+		body.int32(1)
+	}
+}
+
+// produce handle the produce v3 request, the records are saved unless the topic is failing
+func (b *fakeBroker) produce(d *decoder, body *encoder) {
+	if size := d.int16(); size > 0 {
+		d.next(int(size)) // transactional id
+	}
+	d.int16() // acks
+	d.int32() // timeout
+
+	topicCount := d.int32()
+	body.int32(topicCount)
+	for i := int32(0); i < topicCount; i++ {
+		topic := d.string()
+		partitionCount := d.int32()
+		body.string(topic)
+		body.int32(partitionCount)
+		for j := int32(0); j < partitionCount; j++ {
+			partition := d.int32()
+			records := decodeRecordBatch(&decoder{b: d.next(int(d.int32()))})
+
+			b.lock.Lock()
+			errCode, offset := int16(0), int64(len(b.records[topic]))
+			if b.failing[topic] {
+				errCode = errLeaderNotAvailable
+			} else {
+				b.records[topic] = append(b.records[topic], records...)
+			}
+			b.lock.Unlock()
+
+			body.int32(partition)
+			body.int16(errCode)
+			body.int64(offset)
+			body.int64(-1)
+		}
+	}
+	body.int32(0) // throttle time
+}
+
+// decodeRecordBatch decode the uncompressed record batch v2
+func decodeRecordBatch(d *decoder) []fakeRecord {
+	// base offset, batch length, leader epoch, magic, crc, attributes, last offset delta,
+	// first timestamp, max timestamp, producer id, producer epoch and base sequence
+	d.next(8 + 4 + 4 + 1 + 4 + 2 + 4 + 8 + 8 + 8 + 2 + 4)
+	records := make([]fakeRecord, d.int32())
+	for idx := range records {
+		d.varint() // length
+		d.int8()   // attributes
+		d.varint() // timestamp delta
+		d.varint() // offset delta
+		records[idx].key = string(d.varbytes())
+		records[idx].value = string(d.varbytes())
+		records[idx].headers = make(map[string]string)
+		for count := d.varint(); count > 0; count-- {
+			key := string(d.varbytes())
+			records[idx].headers[key] = string(d.varbytes())
+		}
+	}
+	return records
+}
+
+type decoder struct {
+	b []byte
+}
+
+func (d *decoder) next(n int) []byte {
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) int8() int8 {
+	return int8(d.next(1)[0])
+}
+
+func (d *decoder) int16() int16 {
+	return int16(binary.BigEndian.Uint16(d.next(2)))
+}
+
+func (d *decoder) int32() int32 {
+	return int32(binary.BigEndian.Uint32(d.next(4)))
+}
+
+func (d *decoder) string() string {
+	return string(d.next(int(d.int16())))
+}
+
+func (d *decoder) varint() int64 {
+	v, n := binary.Varint(d.b)
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) varbytes() []byte {
+	size := d.varint()
+	if size < 0 {
+		return nil
+	}
+	return d.next(int(size))
+}
+
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) int8(v int8) {
+	e.WriteByte(byte(v))
+}
+
+func (e *encoder) int16(v int16) {
+	binary.Write(e, binary.BigEndian, v)
+}
+
+func (e *encoder) int32(v int32) {
+	binary.Write(e, binary.BigEndian, v)
+}
+
+func (e *encoder) int64(v int64) {
+	binary.Write(e, binary.BigEndian, v)
+}
+
+func (e *encoder) string(v string) {
+	e.int16(int16(len(v)))
+	e.WriteString(v)
+}
@@ -0,0 +1,259 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package kafka send the events to kafka with github.com/segmentio/kafka-go
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/payload"
+	"configcenter/src/scene_server/event_server/sink"
+)
+
+// SinkName the name of the kafka sink
+const SinkName = "kafka"
+
+// the headers of the event messages
+const (
+	HeaderEventID   = "bk_event_id"
+	HeaderEventType = "bk_event_type"
+	HeaderAction    = "bk_action"
+	HeaderOwnerID   = "bk_supplier_account"
+	// HeaderDedupKey the unique key of the message, the messages are sent at least once,
+	// so the consumers drop the duplicated messages by it.
+	HeaderDedupKey = "bk_dedup_key"
+)
+
+const (
+	defaultTopicPrefix = "bk_cmdb_"
+	defaultClientID    = "bk-cmdb"
+	defaultTimeout     = 10 * time.Second
+	defaultLinger      = 100 * time.Millisecond
+)
+
+func init() {
+	sink.Register(SinkName, NewSinkFromConfig)
+}
+
+// Sink send every data of the events as a message to the topic of its object
+// type, the key of the message is the instance id, so that the changes of an
+// instance are in order. the message value is the event with the only data.
+// the messages are sent at least once, see HeaderDedupKey.
+type Sink struct {
+	config      kafka.WriterConfig
+	topicPrefix string
+
+	lock    sync.Mutex
+	writers map[string]*kafka.Writer
+
+	// acked the messages written by the last failed Send, which are skipped when
+	// the events are sent again, it's reset once the events are sent or dropped.
+	ackLock sync.Mutex
+	acked   map[string]bool
+}
+
+// NewSinkFromConfig create the kafka sink from the config, such as:
+// [kafka]
+// brokers=127.0.0.1:9092,127.0.0.2:9092
+// topic_prefix=bk_cmdb_
+func NewSinkFromConfig(prefix string, configmap map[string]string) (sink.Sink, error) {
+	config := kafka.WriterConfig{
+		Dialer:       &kafka.Dialer{ClientID: defaultClientID, Timeout: defaultTimeout, DualStack: true},
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: -1,
+		BatchTimeout: defaultLinger,
+		ReadTimeout:  defaultTimeout,
+		WriteTimeout: defaultTimeout,
+	}
+	for _, broker := range strings.Split(configmap[prefix+".brokers"], ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			config.Brokers = append(config.Brokers, broker)
+		}
+	}
+	if clientID := configmap[prefix+".client_id"]; clientID != "" {
+		config.Dialer.ClientID = clientID
+	}
+	if timeout, err := time.ParseDuration(configmap[prefix+".timeout"]); err == nil && timeout > 0 {
+		config.Dialer.Timeout = timeout
+		config.ReadTimeout = timeout
+		config.WriteTimeout = timeout
+	}
+	if linger, err := time.ParseDuration(configmap[prefix+".linger"]); err == nil && linger > 0 {
+		config.BatchTimeout = linger
+	}
+	if size, err := strconv.Atoi(configmap[prefix+".batch_size"]); err == nil && size > 0 {
+		config.BatchSize = size
+	}
+	if max, err := strconv.Atoi(configmap[prefix+".max_buffered"]); err == nil && max > 0 {
+		config.QueueCapacity = max
+	}
+
+	topicPrefix, ok := configmap[prefix+".topic_prefix"]
+	if !ok {
+		topicPrefix = defaultTopicPrefix
+	}
+	return NewSink(config, topicPrefix)
+}
+
+// NewSink returns a kafka sink, the writers of the topics are created with
+// the config when the events are sent to them.
+func NewSink(config kafka.WriterConfig, topicPrefix string) (*Sink, error) {
+	if len(config.Brokers) == 0 {
+		return nil, errors.New("kafka brokers is not set")
+	}
+	return &Sink{
+		config:      config,
+		topicPrefix: topicPrefix,
+		writers:     make(map[string]*kafka.Writer),
+		acked:       make(map[string]bool),
+	}, nil
+}
+
+// Name the name of the sink
+func (s *Sink) Name() string {
+	return SinkName
+}
+
+// Send write the messages of the events and wait until they are acked,
+// the messages of every topic are written in the order of the events.
+// the topics written before a failed one are skipped when the events are
+// sent again, so that only the failed messages are sent twice.
+func (s *Sink) Send(ctx context.Context, events []*metadata.EventInst) error {
+	s.ackLock.Lock()
+	defer s.ackLock.Unlock()
+
+	topics := make([]string, 0)
+	msgs := make(map[string][]kafka.Message)
+	for _, event := range events {
+		eventMsgs, err := s.messages(event)
+		if err != nil {
+			s.acked = make(map[string]bool)
+			return &sink.PermanentError{Err: err}
+		}
+		topic := s.Topic(event.ObjType)
+		if _, ok := msgs[topic]; !ok {
+			topics = append(topics, topic)
+		}
+		for _, msg := range eventMsgs {
+			if !s.acked[ackKey(topic, msg)] {
+				msgs[topic] = append(msgs[topic], msg)
+			}
+		}
+	}
+
+	for _, topic := range topics {
+		if err := s.writer(topic).WriteMessages(ctx, msgs[topic]...); err != nil {
+			if isPermanent(err) {
+				s.acked = make(map[string]bool)
+				return &sink.PermanentError{Err: err}
+			}
+			return err
+		}
+		for _, msg := range msgs[topic] {
+			s.acked[ackKey(topic, msg)] = true
+		}
+	}
+	s.acked = make(map[string]bool)
+	return nil
+}
+
+// Close close the writers of all the topics
+func (s *Sink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var closeErr error
+	for topic, writer := range s.writers {
+		if err := writer.Close(); err != nil {
+			closeErr = err
+		}
+		delete(s.writers, topic)
+	}
+	return closeErr
+}
+
+// Topic returns the topic of the object type
+func (s *Sink) Topic(objType string) string {
+	return s.topicPrefix + objType
+}
+
+func (s *Sink) writer(topic string) *kafka.Writer {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	writer, ok := s.writers[topic]
+	if !ok {
+		config := s.config
+		config.Topic = topic
+		writer = kafka.NewWriter(config)
+		s.writers[topic] = writer
+	}
+	return writer
+}
+
+func (s *Sink) messages(event *metadata.EventInst) ([]kafka.Message, error) {
+	msgs := make([]kafka.Message, 0, len(event.Data))
+	eventID := strconv.FormatInt(event.ID, 10)
+	for idx, data := range event.Data {
+		single := *event
+		single.Data = []metadata.EventData{data}
+		value, err := json.Marshal(single)
+		if err != nil {
+			return nil, err
+		}
+
+		msgs = append(msgs, kafka.Message{
+			Key:   []byte(payload.InstID(event.ObjType, data)),
+			Value: value,
+			Headers: []kafka.Header{
+				{Key: HeaderEventID, Value: []byte(eventID)},
+				{Key: HeaderEventType, Value: []byte(event.EventType)},
+				{Key: HeaderAction, Value: []byte(event.Action)},
+				{Key: HeaderOwnerID, Value: []byte(event.OwnerID)},
+				// the data of an event is in the same order when it's sent again
+				{Key: HeaderDedupKey, Value: []byte(eventID + "-" + strconv.Itoa(idx))},
+			},
+		})
+	}
+	return msgs, nil
+}
+
+// ackKey returns the key of the written message in the topic
+func ackKey(topic string, msg kafka.Message) string {
+	for _, header := range msg.Headers {
+		if header.Key == HeaderDedupKey {
+			return topic + "/" + string(header.Value)
+		}
+	}
+	return topic
+}
+
+// isPermanent check whether the messages are rejected by kafka and can never be written
+func isPermanent(err error) bool {
+	switch e := err.(type) {
+	case kafka.MessageTooLargeError:
+		return true
+	case kafka.Error:
+		return !e.Temporary()
+	}
+	return false
+}
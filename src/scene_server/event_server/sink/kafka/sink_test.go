@@ -0,0 +1,171 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/sink"
+)
+
+func TestNewSinkFromConfig(t *testing.T) {
+	if _, err := NewSinkFromConfig("kafka", map[string]string{}); err == nil {
+		t.Errorf("create the sink without brokers should fail")
+	}
+
+	s, err := NewSinkFromConfig("kafka", map[string]string{
+		"kafka.brokers":    "127.0.0.1:9092, 127.0.0.2:9092",
+		"kafka.client_id":  "cmdb",
+		"kafka.timeout":    "1s",
+		"kafka.linger":     "10ms",
+		"kafka.batch_size": "abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := s.(*Sink).config
+	if len(config.Brokers) != 2 || config.Brokers[1] != "127.0.0.2:9092" {
+		t.Errorf("unexpected brokers %v", config.Brokers)
+	}
+	if config.Dialer.ClientID != "cmdb" || config.WriteTimeout != time.Second || config.BatchTimeout != 10*time.Millisecond || config.BatchSize != 0 {
+		t.Errorf("unexpected writer config %+v", config)
+	}
+	if topic := s.(*Sink).Topic("host"); topic != defaultTopicPrefix+"host" {
+		t.Errorf("unexpected topic %s", topic)
+	}
+}
+
+func TestSinkMessages(t *testing.T) {
+	s, err := NewSink(kafka.WriterConfig{Brokers: []string{"127.0.0.1:9092"}}, defaultTopicPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := &metadata.EventInst{
+		ID:        12,
+		EventType: metadata.EventTypeInstData,
+		Action:    metadata.EventActionUpdate,
+		ObjType:   "host",
+		OwnerID:   "0",
+		Data: []metadata.EventData{
+			{CurData: map[string]interface{}{"bk_host_id": 1}},
+			{PreData: map[string]interface{}{"bk_host_id": 2}},
+		},
+	}
+	msgs, err := s.messages(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("every data should be a message, but got %d messages", len(msgs))
+	}
+
+	for idx, key := range []string{"1", "2"} {
+		if string(msgs[idx].Key) != key {
+			t.Errorf("the message should be keyed by the host id %s, but got %s", key, msgs[idx].Key)
+		}
+		headers := make(map[string]string)
+		for _, header := range msgs[idx].Headers {
+			headers[header.Key] = string(header.Value)
+		}
+		if headers[HeaderEventID] != "12" || headers[HeaderAction] != metadata.EventActionUpdate || headers[HeaderOwnerID] != "0" ||
+			headers[HeaderDedupKey] != "12-"+strconv.Itoa(idx) {
+			t.Errorf("unexpected headers %v", headers)
+		}
+		inst := metadata.EventInst{}
+		if err := json.Unmarshal(msgs[idx].Value, &inst); err != nil {
+			t.Fatal(err)
+		}
+		if len(inst.Data) != 1 {
+			t.Errorf("every message should have only one data, but got %d", len(inst.Data))
+		}
+	}
+}
+
+func TestSinkSend(t *testing.T) {
+	broker := newFakeBroker(t)
+	defer broker.close()
+
+	s, err := NewSink(kafka.WriterConfig{
+		Brokers:      []string{broker.addr()},
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: -1,
+		BatchTimeout: 10 * time.Millisecond,
+		MaxAttempts:  1,
+	}, defaultTopicPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	events := []*metadata.EventInst{
+		{
+			ID: 1, EventType: metadata.EventTypeInstData, Action: metadata.EventActionCreate, ObjType: "host", OwnerID: "0",
+			Data: []metadata.EventData{
+				{CurData: map[string]interface{}{"bk_host_id": 1}},
+				{CurData: map[string]interface{}{"bk_host_id": 2}},
+			},
+		},
+		{
+			ID: 2, EventType: metadata.EventTypeInstData, Action: metadata.EventActionCreate, ObjType: "set", OwnerID: "0",
+			Data: []metadata.EventData{{CurData: map[string]interface{}{"bk_set_id": 3}}},
+		},
+	}
+	hostTopic, setTopic := s.Topic("host"), s.Topic("set")
+
+	// the set topic fails after the host topic is written
+	broker.setFailing(setTopic, true)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = s.Send(ctx, events)
+	if err == nil || sink.IsPermanent(err) {
+		t.Fatalf("send to the failing topic should fail temporarily, but got %v", err)
+	}
+	if records := broker.topicRecords(hostTopic); len(records) != 2 {
+		t.Fatalf("the host topic should be written, but got %d records", len(records))
+	}
+
+	// only the messages of the failed topic are sent again
+	broker.setFailing(setTopic, false)
+	if err := s.Send(ctx, events); err != nil {
+		t.Fatalf("send again failed, err: %v", err)
+	}
+	hostRecords, setRecords := broker.topicRecords(hostTopic), broker.topicRecords(setTopic)
+	if len(hostRecords) != 2 || len(setRecords) != 1 {
+		t.Fatalf("every message should be written once, but got %d host and %d set records", len(hostRecords), len(setRecords))
+	}
+	for idx, record := range hostRecords {
+		if record.key != []string{"1", "2"}[idx] || record.headers[HeaderDedupKey] != []string{"1-0", "1-1"}[idx] {
+			t.Errorf("unexpected host record %+v", record)
+		}
+	}
+	if setRecords[0].key != "3" || setRecords[0].headers[HeaderDedupKey] != "2-0" || setRecords[0].headers[HeaderEventID] != "2" {
+		t.Errorf("unexpected set record %+v", setRecords[0])
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	if !isPermanent(kafka.MessageSizeTooLarge) || !isPermanent(kafka.MessageTooLargeError{}) {
+		t.Errorf("the too large messages can never be written")
+	}
+	if isPermanent(kafka.LeaderNotAvailable) || isPermanent(kafka.RequestTimedOut) {
+		t.Errorf("the temporary errors should be retried")
+	}
+}
@@ -0,0 +1,121 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sink define the outbound sinks of the events besides the http callbacks,
+// such as a message queue. every event is sent to all the enabled sinks.
+package sink
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"configcenter/src/common/metadata"
+)
+
+// Sink send the events to an outbound system
+type Sink interface {
+	// Name the name the sink registered with
+	Name() string
+	// Send send the events in order, the events are sent again if it returns
+	// error, so the sink should skip the events sent before if it can. the
+	// events are delivered at least once, the receivers should be idempotent.
+	Send(ctx context.Context, events []*metadata.EventInst) error
+	// Close release the resources of the sink
+	Close() error
+}
+
+// Factory create the sink with the config, the config keys start with the prefix
+type Factory func(prefix string, configmap map[string]string) (Sink, error)
+
+var (
+	lock      sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register register a sink factory, the factory with the same name
+// registered before will be replaced.
+func Register(name string, factory Factory) {
+	lock.Lock()
+	defer lock.Unlock()
+	factories[name] = factory
+}
+
+// Names returns all the registered sinks
+func Names() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Config define the enabled sinks, such as:
+// [sink]
+// enabled=kafka
+// every sink read its config in the section of its name.
+type Config struct {
+	Enabled   []string
+	ConfigMap map[string]string
+}
+
+// ParseConfigFromKV returns new config
+func ParseConfigFromKV(prefix string, configmap map[string]string) Config {
+	cfg := Config{ConfigMap: configmap}
+	for _, name := range strings.Split(configmap[prefix+".enabled"], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cfg.Enabled = append(cfg.Enabled, name)
+		}
+	}
+	return cfg
+}
+
+// NewFromConfig create the enabled sinks
+func NewFromConfig(cfg Config) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(cfg.Enabled))
+	for _, name := range cfg.Enabled {
+		lock.RLock()
+		factory, ok := factories[name]
+		lock.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("event sink %s is not supported", name)
+		}
+
+		s, err := factory(name, cfg.ConfigMap)
+		if err != nil {
+			return nil, fmt.Errorf("create event sink %s failed, err: %v", name, err)
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+// PermanentError the events can never be sent, they should be dropped
+// instead of sending again.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+// IsPermanent check whether the error is a PermanentError
+func IsPermanent(err error) bool {
+	_, ok := err.(*PermanentError)
+	return ok
+}
@@ -34,6 +34,8 @@ const (
 	EventCacheDistRunningPrefix = common.BKCacheKeyV3Prefix + "event:dist_running_"
 	EventCacheDistTimeoutPrefix = common.BKCacheKeyV3Prefix + "event:dist_timeout_"
 	EventCacheDistDonePrefix    = common.BKCacheKeyV3Prefix + "event:dist_done_"
+	// EventCacheDistRetryPrefix the sorted set of the failed distributions, scored by the next retry time
+	EventCacheDistRetryPrefix = common.BKCacheKeyV3Prefix + "event:dist_retry_"
 
 	EventCacheDistCallBackCountPrefix = common.BKCacheKeyV3Prefix + "event:dist_callback_"
 
@@ -42,6 +44,20 @@ const (
 	EventCacheSubscribesKey    = common.BKCacheKeyV3Prefix + "event:subscribers"
 	EventCacheProcessChannel   = common.BKCacheKeyV3Prefix + "event_process_channel"
 
+	// EventCacheSubscribeFilterKey the hash of the subscription filters, the field is the subscription id
+	EventCacheSubscribeFilterKey = common.BKCacheKeyV3Prefix + "event:subscribe_filter"
+	// EventCacheWatchKey the sorted set of the latest events for watching, scored by the watch sequence
+	EventCacheWatchKey = common.BKCacheKeyV3Prefix + "event:watch"
+	// EventCacheWatchSeqKey the watch sequence, which is increased when an event is saved for watching
+	EventCacheWatchSeqKey = common.BKCacheKeyV3Prefix + "event:watch_seq"
+
+	// EventCacheSinkQueuePrefix the list of the events waiting to be sent to the sink, suffixed by the sink name
+	EventCacheSinkQueuePrefix = common.BKCacheKeyV3Prefix + "event:sink_queue_"
+	// EventCacheSinkProcessingPrefix the list of the events being sent to the sink
+	EventCacheSinkProcessingPrefix = common.BKCacheKeyV3Prefix + "event:sink_processing_"
+	// EventCacheSinkLockPrefix the lock of the event server which sends to the sink
+	EventCacheSinkLockPrefix = common.BKCacheKeyV3Prefix + "event:sink_lock_"
+
 	EventCacheIdentInstPrefix = common.BKCacheKeyV3Prefix + "ident:inst_"
 )
 
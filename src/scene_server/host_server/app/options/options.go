@@ -14,6 +14,8 @@ package options
 
 import (
 	"configcenter/src/common/core/cc/config"
+	"configcenter/src/common/cryptor"
+	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
 	"github.com/spf13/pflag"
 )
@@ -48,6 +50,11 @@ type Gse struct {
 }
 
 type Config struct {
-	Gse   Gse
-	Redis redis.Config
+	Gse        Gse
+	Redis      redis.Config
+	Encryption cryptor.Config
+	// Mongo the transactions of the host operations are started by its transaction config
+	Mongo mongo.Config
+	// CloudFixtureDir the dir of the fixture files of the fixture cloud vendor, only set in the test environments
+	CloudFixtureDir string
 }
@@ -24,10 +24,15 @@ import (
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/cryptor"
 	"configcenter/src/common/types"
 	"configcenter/src/common/version"
 	"configcenter/src/scene_server/host_server/app/options"
+	"configcenter/src/scene_server/host_server/cloudprovider"
 	hostsvc "configcenter/src/scene_server/host_server/service"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/mongo"
+	"configcenter/src/storage/dal/mongo/remote"
 	"configcenter/src/storage/dal/redis"
 )
 
@@ -38,7 +43,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 	}
 
 	service := new(hostsvc.Service)
-	hostSrv := new(HostServer)
+	hostSrv := &HostServer{Cryptor: new(cryptor.Holder)}
 
 	input := &backbone.BackboneParameter{
 		Regdiscv:     op.ServConf.RegDiscover,
@@ -67,10 +72,20 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 	if err != nil {
 		blog.Errorf("new redis client failed, err: %s", err.Error())
 	}
+	if hostSrv.Cryptor.Load() == nil {
+		return fmt.Errorf("new encryption envelope failed, the encryption config is invalid")
+	}
+
+	tx, err := newTransactionDB(engine, hostSrv.Config.Mongo)
+	if err != nil {
+		return fmt.Errorf("new transaction db failed, err: %v", err)
+	}
 
 	service.Engine = engine
 	service.Config = &hostSrv.Config
 	service.CacheDB = cacheDB
+	service.Cryptor = hostSrv.Cryptor
+	service.Tx = tx
 	hostSrv.Core = engine
 	hostSrv.Service = service
 
@@ -85,6 +100,8 @@ type HostServer struct {
 	Core    *backbone.Engine
 	Config  options.Config
 	Service *hostsvc.Service
+	// Cryptor the envelope of the latest encryption config, which is rebuilt when the config is updated
+	Cryptor *cryptor.Holder
 }
 
 func (h *HostServer) WebService() *restful.WebService {
@@ -103,6 +120,24 @@ func (h *HostServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 	h.Config.Redis.Password = current.ConfigMap["redis.pwd"]
 	h.Config.Redis.Port = current.ConfigMap["redis.port"]
 	h.Config.Redis.MasterName = current.ConfigMap["redis.user"]
+
+	h.Config.Encryption = cryptor.ParseConfigFromKV("encryption", current.ConfigMap)
+	if err := h.Cryptor.Update(h.Config.Encryption); err != nil {
+		blog.Errorf("new encryption envelope failed, keep the old master keys, err: %v", err)
+	}
+	h.Config.Mongo = mongo.ParseConfigFromKV("mongodb", current.ConfigMap)
+
+	h.Config.CloudFixtureDir = current.ConfigMap["cloud.fixture_dir"]
+	cloudprovider.SetFixtureDir(h.Config.CloudFixtureDir)
+}
+
+// newTransactionDB returns the db to start the transactions, nil if the transaction is not enabled.
+// the local transactions are kept in the process, so that they can not span the controllers.
+func newTransactionDB(engine *backbone.Engine, config mongo.Config) (dal.DB, error) {
+	if config.Transaction != mongo.TransactionEnable {
+		return nil, nil
+	}
+	return remote.NewWithDiscover(engine.ServiceManageInterface.TMServer().GetServers, config)
 }
 
 func newServerInfo(op *options.ServerOption) (*types.ServerInfo, error) {
@@ -0,0 +1,62 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package app
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/cryptor"
+)
+
+func TestRotateMasterKeyByConfigUpdate(t *testing.T) {
+	key1 := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	key2 := base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+	hostSrv := &HostServer{Cryptor: new(cryptor.Holder)}
+	hostSrv.onHostConfigUpdate(cc.ProcessConfig{}, cc.ProcessConfig{ConfigMap: map[string]string{
+		"encryption.master_keys": "k1:" + key1,
+		"encryption.active_key":  "k1",
+	}})
+	old, err := hostSrv.Cryptor.Load().Encrypt("my secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rotate to the new master key
+	hostSrv.onHostConfigUpdate(cc.ProcessConfig{}, cc.ProcessConfig{ConfigMap: map[string]string{
+		"encryption.master_keys": "k1:" + key1 + ",k2:" + key2,
+		"encryption.active_key":  "k2",
+	}})
+	envelope := hostSrv.Cryptor.Load()
+	text, err := envelope.Encrypt("my secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(text, "cc-enc:v1:k2:") {
+		t.Errorf("expect the secret encrypted by the rotated master key, got %s", text)
+	}
+	if secret, err := envelope.Decrypt(old); err != nil || secret != "my secret" {
+		t.Errorf("expect the secret encrypted by the old master key decrypted, got %s, err: %v", secret, err)
+	}
+
+	// the invalid config keeps the old master keys
+	hostSrv.onHostConfigUpdate(cc.ProcessConfig{}, cc.ProcessConfig{ConfigMap: map[string]string{
+		"encryption.master_keys": "k1:" + key1,
+		"encryption.active_key":  "k3",
+	}})
+	if hostSrv.Cryptor.Load() != envelope {
+		t.Error("expect the envelope kept on the invalid config")
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+// FixtureCloudVendor the vendor which obtain the cloud hosts from the fixture files
+const FixtureCloudVendor = "fixture"
+
+// SetFixtureDir register the fixture provider which reads the fixture files in
+// the dir, the provider is removed if the dir is empty. it's used to test the
+// cloud sync without a real cloud account, so it's disabled by default.
+func SetFixtureDir(dir string) {
+	if dir == "" {
+		unregister(FixtureCloudVendor)
+		return
+	}
+	Register(&fixture{dir: dir})
+}
+
+// fixture obtain the cloud hosts from a json file in the fixture dir, the file
+// name is the secret id of the account, it can't be a path out of the dir.
+// the content is a json array of hosts, such as:
+// [{"bk_host_innerip": "10.0.0.1", "bk_host_outerip": "", "bk_os_name": "linux", "bk_cloud_region": "local"}]
+type fixture struct {
+	dir string
+}
+
+func (f *fixture) Vendor() string {
+	return FixtureCloudVendor
+}
+
+func (f *fixture) GetHosts(ctx context.Context, account Account) ([]mapstr.MapStr, error) {
+	name := account.SecretID
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+		return nil, fmt.Errorf("invalid fixture name %s", name)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(f.dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]mapstr.MapStr, 0)
+	if err := json.Unmarshal(content, &hosts); err != nil {
+		return nil, fmt.Errorf("unmarshal fixture %s failed, err: %v", name, err)
+	}
+
+	for idx, host := range hosts {
+		if innerIP, ok := host[common.BKHostInnerIPField].(string); !ok || innerIP == "" {
+			return nil, fmt.Errorf("the %d host of fixture %s has no %s", idx, name, common.BKHostInnerIPField)
+		}
+		// the sync task compares these fields as string
+		for _, field := range []string{common.BKHostOuterIPField, common.BKOSNameField, common.BKHostCloudRegionField} {
+			value, err := host.String(field)
+			if err != nil {
+				return nil, err
+			}
+			host[field] = value
+		}
+	}
+
+	return hosts, nil
+}
@@ -0,0 +1,104 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudprovider
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+const fixtureHosts = `[
+	{"bk_host_innerip": "10.0.0.1", "bk_host_outerip": "1.1.1.1", "bk_os_name": "linux", "bk_cloud_region": "local"},
+	{"bk_host_innerip": "10.0.0.2"}
+]`
+
+func TestGetProvider(t *testing.T) {
+	provider, err := GetProvider("")
+	if err != nil {
+		t.Fatalf("get default provider failed, err: %v", err)
+	}
+	if provider.Vendor() != common.TencentCloudVendor {
+		t.Errorf("default provider should be %s, but got %s", common.TencentCloudVendor, provider.Vendor())
+	}
+
+	if _, err := GetProvider("not_exist"); err == nil {
+		t.Error("get not registered provider should fail")
+	}
+}
+
+func TestFixture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cloudprovider")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "hosts.json"), []byte(fixtureHosts), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "invalid.json"), []byte(`[{"bk_os_name": "linux"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the fixture provider is disabled by default
+	if _, err := GetProvider(FixtureCloudVendor); err == nil {
+		t.Fatal("the fixture provider should not be registered before the fixture dir is set")
+	}
+	SetFixtureDir(dir)
+	defer SetFixtureDir("")
+
+	provider, err := GetProvider(FixtureCloudVendor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hosts, err := provider.GetHosts(context.Background(), Account{SecretID: "hosts.json"})
+	if err != nil {
+		t.Fatalf("get hosts from fixture failed, err: %v", err)
+	}
+	checkFixtureHosts(t, hosts)
+
+	if _, err := provider.GetHosts(context.Background(), Account{SecretID: "invalid.json"}); err == nil {
+		t.Error("host without inner ip should fail")
+	}
+
+	// only the files in the fixture dir can be read
+	for _, name := range []string{"", "..", "../hosts.json", filepath.Join(dir, "hosts.json"), "file://" + filepath.Join(dir, "hosts.json"), "http://127.0.0.1/hosts.json"} {
+		if _, err := provider.GetHosts(context.Background(), Account{SecretID: name}); err == nil {
+			t.Errorf("get hosts from fixture %s should fail", name)
+		}
+	}
+
+	SetFixtureDir("")
+	if _, err := GetProvider(FixtureCloudVendor); err == nil {
+		t.Error("the fixture provider should be removed when the fixture dir is cleared")
+	}
+}
+
+func checkFixtureHosts(t *testing.T, hosts []mapstr.MapStr) {
+	if len(hosts) != 2 {
+		t.Fatalf("should get 2 hosts, but got %d", len(hosts))
+	}
+	if hosts[0][common.BKHostOuterIPField] != "1.1.1.1" {
+		t.Errorf("unexpected outer ip %v", hosts[0][common.BKHostOuterIPField])
+	}
+	if osName, ok := hosts[1][common.BKOSNameField].(string); !ok || osName != "" {
+		t.Errorf("missing os name should be filled with empty string, but got %v", hosts[1][common.BKOSNameField])
+	}
+}
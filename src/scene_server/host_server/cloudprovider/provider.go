@@ -0,0 +1,86 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+// Account the credential used by a cloud provider to obtain the cloud hosts
+type Account struct {
+	SecretID  string
+	SecretKey string
+}
+
+// CloudProvider the driver of a cloud vendor used by the cloud sync task.
+// every host returned by GetHosts contains bk_host_innerip, bk_host_outerip,
+// bk_os_name and bk_cloud_region fields.
+type CloudProvider interface {
+	// Vendor the vendor name the provider registered with, same as bk_cloud_vendor
+	Vendor() string
+	// GetHosts obtain all the cloud hosts belongs to the account
+	GetHosts(ctx context.Context, account Account) ([]mapstr.MapStr, error)
+}
+
+var (
+	lock      sync.RWMutex
+	providers = make(map[string]CloudProvider)
+)
+
+// Register register a cloud provider, the provider with the same vendor
+// registered before will be replaced.
+func Register(provider CloudProvider) {
+	lock.Lock()
+	defer lock.Unlock()
+	providers[provider.Vendor()] = provider
+}
+
+func unregister(vendor string) {
+	lock.Lock()
+	defer lock.Unlock()
+	delete(providers, vendor)
+}
+
+// GetProvider get the cloud provider of the vendor, empty vendor means
+// the tencent cloud which is the only vendor supported before.
+func GetProvider(vendor string) (CloudProvider, error) {
+	if vendor == "" {
+		vendor = common.TencentCloudVendor
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	provider, ok := providers[vendor]
+	if !ok {
+		return nil, fmt.Errorf("cloud vendor %s is not supported", vendor)
+	}
+	return provider, nil
+}
+
+// Vendors returns all the registered vendors
+func Vendors() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	vendors := make([]string, 0, len(providers))
+	for vendor := range providers {
+		vendors = append(vendors, vendor)
+	}
+	sort.Strings(vendors)
+	return vendors
+}
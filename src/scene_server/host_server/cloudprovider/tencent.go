@@ -0,0 +1,118 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudprovider
+
+import (
+	"context"
+	"encoding/json"
+
+	com "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/regions"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+)
+
+func init() {
+	Register(&tencentCloud{})
+}
+
+// tencentCloud obtain the cloud hosts with the tencent cloud cvm api
+type tencentCloud struct{}
+
+func (t *tencentCloud) Vendor() string {
+	return common.TencentCloudVendor
+}
+
+func (t *tencentCloud) GetHosts(ctx context.Context, account Account) ([]mapstr.MapStr, error) {
+	credential := com.NewCredential(
+		account.SecretID,
+		account.SecretKey,
+	)
+
+	cpf := profile.NewClientProfile()
+	cpf.HttpProfile.ReqMethod = common.BKHttpGet
+	cpf.HttpProfile.ReqTimeout = common.BKTencentCloudTimeOut
+	cpf.HttpProfile.Endpoint = common.TencentCloudUrl
+	cpf.SignMethod = common.TencentCloudSignMethod
+
+	ClientRegion, _ := cvm.NewClient(credential, regions.Guangzhou, cpf)
+	regionRequest := cvm.NewDescribeRegionsRequest()
+	Response, err := ClientRegion.DescribeRegions(regionRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data := Response.ToJsonString()
+	regionResponse := new(meta.RegionResponse)
+	if err := json.Unmarshal([]byte(data), regionResponse); err != nil {
+		blog.Errorf("json unmarsha1 error :%v\n", err)
+		return nil, err
+	}
+
+	cloudHostInfo := make([]mapstr.MapStr, 0)
+	for _, region := range regionResponse.Response.Data {
+		var inneripList string
+		var outeripList string
+		var osName string
+		regionHosts := mapstr.MapStr{}
+
+		client, _ := cvm.NewClient(credential, region.Region, cpf)
+		instRequest := cvm.NewDescribeInstancesRequest()
+		response, err := client.DescribeInstances(instRequest)
+
+		if _, ok := err.(*errors.TencentCloudSDKError); ok {
+			blog.Errorf("an tencent cloud api error has returned: %s", err)
+			return nil, err
+		}
+		if err != nil {
+			blog.Error("obtain cloud hosts failed")
+			return nil, err
+		}
+
+		data := response.ToJsonString()
+		Hosts := meta.HostResponse{}
+		if err := json.Unmarshal([]byte(data), &Hosts); err != nil {
+			blog.Errorf("json unmarsha1 error :%v\n", err)
+		}
+
+		instSet := Hosts.HostResponse.InstanceSet
+		for _, obj := range instSet {
+			osName = obj.OsName
+			if len(obj.PrivateIpAddresses) > 0 {
+				inneripList = obj.PrivateIpAddresses[0]
+			}
+		}
+
+		for _, obj := range instSet {
+			if len(obj.PublicIpAddresses) > 0 {
+				outeripList = obj.PublicIpAddresses[0]
+			}
+		}
+
+		if len(instSet) > 0 {
+			regionHosts[common.BKHostCloudRegionField] = region.Region
+			regionHosts[common.BKHostInnerIPField] = inneripList
+			regionHosts[common.BKHostOuterIPField] = outeripList
+			regionHosts[common.BKOSNameField] = osName
+			cloudHostInfo = append(cloudHostInfo, regionHosts)
+		}
+	}
+	return cloudHostInfo, nil
+}
@@ -14,7 +14,6 @@ package logics
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -23,17 +22,12 @@ import (
 	"sync"
 	"time"
 
-	com "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/regions"
-	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
-
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
 	meta "configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/scene_server/host_server/cloudprovider"
 	hutil "configcenter/src/scene_server/host_server/util"
 )
 
@@ -53,8 +47,20 @@ func (lgc *Logics) AddCloudTask(ctx context.Context, taskList *meta.CloudTaskLis
 		return lgc.ccErr.Error(1110038)
 	}
 
-	// Encode secretKey
-	taskList.SecretKey = base64.StdEncoding.EncodeToString([]byte(taskList.SecretKey))
+	if taskList.CloudVendor == "" {
+		taskList.CloudVendor = common.TencentCloudVendor
+	}
+	if _, err := cloudprovider.GetProvider(taskList.CloudVendor); err != nil {
+		blog.Errorf("add task failed, err: %v, rid: %s", err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCloudVendorNotSupport, taskList.CloudVendor)
+	}
+
+	secretKey, err := lgc.cryptor.Encrypt(taskList.SecretKey)
+	if err != nil {
+		blog.Errorf("add task failed, encrypt secret key failed, err: %v, rid: %s", err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCloudSecretEncryptFail)
+	}
+	taskList.SecretKey = secretKey
 
 	if _, err := lgc.CoreAPI.HostController().Cloud().AddCloudTask(ctx, lgc.header, taskList); err != nil {
 		blog.Errorf("add cloud task failed, err: %v, rid: %s", err, lgc.rid)
@@ -64,6 +70,31 @@ func (lgc *Logics) AddCloudTask(ctx context.Context, taskList *meta.CloudTaskLis
 	return nil
 }
 
+// EncryptCloudTaskSecret encrypt the secret key in the cloud task update data,
+// an empty secret key means the secret key is not changed.
+func (lgc *Logics) EncryptCloudTaskSecret(data mapstr.MapStr) error {
+	if _, ok := data[common.BKCloudSecretKey]; !ok {
+		return nil
+	}
+
+	secretKey, err := data.String(common.BKCloudSecretKey)
+	if err != nil {
+		return err
+	}
+	if secretKey == "" {
+		delete(data, common.BKCloudSecretKey)
+		return nil
+	}
+
+	encrypted, err := lgc.cryptor.Encrypt(secretKey)
+	if err != nil {
+		blog.Errorf("encrypt cloud task secret key failed, err: %v, rid: %s", err, lgc.rid)
+		return lgc.ccErr.Error(common.CCErrCloudSecretEncryptFail)
+	}
+	data[common.BKCloudSecretKey] = encrypted
+	return nil
+}
+
 func (lgc *Logics) TimerTriggerCheckStatus(ctx context.Context) {
 	go func() {
 		if err := lgc.SyncTaskDBManager(ctx); err != nil {
@@ -484,19 +515,26 @@ func (lgc *Logics) ExecSync(ctx context.Context, taskInfo meta.CloudTaskInfo) er
 		existHostList = append(existHostList, ip)
 	}
 
-	// obtain hosts from TencentCloud needs secretID and secretKey
-	decodeBytes, errDecode := base64.StdEncoding.DecodeString(taskInfo.SecretKey)
-	if errDecode != nil {
-		blog.Errorf("Base64 decode secretKey failed, rid: %s", lgc.rid)
-		errOrigin = errDecode
-		return errDecode
+	provider, err := cloudprovider.GetProvider(taskInfo.CloudVendor)
+	if err != nil {
+		blog.Errorf("get cloud provider failed, err: %v, rid: %s", err, lgc.rid)
+		errOrigin = err
+		return err
+	}
+	cloudHistory.CloudVendor = provider.Vendor()
+
+	// obtain hosts from the cloud provider needs secretID and secretKey,
+	// the secret key is only decrypted here.
+	secretKey, err := lgc.cryptor.Decrypt(taskInfo.SecretKey)
+	if err != nil {
+		blog.Errorf("decrypt secretKey failed, err: %v, rid: %s", err, lgc.rid)
+		errOrigin = err
+		return err
 	}
-	secretKey := string(decodeBytes)
 
 	secretID := taskInfo.SecretID
 
-	// ObtainCloudHosts obtain cloud hosts
-	cloudHostInfo, err := lgc.ObtainCloudHosts(ctx, secretID, secretKey)
+	cloudHostInfo, err := provider.GetHosts(ctx, cloudprovider.Account{SecretID: secretID, SecretKey: secretKey})
 	if err != nil {
 		blog.Errorf("obtain cloud hosts failed with err: %v, rid: %s", err, lgc.rid)
 		errOrigin = err
@@ -648,6 +686,7 @@ func (lgc *Logics) ExecSync(ctx context.Context, taskInfo meta.CloudTaskInfo) er
 			resourceConfirm[common.BKCloudConfirm] = false
 			resourceConfirm[common.BKCloudSyncTaskName] = taskInfo.TaskName
 			resourceConfirm[common.BKCloudAccountType] = taskInfo.AccountType
+			resourceConfirm[common.BKCloudVendor] = provider.Vendor()
 			resourceConfirm[common.BKCloudSyncAccountAdmin] = taskInfo.AccountAdmin
 			resourceConfirm[common.BKResourceType] = "change"
 
@@ -723,7 +762,7 @@ func (lgc *Logics) UpdateCloudHosts(ctx context.Context, cloudHostAttr []mapstr.
 		delete(hostInfo, common.BKAttrConfirm)
 		opt := mapstr.MapStr{"condition": mapstr.MapStr{common.BKHostIDField: hostID}, "data": hostInfo}
 
-		blog.V(5).Info("opt: %v", opt)
+		blog.V(5).Infof("opt: %v", opt)
 		result, err := lgc.CoreAPI.ObjectController().Instance().UpdateObject(ctx, common.BKInnerObjIDHost, lgc.header, opt)
 		if err != nil || (err == nil && !result.Result) {
 			blog.Errorf("update host batch failed, ids[%v], err: %v, %v, rid: %s", hostID, err, result.ErrMsg, lgc.rid)
@@ -734,6 +773,12 @@ func (lgc *Logics) UpdateCloudHosts(ctx context.Context, cloudHostAttr []mapstr.
 }
 
 func (lgc *Logics) NewAddConfirm(ctx context.Context, taskInfo meta.CloudTaskInfo, newCloudHost []mapstr.MapStr) (int, error) {
+	provider, err := cloudprovider.GetProvider(taskInfo.CloudVendor)
+	if err != nil {
+		blog.Errorf("get cloud provider failed, err: %v, rid: %s", err, lgc.rid)
+		return 0, err
+	}
+
 	// Check whether the host is already exist in resource confirm.
 	opt := make(map[string]interface{})
 	confirmHosts, err := lgc.CoreAPI.HostController().Cloud().SearchConfirm(ctx, lgc.header, opt)
@@ -793,6 +838,7 @@ func (lgc *Logics) NewAddConfirm(ctx context.Context, taskInfo meta.CloudTaskInf
 			resourceConfirm[common.BKAttrConfirm] = false
 			resourceConfirm[common.BKCloudSyncTaskName] = taskInfo.TaskName
 			resourceConfirm[common.BKCloudAccountType] = taskInfo.AccountType
+			resourceConfirm[common.BKCloudVendor] = provider.Vendor()
 			resourceConfirm[common.BKCloudSyncAccountAdmin] = taskInfo.AccountAdmin
 			resourceConfirm[common.BKResourceType] = common.BKNewAddHost
 
@@ -904,84 +950,6 @@ func (lgc *Logics) CloudSyncHistory(ctx context.Context, taskID int64, startTime
 	return nil
 }
 
-func (lgc *Logics) ObtainCloudHosts(ctx context.Context, secretID string, secretKey string) ([]map[string]interface{}, error) {
-	credential := com.NewCredential(
-		secretID,
-		secretKey,
-	)
-
-	cpf := profile.NewClientProfile()
-	cpf.HttpProfile.ReqMethod = common.BKHttpGet
-	cpf.HttpProfile.ReqTimeout = common.BKTencentCloudTimeOut
-	cpf.HttpProfile.Endpoint = common.TencentCloudUrl
-	cpf.SignMethod = common.TencentCloudSignMethod
-
-	ClientRegion, _ := cvm.NewClient(credential, regions.Guangzhou, cpf)
-	regionRequest := cvm.NewDescribeRegionsRequest()
-	Response, err := ClientRegion.DescribeRegions(regionRequest)
-
-	if err != nil {
-		return nil, err
-	}
-
-	data := Response.ToJsonString()
-	regionResponse := new(meta.RegionResponse)
-	if err := json.Unmarshal([]byte(data), regionResponse); err != nil {
-		blog.Errorf("json unmarsha1 error :%v\n", err)
-		return nil, err
-	}
-
-	cloudHostInfo := make([]map[string]interface{}, 0)
-	for _, region := range regionResponse.Response.Data {
-		var inneripList string
-		var outeripList string
-		var osName string
-		regionHosts := make(map[string]interface{})
-
-		client, _ := cvm.NewClient(credential, region.Region, cpf)
-		instRequest := cvm.NewDescribeInstancesRequest()
-		response, err := client.DescribeInstances(instRequest)
-
-		if _, ok := err.(*errors.TencentCloudSDKError); ok {
-			fmt.Printf("An API error has returned: %s", err)
-			return nil, err
-		}
-		if err != nil {
-			blog.Error("obtain cloud hosts failed")
-			return nil, err
-		}
-
-		data := response.ToJsonString()
-		Hosts := meta.HostResponse{}
-		if err := json.Unmarshal([]byte(data), &Hosts); err != nil {
-			fmt.Printf("json unmarsha1 error :%v\n", err)
-		}
-
-		instSet := Hosts.HostResponse.InstanceSet
-		for _, obj := range instSet {
-			osName = obj.OsName
-			if len(obj.PrivateIpAddresses) > 0 {
-				inneripList = obj.PrivateIpAddresses[0]
-			}
-		}
-
-		for _, obj := range instSet {
-			if len(obj.PublicIpAddresses) > 0 {
-				outeripList = obj.PublicIpAddresses[0]
-			}
-		}
-
-		if len(instSet) > 0 {
-			regionHosts[common.BKHostCloudRegionField] = region.Region
-			regionHosts[common.BKHostInnerIPField] = inneripList
-			regionHosts[common.BKHostOuterIPField] = outeripList
-			regionHosts[common.BKOSNameField] = osName
-			cloudHostInfo = append(cloudHostInfo, regionHosts)
-		}
-	}
-	return cloudHostInfo, nil
-}
-
 func copyHeader(ctx context.Context, header http.Header) http.Header {
 	newHeader := make(http.Header, 0)
 	for key, values := range header {
@@ -0,0 +1,180 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/apimachinery/util"
+	"configcenter/src/common"
+	"configcenter/src/common/backbone"
+	"configcenter/src/common/cryptor"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/language"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/scene_server/host_server/cloudprovider"
+)
+
+// hostCtrlDiscovery discovers the host controller at the test server
+type hostCtrlDiscovery struct {
+	discovery.DiscoveryInterface
+	server string
+}
+
+func (d *hostCtrlDiscovery) HostCtrl() discovery.Interface {
+	return d
+}
+
+func (d *hostCtrlDiscovery) GetServers() ([]string, error) {
+	return []string{d.server}, nil
+}
+
+// fakeCloudCtrl the host controller apis used by the cloud sync task
+type fakeCloudCtrl struct {
+	lock     sync.Mutex
+	confirms []mapstr.MapStr
+	history  []mapstr.MapStr
+	updates  []mapstr.MapStr
+	unknown  []string
+}
+
+func (f *fakeCloudCtrl) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	body := mapstr.New()
+	json.NewDecoder(r.Body).Decode(&body)
+	var data interface{}
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/hosts/search"):
+		data = meta.HostInfo{Info: []mapstr.MapStr{}}
+	case strings.HasSuffix(r.URL.Path, "/hosts/cloud/confirm/search"):
+		data = meta.FavoriteResult{Info: []map[string]interface{}{}}
+	case strings.HasSuffix(r.URL.Path, "/hosts/cloud/confirm"):
+		f.confirms = append(f.confirms, body)
+	case strings.HasSuffix(r.URL.Path, "/hosts/cloud/update"):
+		f.updates = append(f.updates, body)
+	case strings.HasSuffix(r.URL.Path, "/hosts/cloud/syncHistory/add"):
+		f.history = append(f.history, body)
+	default:
+		f.unknown = append(f.unknown, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(meta.Response{BaseResp: meta.SuccessBaseResp, Data: data})
+}
+
+func newTestLogics(t *testing.T, server string) *Logics {
+	coreAPI, err := apimachinery.NewApiMachinery(&util.APIMachineryConfig{QPS: 1000, Burst: 1000},
+		&hostCtrlDiscovery{DiscoveryInterface: discovery.NewMockDiscoveryInterface(), server: server})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := &backbone.Engine{
+		CoreAPI:  coreAPI,
+		CCErr:    errors.NewFromCtx(map[string]errors.ErrorCode{}),
+		Language: language.NewFromCtx(map[string]language.LanguageMap{}),
+	}
+
+	envelope, err := cryptor.NewFromConfig(cryptor.Config{
+		MasterKeys: "k1:" + base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+		ActiveKey:  "k1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := make(http.Header)
+	header.Set(common.BKHTTPOwnerID, common.BKDefaultOwnerID)
+	header.Set(common.BKHTTPHeaderUser, "admin")
+	return NewLogics(engine, header, nil, envelope)
+}
+
+func TestExecSyncWithFixture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cloudsync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	hosts := `[
+		{"bk_host_innerip": "10.0.0.1", "bk_host_outerip": "1.1.1.1", "bk_os_name": "linux", "bk_cloud_region": "local"},
+		{"bk_host_innerip": "10.0.0.2"}
+	]`
+	if err := ioutil.WriteFile(filepath.Join(dir, "hosts.json"), []byte(hosts), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cloudprovider.SetFixtureDir(dir)
+	defer cloudprovider.SetFixtureDir("")
+
+	ctrl := new(fakeCloudCtrl)
+	server := httptest.NewServer(ctrl)
+	defer server.Close()
+	lgc := newTestLogics(t, server.URL)
+
+	secretKey, err := lgc.cryptor.Encrypt("any")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := meta.CloudTaskInfo{
+		TaskID:          1,
+		TaskName:        "fixture sync",
+		ObjID:           common.BKInnerObjIDHost,
+		CloudVendor:     cloudprovider.FixtureCloudVendor,
+		SecretID:        "hosts.json",
+		SecretKey:       secretKey,
+		ResourceConfirm: true,
+	}
+	if err := lgc.ExecSync(context.Background(), task); err != nil {
+		t.Fatalf("exec sync failed, err: %v", err)
+	}
+
+	if len(ctrl.unknown) > 0 {
+		t.Fatalf("unexpected requests %v", ctrl.unknown)
+	}
+	// the new hosts wait for the confirmation
+	if len(ctrl.confirms) != 2 {
+		t.Fatalf("should add 2 resource confirms, but got %v", ctrl.confirms)
+	}
+	for idx, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		confirm := ctrl.confirms[idx]
+		if confirm[common.BKHostInnerIPField] != ip || confirm[common.BKCloudVendor] != cloudprovider.FixtureCloudVendor ||
+			confirm[common.BKResourceType] != common.BKNewAddHost || confirm[common.BKCloudConfirm] != true {
+			t.Errorf("unexpected resource confirm %v", confirm)
+		}
+	}
+	if ctrl.confirms[1][common.BKOSNameField] != "" {
+		t.Errorf("missing os name should be empty, but got %v", ctrl.confirms[1][common.BKOSNameField])
+	}
+
+	// the result of the sync is recorded
+	if len(ctrl.updates) != 1 || ctrl.updates[0][common.BKSyncStatus] != "success" {
+		t.Errorf("the task should be updated with success status, but got %v", ctrl.updates)
+	}
+	if len(ctrl.history) != 1 || ctrl.history[0]["bk_status"] != "success" || ctrl.history[0]["new_add"] != float64(2) ||
+		ctrl.history[0]["bk_cloud_vendor"] != cloudprovider.FixtureCloudVendor {
+		t.Errorf("unexpected sync history %v", ctrl.history)
+	}
+}
@@ -359,7 +359,7 @@ func (lgc *Logics) TransferHostAcrossBusiness(ctx context.Context, srcBizID, dst
 		blog.Errorf("TransferHostAcrossBusiness http do error, err:%s, input:%+v, rid:%s", err.Error(), addRelation, lgc.rid)
 		return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
 	}
-	if !delRet.Result {
+	if !addRet.Result {
 		blog.Errorf("TransferHostAcrossBusiness http response error, err code:%d, err msg:%s, input:%#v, rid:%s", addRet.Code, addRet.ErrMsg, addRelation, lgc.rid)
 		return lgc.ccErr.New(addRet.Code, addRet.ErrMsg)
 	}
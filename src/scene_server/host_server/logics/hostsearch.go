@@ -127,6 +127,8 @@ type searchHostInterface interface {
 }
 
 func NewSearchHost(ctx context.Context, lgc *Logics, hostSearchParam *metadata.HostCommonSearch) searchHostInterface {
+	// host search is read only and tolerant of stale data, the reads can be served by the secondaries
+	lgc = lgc.NewFromHeader(util.SecondaryEligibleHeader(lgc.header))
 	sh := &searchHost{
 		lgc:             lgc,
 		pheader:         lgc.header,
@@ -202,7 +204,7 @@ func (sh *searchHost) FillTopologyData() ([]mapstr.MapStr, int, errors.CCError)
 		hostIDArr = append(hostIDArr, hostInfoItem.hostID)
 	}
 	queryCond[common.BKHostIDField] = hostIDArr
-	mhconfig, err := sh.lgc.GetConfigByCond(sh.ctx, queryCond)
+	mhconfig, err := sh.getConfigByCond(queryCond)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -463,7 +465,7 @@ func (sh *searchHost) fetchTopoAppCacheInfo(appIDArr []int64) (map[int64]mapstr.
 		celld := mapstr.New()
 		celld.Set(common.BKDBIN, appIDArr)
 		cond.Set(common.BKAppIDField, celld)
-		return sh.lgc.GetAppMapByCond(sh.ctx, sh.conds.appCond.Fields, cond)
+		return sh.getInstMapByCond(common.BKInnerObjIDApp, sh.conds.appCond.Fields, cond)
 
 	}
 	return nil, nil
@@ -480,7 +482,7 @@ func (sh *searchHost) fetchTopoSetCacheInfo(setIDArr []int64) (map[int64]mapstr.
 		celld := mapstr.New()
 		celld.Set(common.BKDBIN, setIDArr)
 		cond.Set(common.BKSetIDField, celld)
-		return sh.lgc.GetSetMapByCond(sh.ctx, sh.conds.setCond.Fields, cond)
+		return sh.getInstMapByCond(common.BKInnerObjIDSet, sh.conds.setCond.Fields, cond)
 	}
 
 	return nil, nil
@@ -496,7 +498,7 @@ func (sh *searchHost) fetchTopoModuleCacheInfo(moduleIDArr []int64) (map[int64]m
 		celld := mapstr.New()
 		celld.Set(common.BKDBIN, moduleIDArr)
 		cond.Set(common.BKModuleIDField, celld)
-		return sh.lgc.GetModuleMapByCond(sh.ctx, sh.conds.moduleCond.Fields, cond)
+		return sh.getInstMapByCond(common.BKInnerObjIDModule, sh.conds.moduleCond.Fields, cond)
 
 	}
 
@@ -560,7 +562,7 @@ func (sh *searchHost) searchByApp() errors.CCError {
 		return nil
 	}
 	if len(sh.conds.appCond.Condition) > 0 {
-		appIDArr, err := sh.lgc.GetAppIDByCond(sh.ctx, sh.conds.appCond.Condition)
+		appIDArr, err := sh.getInstIDByCond(common.BKInnerObjIDApp, sh.conds.appCond.Condition)
 		if err != nil {
 			return err
 		}
@@ -610,7 +612,7 @@ func (sh *searchHost) searchByMainline() errors.CCError {
 				Value:    objSetIDArr,
 			})
 		}
-		setIDArr, err = sh.lgc.GetSetIDByCond(sh.ctx, sh.conds.setCond.Condition)
+		setIDArr, err = sh.getInstIDByCond(common.BKInnerObjIDSet, sh.conds.setCond.Condition)
 		if err != nil {
 			return err
 		}
@@ -647,7 +649,7 @@ func (sh *searchHost) searchByModule() errors.CCError {
 			})
 		}
 		//search module by cond
-		moduleIDArr, err := sh.lgc.GetModuleIDByCond(sh.ctx, sh.conds.moduleCond.Condition)
+		moduleIDArr, err := sh.getInstIDByCond(common.BKInnerObjIDModule, sh.conds.moduleCond.Condition)
 		if err != nil {
 			return err
 		}
@@ -681,6 +683,7 @@ func (sh *searchHost) searchByHostConds() errors.CCError {
 	hostParse.ParseHostParams(sh.conds.hostCond.Condition, condition)
 	hostParse.ParseHostIPParams(sh.hostSearchParam.Ip, condition)
 
+	var hosts []mapstr.MapStr
 	query := &metadata.QueryInput{
 		Condition: condition,
 		Start:     sh.hostSearchParam.Page.Start,
@@ -688,22 +691,29 @@ func (sh *searchHost) searchByHostConds() errors.CCError {
 		Sort:      sh.hostSearchParam.Page.Sort,
 	}
 
-	gResult, err := sh.lgc.CoreAPI.HostController().Host().GetHosts(sh.ctx, sh.pheader, query)
-	if err != nil {
-		blog.Errorf("get hosts failed, err: %v", err)
-		return err
-	}
-	if !gResult.Result {
-		blog.Errorf("get host failed, error code:%d, error message:%s", gResult.Code, gResult.ErrMsg)
-		return sh.ccErr.New(gResult.Code, gResult.ErrMsg)
+	if sh.isAsOf() {
+		sh.totalHostCnt, hosts, err = sh.getHostsAsOf(query)
+		if err != nil {
+			return err
+		}
+	} else {
+		gResult, err := sh.lgc.CoreAPI.HostController().Host().GetHosts(sh.ctx, sh.pheader, query)
+		if err != nil {
+			blog.Errorf("get hosts failed, err: %v", err)
+			return err
+		}
+		if !gResult.Result {
+			blog.Errorf("get host failed, error code:%d, error message:%s", gResult.Code, gResult.ErrMsg)
+			return sh.ccErr.New(gResult.Code, gResult.ErrMsg)
+		}
+		sh.totalHostCnt, hosts = gResult.Data.Count, gResult.Data.Info
 	}
 
-	if len(gResult.Data.Info) == 0 {
+	if len(hosts) == 0 {
 		sh.noData = true
 	}
 
-	sh.totalHostCnt = gResult.Data.Count
-	for _, host := range gResult.Data.Info {
+	for _, host := range hosts {
 		hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
 		if err != nil {
 			return err
@@ -738,7 +748,7 @@ func (sh *searchHost) appendHostTopoConds() errors.CCError {
 	if !isAddHostID {
 		return nil
 	}
-	hostIDArr, err := sh.lgc.GetHostIDByCond(sh.ctx, moduleHostConfig)
+	hostIDArr, err := sh.getHostIDByCond(moduleHostConfig)
 	if err != nil {
 		blog.Errorf("GetHostIDByCond get hosts failed, err: %v", err)
 		return err
@@ -0,0 +1,209 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	parse "configcenter/src/common/paraparse"
+	"configcenter/src/common/util"
+)
+
+// The host search with the as_of time reads the topology instances, the hosts and the module host relations from
+// their histories, the cloud areas and the custom mainline objects are still the current ones.
+
+func (sh *searchHost) isAsOf() bool {
+	return nil != sh.hostSearchParam.AsOf
+}
+
+// asOf returns the as_of time in the local zone, which is sent without the zone and parsed in the local zone
+func (sh *searchHost) asOf() metadata.Time {
+	return metadata.Time{Time: sh.hostSearchParam.AsOf.Time.Local()}
+}
+
+// getInstIDByCond get the ids of the app, set or module instances matching the condition
+func (sh *searchHost) getInstIDByCond(objID string, cond []metadata.ConditionItem) ([]int64, errors.CCError) {
+	if !sh.isAsOf() {
+		switch objID {
+		case common.BKInnerObjIDApp:
+			return sh.lgc.GetAppIDByCond(sh.ctx, cond)
+		case common.BKInnerObjIDSet:
+			return sh.lgc.GetSetIDByCond(sh.ctx, cond)
+		default:
+			return sh.lgc.GetModuleIDByCond(sh.ctx, cond)
+		}
+	}
+
+	condc := make(map[string]interface{})
+	parse.ParseCommonParams(cond, condc)
+	instIDField := common.GetInstIDField(objID)
+	infos, err := sh.readInstanceAsOf(objID, []string{instIDField}, mapstr.NewFromMap(condc))
+	if nil != err {
+		return nil, err
+	}
+
+	instIDArr := make([]int64, 0)
+	for _, info := range infos {
+		instID, err := info.Int64(instIDField)
+		if nil != err {
+			blog.Errorf("search host as of %v, convert %s %s to integer error, inst:%+v, rid:%s", sh.hostSearchParam.AsOf.Time, objID, instIDField, info, sh.ccRid)
+			return nil, sh.ccErr.Errorf(common.CCErrCommInstFieldConvFail, objID, instIDField, "int", err.Error())
+		}
+		instIDArr = append(instIDArr, instID)
+	}
+	return instIDArr, nil
+}
+
+// getInstMapByCond get the app, set or module instances matching the condition, keyed by the instance id
+func (sh *searchHost) getInstMapByCond(objID string, fields []string, cond mapstr.MapStr) (map[int64]mapstr.MapStr, errors.CCError) {
+	if !sh.isAsOf() {
+		switch objID {
+		case common.BKInnerObjIDApp:
+			return sh.lgc.GetAppMapByCond(sh.ctx, fields, cond)
+		case common.BKInnerObjIDSet:
+			return sh.lgc.GetSetMapByCond(sh.ctx, fields, cond)
+		default:
+			return sh.lgc.GetModuleMapByCond(sh.ctx, fields, cond)
+		}
+	}
+
+	infos, err := sh.readInstanceAsOf(objID, fields, cond)
+	if nil != err {
+		return nil, err
+	}
+
+	instIDField := common.GetInstIDField(objID)
+	instMap := make(map[int64]mapstr.MapStr)
+	for _, info := range infos {
+		instID, err := info.Int64(instIDField)
+		if nil != err {
+			blog.Errorf("search host as of %v, convert %s %s to integer error, inst:%+v, rid:%s", sh.hostSearchParam.AsOf.Time, objID, instIDField, info, sh.ccRid)
+			return nil, sh.ccErr.Errorf(common.CCErrCommInstFieldConvFail, objID, instIDField, "int", err.Error())
+		}
+		instMap[instID] = info
+	}
+	return instMap, nil
+}
+
+func (sh *searchHost) readInstanceAsOf(objID string, fields []string, cond mapstr.MapStr) ([]mapstr.MapStr, errors.CCError) {
+	query := &metadata.AsOfQueryCondition{
+		QueryCondition: metadata.QueryCondition{
+			Condition: cond,
+			Fields:    fields,
+			Limit:     metadata.SearchLimit{Offset: 0, Limit: common.BKNoLimit},
+		},
+		AsOf: sh.asOf(),
+	}
+	result, err := sh.lgc.CoreAPI.CoreService().Instance().ReadInstanceAsOf(sh.ctx, sh.pheader, objID, query)
+	if nil != err {
+		blog.Errorf("search host, ReadInstanceAsOf http do error, err:%s, objID:%s, input:%+v, rid:%s", err.Error(), objID, query, sh.ccRid)
+		return nil, sh.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("search host, ReadInstanceAsOf http response error, err code:%d, err msg:%s, objID:%s, input:%+v, rid:%s", result.Code, result.ErrMsg, objID, query, sh.ccRid)
+		return nil, sh.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return result.Data.Info, nil
+}
+
+// getConfigByCond get the module host relations matching the condition
+func (sh *searchHost) getConfigByCond(cond map[string][]int64) ([]map[string]int64, errors.CCError) {
+	if !sh.isAsOf() {
+		return sh.lgc.GetConfigByCond(sh.ctx, cond)
+	}
+
+	configArr := make([]map[string]int64, 0)
+	if 0 == len(cond) {
+		return configArr, nil
+	}
+	relations, err := sh.getModuleHostConfigAsOf(cond)
+	if nil != err {
+		return nil, err
+	}
+	for _, relation := range relations {
+		configArr = append(configArr, map[string]int64{
+			common.BKAppIDField:    relation.AppID,
+			common.BKSetIDField:    relation.SetID,
+			common.BKModuleIDField: relation.ModuleID,
+			common.BKHostIDField:   relation.HostID,
+		})
+	}
+	return configArr, nil
+}
+
+// getHostIDByCond get the ids of the hosts in the module host relations matching the condition
+func (sh *searchHost) getHostIDByCond(cond map[string][]int64) ([]int64, errors.CCError) {
+	if !sh.isAsOf() {
+		return sh.lgc.GetHostIDByCond(sh.ctx, cond)
+	}
+
+	relations, err := sh.getModuleHostConfigAsOf(cond)
+	if nil != err {
+		return nil, err
+	}
+	hostIDArr := make([]int64, 0)
+	for _, relation := range relations {
+		hostIDArr = append(hostIDArr, relation.HostID)
+	}
+	return util.IntArrayUnique(hostIDArr), nil
+}
+
+func (sh *searchHost) getModuleHostConfigAsOf(cond map[string][]int64) ([]metadata.ModuleHost, errors.CCError) {
+	input := &metadata.ModuleHostAsOfCondition{
+		Condition: cond,
+		AsOf:      sh.asOf(),
+	}
+	result, err := sh.lgc.CoreAPI.HostController().Module().GetModulesHostConfigAsOf(sh.ctx, sh.pheader, input)
+	if nil != err {
+		blog.Errorf("search host, GetModulesHostConfigAsOf http do error, err:%s, input:%+v, rid:%s", err.Error(), input, sh.ccRid)
+		return nil, sh.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("search host, GetModulesHostConfigAsOf http response error, err code:%d, err msg:%s, input:%+v, rid:%s", result.Code, result.ErrMsg, input, sh.ccRid)
+		return nil, sh.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return result.Data, nil
+}
+
+// getHostsAsOf get the hosts matching the query as they were at the as_of time and the total count of them
+func (sh *searchHost) getHostsAsOf(query *metadata.QueryInput) (int, []mapstr.MapStr, errors.CCError) {
+	cond, err := mapstr.NewFromInterface(query.Condition)
+	if nil != err {
+		blog.Errorf("search host as of %v, parse the condition %#v failed, err: %v, rid:%s", sh.hostSearchParam.AsOf.Time, query.Condition, err, sh.ccRid)
+		return 0, nil, sh.ccErr.Error(common.CCErrCommParamsInvalid)
+	}
+	input := &metadata.AsOfQueryCondition{
+		QueryCondition: metadata.QueryCondition{
+			Condition: cond,
+			Limit:     metadata.SearchLimit{Offset: int64(query.Start), Limit: int64(query.Limit)},
+			SortArr:   metadata.PageSortToSearchSort(query.Sort),
+		},
+		AsOf: sh.asOf(),
+	}
+	if "" != query.Fields {
+		input.Fields = util.SplitStrField(query.Fields, ",")
+	}
+	result, err := sh.lgc.CoreAPI.CoreService().Instance().ReadInstanceAsOf(sh.ctx, sh.pheader, common.BKInnerObjIDHost, input)
+	if nil != err {
+		blog.Errorf("search host, ReadInstanceAsOf http do error, err:%s, input:%+v, rid:%s", err.Error(), input, sh.ccRid)
+		return 0, nil, sh.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("search host, ReadInstanceAsOf http response error, err code:%d, err msg:%s, input:%+v, rid:%s", result.Code, result.ErrMsg, input, sh.ccRid)
+		return 0, nil, sh.ccErr.New(result.Code, result.ErrMsg)
+	}
+	return result.Data.Count, result.Data.Info, nil
+}
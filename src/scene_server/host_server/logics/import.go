@@ -263,12 +263,12 @@ func (h *importInstance) updateHostInstance(index int64, host map[string]interfa
 	if err != nil {
 		ip, _ := host[common.BKHostInnerIPField].(string)
 		blog.Errorf("updateHostInstance http do error,  err:%s,input:%+v,rid:%s", err.Error(), input, h.rid)
-		return fmt.Errorf(h.ccLang.Languagef("host_import_update_fail", index, ip, err.Error()))
+		return fmt.Errorf("%s", h.ccLang.Languagef("host_import_update_fail", index, ip, err.Error()))
 	}
 	if !uResult.Result {
 		ip, _ := host[common.BKHostInnerIPField].(string)
 		blog.Errorf("updateHostInstance http response error,  err code:%d, err msg:%s,input:%+v,rid:%s", uResult.Code, uResult.ErrMsg, input, h.rid)
-		return fmt.Errorf(h.ccLang.Languagef("host_import_update_fail", index, ip, uResult.ErrMsg))
+		return fmt.Errorf("%s", h.ccLang.Languagef("host_import_update_fail", index, ip, uResult.ErrMsg))
 	}
 	return nil
 }
@@ -287,11 +287,11 @@ func (h *importInstance) addHostInstance(cloudID, index, appID int64, moduleID [
 	result, err := h.CoreAPI.CoreService().Instance().CreateInstance(h.ctx, h.pheader, common.BKInnerObjIDHost, input) //(h.ctx, h.pheader, host)
 	if err != nil {
 		blog.Errorf("addHostInstance http do error,err:%s, input:%+v,rid:%s", err.Error(), host, h.rid)
-		return 0, fmt.Errorf(h.ccLang.Languagef("host_import_add_fail", index, ip, err.Error()))
+		return 0, fmt.Errorf("%s", h.ccLang.Languagef("host_import_add_fail", index, ip, err.Error()))
 	}
 	if !result.Result {
 		blog.Errorf("addHostInstance http response error,err code:%d,err msg:%s, input:%+v,rid:%s", result.Code, result.ErrMsg, host, h.rid)
-		return 0, fmt.Errorf(h.ccLang.Languagef("host_import_add_fail", index, ip, result.ErrMsg))
+		return 0, fmt.Errorf("%s", h.ccLang.Languagef("host_import_add_fail", index, ip, result.ErrMsg))
 	}
 
 	hostID := int64(result.Data.Created.ID)
@@ -304,10 +304,10 @@ func (h *importInstance) addHostInstance(cloudID, index, appID int64, moduleID [
 	hResult, err := h.CoreAPI.HostController().Module().AddModuleHostConfig(h.ctx, h.pheader, opt)
 	if err != nil {
 		blog.Errorf("add host module by ip:%s  err:%s,input:%+v,rid:%s", ip, err.Error(), opt, h.rid)
-		return 0, fmt.Errorf(h.ccLang.Languagef("host_import_add_fail", index, ip, err.Error()))
+		return 0, fmt.Errorf("%s", h.ccLang.Languagef("host_import_add_fail", index, ip, err.Error()))
 	} else if err == nil && !hResult.Result {
 		blog.Errorf("add host module by ip:%s  err code:%d,err msg:%s,input:%+v,rid:%s", ip, hResult.Code, hResult.ErrMsg, opt, h.rid)
-		return 0, fmt.Errorf(h.ccLang.Languagef("host_import_add_fail", index, ip, hResult.ErrMsg))
+		return 0, fmt.Errorf("%s", h.ccLang.Languagef("host_import_add_fail", index, ip, hResult.ErrMsg))
 	}
 
 	return hostID, nil
@@ -19,6 +19,7 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/backbone"
+	"configcenter/src/common/cryptor"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
 	"configcenter/src/common/util"
@@ -33,6 +34,7 @@ type Logics struct {
 	user    string
 	ownerID string
 	cache   *redis.Client
+	cryptor *cryptor.Envelope
 }
 
 // NewFromHeader new Logic from header
@@ -52,6 +54,7 @@ func (lgc *Logics) NewFromHeader(header http.Header) *Logics {
 		Engine:  lgc.Engine,
 		rid:     rid,
 		cache:   lgc.cache,
+		cryptor: lgc.cryptor,
 		user:    util.GetUser(header),
 		ownerID: util.GetOwnerID(header),
 	}
@@ -67,7 +70,7 @@ func (lgc *Logics) NewFromHeader(header http.Header) *Logics {
 }
 
 // NewLogics get logic handle
-func NewLogics(b *backbone.Engine, header http.Header, cache *redis.Client) *Logics {
+func NewLogics(b *backbone.Engine, header http.Header, cache *redis.Client, cryptor *cryptor.Envelope) *Logics {
 	lang := util.GetLanguage(header)
 	return &Logics{
 		Engine:  b,
@@ -78,5 +81,6 @@ func NewLogics(b *backbone.Engine, header http.Header, cache *redis.Client) *Log
 		user:    util.GetUser(header),
 		ownerID: util.GetOwnerID(header),
 		cache:   cache,
+		cryptor: cryptor,
 	}
 }
@@ -14,7 +14,6 @@ package service
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"net/http"
 
@@ -76,7 +75,9 @@ func (s *Service) SearchCloudTask(req *restful.Request, resp *restful.Response)
 	if err != nil {
 		blog.Errorf("search %v failed, err: %v, rid: %s", opt["bk_task_name"], err, srvData.rid)
 		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCloudGetTaskFail)})
+		return
 	}
+	hideCloudTaskSecret(response.Info)
 
 	resp.WriteEntity(meta.NewSuccessResp(response))
 }
@@ -91,6 +92,12 @@ func (s *Service) UpdateCloudTask(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	if err := srvData.lgc.EncryptCloudTaskSecret(data); err != nil {
+		blog.Errorf("update task failed, err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
 	// TaskName Uniqueness check
 	response, err := s.CoreAPI.HostController().Cloud().TaskNameCheck(srvData.ctx, srvData.header, data)
 	if err != nil {
@@ -296,26 +303,12 @@ func (s *Service) SearchAccount(req *restful.Request, resp *restful.Response) {
 	}
 
 	response, err := s.CoreAPI.HostController().Cloud().SearchCloudTask(srvData.ctx, srvData.header, opt)
-
 	if err != nil {
 		blog.Errorf("search %v failed, err: %v, rid: %s", opt["bk_task_name"], err, srvData.rid)
-		resp.WriteEntity(meta.NewSuccessResp(err))
-	}
-
-	secretID := response.Info[0].SecretID
-	secretKey := response.Info[0].SecretKey
-
-	// decode secretKey
-	decodeBytes, errDecode := base64.StdEncoding.DecodeString(secretKey)
-	if errDecode != nil {
-		blog.Errorf("Base64 decode secretKey failed. rid: %s", srvData.rid)
-		resp.WriteEntity(meta.NewSuccessResp(errDecode))
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCloudGetTaskFail)})
+		return
 	}
-	secretKeyOrigin := string(decodeBytes)
-
-	result := make(map[string]interface{}, 0)
-	result["ID"] = secretID
-	result["Key"] = secretKeyOrigin
+	hideCloudTaskSecret(response.Info)
 
 	resp.WriteEntity(meta.NewSuccessResp(response))
 }
@@ -391,3 +384,10 @@ func (s *Service) SearchConfirmHistory(req *restful.Request, resp *restful.Respo
 
 	resp.WriteEntity(meta.NewSuccessResp(response))
 }
+
+// hideCloudTaskSecret the secret key is only used by the cloud sync, never return it
+func hideCloudTaskSecret(tasks []meta.CloudTaskInfo) {
+	for idx := range tasks {
+		tasks[idx].SecretKey = ""
+	}
+}
@@ -590,7 +590,10 @@ func (s *Service) TransferHostAcrossBusiness(req *restful.Request, resp *restful
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
 		return
 	}
-	err := srvData.lgc.TransferHostAcrossBusiness(srvData.ctx, data.SrcAppID, data.DstAppID, data.HostID, data.DstModuleIDArr)
+	// the host is removed from the source business and added to the destination one together
+	err := s.withTransaction(srvData, func(srvData *srvComm) error {
+		return srvData.lgc.TransferHostAcrossBusiness(srvData.ctx, data.SrcAppID, data.DstAppID, data.HostID, data.DstModuleIDArr)
+	})
 	if err != nil {
 		blog.Errorf("TransferHostAcrossBusiness logcis err:%s,input:%#v,rid:%s", err.Error(), data, srvData.rid)
 		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: err})
@@ -22,6 +22,7 @@ import (
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/common"
 	"configcenter/src/common/backbone"
+	"configcenter/src/common/cryptor"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
 	"configcenter/src/common/metadata"
@@ -31,6 +32,7 @@ import (
 	"configcenter/src/common/util"
 	"configcenter/src/scene_server/host_server/app/options"
 	"configcenter/src/scene_server/host_server/logics"
+	"configcenter/src/storage/dal"
 )
 
 type Service struct {
@@ -38,6 +40,10 @@ type Service struct {
 	*backbone.Engine
 	disc    discovery.DiscoveryInterface
 	CacheDB *redis.Client
+	// Cryptor holds the envelope of the latest encryption config
+	Cryptor *cryptor.Holder
+	// Tx starts the transactions, nil if the transaction is not enabled
+	Tx dal.DB
 }
 
 type srvComm struct {
@@ -64,7 +70,7 @@ func (s *Service) newSrvComm(header http.Header) *srvComm {
 		ctxCancelFunc: cancel,
 		user:          util.GetUser(header),
 		ownerID:       util.GetOwnerID(header),
-		lgc:           logics.NewLogics(s.Engine, header, s.CacheDB),
+		lgc:           logics.NewLogics(s.Engine, header, s.CacheDB, s.Cryptor.Load()),
 	}
 }
 
@@ -0,0 +1,39 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/host_server/logics"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/types"
+)
+
+// withTransaction run the operation in a new transaction, the controllers join the transaction
+// by the transaction id in the header, so that their writes are rolled back if the operation failed.
+// the operation runs without transaction if the transaction is not enabled,
+// and it joins the transaction of the caller if the caller has started one.
+func (s *Service) withTransaction(srvData *srvComm, operation func(srvData *srvComm) error) error {
+	ctx := util.GetDBContext(srvData.ctx, srvData.header)
+	return dal.RunTransaction(ctx, s.Tx, srvData.ccErr.Error(common.CCErrObjectDBOpErrno), func(txn *types.Transaction) error {
+		if txn == nil {
+			return operation(srvData)
+		}
+
+		txnData := *srvData
+		txnData.header = txn.IntoHeader(srvData.header)
+		txnData.lgc = logics.NewLogics(s.Engine, txnData.header, s.CacheDB, s.Cryptor.Load())
+		return operation(&txnData)
+	})
+}
@@ -70,31 +70,35 @@ func (lgc *Logics) RefreshAllHostInstance(ctx context.Context) error {
 	}
 	newLgc := lgc.NewFromHeader(header)
 	fields := []string{common.BKAppIDField, common.BKOwnerIDField}
-	appInfoArr, err := newLgc.GetAppList(ctx, fields)
+	// the apps are fetched page by page, so that all the apps are never loaded at once
+	err := newLgc.IterateAppList(ctx, fields, refreshAppPageSize, func(appInfoArr []mapstr.MapStr) error {
+		for _, appInfo := range appInfoArr {
+			appID, err := appInfo.Int64(common.BKAppIDField)
+			if nil != err {
+				blog.Warnf("RefreshAllHostInstance get appID by app Info:%+v error:%s,rid:%s", appInfo, err.Error(), newLgc.rid)
+				continue
+			}
+			ownerID, err := appInfo.String(common.BKOwnerIDField)
+			if nil != err {
+				blog.Warnf("RefreshAllHostInstance get supplier accout by app Info:%+v error:%s,rid:%s", appInfo, err.Error(), newLgc.rid)
+				continue
+			}
+			// the header of the app list is kept, as the next pages are fetched with it
+			newHeader := copyHeader(lgc.header)
+			newHeader.Set(common.BKHTTPOwnerID, ownerID)
+			newLgc := lgc.NewFromHeader(newHeader)
+			err = newLgc.RefreshHostInstanceByApp(ctx, appID, appInfo)
+			if nil != err {
+				blog.Warnf("RefreshAllHostInstance RefreshHostInstanceByApp by app Info:%+v error:%s,rid:%s", appInfo, err.Error(), newLgc.rid)
+				continue
+			}
+		}
+		return nil
+	})
 	if nil != err {
 		blog.Errorf("RefreshAllHostInstance error:%s,rid:%s", err.Error(), newLgc.rid)
 		return err
 	}
-	for _, appInfo := range appInfoArr {
-		appID, err := appInfo.Int64(common.BKAppIDField)
-		if nil != err {
-			blog.Warnf("RefreshAllHostInstance get appID by app Info:%+v error:%s,rid:%s", appInfo, err.Error(), newLgc.rid)
-			continue
-		}
-		ownerID, err := appInfo.String(common.BKOwnerIDField)
-		if nil != err {
-			blog.Warnf("RefreshAllHostInstance get supplier accout by app Info:%+v error:%s,rid:%s", appInfo, err.Error(), newLgc.rid)
-			continue
-		}
-		newHeader := copyHeader(lgc.header)
-		header.Set(common.BKHTTPOwnerID, ownerID)
-		newLgc := lgc.NewFromHeader(newHeader)
-		err = newLgc.RefreshHostInstanceByApp(ctx, appID, appInfo)
-		if nil != err {
-			blog.Warnf("RefreshAllHostInstance RefreshHostInstanceByApp by app Info:%+v error:%s,rid:%s", appInfo, err.Error(), newLgc.rid)
-			continue
-		}
-	}
 	return nil
 }
 
@@ -48,6 +48,7 @@ var (
 	refreshHostInstModuleIDChan chan *refreshHostInstModuleID
 	gseOPProcTaskChan           chan *opProcTask
 	maxRefreshModuleData        int           = 100
+	refreshAppPageSize          int64         = 100
 	maxEventDataChan            int           = 10000
 	retry                       int           = 3
 	SPOPINTERVAL                time.Duration = time.Second * 30
@@ -20,6 +20,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 )
 
 func (lgc *Logics) getModuleNameByID(ctx context.Context, ID int64) (name string, appID int64, setID int64, err error) {
@@ -166,3 +167,44 @@ func (lgc *Logics) GetAppList(ctx context.Context, fields []string) ([]mapstr.Ma
 
 	return ret.Data.Info, nil
 }
+
+// IterateAppList get the apps page by page ordered by bk_biz_id, each page is fetched
+// after the last app id of the previous page, so only one page is kept in memory
+func (lgc *Logics) IterateAppList(ctx context.Context, fields []string, pageSize int64, handler func(apps []mapstr.MapStr) error) error {
+	defErr := lgc.ccErr
+	if 0 < len(fields) && !util.Contains(fields, common.BKAppIDField) {
+		fields = append(fields[:len(fields):len(fields)], common.BKAppIDField)
+	}
+	var lastAppID int64
+	for {
+		dat := new(metadata.QueryCondition)
+		dat.Fields = fields
+		dat.Condition = mapstr.MapStr{common.BKAppIDField: mapstr.MapStr{common.BKDBGT: lastAppID}}
+		dat.SortArr = []metadata.SearchSort{{Field: common.BKAppIDField}}
+		dat.Limit.Limit = pageSize
+		ret, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(ctx, lgc.header, common.BKInnerObjIDApp, dat)
+		if nil != err {
+			blog.Errorf("IterateAppList  http do error:%s,query:%+v,rid:%s", err.Error(), dat, lgc.rid)
+			return defErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !ret.Result {
+			blog.Errorf("IterateAppList  http reply error,err code:%d,err msg:%s,query:%+v,rid:%s", ret.Code, ret.ErrMsg, dat, lgc.rid)
+			return defErr.New(ret.Code, ret.ErrMsg)
+		}
+		apps := ret.Data.Info
+		if 0 == len(apps) {
+			return nil
+		}
+		if err := handler(apps); nil != err {
+			return err
+		}
+		if int64(len(apps)) < pageSize {
+			return nil
+		}
+		lastAppID, err = apps[len(apps)-1].Int64(common.BKAppIDField)
+		if nil != err {
+			blog.Errorf("IterateAppList get app id from app info:%+v error:%s,rid:%s", apps[len(apps)-1], err.Error(), lgc.rid)
+			return defErr.Errorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+		}
+	}
+}
@@ -187,7 +187,8 @@ func (s *synchronizeItem) synchronizeInstance(ctx context.Context, objID string,
 		}
 
 		start += limit
-		if start >= int64(info.Count) {
+		// a short page is the last page
+		if start >= int64(info.Count) || int64(len(info.Info)) < limit {
 			break
 		}
 	}
@@ -312,7 +313,8 @@ func (s *synchronizeItem) synchronizeModel(ctx context.Context, model *FetchMode
 			errorInfoArr = append(errorInfoArr, pageErrInfoArr...)
 		}
 		start += limit
-		if start >= int64(info.Count) {
+		// a short page is the last page
+		if start >= int64(info.Count) || int64(len(info.Info)) < limit {
 			break
 		}
 	}
@@ -377,6 +379,7 @@ func (s *synchronizeItem) synchronizeAssociationTask(ctx context.Context) ([]met
 
 	classifyArr := []string{
 		common.SynchronizeAssociationTypeModelHost,
+		common.SynchronizeAssociationTypeInstAsst,
 	}
 	association.SetAppIDArr(s.appIDArr)
 	for _, dataClassify := range classifyArr {
@@ -420,7 +423,8 @@ func (s *synchronizeItem) sycnhronizeAssociation(ctx context.Context, associatio
 			errorInfoArr = append(errorInfoArr, pageErrInfoArr...)
 		}
 		start += limit
-		if start >= int64(info.Count) {
+		// a short page is the last page
+		if start >= int64(info.Count) || int64(len(info.Info)) < limit {
 			break
 		}
 	}
@@ -72,6 +72,8 @@ func SynchronizeFindInfoParameterToQuerycondition(input *metadata.SynchronizeFin
 	ret := &metadata.QueryCondition{
 		Limit:     metadata.SearchLimit{Limit: int64(input.Limit), Offset: int64(input.Start)},
 		Condition: input.Condition,
+		// sorted, so that the pages are stable
+		SortArr: []metadata.SearchSort{{Field: common.GetInstIDField(input.DataClassify)}},
 	}
 	if ret.Limit.Limit <= 0 {
 		//  limit
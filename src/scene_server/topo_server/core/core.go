@@ -37,6 +37,8 @@ type Core interface {
 	AuditOperation() operation.AuditOperationInterface
 	HealthOperation() operation.HealthOperationInterface
 	UniqueOperation() operation.UniqueOperationInterface
+	RecycleOperation() operation.RecycleOperationInterface
+	HistoryOperation() operation.HistoryOperationInterface
 }
 
 type core struct {
@@ -56,6 +58,8 @@ type core struct {
 	identifier     operation.IdentifierOperationInterface
 	health         operation.HealthOperationInterface
 	unique         operation.UniqueOperationInterface
+	recycle        operation.RecycleOperationInterface
+	history        operation.HistoryOperationInterface
 }
 
 // New create a core manager
@@ -80,6 +84,8 @@ func New(client apimachinery.ClientSetInterface) Core {
 	identifier := operation.NewIdentifier(client)
 	audit := operation.NewAuditOperation(client)
 	unique := operation.NewUniqueOperation(client)
+	recycle := operation.NewRecycleOperation(client)
+	history := operation.NewHistoryOperation(client)
 
 	targetModel := model.New(client)
 	targetInst := inst.New(client)
@@ -115,6 +121,8 @@ func New(client apimachinery.ClientSetInterface) Core {
 		identifier:     identifier,
 		health:         healthOpeartion,
 		unique:         unique,
+		recycle:        recycle,
+		history:        history,
 	}
 }
 
@@ -169,3 +177,9 @@ func (c *core) HealthOperation() operation.HealthOperationInterface {
 func (c *core) UniqueOperation() operation.UniqueOperationInterface {
 	return c.unique
 }
+func (c *core) RecycleOperation() operation.RecycleOperationInterface {
+	return c.recycle
+}
+func (c *core) HistoryOperation() operation.HistoryOperationInterface {
+	return c.history
+}
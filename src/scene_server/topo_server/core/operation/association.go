@@ -22,6 +22,7 @@ import (
 	"configcenter/src/common/condition"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 	"configcenter/src/scene_server/topo_server/core/inst"
 	"configcenter/src/scene_server/topo_server/core/model"
 	"configcenter/src/scene_server/topo_server/core/types"
@@ -60,6 +61,7 @@ type AssociationOperationInterface interface {
 	SearchInst(params types.ContextParams, request *metadata.SearchAssociationInstRequest) (resp *metadata.SearchAssociationInstResult, err error)
 	CreateInst(params types.ContextParams, request *metadata.CreateAssociationInstRequest) (resp *metadata.CreateAssociationInstResult, err error)
 	DeleteInst(params types.ContextParams, assoID int64) (resp *metadata.DeleteAssociationInstResult, err error)
+	PreviewDeleteInst(params types.ContextParams, assoID int64) (*metadata.InstDeletePlan, error)
 
 	ImportInstAssociation(ctx context.Context, params types.ContextParams, objID string, importData map[int]metadata.ExcelAssocation) (resp metadata.ResponeImportAssociationData, err error)
 
@@ -642,48 +644,7 @@ func (a *association) CreateInst(params types.ContextParams, request *metadata.C
 	objID := objectAsst.ObjectID
 	asstObjID := objectAsst.AsstObjID
 
-	switch result.Data[0].Mapping {
-	case metadata.OneToOneMapping:
-		// search instances belongs to this association.
-		cond := condition.CreateCondition()
-		cond.Field(common.AssociationObjAsstIDField).Eq(request.ObjectAsstID)
-		cond.Field(common.BKInstIDField).Eq(request.InstID)
-		inst, err := a.SearchInst(params, &metadata.SearchAssociationInstRequest{Condition: cond.ToMapStr()})
-		if err != nil {
-			blog.Errorf("create association instance, but check instance with cond[%v] failed, err: %v", cond, err)
-			return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
-		}
-
-		if !inst.Result {
-			blog.Errorf("create association instance, but check instance with cond[%v] failed, err: %s", cond, resp.ErrMsg)
-			return nil, params.Err.New(resp.Code, resp.ErrMsg)
-		}
-		if len(inst.Data) >= 1 {
-			return nil, params.Err.Error(common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation)
-		}
-
-		cond = condition.CreateCondition()
-		cond.Field(common.AssociationObjAsstIDField).Eq(request.ObjectAsstID)
-		cond.Field(common.BKAsstInstIDField).Eq(request.AsstInstID)
-
-		inst, err = a.SearchInst(params, &metadata.SearchAssociationInstRequest{Condition: cond.ToMapStr()})
-		if err != nil {
-			blog.Errorf("create association instance, but check instance with cond[%v] failed, err: %v", cond, err)
-			return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
-		}
-
-		if !inst.Result {
-			blog.Errorf("create association instance, but check instance with cond[%v] failed, err: %s", cond, resp.ErrMsg)
-			return nil, params.Err.New(resp.Code, resp.ErrMsg)
-		}
-		if len(inst.Data) >= 1 {
-			return nil, params.Err.Error(common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation)
-		}
-
-	default:
-		// after all the check, new association instance can be created.
-	}
-
+	// the mapping of the association is checked by the core service.
 	input := metadata.CreateOneInstanceAssociation{
 		Data: metadata.InstAsst{
 			ObjectAsstID:      request.ObjectAsstID,
@@ -702,6 +663,13 @@ func (a *association) CreateInst(params types.ContextParams, request *metadata.C
 }
 
 func (a *association) DeleteInst(params types.ContextParams, assoID int64) (resp *metadata.DeleteAssociationInstResult, err error) {
+	// the instance association is deleted by the on delete action of its model association,
+	// which may delete the source or destination instance too.
+	_, cause, err := a.instAsstDeleteCause(params, assoID)
+	if nil != err {
+		return nil, err
+	}
+
 	input := metadata.DeleteOption{
 		Condition: condition.CreateCondition().Field(common.BKFieldID).Eq(assoID).ToMapStr(),
 	}
@@ -709,6 +677,98 @@ func (a *association) DeleteInst(params types.ContextParams, assoID int64) (resp
 	resp = &metadata.DeleteAssociationInstResult{
 		BaseResp: rsp.BaseResp,
 	}
+	if nil != err || !rsp.Result || nil == cause {
+		return resp, err
+	}
 
-	return resp, err
+	obj, err := a.obj.FindSingleObject(params, cause.ObjectID)
+	if nil != err {
+		blog.Errorf("[operation-asst] failed to find the object(%s), err: %s", cause.ObjectID, err.Error())
+		return nil, err
+	}
+	if err := a.inst.CascadeDeleteInst(params, obj, *cause, true); nil != err {
+		blog.Errorf("[operation-asst] failed to delete the object(%s) inst(%d) by the association(%d), err: %s", cause.ObjectID, cause.InstID, assoID, err.Error())
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// PreviewDeleteInst returns what will be deleted with the instance association, nothing is deleted.
+func (a *association) PreviewDeleteInst(params types.ContextParams, assoID int64) (*metadata.InstDeletePlan, error) {
+	instAsst, cause, err := a.instAsstDeleteCause(params, assoID)
+	if nil != err {
+		return nil, err
+	}
+
+	plan := &metadata.InstDeletePlan{
+		Instances:    []metadata.InstDeleteNode{},
+		Associations: []metadata.InstAsst{},
+		Restricted:   []metadata.InstAsst{},
+		Cycles:       []metadata.InstAsst{},
+	}
+	if nil == instAsst {
+		return plan, nil
+	}
+
+	if nil != cause {
+		obj, err := a.obj.FindSingleObject(params, cause.ObjectID)
+		if nil != err {
+			blog.Errorf("[operation-asst] failed to find the object(%s), err: %s", cause.ObjectID, err.Error())
+			return nil, err
+		}
+		plan, err = a.inst.PreviewDeleteInst(params, obj, []metadata.InstDeleteNode{*cause}, true)
+		if nil != err {
+			return nil, err
+		}
+	}
+
+	for _, asst := range plan.Associations {
+		if asst.ID == instAsst.ID {
+			return plan, nil
+		}
+	}
+	plan.Associations = append([]metadata.InstAsst{*instAsst}, plan.Associations...)
+	return plan, nil
+}
+
+// instAsstDeleteCause returns the instance association and the instance which should be deleted together
+// with it by the on delete action of the model association, the instance is nil if nothing should be deleted.
+func (a *association) instAsstDeleteCause(params types.ContextParams, assoID int64) (*metadata.InstAsst, *metadata.InstDeleteNode, error) {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKFieldID).Eq(assoID)
+	assts, err := a.SearchInstAssociation(params, &metadata.QueryInput{Condition: cond.ToMapStr()})
+	if nil != err {
+		return nil, nil, err
+	}
+	if 0 == len(assts) {
+		return nil, nil, nil
+	}
+
+	instAsst := assts[0]
+	lookup := &instDeleteLookup{params: params, client: a.clientSet, asst: a}
+	objAsst, err := lookup.ObjectAssociation(instAsst.ObjectAsstID)
+	if nil != err {
+		return nil, nil, err
+	}
+	if nil == objAsst {
+		return &instAsst, nil, nil
+	}
+
+	cause := &metadata.InstDeleteNode{ObjectAsstID: instAsst.ObjectAsstID, OnDelete: objAsst.OnDelete, Depth: 1}
+	switch objAsst.OnDelete {
+	case metadata.DeleteSource:
+		cause.ObjectID, cause.InstID = instAsst.ObjectID, instAsst.InstID
+	case metadata.DeleteDestinatioin:
+		cause.ObjectID, cause.InstID = instAsst.AsstObjectID, instAsst.AsstInstID
+	default:
+		return &instAsst, nil, nil
+	}
+
+	// the inner objects' instances are never deleted by cascade.
+	if util.IsInnerObject(cause.ObjectID) {
+		blog.Errorf("[operation-asst] the association(%d) can not delete the inner object(%s) inst(%d) by cascade", assoID, cause.ObjectID, cause.InstID)
+		return nil, nil, params.Err.Errorf(common.CCErrTopoInstHasBeenAssociation, []string{cause.ObjectID})
+	}
+	return &instAsst, cause, nil
 }
@@ -20,6 +20,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 	"configcenter/src/scene_server/topo_server/core/types"
 )
 
@@ -98,7 +99,8 @@ func (a *audit) Query(params types.ContextParams, data mapstr.MapStr) (interface
 		query.Limit = common.BKDefaultLimit
 	}
 
-	rsp, err := a.clientSet.AuditController().GetAuditLog(context.Background(), params.Header, query)
+	// the audit logs are append only, the search can be served by the secondaries
+	rsp, err := a.clientSet.AuditController().GetAuditLog(context.Background(), util.SecondaryEligibleHeader(params.Header), query)
 	if nil != err {
 		blog.Errorf("[audit] failed request audit conroller, error info is %s", err.Error())
 		return nil, params.Err.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
@@ -0,0 +1,127 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/inst"
+	"configcenter/src/scene_server/topo_server/core/model"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// HistoryOperationInterface instance history operation methods
+type HistoryOperationInterface interface {
+	SearchInstHistory(params types.ContextParams, objID string, instID int64, input *metadata.QueryCondition) (*metadata.InstHistoryQueryResult, error)
+	DiffInstHistory(params types.ContextParams, objID string, instID, fromVersion, toVersion int64) (*metadata.InstHistoryDiff, error)
+	FindInstAsOf(params types.ContextParams, obj model.Object, cond *metadata.QueryInput, asOf time.Time) (count int, results []inst.Inst, err error)
+}
+
+// NewHistoryOperation create a new instance history operation instance
+func NewHistoryOperation(client apimachinery.ClientSetInterface) HistoryOperationInterface {
+	return &history{
+		clientSet: client,
+	}
+}
+
+type history struct {
+	clientSet apimachinery.ClientSetInterface
+}
+
+func (h *history) SearchInstHistory(params types.ContextParams, objID string, instID int64, input *metadata.QueryCondition) (*metadata.InstHistoryQueryResult, error) {
+	rsp, err := h.clientSet.CoreService().Instance().SearchInstHistory(context.Background(), params.Header, objID, instID, input)
+	if nil != err {
+		blog.Errorf("[operation-history] failed to request core service, err: %s", err.Error())
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-history] failed to search the history of the object(%s) inst(%d), err: %s", objID, instID, rsp.ErrMsg)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	return &rsp.Data, nil
+}
+
+func (h *history) DiffInstHistory(params types.ContextParams, objID string, instID, fromVersion, toVersion int64) (*metadata.InstHistoryDiff, error) {
+	input := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{"version": mapstr.MapStr{common.BKDBIN: []int64{fromVersion, toVersion}}},
+	}
+	versions, err := h.SearchInstHistory(params, objID, instID, input)
+	if nil != err {
+		return nil, err
+	}
+
+	var from, to *metadata.InstHistory
+	for idx := range versions.Info {
+		if versions.Info[idx].Version == fromVersion {
+			from = &versions.Info[idx]
+		}
+		if versions.Info[idx].Version == toVersion {
+			to = &versions.Info[idx]
+		}
+	}
+	if nil == from || nil == to {
+		blog.Errorf("[operation-history] the versions(%d, %d) of the object(%s) inst(%d) are not found", fromVersion, toVersion, objID, instID)
+		return nil, params.Err.Error(common.CCErrCommNotFound)
+	}
+
+	return &metadata.InstHistoryDiff{
+		ObjectID:    objID,
+		InstID:      instID,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Diffs:       metadata.DiffInstData(from.Data, to.Data),
+	}, nil
+}
+
+func (h *history) FindInstAsOf(params types.ContextParams, obj model.Object, cond *metadata.QueryInput, asOf time.Time) (count int, results []inst.Inst, err error) {
+	queryCond, err := mapstr.NewFromInterface(cond.Condition)
+	if nil != err {
+		blog.Errorf("[operation-history] failed to parse the condition(%#v), err: %s", cond.Condition, err.Error())
+		return 0, nil, params.Err.Error(common.CCErrCommParamsInvalid)
+	}
+
+	// the time is sent without the zone, which is parsed in the local zone
+	input := &metadata.AsOfQueryCondition{
+		QueryCondition: metadata.QueryCondition{
+			Condition: queryCond,
+			Limit:     metadata.SearchLimit{Offset: int64(cond.Start), Limit: int64(cond.Limit)},
+			SortArr:   metadata.PageSortToSearchSort(cond.Sort),
+		},
+		AsOf: metadata.Time{Time: asOf.Local()},
+	}
+	if "" != cond.Fields {
+		input.Fields = strings.Split(cond.Fields, ",")
+	}
+
+	rsp, err := h.clientSet.CoreService().Instance().ReadInstanceAsOf(context.Background(), params.Header, obj.GetObjectID(), input)
+	if nil != err {
+		blog.Errorf("[operation-history] failed to request core service, err: %s", err.Error())
+		return 0, nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-history] failed to search the object(%s) inst as of %v by the condition(%#v), err: %s", obj.GetObjectID(), asOf, queryCond, rsp.ErrMsg)
+		return 0, nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	return rsp.Data.Count, inst.CreateInst(params, h.clientSet, obj, rsp.Data.Info), nil
+}
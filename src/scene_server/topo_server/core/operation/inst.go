@@ -37,6 +37,8 @@ type InstOperationInterface interface {
 	CreateInstBatch(params types.ContextParams, obj model.Object, batchInfo *InstBatchInfo) (*BatchResult, error)
 	DeleteInst(params types.ContextParams, obj model.Object, cond condition.Condition, needCheckHost bool) error
 	DeleteInstByInstID(params types.ContextParams, obj model.Object, instID []int64, needCheckHost bool) error
+	CascadeDeleteInst(params types.ContextParams, obj model.Object, cause metadata.InstDeleteNode, needCheckHost bool) error
+	PreviewDeleteInst(params types.ContextParams, obj model.Object, roots []metadata.InstDeleteNode, needCheckHost bool) (*metadata.InstDeletePlan, error)
 	FindOriginInst(params types.ContextParams, obj model.Object, cond *metadata.QueryInput) (*metadata.InstResult, error)
 	FindInst(params types.ContextParams, obj model.Object, cond *metadata.QueryInput, needAsstDetail bool) (count int, results []inst.Inst, err error)
 	FindInstByAssociationInst(params types.ContextParams, obj model.Object, data mapstr.MapStr) (cont int, results []inst.Inst, err error)
@@ -295,77 +297,19 @@ func (c *commonInst) hasHost(params types.ContextParams, targetInst inst.Inst, c
 
 func (c *commonInst) DeleteInstByInstID(params types.ContextParams, obj model.Object, instID []int64, needCheckHost bool) error {
 
-	object := obj.Object()
-	cond := condition.CreateCondition()
-	cond.Field(obj.GetInstIDFieldName()).In(instID)
-	if obj.IsCommon() {
-		cond.Field(common.BKObjIDField).Eq(object.ObjectID)
+	roots := make([]metadata.InstDeleteNode, 0, len(instID))
+	for _, id := range instID {
+		roots = append(roots, metadata.InstDeleteNode{ObjectID: obj.GetObjectID(), InstID: id})
 	}
 
-	query := &metadata.QueryInput{}
-	query.Condition = cond.ToMapStr()
-
-	_, insts, err := c.FindInst(params, obj, query, false)
+	// the instances bound to these instances are deleted or forbid the deletion
+	// according to the on delete action of the association.
+	plan, objects, err := c.planDeleteInst(params, obj, roots, needCheckHost)
 	if nil != err {
 		return err
 	}
 
-	deleteIDS := []deletedInst{}
-	for _, inst := range insts {
-		ids, exists, err := c.hasHost(params, inst, needCheckHost)
-		if nil != err {
-			return params.Err.Error(common.CCErrTopoHasHostCheckFailed)
-		}
-
-		if exists {
-			return params.Err.Error(common.CCErrTopoHasHostCheckFailed)
-		}
-
-		deleteIDS = append(deleteIDS, ids...)
-	}
-
-	for _, delInst := range deleteIDS {
-		preAudit := NewSupplementary().Audit(params, c.clientSet, obj, c).CreateSnapshot(delInst.instID, condition.CreateCondition().ToMapStr())
-		// if this instance has been bind to a instance by the association, then this instance should not be deleted.
-		innerCond := condition.CreateCondition()
-		innerCond.Field(common.BKAsstObjIDField).Eq(object.ObjectID)
-		innerCond.Field(common.BKAsstInstIDField).Eq(delInst.instID)
-		err := c.asst.CheckBeAssociation(params, obj, innerCond)
-		if nil != err {
-			return err
-		}
-
-		// this instance has not be bind to another instance, we can delete all the associations it created
-		// by the association with other instances.
-		innerCond = condition.CreateCondition()
-		innerCond.Field(common.BKObjIDField).Eq(object.ObjectID)
-		innerCond.Field(common.BKInstIDField).Eq(delInst.instID)
-		if err := c.asst.DeleteInstAssociation(params, innerCond); nil != err {
-			blog.Errorf("[operation-inst] failed to delete the inst asst, err: %s", err.Error())
-			return err
-		}
-
-		// delete this instance now.
-		delCond := condition.CreateCondition()
-		delCond.Field(obj.GetInstIDFieldName()).In(delInst.instID)
-		if obj.IsCommon() {
-			delCond.Field(common.BKObjIDField).Eq(object.ObjectID)
-		}
-		// clear association
-		rsp, err := c.clientSet.CoreService().Instance().DeleteInstance(context.Background(), params.Header, obj.GetObjectID(), &metadata.DeleteOption{Condition: delCond.ToMapStr()})
-		if nil != err {
-			blog.Errorf("[operation-inst] failed to request object controller, err: %s", err.Error())
-			return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
-		}
-
-		if !rsp.Result {
-			blog.Errorf("[operation-inst] failed to delete the object(%s) inst by the condition(%#v), err: %s", object.ObjectID, delCond.ToMapStr(), rsp.ErrMsg)
-			return params.Err.New(rsp.Code, rsp.ErrMsg)
-		}
-
-		NewSupplementary().Audit(params, c.clientSet, obj, c).CommitDeleteLog(preAudit, nil, nil)
-	}
-	return nil
+	return c.deleteInstByPlan(params, obj, plan, objects)
 }
 
 func (c *commonInst) DeleteInst(params types.ContextParams, obj model.Object, cond condition.Condition, needCheckHost bool) error {
@@ -0,0 +1,230 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"context"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/model"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// instAsstCondition the condition of the instance associations which the instance is the source or the destination of.
+func instAsstCondition(objID string, instID int64) condition.Condition {
+	cond := condition.CreateCondition()
+	or := cond.NewOR()
+	or.Item(mapstr.MapStr{common.BKObjIDField: objID, common.BKInstIDField: instID})
+	or.Item(mapstr.MapStr{common.BKAsstObjIDField: objID, common.BKAsstInstIDField: instID})
+	return cond
+}
+
+// instDeleteLookup looks up the associations by the core service when plan an instance deletion.
+type instDeleteLookup struct {
+	params types.ContextParams
+	client apimachinery.ClientSetInterface
+	asst   AssociationOperationInterface
+}
+
+func (l *instDeleteLookup) InstAssociations(objID string, instID int64) ([]metadata.InstAsst, error) {
+	return l.asst.SearchInstAssociation(l.params, &metadata.QueryInput{Condition: instAsstCondition(objID, instID).ToMapStr()})
+}
+
+func (l *instDeleteLookup) ObjectAssociation(objAsstID string) (*metadata.Association, error) {
+	cond := condition.CreateCondition()
+	cond.Field(common.AssociationObjAsstIDField).Eq(objAsstID)
+	rsp, err := l.client.CoreService().Association().ReadModelAssociation(context.Background(), l.params.Header, &metadata.QueryCondition{Condition: cond.ToMapStr()})
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to request object controller, err: %s", err.Error())
+		return nil, l.params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-inst] failed to search the object association(%s), err: %s", objAsstID, rsp.ErrMsg)
+		return nil, l.params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	if 0 == len(rsp.Data.Info) {
+		return nil, nil
+	}
+	return &rsp.Data.Info[0], nil
+}
+
+func (l *instDeleteLookup) IsMainlineObject(objID string) (bool, error) {
+	cond := condition.CreateCondition()
+	cond.Field(common.AssociationKindIDField).Eq(common.AssociationKindMainline)
+	cond.Field(common.BKObjIDField).Eq(objID)
+	rsp, err := l.client.CoreService().Association().ReadModelAssociation(context.Background(), l.params.Header, &metadata.QueryCondition{Condition: cond.ToMapStr()})
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to request object controller, err: %s", err.Error())
+		return false, l.params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-inst] failed to search the mainline association of the object(%s), err: %s", objID, rsp.ErrMsg)
+		return false, l.params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	return 0 != len(rsp.Data.Info), nil
+}
+
+// planDeleteInst finds the instances and their mainline children, then works out what
+// should be deleted together with them by the on delete actions of the associations.
+// the roots must be the instances of the object, their cause is kept by the children.
+func (c *commonInst) planDeleteInst(params types.ContextParams, obj model.Object, roots []metadata.InstDeleteNode, needCheckHost bool) (*metadata.InstDeletePlan, map[string]model.Object, error) {
+
+	causes := make(map[int64]metadata.InstDeleteNode)
+	instID := make([]int64, 0, len(roots))
+	for _, root := range roots {
+		causes[root.InstID] = root
+		instID = append(instID, root.InstID)
+	}
+
+	cond := condition.CreateCondition()
+	cond.Field(obj.GetInstIDFieldName()).In(instID)
+	if obj.IsCommon() {
+		cond.Field(common.BKObjIDField).Eq(obj.GetObjectID())
+	}
+
+	query := &metadata.QueryInput{}
+	query.Condition = cond.ToMapStr()
+	_, insts, err := c.FindInst(params, obj, query, false)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	objects := map[string]model.Object{obj.GetObjectID(): obj}
+	nodes := []metadata.InstDeleteNode{}
+	for _, inst := range insts {
+		ids, exists, err := c.hasHost(params, inst, needCheckHost)
+		if nil != err {
+			return nil, nil, params.Err.Error(common.CCErrTopoHasHostCheckFailed)
+		}
+
+		if exists {
+			return nil, nil, params.Err.Error(common.CCErrTopoHasHostCheckFailed)
+		}
+
+		id, err := inst.GetInstID()
+		if nil != err {
+			return nil, nil, err
+		}
+
+		for _, delInst := range ids {
+			node := causes[id]
+			node.ObjectID = delInst.obj.GetObjectID()
+			node.InstID = delInst.instID
+			nodes = append(nodes, node)
+			objects[node.ObjectID] = delInst.obj
+		}
+	}
+
+	plan, err := metadata.PlanInstDelete(nodes, &instDeleteLookup{params: params, client: c.clientSet, asst: c.asst})
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to plan the deletion of the object(%s) insts(%v), err: %s", obj.GetObjectID(), instID, err.Error())
+		return nil, nil, err
+	}
+	return plan, objects, nil
+}
+
+// deleteInstByPlan deletes the instances in the plan, the instances deleted by cascade get
+// their own audit logs which tell the association.
+func (c *commonInst) deleteInstByPlan(params types.ContextParams, obj model.Object, plan *metadata.InstDeletePlan, objects map[string]model.Object) error {
+
+	if 0 != len(plan.Restricted) {
+		// the check cleans the dirty associations whose source instance has been deleted,
+		// and fails if any instance is still bound to the instances to be deleted.
+		asstIDs := make([]int64, 0, len(plan.Restricted))
+		for _, asst := range plan.Restricted {
+			asstIDs = append(asstIDs, asst.ID)
+		}
+		cond := condition.CreateCondition()
+		cond.Field(common.BKFieldID).In(asstIDs)
+		if err := c.asst.CheckBeAssociation(params, obj, cond); nil != err {
+			return err
+		}
+	}
+
+	for _, node := range plan.Instances {
+		delObj, exists := objects[node.ObjectID]
+		if !exists {
+			var err error
+			delObj, err = c.obj.FindSingleObject(params, node.ObjectID)
+			if nil != err {
+				blog.Errorf("[operation-inst] failed to find the object(%s), err: %s", node.ObjectID, err.Error())
+				return err
+			}
+			objects[node.ObjectID] = delObj
+		}
+
+		if err := c.deleteInstNode(params, delObj, node); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *commonInst) deleteInstNode(params types.ContextParams, obj model.Object, node metadata.InstDeleteNode) error {
+
+	audit := NewSupplementary().Audit(params, c.clientSet, obj, c)
+	preAudit := audit.CreateSnapshot(node.InstID, condition.CreateCondition().ToMapStr())
+
+	// the associations have been checked by the plan, all of them are deleted with the instance.
+	if err := c.asst.DeleteInstAssociation(params, instAsstCondition(node.ObjectID, node.InstID)); nil != err {
+		blog.Errorf("[operation-inst] failed to delete the inst asst, err: %s", err.Error())
+		return err
+	}
+
+	delCond := condition.CreateCondition()
+	delCond.Field(obj.GetInstIDFieldName()).Eq(node.InstID)
+	if obj.IsCommon() {
+		delCond.Field(common.BKObjIDField).Eq(node.ObjectID)
+	}
+
+	rsp, err := c.clientSet.CoreService().Instance().DeleteInstance(context.Background(), params.Header, node.ObjectID, &metadata.DeleteOption{Condition: delCond.ToMapStr()})
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to request object controller, err: %s", err.Error())
+		return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-inst] failed to delete the object(%s) inst by the condition(%#v), err: %s", node.ObjectID, delCond.ToMapStr(), rsp.ErrMsg)
+		return params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	if 0 == node.Depth {
+		audit.CommitDeleteLog(preAudit, nil, nil)
+	} else {
+		audit.CommitCascadeDeleteLog(preAudit, node.ObjectAsstID)
+	}
+	return nil
+}
+
+// CascadeDeleteInst deletes the instance which is caused by the on delete action of an association.
+func (c *commonInst) CascadeDeleteInst(params types.ContextParams, obj model.Object, cause metadata.InstDeleteNode, needCheckHost bool) error {
+	plan, objects, err := c.planDeleteInst(params, obj, []metadata.InstDeleteNode{cause}, needCheckHost)
+	if nil != err {
+		return err
+	}
+	return c.deleteInstByPlan(params, obj, plan, objects)
+}
+
+// PreviewDeleteInst returns what will be deleted with the instances, nothing is deleted.
+func (c *commonInst) PreviewDeleteInst(params types.ContextParams, obj model.Object, roots []metadata.InstDeleteNode, needCheckHost bool) (*metadata.InstDeletePlan, error) {
+	plan, _, err := c.planDeleteInst(params, obj, roots, needCheckHost)
+	return plan, err
+}
@@ -0,0 +1,160 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"context"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// RecycleOperationInterface recycle bin operation methods
+type RecycleOperationInterface interface {
+	Search(params types.ContextParams, input *metadata.QueryCondition) (*metadata.DelArchiveQueryResult, error)
+	Restore(params types.ContextParams, id int64) (*metadata.RestoredDelArchive, error)
+	Purge(params types.ContextParams, id int64) error
+}
+
+// NewRecycleOperation create a new recycle bin operation instance
+func NewRecycleOperation(client apimachinery.ClientSetInterface) RecycleOperationInterface {
+	return &recycle{
+		clientSet: client,
+	}
+}
+
+type recycle struct {
+	clientSet apimachinery.ClientSetInterface
+}
+
+func (r *recycle) Search(params types.ContextParams, input *metadata.QueryCondition) (*metadata.DelArchiveQueryResult, error) {
+	rsp, err := r.clientSet.CoreService().Instance().SearchDelArchive(context.Background(), params.Header, input)
+	if nil != err {
+		blog.Errorf("[operation-recycle] failed to request core service, err: %s", err.Error())
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-recycle] failed to search the archives by the condition(%#v), err: %s", input.Condition, rsp.ErrMsg)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	return &rsp.Data, nil
+}
+
+func (r *recycle) Restore(params types.ContextParams, id int64) (*metadata.RestoredDelArchive, error) {
+	rsp, err := r.clientSet.CoreService().Instance().RestoreDelArchive(context.Background(), params.Header, id)
+	if nil != err {
+		blog.Errorf("[operation-recycle] failed to request core service, err: %s", err.Error())
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-recycle] failed to restore the archive(%d), err: %s", id, rsp.ErrMsg)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	// the module relations of a host are not archived, so a restored host goes back to the resource pool
+	if rsp.Data.ObjectID == common.BKInnerObjIDHost {
+		if err := r.transferHostToResourcePool(params, rsp.Data.InstID); nil != err {
+			return nil, err
+		}
+	}
+
+	return &rsp.Data, nil
+}
+
+func (r *recycle) Purge(params types.ContextParams, id int64) error {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKFieldID).Eq(id)
+
+	rsp, err := r.clientSet.CoreService().Instance().PurgeDelArchive(context.Background(), params.Header, &metadata.DeleteOption{Condition: cond.ToMapStr()})
+	if nil != err {
+		blog.Errorf("[operation-recycle] failed to request core service, err: %s", err.Error())
+		return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-recycle] failed to purge the archive(%d), err: %s", id, rsp.ErrMsg)
+		return params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	return nil
+}
+
+func (r *recycle) transferHostToResourcePool(params types.ContextParams, hostID int64) error {
+	bizCond := condition.CreateCondition()
+	bizCond.Field(common.BKDefaultField).Eq(common.DefaultAppFlag)
+	bizCond.Field(common.BKOwnerIDField).Eq(params.SupplierAccount)
+	bizID, err := r.searchOneInstID(params, common.BKInnerObjIDApp, common.BKAppIDField, bizCond)
+	if nil != err {
+		return err
+	}
+
+	moduleCond := condition.CreateCondition()
+	moduleCond.Field(common.BKAppIDField).Eq(bizID)
+	moduleCond.Field(common.BKDefaultField).Eq(common.DefaultResModuleFlag)
+	moduleID, err := r.searchOneInstID(params, common.BKInnerObjIDModule, common.BKModuleIDField, moduleCond)
+	if nil != err {
+		return err
+	}
+
+	input := &metadata.ModuleHostConfigParams{
+		ApplicationID: bizID,
+		HostID:        hostID,
+		ModuleID:      []int64{moduleID},
+		OwnerID:       params.SupplierAccount,
+	}
+	rsp, err := r.clientSet.HostController().Module().AddModuleHostConfig(context.Background(), params.Header, input)
+	if nil != err {
+		blog.Errorf("[operation-recycle] failed to request host controller, err: %s", err.Error())
+		return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-recycle] failed to transfer the restored host(%d) to the module(%d), err: %s", hostID, moduleID, rsp.ErrMsg)
+		return params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	return nil
+}
+
+func (r *recycle) searchOneInstID(params types.ContextParams, objID, idField string, cond condition.Condition) (int64, error) {
+	query := &metadata.QueryCondition{Condition: cond.ToMapStr()}
+	query.Limit.Limit = 1
+	rsp, err := r.clientSet.CoreService().Instance().ReadInstance(context.Background(), params.Header, objID, query)
+	if nil != err {
+		blog.Errorf("[operation-recycle] failed to request core service, err: %s", err.Error())
+		return 0, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-recycle] failed to search the object(%s) inst by the condition(%#v), err: %s", objID, cond.ToMapStr(), rsp.ErrMsg)
+		return 0, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	if len(rsp.Data.Info) == 0 {
+		blog.Errorf("[operation-recycle] the object(%s) inst is not found by the condition(%#v)", objID, cond.ToMapStr())
+		return 0, params.Err.Error(common.CCErrTopoInstSelectFailed)
+	}
+
+	id, err := rsp.Data.Info[0].Int64(idField)
+	if nil != err {
+		return 0, params.Err.Errorf(common.CCErrCommParamsNeedInt, idField)
+	}
+	return id, nil
+}
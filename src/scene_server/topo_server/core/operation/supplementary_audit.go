@@ -14,6 +14,7 @@ package operation
 
 import (
 	"context"
+	"fmt"
 
 	"configcenter/src/apimachinery"
 	"configcenter/src/common"
@@ -37,6 +38,7 @@ type AuditInterface interface {
 	CreateSnapshot(instID int64, cond mapstr.MapStr) *WrapperResult
 	CommitCreateLog(preData, currData *WrapperResult, inst inst.Inst)
 	CommitDeleteLog(preData, currData *WrapperResult, inst inst.Inst)
+	CommitCascadeDeleteLog(preData *WrapperResult, objAsstID string)
 	CommitUpdateLog(preData, currData *WrapperResult, inst inst.Inst)
 }
 
@@ -45,6 +47,8 @@ type auditLog struct {
 	inst   InstOperationInterface
 	params types.ContextParams
 	obj    model.Object
+	// the association which causes the deletion
+	objAsstID string
 }
 
 func (a *auditLog) commitSnapshot(preData, currData *WrapperResult, action auditoplog.AuditOpType) {
@@ -95,6 +99,9 @@ func (a *auditLog) commitSnapshot(preData, currData *WrapperResult, action audit
 			desc = "create " + a.obj.GetObjectType()
 		case auditoplog.AuditOpTypeDel:
 			desc = "delete " + a.obj.GetObjectType()
+			if 0 != len(a.objAsstID) {
+				desc = fmt.Sprintf("cascade delete %s by association %s", a.obj.GetObjectType(), a.objAsstID)
+			}
 		case auditoplog.AuditOpTypeModify:
 			if currDataTmp[common.BKDataStatusField] != preDataTmp[common.BKDataStatusField] {
 				switch currDataTmp[common.BKDataStatusField] {
@@ -227,6 +234,11 @@ func (a *auditLog) CommitDeleteLog(preData, currData *WrapperResult, inst inst.I
 	a.commitSnapshot(preData, currData, auditoplog.AuditOpTypeDel)
 }
 
+func (a *auditLog) CommitCascadeDeleteLog(preData *WrapperResult, objAsstID string) {
+	a.objAsstID = objAsstID
+	a.commitSnapshot(preData, nil, auditoplog.AuditOpTypeDel)
+}
+
 func (a *auditLog) CommitUpdateLog(preData, currData *WrapperResult, inst inst.Inst) {
 	a.commitSnapshot(preData, currData, auditoplog.AuditOpTypeModify)
 }
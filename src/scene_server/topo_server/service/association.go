@@ -20,60 +20,46 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 	"configcenter/src/scene_server/topo_server/core/types"
+	"configcenter/src/storage/dal"
+	daltypes "configcenter/src/storage/types"
 )
 
 // CreateMainLineObject create a new object in the main line topo
 func (s *topoService) CreateMainLineObject(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
-	tx, err := s.tx.StartTransaction(context.Background())
-	if err != nil {
-		return nil, params.Err.Error(common.CCErrObjectDBOpErrno)
-	}
-	params.Header = tx.TxnInfo().IntoHeader(params.Header)
-	mainLineAssociation := &metadata.Association{}
-
-	_, err = mainLineAssociation.Parse(data)
-	if nil != err {
-		blog.Errorf("[api-asst] failed to parse the data(%#v), error info is %s", data, err.Error())
-	}
-	params.MetaData = &mainLineAssociation.Metadata
-	ret, err := s.core.AssociationOperation().CreateMainlineAssociation(params, mainLineAssociation)
-
-	if err != nil {
-		if txerr := tx.Abort(context.Background()); txerr != nil {
-			blog.Errorf("[api-asst] abort transaction failed; %v", err)
-			return ret, params.Err.Error(common.CCErrObjectDBOpErrno)
+	var ret interface{}
+	ctx := util.GetDBContext(context.Background(), params.Header)
+	err := dal.RunTransaction(ctx, s.tx, params.Err.Error(common.CCErrObjectDBOpErrno), func(txn *daltypes.Transaction) error {
+		txnParams := params
+		if txn != nil {
+			txnParams.Header = txn.IntoHeader(params.Header)
 		}
-	} else {
-		if txerr := tx.Commit(context.Background()); txerr != nil {
-			return ret, params.Err.Error(common.CCErrObjectDBOpErrno)
+
+		mainLineAssociation := &metadata.Association{}
+		if _, err := mainLineAssociation.Parse(data); nil != err {
+			blog.Errorf("[api-asst] failed to parse the data(%#v), error info is %s", data, err.Error())
 		}
-	}
+		txnParams.MetaData = &mainLineAssociation.Metadata
 
+		var err error
+		ret, err = s.core.AssociationOperation().CreateMainlineAssociation(txnParams, mainLineAssociation)
+		return err
+	})
 	return ret, err
 }
 
 // DeleteMainLineObject delete a object int the main line topo
 func (s *topoService) DeleteMainLineObject(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
-	tx, err := s.tx.StartTransaction(context.Background())
-	if err != nil {
-		return nil, params.Err.Error(common.CCErrObjectDBOpErrno)
-	}
-	params.Header = tx.TxnInfo().IntoHeader(params.Header)
-
 	objID := pathParams("bk_obj_id")
-	err = s.core.AssociationOperation().DeleteMainlineAssociaton(params, objID)
-
-	if err != nil {
-		if txerr := tx.Abort(context.Background()); txerr != nil {
-			blog.Errorf("[api-asst] abort transaction failed; %v", err)
-			return nil, params.Err.Error(common.CCErrObjectDBOpErrno)
-		}
-	} else {
-		if txerr := tx.Commit(context.Background()); txerr != nil {
-			return nil, params.Err.Error(common.CCErrObjectDBOpErrno)
+	ctx := util.GetDBContext(context.Background(), params.Header)
+	err := dal.RunTransaction(ctx, s.tx, params.Err.Error(common.CCErrObjectDBOpErrno), func(txn *daltypes.Transaction) error {
+		txnParams := params
+		if txn != nil {
+			txnParams.Header = txn.IntoHeader(params.Header)
 		}
-	}
+		return s.core.AssociationOperation().DeleteMainlineAssociaton(txnParams, objID)
+	})
 	return nil, err
 }
 
@@ -278,3 +264,13 @@ func (s *topoService) DeleteAssociationInst(params types.ContextParams, pathPara
 
 	}
 }
+
+func (s *topoService) PreviewDeleteAssociationInst(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+
+	id, err := strconv.ParseInt(pathParams("association_id"), 10, 64)
+	if err != nil {
+		return nil, params.Err.Error(common.CCErrCommParamsIsInvalid)
+	}
+
+	return s.core.AssociationOperation().PreviewDeleteInst(params, id)
+}
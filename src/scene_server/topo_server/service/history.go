@@ -0,0 +1,58 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// SearchInstHistory search the versions of an instance
+func (s *topoService) SearchInstHistory(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	instID, err := strconv.ParseInt(pathParams("inst_id"), 10, 64)
+	if nil != err {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "inst_id")
+	}
+
+	input := &metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(input); nil != err {
+		blog.Errorf("[SearchInstHistory] unmarshal error: %v, data: %#v", err, data)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	return s.core.HistoryOperation().SearchInstHistory(params, pathParams("bk_obj_id"), instID, input)
+}
+
+// DiffInstHistory compare two versions of an instance
+func (s *topoService) DiffInstHistory(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	instID, err := strconv.ParseInt(pathParams("inst_id"), 10, 64)
+	if nil != err {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "inst_id")
+	}
+
+	fromVersion, err := data.Int64("from_version")
+	if nil != err {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "from_version")
+	}
+	toVersion, err := data.Int64("to_version")
+	if nil != err {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "to_version")
+	}
+
+	return s.core.HistoryOperation().DiffInstHistory(params, pathParams("bk_obj_id"), instID, fromVersion, toVersion)
+}
@@ -24,6 +24,7 @@ import (
 
 	"configcenter/src/common/metadata"
 	paraparse "configcenter/src/common/paraparse"
+	"configcenter/src/scene_server/topo_server/core/inst"
 	"configcenter/src/scene_server/topo_server/core/operation"
 	"configcenter/src/scene_server/topo_server/core/types"
 )
@@ -111,6 +112,25 @@ func (s *topoService) DeleteInst(params types.ContextParams, pathParams, queryPa
 	err = s.core.InstOperation().DeleteInstByInstID(params, obj, []int64{instID}, true)
 	return nil, err
 }
+
+// PreviewDeleteInst returns the instances and associations which will be deleted with the inst
+func (s *topoService) PreviewDeleteInst(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+
+	instID, err := strconv.ParseInt(pathParams("inst_id"), 10, 64)
+	if nil != err {
+		blog.Errorf("[api-inst]failed to parse the inst id, error info is %s", err.Error())
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "inst id")
+	}
+
+	obj, err := s.core.ObjectOperation().FindSingleObject(params, pathParams("bk_obj_id"))
+	if nil != err {
+		blog.Errorf("[api-inst] failed to find the objects(%s), error info is %s", pathParams("bk_obj_id"), err.Error())
+		return nil, err
+	}
+
+	roots := []metadata.InstDeleteNode{{ObjectID: obj.GetObjectID(), InstID: instID}}
+	return s.core.InstOperation().PreviewDeleteInst(params, obj, roots, true)
+}
 func (s *topoService) UpdateInsts(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
 
 	objID := pathParams("bk_obj_id")
@@ -213,7 +233,13 @@ func (s *topoService) SearchInsts(params types.ContextParams, pathParams, queryP
 	query.Sort = page.Sort
 	query.Start = page.Start
 
-	cnt, instItems, err := s.core.InstOperation().FindInst(params, obj, query, false)
+	var cnt int
+	var instItems []inst.Inst
+	if nil != queryCond.AsOf {
+		cnt, instItems, err = s.core.HistoryOperation().FindInstAsOf(params, obj, query, queryCond.AsOf.Time)
+	} else {
+		cnt, instItems, err = s.core.InstOperation().FindInst(params, obj, query, false)
+	}
 	if nil != err {
 		blog.Errorf("[api-inst] failed to find the objects(%s), error info is %s", pathParams("obj_id"), err.Error())
 		return nil, err
@@ -286,7 +312,14 @@ func (s *topoService) SearchInstByObject(params types.ContextParams, pathParams,
 	query.Limit = page.Limit
 	query.Sort = page.Sort
 	query.Start = page.Start
-	cnt, instItems, err := s.core.InstOperation().FindInst(params, obj, query, false)
+
+	var cnt int
+	var instItems []inst.Inst
+	if nil != queryCond.AsOf {
+		cnt, instItems, err = s.core.HistoryOperation().FindInstAsOf(params, obj, query, queryCond.AsOf.Time)
+	} else {
+		cnt, instItems, err = s.core.InstOperation().FindInst(params, obj, query, false)
+	}
 	if nil != err {
 		blog.Errorf("[api-inst] failed to find the objects(%s), error info is %s", pathParams("bk_obj_id"), err.Error())
 		return nil, err
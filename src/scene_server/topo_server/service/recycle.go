@@ -0,0 +1,54 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// SearchDelArchive search the archives of the deleted instances in the recycle bin
+func (s *topoService) SearchDelArchive(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := &metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(input); nil != err {
+		blog.Errorf("[SearchDelArchive] unmarshal error: %v, data: %#v", err, data)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	return s.core.RecycleOperation().Search(params, input)
+}
+
+// RestoreDelArchive restore an archived instance and the associations deleted with it
+func (s *topoService) RestoreDelArchive(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if nil != err {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "id")
+	}
+
+	return s.core.RecycleOperation().Restore(params, id)
+}
+
+// PurgeDelArchive remove an archive from the recycle bin permanently
+func (s *topoService) PurgeDelArchive(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseInt(pathParams("id"), 10, 64)
+	if nil != err {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "id")
+	}
+
+	return nil, s.core.RecycleOperation().Purge(params, id)
+}
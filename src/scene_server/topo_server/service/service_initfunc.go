@@ -50,6 +50,7 @@ func (s *topoService) initAssociation() {
 	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/inst/association/action/search", HandlerFunc: s.SearchAssociationInst})
 	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/inst/association/action/create", HandlerFunc: s.CreateAssociationInst})
 	s.actions = append(s.actions, action{Method: http.MethodDelete, Path: "/inst/association/{association_id}/action/delete", HandlerFunc: s.DeleteAssociationInst})
+	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/inst/association/{association_id}/action/delete/preview", HandlerFunc: s.PreviewDeleteAssociationInst})
 
 	// topo search methods
 	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/inst/association/search/owner/{owner_id}/object/{bk_obj_id}", HandlerFunc: s.SearchInstByAssociation})
@@ -113,6 +114,7 @@ func (s *topoService) initInst() {
 	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/inst/{owner_id}/{bk_obj_id}", HandlerFunc: s.CreateInst})
 	s.actions = append(s.actions, action{Method: http.MethodDelete, Path: "/inst/{owner_id}/{bk_obj_id}/{inst_id}", HandlerFunc: s.DeleteInst})
 	s.actions = append(s.actions, action{Method: http.MethodDelete, Path: "/inst/{owner_id}/{bk_obj_id}/batch", HandlerFunc: s.DeleteInsts})
+	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/inst/delete/preview/{owner_id}/{bk_obj_id}/{inst_id}", HandlerFunc: s.PreviewDeleteInst})
 	s.actions = append(s.actions, action{Method: http.MethodPut, Path: "/inst/{owner_id}/{bk_obj_id}/{inst_id}", HandlerFunc: s.UpdateInst})
 	s.actions = append(s.actions, action{Method: http.MethodPut, Path: "/inst/{owner_id}/{bk_obj_id}/batch/update", HandlerFunc: s.UpdateInsts})
 	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/inst/search/{owner_id}/{bk_obj_id}", HandlerFunc: s.SearchInsts})
@@ -189,6 +191,17 @@ func (s *topoService) initIdentifier() {
 	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/identifier/{obj_type}/search", HandlerFunc: s.SearchIdentifier, HandlerParseOriginDataFunc: s.ParseSearchIdentifierOriginData})
 }
 
+func (s *topoService) initRecycle() {
+	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/recycle/archive/search", HandlerFunc: s.SearchDelArchive})
+	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/recycle/archive/{id}/action/restore", HandlerFunc: s.RestoreDelArchive})
+	s.actions = append(s.actions, action{Method: http.MethodDelete, Path: "/recycle/archive/{id}", HandlerFunc: s.PurgeDelArchive})
+}
+
+func (s *topoService) initInstHistory() {
+	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/inst/{bk_obj_id}/{inst_id}/history", HandlerFunc: s.SearchInstHistory})
+	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/inst/{bk_obj_id}/{inst_id}/history/diff", HandlerFunc: s.DiffInstHistory})
+}
+
 func (s *topoService) initService() {
 	s.initHealth()
 	s.initAssociation()
@@ -208,6 +221,8 @@ func (s *topoService) initService() {
 	s.initGraphics()
 	s.initIdentifier()
 	s.initObjectObjectUnique()
+	s.initRecycle()
+	s.initInstHistory()
 
 	s.initBusinessObject()
 	s.initBusinessClassification()
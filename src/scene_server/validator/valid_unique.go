@@ -40,6 +40,10 @@ func (valid *ValidMap) validCreateUnique(valData map[string]interface{}) error {
 	}
 
 	for _, unique := range uniqueresp.Data {
+		if unique.HasAssociationKey() {
+			// the unique with association keys is checked by the core service with the associated instances
+			continue
+		}
 		// retrieve unique value
 		uniquekeys := map[string]bool{}
 		for _, key := range unique.Keys {
@@ -140,6 +144,10 @@ func (valid *ValidMap) validUpdateUnique(valData map[string]interface{}, instID
 	}
 
 	for _, unique := range uniqueresp.Data {
+		if unique.HasAssociationKey() {
+			// the unique with association keys is checked by the core service with the associated instances
+			continue
+		}
 		// retrive unique value
 		uniquekeys := map[string]bool{}
 		for _, key := range unique.Keys {
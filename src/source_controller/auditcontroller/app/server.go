@@ -29,6 +29,7 @@ import (
 	"configcenter/src/source_controller/auditcontroller/app/options"
 	"configcenter/src/source_controller/auditcontroller/logics"
 	"configcenter/src/source_controller/auditcontroller/service"
+	"configcenter/src/storage/dal/instrument"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
 )
@@ -44,6 +45,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 	coreService := new(service.Service)
 	audit := new(AuditController)
 	audit.Service = coreService
+	audit.process = instrument.ProcessName(types.CC_MODULE_AUDITCONTROLLER, svrInfo)
 	coreService.Logics = &logics.Logics{Instance: audit.Instance, Engine: audit.Service.Engine}
 	input := &backbone.BackboneParameter{
 		ConfigUpdate: audit.onAduitConfigUpdate,
@@ -85,7 +87,8 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 // AuditController  audit controller config
 type AuditController struct {
 	*service.Service
-	Config *options.Config
+	Config  *options.Config
+	process string
 }
 
 func (h *AuditController) onAduitConfigUpdate(previous, current cc.ProcessConfig) {
@@ -93,14 +96,30 @@ func (h *AuditController) onAduitConfigUpdate(previous, current cc.ProcessConfig
 		Mongo: mongo.ParseConfigFromKV("mongodb", current.ConfigMap),
 	}
 
-	instance, err := local.NewMgo(h.Config.Mongo.BuildURI(), time.Minute)
+	readOpt, err := h.Config.Mongo.GetReadOption()
+	if err != nil {
+		blog.Errorf("invalid mongo read preference config, err: %s", err.Error())
+		return
+	}
+	db, err := local.NewMgoByConfig(h.Config.Mongo, time.Minute)
 	if err != nil {
 		blog.Errorf("new mongo client failed, err: %s", err.Error())
 		return
 	}
+	if err = db.SetDefaultReadOption(readOpt); err != nil {
+		blog.Errorf("invalid mongo read preference config, err: %s", err.Error())
+		return
+	}
+	// record the latencies and the slow queries of the db operations
+	instance := instrument.New(db, instrument.Options{
+		SlowThreshold: h.Config.Mongo.GetSlowQueryThreshold(),
+		Process:       h.process,
+		FlushInterval: time.Minute,
+	})
 	h.Service.Instance = instance
 	h.Service.Logics.Instance = instance
-
+	// close the replaced db so that its profiler stops flushing
+	h.Service.DBMetric.Swap(instance)
 }
 
 func newServerInfo(op *options.ServerOption) (*types.ServerInfo, error) {
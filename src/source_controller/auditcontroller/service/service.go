@@ -24,12 +24,15 @@ import (
 	"configcenter/src/common/types"
 	"configcenter/src/source_controller/auditcontroller/logics"
 	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/instrument"
 )
 
 type Service struct {
 	*backbone.Engine
 	*logics.Logics
 	Instance dal.RDB
+	// DBMetric exports the latency histograms of the db operations
+	DBMetric instrument.Collector
 }
 
 func (s *Service) WebService() *restful.WebService {
@@ -54,6 +57,7 @@ func (s *Service) WebService() *restful.WebService {
 	ws.Route(ws.POST("/sets/{owner_id}/{biz_id}/{user}").To(s.AddSetLogs))
 	ws.Route(ws.POST("/search").To(s.Get))
 	ws.Route(ws.GET("/healthz").To(s.Healthz))
+	s.DBMetric.Register(ws, types.CC_MODULE_AUDITCONTROLLER)
 
 	return ws
 }
@@ -13,6 +13,9 @@
 package options
 
 import (
+	"strconv"
+	"time"
+
 	"configcenter/src/common/core/cc/config"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
@@ -27,8 +30,46 @@ type ServerOption struct {
 
 // Config export
 type Config struct {
-	Mongo mongo.Config
-	Redis redis.Config
+	Mongo   mongo.Config
+	Redis   redis.Config
+	Recycle RecycleConfig
+	History HistoryConfig
+}
+
+// defaultRecycleTTL the default time the archives are kept in the recycle bin
+const defaultRecycleTTL = 7 * 24 * time.Hour
+
+// RecycleConfig the config of the recycle bin, the deleted instances and instance associations
+// are archived into the recycle bin if it's enabled, and can be restored before they expire.
+type RecycleConfig struct {
+	Enabled bool
+	TTL     time.Duration
+}
+
+// ParseRecycleConfigFromKV returns the recycle bin config, which is disabled by default,
+// the ttl is configured in hours.
+func ParseRecycleConfigFromKV(prefix string, configmap map[string]string) RecycleConfig {
+	cfg := RecycleConfig{TTL: defaultRecycleTTL}
+	cfg.Enabled, _ = strconv.ParseBool(configmap[prefix+".enabled"])
+	if hours, err := strconv.Atoi(configmap[prefix+".ttlHours"]); err == nil && hours > 0 {
+		cfg.TTL = time.Duration(hours) * time.Hour
+	}
+	return cfg
+}
+
+// HistoryConfig the config of the history of the instances and the module host relations, the history older
+// than the retention is pruned except the versions the queries as of a later time need, 0 keeps it forever.
+type HistoryConfig struct {
+	Retention time.Duration
+}
+
+// ParseHistoryConfigFromKV returns the history config, the retention is configured in days.
+func ParseHistoryConfigFromKV(prefix string, configmap map[string]string) HistoryConfig {
+	cfg := HistoryConfig{}
+	if days, err := strconv.Atoi(configmap[prefix+".retentionDays"]); err == nil && days > 0 {
+		cfg.Retention = time.Duration(days) * 24 * time.Hour
+	}
+	return cfg
 }
 
 //NewServerOption create a ServerOption object
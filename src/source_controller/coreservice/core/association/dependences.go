@@ -13,6 +13,7 @@
 package association
 
 import (
+	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
 )
 
@@ -23,4 +24,7 @@ type OperationDependences interface {
 
 	// IsInstanceExist used to check if the  instances exist
 	IsInstanceExist(ctx core.ContextParams, objID string, instID uint64) (exists bool, err error)
+
+	// ArchiveDeleted archive the deleted instances and instance associations into the recycle bin
+	ArchiveDeleted(ctx core.ContextParams, archives []metadata.DelArchive) error
 }
@@ -86,7 +86,20 @@ func (m *associationInstance) save(ctx core.ContextParams, asstInst metadata.Ins
 	asstInst.ID = int64(id)
 
 	err = m.dbProxy.Table(common.BKTableNameInstAsst).Insert(ctx, asstInst)
-	return id, err
+	if err != nil {
+		return id, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	// the mapping checked before may be broken by the one saved concurrently
+	if err := RecheckInstAsstMapping(ctx, m.dbProxy, asstInst); nil != err {
+		cond := mongo.NewCondition()
+		cond.Element(&mongo.Eq{Key: common.BKFieldID, Val: asstInst.ID})
+		if delErr := m.dbProxy.Table(common.BKTableNameInstAsst).Delete(ctx, cond.ToMapStr()); nil != delErr {
+			blog.Errorf("request(%s): failed to remove the instance association(%d) breaking the mapping, error info is %s", ctx.ReqID, asstInst.ID, delErr.Error())
+		}
+		return 0, err
+	}
+	return id, nil
 }
 
 func (m *associationInstance) CreateOneInstanceAssociation(ctx core.ContextParams, inputParam metadata.CreateOneInstanceAssociation) (*metadata.CreateOneDataResult, error) {
@@ -132,6 +145,14 @@ func (m *associationInstance) CreateOneInstanceAssociation(ctx core.ContextParam
 		blog.Errorf("asst inst is not exist objid(%#v), instid(%#v)", inputParam.Data.ObjectID, inputParam.Data.InstID)
 		return nil, ctx.Error.Error(common.CCErrorInstToAsstIsNotExist)
 	}
+	//check the mapping of the association
+	if err := CheckInstAsstMapping(ctx, m.dbProxy, inputParam.Data); nil != err {
+		return nil, err
+	}
+	//check the uniques with the association keys of the instances
+	if err := CheckInstAsstUnique(ctx, m.dbProxy, []metadata.InstAsst{inputParam.Data}, false); nil != err {
+		return nil, err
+	}
 	id, err := m.save(ctx, inputParam.Data)
 	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, err
 }
@@ -156,8 +177,10 @@ func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextPara
 			dataResult.Repeated = append(dataResult.Repeated, metadata.RepeatedDataResult{OriginIndex: int64(itemIdx), Data: mapstr.NewFromStruct(item, "field")})
 			continue
 		}
-		//check asst kind
-		_, exists, err = m.associationKind.isExists(ctx, item.ObjectAsstID)
+		//check the model association, as CreateOneInstanceAssociation does
+		cond := mongo.NewCondition()
+		cond.Element(&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: item.ObjectAsstID})
+		_, exists, err = m.associationModel.isExists(ctx, cond)
 		if nil != err {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
 				Message:     err.Error(),
@@ -169,8 +192,8 @@ func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextPara
 		}
 		if !exists {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
-				Message:     ctx.Error.Error(common.CCErrorAsstInstIsNotExist).Error(),
-				Code:        int64(common.CCErrorAsstInstIsNotExist),
+				Message:     ctx.Error.Error(common.CCErrorTopoAsstKindIsNotExist).Error(),
+				Code:        int64(common.CCErrorTopoAsstKindIsNotExist),
 				Data:        item,
 				OriginIndex: int64(itemIdx),
 			})
@@ -218,6 +241,26 @@ func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextPara
 			})
 			continue
 		}
+		//check the mapping of the association, the items saved before are counted too
+		if err := CheckInstAsstMapping(ctx, m.dbProxy, item); nil != err {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        int64(err.(errors.CCErrorCoder).GetCode()),
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
+		//check the uniques with the association keys of the instances
+		if err := CheckInstAsstUnique(ctx, m.dbProxy, []metadata.InstAsst{item}, false); nil != err {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        int64(err.(errors.CCErrorCoder).GetCode()),
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
 		//save asst inst
 		id, err := m.save(ctx, item)
 		if nil != err {
@@ -275,6 +318,24 @@ func (m *associationInstance) DeleteInstanceAssociation(ctx core.ContextParams,
 		blog.Errorf("delete inst association get inst [%#v] count err [%#v]", inputParam.Condition, err)
 		return &metadata.DeletedCount{}, err
 	}
+	//check the uniques with the association keys of the instances, which lose the associations
+	assts := make([]metadata.InstAsst, 0)
+	err = m.dbProxy.Table(common.BKTableNameInstAsst).Find(inputParam.Condition).All(ctx, &assts)
+	if nil != err {
+		blog.Errorf("delete inst association get inst [%#v] err [%#v]", inputParam.Condition, err)
+		return &metadata.DeletedCount{}, err
+	}
+	if err := CheckInstAsstUnique(ctx, m.dbProxy, assts, true); nil != err {
+		return &metadata.DeletedCount{}, err
+	}
+	archives := make([]metadata.DelArchive, 0, len(assts))
+	for _, asst := range assts {
+		archives = append(archives, metadata.NewInstAsstDelArchive(asst))
+	}
+	if err := m.dependent.ArchiveDeleted(ctx, archives); nil != err {
+		blog.Errorf("delete inst association archive [%#v] err [%#v]", inputParam.Condition, err)
+		return &metadata.DeletedCount{}, err
+	}
 
 	err = m.dbProxy.Table(common.BKTableNameInstAsst).Delete(ctx, inputParam.Condition)
 	if nil != err {
@@ -0,0 +1,102 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+// CheckInstAsstMapping checks whether the instance association breaks the mapping of its model association,
+// an instance can be the source(1:1) or the destination(1:1, 1:n) of only one instance association
+// of the model association, n:n has no limit. the instance association itself is skipped when it has an id,
+// so that it can be updated.
+func CheckInstAsstMapping(ctx core.ContextParams, db dal.RDB, asst metadata.InstAsst) errors.CCError {
+	return checkInstAsstMapping(ctx, db, asst)
+}
+
+// RecheckInstAsstMapping checks the mapping again after the instance association is saved, as the check and
+// the save are not atomic, the concurrent ones may all pass the check. any other instance association is
+// counted whatever its id is, so the concurrent ones may all fail and be removed by the callers, which is
+// better than breaking the mapping. the ones not committed yet in the other transactions are not seen.
+func RecheckInstAsstMapping(ctx core.ContextParams, db dal.RDB, asst metadata.InstAsst) errors.CCError {
+	return checkInstAsstMapping(ctx, db, asst)
+}
+
+func checkInstAsstMapping(ctx core.ContextParams, db dal.RDB, asst metadata.InstAsst) errors.CCError {
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: asst.ObjectAsstID})
+	objAsst := metadata.Association{}
+	err := db.Table(common.BKTableNameObjAsst).Find(cond.ToMapStr()).One(ctx, &objAsst)
+	if db.IsNotFoundError(err) {
+		// the association is checked by the caller
+		return nil
+	}
+	if nil != err {
+		blog.Errorf("request(%s): failed to search the association(%s), error info is %s", ctx.ReqID, asst.ObjectAsstID, err.Error())
+		return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	switch objAsst.Mapping {
+	case metadata.OneToOneMapping:
+		exists, err := isInstAsstMapped(ctx, db, asst, common.BKInstIDField, asst.InstID)
+		if nil != err {
+			return err
+		}
+		if !exists {
+			exists, err = isInstAsstMapped(ctx, db, asst, common.BKAsstInstIDField, asst.AsstInstID)
+			if nil != err {
+				return err
+			}
+		}
+		if exists {
+			blog.Errorf("request(%s): the instance association(%#v) breaks the 1:1 mapping", ctx.ReqID, asst)
+			return ctx.Error.Error(common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation)
+		}
+
+	case metadata.OneToManyMapping:
+		exists, err := isInstAsstMapped(ctx, db, asst, common.BKAsstInstIDField, asst.AsstInstID)
+		if nil != err {
+			return err
+		}
+		if exists {
+			blog.Errorf("request(%s): the instance association(%#v) breaks the 1:n mapping", ctx.ReqID, asst)
+			return ctx.Error.Error(common.CCErrorTopoCreateMultipleSourcesForOneToManyAssociation)
+		}
+	}
+	return nil
+}
+
+// isInstAsstMapped checks whether the instance has been related by another instance association of the same
+// model association, instField tells which side the instance is.
+func isInstAsstMapped(ctx core.ContextParams, db dal.RDB, asst metadata.InstAsst, instField string, instID int64) (bool, errors.CCError) {
+	cond := mongo.NewCondition()
+	cond.Element(
+		&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: asst.ObjectAsstID},
+		&mongo.Eq{Key: instField, Val: instID})
+	if 0 != asst.ID {
+		cond.Element(&mongo.Neq{Key: common.BKFieldID, Val: asst.ID})
+	}
+
+	cnt, err := db.Table(common.BKTableNameInstAsst).Find(cond.ToMapStr()).Count(ctx)
+	if nil != err {
+		blog.Errorf("request(%s): failed to count the instance association by the condition(%#v), error info is %s", ctx.ReqID, cond.ToMapStr(), err.Error())
+		return false, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+	return 0 != cnt, nil
+}
@@ -0,0 +1,133 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association_test
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/association"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createMappingAsst creates the association kind and the model association with the mapping
+func createMappingAsst(t *testing.T, asstMgr core.AssociationOperation, objID, asstObjID string, mapping metadata.AssociationMapping) string {
+	kindID := objID + "_" + asstObjID
+	kindParams := metadata.CreateAssociationKind{}
+	kindParams.Data.AssociationKindID = kindID
+	kindParams.Data.AssociationKindName = kindID
+	_, err := asstMgr.CreateAssociationKind(defaultCtx, kindParams)
+	require.NoError(t, err)
+
+	asstParams := metadata.CreateModelAssociation{}
+	asstParams.Spec.AsstKindID = kindID
+	asstParams.Spec.ObjectID = objID
+	asstParams.Spec.AsstObjID = asstObjID
+	asstParams.Spec.AssociationName = objID + "_" + kindID + "_" + asstObjID
+	asstParams.Spec.Mapping = mapping
+	_, err = asstMgr.CreateModelAssociation(defaultCtx, asstParams)
+	require.NoError(t, err)
+	return asstParams.Spec.AssociationName
+}
+
+func newMappingInstAsst(objAsstID, objID, asstObjID string, instID, asstInstID int64) metadata.InstAsst {
+	return metadata.InstAsst{
+		ObjectAsstID: objAsstID,
+		ObjectID:     objID,
+		InstID:       instID,
+		AsstObjectID: asstObjID,
+		AsstInstID:   asstInstID,
+	}
+}
+
+func requireErrCode(t *testing.T, code int, err error) {
+	require.Error(t, err)
+	require.Equal(t, code, err.(errors.CCErrorCoder).GetCode())
+}
+
+func TestCheckInstAsstMapping(t *testing.T) {
+	db := memory.New()
+	asstMgr := association.New(db, &mockDependences{})
+
+	// 1:1, either side can only be related once
+	oneToOne := createMappingAsst(t, asstMgr, "bk_switch", "bk_router", metadata.OneToOneMapping)
+	create := func(objAsstID string, instID, asstInstID int64) error {
+		_, err := asstMgr.CreateOneInstanceAssociation(defaultCtx, metadata.CreateOneInstanceAssociation{
+			Data: newMappingInstAsst(objAsstID, "bk_switch", "bk_router", instID, asstInstID)})
+		return err
+	}
+	require.NoError(t, create(oneToOne, 1, 10))
+	requireErrCode(t, common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation, create(oneToOne, 1, 11))
+	requireErrCode(t, common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation, create(oneToOne, 2, 10))
+
+	// the items created before in the same batch are counted
+	result, err := asstMgr.CreateManyInstanceAssociation(defaultCtx, metadata.CreateManyInstanceAssociation{Datas: []metadata.InstAsst{
+		newMappingInstAsst(oneToOne, "bk_switch", "bk_router", 3, 12),
+		newMappingInstAsst(oneToOne, "bk_switch", "bk_router", 3, 13),
+		newMappingInstAsst(oneToOne, "bk_switch", "bk_router", 4, 14),
+	}})
+	require.NoError(t, err)
+	require.Len(t, result.Created, 2)
+	require.Len(t, result.Exceptions, 1)
+	require.Equal(t, int64(1), result.Exceptions[0].OriginIndex)
+	require.Equal(t, int64(common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation), result.Exceptions[0].Code)
+
+	// 1:n, the destination can only be related once
+	oneToMany := createMappingAsst(t, asstMgr, "bk_rack", "bk_server", metadata.OneToManyMapping)
+	result, err = asstMgr.CreateManyInstanceAssociation(defaultCtx, metadata.CreateManyInstanceAssociation{Datas: []metadata.InstAsst{
+		newMappingInstAsst(oneToMany, "bk_rack", "bk_server", 1, 20),
+		newMappingInstAsst(oneToMany, "bk_rack", "bk_server", 1, 21),
+		newMappingInstAsst(oneToMany, "bk_rack", "bk_server", 2, 20),
+	}})
+	require.NoError(t, err)
+	require.Len(t, result.Created, 2)
+	require.Len(t, result.Exceptions, 1)
+	require.Equal(t, int64(2), result.Exceptions[0].OriginIndex)
+	require.Equal(t, int64(common.CCErrorTopoCreateMultipleSourcesForOneToManyAssociation), result.Exceptions[0].Code)
+	_, err = asstMgr.CreateOneInstanceAssociation(defaultCtx, metadata.CreateOneInstanceAssociation{
+		Data: newMappingInstAsst(oneToMany, "bk_rack", "bk_server", 3, 21)})
+	requireErrCode(t, common.CCErrorTopoCreateMultipleSourcesForOneToManyAssociation, err)
+
+	// the instance association itself is skipped, so that it can be updated
+	asst := metadata.InstAsst{}
+	require.NoError(t, db.Table(common.BKTableNameInstAsst).Find(mapstr.MapStr{common.BKAsstInstIDField: 20}).One(defaultCtx, &asst))
+	require.NoError(t, association.CheckInstAsstMapping(defaultCtx, db, asst))
+	asst.ID = 0
+	requireErrCode(t, common.CCErrorTopoCreateMultipleSourcesForOneToManyAssociation, association.CheckInstAsstMapping(defaultCtx, db, asst))
+}
+
+func TestRecheckInstAsstMapping(t *testing.T) {
+	db := memory.New()
+	asstMgr := association.New(db, &mockDependences{})
+	oneToOne := createMappingAsst(t, asstMgr, "bk_switch", "bk_router", metadata.OneToOneMapping)
+
+	// the ones saved at the same time both passed the check, both of them fail the recheck
+	first := newMappingInstAsst(oneToOne, "bk_switch", "bk_router", 1, 10)
+	first.ID = 100
+	second := newMappingInstAsst(oneToOne, "bk_switch", "bk_router", 1, 11)
+	second.ID = 101
+	table := db.Table(common.BKTableNameInstAsst)
+	require.NoError(t, table.Insert(defaultCtx, []metadata.InstAsst{first, second}))
+	requireErrCode(t, common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation, association.RecheckInstAsstMapping(defaultCtx, db, first))
+	requireErrCode(t, common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation, association.RecheckInstAsstMapping(defaultCtx, db, second))
+
+	// the one saved alone passes the recheck
+	require.NoError(t, table.Delete(defaultCtx, mapstr.MapStr{common.BKFieldID: second.ID}))
+	require.NoError(t, association.RecheckInstAsstMapping(defaultCtx, db, first))
+}
@@ -52,12 +52,12 @@ func TestAssociationInstance(t *testing.T) {
 	asstModelParams.Spec.AsstObjID = asstObjID
 	asstModelParams.Spec.AssociationName = associationName
 	asstModelParams.Spec.AssociationAliasName = "sw2router"
-	createasstModelResult, err := asstMgr.CreateModelAssociation(defaultCtx, metadata.CreateModelAssociation{})
+	createasstModelResult, err := asstMgr.CreateModelAssociation(defaultCtx, asstModelParams)
 	require.Nil(t, err)
 	require.NotNil(t, createasstModelResult)
 
 	//create bk_switch instance
-	objInstanceParams := metadata.CreateModelInstance{}
+	objInstanceParams := metadata.CreateModelInstance{Data: mapstr.New()}
 	objInstanceParams.Data.Set(common.BKInstNameField, xid.New().String())
 	objInstanceParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	instResult, err := instMgr.CreateModelInstance(defaultCtx, objID, objInstanceParams)
@@ -67,7 +67,7 @@ func TestAssociationInstance(t *testing.T) {
 	instID := instResult.Created.ID
 
 	//create bk_router instance
-	objAsstInstanceParams := metadata.CreateModelInstance{}
+	objAsstInstanceParams := metadata.CreateModelInstance{Data: mapstr.New()}
 	objAsstInstanceParams.Data.Set(common.BKInstNameField, xid.New().String())
 	objAsstInstanceParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	instAsstResult, err := instMgr.CreateModelInstance(defaultCtx, asstObjID, objAsstInstanceParams)
@@ -93,7 +93,7 @@ func TestAssociationInstance(t *testing.T) {
 	require.NotNil(t, err)
 
 	//create bk_switch instance 1
-	objInstanceParams = metadata.CreateModelInstance{}
+	objInstanceParams = metadata.CreateModelInstance{Data: mapstr.New()}
 	objInstanceParams.Data.Set(common.BKInstNameField, xid.New().String())
 	objInstanceParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	instResult, err = instMgr.CreateModelInstance(defaultCtx, objID, objInstanceParams)
@@ -103,7 +103,7 @@ func TestAssociationInstance(t *testing.T) {
 	instID1 := instResult.Created.ID
 
 	//create bk_switch instance 2
-	objInstanceParams = metadata.CreateModelInstance{}
+	objInstanceParams = metadata.CreateModelInstance{Data: mapstr.New()}
 	objInstanceParams.Data.Set(common.BKInstNameField, xid.New().String())
 	objInstanceParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	instResult, err = instMgr.CreateModelInstance(defaultCtx, objID, objInstanceParams)
@@ -113,7 +113,7 @@ func TestAssociationInstance(t *testing.T) {
 	instID2 := instResult.Created.ID
 
 	//create bk_router instance1
-	objAsstInstanceParams = metadata.CreateModelInstance{}
+	objAsstInstanceParams = metadata.CreateModelInstance{Data: mapstr.New()}
 	objAsstInstanceParams.Data.Set(common.BKInstNameField, xid.New().String())
 	objAsstInstanceParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	instAsstResult, err = instMgr.CreateModelInstance(defaultCtx, asstObjID, objAsstInstanceParams)
@@ -123,7 +123,7 @@ func TestAssociationInstance(t *testing.T) {
 	asstInstID1 := instAsstResult.Created.ID
 
 	//create bk_router instance2
-	objAsstInstanceParams = metadata.CreateModelInstance{}
+	objAsstInstanceParams = metadata.CreateModelInstance{Data: mapstr.New()}
 	objAsstInstanceParams.Data.Set(common.BKInstNameField, xid.New().String())
 	objAsstInstanceParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	instAsstResult, err = instMgr.CreateModelInstance(defaultCtx, asstObjID, objAsstInstanceParams)
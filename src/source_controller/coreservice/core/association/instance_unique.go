@@ -0,0 +1,307 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+// instEndpoint an instance on one side of instance associations
+type instEndpoint struct {
+	objID  string
+	instID int64
+}
+
+// instAsstChange the changed instance associations of an instance, it is
+// bk_obj_asst_id -> the id of the associated instance -> whether the association is added or deleted
+type instAsstChange map[string]map[int64]bool
+
+// apply returns the ids of the associated instances after the change
+func (c instAsstChange) apply(objAsstID string, ids []int64) []int64 {
+	changed, ok := c[objAsstID]
+	if !ok {
+		return ids
+	}
+	result := make([]int64, 0, len(ids)+len(changed))
+	for _, id := range ids {
+		if added, ok := changed[id]; ok && !added {
+			continue
+		}
+		result = append(result, id)
+	}
+	for id, added := range changed {
+		if added {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// CheckInstUnique checks the uniques with association keys of the instance, inst is the data of the instance
+// to be saved. the uniques without association keys are checked by the instance validator.
+func CheckInstUnique(ctx core.ContextParams, db dal.RDB, objID string, instID int64, inst mapstr.MapStr) errors.CCError {
+	return checkInstUnique(ctx, db, instEndpoint{objID: objID, instID: instID}, inst, nil)
+}
+
+// CheckInstAsstUnique checks the uniques with association keys of the instances on both sides of the instance
+// associations, as if the instance associations have been created, or deleted if deleted is true.
+func CheckInstAsstUnique(ctx core.ContextParams, db dal.RDB, assts []metadata.InstAsst, deleted bool) errors.CCError {
+	changes := make(map[instEndpoint]instAsstChange)
+	endpoints := make([]instEndpoint, 0)
+	addChange := func(endpoint instEndpoint, objAsstID string, asstInstID int64) {
+		change, ok := changes[endpoint]
+		if !ok {
+			change = make(instAsstChange)
+			changes[endpoint] = change
+			endpoints = append(endpoints, endpoint)
+		}
+		if _, ok := change[objAsstID]; !ok {
+			change[objAsstID] = make(map[int64]bool)
+		}
+		change[objAsstID][asstInstID] = !deleted
+	}
+	for _, asst := range assts {
+		addChange(instEndpoint{objID: asst.ObjectID, instID: asst.InstID}, asst.ObjectAsstID, asst.AsstInstID)
+		// the instance of a self association is regarded as the source side
+		if asst.ObjectID != asst.AsstObjectID {
+			addChange(instEndpoint{objID: asst.AsstObjectID, instID: asst.AsstInstID}, asst.ObjectAsstID, asst.InstID)
+		}
+	}
+
+	for _, endpoint := range endpoints {
+		cond := mongo.NewCondition()
+		cond.Element(&mongo.Eq{Key: common.GetInstIDField(endpoint.objID), Val: endpoint.instID})
+		if common.GetInstTableName(endpoint.objID) == common.BKTableNameBaseInst {
+			cond.Element(&mongo.Eq{Key: common.BKObjIDField, Val: endpoint.objID})
+		}
+		inst := mapstr.New()
+		err := db.Table(common.GetInstTableName(endpoint.objID)).Find(cond.ToMapStr()).One(ctx, &inst)
+		if db.IsNotFoundError(err) {
+			// the instance is checked by the caller
+			continue
+		}
+		if nil != err {
+			blog.Errorf("request(%s): failed to search the instance by the condition(%#v), error info is %s", ctx.ReqID, cond.ToMapStr(), err.Error())
+			return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+		}
+		if err := checkInstUnique(ctx, db, endpoint, inst, changes); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkInstUnique checks the uniques with association keys of the instance, the changed instance associations
+// of the instances are applied before the check.
+func checkInstUnique(ctx core.ContextParams, db dal.RDB, endpoint instEndpoint, inst mapstr.MapStr, changes map[instEndpoint]instAsstChange) errors.CCError {
+	cond := mongo.NewCondition()
+	cond.Element(
+		&mongo.Eq{Key: common.BKObjIDField, Val: endpoint.objID},
+		&mongo.Eq{Key: "keys.key_kind", Val: metadata.UniqueKeyKindAssociation})
+	uniques := make([]metadata.ObjectUnique, 0)
+	if err := db.Table(common.BKTableNameObjUnique).Find(cond.ToMapStr()).All(ctx, &uniques); nil != err {
+		blog.Errorf("request(%s): failed to search the uniques of the object(%s), error info is %s", ctx.ReqID, endpoint.objID, err.Error())
+		return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	for _, unique := range uniques {
+		if !unique.HasAssociationKey() {
+			continue
+		}
+		if err := checkOneInstUnique(ctx, db, unique, endpoint, inst, changes); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOneInstUnique checks whether there is another instance with the same property values, which is associated
+// with the same instances by every association key of the unique. the unique is skipped if the instance is not
+// associated by any of the association keys, because it can not be compared within the associated instances.
+func checkOneInstUnique(ctx core.ContextParams, db dal.RDB, unique metadata.ObjectUnique, endpoint instEndpoint, inst mapstr.MapStr, changes map[instEndpoint]instAsstChange) errors.CCError {
+	propertyIDs := make([]uint64, 0)
+	asstIDs := make([]uint64, 0)
+	for _, key := range unique.Keys {
+		switch key.Kind {
+		case metadata.UniqueKeyKindProperty:
+			propertyIDs = append(propertyIDs, key.ID)
+		case metadata.UniqueKeyKindAssociation:
+			asstIDs = append(asstIDs, key.ID)
+		default:
+			blog.Errorf("request(%s): the unique(%d) of the object(%s) has an invalid key kind(%s)", ctx.ReqID, unique.ID, endpoint.objID, key.Kind)
+			return ctx.Error.Errorf(common.CCErrTopoObjectUniqueKeyKindInvalid, key.Kind)
+		}
+	}
+
+	attrs := make([]metadata.Attribute, 0)
+	if 0 != len(propertyIDs) {
+		cond := mongo.NewCondition()
+		cond.Element(&mongo.In{Key: common.BKFieldID, Val: propertyIDs})
+		if err := db.Table(common.BKTableNameObjAttDes).Find(cond.ToMapStr()).All(ctx, &attrs); nil != err {
+			blog.Errorf("request(%s): failed to search the properties of the unique(%d), error info is %s", ctx.ReqID, unique.ID, err.Error())
+			return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+		}
+		if len(attrs) != len(propertyIDs) {
+			blog.Errorf("request(%s): some properties(%v) of the unique(%d) are not found", ctx.ReqID, propertyIDs, unique.ID)
+			return ctx.Error.Errorf(common.CCErrTopoObjectPropertyNotFound, unique.ID)
+		}
+	}
+
+	objAssts := make([]metadata.Association, 0)
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.In{Key: common.BKFieldID, Val: asstIDs})
+	if err := db.Table(common.BKTableNameObjAsst).Find(cond.ToMapStr()).All(ctx, &objAssts); nil != err {
+		blog.Errorf("request(%s): failed to search the associations of the unique(%d), error info is %s", ctx.ReqID, unique.ID, err.Error())
+		return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+	if len(objAssts) != len(asstIDs) {
+		blog.Errorf("request(%s): some associations(%v) of the unique(%d) are not found", ctx.ReqID, asstIDs, unique.ID)
+		return ctx.Error.Error(common.CCErrorTopoObjectAssociationNotExist)
+	}
+
+	associated := make([][]int64, len(objAssts))
+	for idx, objAsst := range objAssts {
+		ids, err := searchAssociatedInstIDs(ctx, db, objAsst, endpoint.objID, endpoint.instID)
+		if nil != err {
+			return err
+		}
+		ids = changes[endpoint].apply(objAsst.AssociationName, ids)
+		if 0 == len(ids) {
+			return nil
+		}
+		associated[idx] = ids
+	}
+
+	instCond := mongo.NewCondition()
+	for _, attr := range attrs {
+		val, ok := inst[attr.PropertyID]
+		if (!ok || nil == val || "" == val) && !unique.MustCheck {
+			return nil
+		}
+		instCond.Element(&mongo.Eq{Key: attr.PropertyID, Val: val})
+	}
+
+	// the instances to be compared must be associated with the same instance by the first association key
+	candidates, err := searchAssociatingInstIDs(ctx, db, objAssts[0], endpoint.objID, associated[0][0])
+	if nil != err {
+		return err
+	}
+	candidateIDs := make([]int64, 0, len(candidates))
+	for _, id := range candidates {
+		if id != endpoint.instID {
+			candidateIDs = append(candidateIDs, id)
+		}
+	}
+	if 0 == len(candidateIDs) {
+		return nil
+	}
+
+	instIDField := common.GetInstIDField(endpoint.objID)
+	instCond.Element(
+		&mongo.In{Key: instIDField, Val: candidateIDs},
+		&mongo.Neq{Key: common.BKDataStatusField, Val: common.DataStatusDisabled})
+	if common.GetInstTableName(endpoint.objID) == common.BKTableNameBaseInst {
+		instCond.Element(&mongo.Eq{Key: common.BKObjIDField, Val: endpoint.objID})
+	}
+	insts := make([]mapstr.MapStr, 0)
+	if err := db.Table(common.GetInstTableName(endpoint.objID)).Find(instCond.ToMapStr()).Fields(instIDField).All(ctx, &insts); nil != err {
+		blog.Errorf("request(%s): failed to search the instances by the condition(%#v), error info is %s", ctx.ReqID, instCond.ToMapStr(), err.Error())
+		return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	for _, item := range insts {
+		instID, err := util.GetInt64ByInterface(item[instIDField])
+		if nil != err {
+			blog.Errorf("request(%s): the instance(%#v) has an invalid id, error info is %s", ctx.ReqID, item, err.Error())
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, instIDField)
+		}
+		candidate := instEndpoint{objID: endpoint.objID, instID: instID}
+		same := true
+		for idx, objAsst := range objAssts {
+			ids, err := searchAssociatedInstIDs(ctx, db, objAsst, candidate.objID, candidate.instID)
+			if nil != err {
+				return err
+			}
+			if !metadata.SameInstIDs(changes[candidate].apply(objAsst.AssociationName, ids), associated[idx]) {
+				same = false
+				break
+			}
+		}
+		if !same {
+			continue
+		}
+
+		blog.Errorf("request(%s): the instance(%s:%d) conflicts with the instance(%d) on the unique(%d)", ctx.ReqID, endpoint.objID, endpoint.instID, instID, unique.ID)
+		keyNames := make([]string, 0, len(unique.Keys))
+		for _, attr := range attrs {
+			keyNames = append(keyNames, util.FirstNotEmptyString(ctx.Lang.Language(endpoint.objID+"_property_"+attr.PropertyID), attr.PropertyName, attr.PropertyID))
+		}
+		for _, objAsst := range objAssts {
+			keyNames = append(keyNames, util.FirstNotEmptyString(objAsst.AssociationAliasName, objAsst.AssociationName))
+		}
+		return ctx.Error.Errorf(common.CCErrorTopoInstUniqueConflict, strings.Join(keyNames, " + "), strconv.FormatInt(instID, 10))
+	}
+	return nil
+}
+
+// searchAssociatedInstIDs returns the ids of the instances associated with the instance by the model association
+func searchAssociatedInstIDs(ctx core.ContextParams, db dal.RDB, objAsst metadata.Association, objID string, instID int64) ([]int64, errors.CCError) {
+	instField, asstInstField := common.BKAsstInstIDField, common.BKInstIDField
+	if objAsst.ObjectID == objID {
+		instField, asstInstField = common.BKInstIDField, common.BKAsstInstIDField
+	}
+	return searchInstAsstIDs(ctx, db, objAsst.AssociationName, instField, instID, asstInstField)
+}
+
+// searchAssociatingInstIDs returns the ids of the instances of the object, which are associated with the
+// instance on the other side of the model association
+func searchAssociatingInstIDs(ctx core.ContextParams, db dal.RDB, objAsst metadata.Association, objID string, asstInstID int64) ([]int64, errors.CCError) {
+	instField, asstInstField := common.BKInstIDField, common.BKAsstInstIDField
+	if objAsst.ObjectID == objID {
+		instField, asstInstField = common.BKAsstInstIDField, common.BKInstIDField
+	}
+	return searchInstAsstIDs(ctx, db, objAsst.AssociationName, instField, asstInstID, asstInstField)
+}
+
+// searchInstAsstIDs returns the resultField of the instance associations, whose instField is instID
+func searchInstAsstIDs(ctx core.ContextParams, db dal.RDB, objAsstID, instField string, instID int64, resultField string) ([]int64, errors.CCError) {
+	cond := mongo.NewCondition()
+	cond.Element(
+		&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: objAsstID},
+		&mongo.Eq{Key: instField, Val: instID})
+	assts := make([]metadata.InstAsst, 0)
+	if err := db.Table(common.BKTableNameInstAsst).Find(cond.ToMapStr()).All(ctx, &assts); nil != err {
+		blog.Errorf("request(%s): failed to search the instance associations by the condition(%#v), error info is %s", ctx.ReqID, cond.ToMapStr(), err.Error())
+		return nil, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+	ids := make([]int64, 0, len(assts))
+	for _, asst := range assts {
+		if resultField == common.BKInstIDField {
+			ids = append(ids, asst.InstID)
+		} else {
+			ids = append(ids, asst.AsstInstID)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,116 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association_test
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/association"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newRackPositionUnique prepares the servers in the racks, the position of a server is unique within a rack,
+// which is the unique of the position property and the association to the rack
+func newRackPositionUnique(t *testing.T) (dal.RDB, core.AssociationOperation, string) {
+	db := memory.New()
+	asstMgr := association.New(db, &mockDependences{})
+	objAsstID := createMappingAsst(t, asstMgr, "bk_server", "bk_rack", metadata.ManyToManyMapping)
+	objAsst := metadata.Association{}
+	require.NoError(t, db.Table(common.BKTableNameObjAsst).Find(mapstr.MapStr{common.AssociationObjAsstIDField: objAsstID}).One(defaultCtx, &objAsst))
+
+	require.NoError(t, db.Table(common.BKTableNameObjAttDes).Insert(defaultCtx, mapstr.MapStr{
+		common.BKFieldID: 1, common.BKObjIDField: "bk_server", common.BKPropertyIDField: "position", common.BKPropertyNameField: "position",
+	}))
+	require.NoError(t, db.Table(common.BKTableNameObjUnique).Insert(defaultCtx, mapstr.MapStr{
+		"id": 1, common.BKObjIDField: "bk_server", "must_check": false, "keys": []metadata.UniqueKey{
+			{Kind: metadata.UniqueKeyKindProperty, ID: 1},
+			{Kind: metadata.UniqueKeyKindAssociation, ID: uint64(objAsst.ID)},
+		},
+	}))
+	servers := make([]mapstr.MapStr, 0)
+	for instID, position := range map[int64]string{1: "u1", 2: "u1", 3: "u2", 4: "u5", 5: "u5"} {
+		servers = append(servers, mapstr.MapStr{common.BKInstIDField: instID, common.BKObjIDField: "bk_server", "position": position})
+	}
+	require.NoError(t, db.Table(common.BKTableNameBaseInst).Insert(defaultCtx, servers))
+	return db, asstMgr, objAsstID
+}
+
+func TestCheckInstAsstUnique(t *testing.T) {
+	db, asstMgr, objAsstID := newRackPositionUnique(t)
+	create := func(serverID, rackID int64) error {
+		_, err := asstMgr.CreateOneInstanceAssociation(defaultCtx, metadata.CreateOneInstanceAssociation{
+			Data: newMappingInstAsst(objAsstID, "bk_server", "bk_rack", serverID, rackID)})
+		return err
+	}
+	remove := func(serverID, rackID int64) error {
+		_, err := asstMgr.DeleteInstanceAssociation(defaultCtx, metadata.DeleteOption{Condition: mapstr.MapStr{
+			common.AssociationObjAsstIDField: objAsstID, common.BKInstIDField: serverID, common.BKAsstInstIDField: rackID}})
+		return err
+	}
+
+	// the servers in the same rack can not be at the same position
+	require.NoError(t, create(1, 10))
+	requireErrCode(t, common.CCErrorTopoInstUniqueConflict, create(2, 10))
+	require.NoError(t, create(3, 10))
+	require.NoError(t, create(2, 11))
+
+	// the associated racks are compared as a whole
+	require.NoError(t, create(4, 10))
+	require.NoError(t, create(4, 11))
+	require.NoError(t, create(5, 10))
+	requireErrCode(t, common.CCErrorTopoInstUniqueConflict, remove(4, 11))
+	require.NoError(t, remove(5, 10))
+	require.NoError(t, remove(4, 11))
+
+	// the instance associations of the batch are applied together, server 4 is in rack 10 now
+	err := association.CheckInstAsstUnique(defaultCtx, db, []metadata.InstAsst{
+		newMappingInstAsst(objAsstID, "bk_server", "bk_rack", 5, 13),
+		newMappingInstAsst(objAsstID, "bk_server", "bk_rack", 4, 13),
+	}, false)
+	require.NoError(t, err)
+	err = association.CheckInstAsstUnique(defaultCtx, db, []metadata.InstAsst{
+		newMappingInstAsst(objAsstID, "bk_server", "bk_rack", 5, 10),
+		newMappingInstAsst(objAsstID, "bk_server", "bk_rack", 5, 13),
+		newMappingInstAsst(objAsstID, "bk_server", "bk_rack", 4, 13),
+	}, false)
+	requireErrCode(t, common.CCErrorTopoInstUniqueConflict, err)
+}
+
+func TestCheckInstUnique(t *testing.T) {
+	db, asstMgr, objAsstID := newRackPositionUnique(t)
+	for _, asst := range [][2]int64{{1, 10}, {3, 10}, {2, 11}} {
+		_, err := asstMgr.CreateOneInstanceAssociation(defaultCtx, metadata.CreateOneInstanceAssociation{
+			Data: newMappingInstAsst(objAsstID, "bk_server", "bk_rack", asst[0], asst[1])})
+		require.NoError(t, err)
+	}
+
+	// the server can not be moved to the position taken in the rack
+	err := association.CheckInstUnique(defaultCtx, db, "bk_server", 3, mapstr.MapStr{"position": "u1"})
+	requireErrCode(t, common.CCErrorTopoInstUniqueConflict, err)
+	require.NoError(t, association.CheckInstUnique(defaultCtx, db, "bk_server", 3, mapstr.MapStr{"position": "u3"}))
+	// the instance itself is not a conflict
+	require.NoError(t, association.CheckInstUnique(defaultCtx, db, "bk_server", 1, mapstr.MapStr{"position": "u1"}))
+	// the positions in other racks are free
+	require.NoError(t, association.CheckInstUnique(defaultCtx, db, "bk_server", 2, mapstr.MapStr{"position": "u2"}))
+	// the empty value is allowed as the unique is not must check
+	require.NoError(t, association.CheckInstUnique(defaultCtx, db, "bk_server", 3, mapstr.MapStr{"position": ""}))
+	// the server not in any rack is not checked
+	require.NoError(t, association.CheckInstUnique(defaultCtx, db, "bk_server", 4, mapstr.MapStr{"position": "u1"}))
+}
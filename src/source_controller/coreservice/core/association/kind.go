@@ -29,6 +29,11 @@ type associationKind struct {
 }
 
 func (m *associationKind) CreateAssociationKind(ctx core.ContextParams, inputParam metadata.CreateAssociationKind) (*metadata.CreateOneDataResult, error) {
+	if 0 == len(inputParam.Data.AssociationKindID) {
+		blog.Errorf("request(%s): it is failed to create a new association kind, because of the %s is not set", ctx.ReqID, common.AssociationKindIDField)
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, common.AssociationKindIDField)
+	}
+
 	_, exists, err := m.isExists(ctx, inputParam.Data.AssociationKindID)
 	if nil != err {
 		blog.Errorf("check association kind is exist error (%#v)", err)
@@ -47,7 +47,7 @@ func TestCreateAssociationKind(t *testing.T) {
 	require.NotNil(t, err)
 
 	//create a new association kind with dup kind Name
-	inputParams.Data.AssociationKindName = xid.New().String()
+	inputParams.Data.AssociationKindID = xid.New().String()
 	dataResult, err = asstMgr.CreateAssociationKind(defaultCtx, inputParams)
 	require.Nil(t, err)
 }
@@ -139,7 +139,7 @@ func TestUpdateAssociationKind(t *testing.T) {
 
 	//create association kind without ID
 	asstKind := metadata.CreateAssociationKind{}
-	updateKind := metadata.UpdateOption{}
+	updateKind := metadata.UpdateOption{Condition: mapstr.New(), Data: mapstr.New()}
 	kindID := xid.New().String()
 
 	asstKind.Data.AssociationKindID = kindID
@@ -159,7 +159,7 @@ func TestUpdateAssociationKind(t *testing.T) {
 	// update the created association kind
 	updateResult, err := asstMgr.UpdateAssociationKind(defaultCtx, updateKind)
 	require.Nil(t, err)
-	require.Equal(t, 1, updateResult.Count)
+	require.Equal(t, uint64(1), updateResult.Count)
 
 }
 
@@ -183,7 +183,7 @@ func TestSearchAssociationKind(t *testing.T) {
 
 	//search association kind
 	searchCond := metadata.QueryCondition{}
-	searchCond.Condition = mapstr.MapStr{common.AssociationKindIDField: asstKind}
+	searchCond.Condition = mapstr.MapStr{common.AssociationKindIDField: kindID}
 	searchResult, err := asstMgr.SearchAssociationKind(defaultCtx, searchCond)
 	require.Nil(t, err)
 	require.NotEqual(t, 0, searchResult.Count)
@@ -210,7 +210,7 @@ func TestDeleteassociationKind(t *testing.T) {
 
 	//delete the created association kind by condition
 	searchCond := metadata.DeleteOption{}
-	searchCond.Condition = mapstr.MapStr{common.AssociationKindIDField: asstKind}
+	searchCond.Condition = mapstr.MapStr{common.AssociationKindIDField: kindID}
 	searchResult, err := asstMgr.DeleteAssociationKind(defaultCtx, searchCond)
 	require.Nil(t, err)
 	require.NotEqual(t, 0, searchResult.Count)
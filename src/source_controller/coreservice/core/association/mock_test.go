@@ -15,8 +15,8 @@ package association_test
 import (
 	"context"
 	"testing"
-	"time"
 
+	"configcenter/src/common"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
 	"configcenter/src/common/metadata"
@@ -24,10 +24,7 @@ import (
 	"configcenter/src/source_controller/coreservice/core/association"
 	"configcenter/src/source_controller/coreservice/core/instances"
 	"configcenter/src/source_controller/coreservice/core/model"
-	"configcenter/src/storage/dal/mongo"
-	"configcenter/src/storage/dal/mongo/local"
-
-	"github.com/stretchr/testify/require"
+	"configcenter/src/storage/dal/memory"
 )
 
 type instDependences struct {
@@ -43,9 +40,32 @@ func (s *instDependences) DeleteInstAsst(ctx core.ContextParams, objID string, i
 	return nil
 }
 
+// SearchInstAsst used to search the inst asst which the inst is the source or the destination of
+func (s *instDependences) SearchInstAsst(ctx core.ContextParams, objID string, instID uint64) (asst []metadata.InstAsst, err error) {
+	return nil, nil
+}
+
+// SearchModelAsst used to search the model asst by the bk_obj_asst_id, nil if not exist
+func (s *instDependences) SearchModelAsst(ctx core.ContextParams, objAsstID string) (asst *metadata.Association, err error) {
+	return nil, nil
+}
+
+// IsMainlineObject used to check if the model is a node of the mainline topology
+func (s *instDependences) IsMainlineObject(ctx core.ContextParams, objID string) (bool, error) {
+	return false, nil
+}
+
+// ArchiveDeleted archive the deleted instances and instance associations into the recycle bin
+func (s *instDependences) ArchiveDeleted(ctx core.ContextParams, archives []metadata.DelArchive) error {
+	return nil
+}
+
 // SelectObjectAttWithParams select object att with params
 func (s *instDependences) SelectObjectAttWithParams(ctx core.ContextParams, objID string) (attribute []metadata.Attribute, err error) {
-	return nil, nil
+	return []metadata.Attribute{
+		{ID: 1, ObjectID: objID, PropertyID: common.BKInstNameField, PropertyType: common.FieldTypeSingleChar, IsEditable: true, IsRequired: true},
+		{ID: 2, ObjectID: objID, PropertyID: common.BKAssetIDField, PropertyType: common.FieldTypeSingleChar, IsEditable: true},
+	}, nil
 }
 
 // SearchUnique search unique attribute
@@ -76,27 +96,28 @@ func (s *mockDependences) CascadeDeleteInstances(ctx core.ContextParams, objIDS
 }
 
 func (m *mockDependences) IsInstanceExist(ctx core.ContextParams, objID string, instID uint64) (exists bool, err error) {
-	return false, nil
+	return true, nil
+}
+
+func (m *mockDependences) ArchiveDeleted(ctx core.ContextParams, archives []metadata.DelArchive) error {
+	return nil
 }
 
 func newModel(t *testing.T) core.ModelOperation {
 
-	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
-	require.NoError(t, err)
+	db := memory.New()
 	return model.New(db, &mockDependences{})
 }
 
 func newAssociation(t *testing.T) core.AssociationOperation {
 
-	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
-	require.NoError(t, err)
+	db := memory.New()
 	return association.New(db, &mockDependences{})
 }
 
 func newInstances(t *testing.T) core.InstanceOperation {
 
-	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
-	require.NoError(t, err)
+	db := memory.New()
 	return instances.New(db, &instDependences{})
 }
 
@@ -81,9 +81,17 @@ type InstanceOperation interface {
 	CreateModelInstance(ctx ContextParams, objID string, inputParam metadata.CreateModelInstance) (*metadata.CreateOneDataResult, error)
 	CreateManyModelInstance(ctx ContextParams, objID string, inputParam metadata.CreateManyModelInstance) (*metadata.CreateManyDataResult, error)
 	UpdateModelInstance(ctx ContextParams, objID string, inputParam metadata.UpdateOption) (*metadata.UpdatedCount, error)
+	UpdateManyModelInstance(ctx ContextParams, objID string, inputParam metadata.UpdateManyModelInstance) (*metadata.UpdateManyDataResult, error)
+	UpsertModelInstance(ctx ContextParams, objID string, inputParam metadata.UpsertModelInstance) (*metadata.SetDataResult, error)
 	SearchModelInstance(ctx ContextParams, objID string, inputParam metadata.QueryCondition) (*metadata.QueryResult, error)
 	DeleteModelInstance(ctx ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
 	CascadeDeleteModelInstance(ctx ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
+
+	SearchDelArchive(ctx ContextParams, inputParam metadata.QueryCondition) (*metadata.DelArchiveQueryResult, error)
+	RestoreDelArchive(ctx ContextParams, id int64) (*metadata.RestoredDelArchive, error)
+	PurgeDelArchive(ctx ContextParams, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
+	SearchInstHistory(ctx ContextParams, objID string, instID int64, inputParam metadata.QueryCondition) (*metadata.InstHistoryQueryResult, error)
+	SearchModelInstanceAsOf(ctx ContextParams, objID string, inputParam metadata.AsOfQueryCondition) (*metadata.QueryResult, error)
 }
 
 // AssociationKind association kind methods
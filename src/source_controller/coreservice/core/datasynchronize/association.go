@@ -17,8 +17,11 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/history"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
+	coreasst "configcenter/src/source_controller/coreservice/core/association"
 	"configcenter/src/storage/dal"
 )
 
@@ -47,6 +50,8 @@ func (a *association) SaveSynchronize(ctx core.ContextParams) errors.CCError {
 	switch a.base.syncData.DataClassify {
 	case common.SynchronizeAssociationTypeModelHost:
 		return a.saveSynchronizeAssociationModuleHostConfig(ctx)
+	case common.SynchronizeAssociationTypeInstAsst:
+		return a.saveSynchronizeAssociationInstAsst(ctx)
 	default:
 		return ctx.Error.Errorf(common.CCErrCoreServiceSyncDataClassifyNotExistError, a.dataType, a.DataClassify)
 	}
@@ -87,6 +92,7 @@ func (a *association) GetErrorStringArr(ctx core.ContextParams) ([]metadata.Exce
 // Host and module relationship is special, need special implementation
 func (a *association) saveSynchronizeAssociationModuleHostConfig(ctx core.ContextParams) errors.CCError {
 	tableName := common.BKTableNameModuleHostConfig
+	added := make([]map[string]interface{}, 0)
 	for _, item := range a.base.syncData.InfoArray {
 
 		//  branch clone not support deep copy
@@ -113,6 +119,7 @@ func (a *association) saveSynchronizeAssociationModuleHostConfig(ctx core.Contex
 				}
 				continue
 			}
+			added = append(added, item.Info)
 		} else {
 			err := a.dbProxy.Table(tableName).Update(ctx, newItem, item.Info)
 			if err != nil {
@@ -125,6 +132,64 @@ func (a *association) saveSynchronizeAssociationModuleHostConfig(ctx core.Contex
 			}
 		}
 	}
+
+	op := history.Operation{RequestID: ctx.ReqID, Operator: ctx.User, OwnerID: ctx.SupplierAccount}
+	err := history.RecordModuleHost(ctx, a.dbProxy, op, metadata.ModuleHostHistoryActionAdd, added)
+	if nil != history.Check(ctx, op, err) {
+		return ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	return nil
+}
+
+// saveSynchronizeAssociationInstAsst
+// the instance associations should keep the mapping of the model association,
+// each one is checked after the ones before it are saved, and checked again after
+// it's saved, as the instance associations may be created at the same time.
+func (a *association) saveSynchronizeAssociationInstAsst(ctx core.ContextParams) errors.CCError {
+	var dbParam synchronizeAdapterDBParameter
+	dbParam.tableName = common.BKTableNameInstAsst
+	dbParam.InstIDField = common.BKFieldID
+	if a.base.syncData.OperateType == metadata.SynchronizeOperateTypeDelete {
+		a.base.saveSynchronize(ctx, dbParam)
+		return nil
+	}
+
+	for _, item := range a.base.syncData.InfoArray {
+		if _, ok := a.base.errorArray[item.ID]; ok {
+			continue
+		}
+		asst := metadata.InstAsst{}
+		if err := mapstr.SetValueToStructByTags(&asst, item.Info); err != nil {
+			blog.Errorf("saveSynchronizeAssociationInstAsst parse data error,err:%s.DataSign:%s,info:%#v,rid:%s", err.Error(), a.DataClassify, item, ctx.ReqID)
+			a.base.errorArray[item.ID] = synchronizeAdapterError{
+				instInfo: item,
+				err:      ctx.Error.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID),
+			}
+			continue
+		}
+		asst.ID = item.ID
+		if err := coreasst.CheckInstAsstMapping(ctx, a.dbProxy, asst); err != nil {
+			a.base.errorArray[item.ID] = synchronizeAdapterError{
+				instInfo: item,
+				err:      err,
+			}
+			continue
+		}
+		a.base.replaceSynchronizeItem(ctx, dbParam, item)
+		if _, ok := a.base.errorArray[item.ID]; ok {
+			continue
+		}
+		if err := coreasst.RecheckInstAsstMapping(ctx, a.dbProxy, asst); err != nil {
+			cond := mapstr.MapStr{common.BKFieldID: item.ID}
+			if delErr := a.dbProxy.Table(dbParam.tableName).Delete(ctx, cond); delErr != nil {
+				blog.Errorf("saveSynchronizeAssociationInstAsst remove data error,err:%s.DataSign:%s,condition:%#v,rid:%s", delErr.Error(), a.DataClassify, cond, ctx.ReqID)
+			}
+			a.base.errorArray[item.ID] = synchronizeAdapterError{
+				instInfo: item,
+				err:      err,
+			}
+		}
+	}
 	return nil
 }
 
@@ -17,11 +17,16 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/condition"
+	"configcenter/src/common/history"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/storage/dal"
 )
 
+// clearDataBatchSize the count of the module host relations deleted at once
+const clearDataBatchSize = 1000
+
 type clearDataInterface interface {
 	clearData(ctx core.ContextParams)
 }
@@ -64,10 +69,47 @@ func (c *clearData) clearData(ctx core.ContextParams) {
 			continue
 		}
 
+		// the module host relations deleted are recorded in the history
+		if tableName == common.BKTableNameModuleHostConfig {
+			c.clearModuleHostConfig(ctx, deleteConditon)
+			continue
+		}
+
 		err = c.dbProxy.Table(tableName).Delete(ctx, deleteConditon)
 		if err != nil {
 			blog.Errorf("clearData  delete %s table row error, err:%s,rid:%s", tableName, err.Error(), ctx.ReqID)
+			continue
+		}
+	}
+}
+
+// clearModuleHostConfig delete the module host relations batch by batch,
+// and record the history of each batch after it's deleted
+func (c *clearData) clearModuleHostConfig(ctx core.ContextParams, deleteConditon mapstr.MapStr) {
+	tableName := common.BKTableNameModuleHostConfig
+	op := history.Operation{RequestID: ctx.ReqID, Operator: ctx.User, OwnerID: ctx.SupplierAccount}
+	relations := make([]map[string]interface{}, 0)
+	err := dal.IterateBatch(ctx, c.dbProxy.Table(tableName).Find(deleteConditon), clearDataBatchSize, &relations, func() error {
+		relationConds := make([]mapstr.MapStr, 0, len(relations))
+		for _, relation := range relations {
+			relationConds = append(relationConds, mapstr.MapStr{
+				common.BKHostIDField:   relation[common.BKHostIDField],
+				common.BKModuleIDField: relation[common.BKModuleIDField],
+			})
+		}
+		cond := mapstr.MapStr{common.BKDBAND: []mapstr.MapStr{deleteConditon, {common.BKDBOR: relationConds}}}
+		if err := c.dbProxy.Table(tableName).Delete(ctx, cond); err != nil {
+			blog.Errorf("clearData  delete %s table row error, err:%s,rid:%s", tableName, err.Error(), ctx.ReqID)
+			return err
+		}
+		err := history.RecordModuleHost(ctx, c.dbProxy, op, metadata.ModuleHostHistoryActionDelete, relations)
+		if err != nil {
+			blog.Errorf("clearData  record the history of the deleted %s table row error, err:%s,rid:%s", tableName, err.Error(), ctx.ReqID)
 		}
+		return nil
+	})
+	if err != nil {
+		blog.Errorf("clearData  find %s table deleted row error, err:%s,rid:%s", tableName, err.Error(), ctx.ReqID)
 	}
 }
 
@@ -59,27 +59,28 @@ func (a *associationFindData) Find(ctx core.ContextParams) ([]mapstr.MapStr, uin
 func (a *associationFindData) findModel(ctx core.ContextParams) ([]mapstr.MapStr, uint64, errors.CCError) {
 	switch a.dataClassify {
 	case common.SynchronizeModelTypeBase:
-		return a.dbQueryModel(ctx, common.BKTableNameBaseModule)
+		return a.dbQueryModel(ctx, common.BKTableNameBaseModule, common.BKModuleIDField)
 	case common.SynchronizeModelTypeModelClassificationRelation:
-		return a.dbQueryModel(ctx, common.BKTableNameObjDes)
+		return a.dbQueryModel(ctx, common.BKTableNameObjDes, common.BKFieldID)
 	case common.SynchronizeModelTypeAttribute:
-		return a.dbQueryModel(ctx, common.BKTableNameObjAttDes)
+		return a.dbQueryModel(ctx, common.BKTableNameObjAttDes, common.BKFieldID)
 	case common.SynchronizeModelTypeAttributeGroup:
-		return a.dbQueryModel(ctx, common.BKTableNamePropertyGroup)
+		return a.dbQueryModel(ctx, common.BKTableNamePropertyGroup, common.BKFieldID)
 	case common.SynchronizeModelTypeClassification:
-		return a.dbQueryModel(ctx, common.BKTableNameObjClassifiction)
+		return a.dbQueryModel(ctx, common.BKTableNameObjClassifiction, common.BKFieldID)
 	}
 	return nil, 0, nil
 }
 
-func (a *associationFindData) dbQueryModel(ctx core.ContextParams, tableName string) ([]mapstr.MapStr, uint64, errors.CCError) {
+func (a *associationFindData) dbQueryModel(ctx core.ContextParams, tableName, sort string) ([]mapstr.MapStr, uint64, errors.CCError) {
 	info := make([]mapstr.MapStr, 0)
-	err := a.dbProxy.Table(tableName).Find(a.condition).Start(a.start).Limit(a.limit).All(ctx, &info)
+	// sorted, so that the pages are stable
+	err := a.dbProxy.Table(tableName).Find(a.condition).Sort(sort).Start(a.start).Limit(a.limit).All(ctx, &info)
 	if err != nil {
 		blog.Errorf("dbQueryModel info error. error:%s,rid:%s", err.Error(), ctx.ReqID)
 		return nil, 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
 	}
-	cnt, err := a.dbProxy.Table(tableName).Find(nil).Count(ctx)
+	cnt, err := a.dbProxy.Table(tableName).Find(a.condition).Count(ctx)
 	if err != nil {
 		blog.Errorf("dbQueryModel count error. error:%s,rid:%s", err.Error(), ctx.ReqID)
 		return nil, 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
@@ -90,19 +91,22 @@ func (a *associationFindData) dbQueryModel(ctx core.ContextParams, tableName str
 func (a *associationFindData) findAssociation(ctx core.ContextParams) ([]mapstr.MapStr, uint64, errors.CCError) {
 	switch a.dataClassify {
 	case common.SynchronizeAssociationTypeModelHost:
-		return a.dbQueryAssociation(ctx)
+		return a.dbQueryAssociation(ctx, common.BKTableNameModuleHostConfig, common.BKHostIDField+","+common.BKModuleIDField)
+	case common.SynchronizeAssociationTypeInstAsst:
+		return a.dbQueryAssociation(ctx, common.BKTableNameInstAsst, common.BKFieldID)
 	}
 	return nil, 0, nil
 }
 
-func (a *associationFindData) dbQueryAssociation(ctx core.ContextParams) ([]mapstr.MapStr, uint64, errors.CCError) {
+func (a *associationFindData) dbQueryAssociation(ctx core.ContextParams, tableName, sort string) ([]mapstr.MapStr, uint64, errors.CCError) {
 	info := make([]mapstr.MapStr, 0)
-	err := a.dbProxy.Table(common.BKTableNameModuleHostConfig).Find(a.condition).Start(a.start).Limit(a.limit).All(ctx, &info)
+	// sorted, so that the pages are stable
+	err := a.dbProxy.Table(tableName).Find(a.condition).Sort(sort).Start(a.start).Limit(a.limit).All(ctx, &info)
 	if err != nil {
 		blog.Errorf("dbQueryAssociation info error. error:%s,rid:%s", err.Error(), ctx.ReqID)
 		return nil, 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
 	}
-	cnt, err := a.dbProxy.Table(common.BKTableNameModuleHostConfig).Find(nil).Count(ctx)
+	cnt, err := a.dbProxy.Table(tableName).Find(a.condition).Count(ctx)
 	if err != nil {
 		blog.Errorf("dbQueryAssociation count error. error:%s,rid:%s", err.Error(), ctx.ReqID)
 		return nil, 0, ctx.Error.Error(common.CCErrCommDBSelectFailed)
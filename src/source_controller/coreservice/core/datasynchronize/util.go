@@ -130,35 +130,37 @@ func (s *synchronizeAdapter) replaceSynchronize(ctx core.ContextParams, dbParam
 		if ok {
 			continue
 		}
-		conds := mapstr.MapStr{dbParam.InstIDField: item.ID}
-		exist, err := s.existSynchronizeID(ctx, dbParam.tableName, conds)
+		s.replaceSynchronizeItem(ctx, dbParam, item)
+	}
+}
+
+func (s *synchronizeAdapter) replaceSynchronizeItem(ctx core.ContextParams, dbParam synchronizeAdapterDBParameter, item *metadata.SynchronizeItem) {
+	conds := mapstr.MapStr{dbParam.InstIDField: item.ID}
+	exist, err := s.existSynchronizeID(ctx, dbParam.tableName, conds)
+	if err != nil {
+		blog.Errorf("replaceSynchronize existSynchronizeID error.DataClassify:%s,info:%#v,rid:%s", s.syncData.DataClassify, item, ctx.ReqID)
+		s.errorArray[item.ID] = synchronizeAdapterError{
+			instInfo: item,
+			err:      err,
+		}
+		return
+	}
+	if exist {
+		err := s.dbProxy.Table(dbParam.tableName).Update(ctx, conds, item.Info)
 		if err != nil {
-			blog.Errorf("replaceSynchronize existSynchronizeID error.DataClassify:%s,info:%#v,rid:%s", s.syncData.DataClassify, item, ctx.ReqID)
+			blog.Errorf("replaceSynchronize update info error,err:%s.DataClassify:%s,condition:%#v,info:%#v,rid:%s", err.Error(), s.syncData.DataClassify, conds, item, ctx.ReqID)
 			s.errorArray[item.ID] = synchronizeAdapterError{
 				instInfo: item,
-				err:      err,
+				err:      ctx.Error.Error(common.CCErrCommDBUpdateFailed),
 			}
-			continue
 		}
-		if exist {
-			err := s.dbProxy.Table(dbParam.tableName).Update(ctx, conds, item.Info)
-			if err != nil {
-				blog.Errorf("replaceSynchronize update info error,err:%s.DataClassify:%s,condition:%#v,info:%#v,rid:%s", err.Error(), s.syncData.DataClassify, conds, item, ctx.ReqID)
-				s.errorArray[item.ID] = synchronizeAdapterError{
-					instInfo: item,
-					err:      ctx.Error.Error(common.CCErrCommDBUpdateFailed),
-				}
-				continue
-			}
-		} else {
-			err := s.dbProxy.Table(dbParam.tableName).Insert(ctx, item.Info)
-			if err != nil {
-				blog.Errorf("replaceSynchronize insert info error,err:%s.DataClassify:%s,info:%#v,rid:%s", err.Error(), s.syncData.DataClassify, item, ctx.ReqID)
-				s.errorArray[item.ID] = synchronizeAdapterError{
-					instInfo: item,
-					err:      ctx.Error.Error(common.CCErrCommDBInsertFailed),
-				}
-				continue
+	} else {
+		err := s.dbProxy.Table(dbParam.tableName).Insert(ctx, item.Info)
+		if err != nil {
+			blog.Errorf("replaceSynchronize insert info error,err:%s.DataClassify:%s,info:%#v,rid:%s", err.Error(), s.syncData.DataClassify, item, ctx.ReqID)
+			s.errorArray[item.ID] = synchronizeAdapterError{
+				instInfo: item,
+				err:      ctx.Error.Error(common.CCErrCommDBInsertFailed),
 			}
 		}
 	}
@@ -28,9 +28,21 @@ type OperationDependences interface {
 	// DeleteInstAsst used to delete inst asst
 	DeleteInstAsst(ctx core.ContextParams, objID string, instID uint64) error
 
+	// SearchInstAsst used to search the inst asst which the inst is the source or the destination of
+	SearchInstAsst(ctx core.ContextParams, objID string, instID uint64) (asst []metadata.InstAsst, err error)
+
+	// SearchModelAsst used to search the model asst by the bk_obj_asst_id, nil if not exist
+	SearchModelAsst(ctx core.ContextParams, objAsstID string) (asst *metadata.Association, err error)
+
+	// IsMainlineObject used to check if the model is a node of the mainline topology
+	IsMainlineObject(ctx core.ContextParams, objID string) (bool, error)
+
 	// SelectObjectAttWithParams select object att with params
 	SelectObjectAttWithParams(ctx core.ContextParams, objID string) (attribute []metadata.Attribute, err error)
 
 	// SearchUnique search unique attribute
 	SearchUnique(ctx core.ContextParams, objID string) (uniqueAttr []metadata.ObjectUnique, err error)
+
+	// ArchiveDeleted archive the deleted instances and instance associations into the recycle bin
+	ArchiveDeleted(ctx core.ContextParams, archives []metadata.DelArchive) error
 }
@@ -13,6 +13,8 @@
 package instances
 
 import (
+	"strconv"
+
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
@@ -22,6 +24,7 @@ import (
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/storage/dal"
+	"configcenter/src/storage/types"
 )
 
 var _ core.InstanceOperation = (*instanceManager)(nil)
@@ -58,7 +61,13 @@ func (m *instanceManager) CreateModelInstance(ctx core.ContextParams, objID stri
 }
 
 func (m *instanceManager) CreateManyModelInstance(ctx core.ContextParams, objID string, inputParam metadata.CreateManyModelInstance) (*metadata.CreateManyDataResult, error) {
+	batch, err := m.newBatchUnique(ctx, objID)
+	if nil != err {
+		return nil, err
+	}
 	dataResult := &metadata.CreateManyDataResult{}
+	models := make([]dal.BulkModel, 0, len(inputParam.Datas))
+	created := make([]metadata.CreatedDataResult, 0, len(inputParam.Datas))
 	for itemIdx, item := range inputParam.Datas {
 		item.Set(common.BKOwnerIDField, ctx.SupplierAccount)
 		err := m.validCreateInstanceData(ctx, objID, item)
@@ -71,8 +80,7 @@ func (m *instanceManager) CreateManyModelInstance(ctx core.ContextParams, objID
 			})
 			continue
 		}
-		item.Set(common.BKOwnerIDField, ctx.SupplierAccount)
-		id, err := m.save(ctx, objID, item)
+		conflict, err := batch.take(map[string]mapstr.MapStr{strconv.Itoa(itemIdx): item}, nil)
 		if nil != err {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
 				Message:     err.Error(),
@@ -82,23 +90,265 @@ func (m *instanceManager) CreateManyModelInstance(ctx core.ContextParams, objID
 			})
 			continue
 		}
+		if "" != conflict {
+			blog.Errorf("create many instance of %s, the unique (%s) of item %d is repeated in the batch, rid: %s", objID, conflict, itemIdx, ctx.ReqID)
+			dataResult.Repeated = append(dataResult.Repeated, metadata.RepeatedDataResult{OriginIndex: int64(itemIdx), Data: item})
+			continue
+		}
+		id, err := m.newInstanceData(ctx, objID, item)
+		if nil != err {
+			blog.Errorf("create many instance get the next sequence of %s error: %v, rid: %s", objID, err, ctx.ReqID)
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        common.CCErrCommDBSelectFailed,
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
+		models = append(models, dal.NewBulkInsert(item))
+		created = append(created, metadata.CreatedDataResult{OriginIndex: int64(itemIdx), ID: id})
+	}
+	if 0 == len(models) {
+		return dataResult, nil
+	}
 
-		dataResult.Created = append(dataResult.Created, metadata.CreatedDataResult{
-			ID: id,
+	// the valid instances are inserted in one unordered bulk, the failed rows are reported one by one
+	result, err := m.dbProxy.Table(common.GetInstTableName(objID)).BulkWrite(ctx, models, false)
+	if nil != err && dal.ErrBulkWrite != err {
+		blog.Errorf("create many instance of %s error: %v, rid: %s", objID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	failed := make(map[int]types.BulkWriteError, len(result.Errors))
+	for _, writeErr := range result.Errors {
+		failed[writeErr.Index] = writeErr
+	}
+	inserted := make([]mapstr.MapStr, 0, len(created))
+	for idx, item := range created {
+		writeErr, ok := failed[idx]
+		if !ok {
+			dataResult.Created = append(dataResult.Created, item)
+			inserted = append(inserted, inputParam.Datas[item.OriginIndex])
+			continue
+		}
+		code := common.CCErrCommDBInsertFailed
+		if writeErr.IsDuplicated() {
+			code = common.CCErrCommDuplicateItem
+		}
+		dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+			Message:     writeErr.Message,
+			Code:        int64(code),
+			Data:        inputParam.Datas[item.OriginIndex],
+			OriginIndex: item.OriginIndex,
 		})
-
+	}
+	if err := m.recordHistory(ctx, objID, metadata.InstHistoryActionCreate, inserted); nil != err {
+		return nil, err
 	}
 
 	return dataResult, nil
 }
 
 func (m *instanceManager) UpdateModelInstance(ctx core.ContextParams, objID string, inputParam metadata.UpdateOption) (*metadata.UpdatedCount, error) {
+	instIDs, _, err := m.validUpdateOption(ctx, objID, inputParam)
+	if nil != err {
+		return nil, err
+	}
+	cnt, err := m.update(ctx, objID, inputParam.Data, inputParam.Condition)
+	if nil != err {
+		return &metadata.UpdatedCount{Count: cnt}, err
+	}
+	return &metadata.UpdatedCount{Count: cnt}, m.recordUpdatedHistory(ctx, objID, instIDs)
+}
+
+func (m *instanceManager) UpdateManyModelInstance(ctx core.ContextParams, objID string, inputParam metadata.UpdateManyModelInstance) (*metadata.UpdateManyDataResult, error) {
+	batch, err := m.newBatchUnique(ctx, objID)
+	if nil != err {
+		return nil, err
+	}
+	dataResult := &metadata.UpdateManyDataResult{}
+	models := make([]dal.BulkModel, 0, len(inputParam.Updates))
+	indexes := make([]int, 0, len(inputParam.Updates))
+	updated := make([][]uint64, 0, len(inputParam.Updates))
+	for itemIdx, item := range inputParam.Updates {
+		if nil == item.Condition {
+			item.Condition = mapstr.New()
+		}
+		if nil == item.Data {
+			item.Data = mapstr.New()
+		}
+		instIDs, origins, err := m.validUpdateOption(ctx, objID, item)
+		if nil == err {
+			// the unique values the instances have after the update are taken in the batch
+			updates := make(map[string]mapstr.MapStr, len(origins))
+			for idx, origin := range origins {
+				for key, val := range item.Data {
+					origin[key] = val
+				}
+				updates[strconv.FormatUint(instIDs[idx], 10)] = origin
+			}
+			var conflict string
+			conflict, err = batch.take(updates, item.Data)
+			if nil == err && "" != conflict {
+				blog.Errorf("update many instance of %s, the unique (%s) of item %d is repeated in the batch, rid: %s", objID, conflict, itemIdx, ctx.ReqID)
+				err = ctx.Error.Errorf(common.CCErrCommDuplicateItem, conflict)
+			}
+		}
+		if nil != err {
+			code := int64(common.CCErrCommDBSelectFailed)
+			if ccErr, ok := err.(errors.CCErrorCoder); ok {
+				code = int64(ccErr.GetCode())
+			}
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        code,
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
+		if 0 == len(instIDs) {
+			continue
+		}
+		m.prepareUpdate(objID, item.Data, item.Condition)
+		models = append(models, dal.NewBulkUpdate(item.Condition, item.Data))
+		indexes = append(indexes, itemIdx)
+		updated = append(updated, instIDs)
+	}
+	if 0 == len(models) {
+		return dataResult, nil
+	}
+
+	result, err := m.dbProxy.Table(common.GetInstTableName(objID)).BulkWrite(ctx, models, false)
+	if nil != err && dal.ErrBulkWrite != err {
+		blog.Errorf("update many instance of %s error: %v, rid: %s", objID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+	}
+	failed := make(map[int]types.BulkWriteError, len(result.Errors))
+	for _, writeErr := range result.Errors {
+		failed[writeErr.Index] = writeErr
+	}
+	updatedIDs := make([]uint64, 0, len(updated))
+	for idx, itemIdx := range indexes {
+		writeErr, ok := failed[idx]
+		if !ok {
+			for _, instID := range updated[idx] {
+				dataResult.Updated = append(dataResult.Updated, metadata.UpdatedDataResult{OriginIndex: int64(itemIdx), ID: instID})
+			}
+			updatedIDs = append(updatedIDs, updated[idx]...)
+			dataResult.Count += uint64(len(updated[idx]))
+			continue
+		}
+		code := common.CCErrCommDBUpdateFailed
+		if writeErr.IsDuplicated() {
+			code = common.CCErrCommDuplicateItem
+		}
+		dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+			Message:     writeErr.Message,
+			Code:        int64(code),
+			Data:        inputParam.Updates[itemIdx],
+			OriginIndex: int64(itemIdx),
+		})
+	}
+	if err := m.recordUpdatedHistory(ctx, objID, updatedIDs); nil != err {
+		return nil, err
+	}
+
+	return dataResult, nil
+}
+
+// UpsertModelInstance update the instances matched by the condition, or create the instance with the data and
+// the equal conditions if none matches. The concurrent callers with the same condition may both create the
+// instance unless a unique index covers the condition fields, with which the loser of the race gets a duplicate
+// key error and updates the instance created by the winner instead.
+func (m *instanceManager) UpsertModelInstance(ctx core.ContextParams, objID string, inputParam metadata.UpsertModelInstance) (*metadata.SetDataResult, error) {
+	if 0 == len(inputParam.Condition) {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "condition")
+	}
+	if nil == inputParam.Data {
+		inputParam.Data = mapstr.New()
+	}
+	inputParam.Condition.Set(common.BKOwnerIDField, ctx.SupplierAccount)
+	origins, _, err := m.getInsts(ctx, objID, inputParam.Condition)
+	if nil != err {
+		blog.Errorf("upsert model instance get inst error: %v, rid: %s", err, ctx.ReqID)
+		return nil, err
+	}
+
+	if 0 == len(origins) {
+		item := mapstr.New()
+		for key, val := range inputParam.Condition {
+			switch val.(type) {
+			case map[string]interface{}, mapstr.MapStr:
+				// the operator conditions are not the values of the instance
+			default:
+				item[key] = val
+			}
+		}
+		// filled by newInstanceData
+		item.Remove(common.BKObjIDField)
+		for key, val := range inputParam.Data {
+			item[key] = val
+		}
+		if err := m.validCreateInstanceData(ctx, objID, item); nil != err {
+			blog.Errorf("upsert model instance valid error: %v, rid: %s", err, ctx.ReqID)
+			return nil, err
+		}
+		id, err := m.newInstanceData(ctx, objID, item)
+		if nil != err {
+			return nil, err
+		}
+
+		err = m.dbProxy.Table(common.GetInstTableName(objID)).Insert(ctx, item)
+		if nil == err {
+			if err := m.recordHistory(ctx, objID, metadata.InstHistoryActionCreate, []mapstr.MapStr{item}); nil != err {
+				return nil, err
+			}
+			return &metadata.SetDataResult{
+				CreatedCount: metadata.CreatedCount{Count: 1},
+				Created:      []metadata.CreatedDataResult{{ID: id}},
+			}, nil
+		}
+		if !m.dbProxy.IsDuplicatedError(err) {
+			blog.Errorf("upsert model instance of %s error: %v, rid: %s", objID, err, ctx.ReqID)
+			return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+		}
+		// the instance may be created by another caller in the meantime, update it instead
+		blog.V(3).Infof("upsert model instance of %s duplicated, update it instead, rid: %s", objID, ctx.ReqID)
+	}
+
+	updateOption := metadata.UpdateOption{Condition: inputParam.Condition, Data: inputParam.Data}
+	instIDs, _, err := m.validUpdateOption(ctx, objID, updateOption)
+	if nil != err {
+		return nil, err
+	}
+	if 0 == len(origins) && 0 == len(instIDs) {
+		// the created instance conflicts with another one which the condition doesn't match
+		return nil, ctx.Error.Error(common.CCErrCommDuplicateItem)
+	}
+	if _, err := m.update(ctx, objID, updateOption.Data, updateOption.Condition); nil != err {
+		blog.Errorf("upsert model instance update %s error: %v, rid: %s", objID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+	}
+	if err := m.recordUpdatedHistory(ctx, objID, instIDs); nil != err {
+		return nil, err
+	}
+	dataResult := &metadata.SetDataResult{UpdatedCount: metadata.UpdatedCount{Count: uint64(len(instIDs))}}
+	for _, instID := range instIDs {
+		dataResult.Updated = append(dataResult.Updated, metadata.UpdatedDataResult{ID: instID})
+	}
+	return dataResult, nil
+}
+
+// validUpdateOption validate the data of the update option against every instance it matches, returns the matched instance ids
+// and the matched instances
+func (m *instanceManager) validUpdateOption(ctx core.ContextParams, objID string, inputParam metadata.UpdateOption) ([]uint64, []mapstr.MapStr, error) {
 	instIDFieldName := common.GetInstIDField(objID)
 	inputParam.Condition.Set(common.BKOwnerIDField, ctx.SupplierAccount)
 	origins, _, err := m.getInsts(ctx, objID, inputParam.Condition)
 	if nil != err {
 		blog.Errorf("update module instance get inst error :%v ", err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	var instMedataData metadata.Metadata
@@ -113,22 +363,18 @@ func (m *instanceManager) UpdateModelInstance(ctx core.ContextParams, objID stri
 		}
 	}
 
+	instIDs := make([]uint64, 0, len(origins))
 	for _, origin := range origins {
 		instIDI := origin[instIDFieldName]
 		instID, _ := util.GetInt64ByInterface(instIDI)
 		err := m.validUpdateInstanceData(ctx, objID, inputParam.Data, instMedataData, uint64(instID))
 		if nil != err {
 			blog.Errorf("update module instance validate error :%v ", err)
-			return nil, err
+			return nil, nil, err
 		}
+		instIDs = append(instIDs, uint64(instID))
 	}
-
-	if nil != err {
-		blog.Errorf("update module instance validate error :%v ", err)
-		return &metadata.UpdatedCount{}, err
-	}
-	cnt, err := m.update(ctx, objID, inputParam.Data, inputParam.Condition)
-	return &metadata.UpdatedCount{Count: cnt}, err
+	return instIDs, origins, nil
 }
 
 func (m *instanceManager) SearchModelInstance(ctx core.ContextParams, objID string, inputParam metadata.QueryCondition) (*metadata.QueryResult, error) {
@@ -180,6 +426,12 @@ func (m *instanceManager) DeleteModelInstance(ctx core.ContextParams, objID stri
 			return &metadata.DeletedCount{}, ctx.Error.Error(common.CCErrorInstHasAsst)
 		}
 	}
+	if err := m.archiveInstances(ctx, objID, origins); nil != err {
+		return &metadata.DeletedCount{}, err
+	}
+	if err := m.recordHistory(ctx, objID, metadata.InstHistoryActionDelete, origins); nil != err {
+		return &metadata.DeletedCount{}, err
+	}
 	err = m.dbProxy.Table(tableName).Delete(ctx, inputParam.Condition)
 	if nil != err {
 		return &metadata.DeletedCount{}, err
@@ -191,21 +443,49 @@ func (m *instanceManager) CascadeDeleteModelInstance(ctx core.ContextParams, obj
 	tableName := common.GetInstTableName(objID)
 	instIDFieldName := common.GetInstIDField(objID)
 	origins, _, err := m.getInsts(ctx, objID, inputParam.Condition)
-	blog.Errorf("cascade delete model instance get inst error:%v", origins)
 	if nil != err {
 		blog.Errorf("cascade delete model instance get inst error:%v", err)
 		return &metadata.DeletedCount{}, err
 	}
 
+	roots := make([]metadata.InstDeleteNode, 0, len(origins))
 	for _, origin := range origins {
 		instID, err := util.GetInt64ByInterface(origin[instIDFieldName])
 		if nil != err {
 			return &metadata.DeletedCount{}, err
 		}
-		err = m.dependent.DeleteInstAsst(ctx, objID, uint64(instID))
+		roots = append(roots, metadata.InstDeleteNode{ObjectID: objID, InstID: instID})
+	}
+
+	// the instances bound by the associations with delete_src or delete_dest are deleted too,
+	// the other associations are just deleted with the instances.
+	plan, err := metadata.PlanInstDelete(roots, &instDeleteLookup{ctx: ctx, dependent: m.dependent})
+	if nil != err {
+		blog.Errorf("cascade delete model instance plan the deletion error:%v", err)
+		return &metadata.DeletedCount{}, err
+	}
+	if 0 != len(plan.Restricted) {
+		blog.Errorf("cascade delete model instance %s is restricted by the associations %#v, rid: %s", objID, plan.Restricted, ctx.ReqID)
+		return &metadata.DeletedCount{}, ctx.Error.Error(common.CCErrorInstHasAsst)
+	}
+
+	for _, node := range plan.Instances {
+		err = m.dependent.DeleteInstAsst(ctx, node.ObjectID, uint64(node.InstID))
 		if nil != err {
 			return &metadata.DeletedCount{}, err
 		}
+		if 0 == node.Depth {
+			continue
+		}
+		if err := m.cascadeDeleteInstance(ctx, node); nil != err {
+			return &metadata.DeletedCount{}, err
+		}
+	}
+	if err := m.archiveInstances(ctx, objID, origins); nil != err {
+		return &metadata.DeletedCount{}, err
+	}
+	if err := m.recordHistory(ctx, objID, metadata.InstHistoryActionDelete, origins); nil != err {
+		return &metadata.DeletedCount{}, err
 	}
 	inputParam.Condition.Set(common.BKOwnerIDField, ctx.SupplierAccount)
 	err = m.dbProxy.Table(tableName).Delete(ctx, inputParam.Condition)
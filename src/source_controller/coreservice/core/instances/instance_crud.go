@@ -24,7 +24,19 @@ import (
 )
 
 func (m *instanceManager) save(ctx core.ContextParams, objID string, inputParam mapstr.MapStr) (id uint64, err error) {
+	id, err = m.newInstanceData(ctx, objID, inputParam)
+	if nil != err {
+		return id, err
+	}
+	err = m.dbProxy.Table(common.GetInstTableName(objID)).Insert(ctx, inputParam)
+	if nil != err {
+		return id, err
+	}
+	return id, m.recordHistory(ctx, objID, metadata.InstHistoryActionCreate, []mapstr.MapStr{inputParam})
+}
 
+// newInstanceData allocate the instance id and fill the inner fields of the instance to insert
+func (m *instanceManager) newInstanceData(ctx core.ContextParams, objID string, inputParam mapstr.MapStr) (id uint64, err error) {
 	tableName := common.GetInstTableName(objID)
 	id, err = m.dbProxy.NextSequence(ctx, tableName)
 	if nil != err {
@@ -39,26 +51,29 @@ func (m *instanceManager) save(ctx core.ContextParams, objID string, inputParam
 	inputParam.Set(common.BKOwnerIDField, ctx.SupplierAccount)
 	inputParam.Set(common.CreateTimeField, ts)
 	inputParam.Set(common.LastTimeField, ts)
-	err = m.dbProxy.Table(tableName).Insert(ctx, inputParam)
-	return id, err
+	return id, nil
 }
 
 func (m *instanceManager) update(ctx core.ContextParams, objID string, data mapstr.MapStr, cond mapstr.MapStr) (cnt uint64, err error) {
 	tableName := common.GetInstTableName(objID)
-	if !util.IsInnerObject(objID) {
-		cond.Set(common.BKObjIDField, objID)
-	}
+	m.prepareUpdate(objID, data, cond)
 	cnt, err = m.dbProxy.Table(tableName).Find(cond).Count(ctx)
 	if nil != err {
 		return cnt, err
 	}
-	ts := time.Now()
-	data.Set(common.LastTimeField, ts)
-	data.Remove(common.BKObjIDField)
 	err = m.dbProxy.Table(tableName).Update(ctx, cond, data)
 	return cnt, err
 }
 
+// prepareUpdate fill the inner fields of the update condition and data
+func (m *instanceManager) prepareUpdate(objID string, data mapstr.MapStr, cond mapstr.MapStr) {
+	if !util.IsInnerObject(objID) {
+		cond.Set(common.BKObjIDField, objID)
+	}
+	data.Set(common.LastTimeField, time.Now())
+	data.Remove(common.BKObjIDField)
+}
+
 func (m *instanceManager) getInsts(ctx core.ContextParams, objID string, cond mapstr.MapStr) (origins []mapstr.MapStr, exists bool, err error) {
 	origins = make([]mapstr.MapStr, 0)
 	tableName := common.GetInstTableName(objID)
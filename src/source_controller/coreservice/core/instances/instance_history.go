@@ -0,0 +1,165 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/history"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// recordHistory record the versions of the instances of the object changed by the action, the lost history
+// only fails the request in a transaction, as the changes have been committed otherwise.
+func (m *instanceManager) recordHistory(ctx core.ContextParams, objID, action string, insts []mapstr.MapStr) error {
+	op := history.Operation{RequestID: ctx.ReqID, Operator: ctx.User, OwnerID: ctx.SupplierAccount}
+	err := history.RecordInst(ctx, m.dbProxy, op, objID, action, insts)
+	if nil != history.Check(ctx, op, err) {
+		return ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	return nil
+}
+
+// recordUpdatedHistory record the versions of the updated instances, which are read again to get the whole data
+func (m *instanceManager) recordUpdatedHistory(ctx core.ContextParams, objID string, instIDs []uint64) error {
+	if 0 == len(instIDs) {
+		return nil
+	}
+
+	cond := mapstr.MapStr{common.GetInstIDField(objID): mapstr.MapStr{common.BKDBIN: instIDs}}
+	insts, _, err := m.getInsts(ctx, objID, cond)
+	if nil != err {
+		blog.Errorf("record the history of the updated instances of %s, but get them failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	return m.recordHistory(ctx, objID, metadata.InstHistoryActionUpdate, insts)
+}
+
+// SearchInstHistory search the versions of an instance, the latest version is the first by default
+func (m *instanceManager) SearchInstHistory(ctx core.ContextParams, objID string, instID int64, inputParam metadata.QueryCondition) (*metadata.InstHistoryQueryResult, error) {
+	cond, err := mongo.NewConditionFromMapStr(inputParam.Condition)
+	if nil != err {
+		blog.Errorf("search the history, but parse the condition %#v failed, err: %v, rid: %s", inputParam.Condition, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommParamsInvalid)
+	}
+	cond.Element(&mongo.Eq{Key: common.BKObjIDField, Val: objID})
+	cond.Element(&mongo.Eq{Key: common.BKInstIDField, Val: instID})
+	cond.Element(&mongo.In{Key: common.BKOwnerIDField, Val: []string{ctx.SupplierAccount, common.BKDefaultOwnerID}})
+
+	query := m.dbProxy.Table(common.BKTableNameInstHistory).Find(cond.ToMapStr())
+	count, err := query.Count(ctx)
+	if nil != err {
+		blog.Errorf("search the history, but count it failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+
+	sort := "-version"
+	if 0 != len(inputParam.SortArr) {
+		sort = inputParam.SortArr[0].Field
+		if inputParam.SortArr[0].IsDsc {
+			sort = "-" + sort
+		}
+	}
+	result := &metadata.InstHistoryQueryResult{Count: count, Info: make([]metadata.InstHistory, 0)}
+	err = query.Sort(sort).Start(uint64(inputParam.Limit.Offset)).Limit(uint64(inputParam.Limit.Limit)).All(ctx, &result.Info)
+	if nil != err {
+		blog.Errorf("search the history failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	return result, nil
+}
+
+// instVersionSort the sort of the versions by the instance and the version, it's a struct rather than a map,
+// so that the fields are in the order of the index
+type instVersionSort struct {
+	InstID  int `bson:"bk_inst_id"`
+	Version int `bson:"version"`
+}
+
+// SearchModelInstanceAsOf search the instances of the object as they were at the point of time, which are the
+// latest versions recorded no later than it, the instances deleted by then are excluded. the condition is
+// applied to the data of the versions. the history older than the retention of the coreservice may be pruned,
+// so the time should be within it.
+func (m *instanceManager) SearchModelInstanceAsOf(ctx core.ContextParams, objID string, inputParam metadata.AsOfQueryCondition) (*metadata.QueryResult, error) {
+	cond := metadata.PrefixConditionField(inputParam.Condition, "doc.data.")
+	cond.Set("doc.action", mapstr.MapStr{common.BKDBNE: metadata.InstHistoryActionDelete})
+	pipeline := []mapstr.MapStr{
+		{"$match": mapstr.MapStr{
+			common.BKObjIDField:   objID,
+			common.BKOwnerIDField: mapstr.MapStr{common.BKDBIN: []string{ctx.SupplierAccount, common.BKDefaultOwnerID}},
+			"op_time":             mapstr.MapStr{common.BKDBLTE: inputParam.AsOf.Time},
+		}},
+		// sorted in the order of the unique index of bk_obj_id, bk_inst_id and version, so that the sort is done by
+		// walking the index rather than in memory, and only the fields used are kept in the groups
+		{"$sort": instVersionSort{InstID: 1, Version: -1}},
+		{"$group": mapstr.MapStr{
+			"_id": "$" + common.BKInstIDField,
+			"doc": mapstr.MapStr{"$first": mapstr.MapStr{"action": "$action", "data": "$data"}},
+		}},
+		{"$match": cond},
+	}
+
+	counts := make([]struct {
+		Count uint64 `bson:"count"`
+	}, 0)
+	countPipeline := append(append([]mapstr.MapStr{}, pipeline...), mapstr.MapStr{"$count": "count"})
+	if err := m.dbProxy.Table(common.BKTableNameInstHistory).AggregateAll(ctx, countPipeline, &counts); nil != err {
+		blog.Errorf("search the instances of %s as of %v, but count them failed, err: %v, rid: %s", objID, inputParam.AsOf.Time, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	result := &metadata.QueryResult{Info: make([]mapstr.MapStr, 0)}
+	if 0 == len(counts) || 0 == counts[0].Count {
+		return result, nil
+	}
+	result.Count = counts[0].Count
+
+	sortField, sortOrder := common.GetInstIDField(objID), 1
+	if 0 != len(inputParam.SortArr) {
+		sortField = inputParam.SortArr[0].Field
+		if inputParam.SortArr[0].IsDsc {
+			sortOrder = -1
+		}
+	}
+	pipeline = append(pipeline, mapstr.MapStr{"$sort": mapstr.MapStr{"doc.data." + sortField: sortOrder}})
+	if 0 < inputParam.Limit.Offset {
+		pipeline = append(pipeline, mapstr.MapStr{"$skip": inputParam.Limit.Offset})
+	}
+	if 0 < inputParam.Limit.Limit {
+		pipeline = append(pipeline, mapstr.MapStr{"$limit": inputParam.Limit.Limit})
+	}
+
+	versions := make([]struct {
+		Doc metadata.InstHistory `bson:"doc"`
+	}, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstHistory).AggregateAll(ctx, pipeline, &versions); nil != err {
+		blog.Errorf("search the instances of %s as of %v failed, err: %v, rid: %s", objID, inputParam.AsOf.Time, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	for _, version := range versions {
+		if 0 == len(inputParam.Fields) {
+			result.Info = append(result.Info, version.Doc.Data)
+			continue
+		}
+		inst := mapstr.New()
+		for _, field := range inputParam.Fields {
+			if val, exists := version.Doc.Data[field]; exists {
+				inst[field] = val
+			}
+		}
+		result.Info = append(result.Info, inst)
+	}
+	return result, nil
+}
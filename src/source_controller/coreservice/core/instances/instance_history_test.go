@@ -0,0 +1,105 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances_test
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+// moment returns the point of time between the changes, whose histories are recorded at the time of them
+func moment() metadata.Time {
+	time.Sleep(5 * time.Millisecond)
+	now := metadata.Time{Time: time.Now()}
+	time.Sleep(5 * time.Millisecond)
+	return now
+}
+
+func TestSearchModelInstanceAsOf(t *testing.T) {
+	instMgr := newInstances(t)
+	objID := "bk_switch"
+	beforeCreate := moment()
+
+	for _, name := range []string{"sw_asof_a", "sw_asof_b"} {
+		inputParams := metadata.CreateModelInstance{Data: mapstr.MapStr{
+			common.BKInstNameField: name,
+			common.BKAssetIDField:  name,
+			"bk_sn":                "cmdb_sn_asof",
+		}}
+		_, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
+		require.NoError(t, err)
+	}
+	afterCreate := moment()
+
+	updateParams := metadata.UpdateOption{
+		Condition: mapstr.MapStr{common.BKInstNameField: "sw_asof_a"},
+		Data:      mapstr.MapStr{"bk_operator": "test_operator"},
+	}
+	_, err := instMgr.UpdateModelInstance(defaultCtx, objID, updateParams)
+	require.NoError(t, err)
+	afterUpdate := moment()
+
+	deleteParams := metadata.DeleteOption{Condition: mapstr.MapStr{common.BKInstNameField: "sw_asof_b"}}
+	_, err = instMgr.DeleteModelInstance(defaultCtx, objID, deleteParams)
+	require.NoError(t, err)
+	afterDelete := moment()
+
+	search := func(asOf metadata.Time, cond mapstr.MapStr) *metadata.QueryResult {
+		input := metadata.AsOfQueryCondition{AsOf: asOf}
+		input.Condition = cond
+		input.Fields = []string{common.BKInstNameField, "bk_operator"}
+		input.SortArr = []metadata.SearchSort{{Field: common.BKInstNameField}}
+		result, err := instMgr.SearchModelInstanceAsOf(defaultCtx, objID, input)
+		require.NoError(t, err)
+		return result
+	}
+	snCond := mapstr.MapStr{"bk_sn": "cmdb_sn_asof"}
+
+	result := search(beforeCreate, snCond)
+	require.Equal(t, uint64(0), result.Count)
+	require.Len(t, result.Info, 0)
+
+	result = search(afterCreate, snCond)
+	require.Equal(t, uint64(2), result.Count)
+	require.Equal(t, "sw_asof_a", result.Info[0][common.BKInstNameField])
+	require.NotEqual(t, "test_operator", result.Info[0]["bk_operator"])
+	require.Equal(t, "sw_asof_b", result.Info[1][common.BKInstNameField])
+
+	// the condition is applied to the data of the versions
+	result = search(afterUpdate, mapstr.MapStr{"bk_operator": "test_operator"})
+	require.Equal(t, uint64(1), result.Count)
+	require.Equal(t, mapstr.MapStr{common.BKInstNameField: "sw_asof_a", "bk_operator": "test_operator"}, result.Info[0])
+	require.Equal(t, uint64(0), search(afterCreate, mapstr.MapStr{"bk_operator": "test_operator"}).Count)
+
+	// the deleted instance is excluded
+	result = search(afterDelete, snCond)
+	require.Equal(t, uint64(1), result.Count)
+	require.Equal(t, "sw_asof_a", result.Info[0][common.BKInstNameField])
+
+	// the page of the instances
+	input := metadata.AsOfQueryCondition{AsOf: afterUpdate}
+	input.Condition = snCond
+	input.SortArr = []metadata.SearchSort{{Field: common.BKInstNameField, IsDsc: true}}
+	input.Limit = metadata.SearchLimit{Offset: 0, Limit: 1}
+	result, err = instMgr.SearchModelInstanceAsOf(defaultCtx, objID, input)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), result.Count)
+	require.Len(t, result.Info, 1)
+	require.Equal(t, "sw_asof_b", result.Info[0][common.BKInstNameField])
+}
@@ -0,0 +1,92 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// instDeleteLookup looks up the associations by the dependences when plan an instance deletion
+type instDeleteLookup struct {
+	ctx       core.ContextParams
+	dependent OperationDependences
+}
+
+func (l *instDeleteLookup) InstAssociations(objID string, instID int64) ([]metadata.InstAsst, error) {
+	return l.dependent.SearchInstAsst(l.ctx, objID, uint64(instID))
+}
+
+func (l *instDeleteLookup) ObjectAssociation(objAsstID string) (*metadata.Association, error) {
+	return l.dependent.SearchModelAsst(l.ctx, objAsstID)
+}
+
+func (l *instDeleteLookup) IsMainlineObject(objID string) (bool, error) {
+	return l.dependent.IsMainlineObject(l.ctx, objID)
+}
+
+// cascadeDeleteInstance deletes the instance which is reached by the on delete action of an association,
+// an operation log is written for it as the caller only knows the instances it asks to delete.
+func (m *instanceManager) cascadeDeleteInstance(ctx core.ContextParams, node metadata.InstDeleteNode) error {
+	cond := mapstr.MapStr{common.GetInstIDField(node.ObjectID): node.InstID}
+	if !util.IsInnerObject(node.ObjectID) {
+		cond.Set(common.BKObjIDField, node.ObjectID)
+	}
+	cond.Set(common.BKOwnerIDField, ctx.SupplierAccount)
+
+	origins, exists, err := m.getInsts(ctx, node.ObjectID, cond)
+	if nil != err {
+		blog.Errorf("cascade delete model instance get inst error:%v, rid: %s", err, ctx.ReqID)
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	if err := m.archiveInstances(ctx, node.ObjectID, origins); nil != err {
+		return err
+	}
+	if err := m.recordHistory(ctx, node.ObjectID, metadata.InstHistoryActionDelete, origins); nil != err {
+		return err
+	}
+	if err := m.dbProxy.Table(common.GetInstTableName(node.ObjectID)).Delete(ctx, cond); nil != err {
+		blog.Errorf("cascade delete model instance %s %d error:%v, rid: %s", node.ObjectID, node.InstID, err, ctx.ReqID)
+		return err
+	}
+
+	bizID, _ := util.GetInt64ByInterface(origins[0][common.BKAppIDField])
+	log := &metadata.OperationLog{
+		OwnerID:       ctx.SupplierAccount,
+		ApplicationID: bizID,
+		OpType:        int(auditoplog.AuditOpTypeDel),
+		OpTarget:      node.ObjectID,
+		User:          ctx.User,
+		OpDesc:        fmt.Sprintf("cascade delete %s by association %s", node.ObjectID, node.ObjectAsstID),
+		Content:       metadata.Content{PreData: origins[0], Headers: []metadata.Header{}},
+		CreateTime:    time.Now(),
+		InstID:        node.InstID,
+	}
+	if err := m.dbProxy.Table(common.BKTableNameOperationLog).Insert(ctx, log); nil != err {
+		blog.Errorf("cascade delete model instance add operation log error:%v, rid: %s", err, ctx.ReqID)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/history"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/association"
+)
+
+// archiveInstances archive the instances to be deleted into the recycle bin
+func (m *instanceManager) archiveInstances(ctx core.ContextParams, objID string, origins []mapstr.MapStr) error {
+	archives := make([]metadata.DelArchive, 0, len(origins))
+	for _, origin := range origins {
+		archive, err := metadata.NewInstDelArchive(objID, origin)
+		if nil != err {
+			blog.Errorf("archive the instance %#v of %s failed, err: %v, rid: %s", origin, objID, err, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsNeedInt, common.GetInstIDField(objID))
+		}
+		archives = append(archives, archive)
+	}
+	return m.dependent.ArchiveDeleted(ctx, archives)
+}
+
+// SearchDelArchive search the archives in the recycle bin
+func (m *instanceManager) SearchDelArchive(ctx core.ContextParams, inputParam metadata.QueryCondition) (*metadata.DelArchiveQueryResult, error) {
+	cond, err := mongo.NewConditionFromMapStr(inputParam.Condition)
+	if nil != err {
+		blog.Errorf("search the archives, but parse the condition %#v failed, err: %v, rid: %s", inputParam.Condition, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommParamsInvalid)
+	}
+	cond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+
+	query := m.dbProxy.Table(common.BKTableNameDelArchive).Find(cond.ToMapStr())
+	count, err := query.Count(ctx)
+	if nil != err {
+		blog.Errorf("search the archives, but count them failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+
+	sort := "-delete_time"
+	if 0 != len(inputParam.SortArr) {
+		sort = inputParam.SortArr[0].Field
+		if inputParam.SortArr[0].IsDsc {
+			sort = "-" + sort
+		}
+	}
+	result := &metadata.DelArchiveQueryResult{Count: count, Info: make([]metadata.DelArchive, 0)}
+	err = query.Sort(sort).Start(uint64(inputParam.Limit.Offset)).Limit(uint64(inputParam.Limit.Limit)).All(ctx, &result.Info)
+	if nil != err {
+		blog.Errorf("search the archives failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	return result, nil
+}
+
+// PurgeDelArchive delete the archives from the recycle bin, the instance associations archived
+// with the instances are deleted too.
+func (m *instanceManager) PurgeDelArchive(ctx core.ContextParams, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error) {
+	cond, err := mongo.NewConditionFromMapStr(inputParam.Condition)
+	if nil != err {
+		blog.Errorf("purge the archives, but parse the condition %#v failed, err: %v, rid: %s", inputParam.Condition, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommParamsInvalid)
+	}
+	cond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+
+	archives := make([]metadata.DelArchive, 0)
+	if err := m.dbProxy.Table(common.BKTableNameDelArchive).Find(cond.ToMapStr()).All(ctx, &archives); nil != err {
+		blog.Errorf("purge the archives, but search them failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+
+	for _, archive := range archives {
+		if archive.Kind != metadata.DelArchiveKindInstance {
+			continue
+		}
+		if err := m.dbProxy.Table(common.BKTableNameDelArchive).Delete(ctx, archivedInstAsstCondition(archive)); nil != err {
+			blog.Errorf("purge the instance associations archived with %d failed, err: %v, rid: %s", archive.ID, err, ctx.ReqID)
+			return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+		}
+	}
+	if err := m.dbProxy.Table(common.BKTableNameDelArchive).Delete(ctx, cond.ToMapStr()); nil != err {
+		blog.Errorf("purge the archives failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	return &metadata.DeletedCount{Count: uint64(len(archives))}, nil
+}
+
+// RestoreDelArchive restore the archived instance, the parent of it must exist and it should not break the uniques.
+// the instance associations archived with it are restored if the instances on the other side exist, the others
+// are kept in the recycle bin, so that they can be restored with the other side instances later. only the hosts in
+// the resource pool can be deleted, so the restored host is put back into the idle module of the resource pool.
+func (m *instanceManager) RestoreDelArchive(ctx core.ContextParams, id int64) (*metadata.RestoredDelArchive, error) {
+	cond := mongo.NewCondition()
+	cond.Element(
+		&mongo.Eq{Key: common.BKFieldID, Val: id},
+		&mongo.Eq{Key: "kind", Val: metadata.DelArchiveKindInstance},
+		&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	archive := metadata.DelArchive{}
+	err := m.dbProxy.Table(common.BKTableNameDelArchive).Find(cond.ToMapStr()).One(ctx, &archive)
+	if m.dbProxy.IsNotFoundError(err) {
+		blog.Errorf("restore the archive %d, but it is not found, rid: %s", id, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommNotFound)
+	}
+	if nil != err {
+		blog.Errorf("restore the archive %d, but search it failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+
+	objID, inst := archive.ObjectID, archive.Detail
+	exists, err := m.isInstanceExist(ctx, objID, archive.InstID)
+	if nil != err {
+		return nil, err
+	}
+	if exists {
+		blog.Errorf("restore the archive %d, but the instance %s %d exists, rid: %s", id, objID, archive.InstID, ctx.ReqID)
+		return nil, ctx.Error.Errorf(common.CCErrCommDuplicateItem, common.GetInstIDField(objID))
+	}
+	if err := m.validArchiveParent(ctx, objID, inst); nil != err {
+		return nil, err
+	}
+	var relation *metadata.ModuleHost
+	if objID == common.BKInnerObjIDHost {
+		if relation, err = m.getResourcePoolIdleRelation(ctx, archive.InstID); nil != err {
+			return nil, err
+		}
+	}
+
+	valid, err := NewValidator(ctx, m.dependent, objID)
+	if nil != err {
+		blog.Errorf("restore the archive %d, but init the validator failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return nil, err
+	}
+	instMetaData := metadata.Metadata{Label: make(metadata.Label)}
+	if bizID := metadata.GetBusinessIDFromMeta(inst[metadata.BKMetadata]); "" != bizID {
+		instMetaData.Label.Set(metadata.LabelBusinessID, bizID)
+	}
+	if err := valid.validCreateUnique(ctx, inst, instMetaData, m); nil != err {
+		return nil, err
+	}
+
+	if err := m.dbProxy.Table(common.GetInstTableName(objID)).Insert(ctx, inst); nil != err {
+		blog.Errorf("restore the archive %d, but insert the instance failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	if nil != relation {
+		if err := m.dbProxy.Table(common.BKTableNameModuleHostConfig).Insert(ctx, relation); nil != err {
+			blog.Errorf("restore the archive %d, but insert the module host relation %#v failed, err: %v, rid: %s", id, relation, err, ctx.ReqID)
+			m.rollbackRestore(ctx, archive, nil)
+			return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+		}
+	}
+
+	assts, restored, err := m.restoreArchivedInstAssts(ctx, archive)
+	if nil != err {
+		m.rollbackRestore(ctx, archive, nil)
+		return nil, err
+	}
+
+	delCond := mongo.NewCondition()
+	delCond.Element(&mongo.In{Key: common.BKFieldID, Val: append(restored, archive.ID)})
+	if err := m.dbProxy.Table(common.BKTableNameDelArchive).Delete(ctx, delCond.ToMapStr()); nil != err {
+		blog.Errorf("restore the archive %d, but delete the restored archives failed, err: %v, rid: %s", id, err, ctx.ReqID)
+		m.rollbackRestore(ctx, archive, assts)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+
+	if err := m.recordHistory(ctx, objID, metadata.InstHistoryActionRestore, []mapstr.MapStr{inst}); nil != err {
+		return nil, err
+	}
+	if nil != relation {
+		op := history.Operation{RequestID: ctx.ReqID, Operator: ctx.User, OwnerID: ctx.SupplierAccount}
+		relations := []map[string]interface{}{{
+			common.BKAppIDField:    relation.AppID,
+			common.BKSetIDField:    relation.SetID,
+			common.BKModuleIDField: relation.ModuleID,
+			common.BKHostIDField:   relation.HostID,
+			common.BKOwnerIDField:  relation.OwnerID,
+		}}
+		err := history.RecordModuleHost(ctx, m.dbProxy, op, metadata.ModuleHostHistoryActionAdd, relations)
+		if nil != history.Check(ctx, op, err) {
+			return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+		}
+	}
+	return &metadata.RestoredDelArchive{ObjectID: objID, InstID: archive.InstID, Associations: assts}, nil
+}
+
+// restoreArchivedInstAssts restore the instance associations archived with the instance, whose instances
+// on the other side exist, and returns them with the ids of their archives. the mapping and the uniques of
+// all the associations are checked before any of them is restored, the restored ones are removed if the
+// later ones fail to be restored.
+func (m *instanceManager) restoreArchivedInstAssts(ctx core.ContextParams, archive metadata.DelArchive) ([]metadata.InstAsst, []int64, error) {
+	asstArchives := make([]metadata.DelArchive, 0)
+	err := m.dbProxy.Table(common.BKTableNameDelArchive).Find(archivedInstAsstCondition(archive)).All(ctx, &asstArchives)
+	if nil != err {
+		blog.Errorf("restore the instance associations archived with %d, but search them failed, err: %v, rid: %s", archive.ID, err, ctx.ReqID)
+		return nil, nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+
+	assts := make([]metadata.InstAsst, 0)
+	restored := make([]int64, 0)
+	for _, asstArchive := range asstArchives {
+		if nil == asstArchive.InstAsst {
+			continue
+		}
+		asst := *asstArchive.InstAsst
+		otherObjID, otherInstID := asst.AsstObjectID, asst.AsstInstID
+		if asst.AsstObjectID == archive.ObjectID && asst.AsstInstID == archive.InstID {
+			otherObjID, otherInstID = asst.ObjectID, asst.InstID
+		}
+		exists, err := m.isInstanceExist(ctx, otherObjID, otherInstID)
+		if nil != err {
+			return nil, nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		if err := association.CheckInstAsstMapping(ctx, m.dbProxy, asst); nil != err {
+			return nil, nil, err
+		}
+		assts = append(assts, asst)
+		restored = append(restored, asstArchive.ID)
+	}
+	if 0 == len(assts) {
+		return assts, restored, nil
+	}
+	if err := association.CheckInstAsstUnique(ctx, m.dbProxy, assts, false); nil != err {
+		return nil, nil, err
+	}
+
+	for idx, asst := range assts {
+		if err := m.dbProxy.Table(common.BKTableNameInstAsst).Insert(ctx, asst); nil != err {
+			blog.Errorf("restore the instance association %#v failed, err: %v, rid: %s", asst, err, ctx.ReqID)
+			m.removeRestoredInstAssts(ctx, assts[:idx])
+			return nil, nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+		}
+	}
+	return assts, restored, nil
+}
+
+// rollbackRestore remove the restored instance and instance associations as the transaction may be not enabled,
+// the archives are kept in the recycle bin.
+func (m *instanceManager) rollbackRestore(ctx core.ContextParams, archive metadata.DelArchive, assts []metadata.InstAsst) {
+	m.removeRestoredInstAssts(ctx, assts)
+
+	if archive.ObjectID == common.BKInnerObjIDHost {
+		cond := mongo.NewCondition()
+		cond.Element(&mongo.Eq{Key: common.BKHostIDField, Val: archive.InstID})
+		if err := m.dbProxy.Table(common.BKTableNameModuleHostConfig).Delete(ctx, cond.ToMapStr()); nil != err {
+			blog.Errorf("restore the archive %d, but remove the restored module host relation failed, err: %v, rid: %s", archive.ID, err, ctx.ReqID)
+		}
+	}
+
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.GetInstIDField(archive.ObjectID), Val: archive.InstID})
+	if err := m.dbProxy.Table(common.GetInstTableName(archive.ObjectID)).Delete(ctx, cond.ToMapStr()); nil != err {
+		blog.Errorf("restore the archive %d, but remove the restored instance failed, err: %v, rid: %s", archive.ID, err, ctx.ReqID)
+	}
+}
+
+// removeRestoredInstAssts remove the restored instance associations
+func (m *instanceManager) removeRestoredInstAssts(ctx core.ContextParams, assts []metadata.InstAsst) {
+	if 0 == len(assts) {
+		return
+	}
+	ids := make([]int64, 0, len(assts))
+	for _, asst := range assts {
+		ids = append(ids, asst.ID)
+	}
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.In{Key: common.BKFieldID, Val: ids})
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Delete(ctx, cond.ToMapStr()); nil != err {
+		blog.Errorf("remove the restored instance associations %v failed, err: %v, rid: %s", ids, err, ctx.ReqID)
+	}
+}
+
+// validArchiveParent checks the parent of the archived instance on the mainline and the business it belongs to
+func (m *instanceManager) validArchiveParent(ctx core.ContextParams, objID string, inst mapstr.MapStr) error {
+	parents := make(map[string]interface{})
+	if bizID, ok := inst[common.BKAppIDField]; ok && objID != common.BKInnerObjIDApp && objID != common.BKInnerObjIDHost {
+		parents[common.BKInnerObjIDApp] = bizID
+	}
+
+	cond := mongo.NewCondition()
+	cond.Element(
+		&mongo.Eq{Key: common.BKObjIDField, Val: objID},
+		&mongo.Eq{Key: common.AssociationKindIDField, Val: common.AssociationKindMainline})
+	mainline := make([]metadata.Association, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(cond.ToMapStr()).All(ctx, &mainline); nil != err {
+		blog.Errorf("restore the instance of %s, but search the mainline failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	if parentID, ok := inst[common.BKInstParentStr]; ok && 0 != len(mainline) {
+		parents[mainline[0].AsstObjID] = parentID
+	}
+
+	for parentObjID, parentID := range parents {
+		id, err := util.GetInt64ByInterface(parentID)
+		if nil != err {
+			blog.Errorf("restore the instance of %s, but the parent %s id %v is invalid, rid: %s", objID, parentObjID, parentID, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCommParamsNeedInt, common.GetInstIDField(parentObjID))
+		}
+		exists, err := m.isInstanceExist(ctx, parentObjID, id)
+		if nil != err {
+			return err
+		}
+		if !exists {
+			blog.Errorf("restore the instance of %s, but the parent %s %d does not exist, rid: %s", objID, parentObjID, id, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrorTopoRecycleParentNotExist, parentObjID, id)
+		}
+	}
+	return nil
+}
+
+// getResourcePoolIdleRelation returns the relation which puts the host into the idle module of the resource pool
+func (m *instanceManager) getResourcePoolIdleRelation(ctx core.ContextParams, hostID int64) (*metadata.ModuleHost, error) {
+	cond := mongo.NewCondition()
+	cond.Element(
+		&mongo.Eq{Key: common.BKDefaultField, Val: common.DefaultAppFlag},
+		&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	biz := make([]metadata.BizInst, 0)
+	if err := m.dbProxy.Table(common.BKTableNameBaseApp).Find(cond.ToMapStr()).Limit(1).All(ctx, &biz); nil != err {
+		blog.Errorf("restore the host %d, but search the resource pool failed, err: %v, rid: %s", hostID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	if 0 == len(biz) {
+		blog.Errorf("restore the host %d, but the resource pool does not exist, rid: %s", hostID, ctx.ReqID)
+		return nil, ctx.Error.Errorf(common.CCErrorTopoRecycleParentNotExist, common.BKInnerObjIDApp, common.BKDefaultField)
+	}
+
+	cond = mongo.NewCondition()
+	cond.Element(
+		&mongo.Eq{Key: common.BKAppIDField, Val: biz[0].BizID},
+		&mongo.Eq{Key: common.BKDefaultField, Val: common.DefaultResModuleFlag})
+	modules := make([]metadata.ModuleInst, 0)
+	if err := m.dbProxy.Table(common.BKTableNameBaseModule).Find(cond.ToMapStr()).Limit(1).All(ctx, &modules); nil != err {
+		blog.Errorf("restore the host %d, but search the idle module failed, err: %v, rid: %s", hostID, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	if 0 == len(modules) {
+		blog.Errorf("restore the host %d, but the idle module of the resource pool %d does not exist, rid: %s", hostID, biz[0].BizID, ctx.ReqID)
+		return nil, ctx.Error.Errorf(common.CCErrorTopoRecycleParentNotExist, common.BKInnerObjIDModule, common.BKDefaultField)
+	}
+
+	return &metadata.ModuleHost{
+		AppID:    biz[0].BizID,
+		SetID:    modules[0].SetID,
+		ModuleID: modules[0].ModuleID,
+		HostID:   hostID,
+		OwnerID:  ctx.SupplierAccount,
+	}, nil
+}
+
+// isInstanceExist checks whether the instance of the object exists
+func (m *instanceManager) isInstanceExist(ctx core.ContextParams, objID string, instID int64) (bool, error) {
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.GetInstIDField(objID), Val: instID})
+	if common.GetInstTableName(objID) == common.BKTableNameBaseInst {
+		cond.Element(&mongo.Eq{Key: common.BKObjIDField, Val: objID})
+	}
+	_, exists, err := m.instCnt(ctx, objID, cond.ToMapStr())
+	if nil != err {
+		blog.Errorf("check the instance %s %d exists failed, err: %v, rid: %s", objID, instID, err, ctx.ReqID)
+		return false, ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	return exists, nil
+}
+
+// archivedInstAsstCondition the condition of the instance associations archived with the instance
+func archivedInstAsstCondition(archive metadata.DelArchive) mapstr.MapStr {
+	return mapstr.MapStr{
+		"kind":                metadata.DelArchiveKindInstAsst,
+		"rid":                 archive.RequestID,
+		common.BKOwnerIDField: archive.OwnerID,
+		common.BKDBOR: []mapstr.MapStr{
+			{common.BKObjIDField: archive.ObjectID, common.BKInstIDField: archive.InstID},
+			{common.BKAsstObjIDField: archive.ObjectID, common.BKAsstInstIDField: archive.InstID},
+		},
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances_test
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/instances"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newRecycleInstances returns the instance operation whose deleted instances are archived into the db,
+// the bk_switch instances are connected to the bk_router instances 1 and 2 by the 1:1 associations.
+func newRecycleInstances(t *testing.T) (dal.RDB, core.InstanceOperation) {
+	db := memory.New()
+	for _, objAsstID := range []string{"bk_switch_connect_bk_router", "bk_switch_link_bk_router"} {
+		err := db.Table(common.BKTableNameObjAsst).Insert(defaultCtx, mapstr.MapStr{
+			common.AssociationObjAsstIDField: objAsstID,
+			common.BKObjIDField:              "bk_switch",
+			common.BKAsstObjIDField:          "bk_router",
+			metadata.AssociationFieldMapping: string(metadata.OneToOneMapping),
+		})
+		require.NoError(t, err)
+	}
+	for _, routerID := range []int64{1, 2} {
+		err := db.Table(common.BKTableNameBaseInst).Insert(defaultCtx, mapstr.MapStr{
+			common.BKObjIDField:  "bk_router",
+			common.BKInstIDField: routerID,
+		})
+		require.NoError(t, err)
+	}
+	return db, instances.New(db, &mockDependences{db: db})
+}
+
+// deleteSwitch creates a bk_switch instance and deletes it, returns the instance id and the id of its archive
+func deleteSwitch(t *testing.T, db dal.RDB, instMgr core.InstanceOperation, sn string) (int64, int64) {
+	inputParams := metadata.CreateModelInstance{Data: mapstr.New()}
+	inputParams.Data.Set(common.BKInstNameField, sn)
+	inputParams.Data.Set(common.BKAssetIDField, sn)
+	inputParams.Data.Set("bk_sn", sn)
+	dataResult, err := instMgr.CreateModelInstance(defaultCtx, "bk_switch", inputParams)
+	require.NoError(t, err)
+	instID := int64(dataResult.Created.ID)
+
+	_, err = instMgr.DeleteModelInstance(defaultCtx, "bk_switch", metadata.DeleteOption{Condition: mapstr.MapStr{"bk_sn": sn}})
+	require.NoError(t, err)
+
+	archive := metadata.DelArchive{}
+	cond := mapstr.MapStr{"kind": metadata.DelArchiveKindInstance, common.BKInstIDField: instID}
+	require.NoError(t, db.Table(common.BKTableNameDelArchive).Find(cond).One(defaultCtx, &archive))
+	require.Equal(t, "bk_switch", archive.ObjectID)
+	require.Equal(t, defaultCtx.ReqID, archive.RequestID)
+	require.Equal(t, sn, archive.Detail[common.BKInstNameField])
+	return instID, archive.ID
+}
+
+// archiveInstAssts archives the instance associations of the deleted bk_switch instance as the association
+// operation does, the ids of the archives are the ids of the associations.
+func archiveInstAssts(t *testing.T, db dal.RDB, assts ...metadata.InstAsst) {
+	for _, asst := range assts {
+		archive := metadata.NewInstAsstDelArchive(asst)
+		archive.ID = asst.ID
+		archive.RequestID = defaultCtx.ReqID
+		archive.OwnerID = defaultCtx.SupplierAccount
+		require.NoError(t, db.Table(common.BKTableNameDelArchive).Insert(defaultCtx, archive))
+	}
+}
+
+func countTable(t *testing.T, db dal.RDB, table string, cond mapstr.MapStr) uint64 {
+	cnt, err := db.Table(table).Find(cond).Count(defaultCtx)
+	require.NoError(t, err)
+	return cnt
+}
+
+func TestRestoreDelArchive(t *testing.T) {
+	db, instMgr := newRecycleInstances(t)
+	instID, archiveID := deleteSwitch(t, db, instMgr, "sw_restore")
+	require.Equal(t, uint64(0), countTable(t, db, common.BKTableNameBaseInst, mapstr.MapStr{common.BKObjIDField: "bk_switch", common.BKInstIDField: instID}))
+
+	archiveInstAssts(t, db,
+		metadata.InstAsst{ID: 1001, ObjectAsstID: "bk_switch_connect_bk_router", ObjectID: "bk_switch", InstID: instID, AsstObjectID: "bk_router", AsstInstID: 1},
+		// the bk_router 3 does not exist, the association is kept in the recycle bin
+		metadata.InstAsst{ID: 1002, ObjectAsstID: "bk_switch_link_bk_router", ObjectID: "bk_switch", InstID: instID, AsstObjectID: "bk_router", AsstInstID: 3},
+	)
+
+	// the instance is not restored while it exists
+	detail := mapstr.MapStr{common.BKObjIDField: "bk_switch", common.BKInstIDField: instID, common.BKAssetIDField: "sw_other"}
+	require.NoError(t, db.Table(common.BKTableNameBaseInst).Insert(defaultCtx, detail))
+	_, err := instMgr.RestoreDelArchive(defaultCtx, archiveID)
+	require.Error(t, err)
+	require.Equal(t, common.CCErrCommDuplicateItem, err.(errors.CCErrorCoder).GetCode())
+	require.NoError(t, db.Table(common.BKTableNameBaseInst).Delete(defaultCtx, detail))
+
+	restored, err := instMgr.RestoreDelArchive(defaultCtx, archiveID)
+	require.NoError(t, err)
+	require.Equal(t, instID, restored.InstID)
+	require.Len(t, restored.Associations, 1)
+	require.Equal(t, int64(1001), restored.Associations[0].ID)
+
+	require.Equal(t, uint64(1), countTable(t, db, common.BKTableNameBaseInst, mapstr.MapStr{common.BKInstIDField: instID, "bk_sn": "sw_restore"}))
+	require.Equal(t, uint64(1), countTable(t, db, common.BKTableNameInstAsst, mapstr.MapStr{common.BKFieldID: 1001}))
+	require.Equal(t, uint64(0), countTable(t, db, common.BKTableNameDelArchive, mapstr.MapStr{common.BKFieldID: archiveID}))
+	require.Equal(t, uint64(0), countTable(t, db, common.BKTableNameDelArchive, mapstr.MapStr{common.BKFieldID: 1001}))
+	require.Equal(t, uint64(1), countTable(t, db, common.BKTableNameDelArchive, mapstr.MapStr{common.BKFieldID: 1002}))
+
+	// the archive is gone once it's restored
+	_, err = instMgr.RestoreDelArchive(defaultCtx, archiveID)
+	require.Error(t, err)
+	require.Equal(t, common.CCErrCommNotFound, err.(errors.CCErrorCoder).GetCode())
+}
+
+func TestRestoreDelArchiveRollback(t *testing.T) {
+	db, instMgr := newRecycleInstances(t)
+	instID, archiveID := deleteSwitch(t, db, instMgr, "sw_rollback")
+
+	// the bk_router 2 has been linked by another bk_switch since the instance was deleted
+	linked := metadata.InstAsst{ID: 1, ObjectAsstID: "bk_switch_link_bk_router", ObjectID: "bk_switch", InstID: instID + 100, AsstObjectID: "bk_router", AsstInstID: 2}
+	require.NoError(t, db.Table(common.BKTableNameInstAsst).Insert(defaultCtx, linked))
+	archiveInstAssts(t, db,
+		metadata.InstAsst{ID: 1001, ObjectAsstID: "bk_switch_connect_bk_router", ObjectID: "bk_switch", InstID: instID, AsstObjectID: "bk_router", AsstInstID: 1},
+		metadata.InstAsst{ID: 1002, ObjectAsstID: "bk_switch_link_bk_router", ObjectID: "bk_switch", InstID: instID, AsstObjectID: "bk_router", AsstInstID: 2},
+	)
+
+	_, err := instMgr.RestoreDelArchive(defaultCtx, archiveID)
+	require.Error(t, err)
+	require.Equal(t, common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation, err.(errors.CCErrorCoder).GetCode())
+
+	// nothing is restored and all the archives are kept
+	require.Equal(t, uint64(0), countTable(t, db, common.BKTableNameBaseInst, mapstr.MapStr{common.BKObjIDField: "bk_switch", common.BKInstIDField: instID}))
+	require.Equal(t, uint64(1), countTable(t, db, common.BKTableNameInstAsst, mapstr.MapStr{}))
+	require.Equal(t, uint64(3), countTable(t, db, common.BKTableNameDelArchive, mapstr.MapStr{}))
+}
+
+func TestRestoreDelArchiveHost(t *testing.T) {
+	db, instMgr := newRecycleInstances(t)
+
+	inputParams := metadata.CreateModelInstance{Data: mapstr.MapStr{common.BKHostInnerIPField: "127.0.0.1"}}
+	dataResult, err := instMgr.CreateModelInstance(defaultCtx, common.BKInnerObjIDHost, inputParams)
+	require.NoError(t, err)
+	hostID := int64(dataResult.Created.ID)
+
+	_, err = instMgr.DeleteModelInstance(defaultCtx, common.BKInnerObjIDHost, metadata.DeleteOption{Condition: mapstr.MapStr{common.BKHostIDField: hostID}})
+	require.NoError(t, err)
+	archive := metadata.DelArchive{}
+	cond := mapstr.MapStr{"kind": metadata.DelArchiveKindInstance, common.BKObjIDField: common.BKInnerObjIDHost}
+	require.NoError(t, db.Table(common.BKTableNameDelArchive).Find(cond).One(defaultCtx, &archive))
+
+	// the host is not restored without the idle module of the resource pool
+	require.NoError(t, db.Table(common.BKTableNameBaseApp).Insert(defaultCtx, mapstr.MapStr{
+		common.BKAppIDField:   10,
+		common.BKDefaultField: common.DefaultAppFlag,
+		common.BKOwnerIDField: defaultCtx.SupplierAccount,
+	}))
+	_, err = instMgr.RestoreDelArchive(defaultCtx, archive.ID)
+	require.Error(t, err)
+	require.Equal(t, common.CCErrorTopoRecycleParentNotExist, err.(errors.CCErrorCoder).GetCode())
+	require.Equal(t, uint64(0), countTable(t, db, common.BKTableNameBaseHost, mapstr.MapStr{common.BKHostIDField: hostID}))
+
+	require.NoError(t, db.Table(common.BKTableNameBaseModule).Insert(defaultCtx, mapstr.MapStr{
+		common.BKAppIDField:    10,
+		common.BKSetIDField:    11,
+		common.BKModuleIDField: 12,
+		common.BKDefaultField:  common.DefaultResModuleFlag,
+	}))
+	restored, err := instMgr.RestoreDelArchive(defaultCtx, archive.ID)
+	require.NoError(t, err)
+	require.Equal(t, hostID, restored.InstID)
+
+	// the restored host is put back into the idle module of the resource pool
+	require.Equal(t, uint64(1), countTable(t, db, common.BKTableNameBaseHost, mapstr.MapStr{common.BKHostIDField: hostID, common.BKHostInnerIPField: "127.0.0.1"}))
+	relations := make([]metadata.ModuleHost, 0)
+	require.NoError(t, db.Table(common.BKTableNameModuleHostConfig).Find(nil).All(defaultCtx, &relations))
+	require.Equal(t, []metadata.ModuleHost{{AppID: 10, SetID: 11, ModuleID: 12, HostID: hostID, OwnerID: defaultCtx.SupplierAccount}}, relations)
+	require.Equal(t, uint64(1), countTable(t, db, common.BKTableNameModuleHostHistory, mapstr.MapStr{
+		common.BKHostIDField: hostID, "action": metadata.ModuleHostHistoryActionAdd,
+	}))
+	require.Equal(t, uint64(0), countTable(t, db, common.BKTableNameDelArchive, mapstr.MapStr{common.BKFieldID: archive.ID}))
+}
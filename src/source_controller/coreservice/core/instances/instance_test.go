@@ -19,6 +19,9 @@ import (
 	"configcenter/src/common/errors"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core/instances"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/memory"
 
 	"github.com/rs/xid"
 	"github.com/stretchr/testify/require"
@@ -28,14 +31,13 @@ func TestCreateOneInstance(t *testing.T) {
 
 	instMgr := newInstances(t)
 	objID := "bk_switch"
-	inputParams := metadata.CreateModelInstance{}
+	inputParams := metadata.CreateModelInstance{Data: mapstr.New()}
 	inputParams.Data.Set(common.BKInstNameField, xid.New().String())
 
 	// create a new bk_switch instance without bk_asset_id
 	dataResult, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
 	require.NotNil(t, err)
-	require.NotNil(t, dataResult)
-	require.Equal(t, uint64(0), dataResult.Created.ID)
+	require.Nil(t, dataResult)
 	tmpErr, ok := err.(errors.CCErrorCoder)
 	require.True(t, ok, "err must be the errors of the cmdb")
 	require.Equal(t, common.CCErrCommParamsNeedSet, tmpErr.GetCode())
@@ -81,6 +83,9 @@ func TestCreateManyInstance(t *testing.T) {
 	require.NotNil(t, dataResult)
 	require.NotEqual(t, 0, len(dataResult.Repeated))
 	require.NotEqual(t, 0, len(dataResult.Created))
+	require.Equal(t, 1, len(dataResult.Repeated))
+	require.Equal(t, int64(2), dataResult.Repeated[0].OriginIndex)
+	require.Equal(t, 3, len(dataResult.Created))
 }
 
 func TestUpdateOneInstance(t *testing.T) {
@@ -89,14 +94,14 @@ func TestUpdateOneInstance(t *testing.T) {
 	objID := "bk_switch"
 
 	//create one bk_switch instance data
-	inputParams := metadata.CreateModelInstance{}
+	inputParams := metadata.CreateModelInstance{Data: mapstr.New()}
 	inputParams.Data.Set(common.BKInstNameField, xid.New().String())
 	inputParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	inputParams.Data.Set("bk_sn", "cmdb_sn")
 	dataResult, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
 	require.Nil(t, err)
 	require.NotNil(t, dataResult)
-	require.Equal(t, uint64(0), dataResult.Created.ID)
+	require.NotEqual(t, uint64(0), dataResult.Created.ID)
 
 	//update one bk_switch instance by condition
 	updateParams := metadata.UpdateOption{}
@@ -110,22 +115,178 @@ func TestUpdateOneInstance(t *testing.T) {
 
 }
 
+func TestUpdateManyInstance(t *testing.T) {
+
+	instMgr := newInstances(t)
+	objID := "bk_switch"
+
+	//create one bk_switch instance data
+	inputParams := metadata.CreateModelInstance{Data: mapstr.New()}
+	inputParams.Data.Set(common.BKInstNameField, xid.New().String())
+	inputParams.Data.Set(common.BKAssetIDField, xid.New().String())
+	inputParams.Data.Set("bk_sn", "cmdb_sn_many")
+	dataResult, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
+	require.Nil(t, err)
+	require.NotNil(t, dataResult)
+
+	//update the bk_switch instance and a not exists instance in one request
+	updateParams := metadata.UpdateManyModelInstance{}
+	updateParams.Updates = append(updateParams.Updates, metadata.UpdateOption{
+		Condition: mapstr.MapStr{"bk_sn": "cmdb_sn_many"},
+		Data:      mapstr.MapStr{"bk_operator": "test"},
+	})
+	updateParams.Updates = append(updateParams.Updates, metadata.UpdateOption{
+		Condition: mapstr.MapStr{"bk_sn": xid.New().String()},
+		Data:      mapstr.MapStr{"bk_operator": "test"},
+	})
+	updateResult, err := instMgr.UpdateManyModelInstance(defaultCtx, objID, updateParams)
+
+	require.Nil(t, err)
+	require.NotNil(t, updateResult)
+	require.Equal(t, 0, len(updateResult.Exceptions))
+	require.NotEqual(t, uint64(0), updateResult.Count)
+	require.Equal(t, int64(0), updateResult.Updated[0].OriginIndex)
+}
+
+func TestUpdateManyInstanceRepeated(t *testing.T) {
+
+	instMgr := newInstances(t)
+	objID := "bk_switch"
+
+	//create two bk_switch instances
+	for _, sn := range []string{"cmdb_sn_repeated1", "cmdb_sn_repeated2"} {
+		inputParams := metadata.CreateModelInstance{Data: mapstr.New()}
+		inputParams.Data.Set(common.BKInstNameField, xid.New().String())
+		inputParams.Data.Set(common.BKAssetIDField, xid.New().String())
+		inputParams.Data.Set("bk_sn", sn)
+		dataResult, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
+		require.Nil(t, err)
+		require.NotEqual(t, uint64(0), dataResult.Created.ID)
+	}
+
+	//update both of them to the same bk_asset_id in one request
+	assetID := xid.New().String()
+	updateParams := metadata.UpdateManyModelInstance{}
+	updateParams.Updates = append(updateParams.Updates, metadata.UpdateOption{
+		Condition: mapstr.MapStr{"bk_sn": "cmdb_sn_repeated1"},
+		Data:      mapstr.MapStr{common.BKAssetIDField: assetID},
+	})
+	updateParams.Updates = append(updateParams.Updates, metadata.UpdateOption{
+		Condition: mapstr.MapStr{"bk_sn": "cmdb_sn_repeated2"},
+		Data:      mapstr.MapStr{common.BKAssetIDField: assetID},
+	})
+	updateResult, err := instMgr.UpdateManyModelInstance(defaultCtx, objID, updateParams)
+	require.Nil(t, err)
+	require.Equal(t, uint64(1), updateResult.Count)
+	require.Equal(t, int64(0), updateResult.Updated[0].OriginIndex)
+	require.Equal(t, 1, len(updateResult.Exceptions))
+	require.Equal(t, int64(1), updateResult.Exceptions[0].OriginIndex)
+	require.Equal(t, int64(common.CCErrCommDuplicateItem), updateResult.Exceptions[0].Code)
+
+	//only the first one has the bk_asset_id
+	searchResult, err := instMgr.SearchModelInstance(defaultCtx, objID, metadata.QueryCondition{Condition: mapstr.MapStr{common.BKAssetIDField: assetID}})
+	require.Nil(t, err)
+	require.Equal(t, uint64(1), searchResult.Count)
+
+	//an update of the instance matched twice in one request is not repeated by itself
+	updateParams = metadata.UpdateManyModelInstance{}
+	updateParams.Updates = append(updateParams.Updates, metadata.UpdateOption{
+		Condition: mapstr.MapStr{"bk_sn": "cmdb_sn_repeated1"},
+		Data:      mapstr.MapStr{common.BKAssetIDField: assetID},
+	})
+	updateParams.Updates = append(updateParams.Updates, metadata.UpdateOption{
+		Condition: mapstr.MapStr{common.BKAssetIDField: assetID},
+		Data:      mapstr.MapStr{common.BKAssetIDField: assetID, "bk_operator": "test"},
+	})
+	updateResult, err = instMgr.UpdateManyModelInstance(defaultCtx, objID, updateParams)
+	require.Nil(t, err)
+	require.Equal(t, 0, len(updateResult.Exceptions))
+	require.Equal(t, uint64(2), updateResult.Count)
+}
+
+func TestUpsertInstance(t *testing.T) {
+
+	instMgr := newInstances(t)
+	objID := "bk_switch"
+
+	//create the bk_switch instance not found by the condition
+	upsertParams := metadata.UpsertModelInstance{
+		Condition: mapstr.MapStr{"bk_sn": "cmdb_sn_upsert"},
+		Data: mapstr.MapStr{
+			common.BKInstNameField: xid.New().String(),
+			common.BKAssetIDField:  xid.New().String(),
+		},
+	}
+	upsertResult, err := instMgr.UpsertModelInstance(defaultCtx, objID, upsertParams)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(upsertResult.Created))
+	require.NotEqual(t, uint64(0), upsertResult.Created[0].ID)
+	require.Equal(t, 0, len(upsertResult.Updated))
+
+	//update the instance found by the condition
+	upsertParams.Data = mapstr.MapStr{"bk_operator": "test"}
+	upsertResult, err = instMgr.UpsertModelInstance(defaultCtx, objID, upsertParams)
+	require.Nil(t, err)
+	require.Equal(t, 0, len(upsertResult.Created))
+	require.Equal(t, 1, len(upsertResult.Updated))
+
+	searchResult, err := instMgr.SearchModelInstance(defaultCtx, objID, metadata.QueryCondition{Condition: mapstr.MapStr{"bk_sn": "cmdb_sn_upsert"}})
+	require.Nil(t, err)
+	require.Equal(t, uint64(1), searchResult.Count)
+	require.Equal(t, "test", searchResult.Info[0]["bk_operator"])
+
+	//the condition is required
+	_, err = instMgr.UpsertModelInstance(defaultCtx, objID, metadata.UpsertModelInstance{Data: upsertParams.Data})
+	require.NotNil(t, err)
+}
+
+func TestUpsertInstanceDuplicated(t *testing.T) {
+	db := memory.New()
+	objID := "bk_switch"
+	instMgr := instances.New(db, &mockDependences{})
+	require.Nil(t, db.Table(common.GetInstTableName(objID)).CreateIndex(defaultCtx, dal.Index{
+		Name: "bk_sn_1", Keys: []dal.IndexKey{{Key: "bk_sn", Direction: 1}}, Unique: true}))
+
+	inputParams := metadata.CreateModelInstance{Data: mapstr.MapStr{
+		common.BKInstNameField: xid.New().String(),
+		common.BKAssetIDField:  xid.New().String(),
+		"bk_sn":                "cmdb_sn_dup",
+		"bk_operator":          "first",
+	}}
+	_, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
+	require.Nil(t, err)
+
+	// the created instance conflicts with the existing one which the condition doesn't match
+	upsertParams := metadata.UpsertModelInstance{
+		Condition: mapstr.MapStr{"bk_sn": "cmdb_sn_dup", "bk_operator": "second"},
+		Data: mapstr.MapStr{
+			common.BKInstNameField: xid.New().String(),
+			common.BKAssetIDField:  xid.New().String(),
+		},
+	}
+	_, err = instMgr.UpsertModelInstance(defaultCtx, objID, upsertParams)
+	require.NotNil(t, err)
+	tmpErr, ok := err.(errors.CCErrorCoder)
+	require.True(t, ok, "err must be the errors of the cmdb")
+	require.Equal(t, common.CCErrCommDuplicateItem, tmpErr.GetCode())
+}
+
 func TestSearchAndDeleteInstance(t *testing.T) {
 	instMgr := newInstances(t)
 	objID := "bk_switch"
 
 	//create one bk_switch instance data
-	inputParams := metadata.CreateModelInstance{}
+	inputParams := metadata.CreateModelInstance{Data: mapstr.New()}
 	inputParams.Data.Set(common.BKInstNameField, "test_sw1")
 	inputParams.Data.Set(common.BKAssetIDField, "test_sw_001")
 	inputParams.Data.Set("bk_sn", "cmdb_sn")
 	dataResult, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
 	require.Nil(t, err)
 	require.NotNil(t, dataResult)
-	require.Equal(t, uint64(0), dataResult.Created.ID)
+	require.NotEqual(t, uint64(0), dataResult.Created.ID)
 
 	//search  this instance
-	searchCond := metadata.QueryCondition{}
+	searchCond := metadata.QueryCondition{Condition: mapstr.New()}
 	searchCond.Condition.Set("bk_sn", "cmdb_sn")
 	searchResult, err := instMgr.SearchModelInstance(defaultCtx, objID, searchCond)
 	require.Nil(t, err)
@@ -134,7 +295,7 @@ func TestSearchAndDeleteInstance(t *testing.T) {
 	require.NotEqual(t, uint64(0), len(searchResult.Info))
 
 	//delete   this instance
-	deleteCond := metadata.DeleteOption{}
+	deleteCond := metadata.DeleteOption{Condition: mapstr.New()}
 	deleteCond.Condition.Set("bk_sn", "cmdb_sn")
 	deleteResult, err := instMgr.DeleteModelInstance(defaultCtx, objID, deleteCond)
 	require.Nil(t, err)
@@ -147,20 +308,59 @@ func TestCascadeDeleteInstance(t *testing.T) {
 	objID := "bk_switch"
 
 	//create one bk_switch instance data
-	inputParams := metadata.CreateModelInstance{}
+	inputParams := metadata.CreateModelInstance{Data: mapstr.New()}
 	inputParams.Data.Set(common.BKInstNameField, xid.New().String())
 	inputParams.Data.Set(common.BKAssetIDField, xid.New().String())
 	inputParams.Data.Set("bk_sn", "cmdb_sn")
 	dataResult, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
 	require.Nil(t, err)
 	require.NotNil(t, dataResult)
-	require.Equal(t, uint64(0), dataResult.Created.ID)
+	require.NotEqual(t, uint64(0), dataResult.Created.ID)
 
 	//delete   this instance
-	deleteCond := metadata.DeleteOption{}
+	deleteCond := metadata.DeleteOption{Condition: mapstr.New()}
 	deleteCond.Condition.Set("bk_sn", "cmdb_sn")
 	deleteResult, err := instMgr.CascadeDeleteModelInstance(defaultCtx, objID, deleteCond)
 	require.Nil(t, err)
 	require.NotNil(t, deleteResult)
 	require.NotEqual(t, uint64(0), deleteResult.Count)
 }
+
+func TestCascadeDeleteInstanceRestricted(t *testing.T) {
+	dependent := &mockDependences{
+		modelAssts: map[string]*metadata.Association{
+			"bk_router_connect_bk_switch": {AssociationName: "bk_router_connect_bk_switch", ObjectID: "bk_router", AsstObjID: "bk_switch", OnDelete: metadata.NoAction},
+			"bk_switch_belong_city":       {AssociationName: "bk_switch_belong_city", ObjectID: "bk_switch", AsstObjID: "city", OnDelete: metadata.DeleteDestinatioin},
+		},
+		mainline: map[string]bool{"city": true},
+	}
+	instMgr := instances.New(memory.New(), dependent)
+	objID := "bk_switch"
+
+	//create one bk_switch instance data
+	inputParams := metadata.CreateModelInstance{Data: mapstr.New()}
+	inputParams.Data.Set(common.BKInstNameField, xid.New().String())
+	inputParams.Data.Set(common.BKAssetIDField, xid.New().String())
+	inputParams.Data.Set("bk_sn", "cmdb_sn_restricted")
+	dataResult, err := instMgr.CreateModelInstance(defaultCtx, objID, inputParams)
+	require.Nil(t, err)
+	instID := int64(dataResult.Created.ID)
+
+	cases := [][]metadata.InstAsst{
+		// a bk_router which is kept connects to the instance
+		{{ID: 1, ObjectAsstID: "bk_router_connect_bk_switch", ObjectID: "bk_router", InstID: 1, AsstObjectID: objID, AsstInstID: instID}},
+		// the instance of the custom mainline model is never deleted by cascade
+		{{ID: 2, ObjectAsstID: "bk_switch_belong_city", ObjectID: objID, InstID: instID, AsstObjectID: "city", AsstInstID: 1}},
+	}
+	for _, instAssts := range cases {
+		dependent.instAssts = instAssts
+		deleteCond := metadata.DeleteOption{Condition: mapstr.MapStr{"bk_sn": "cmdb_sn_restricted"}}
+		_, err = instMgr.CascadeDeleteModelInstance(defaultCtx, objID, deleteCond)
+		require.NotNil(t, err)
+		require.Equal(t, common.CCErrorInstHasAsst, err.(errors.CCErrorCoder).GetCode())
+
+		searchResult, err := instMgr.SearchModelInstance(defaultCtx, objID, metadata.QueryCondition{Condition: mapstr.MapStr{"bk_sn": "cmdb_sn_restricted"}})
+		require.Nil(t, err)
+		require.Equal(t, uint64(1), searchResult.Count)
+	}
+}
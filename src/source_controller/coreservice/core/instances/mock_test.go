@@ -15,20 +15,42 @@ package instances_test
 import (
 	"context"
 	"testing"
-	"time"
 
+	"configcenter/src/common"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
 	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/source_controller/coreservice/core/instances"
-	"configcenter/src/storage/dal/mongo"
-	"configcenter/src/storage/dal/mongo/local"
-
-	"github.com/stretchr/testify/require"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/memory"
 )
 
+// switchAttributes the attributes of the bk_switch model used in the tests
+var switchAttributes = []metadata.Attribute{
+	{ID: 1, ObjectID: "bk_switch", PropertyID: common.BKInstNameField, PropertyType: common.FieldTypeSingleChar, IsRequired: true, IsEditable: true},
+	{ID: 2, ObjectID: "bk_switch", PropertyID: common.BKAssetIDField, PropertyType: common.FieldTypeSingleChar, IsRequired: true, IsEditable: true},
+	{ID: 3, ObjectID: "bk_switch", PropertyID: "bk_sn", PropertyType: common.FieldTypeSingleChar, IsEditable: true},
+	{ID: 4, ObjectID: "bk_switch", PropertyID: "bk_operator", PropertyType: common.FieldTypeSingleChar, IsEditable: true},
+}
+
+// hostAttributes the attributes of the hosts used by the tests
+var hostAttributes = []metadata.Attribute{
+	{ID: 11, ObjectID: common.BKInnerObjIDHost, PropertyID: common.BKHostInnerIPField, PropertyType: common.FieldTypeSingleChar, IsRequired: true, IsEditable: true},
+}
+
+// switchUniques the bk_asset_id of the bk_switch instances is unique
+var switchUniques = []metadata.ObjectUnique{
+	{ID: 1, ObjID: "bk_switch", MustCheck: true, Keys: []metadata.UniqueKey{{Kind: metadata.UniqueKeyKindProperty, ID: 2}}},
+}
+
 type mockDependences struct {
+	// the instance associations, the model associations and the mainline models of the tests
+	instAssts  []metadata.InstAsst
+	modelAssts map[string]*metadata.Association
+	mainline   map[string]bool
+	// db the archives are written into if it's set
+	db dal.RDB
 }
 
 // IsInstanceExist used to check if the  instances  asst exist
@@ -41,20 +63,68 @@ func (s *mockDependences) DeleteInstAsst(ctx core.ContextParams, objID string, i
 	return nil
 }
 
+// SearchInstAsst used to search the inst asst which the inst is the source or the destination of
+func (s *mockDependences) SearchInstAsst(ctx core.ContextParams, objID string, instID uint64) (asst []metadata.InstAsst, err error) {
+	for _, item := range s.instAssts {
+		if (item.ObjectID == objID && item.InstID == int64(instID)) || (item.AsstObjectID == objID && item.AsstInstID == int64(instID)) {
+			asst = append(asst, item)
+		}
+	}
+	return asst, nil
+}
+
+// SearchModelAsst used to search the model asst by the bk_obj_asst_id, nil if not exist
+func (s *mockDependences) SearchModelAsst(ctx core.ContextParams, objAsstID string) (asst *metadata.Association, err error) {
+	return s.modelAssts[objAsstID], nil
+}
+
+// IsMainlineObject used to check if the model is a node of the mainline topology
+func (s *mockDependences) IsMainlineObject(ctx core.ContextParams, objID string) (bool, error) {
+	return s.mainline[objID], nil
+}
+
+// ArchiveDeleted archive the deleted instances and instance associations into the recycle bin
+func (s *mockDependences) ArchiveDeleted(ctx core.ContextParams, archives []metadata.DelArchive) error {
+	if nil == s.db {
+		return nil
+	}
+	for _, archive := range archives {
+		id, err := s.db.NextSequence(ctx, common.BKTableNameDelArchive)
+		if nil != err {
+			return err
+		}
+		archive.ID = int64(id)
+		archive.RequestID = ctx.ReqID
+		archive.OwnerID = ctx.SupplierAccount
+		if err := s.db.Table(common.BKTableNameDelArchive).Insert(ctx, archive); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
 // SelectObjectAttWithParams select object att with params
 func (s *mockDependences) SelectObjectAttWithParams(ctx core.ContextParams, objID string) (attribute []metadata.Attribute, err error) {
+	switch objID {
+	case "bk_switch":
+		return switchAttributes, nil
+	case common.BKInnerObjIDHost:
+		return hostAttributes, nil
+	}
 	return nil, nil
 }
 
 // SearchUnique search unique attribute
 func (s *mockDependences) SearchUnique(ctx core.ContextParams, objID string) (uniqueAttr []metadata.ObjectUnique, err error) {
+	if "bk_switch" == objID {
+		return switchUniques, nil
+	}
 	return nil, nil
 }
 
 func newInstances(t *testing.T) core.InstanceOperation {
 
-	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
-	require.NoError(t, err)
+	db := memory.New()
 	return instances.New(db, &mockDependences{})
 }
 
@@ -13,6 +13,7 @@
 package instances
 
 import (
+	"fmt"
 	"strings"
 
 	"configcenter/src/common"
@@ -22,6 +23,7 @@ import (
 	"configcenter/src/common/universalsql/mongo"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/association"
 )
 
 // validCreateUnique  valid create inst data unique
@@ -38,6 +40,11 @@ func (valid *validator) validCreateUnique(ctx core.ContextParams, instanceData m
 	}
 
 	for _, unique := range uniqueAttr {
+		if unique.HasAssociationKey() {
+			// the new instance is not associated with any instance yet, the unique with association keys
+			// is checked when the instance association is created.
+			continue
+		}
 		// retrive unique value
 		uniquekeys := map[string]bool{}
 		for _, key := range unique.Keys {
@@ -130,6 +137,10 @@ func (valid *validator) validUpdateUnique(ctx core.ContextParams, instanceData m
 	}
 
 	for _, unique := range uniqueAttr {
+		if unique.HasAssociationKey() {
+			// checked with the associated instances below
+			continue
+		}
 		// retrive unique value
 		uniquekeys := map[string]bool{}
 		for _, key := range unique.Keys {
@@ -192,5 +203,79 @@ func (valid *validator) validUpdateUnique(ctx core.ContextParams, instanceData m
 
 		}
 	}
-	return nil
+	return association.CheckInstUnique(ctx, instanceManager.dbProxy, valid.objID, int64(instID), mapData)
+}
+
+// batchUnique hashes the unique values of the instances written in one batch, so that the instances of the
+// batch conflicting with each other are rejected before the batch is written, the database only knows about
+// the instances written before the batch.
+type batchUnique struct {
+	valid   *validator
+	uniques []metadata.ObjectUnique
+	// taken the unique value hash to the owner which takes it
+	taken map[string]string
+}
+
+func (m *instanceManager) newBatchUnique(ctx core.ContextParams, objID string) (*batchUnique, error) {
+	valid, err := NewValidator(ctx, m.dependent, objID)
+	if nil != err {
+		blog.Errorf("[newBatchUnique] init [%s] validator error %v, rid: %s", objID, err, ctx.ReqID)
+		return nil, err
+	}
+	uniques, err := m.dependent.SearchUnique(ctx, objID)
+	if nil != err {
+		blog.Errorf("[newBatchUnique] search [%s] unique error %v, rid: %s", objID, err, ctx.ReqID)
+		return nil, err
+	}
+	return &batchUnique{valid: valid, uniques: uniques, taken: make(map[string]string)}, nil
+}
+
+// take takes the unique values of the instances for their owners, only the uniques with a key in the fields are
+// taken if the fields are not nil. It returns the property names of the unique whose value is taken by another
+// owner of the batch already, nothing is taken in that case.
+func (b *batchUnique) take(insts map[string]mapstr.MapStr, fields mapstr.MapStr) (string, error) {
+	hashes := make(map[string]string)
+	for owner, inst := range insts {
+		bizID := metadata.GetBusinessIDFromMeta(inst[metadata.BKMetadata])
+		for _, unique := range b.uniques {
+			if unique.HasAssociationKey() {
+				// checked when the instance association is created
+				continue
+			}
+			values := make([]interface{}, 0, len(unique.Keys))
+			names := make([]string, 0, len(unique.Keys))
+			anyEmpty, touched := false, nil == fields
+			for _, key := range unique.Keys {
+				property, ok := b.valid.idToProperty[int64(key.ID)]
+				if !ok {
+					return "", b.valid.errif.Errorf(common.CCErrTopoObjectPropertyNotFound, key.ID)
+				}
+				if _, ok := fields[property.PropertyID]; ok {
+					touched = true
+				}
+				val, ok := inst[property.PropertyID]
+				if !ok || isEmpty(val) {
+					anyEmpty = true
+				}
+				values = append(values, val)
+				names = append(names, property.PropertyID)
+			}
+			if !touched || (anyEmpty && !unique.MustCheck) {
+				continue
+			}
+
+			hash := fmt.Sprintf("%d#%s#%s", unique.ID, bizID, metadata.UniqueValueHash(values, nil))
+			if taker, ok := b.taken[hash]; ok && taker != owner {
+				return strings.Join(names, ","), nil
+			}
+			if taker, ok := hashes[hash]; ok && taker != owner {
+				return strings.Join(names, ","), nil
+			}
+			hashes[hash] = owner
+		}
+	}
+	for hash, owner := range hashes {
+		b.taken[hash] = owner
+	}
+	return "", nil
 }
@@ -15,16 +15,12 @@ package model_test
 import (
 	"context"
 	"testing"
-	"time"
 
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/source_controller/coreservice/core/model"
-	"configcenter/src/storage/dal/mongo"
-	"configcenter/src/storage/dal/mongo/local"
-
-	"github.com/stretchr/testify/require"
+	"configcenter/src/storage/dal/memory"
 )
 
 type mockDependences struct {
@@ -52,8 +48,7 @@ func (s *mockDependences) CascadeDeleteInstances(ctx core.ContextParams, objIDS
 
 func newModel(t *testing.T) core.ModelOperation {
 
-	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
-	require.NoError(t, err)
+	db := memory.New()
 	return model.New(db, &mockDependences{})
 }
 
@@ -18,6 +18,7 @@ import (
 	"configcenter/src/common/condition"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/storage/dal"
 )
@@ -48,6 +49,11 @@ func (m *modelAttrUnique) createModelAttrUnique(ctx core.ContextParams, objID st
 	for _, key := range inputParam.Data.Keys {
 		switch key.Kind {
 		case metadata.UniqueKeyKindProperty:
+		case metadata.UniqueKeyKindAssociation:
+			if err := m.checkUniqueAsstKey(ctx, objID, key); nil != err {
+				blog.Errorf("[CreateObjectUnique] invalid association key %d: %v", key.ID, err)
+				return 0, err
+			}
 		default:
 			blog.Errorf("[CreateObjectUnique] invalid key kind: %s", key.Kind)
 			return 0, ctx.Error.Errorf(common.CCErrTopoObjectUniqueKeyKindInvalid, key.Kind)
@@ -111,6 +117,11 @@ func (m *modelAttrUnique) updateModelAttrUnique(ctx core.ContextParams, objID st
 	for _, key := range unique.Keys {
 		switch key.Kind {
 		case metadata.UniqueKeyKindProperty:
+		case metadata.UniqueKeyKindAssociation:
+			if err := m.checkUniqueAsstKey(ctx, objID, key); nil != err {
+				blog.Errorf("[UpdateObjectUnique] invalid association key %d: %v", key.ID, err)
+				return err
+			}
 		default:
 			blog.Errorf("[UpdateObjectUnique] invalid key kind: %s", key.Kind)
 			return ctx.Error.Errorf(common.CCErrTopoObjectUniqueKeyKindInvalid, key.Kind)
@@ -192,6 +203,10 @@ func (m *modelAttrUnique) deleteModelAttrUnique(ctx core.ContextParams, objID st
 }
 
 func (m *modelAttrUnique) recheckUniqueForExistsInsts(ctx core.ContextParams, objID string, keys []metadata.UniqueKey, mustCheck bool) error {
+	if (metadata.ObjectUnique{Keys: keys}).HasAssociationKey() {
+		return m.recheckAsstUniqueForExistsInsts(ctx, objID, keys, mustCheck)
+	}
+
 	propertyIDs := []uint64{}
 	for _, key := range keys {
 		switch key.Kind {
@@ -276,3 +291,154 @@ func (m *modelAttrUnique) recheckUniqueForExistsInsts(ctx core.ContextParams, ob
 
 	return nil
 }
+
+// checkUniqueAsstKey checks the association key, which must be an association of the model
+func (m *modelAttrUnique) checkUniqueAsstKey(ctx core.ContextParams, objID string, key metadata.UniqueKey) error {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKFieldID).Eq(key.ID)
+	objAsst := metadata.Association{}
+	err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(cond.ToMapStr()).One(ctx, &objAsst)
+	if m.dbProxy.IsNotFoundError(err) {
+		return ctx.Error.Error(common.CCErrorTopoObjectAssociationNotExist)
+	}
+	if nil != err {
+		blog.Errorf("[ObjectUnique] find association %d error: %v", key.ID, err)
+		return ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	if objAsst.ObjectID != objID && objAsst.AsstObjID != objID {
+		return ctx.Error.Error(common.CCErrorTopoObjectAssociationNotExist)
+	}
+	if objAsst.AsstKindID == common.AssociationKindMainline {
+		return ctx.Error.Error(common.CCErrorTopoAssociationKindMainlineUnavailable)
+	}
+	return nil
+}
+
+// recheckAsstUniqueForExistsInsts recheck the unique with association keys for the exists instances,
+// the instances not associated by any of the association keys are skipped.
+func (m *modelAttrUnique) recheckAsstUniqueForExistsInsts(ctx core.ContextParams, objID string, keys []metadata.UniqueKey, mustCheck bool) error {
+	propertyIDs := []uint64{}
+	asstIDs := []uint64{}
+	for _, key := range keys {
+		switch key.Kind {
+		case metadata.UniqueKeyKindProperty:
+			propertyIDs = append(propertyIDs, key.ID)
+		case metadata.UniqueKeyKindAssociation:
+			asstIDs = append(asstIDs, key.ID)
+		default:
+			return ctx.Error.Errorf(common.CCErrTopoObjectUniqueKeyKindInvalid, key.Kind)
+		}
+	}
+
+	keynames := []string{}
+	if len(propertyIDs) > 0 {
+		propertys := []metadata.Attribute{}
+		cond := condition.CreateCondition()
+		cond.Field(common.BKObjIDField).Eq(objID)
+		cond.Field(common.BKOwnerIDField).Eq(ctx.SupplierAccount)
+		cond.Field(common.BKFieldID).In(propertyIDs)
+		err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond.ToMapStr()).All(ctx, &propertys)
+		if err != nil {
+			blog.Errorf("[ObjectUnique] recheckAsstUniqueForExistsInsts find propertys for %s failed %v", objID, err)
+			return err
+		}
+		for _, property := range propertys {
+			keynames = append(keynames, property.PropertyID)
+		}
+	}
+
+	objAssts := []metadata.Association{}
+	cond := condition.CreateCondition()
+	cond.Field(common.BKFieldID).In(asstIDs)
+	err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(cond.ToMapStr()).All(ctx, &objAssts)
+	if err != nil {
+		blog.Errorf("[ObjectUnique] recheckAsstUniqueForExistsInsts find associations for %s failed %v", objID, err)
+		return err
+	}
+
+	// the ids of the associated instances by every association key, instance id -> associated instance ids
+	associated := make([]map[int64][]int64, len(objAssts))
+	for idx, objAsst := range objAssts {
+		assts := []metadata.InstAsst{}
+		cond := condition.CreateCondition()
+		cond.Field(common.AssociationObjAsstIDField).Eq(objAsst.AssociationName)
+		err := m.dbProxy.Table(common.BKTableNameInstAsst).Find(cond.ToMapStr()).All(ctx, &assts)
+		if err != nil {
+			blog.Errorf("[ObjectUnique] recheckAsstUniqueForExistsInsts find instance associations of %s failed %v", objAsst.AssociationName, err)
+			return err
+		}
+		associated[idx] = make(map[int64][]int64)
+		for _, asst := range assts {
+			if objAsst.ObjectID == objID {
+				associated[idx][asst.InstID] = append(associated[idx][asst.InstID], asst.AsstInstID)
+			} else {
+				associated[idx][asst.AsstInstID] = append(associated[idx][asst.AsstInstID], asst.InstID)
+			}
+		}
+	}
+	if len(associated) == 0 || len(associated[0]) == 0 {
+		return nil
+	}
+
+	// only the instances associated by the first association key need to be checked
+	instIDs := make([]int64, 0, len(associated[0]))
+	for instID := range associated[0] {
+		instIDs = append(instIDs, instID)
+	}
+	instIDField := common.GetInstIDField(objID)
+	instcond := condition.CreateCondition()
+	instcond.Field(instIDField).In(instIDs)
+	instcond.Field(common.BKDataStatusField).NotEq(common.DataStatusDisabled)
+	if common.GetInstTableName(objID) == common.BKTableNameBaseInst {
+		instcond.Field(common.BKObjIDField).Eq(objID)
+	}
+	insts := []mapstr.MapStr{}
+	fields := append([]string{instIDField}, keynames...)
+	err = m.dbProxy.Table(common.GetInstTableName(objID)).Find(instcond.ToMapStr()).Fields(fields...).All(ctx, &insts)
+	if err != nil {
+		blog.Errorf("[ObjectUnique] recheckAsstUniqueForExistsInsts find instances of %s failed %v", objID, err)
+		return err
+	}
+
+	hashes := map[string]bool{}
+	for _, inst := range insts {
+		instID, err := util.GetInt64ByInterface(inst[instIDField])
+		if err != nil {
+			blog.Errorf("[ObjectUnique] recheckAsstUniqueForExistsInsts instance %#v has invalid id: %v", inst, err)
+			return err
+		}
+
+		values := make([]interface{}, 0, len(keynames))
+		anyEmpty := false
+		for _, key := range keynames {
+			val, ok := inst[key]
+			if !ok || val == nil || val == "" {
+				anyEmpty = true
+			}
+			values = append(values, val)
+		}
+		if anyEmpty && !mustCheck {
+			continue
+		}
+
+		associatedIDs := make([][]int64, 0, len(associated))
+		for _, instAssociated := range associated {
+			if len(instAssociated[instID]) == 0 {
+				break
+			}
+			associatedIDs = append(associatedIDs, instAssociated[instID])
+		}
+		if len(associatedIDs) != len(associated) {
+			continue
+		}
+
+		hash := metadata.UniqueValueHash(values, associatedIDs)
+		if hashes[hash] {
+			blog.Errorf("[ObjectUnique] recheckAsstUniqueForExistsInsts instance %d of %s is duplicated", instID, objID)
+			return dal.ErrDuplicated
+		}
+		hashes[hash] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/history"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// pruneHistoryInterval the interval the history older than the retention is pruned
+const pruneHistoryInterval = time.Hour
+
+// pruneHistoryLoop prune the history older than the retention periodically, the concurrent prunes of the
+// coreservice processes remove the same histories, which does no harm.
+func (s *coreService) pruneHistoryLoop(retention time.Duration) {
+	for {
+		before := time.Now().Add(-retention)
+		if err := history.Prune(context.Background(), s.db, before); nil != err {
+			blog.Errorf("prune the history before %v failed, err: %v", before, err)
+		}
+		time.Sleep(pruneHistoryInterval)
+	}
+}
+
+func (s *coreService) SearchInstHistory(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+
+	instID, err := strconv.ParseInt(pathParams("bk_inst_id"), 10, 64)
+	if err != nil {
+		return nil, params.Error.Errorf(common.CCErrCommParamsNeedInt, common.BKInstIDField)
+	}
+	inputData := metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.InstanceOperation().SearchInstHistory(params, pathParams("bk_obj_id"), instID, inputData)
+}
+
+func (s *coreService) SearchModelInstancesAsOf(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+
+	inputData := metadata.AsOfQueryCondition{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	if inputData.AsOf.IsZero() {
+		return nil, params.Error.Errorf(common.CCErrCommParamsNeedSet, "as_of")
+	}
+	return s.core.InstanceOperation().SearchModelInstanceAsOf(params, pathParams("bk_obj_id"), inputData)
+}
@@ -48,6 +48,24 @@ func (s *coreService) UpdateModelInstances(params core.ContextParams, pathParams
 	return s.core.InstanceOperation().UpdateModelInstance(params, pathParams("bk_obj_id"), inputData)
 }
 
+func (s *coreService) UpdateManyModelInstances(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+
+	inputData := metadata.UpdateManyModelInstance{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.InstanceOperation().UpdateManyModelInstance(params, pathParams("bk_obj_id"), inputData)
+}
+
+func (s *coreService) UpsertModelInstances(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+
+	inputData := metadata.UpsertModelInstance{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.InstanceOperation().UpsertModelInstance(params, pathParams("bk_obj_id"), inputData)
+}
+
 func (s *coreService) SearchModelInstances(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
 
 	inputData := metadata.QueryCondition{}
@@ -15,7 +15,9 @@ package service
 import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql"
 	"configcenter/src/common/universalsql/mongo"
 	"configcenter/src/source_controller/coreservice/core"
 )
@@ -66,6 +68,69 @@ func (s *coreService) DeleteInstAsst(ctx core.ContextParams, objID string, instI
 	return nil
 }
 
+// SearchInstAsst used to search the inst asst which the inst is the source or the destination of
+func (s *coreService) SearchInstAsst(ctx core.ContextParams, objID string, instID uint64) (assts []metadata.InstAsst, err error) {
+	assts = make([]metadata.InstAsst, 0)
+	exists := make(map[int64]bool)
+	conds := []universalsql.Condition{
+		mongo.NewCondition().Element(&mongo.Eq{Key: common.BKObjIDField, Val: objID}, &mongo.Eq{Key: common.BKInstIDField, Val: instID}),
+		mongo.NewCondition().Element(&mongo.Eq{Key: common.BKAsstObjIDField, Val: objID}, &mongo.Eq{Key: common.BKAsstInstIDField, Val: instID}),
+	}
+	for _, cond := range conds {
+		result, err := s.core.AssociationOperation().SearchInstanceAssociation(ctx, metadata.QueryCondition{Condition: cond.ToMapStr()})
+		if nil != err {
+			blog.Errorf("search instance association error %v", err)
+			return nil, err
+		}
+		for _, item := range result.Info {
+			asst := metadata.InstAsst{}
+			if err := mapstr.SetValueToStructByTags(&asst, item); nil != err {
+				blog.Errorf("parse instance association %v error %v", item, err)
+				return nil, err
+			}
+			if exists[asst.ID] {
+				continue
+			}
+			exists[asst.ID] = true
+			assts = append(assts, asst)
+		}
+	}
+	return assts, nil
+}
+
+// SearchModelAsst used to search the model asst by the bk_obj_asst_id, nil if not exist
+func (s *coreService) SearchModelAsst(ctx core.ContextParams, objAsstID string) (*metadata.Association, error) {
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: objAsstID})
+	result, err := s.core.AssociationOperation().SearchModelAssociation(ctx, metadata.QueryCondition{Condition: cond.ToMapStr()})
+	if nil != err {
+		blog.Errorf("search model association error %v", err)
+		return nil, err
+	}
+	if 0 == len(result.Info) {
+		return nil, nil
+	}
+	asst := &metadata.Association{}
+	if err := mapstr.SetValueToStructByTags(asst, result.Info[0]); nil != err {
+		blog.Errorf("parse model association %v error %v", result.Info[0], err)
+		return nil, err
+	}
+	return asst, nil
+}
+
+// IsMainlineObject used to check if the model is a node of the mainline topology, which is the child of a mainline association
+func (s *coreService) IsMainlineObject(ctx core.ContextParams, objID string) (bool, error) {
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.AssociationKindIDField, Val: common.AssociationKindMainline})
+	cond.Element(&mongo.Eq{Key: common.BKObjIDField, Val: objID})
+	result, err := s.core.AssociationOperation().SearchModelAssociation(ctx, metadata.QueryCondition{Condition: cond.ToMapStr()})
+	if nil != err {
+		blog.Errorf("search mainline model association of %s error %v", objID, err)
+		return false, err
+	}
+	return 0 != len(result.Info), nil
+}
+
 // SelectObjectAttWithParams select object att with params
 func (s *coreService) SelectObjectAttWithParams(ctx core.ContextParams, objID string) (attributeArr []metadata.Attribute, err error) {
 	attributeArr = make([]metadata.Attribute, 0)
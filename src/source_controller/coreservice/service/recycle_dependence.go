@@ -0,0 +1,53 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// ArchiveDeleted archive the deleted instances and instance associations into the recycle bin if it's enabled,
+// the archives are kept for the ttl of the recycle bin.
+func (s *coreService) ArchiveDeleted(ctx core.ContextParams, archives []metadata.DelArchive) error {
+	if !s.cfg.Recycle.Enabled || 0 == len(archives) {
+		return nil
+	}
+
+	now := time.Now()
+	docs := make([]metadata.DelArchive, 0, len(archives))
+	for _, archive := range archives {
+		id, err := s.db.NextSequence(ctx, common.BKTableNameDelArchive)
+		if nil != err {
+			blog.Errorf("archive the deleted data, but get the sequence failed, err: %v, rid: %s", err, ctx.ReqID)
+			return ctx.Error.Error(common.CCErrObjectDBOpErrno)
+		}
+		archive.ID = int64(id)
+		archive.RequestID = ctx.ReqID
+		archive.OwnerID = ctx.SupplierAccount
+		archive.Operator = ctx.User
+		archive.DeleteTime = now
+		archive.ExpireAt = now.Add(s.cfg.Recycle.TTL)
+		docs = append(docs, archive)
+	}
+
+	if err := s.db.Table(common.BKTableNameDelArchive).Insert(ctx, docs); nil != err {
+		blog.Errorf("archive the deleted data failed, err: %v, rid: %s", err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrObjectDBOpErrno)
+	}
+	return nil
+}
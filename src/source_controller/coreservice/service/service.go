@@ -30,6 +30,7 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/rdapi"
+	"configcenter/src/common/types"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/app/options"
 	"configcenter/src/source_controller/coreservice/core"
@@ -38,6 +39,8 @@ import (
 	"configcenter/src/source_controller/coreservice/core/instances"
 	"configcenter/src/source_controller/coreservice/core/model"
 	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/instrument"
+	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/dal/mongo/remote"
 
@@ -63,6 +66,8 @@ type coreService struct {
 	actions  []action
 	cfg      options.Config
 	core     core.Core
+	db       dal.DB
+	dbMetric instrument.Collector
 }
 
 func (s *coreService) SetConfig(cfg options.Config, engin *backbone.Engine, err errors.CCErrorIf, language language.CCLanguageIf) error {
@@ -78,25 +83,50 @@ func (s *coreService) SetConfig(cfg options.Config, engin *backbone.Engine, err
 		s.language = language
 	}
 
+	readOpt, dbErr := cfg.Mongo.GetReadOption()
+	if dbErr != nil {
+		blog.Errorf("invalid mongodb read preference config, error info is %s", dbErr.Error())
+		return dbErr
+	}
+
 	var db dal.DB
-	var dbErr error
-	if cfg.Mongo.Transaction == "enable" {
+	var localDB *local.Mongo
+	switch cfg.Mongo.Transaction {
+	case mongo.TransactionEnable:
 		blog.Infof("connecting to transaction manager")
 		db, dbErr = remote.NewWithDiscover(engin.ServiceManageInterface.TMServer().GetServers, cfg.Mongo)
 		if dbErr != nil {
 			blog.Errorf("failed to connect the txc server, error info is %s", dbErr.Error())
 			return dbErr
 		}
-	} else {
-		db, dbErr = local.NewMgo(cfg.Mongo.BuildURI(), time.Minute)
+	default:
+		localDB, dbErr = local.NewMgoByConfig(cfg.Mongo, time.Minute)
 		if dbErr != nil {
 			blog.Errorf("failed to connect the remote server(%s), error info is %s", cfg.Mongo.BuildURI(), dbErr.Error())
 			return dbErr
 		}
+		if dbErr = localDB.SetDefaultReadOption(readOpt); dbErr != nil {
+			blog.Errorf("invalid mongodb read preference config, error info is %s", dbErr.Error())
+			return dbErr
+		}
+		db = localDB
 	}
 	// connect the remote mongodb
 
+	// record the latencies and the slow queries of the db operations
+	instrumented := instrument.New(db, instrument.Options{
+		SlowThreshold: cfg.Mongo.GetSlowQueryThreshold(),
+		Process:       instrument.ProcessName(types.CC_MODULE_CORESERVICE, &engin.ServerInfo),
+		FlushInterval: time.Minute,
+	})
+	db = instrumented
+	s.dbMetric.Swap(instrumented)
+
+	s.db = db
 	s.core = core.New(model.New(db, s), instances.New(db, s), association.New(db, s), datasynchronize.New(db, s))
+	if cfg.History.Retention > 0 {
+		go s.pruneHistoryLoop(cfg.History.Retention)
+	}
 	return nil
 }
 
@@ -129,6 +159,9 @@ func (s *coreService) WebService() *restful.WebService {
 		}
 	}
 
+	// the latency histograms of the db operations
+	s.dbMetric.Register(ws, types.CC_MODULE_CORESERVICE)
+
 	return ws
 }
 
@@ -238,7 +271,16 @@ func (s *coreService) Actions() []*httpserver.Action {
 					}
 				}
 
-				data, dataErr := act.HandlerFunc(core.ContextParams{
+				handler := act.HandlerFunc
+				if act.inTransaction() {
+					handler = func(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+						return s.withTransaction(params, func(params core.ContextParams) (interface{}, error) {
+							return act.HandlerFunc(params, pathParams, queryParams, data)
+						})
+					}
+				}
+
+				data, dataErr := handler(core.ContextParams{
 					Context:         util.GetDBContext(context.Background(), req.Request.Header),
 					Error:           defErr,
 					Lang:            defLang,
@@ -71,11 +71,24 @@ func (s *coreService) initModelInstances() {
 	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/create/model/{bk_obj_id}/instance", HandlerFunc: s.CreateOneModelInstance})
 	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/createmany/model/{bk_obj_id}/instance", HandlerFunc: s.CreateManyModelInstances})
 	s.actions = append(s.actions, action{Method: http.MethodPut, Path: "/update/model/{bk_obj_id}/instance", HandlerFunc: s.UpdateModelInstances})
+	s.actions = append(s.actions, action{Method: http.MethodPut, Path: "/updatemany/model/{bk_obj_id}/instance", HandlerFunc: s.UpdateManyModelInstances})
+	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/upsert/model/{bk_obj_id}/instance", HandlerFunc: s.UpsertModelInstances})
 	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/read/model/{bk_obj_id}/instances", HandlerFunc: s.SearchModelInstances})
 	s.actions = append(s.actions, action{Method: http.MethodDelete, Path: "/delete/model/{bk_obj_id}/instance", HandlerFunc: s.DeleteModelInstances})
 	s.actions = append(s.actions, action{Method: http.MethodDelete, Path: "/delete/model/{bk_obj_id}/instance/cascade", HandlerFunc: s.CascadeDeleteModelInstances})
 }
 
+func (s *coreService) initRecycle() {
+	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/read/recycle/archives", HandlerFunc: s.SearchDelArchive})
+	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/restore/recycle/archive/{id}", HandlerFunc: s.RestoreDelArchive})
+	s.actions = append(s.actions, action{Method: http.MethodDelete, Path: "/delete/recycle/archives", HandlerFunc: s.PurgeDelArchive})
+}
+
+func (s *coreService) initInstHistory() {
+	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/read/history/model/{bk_obj_id}/instance/{bk_inst_id}", HandlerFunc: s.SearchInstHistory})
+	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/read/history/model/{bk_obj_id}/instances", HandlerFunc: s.SearchModelInstancesAsOf})
+}
+
 func (s *coreService) initAssociationKind() {
 
 	s.actions = append(s.actions, action{Method: http.MethodPost, Path: "/create/associationkind", HandlerFunc: s.CreateOneAssociationKind})
@@ -117,5 +130,7 @@ func (s *coreService) initService() {
 	s.initModelAssociation()
 	s.initModelInstances()
 	s.initInstanceAssociation()
+	s.initRecycle()
+	s.initInstHistory()
 	s.initDataSynchronize()
 }
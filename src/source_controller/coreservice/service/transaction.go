@@ -0,0 +1,44 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/types"
+)
+
+// withTransaction run the operation in a new transaction, the transaction is aborted if the operation failed.
+// the operation runs without transaction if the db does not support transaction,
+// and it joins the transaction of the caller if the caller has started one.
+func (s *coreService) withTransaction(params core.ContextParams, operation func(params core.ContextParams) (interface{}, error)) (interface{}, error) {
+	var result interface{}
+	err := dal.RunTransaction(params, s.db, params.Error.Error(common.CCErrObjectDBOpErrno), func(txn *types.Transaction) error {
+		txnParams := params
+		if txn != nil {
+			txnParams.Header = txn.IntoHeader(params.Header)
+			txnParams.Context = context.WithValue(params.Context, common.CCContextKeyJoinOption, dal.JoinOption{
+				RequestID: params.ReqID,
+				TxnID:     txn.TxnID,
+			})
+		}
+
+		var err error
+		result, err = operation(txnParams)
+		return err
+	})
+	return result, err
+}
@@ -13,6 +13,9 @@
 package service
 
 import (
+	"net/http"
+	"strings"
+
 	"configcenter/src/common/http/httpserver"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/source_controller/coreservice/core"
@@ -35,6 +38,15 @@ type action struct {
 	HandlerParseOriginDataFunc ParseOriginDataFunc
 }
 
+// inTransaction returns whether the writes of the action are rolled back together if it failed,
+// the synchronizations are not, as they save the data one by one and report the failed ones.
+func (a action) inTransaction() bool {
+	if a.Method == http.MethodGet || strings.HasPrefix(a.Path, "/read/") {
+		return false
+	}
+	return !strings.Contains(a.Path, "/synchronize")
+}
+
 // API the API interface
 type API interface {
 	Actions() []*httpserver.Action
@@ -28,6 +28,7 @@ import (
 	"configcenter/src/source_controller/hostcontroller/app/options"
 	"configcenter/src/source_controller/hostcontroller/logics"
 	"configcenter/src/source_controller/hostcontroller/service"
+	"configcenter/src/storage/dal/instrument"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
 	dalredis "configcenter/src/storage/dal/redis"
@@ -45,6 +46,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 	coreService := new(service.Service)
 	hostCtrl := new(HostController)
 	hostCtrl.Service = coreService
+	hostCtrl.process = instrument.ProcessName(types.CC_MODULE_HOSTCONTROLLER, svrInfo)
 	coreService.Logics = &logics.Logics{Instance: nil}
 
 	input := &backbone.BackboneParameter{
@@ -86,7 +88,8 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 
 type HostController struct {
 	*service.Service
-	Config *options.Config
+	Config  *options.Config
+	process string
 }
 
 func (h *HostController) onHostConfigUpdate(previous, current cc.ProcessConfig) {
@@ -95,21 +98,39 @@ func (h *HostController) onHostConfigUpdate(previous, current cc.ProcessConfig)
 		Redis: dalredis.ParseConfigFromKV("redis", current.ConfigMap),
 	}
 
-	instance, err := local.NewMgo(h.Config.Mongo.BuildURI(), time.Minute)
+	readOpt, err := h.Config.Mongo.GetReadOption()
+	if err != nil {
+		blog.Errorf("invalid mongo read preference config, err: %v", err)
+		return
+	}
+	db, err := local.NewMgoByConfig(h.Config.Mongo, time.Minute)
 	if err != nil {
 		blog.Errorf("new mongo client failed, err: %v", err)
 		return
 	}
+	if err = db.SetDefaultReadOption(readOpt); err != nil {
+		blog.Errorf("invalid mongo read preference config, err: %v", err)
+		return
+	}
+	// record the latencies and the slow queries of the db operations
+	instance := instrument.New(db, instrument.Options{
+		SlowThreshold: h.Config.Mongo.GetSlowQueryThreshold(),
+		Process:       h.process,
+		FlushInterval: time.Minute,
+	})
 
 	cache, err := dalredis.NewFromConfig(h.Config.Redis)
 	if err != nil {
 		blog.Errorf("new redis client failed, err: %v", err)
+		instance.Close()
 		return
 	}
 	ec := eventclient.NewClientViaRedis(cache, instance)
 
 	h.Service.Instance = instance
 	h.Service.Logics.Instance = instance
+	// close the replaced db so that its profiler stops flushing
+	h.Service.DBMetric.Swap(instance)
 	h.Service.Logics.Cache = cache
 	h.Service.Logics.EventC = ec
 
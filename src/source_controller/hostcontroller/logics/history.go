@@ -0,0 +1,83 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/history"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// RecordInstHistory record a version of the instance of the object changed by the action, the lost history
+// only fails the request in a transaction, as the changes have been committed otherwise.
+func (lgc *Logics) RecordInstHistory(ctx context.Context, header http.Header, objID, action string, inst map[string]interface{}) error {
+	op := history.NewOperation(header)
+	err := history.RecordInst(ctx, lgc.Instance, op, objID, action, []mapstr.MapStr{inst})
+	return history.Check(ctx, op, err)
+}
+
+// RecordModuleHostHistory record the changes of the module host relations like the instances
+func (lgc *Logics) RecordModuleHostHistory(ctx context.Context, header http.Header, action string, relations []map[string]interface{}) error {
+	op := history.NewOperation(header)
+	err := history.RecordModuleHost(ctx, lgc.Instance, op, action, relations)
+	return history.Check(ctx, op, err)
+}
+
+// GetModuleHostConfigAsOf returns the module host relations matching the condition as they were at the point of
+// time, which are the relations whose latest change no later than it is an add.
+func (lgc *Logics) GetModuleHostConfigAsOf(ctx context.Context, header http.Header, input *metadata.ModuleHostAsOfCondition) ([]metadata.ModuleHost, error) {
+	cond := mapstr.MapStr{"op_time": mapstr.MapStr{common.BKDBLTE: input.AsOf.Time}}
+	for key, val := range input.Condition {
+		cond.Set(key, mapstr.MapStr{common.BKDBIN: val})
+	}
+	cond = util.SetModOwner(cond, util.GetOwnerID(header))
+
+	pipeline := []mapstr.MapStr{
+		{"$match": cond},
+		{"$sort": mapstr.MapStr{"version": -1}},
+		{"$group": mapstr.MapStr{
+			"_id": mapstr.MapStr{
+				common.BKAppIDField:    "$" + common.BKAppIDField,
+				common.BKModuleIDField: "$" + common.BKModuleIDField,
+				common.BKHostIDField:   "$" + common.BKHostIDField,
+			},
+			"doc": mapstr.MapStr{"$first": "$$ROOT"},
+		}},
+		{"$match": mapstr.MapStr{"doc.action": metadata.ModuleHostHistoryActionAdd}},
+	}
+	changes := make([]struct {
+		Doc metadata.ModuleHostHistory `bson:"doc"`
+	}, 0)
+	if err := lgc.Instance.Table(common.BKTableNameModuleHostHistory).AggregateAll(ctx, pipeline, &changes); nil != err {
+		blog.Errorf("get the module host relations as of %v failed, err: %v, rid: %s", input.AsOf.Time, err, util.GetHTTPCCRequestID(header))
+		return nil, err
+	}
+
+	relations := make([]metadata.ModuleHost, 0, len(changes))
+	for _, change := range changes {
+		relations = append(relations, metadata.ModuleHost{
+			AppID:    change.Doc.AppID,
+			HostID:   change.Doc.HostID,
+			ModuleID: change.Doc.ModuleID,
+			SetID:    change.Doc.SetID,
+			OwnerID:  change.Doc.OwnerID,
+		})
+	}
+	return relations, nil
+}
@@ -0,0 +1,87 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/hostcontroller/logics"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+// moment returns the point of time between the changes, whose histories are recorded at the time of them
+func moment() metadata.Time {
+	time.Sleep(5 * time.Millisecond)
+	now := metadata.Time{Time: time.Now()}
+	time.Sleep(5 * time.Millisecond)
+	return now
+}
+
+func TestGetModuleHostConfigAsOf(t *testing.T) {
+	ctx := context.Background()
+	header := make(http.Header)
+	header.Set(common.BKHTTPOwnerID, "test_owner")
+	header.Set(common.BKHTTPCCRequestID, "test_req_id")
+	lgc := &logics.Logics{Instance: memory.New()}
+
+	relation := func(moduleID, hostID int64) map[string]interface{} {
+		return map[string]interface{}{
+			common.BKAppIDField:    int64(1),
+			common.BKSetIDField:    int64(2),
+			common.BKModuleIDField: moduleID,
+			common.BKHostIDField:   hostID,
+		}
+	}
+	beforeAdd := moment()
+	err := lgc.RecordModuleHostHistory(ctx, header, metadata.ModuleHostHistoryActionAdd, []map[string]interface{}{relation(3, 10), relation(3, 11)})
+	require.NoError(t, err)
+	afterAdd := moment()
+
+	// the host 10 is transferred from the module 3 to the module 4
+	err = lgc.RecordModuleHostHistory(ctx, header, metadata.ModuleHostHistoryActionDelete, []map[string]interface{}{relation(3, 10)})
+	require.NoError(t, err)
+	err = lgc.RecordModuleHostHistory(ctx, header, metadata.ModuleHostHistoryActionAdd, []map[string]interface{}{relation(4, 10)})
+	require.NoError(t, err)
+	afterTransfer := moment()
+
+	getModules := func(asOf metadata.Time, cond map[string][]int64) map[int64][]int64 {
+		relations, err := lgc.GetModuleHostConfigAsOf(ctx, header, &metadata.ModuleHostAsOfCondition{Condition: cond, AsOf: asOf})
+		require.NoError(t, err)
+		modules := make(map[int64][]int64)
+		for _, relation := range relations {
+			require.Equal(t, int64(1), relation.AppID)
+			require.Equal(t, "test_owner", relation.OwnerID)
+			modules[relation.HostID] = append(modules[relation.HostID], relation.ModuleID)
+		}
+		return modules
+	}
+
+	require.Empty(t, getModules(beforeAdd, nil))
+	require.Equal(t, map[int64][]int64{10: {3}, 11: {3}}, getModules(afterAdd, nil))
+	require.Equal(t, map[int64][]int64{10: {4}, 11: {3}}, getModules(afterTransfer, nil))
+	require.Equal(t, map[int64][]int64{10: {3}}, getModules(afterAdd, map[string][]int64{common.BKHostIDField: {10}}))
+	require.Equal(t, map[int64][]int64{11: {3}}, getModules(afterTransfer, map[string][]int64{common.BKModuleIDField: {3}}))
+
+	// the relations of the other owners are excluded
+	header.Set(common.BKHTTPOwnerID, "other_owner")
+	relations, err := lgc.GetModuleHostConfigAsOf(ctx, header, &metadata.ModuleHostAsOfCondition{AsOf: afterTransfer})
+	require.NoError(t, err)
+	require.Empty(t, relations)
+}
@@ -24,6 +24,7 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
 )
 
 func (lgc *Logics) LockHost(ctx context.Context, header http.Header, input *metadata.HostLockRequest) errors.CCError {
@@ -47,28 +48,17 @@ func (lgc *Logics) LockHost(ctx context.Context, header http.Header, input *meta
 		return defErr.Errorf(common.CCErrCommParamsIsInvalid, " ip_list["+strings.Join(diffIP, ",")+"]")
 	}
 
-	var insertDataArr []interface{}
 	ts := time.Now().UTC()
 	for _, ip := range input.IPS {
+		// the lock is created only if the host is not locked, the existing one is kept
 		conds := mapstr.MapStr{common.BKHostInnerIPField: ip, common.BKCloudIDField: input.CloudID}
-		cnt, err := lgc.Instance.Table(common.BKTableNameHostLock).Find(util.SetQueryOwner(conds, util.GetOwnerID(header))).Count(ctx)
-		if nil != err {
-			blog.Errorf("lcok host, query host lock from db error, error:%s, logID:%s", err.Error(), util.GetHTTPCCRequestID(header))
-			return defErr.Errorf(common.CCErrCommDBSelectFailed)
-		}
-		if 0 == cnt {
-			insertDataArr = append(insertDataArr, metadata.HostLockData{
-				User:       user,
-				IP:         ip,
-				CloudID:    input.CloudID,
-				CreateTime: ts,
-				OwnerID:    util.GetOwnerID(header),
-			})
-		}
-	}
-
-	if 0 < len(insertDataArr) {
-		err := lgc.Instance.Table(common.BKTableNameHostLock).Insert(ctx, insertDataArr)
+		lock := mapstr.MapStr{common.BKDBSetOnInsert: mapstr.MapStr{
+			"bk_user":              user,
+			common.CreateTimeField: ts,
+			common.BKOwnerIDField:  util.GetOwnerID(header),
+		}}
+		opts := dal.ModifyOptions{Upsert: true}
+		err := lgc.Instance.Table(common.BKTableNameHostLock).FindOneAndModify(ctx, util.SetQueryOwner(conds, util.GetOwnerID(header)), lock, opts, &metadata.HostLockData{})
 		if nil != err {
 			blog.Errorf("lcok host, save host lock to db error, error:%s, logID:%s", err.Error(), util.GetHTTPCCRequestID(header))
 			return defErr.Errorf(common.CCErrCommDBInsertFailed)
@@ -88,6 +88,9 @@ func (lgc *Logics) DelSingleHostModuleRelation(ctx context.Context, header http.
 		blog.Errorf("delete single host relation, but del module host relation failed, err: %v", delErr)
 		return false, delErr
 	}
+	if err := lgc.RecordModuleHostHistory(ctx, header, metadata.ModuleHostHistoryActionDelete, origindatas); err != nil {
+		return false, err
+	}
 
 	// send events
 	for _, origindata := range origindatas {
@@ -159,6 +162,10 @@ func (lgc *Logics) AddSingleHostModuleRelation(ctx context.Context, header http.
 		blog.Errorf("add single host module relation, add module host relation error: %v", err)
 		return false, err
 	}
+	err = lgc.RecordModuleHostHistory(ctx, header, metadata.ModuleHostHistoryActionAdd, []map[string]interface{}{moduleHostConfig})
+	if err != nil {
+		return false, err
+	}
 
 	srcevent := eventclient.NewEventWithHeader(header)
 	srcevent.EventType = metadata.EventTypeRelation
@@ -153,6 +153,12 @@ func (s *Service) AddHost(req *restful.Request, resp *restful.Response) {
 		}
 	}
 
+	if err := s.Logics.RecordInstHistory(ctx, pheader, objType, meta.InstHistoryActionCreate, originData); err != nil {
+		blog.Errorf("add host, but record the history error:%v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrHostCreateInst)})
+		return
+	}
+
 	resp.WriteEntity(meta.Response{
 		BaseResp: meta.SuccessBaseResp,
 		Data:     map[string]int64{idName: id},
@@ -450,6 +456,35 @@ func (s *Service) GetModulesHostConfig(req *restful.Request, resp *restful.Respo
 	})
 }
 
+// GetModulesHostConfigAsOf get the module host relations as they were at a point of time
+func (s *Service) GetModulesHostConfigAsOf(req *restful.Request, resp *restful.Response) {
+	ctx := util.GetDBContext(context.Background(), req.Request.Header)
+	pheader := req.Request.Header
+	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+
+	input := new(meta.ModuleHostAsOfCondition)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("get module host config as of a time failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if input.AsOf.IsZero() {
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsNeedSet, "as_of")})
+		return
+	}
+
+	result, err := s.Logics.GetModuleHostConfigAsOf(ctx, pheader, input)
+	if err != nil {
+		resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(meta.HostConfig{
+		BaseResp: meta.SuccessBaseResp,
+		Data:     result,
+	})
+}
+
 func (s *Service) TransferHostToDefaultModuleConfig(req *restful.Request, resp *restful.Response) {
 	header := req.Request.Header
 	defErr := s.Core.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
@@ -24,6 +24,7 @@ import (
 	"configcenter/src/common/types"
 	"configcenter/src/source_controller/hostcontroller/logics"
 	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/instrument"
 
 	"github.com/emicklei/go-restful"
 	redis "gopkg.in/redis.v5"
@@ -35,6 +36,8 @@ type Service struct {
 	EventC   eventclient.Client
 	Cache    *redis.Client
 	Logics   *logics.Logics
+	// DBMetric exports the latency histograms of the db operations
+	DBMetric instrument.Collector
 }
 
 func (s *Service) WebService() *restful.WebService {
@@ -64,6 +67,7 @@ func (s *Service) WebService() *restful.WebService {
 	ws.Route(ws.PUT("/meta/hosts/resource").To(s.MoveHost2ResourcePool))
 	ws.Route(ws.POST("/meta/hosts/assign").To(s.AssignHostToApp))
 	ws.Route(ws.POST("/meta/hosts/module/config/search").To(s.GetModulesHostConfig))
+	ws.Route(ws.POST("/meta/hosts/module/config/search/asof").To(s.GetModulesHostConfigAsOf))
 	ws.Route(ws.POST("/userapi").To(s.AddUserConfig))
 	ws.Route(ws.PUT("/userapi/{bk_biz_id}/{id}").To(s.UpdateUserConfig))
 	ws.Route(ws.DELETE("/userapi/{bk_biz_id}/{id}").To(s.DeleteUserConfig))
@@ -74,6 +78,7 @@ func (s *Service) WebService() *restful.WebService {
 	ws.Route(ws.POST("/usercustom/user/search/{bk_user}").To(s.GetUserCustomByUser))
 	ws.Route(ws.POST("/usercustom/default/search/{bk_user}").To(s.GetDefaultUserCustom))
 	ws.Route(ws.GET("/healthz").To(s.Healthz))
+	s.DBMetric.Register(ws, types.CC_MODULE_HOSTCONTROLLER)
 	ws.Route(ws.POST("/transfer/host/default/module").To(s.TransferHostToDefaultModuleConfig))
 
 	ws.Route(ws.POST("/host/lock").To(s.LockHost))
@@ -29,6 +29,7 @@ import (
 	"configcenter/src/common/version"
 	"configcenter/src/source_controller/objectcontroller/app/options"
 	"configcenter/src/source_controller/objectcontroller/service"
+	"configcenter/src/storage/dal/instrument"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
 	dalredis "configcenter/src/storage/dal/redis"
@@ -45,6 +46,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 
 	objCtr := new(ObjectController)
 	objCtr.Service = coreService
+	objCtr.process = instrument.ProcessName(types.CC_MODULE_OBJECTCONTROLLER, svrInfo)
 	input := &backbone.BackboneParameter{
 		ConfigUpdate: objCtr.onObjectConfigUpdate,
 		ConfigPath:   op.ServConf.ExConfig,
@@ -79,7 +81,8 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 
 type ObjectController struct {
 	*service.Service
-	Config *options.Config
+	Config  *options.Config
+	process string
 }
 
 func (h *ObjectController) onObjectConfigUpdate(previous, current cc.ProcessConfig) {
@@ -88,12 +91,29 @@ func (h *ObjectController) onObjectConfigUpdate(previous, current cc.ProcessConf
 		Redis: dalredis.ParseConfigFromKV("redis", current.ConfigMap),
 	}
 
-	instance, err := local.NewMgo(h.Config.Mongo.BuildURI(), time.Minute)
+	readOpt, err := h.Config.Mongo.GetReadOption()
+	if err != nil {
+		blog.Errorf("invalid mongo read preference config, err: %v", err)
+		return
+	}
+	db, err := local.NewMgoByConfig(h.Config.Mongo, time.Minute)
 	if err != nil {
 		blog.Errorf("new mongo client failed, err: %v", err)
 		return
 	}
+	if err = db.SetDefaultReadOption(readOpt); err != nil {
+		blog.Errorf("invalid mongo read preference config, err: %v", err)
+		return
+	}
+	// record the latencies and the slow queries of the db operations
+	instance := instrument.New(db, instrument.Options{
+		SlowThreshold: h.Config.Mongo.GetSlowQueryThreshold(),
+		Process:       h.process,
+		FlushInterval: time.Minute,
+	})
 	h.Service.Instance = instance
+	// close the replaced db so that its profiler stops flushing
+	h.Service.DBMetric.Swap(instance)
 
 	cache, err := dalredis.NewFromConfig(h.Config.Redis)
 	if err != nil {
@@ -0,0 +1,73 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/history"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+// recordInstHistory record the versions of the instances changed by the action, the instances of the common
+// object are recorded with their own object id. the lost history only fails the request in a transaction,
+// as the changes have been committed otherwise.
+func (cli *Service) recordInstHistory(ctx context.Context, db dal.RDB, header http.Header, objType, action string, insts []map[string]interface{}) error {
+	objIDs := make([]string, 0)
+	objInsts := make(map[string][]mapstr.MapStr)
+	for _, inst := range insts {
+		objID := objType
+		if common.BKInnerObjIDObject == objType {
+			objID = util.GetStrByInterface(inst[common.BKObjIDField])
+		}
+		if _, ok := objInsts[objID]; !ok {
+			objIDs = append(objIDs, objID)
+		}
+		objInsts[objID] = append(objInsts[objID], inst)
+	}
+
+	op := history.NewOperation(header)
+	for _, objID := range objIDs {
+		err := history.RecordInst(ctx, db, op, objID, action, objInsts[objID])
+		if err := history.Check(ctx, op, err); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordUpdatedInstHistory record the versions of the updated instances, which are read again by the ids of
+// their original data to get the whole data.
+func (cli *Service) recordUpdatedInstHistory(ctx context.Context, db dal.RDB, header http.Header, objType string, origins []map[string]interface{}) error {
+	if 0 == len(origins) {
+		return nil
+	}
+	idField := common.GetInstIDField(objType)
+	ids := make([]interface{}, 0, len(origins))
+	for _, origin := range origins {
+		ids = append(ids, origin[idField])
+	}
+
+	insts := make([]map[string]interface{}, 0)
+	cond := mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: ids}}
+	if err := db.Table(common.GetInstTableName(objType)).Find(cond).All(ctx, &insts); nil != err {
+		op := history.NewOperation(header)
+		return history.Check(ctx, op, err)
+	}
+	return cli.recordInstHistory(ctx, db, header, objType, metadata.InstHistoryActionUpdate, insts)
+}
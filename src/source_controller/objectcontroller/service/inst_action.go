@@ -81,6 +81,11 @@ func (cli *Service) DeleteInstObject(req *restful.Request, resp *restful.Respons
 		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.New(common.CCErrObjectDeleteInstFailed, err.Error())})
 		return
 	}
+	if err := cli.recordInstHistory(ctx, db, req.Request.Header, objType, meta.InstHistoryActionDelete, originDatas); err != nil {
+		blog.Errorf("delete object type:%s, but record the history error:%v", objType, err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.New(common.CCErrObjectDeleteInstFailed, err.Error())})
+		return
+	}
 
 	// send events
 	if len(originDatas) > 0 {
@@ -163,6 +168,11 @@ func (cli *Service) UpdateInstObject(req *restful.Request, resp *restful.Respons
 		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.New(common.CCErrObjectDBOpErrno, err.Error())})
 		return
 	}
+	if err := cli.recordUpdatedInstHistory(ctx, db, req.Request.Header, objType, originDatas); err != nil {
+		blog.Errorf("update object type:%s, but record the history error:%v", objType, err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.New(common.CCErrObjectDBOpErrno, err.Error())})
+		return
+	}
 
 	// record event
 	if len(originDatas) > 0 {
@@ -328,6 +338,12 @@ func (cli *Service) CreateInstObject(req *restful.Request, resp *restful.Respons
         }
 	}
 
+	if err := cli.recordInstHistory(ctx, db, req.Request.Header, objType, meta.InstHistoryActionCreate, []map[string]interface{}{origindata}); err != nil {
+		blog.Errorf("create object type:%s, but record the history error:%v", objType, err)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.New(common.CCErrObjectCreateInstFailed, err.Error())})
+		return
+	}
+
 	info := make(map[string]int)
 	info[idName] = id
 	resp.WriteEntity(meta.Response{BaseResp: meta.SuccessBaseResp, Data: info})
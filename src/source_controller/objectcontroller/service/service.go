@@ -23,6 +23,7 @@ import (
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
 	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/instrument"
 
 	"github.com/emicklei/go-restful"
 	"gopkg.in/redis.v5"
@@ -33,6 +34,8 @@ type Service struct {
 	Instance dal.RDB
 	Cache    *redis.Client
 	EventC   eventclient.Client
+	// DBMetric exports the latency histograms of the db operations
+	DBMetric instrument.Collector
 }
 
 func (s *Service) WebService() *restful.WebService {
@@ -125,6 +128,7 @@ func (s *Service) WebService() *restful.WebService {
 	ws.Route(ws.GET("/system/{flag}/{bk_supplier_account}").To(s.GetSystemFlag))
 
 	ws.Route(ws.GET("/healthz").To(s.Healthz))
+	s.DBMetric.Register(ws, types.CC_MODULE_OBJECTCONTROLLER)
 	return ws
 }
 
@@ -23,6 +23,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/eventclient"
+	"configcenter/src/common/history"
 	meta "configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/storage/dal"
@@ -103,6 +104,10 @@ func (cli *Service) delModuleConfigSet(ctx context.Context, db dal.RDB, input ma
 		blog.Errorf("fail to delSetConfigHost: %v", err)
 		return err
 	}
+	err = cli.recordModuleHostHistory(ctx, db, req.Request.Header, meta.ModuleHostHistoryActionDelete, oldContents)
+	if err != nil {
+		return err
+	}
 
 	//发送删除主机关系事件
 	for oldContent := range oldContents {
@@ -166,6 +171,10 @@ func (cli *Service) delModuleConfigSet(ctx context.Context, db dal.RDB, input ma
 			blog.Errorf("fail to exist relation host error: %v", err)
 			return err
 		}
+		err = cli.recordModuleHostHistory(ctx, db, req.Request.Header, meta.ModuleHostHistoryActionAdd, addIdleModuleDatas)
+		if err != nil {
+			return err
+		}
 		//推送新加到空闲机器的关系
 		for _, row := range addIdleModuleDatas {
 			srcevent := eventclient.NewEventWithHeader(req.Request.Header)
@@ -199,3 +208,19 @@ func (cli *Service) GetIdleModule(ctx context.Context, db dal.RDB, appID interfa
 	}
 	return result[common.BKSetIDField], result[common.BKModuleIDField], nil
 }
+
+// recordModuleHostHistory record the changes of the module host relations, the lost history only fails the
+// request in a transaction, as the changes have been committed otherwise.
+func (cli *Service) recordModuleHostHistory(ctx context.Context, db dal.RDB, header http.Header, action string, relations []interface{}) error {
+	relationMaps := make([]map[string]interface{}, 0, len(relations))
+	for _, relation := range relations {
+		switch item := relation.(type) {
+		case bson.M:
+			relationMaps = append(relationMaps, item)
+		case map[string]interface{}:
+			relationMaps = append(relationMaps, item)
+		}
+	}
+	op := history.NewOperation(header)
+	return history.Check(ctx, op, history.RecordModuleHost(ctx, db, op, action, relationMaps))
+}
@@ -29,6 +29,7 @@ import (
 	"configcenter/src/common/version"
 	"configcenter/src/source_controller/proccontroller/app/options"
 	"configcenter/src/source_controller/proccontroller/service"
+	"configcenter/src/storage/dal/instrument"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
 	dalredis "configcenter/src/storage/dal/redis"
@@ -44,6 +45,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 	coreService := new(service.ProctrlServer)
 	procCtr := new(ProcController)
 	procCtr.ProctrlServer = coreService
+	procCtr.process = instrument.ProcessName(types.CC_MODULE_PROCCONTROLLER, svrInfo)
 
 	input := &backbone.BackboneParameter{
 		ConfigUpdate: procCtr.onProcConfigUpdate,
@@ -80,7 +82,8 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 
 type ProcController struct {
 	*service.ProctrlServer
-	Config *options.Config
+	Config  *options.Config
+	process string
 }
 
 func (h *ProcController) onProcConfigUpdate(previous, current cc.ProcessConfig) {
@@ -90,12 +93,20 @@ func (h *ProcController) onProcConfigUpdate(previous, current cc.ProcessConfig)
 		Redis: dalredis.ParseConfigFromKV("redis", current.ConfigMap),
 	}
 
-	instance, err := local.NewMgo(h.Config.Mongo.BuildURI(), time.Minute)
+	db, err := local.NewMgoByConfig(h.Config.Mongo, time.Minute)
 	if err != nil {
 		blog.Errorf("new mongo client failed, err: %v", err)
 		return
 	}
+	// record the latencies and the slow queries of the db operations
+	instance := instrument.New(db, instrument.Options{
+		SlowThreshold: h.Config.Mongo.GetSlowQueryThreshold(),
+		Process:       h.process,
+		FlushInterval: time.Minute,
+	})
 	h.ProctrlServer.Instance = instance
+	// close the replaced db so that its profiler stops flushing
+	h.ProctrlServer.DBMetric.Swap(instance)
 
 	cache, err := dalredis.NewFromConfig(h.Config.Redis)
 	if err != nil {
@@ -22,6 +22,7 @@ import (
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
 	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/instrument"
 
 	"github.com/emicklei/go-restful"
 	"gopkg.in/redis.v5"
@@ -32,6 +33,8 @@ type ProctrlServer struct {
 	EventC   eventclient.Client
 	Instance dal.RDB
 	Cache    *redis.Client
+	// DBMetric exports the latency histograms of the db operations
+	DBMetric instrument.Collector
 }
 
 func (ps *ProctrlServer) WebService() *restful.WebService {
@@ -67,6 +70,7 @@ func (ps *ProctrlServer) WebService() *restful.WebService {
 	ws.Route(ws.POST("/operate/task/search").To(ps.SearchOperateTaskInfo))
 
 	ws.Route(ws.GET("/healthz").To(ps.Healthz))
+	ps.DBMetric.Register(ws, types.CC_MODULE_PROCCONTROLLER)
 
 	return ws
 }
@@ -0,0 +1,45 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dal
+
+import (
+	"configcenter/src/storage/types"
+)
+
+// BulkModel one operation of the bulk write
+type BulkModel struct {
+	Type   types.BulkWriteType
+	Filter Filter      // 更新或删除的条件
+	Doc    interface{} // 要插入的文档或者要更新的字段
+}
+
+// NewBulkInsert returns a model to insert the document
+func NewBulkInsert(doc interface{}) BulkModel {
+	return BulkModel{Type: types.BulkWriteInsert, Doc: doc}
+}
+
+// NewBulkUpdate returns a model to set the fields of all the documents matched
+func NewBulkUpdate(filter Filter, doc interface{}) BulkModel {
+	return BulkModel{Type: types.BulkWriteUpdate, Filter: filter, Doc: doc}
+}
+
+// NewBulkUpsert returns a model to set the fields of one document matched,
+// the document is inserted with the equality fields of the filter if nothing matched
+func NewBulkUpsert(filter Filter, doc interface{}) BulkModel {
+	return BulkModel{Type: types.BulkWriteUpsert, Filter: filter, Doc: doc}
+}
+
+// NewBulkDelete returns a model to delete all the documents matched
+func NewBulkDelete(filter Filter) BulkModel {
+	return BulkModel{Type: types.BulkWriteDelete, Filter: filter}
+}
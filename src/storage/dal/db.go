@@ -27,6 +27,10 @@ var (
 	ErrDocumentNotFound    = errors.New("document not found")
 	ErrNotImplemented      = errors.New("not implemented")
 	ErrDuplicated          = errors.New("duplicated")
+	// ErrStopIteration returned by the IterateHandler to stop the iteration without error
+	ErrStopIteration = errors.New("stop iteration")
+	// ErrBulkWrite returned with the result when some operations of the bulk write failed
+	ErrBulkWrite = errors.New("bulk write has failed operations")
 )
 
 // RDB rename the RDB into DB
@@ -68,15 +72,22 @@ type DB interface {
 type Table interface {
 	// Find 查询多个并反序列化到 Result
 	Find(filter Filter) Find
-	// Aggregate 聚合查询
+	// Aggregate 聚合查询, 读选项由 ctx 决定, 见 WithReadOption 和 WithSecondaryEligible
 	AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error
 	AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error
 	// Insert 插入数据, docs 可以为 单个数据 或者 多个数据
 	Insert(ctx context.Context, docs interface{}) error
 	// Update 更新数据
 	Update(ctx context.Context, filter Filter, doc interface{}) error
+	// Upsert 更新数据, 没有匹配的数据时插入, 插入的数据包含 filter 中的等值条件
+	Upsert(ctx context.Context, filter Filter, doc interface{}) error
+	// FindOneAndModify 原子地查找并修改一条数据, update 为带操作符($set, $inc 等)的更新文档,
+	// result 为修改前的数据, opts.ReturnNew 为 true 时为修改后的数据
+	FindOneAndModify(ctx context.Context, filter Filter, update interface{}, opts ModifyOptions, result interface{}) error
 	// Delete 删除数据
 	Delete(ctx context.Context, filter Filter) error
+	// BulkWrite 批量写, ordered 为 true 时遇到错误即停止, 失败的操作记录在结果的 Errors 中
+	BulkWrite(ctx context.Context, models []BulkModel, ordered bool) (*types.BulkWriteResult, error)
 
 	// CreateIndex 创建索引
 	CreateIndex(ctx context.Context, index Index) error
@@ -93,6 +104,12 @@ type Table interface {
 	DropColumn(ctx context.Context, field string) error
 }
 
+// Explainer is implemented by the DB which can explain the queries
+type Explainer interface {
+	// Explain 查询的执行计划
+	Explain(ctx context.Context, table string, filter Filter, sort string, result interface{}) error
+}
+
 // JoinOption defind join transaction options
 type JoinOption struct {
 	TxnID     string // 事务ID,uuid
@@ -116,7 +133,28 @@ type Find interface {
 	One(ctx context.Context, result interface{}) error
 	// Count 统计数量(非事务)
 	Count(ctx context.Context) (uint64, error)
+	// BatchSize 设置游标每批获取的数量
+	BatchSize(size uint64) Find
+	// Iterate 以游标方式逐个遍历查询结果，不会将所有结果加载到内存
+	Iterate(ctx context.Context, handler IterateHandler) error
+	// ReadPreference 设置读选项, 事务中的读操作总是读主节点
+	ReadPreference(opt ReadOption) Find
+}
+
+// IterateHandler is called for each document found, decode unmarshals the current document.
+// Return ErrStopIteration to stop the iteration early.
+type IterateHandler func(decode func(result interface{}) error) error
+
+// ModifyOptions FindOneAndModify 的选项
+type ModifyOptions struct {
+	// Upsert 没有匹配的数据时插入, 此时若 ReturnNew 为 false 则不修改 result
+	Upsert bool
+	// ReturnNew 返回修改后的数据
+	ReturnNew bool
 }
 
 // Index define the DB index struct
 type Index mongodb.Index
+
+// IndexKey define the key of the DB index
+type IndexKey = mongodb.IndexKey
@@ -0,0 +1,75 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instrument
+
+import (
+	"sync"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common/metric"
+	"configcenter/src/common/types"
+)
+
+// Collector collects the latency histograms of the instrumented db of a server,
+// the db is replaced when the db config of the server is updated.
+type Collector struct {
+	lock sync.RWMutex
+	db   *DB
+}
+
+// Swap replace the collected db, the replaced one is closed so that its profiler
+// stops flushing the slow queries.
+func (c *Collector) Swap(db *DB) {
+	c.lock.Lock()
+	old := c.db
+	c.db = db
+	c.lock.Unlock()
+
+	if old != nil && old != db {
+		old.Close()
+	}
+}
+
+// Collect implements metric.CollectInter, returns the latency histograms of the current db
+func (c *Collector) Collect() []metric.MetricInterf {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.db == nil {
+		return nil
+	}
+	return c.db.Profiler().Collect()
+}
+
+// Register add the route /metrics of the module into the web service, which
+// exports the latency histograms of the db operations.
+func (c *Collector) Register(ws *restful.WebService, module string) {
+	healthFunc := func() metric.HealthMeta {
+		c.lock.RLock()
+		defer c.lock.RUnlock()
+		if c.db == nil {
+			return metric.HealthMeta{Message: "db not connected"}
+		}
+		item := metric.NewHealthItem(types.CCFunctionalityMongo, c.db.Ping())
+		return metric.HealthMeta{IsHealthy: item.IsHealthy, Message: item.Message, Items: []metric.HealthItem{item}}
+	}
+	for _, action := range metric.NewMetricController(metric.Config{ModuleName: module}, healthFunc, metric.NewCollector("dal", c)) {
+		if action.Path != "/metrics" {
+			continue
+		}
+		handler := action.HandlerFunc
+		ws.Route(ws.GET(action.Path).To(func(req *restful.Request, resp *restful.Response) {
+			handler(resp.ResponseWriter, req.Request)
+		}))
+	}
+}
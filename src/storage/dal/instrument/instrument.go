@@ -0,0 +1,207 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instrument
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/types"
+)
+
+// DB the instrumented DB, which records the latencies and the slow queries of the table operations
+type DB struct {
+	dal.DB
+	profiler *Profiler
+	root     bool
+}
+
+// New returns the instrumented db
+func New(db dal.DB, opts Options) *DB {
+	return &DB{
+		DB:       db,
+		profiler: newProfiler(db, opts),
+		root:     true,
+	}
+}
+
+// Profiler returns the profiler shared by the db and its clones
+func (d *DB) Profiler() *Profiler {
+	return d.profiler
+}
+
+// Clone create a new DB instance
+func (d *DB) Clone() dal.DB {
+	return &DB{DB: d.DB.Clone(), profiler: d.profiler}
+}
+
+// StartTransaction 开启新事务
+func (d *DB) StartTransaction(ctx context.Context) (dal.DB, error) {
+	txn, err := d.DB.StartTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{DB: txn, profiler: d.profiler}, nil
+}
+
+// Table collection operation
+func (d *DB) Table(collection string) dal.Table {
+	return &Table{Table: d.DB.Table(collection), name: collection, profiler: d.profiler}
+}
+
+// Explain explain the query, the underlying db should implement dal.Explainer
+func (d *DB) Explain(ctx context.Context, table string, filter dal.Filter, sort string, result interface{}) error {
+	explainer, ok := d.DB.(dal.Explainer)
+	if !ok {
+		return dal.ErrNotImplemented
+	}
+	return explainer.Explain(ctx, table, filter, sort, result)
+}
+
+// Close close the db, the profiler stops when the original db closed
+func (d *DB) Close() error {
+	if d.root {
+		d.profiler.close()
+	}
+	return d.DB.Close()
+}
+
+// Table the instrumented table
+type Table struct {
+	dal.Table
+	name     string
+	profiler *Profiler
+}
+
+// Find 查询多个并反序列化到 Result
+func (t *Table) Find(filter dal.Filter) dal.Find {
+	return &Find{Find: t.Table.Find(filter), table: t, filter: filter}
+}
+
+// AggregateOne 聚合查询
+func (t *Table) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
+	defer t.profiler.observe(ctx, time.Now(), t.name, "aggregate", pipeline, "")
+	return t.Table.AggregateOne(ctx, pipeline, result)
+}
+
+// AggregateAll 聚合查询
+func (t *Table) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
+	defer t.profiler.observe(ctx, time.Now(), t.name, "aggregate", pipeline, "")
+	return t.Table.AggregateAll(ctx, pipeline, result)
+}
+
+// Insert 插入数据, docs 可以为 单个数据 或者 多个数据
+func (t *Table) Insert(ctx context.Context, docs interface{}) error {
+	defer t.profiler.observe(ctx, time.Now(), t.name, "insert", nil, "")
+	return t.Table.Insert(ctx, docs)
+}
+
+// Update 更新数据
+func (t *Table) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	defer t.profiler.observe(ctx, time.Now(), t.name, "update", filter, "")
+	return t.Table.Update(ctx, filter, doc)
+}
+
+// Upsert 更新数据, 没有匹配的数据时插入
+func (t *Table) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	defer t.profiler.observe(ctx, time.Now(), t.name, "upsert", filter, "")
+	return t.Table.Upsert(ctx, filter, doc)
+}
+
+// FindOneAndModify 原子地查找并修改一条数据
+func (t *Table) FindOneAndModify(ctx context.Context, filter dal.Filter, update interface{}, opts dal.ModifyOptions, result interface{}) error {
+	defer t.profiler.observe(ctx, time.Now(), t.name, "findAndModify", filter, "")
+	return t.Table.FindOneAndModify(ctx, filter, update, opts, result)
+}
+
+// Delete 删除数据
+func (t *Table) Delete(ctx context.Context, filter dal.Filter) error {
+	defer t.profiler.observe(ctx, time.Now(), t.name, "delete", filter, "")
+	return t.Table.Delete(ctx, filter)
+}
+
+// BulkWrite 批量写
+func (t *Table) BulkWrite(ctx context.Context, models []dal.BulkModel, ordered bool) (*types.BulkWriteResult, error) {
+	defer t.profiler.observe(ctx, time.Now(), t.name, "bulkWrite", nil, "")
+	return t.Table.BulkWrite(ctx, models, ordered)
+}
+
+// Find the instrumented find operation
+type Find struct {
+	dal.Find
+	table  *Table
+	filter dal.Filter
+	sort   string
+}
+
+// Fields 设置查询字段
+func (f *Find) Fields(fields ...string) dal.Find {
+	f.Find.Fields(fields...)
+	return f
+}
+
+// Sort 设置查询排序
+func (f *Find) Sort(sort string) dal.Find {
+	f.Find.Sort(sort)
+	f.sort = sort
+	return f
+}
+
+// Start 设置限制查询上标
+func (f *Find) Start(start uint64) dal.Find {
+	f.Find.Start(start)
+	return f
+}
+
+// Limit 设置查询数量
+func (f *Find) Limit(limit uint64) dal.Find {
+	f.Find.Limit(limit)
+	return f
+}
+
+// BatchSize 设置游标每批获取的数量
+func (f *Find) BatchSize(size uint64) dal.Find {
+	f.Find.BatchSize(size)
+	return f
+}
+
+// ReadPreference 设置读选项
+func (f *Find) ReadPreference(opt dal.ReadOption) dal.Find {
+	f.Find.ReadPreference(opt)
+	return f
+}
+
+// All 查询多个
+func (f *Find) All(ctx context.Context, result interface{}) error {
+	defer f.table.profiler.observe(ctx, time.Now(), f.table.name, "find", f.filter, f.sort)
+	return f.Find.All(ctx, result)
+}
+
+// One 查询单个
+func (f *Find) One(ctx context.Context, result interface{}) error {
+	defer f.table.profiler.observe(ctx, time.Now(), f.table.name, "find", f.filter, f.sort)
+	return f.Find.One(ctx, result)
+}
+
+// Count 统计数量
+func (f *Find) Count(ctx context.Context) (uint64, error) {
+	defer f.table.profiler.observe(ctx, time.Now(), f.table.name, "count", f.filter, "")
+	return f.Find.Count(ctx)
+}
+
+// Iterate 以游标方式逐个遍历查询结果, 记录的耗时包含 handler 的耗时
+func (f *Find) Iterate(ctx context.Context, handler dal.IterateHandler) error {
+	defer f.table.profiler.observe(ctx, time.Now(), f.table.name, "iterate", f.filter, f.sort)
+	return f.Find.Iterate(ctx, handler)
+}
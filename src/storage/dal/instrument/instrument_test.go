@@ -0,0 +1,115 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instrument_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/instrument"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowQuery(t *testing.T) {
+	ctx := context.Background()
+	db := instrument.New(memory.New(), instrument.Options{SlowThreshold: time.Nanosecond, Process: "test"})
+	defer db.Close()
+
+	table := db.Table("cc_HostBase")
+	require.NoError(t, table.Insert(ctx, []mapstr.MapStr{
+		{"bk_host_id": 1, "bk_cloud_id": 0},
+		{"bk_host_id": 2, "bk_cloud_id": 0},
+	}))
+
+	// the queries differing only in values have the same shape
+	result := make([]mapstr.MapStr, 0)
+	for _, hostID := range []int{1, 2} {
+		filter := mapstr.MapStr{"bk_cloud_id": 0, "bk_host_id": mapstr.MapStr{common.BKDBGT: hostID}}
+		require.NoError(t, table.Find(filter).Sort("bk_host_id").All(ctx, &result))
+	}
+
+	var finds []metadata.SlowQuery
+	for _, query := range db.Profiler().SlowQueries() {
+		if query.Operation == "find" {
+			finds = append(finds, query)
+		}
+	}
+	require.Len(t, finds, 1)
+	require.Equal(t, int64(2), finds[0].Count)
+	require.Equal(t, `{"bk_cloud_id":"?","bk_host_id":{"$gt":"?"}}`, finds[0].Filter)
+	require.Equal(t, []string{"bk_cloud_id"}, finds[0].EqualFields)
+	require.Equal(t, []string{"bk_host_id"}, finds[0].RangeFields)
+	require.Equal(t, "test", finds[0].Process)
+
+	// the slow queries are saved by shape and process
+	require.NoError(t, db.Profiler().Flush(ctx))
+	require.NoError(t, table.Find(mapstr.MapStr{"bk_host_id": 1}).All(ctx, &result))
+	require.NoError(t, db.Profiler().Flush(ctx))
+	saved := make([]metadata.SlowQuery, 0)
+	require.NoError(t, db.Table(common.BKTableNameSlowQuery).Find(mapstr.MapStr{"operation": "find"}).All(ctx, &saved))
+	require.Len(t, saved, 2)
+
+	// the latencies are exported by table
+	require.NotEmpty(t, db.Profiler().Collect())
+}
+
+func TestSuggestIndex(t *testing.T) {
+	equal, ranged := []string{"bk_cloud_id"}, []string{"bk_host_id"}
+	require.Equal(t, []string{"bk_cloud_id", "create_time", "bk_host_id"},
+		instrument.SuggestIndex(equal, ranged, "-create_time", [][]string{{"_id"}}))
+	require.Empty(t, instrument.SuggestIndex(equal, ranged, "", [][]string{{"bk_cloud_id", "bk_host_id", "create_time"}}))
+	require.Empty(t, instrument.SuggestIndex(nil, nil, "create_time", nil))
+
+	// the keys of the index are compared in order
+	require.Equal(t, []string{"bk_cloud_id", "bk_host_id"},
+		instrument.SuggestIndex(equal, ranged, "", [][]string{{"bk_host_id", "bk_cloud_id"}, {"bk_cloud_id"}}))
+	require.Equal(t, []string{"bk_cloud_id", "create_time", "bk_host_id"},
+		instrument.SuggestIndex(equal, ranged, "create_time", [][]string{{"bk_cloud_id", "bk_host_id", "create_time"}}))
+	require.Equal(t, []string{"bk_biz_id", "bk_cloud_id", "create_time"},
+		instrument.SuggestIndex([]string{"bk_biz_id", "bk_cloud_id"}, nil, "create_time", [][]string{{"bk_supplier_account", "bk_biz_id"}}))
+
+	// the equality fields are in any order, the sort fields keep the order
+	require.Empty(t, instrument.SuggestIndex([]string{"bk_biz_id", "bk_cloud_id"}, nil, "create_time,-bk_host_id",
+		[][]string{{"bk_cloud_id", "bk_biz_id", "create_time", "bk_host_id"}}))
+	require.NotEmpty(t, instrument.SuggestIndex([]string{"bk_biz_id", "bk_cloud_id"}, nil, "create_time,-bk_host_id",
+		[][]string{{"bk_cloud_id", "bk_biz_id", "bk_host_id", "create_time"}}))
+}
+
+func TestCollectorSwap(t *testing.T) {
+	ctx := context.Background()
+	collector := new(instrument.Collector)
+	require.Empty(t, collector.Collect())
+
+	oldMem := memory.New()
+	old := instrument.New(oldMem, instrument.Options{SlowThreshold: time.Nanosecond, Process: "test", FlushInterval: 50 * time.Millisecond})
+	collector.Swap(old)
+	require.NoError(t, old.Table("cc_HostBase").Insert(ctx, mapstr.MapStr{"bk_host_id": 1}))
+	require.NotEmpty(t, collector.Collect())
+
+	// the histograms of the replaced db are not exported, and its slow queries are not flushed any more
+	current := instrument.New(memory.New(), instrument.Options{SlowThreshold: time.Nanosecond, Process: "test"})
+	collector.Swap(current)
+	defer current.Close()
+	require.Empty(t, collector.Collect())
+
+	time.Sleep(150 * time.Millisecond)
+	saved := make([]metadata.SlowQuery, 0)
+	require.NoError(t, oldMem.Table(common.BKTableNameSlowQuery).Find(nil).All(ctx, &saved))
+	require.Empty(t, saved)
+}
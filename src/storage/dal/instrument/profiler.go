@@ -0,0 +1,208 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instrument
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/metric"
+	"configcenter/src/common/metric/plugin"
+	"configcenter/src/common/types"
+	"configcenter/src/storage/dal"
+)
+
+// maxShapes limits the slow query shapes kept in memory, the new shapes beyond are only logged
+const maxShapes = 1000
+
+// latencyBuckets the upper bounds of the latency histograms in milliseconds
+var latencyBuckets = []float64{1, 5, 10, 50, 100, 500, 1000, 5000}
+
+// ProcessName returns the name identifying the process in the recorded slow queries, such as "coreservice-127.0.0.1:50009"
+func ProcessName(module string, info *types.ServerInfo) string {
+	return fmt.Sprintf("%s-%s:%d", module, info.IP, info.Port)
+}
+
+// Options the options of the instrumentation
+type Options struct {
+	// SlowThreshold the queries lasting longer are logged and recorded, 0 means not to record
+	SlowThreshold time.Duration
+	// Process identifies the process in the recorded slow queries, such as "coreservice-127.0.0.1:50009"
+	Process string
+	// FlushInterval the interval to save the slow queries into the table cc_SlowQuery, 0 means not to save
+	FlushInterval time.Duration
+}
+
+// Profiler collects the latencies and the slow queries of the instrumented DB
+type Profiler struct {
+	opts Options
+	db   dal.DB
+
+	lock       sync.Mutex
+	histograms map[string]*plugin.HistogramMetric
+	shapes     map[string]*metadata.SlowQuery
+	dirty      map[string]bool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newProfiler(db dal.DB, opts Options) *Profiler {
+	p := &Profiler{
+		opts:       opts,
+		db:         db,
+		histograms: make(map[string]*plugin.HistogramMetric),
+		shapes:     make(map[string]*metadata.SlowQuery),
+		dirty:      make(map[string]bool),
+		stop:       make(chan struct{}),
+	}
+	if opts.SlowThreshold > 0 && opts.FlushInterval > 0 {
+		go p.loopFlush()
+	}
+	return p
+}
+
+// observe record the latency of an operation, and the query if it's slow
+func (p *Profiler) observe(ctx context.Context, start time.Time, table, operation string, filter interface{}, sort string) {
+	cost := time.Since(start)
+	p.histogram(table).Observe(float64(cost) / float64(time.Millisecond))
+	if p.opts.SlowThreshold <= 0 || cost < p.opts.SlowThreshold {
+		return
+	}
+
+	s := newShape(table, operation, filter, sort)
+	var rid string
+	if opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption); ok {
+		rid = opt.RequestID
+	}
+	callerAt := caller()
+	blog.Warnf("slow query, table: %s, operation: %s, filter: %s, sort: %s, cost: %v, caller: %s, rid: %s",
+		table, operation, s.filter, sort, cost, callerAt, rid)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	stat, ok := p.shapes[s.id]
+	if !ok {
+		if len(p.shapes) >= maxShapes {
+			return
+		}
+		stat = &metadata.SlowQuery{
+			ShapeID:     s.id,
+			Process:     p.opts.Process,
+			Table:       table,
+			Operation:   operation,
+			Filter:      s.filter,
+			Sort:        sort,
+			EqualFields: s.equalFields,
+			RangeFields: s.rangeFields,
+		}
+		p.shapes[s.id] = stat
+	}
+	millis := int64(cost / time.Millisecond)
+	stat.Count++
+	stat.TotalMillis += millis
+	if millis > stat.MaxMillis {
+		stat.MaxMillis = millis
+	}
+	stat.LastCaller = callerAt
+	stat.LastRid = rid
+	stat.LastTime = time.Now()
+	p.dirty[s.id] = true
+}
+
+func (p *Profiler) histogram(table string) *plugin.HistogramMetric {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	h, ok := p.histograms[table]
+	if !ok {
+		h = plugin.NewHistogramMetric("dal_latency_ms_"+table, "the latency of the db operations on "+table+" in milliseconds", latencyBuckets)
+		p.histograms[table] = h
+	}
+	return h
+}
+
+// SlowQueries returns the slow queries recorded by this process
+func (p *Profiler) SlowQueries() []metadata.SlowQuery {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	queries := make([]metadata.SlowQuery, 0, len(p.shapes))
+	for _, stat := range p.shapes {
+		queries = append(queries, *stat)
+	}
+	return queries
+}
+
+// Collect implements metric.CollectInter, returns the latency histograms of the tables
+func (p *Profiler) Collect() []metric.MetricInterf {
+	p.lock.Lock()
+	histograms := make([]*plugin.HistogramMetric, 0, len(p.histograms))
+	for _, h := range p.histograms {
+		histograms = append(histograms, h)
+	}
+	p.lock.Unlock()
+
+	metrics := make([]metric.MetricInterf, 0)
+	for _, h := range histograms {
+		metrics = append(metrics, h.Metrics()...)
+	}
+	return metrics
+}
+
+// Flush save the changed slow queries into the table cc_SlowQuery
+func (p *Profiler) Flush(ctx context.Context) error {
+	p.lock.Lock()
+	changed := make([]metadata.SlowQuery, 0, len(p.dirty))
+	for id := range p.dirty {
+		changed = append(changed, *p.shapes[id])
+	}
+	p.dirty = make(map[string]bool)
+	p.lock.Unlock()
+
+	for idx, stat := range changed {
+		filter := map[string]interface{}{"shape_id": stat.ShapeID, "process": stat.Process}
+		if err := p.db.Table(common.BKTableNameSlowQuery).Upsert(ctx, filter, stat); err != nil {
+			// save the unsaved ones next time
+			p.lock.Lock()
+			for _, unsaved := range changed[idx:] {
+				p.dirty[unsaved.ShapeID] = true
+			}
+			p.lock.Unlock()
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Profiler) loopFlush() {
+	ticker := time.NewTicker(p.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			if err := p.Flush(context.Background()); err != nil {
+				blog.Errorf("save the slow queries failed, err: %v", err)
+			}
+		}
+	}
+}
+
+func (p *Profiler) close() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
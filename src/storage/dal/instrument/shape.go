@@ -0,0 +1,209 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instrument
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// the operators which are served by the index as equality matches
+var equalOperators = map[string]bool{
+	"$eq": true,
+	"$in": true,
+}
+
+// shape the query with the filter values erased
+type shape struct {
+	id          string
+	table       string
+	operation   string
+	filter      string
+	sort        string
+	equalFields []string
+	rangeFields []string
+}
+
+func newShape(table, operation string, filter interface{}, sort string) shape {
+	s := shape{table: table, operation: operation, sort: sort}
+	if filter != nil {
+		var doc interface{}
+		if out, err := json.Marshal(filter); err == nil && json.Unmarshal(out, &doc) == nil {
+			erased := eraseValues(doc)
+			out, _ = json.Marshal(erased)
+			s.filter = string(out)
+			if m, ok := erased.(map[string]interface{}); ok {
+				equal, ranged := map[string]bool{}, map[string]bool{}
+				classifyFields(m, equal, ranged)
+				s.equalFields = sortedKeys(equal)
+				s.rangeFields = sortedKeys(ranged)
+			}
+		}
+	}
+
+	sum := md5.Sum([]byte(strings.Join([]string{s.table, s.operation, s.filter, s.sort}, "\x00")))
+	s.id = fmt.Sprintf("%x", sum)
+	return s
+}
+
+// eraseValues replace the values of the filter with "?", the structure of the filter is kept
+func eraseValues(doc interface{}) interface{} {
+	switch value := doc.(type) {
+	case map[string]interface{}:
+		erased := make(map[string]interface{}, len(value))
+		for key, sub := range value {
+			erased[key] = eraseValues(sub)
+		}
+		return erased
+	case []interface{}:
+		// the conditions of $and, $or are kept, the values of $in, $nin are erased as one
+		for _, item := range value {
+			if _, ok := item.(map[string]interface{}); !ok {
+				return "?"
+			}
+		}
+		erased := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			erased = append(erased, eraseValues(item))
+		}
+		return erased
+	default:
+		return "?"
+	}
+}
+
+// classifyFields collect the fields matched by equality and by range, the branches of $or, $nor are
+// ignored as they can't be served by one index
+func classifyFields(filter map[string]interface{}, equal, ranged map[string]bool) {
+	for key, value := range filter {
+		if key == "$and" {
+			items, _ := value.([]interface{})
+			for _, item := range items {
+				if sub, ok := item.(map[string]interface{}); ok {
+					classifyFields(sub, equal, ranged)
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(key, "$") {
+			continue
+		}
+
+		operators, ok := value.(map[string]interface{})
+		if !ok {
+			equal[key] = true
+			continue
+		}
+		isEqual := true
+		for op := range operators {
+			if !equalOperators[op] {
+				isEqual = false
+			}
+		}
+		if isEqual {
+			equal[key] = true
+		} else {
+			ranged[key] = true
+		}
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortFields returns the fields of the sort string, such as "bk_host_id,-create_time"
+func sortFields(sort string) []string {
+	fields := make([]string, 0)
+	for _, item := range strings.Split(sort, ",") {
+		item = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(item), "+-"))
+		if idx := strings.Index(item, ":"); idx >= 0 {
+			item = item[:idx]
+		}
+		if item != "" {
+			fields = append(fields, item)
+		}
+	}
+	return fields
+}
+
+// SuggestIndex returns the keys of the index suggested for the slow query, which follows the
+// equality, sort, range rule. empty is returned if the leading keys of one of the indexes are the
+// suggested ones in order, the equality fields and the range fields may be in any order among
+// themselves, while the sort fields keep their order.
+func SuggestIndex(equalFields, rangeFields []string, sort string, indexes [][]string) []string {
+	if len(equalFields) == 0 && len(rangeFields) == 0 {
+		return []string{}
+	}
+
+	// the group of the suggested fields, 0 for the equality, 1 for the sort and 2 for the range
+	suggested := make([]string, 0)
+	groups := map[string]int{}
+	for group, fields := range [][]string{equalFields, sortFields(sort), rangeFields} {
+		for _, field := range fields {
+			if _, exists := groups[field]; !exists {
+				groups[field] = group
+				suggested = append(suggested, field)
+			}
+		}
+	}
+
+	for _, keys := range indexes {
+		if hasLeadingKeys(keys, suggested, groups) {
+			return []string{}
+		}
+	}
+	return suggested
+}
+
+// hasLeadingKeys checks whether the leading keys of the index are the suggested ones
+func hasLeadingKeys(keys, suggested []string, groups map[string]int) bool {
+	if len(keys) < len(suggested) {
+		return false
+	}
+	for idx, field := range suggested {
+		group, exists := groups[keys[idx]]
+		if !exists || group != groups[field] {
+			return false
+		}
+		if group == 1 && keys[idx] != field {
+			return false
+		}
+	}
+	return true
+}
+
+// caller returns the first caller outside the storage packages
+func caller() string {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.Function, "configcenter/src/storage/") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,157 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// IterateBatch iterate the documents found batch by batch, result must be a pointer to a slice,
+// it is filled with at most size documents before each call of the handler.
+// Return ErrStopIteration from the handler to stop the iteration early.
+func IterateBatch(ctx context.Context, find Find, size uint64, result interface{}, handler func() error) error {
+	resultv := reflect.ValueOf(result)
+	if resultv.Kind() != reflect.Ptr || resultv.Elem().Kind() != reflect.Slice {
+		return errors.New("result argument must be a slice address")
+	}
+	if size == 0 {
+		return errors.New("batch size must be greater than 0")
+	}
+
+	slicev := resultv.Elem()
+	elemt := slicev.Type().Elem()
+	batch := reflect.MakeSlice(slicev.Type(), 0, int(size))
+	flush := func() error {
+		slicev.Set(batch)
+		// the handler may keep the result, so never reuse the backing array
+		batch = reflect.MakeSlice(slicev.Type(), 0, int(size))
+		return handler()
+	}
+
+	err := find.BatchSize(size).Iterate(ctx, func(decode func(result interface{}) error) error {
+		elemp := reflect.New(elemt)
+		if err := decode(elemp.Interface()); err != nil {
+			return err
+		}
+		batch = reflect.Append(batch, elemp.Elem())
+		if uint64(batch.Len()) < size {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return err
+	}
+	if batch.Len() > 0 {
+		if err := flush(); err != nil && err != ErrStopIteration {
+			return err
+		}
+	}
+	return nil
+}
+
+// Keyset keyset (seek) pagination on an unique and sortable field such as bk_host_id.
+// unlike Start/Limit, the cost of a page does not grow with the page number,
+// and the pages stay stable when the documents ahead are added or removed.
+type Keyset struct {
+	Field string      // the pagination field, nested field is not supported
+	After interface{} // the field value of the last document of the previous page, nil for the first page
+}
+
+// Filter returns the filter of the next page
+func (k *Keyset) Filter(filter Filter) Filter {
+	if k.After == nil {
+		return filter
+	}
+	after := map[string]interface{}{k.Field: map[string]interface{}{"$gt": k.After}}
+	if filter == nil {
+		return after
+	}
+	return map[string]interface{}{"$and": []interface{}{filter, after}}
+}
+
+// Next find the next page into result which must be a pointer to a slice,
+// it returns the documents count of the page, 0 means there is no more page.
+func (k *Keyset) Next(ctx context.Context, table Table, filter Filter, limit uint64, result interface{}, fields ...string) (int, error) {
+	find := table.Find(k.Filter(filter)).Sort(k.Field).Limit(limit)
+	if len(fields) > 0 {
+		find = find.Fields(append(fields[:len(fields):len(fields)], k.Field)...)
+	}
+	if err := find.All(ctx, result); err != nil {
+		return 0, err
+	}
+
+	slicev := reflect.Indirect(reflect.ValueOf(result))
+	if slicev.Kind() != reflect.Slice {
+		return 0, errors.New("result argument must be a slice address")
+	}
+	if slicev.Len() == 0 {
+		return 0, nil
+	}
+	after, err := fieldValue(slicev.Index(slicev.Len()-1).Interface(), k.Field)
+	if err != nil {
+		return 0, err
+	}
+	k.After = after
+	return slicev.Len(), nil
+}
+
+// IterateKeyset fetch the documents page by page ordered by the field, result must be a pointer to a slice,
+// it is filled with a page before each call of the handler.
+// Return ErrStopIteration from the handler to stop the iteration early.
+func IterateKeyset(ctx context.Context, table Table, filter Filter, field string, pageSize uint64, result interface{}, handler func() error) error {
+	if pageSize == 0 {
+		return errors.New("page size must be greater than 0")
+	}
+	keyset := Keyset{Field: field}
+	for {
+		count, err := keyset.Next(ctx, table, filter, pageSize, result)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if err = handler(); err == ErrStopIteration {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if uint64(count) < pageSize {
+			return nil
+		}
+	}
+}
+
+// fieldValue returns the field value of the document which can be a map or a struct with bson tags
+func fieldValue(doc interface{}, field string) (interface{}, error) {
+	out, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	m := bson.M{}
+	if err = bson.Unmarshal(out, &m); err != nil {
+		return nil, err
+	}
+	value, ok := m[field]
+	if !ok || value == nil {
+		return nil, fmt.Errorf("keyset field %s not found in the document", field)
+	}
+	return value, nil
+}
@@ -0,0 +1,100 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dal_test
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+type host struct {
+	HostID  int64  `bson:"bk_host_id"`
+	CloudID int64  `bson:"bk_cloud_id"`
+	InnerIP string `bson:"bk_host_innerip"`
+}
+
+func newHostTable(t *testing.T, count int) dal.Table {
+	hosts := make([]host, 0, count)
+	for i := count; i > 0; i-- {
+		hosts = append(hosts, host{HostID: int64(i), CloudID: int64(i % 2)})
+	}
+	table := memory.New().Table("cc_HostBase")
+	require.NoError(t, table.Insert(context.Background(), hosts))
+	return table
+}
+
+func TestIterateBatch(t *testing.T) {
+	table := newHostTable(t, 7)
+
+	batches := [][]host{}
+	result := make([]host, 0)
+	err := dal.IterateBatch(context.Background(), table.Find(nil).Sort("bk_host_id"), 3, &result, func() error {
+		batches = append(batches, result)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, batches, 3)
+	require.Len(t, batches[0], 3)
+	require.Len(t, batches[2], 1)
+	require.Equal(t, int64(1), batches[0][0].HostID)
+	require.Equal(t, int64(7), batches[2][0].HostID)
+
+	count := 0
+	err = dal.IterateBatch(context.Background(), table.Find(nil), 2, &result, func() error {
+		count++
+		return dal.ErrStopIteration
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	err = dal.IterateBatch(context.Background(), table.Find(nil), 2, result, func() error { return nil })
+	require.Error(t, err)
+}
+
+func TestIterateKeyset(t *testing.T) {
+	table := newHostTable(t, 10)
+	filter := mapstr.MapStr{"bk_cloud_id": 1}
+
+	ids := []int64{}
+	pages := 0
+	result := make([]mapstr.MapStr, 0)
+	err := dal.IterateKeyset(context.Background(), table, filter, "bk_host_id", 2, &result, func() error {
+		pages++
+		for _, h := range result {
+			id, err := h.Int64("bk_host_id")
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 3, 5, 7, 9}, ids)
+	require.Equal(t, 3, pages)
+
+	keyset := dal.Keyset{Field: "bk_host_id"}
+	hosts := make([]host, 0)
+	count, err := keyset.Next(context.Background(), table, nil, 4, &hosts)
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+	require.Equal(t, int64(4), keyset.After)
+	count, err = keyset.Next(context.Background(), table, nil, 4, &hosts)
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+	require.Equal(t, int64(5), hosts[0].HostID)
+}
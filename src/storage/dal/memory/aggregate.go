@@ -0,0 +1,345 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/universalsql/mongo"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// aggregate run the pipeline on the documents of the collection, it supports the
+// stages $match $group $project $unwind $sort $skip $limit $count, and the
+// accumulators $sum $avg $min $max $first $last $push $addToSet of $group.
+func (c *Collection) aggregate(pipeline interface{}) ([]map[string]interface{}, error) {
+	// decoded in order, so that the fields of $sort keep the order like mongodb
+	out, err := bson.Marshal(bson.M{"v": pipeline})
+	if err != nil {
+		return nil, fmt.Errorf("invalid pipeline %#v: %v", pipeline, err)
+	}
+	raw := struct {
+		Stages []bson.D `bson:"v"`
+	}{}
+	if err := bson.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("the pipeline should be an array of the documents")
+	}
+
+	c.store.lock.RLock()
+	docs, err := c.matchDocs(nil)
+	if err == nil {
+		for i := range docs {
+			docs[i] = copyValue(docs[i]).(map[string]interface{})
+		}
+	}
+	c.store.lock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stage := range raw.Stages {
+		if len(stage) != 1 {
+			return nil, fmt.Errorf("the stage %#v should be a document with one field", stage)
+		}
+		operator, spec := stage[0].Name, stage[0].Value
+		if operator == "$sort" {
+			docs, err = sortStage(spec, docs)
+		} else {
+			docs, err = runStage(operator, normalize(spec), docs)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+// sortStage sort the documents by the fields in the order of the document, the order of the fields
+// in a map is random, so the multiple fields should be given by a struct or a bson.D.
+func sortStage(spec interface{}, docs []map[string]interface{}) ([]map[string]interface{}, error) {
+	fields, ok := spec.(bson.D)
+	if !ok || len(fields) == 0 {
+		return nil, fmt.Errorf("the value of $sort should be a document")
+	}
+	keys := make([]sortKey, 0, len(fields))
+	for _, field := range fields {
+		o, _ := toFloat(field.Value)
+		keys = append(keys, sortKey{field: field.Name, descending: o < 0})
+	}
+	sortDocs(docs, keys)
+	return docs, nil
+}
+
+func runStage(operator string, spec interface{}, docs []map[string]interface{}) ([]map[string]interface{}, error) {
+	switch operator {
+	case "$match":
+		cond, ok := spec.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the value of %s should be a document", operator)
+		}
+		results := make([]map[string]interface{}, 0)
+		for _, doc := range docs {
+			matched, err := mongo.Match(mapstr.MapStr(cond), mapstr.MapStr(doc))
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				results = append(results, doc)
+			}
+		}
+		return results, nil
+
+	case "$group":
+		group, ok := spec.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the value of %s should be a document", operator)
+		}
+		return groupDocs(group, docs)
+
+	case "$project":
+		fields, ok := spec.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the value of %s should be a document", operator)
+		}
+		return projectDocs(fields, docs), nil
+
+	case "$unwind":
+		path, ok := spec.(string)
+		if opts, isDoc := spec.(map[string]interface{}); isDoc {
+			path, ok = opts["path"].(string)
+		}
+		if !ok || !strings.HasPrefix(path, "$") {
+			return nil, fmt.Errorf("the path of %s should be a field path", operator)
+		}
+		field := strings.TrimPrefix(path, "$")
+		results := make([]map[string]interface{}, 0)
+		for _, doc := range docs {
+			items, ok := getPath(doc, field).([]interface{})
+			if !ok {
+				if getPath(doc, field) != nil {
+					results = append(results, doc)
+				}
+				continue
+			}
+			for _, item := range items {
+				result := copyValue(doc).(map[string]interface{})
+				setPath(result, field, item)
+				results = append(results, result)
+			}
+		}
+		return results, nil
+
+	case "$skip", "$limit":
+		n, ok := toFloat(spec)
+		if !ok || n < 0 {
+			return nil, fmt.Errorf("the value of %s should be a non-negative number", operator)
+		}
+		count := int(n)
+		if count > len(docs) {
+			count = len(docs)
+		}
+		if operator == "$skip" {
+			return docs[count:], nil
+		}
+		return docs[:count], nil
+
+	case "$count":
+		field, ok := spec.(string)
+		if !ok || field == "" {
+			return nil, fmt.Errorf("the value of %s should be a field name", operator)
+		}
+		if len(docs) == 0 {
+			return []map[string]interface{}{}, nil
+		}
+		return []map[string]interface{}{{field: len(docs)}}, nil
+	}
+	return nil, fmt.Errorf("not support the aggregate stage '%s'", operator)
+}
+
+// evaluate returns the value of the expression on the document, the "$field"
+// expression refers to the field of the document, and "$$ROOT" refers to the document.
+func evaluate(expr interface{}, doc map[string]interface{}) interface{} {
+	switch v := expr.(type) {
+	case string:
+		if v == "$$ROOT" {
+			return copyValue(doc)
+		}
+		if strings.HasPrefix(v, "$") {
+			return getPath(doc, strings.TrimPrefix(v, "$"))
+		}
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = evaluate(item, doc)
+		}
+		return result
+	}
+	return expr
+}
+
+type groupState struct {
+	doc    map[string]interface{}
+	counts map[string]int
+}
+
+func groupDocs(spec map[string]interface{}, docs []map[string]interface{}) ([]map[string]interface{}, error) {
+	idExpr, ok := spec[idField]
+	if !ok {
+		return nil, fmt.Errorf("the group should have the '_id' field")
+	}
+
+	accumulators := map[string]map[string]interface{}{}
+	for field, acc := range spec {
+		if field == idField {
+			continue
+		}
+		m, ok := acc.(map[string]interface{})
+		if !ok || len(m) != 1 {
+			return nil, fmt.Errorf("the accumulator of field '%s' should be a document with one field", field)
+		}
+		accumulators[field] = m
+	}
+
+	groups := make([]*groupState, 0)
+	for _, doc := range docs {
+		id := evaluate(idExpr, doc)
+		var group *groupState
+		for _, g := range groups {
+			if compareValues(g.doc[idField], id) == 0 {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = &groupState{doc: map[string]interface{}{idField: id}, counts: map[string]int{}}
+			groups = append(groups, group)
+		}
+
+		for field, acc := range accumulators {
+			for operator, expr := range acc {
+				if err := accumulate(group, field, operator, evaluate(expr, doc)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	results := make([]map[string]interface{}, 0, len(groups))
+	for _, group := range groups {
+		for field, acc := range accumulators {
+			if _, ok := acc["$avg"]; ok {
+				if count := group.counts[field]; count > 0 {
+					sum, _ := toFloat(group.doc[field])
+					group.doc[field] = sum / float64(count)
+				}
+			}
+		}
+		results = append(results, group.doc)
+	}
+	return results, nil
+}
+
+func accumulate(group *groupState, field, operator string, val interface{}) error {
+	current, exists := group.doc[field]
+	switch operator {
+	case "$sum", "$avg":
+		f, ok := toFloat(val)
+		if !ok {
+			// the non-numeric values are ignored
+			if !exists {
+				group.doc[field] = 0
+			}
+			return nil
+		}
+		sum, _ := toFloat(current)
+		sum += f
+		if sum == float64(int64(sum)) && operator == "$sum" {
+			group.doc[field] = int64(sum)
+		} else {
+			group.doc[field] = sum
+		}
+		group.counts[field]++
+	case "$min", "$max":
+		if val == nil {
+			return nil
+		}
+		result := compareValues(val, current)
+		if !exists || current == nil || (operator == "$min" && result < 0) || (operator == "$max" && result > 0) {
+			group.doc[field] = val
+		}
+	case "$first":
+		if !exists {
+			group.doc[field] = val
+		}
+	case "$last":
+		group.doc[field] = val
+	case "$push":
+		items, _ := current.([]interface{})
+		group.doc[field] = append(items, val)
+	case "$addToSet":
+		items, _ := current.([]interface{})
+		for _, item := range items {
+			if compareValues(item, val) == 0 {
+				return nil
+			}
+		}
+		group.doc[field] = append(items, val)
+	default:
+		return fmt.Errorf("not support the group accumulator '%s'", operator)
+	}
+	return nil
+}
+
+func projectDocs(fields map[string]interface{}, docs []map[string]interface{}) []map[string]interface{} {
+	include := false
+	for field, val := range fields {
+		if field == idField {
+			continue
+		}
+		if f, ok := toFloat(val); !ok || f != 0 {
+			include = include || (val != false)
+		}
+	}
+
+	results := make([]map[string]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		var result map[string]interface{}
+		if include {
+			result = map[string]interface{}{}
+			if id, ok := doc[idField]; ok {
+				result[idField] = id
+			}
+		} else {
+			result = copyValue(doc).(map[string]interface{})
+		}
+
+		for field, val := range fields {
+			f, isNumber := toFloat(val)
+			switch {
+			case val == false || (isNumber && f == 0):
+				unsetPath(result, field)
+			case val == true || isNumber:
+				if v := getPath(doc, field); v != nil {
+					setPath(result, field, copyValue(v))
+				}
+			default:
+				setPath(result, field, copyValue(evaluate(val, doc)))
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,137 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/types"
+)
+
+// duplicateKeyCode the mongodb error code of the duplicated key
+const duplicateKeyCode = 11000
+
+// BulkWrite 批量写, ordered 为 true 时遇到错误即停止
+// the documents matched are always counted as modified
+func (c *Collection) BulkWrite(ctx context.Context, models []dal.BulkModel, ordered bool) (*types.BulkWriteResult, error) {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	txn, err := c.txn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.BulkWriteResult{}
+	for index, model := range models {
+		if err := c.bulkWriteOne(txn, model, result); err != nil {
+			writeErr := types.BulkWriteError{Index: index, Message: err.Error()}
+			if err == dal.ErrDuplicated {
+				writeErr.Code = duplicateKeyCode
+			}
+			result.Errors = append(result.Errors, writeErr)
+			if ordered {
+				break
+			}
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result, dal.ErrBulkWrite
+	}
+	return result, nil
+}
+
+// bulkWriteOne the store lock should be held by the caller
+func (c *Collection) bulkWriteOne(txn *txn, model dal.BulkModel, result *types.BulkWriteResult) error {
+	switch model.Type {
+	case types.BulkWriteInsert:
+		if err := c.insertDocs(txn, []interface{}{model.Doc}); err != nil {
+			return err
+		}
+		result.InsertedCount++
+	case types.BulkWriteUpdate, types.BulkWriteUpsert:
+		data, err := toDocument(model.Doc)
+		if err != nil {
+			return err
+		}
+		limit := 0
+		if model.Type == types.BulkWriteUpsert {
+			limit = 1
+		}
+		matched, err := c.modifyDocs(txn, model.Filter, limit, func(doc map[string]interface{}) {
+			for key, val := range data {
+				setPath(doc, key, copyValue(val))
+			}
+		})
+		if err != nil {
+			return err
+		}
+		result.MatchedCount += uint64(matched)
+		result.ModifiedCount += uint64(matched)
+		if matched > 0 || model.Type != types.BulkWriteUpsert {
+			return nil
+		}
+		doc, err := upsertDocument(model.Filter, data)
+		if err != nil {
+			return err
+		}
+		if err := c.insertDocs(txn, []interface{}{doc}); err != nil {
+			return err
+		}
+		result.UpsertedCount++
+	case types.BulkWriteDelete:
+		deleted, err := c.deleteDocs(txn, model.Filter)
+		if err != nil {
+			return err
+		}
+		result.DeletedCount += uint64(deleted)
+	default:
+		return fmt.Errorf("unknown bulk write type %s", model.Type)
+	}
+	return nil
+}
+
+// upsertDocument returns the document to insert when nothing matched the filter of the upsert,
+// it's made of the equality fields of the filter and the fields to set
+func upsertDocument(filter dal.Filter, data map[string]interface{}) (map[string]interface{}, error) {
+	cond, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]interface{}{}
+	for key, val := range cond {
+		if strings.HasPrefix(key, "$") || isOperator(val) {
+			continue
+		}
+		setPath(doc, key, copyValue(val))
+	}
+	for key, val := range data {
+		setPath(doc, key, copyValue(val))
+	}
+	return doc, nil
+}
+
+func isOperator(val interface{}) bool {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for key := range m {
+		if strings.HasPrefix(key, "$") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,615 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+const idField = "_id"
+
+// table the documents and indexes of a collection
+type table struct {
+	docs    []map[string]interface{}
+	indexes []dal.Index
+}
+
+func newTable() *table {
+	return &table{
+		indexes: []dal.Index{{Name: "_id_", Keys: []dal.IndexKey{{Key: idField, Direction: 1}}, Unique: true}},
+	}
+}
+
+// indexOf returns the position of the document with the id, returns -1 if not exists
+func (t *table) indexOf(id interface{}) int {
+	for i, doc := range t.docs {
+		if compareValues(doc[idField], id) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkUnique check whether the document conflicts with the others on the unique indexes
+func (t *table) checkUnique(doc map[string]interface{}) error {
+	for _, index := range t.indexes {
+		if !index.Unique {
+			continue
+		}
+		for _, exist := range t.docs {
+			if compareValues(exist[idField], doc[idField]) == 0 {
+				continue
+			}
+			duplicated := true
+			for _, key := range index.Keys {
+				if compareValues(getPath(exist, key.Key), getPath(doc, key.Key)) != 0 {
+					duplicated = false
+					break
+				}
+			}
+			if duplicated {
+				return dal.ErrDuplicated
+			}
+		}
+	}
+	return nil
+}
+
+// Collection implement dal.Table interface
+type Collection struct {
+	collName string // 集合名
+	*DB
+}
+
+// table returns the table of the collection, the table is created if not exists
+// like mongodb does, the store lock should be held by the caller.
+func (c *Collection) table() *table {
+	t, ok := c.store.tables[c.collName]
+	if !ok {
+		t = newTable()
+		c.store.tables[c.collName] = t
+	}
+	return t
+}
+
+// Find 查询多个并反序列化到 Result
+func (c *Collection) Find(filter dal.Filter) dal.Find {
+	return &Find{Collection: c, filter: filter, projection: map[string]bool{idField: false}}
+}
+
+// Find define a find operation
+type Find struct {
+	*Collection
+	projection map[string]bool
+	filter     dal.Filter
+	start      uint64
+	limit      uint64
+	sort       []string
+}
+
+// Fields 查询字段
+func (f *Find) Fields(fields ...string) dal.Find {
+	for _, field := range fields {
+		if len(field) <= 0 {
+			continue
+		}
+		f.projection[field] = true
+	}
+	return f
+}
+
+// Sort 查询排序
+func (f *Find) Sort(sort string) dal.Find {
+	if sort != "" {
+		f.sort = strings.Split(sort, ",")
+	}
+	return f
+}
+
+// Start 查询上标
+func (f *Find) Start(start uint64) dal.Find {
+	f.start = start
+	return f
+}
+
+// Limit 查询限制
+func (f *Find) Limit(limit uint64) dal.Find {
+	f.limit = limit
+	return f
+}
+
+// All 查询多个
+func (f *Find) All(ctx context.Context, result interface{}) error {
+	docs, err := f.find()
+	if err != nil {
+		return err
+	}
+	return decodeAll(docs, result)
+}
+
+// One 查询一个
+func (f *Find) One(ctx context.Context, result interface{}) error {
+	f.limit = 1
+	docs, err := f.find()
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return dal.ErrDocumentNotFound
+	}
+	return decodeOne(docs[0], result)
+}
+
+// Count 统计数量(非事务)
+func (f *Find) Count(ctx context.Context) (uint64, error) {
+	f.store.lock.RLock()
+	defer f.store.lock.RUnlock()
+	docs, err := f.match()
+	return uint64(len(docs)), err
+}
+
+// BatchSize 游标每批获取的数量, 内存实现无需分批
+func (f *Find) BatchSize(size uint64) dal.Find {
+	return f
+}
+
+// ReadPreference 内存数据库只有一个节点, 读选项不生效
+func (f *Find) ReadPreference(opt dal.ReadOption) dal.Find {
+	return f
+}
+
+// Iterate 逐个遍历查询结果, 遍历的是查询时的快照, handler 中可以修改集合
+func (f *Find) Iterate(ctx context.Context, handler dal.IterateHandler) error {
+	docs, err := f.find()
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		doc := doc
+		err := handler(func(result interface{}) error {
+			return decodeOne(doc, result)
+		})
+		if err == dal.ErrStopIteration {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Find) find() ([]map[string]interface{}, error) {
+	f.store.lock.RLock()
+	defer f.store.lock.RUnlock()
+
+	docs, err := f.match()
+	if err != nil {
+		return nil, err
+	}
+	sortDocs(docs, f.sortKeys())
+
+	if f.start >= uint64(len(docs)) {
+		return nil, nil
+	}
+	docs = docs[f.start:]
+	if f.limit > 0 && f.limit < uint64(len(docs)) {
+		docs = docs[:f.limit]
+	}
+
+	results := make([]map[string]interface{}, len(docs))
+	for i, doc := range docs {
+		results[i] = project(doc, f.projection)
+	}
+	return results, nil
+}
+
+// match returns the documents matching the filter, the store lock should be held by the caller
+func (f *Find) match() ([]map[string]interface{}, error) {
+	return f.matchDocs(f.filter)
+}
+
+// sortKeys converts the sort fields like "-create_time" into the sort keys
+func (f *Find) sortKeys() []sortKey {
+	keys := make([]sortKey, 0)
+	for _, field := range f.sort {
+		field = strings.TrimSpace(field)
+		switch {
+		case strings.HasPrefix(field, "-"):
+			keys = append(keys, sortKey{field: strings.TrimPrefix(field, "-"), descending: true})
+		case field != "":
+			keys = append(keys, sortKey{field: strings.TrimPrefix(field, "+")})
+		}
+	}
+	return keys
+}
+
+// matchDocs returns the documents matching the filter, the store lock should be held by the caller
+func (c *Collection) matchDocs(filter dal.Filter) ([]map[string]interface{}, error) {
+	cond, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := c.store.tables[c.collName]
+	if !ok {
+		return nil, nil
+	}
+	docs := make([]map[string]interface{}, 0)
+	for _, doc := range t.docs {
+		matched, err := mongo.Match(mapstr.MapStr(cond), mapstr.MapStr(doc))
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// Insert 插入数据, docs 可以为 单个数据 或者 多个数据
+func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	txn, err := c.txn(ctx)
+	if err != nil {
+		return err
+	}
+	return c.insertDocs(txn, docs)
+}
+
+// insertDocs the store lock should be held by the caller
+func (c *Collection) insertDocs(txn *txn, docs interface{}) error {
+	newDocs := make([]map[string]interface{}, 0)
+	for _, item := range util.ConverToInterfaceSlice(docs) {
+		doc, err := toDocument(item)
+		if err != nil {
+			return err
+		}
+		if _, ok := doc[idField]; !ok {
+			doc[idField] = bson.NewObjectId()
+		}
+		newDocs = append(newDocs, doc)
+	}
+
+	t := c.table()
+	for _, doc := range newDocs {
+		if err := t.checkUnique(doc); err != nil {
+			return err
+		}
+		if t.indexOf(doc[idField]) >= 0 {
+			return dal.ErrDuplicated
+		}
+		t.docs = append(t.docs, doc)
+		if txn != nil {
+			id := doc[idField]
+			txn.undo = append(txn.undo, func() {
+				if i := t.indexOf(id); i >= 0 {
+					t.docs = append(t.docs[:i], t.docs[i+1:]...)
+				}
+			})
+		}
+	}
+	return nil
+}
+
+// Update 更新数据
+func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	data, err := toDocument(doc)
+	if err != nil {
+		return err
+	}
+	return c.modify(ctx, filter, func(doc map[string]interface{}) {
+		for key, val := range data {
+			setPath(doc, key, copyValue(val))
+		}
+	})
+}
+
+// modify apply the change to the documents matching the filter
+func (c *Collection) modify(ctx context.Context, filter dal.Filter, change func(doc map[string]interface{})) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	txn, err := c.txn(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = c.modifyDocs(txn, filter, 0, change)
+	return err
+}
+
+// modifyDocs change at most limit documents matched, 0 means no limit.
+// returns the count of the documents matched, the store lock should be held by the caller
+func (c *Collection) modifyDocs(txn *txn, filter dal.Filter, limit int, change func(doc map[string]interface{})) (int, error) {
+	docs, err := c.matchDocs(filter)
+	if err != nil {
+		return 0, err
+	}
+	if limit > 0 && len(docs) > limit {
+		docs = docs[:limit]
+	}
+	t := c.table()
+	for _, doc := range docs {
+		newDoc := copyValue(doc).(map[string]interface{})
+		change(newDoc)
+		if err := t.checkUnique(newDoc); err != nil {
+			return 0, err
+		}
+		i := t.indexOf(doc[idField])
+		t.docs[i] = newDoc
+		if txn != nil {
+			oldDoc := doc
+			txn.undo = append(txn.undo, func() {
+				if i := t.indexOf(oldDoc[idField]); i >= 0 {
+					t.docs[i] = oldDoc
+				}
+			})
+		}
+	}
+	return len(docs), nil
+}
+
+// Delete 删除数据
+func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	txn, err := c.txn(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = c.deleteDocs(txn, filter)
+	return err
+}
+
+// deleteDocs returns the count of the documents deleted, the store lock should be held by the caller
+func (c *Collection) deleteDocs(txn *txn, filter dal.Filter) (int, error) {
+	docs, err := c.matchDocs(filter)
+	if err != nil {
+		return 0, err
+	}
+	t := c.table()
+	for _, doc := range docs {
+		i := t.indexOf(doc[idField])
+		t.docs = append(t.docs[:i], t.docs[i+1:]...)
+		if txn != nil {
+			oldDoc := doc
+			txn.undo = append(txn.undo, func() {
+				t.docs = append(t.docs, oldDoc)
+			})
+		}
+	}
+	return len(docs), nil
+}
+
+// CreateIndex 创建索引
+func (c *Collection) CreateIndex(ctx context.Context, index dal.Index) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	t := c.table()
+	for _, exist := range t.indexes {
+		if exist.Name == index.Name {
+			return nil
+		}
+	}
+
+	if index.Unique {
+		// the existing documents should be unique either
+		origin := t.indexes
+		t.indexes = []dal.Index{index}
+		for _, doc := range t.docs {
+			if err := t.checkUnique(doc); err != nil {
+				t.indexes = origin
+				return err
+			}
+		}
+		t.indexes = origin
+	}
+	t.indexes = append(t.indexes, index)
+	return nil
+}
+
+// DropIndex remove index by name
+func (c *Collection) DropIndex(ctx context.Context, indexName string) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	t := c.table()
+	for i, index := range t.indexes {
+		if index.Name == indexName {
+			t.indexes = append(t.indexes[:i], t.indexes[i+1:]...)
+			return nil
+		}
+	}
+	return ErrIndexNotFound
+}
+
+// Indexes get all indexes for the collection
+func (c *Collection) Indexes(ctx context.Context) ([]dal.Index, error) {
+	c.store.lock.RLock()
+	defer c.store.lock.RUnlock()
+	t, ok := c.store.tables[c.collName]
+	if !ok {
+		return nil, ErrTableNotFound
+	}
+	return append([]dal.Index{}, t.indexes...), nil
+}
+
+// AddColumn add a new column for the collection
+func (c *Collection) AddColumn(ctx context.Context, column string, value interface{}) error {
+	val, err := toValue(value)
+	if err != nil {
+		return err
+	}
+	filter := map[string]interface{}{column: map[string]interface{}{"$exists": false}}
+	return c.modify(ctx, filter, func(doc map[string]interface{}) {
+		setPath(doc, column, copyValue(val))
+	})
+}
+
+// RenameColumn rename a column for the collection
+func (c *Collection) RenameColumn(ctx context.Context, oldName, newColumn string) error {
+	filter := map[string]interface{}{oldName: map[string]interface{}{"$exists": true}}
+	return c.modify(ctx, filter, func(doc map[string]interface{}) {
+		setPath(doc, newColumn, getPath(doc, oldName))
+		unsetPath(doc, oldName)
+	})
+}
+
+// DropColumn remove a column by the name
+func (c *Collection) DropColumn(ctx context.Context, field string) error {
+	return c.modify(ctx, map[string]interface{}{}, func(doc map[string]interface{}) {
+		unsetPath(doc, field)
+	})
+}
+
+// AggregateAll aggregate all operation
+func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
+	docs, err := c.aggregate(pipeline)
+	if err != nil {
+		return err
+	}
+	return decodeAll(docs, result)
+}
+
+// AggregateOne aggregate one operation
+func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
+	docs, err := c.aggregate(pipeline)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return dal.ErrDocumentNotFound
+	}
+	return decodeOne(docs[0], result)
+}
+
+// project returns a copy of the document with the fields in the projection, the fields are
+// included if any of them is true, otherwise the fields are excluded
+func project(doc map[string]interface{}, projection map[string]bool) map[string]interface{} {
+	include := false
+	for _, show := range projection {
+		include = include || show
+	}
+
+	if !include {
+		result := copyValue(doc).(map[string]interface{})
+		for field, show := range projection {
+			if !show {
+				unsetPath(result, field)
+			}
+		}
+		return result
+	}
+
+	result := map[string]interface{}{}
+	for field, show := range projection {
+		if !show {
+			continue
+		}
+		if val := getPath(doc, field); val != nil {
+			setPath(result, field, copyValue(val))
+		}
+	}
+	if show, ok := projection[idField]; !ok || show {
+		if id, ok := doc[idField]; ok {
+			result[idField] = id
+		}
+	}
+	return result
+}
+
+type sortKey struct {
+	field      string
+	descending bool
+}
+
+func sortDocs(docs []map[string]interface{}, keys []sortKey) {
+	if len(keys) == 0 {
+		return
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, key := range keys {
+			result := compareValues(getPath(docs[i], key.field), getPath(docs[j], key.field))
+			if result == 0 {
+				continue
+			}
+			if key.descending {
+				return result > 0
+			}
+			return result < 0
+		}
+		return false
+	})
+}
+
+// toDocument converts the document in any type that bson supports into a map
+func toDocument(doc interface{}) (map[string]interface{}, error) {
+	if doc == nil {
+		return map[string]interface{}{}, nil
+	}
+	out, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document %#v: %v", doc, err)
+	}
+	m := bson.M{}
+	if err := bson.Unmarshal(out, &m); err != nil {
+		return nil, err
+	}
+	return normalize(m).(map[string]interface{}), nil
+}
+
+// toValue converts the value in any type that bson supports into the normalized value
+func toValue(val interface{}) (interface{}, error) {
+	doc, err := toDocument(bson.M{"v": val})
+	if err != nil {
+		return nil, err
+	}
+	return doc["v"], nil
+}
+
+// decodeAll decodes the documents into the result which is a slice pointer
+func decodeAll(docs []map[string]interface{}, result interface{}) error {
+	if docs == nil {
+		docs = []map[string]interface{}{}
+	}
+	out, err := bson.Marshal(bson.M{"docs": docs})
+	if err != nil {
+		return err
+	}
+	raw := struct {
+		Docs bson.Raw `bson:"docs"`
+	}{}
+	if err := bson.Unmarshal(out, &raw); err != nil {
+		return err
+	}
+	return raw.Docs.Unmarshal(result)
+}
+
+// decodeOne decodes the document into the result
+func decodeOne(doc map[string]interface{}, result interface{}) error {
+	out, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(out, result)
+}
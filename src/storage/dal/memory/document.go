@@ -0,0 +1,215 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// normalize converts the embedded documents into map[string]interface{}
+// and the arrays into []interface{}, so that they are handled in the same way
+func normalize(val interface{}) interface{} {
+	switch v := val.(type) {
+	case bson.M:
+		return normalize(map[string]interface{}(v))
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = normalize(item)
+		}
+		return m
+	case bson.D:
+		m := make(map[string]interface{}, len(v))
+		for _, item := range v {
+			m[item.Name] = normalize(item.Value)
+		}
+		return m
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = normalize(item)
+		}
+		return items
+	}
+	return val
+}
+
+// copyValue returns a deep copy of the normalized value
+func copyValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = copyValue(item)
+		}
+		return m
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = copyValue(item)
+		}
+		return items
+	}
+	return val
+}
+
+// getPath returns the value by the dotted key, returns nil if not exists
+func getPath(doc map[string]interface{}, key string) interface{} {
+	var current interface{} = doc
+	for _, name := range strings.Split(key, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[name]
+	}
+	return current
+}
+
+// setPath set the value by the dotted key, the embedded documents are created if not exist
+func setPath(doc map[string]interface{}, key string, val interface{}) {
+	names := strings.Split(key, ".")
+	current := doc
+	for _, name := range names[:len(names)-1] {
+		next, ok := current[name].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			current[name] = next
+		}
+		current = next
+	}
+	current[names[len(names)-1]] = val
+}
+
+// unsetPath remove the value by the dotted key
+func unsetPath(doc map[string]interface{}, key string) {
+	names := strings.Split(key, ".")
+	current := doc
+	for _, name := range names[:len(names)-1] {
+		next, ok := current[name].(map[string]interface{})
+		if !ok {
+			return
+		}
+		current = next
+	}
+	delete(current, names[len(names)-1])
+}
+
+// typeOrder the order of the types when comparing the values of different types like mongodb
+func typeOrder(val interface{}) int {
+	if _, ok := toFloat(val); ok {
+		return 2
+	}
+	switch val.(type) {
+	case nil:
+		return 1
+	case string:
+		return 3
+	case map[string]interface{}:
+		return 4
+	case []interface{}:
+		return 5
+	case bson.ObjectId:
+		return 7
+	case bool:
+		return 8
+	case time.Time:
+		return 9
+	}
+	return 10
+}
+
+// compareValues returns -1, 0, 1 if a is less than, equal to, greater than b
+func compareValues(a, b interface{}) int {
+	oa, ob := typeOrder(a), typeOrder(b)
+	if oa != ob {
+		return compareInt(oa, ob)
+	}
+
+	switch va := a.(type) {
+	case nil:
+		return 0
+	case string:
+		return strings.Compare(va, b.(string))
+	case bson.ObjectId:
+		return strings.Compare(string(va), string(b.(bson.ObjectId)))
+	case bool:
+		vb := b.(bool)
+		if va == vb {
+			return 0
+		}
+		if !va {
+			return -1
+		}
+		return 1
+	case time.Time:
+		vb := b.(time.Time)
+		if va.Before(vb) {
+			return -1
+		}
+		if va.After(vb) {
+			return 1
+		}
+		return 0
+	case []interface{}:
+		vb := b.([]interface{})
+		for i := 0; i < len(va) && i < len(vb); i++ {
+			if result := compareValues(va[i], vb[i]); result != 0 {
+				return result
+			}
+		}
+		return compareInt(len(va), len(vb))
+	}
+
+	if fa, ok := toFloat(a); ok {
+		fb, _ := toFloat(b)
+		if fa < fb {
+			return -1
+		}
+		if fa > fb {
+			return 1
+		}
+		return 0
+	}
+	if reflect.DeepEqual(a, b) {
+		return 0
+	}
+	return -1
+}
+
+func compareInt(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+func toFloat(val interface{}) (float64, bool) {
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,219 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/types"
+
+	"github.com/rs/xid"
+)
+
+// errors of the table operations
+var (
+	ErrTableExists   = errors.New("collection already exists")
+	ErrTableNotFound = errors.New("collection not found")
+	ErrIndexNotFound = errors.New("index not found")
+)
+
+// DB implement dal.DB interface in memory, it's used by the tests which need
+// the real query semantics without a mongodb.
+type DB struct {
+	store *store
+
+	TxnID     string // 事务ID,uuid
+	RequestID string // 请求ID,可选项
+}
+
+var _ dal.DB = new(DB)
+
+// New returns a new empty in-memory DB
+func New() *DB {
+	return &DB{
+		store: &store{
+			tables:    map[string]*table{},
+			sequences: map[string]uint64{},
+			txns:      map[string]*txn{},
+		},
+	}
+}
+
+// store the data shared by the DB and its clones
+type store struct {
+	lock      sync.RWMutex
+	tables    map[string]*table
+	sequences map[string]uint64
+	txns      map[string]*txn
+}
+
+// txn records the undo operations of the writes in the transaction, the writes
+// are visible to others before committed, only atomicity is provided.
+type txn struct {
+	createTime time.Time
+	undo       []func()
+}
+
+// Close replica client
+func (c *DB) Close() error {
+	return nil
+}
+
+// Ping replica client
+func (c *DB) Ping() error {
+	return nil
+}
+
+// Clone return the new client
+func (c *DB) Clone() dal.DB {
+	return &DB{store: c.store}
+}
+
+// IsDuplicatedError check duplicated error
+func (c *DB) IsDuplicatedError(err error) bool {
+	return err == dal.ErrDuplicated
+}
+
+// IsNotFoundError check the not found error
+func (c *DB) IsNotFoundError(err error) bool {
+	return err == dal.ErrDocumentNotFound
+}
+
+// Table collection operation
+func (c *DB) Table(collName string) dal.Table {
+	return &Collection{collName: collName, DB: c}
+}
+
+// NextSequence 获取新序列号(非事务)
+func (c *DB) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	c.store.sequences[sequenceName]++
+	return c.store.sequences[sequenceName], nil
+}
+
+// StartTransaction 开启新事务
+func (c *DB) StartTransaction(ctx context.Context) (dal.DB, error) {
+	if c.TxnID != "" {
+		return nil, dal.ErrTransactionStated
+	}
+
+	clone := &DB{store: c.store, TxnID: xid.New().String()}
+	if opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption); ok {
+		clone.RequestID = opt.RequestID
+	}
+
+	c.store.lock.Lock()
+	c.store.txns[clone.TxnID] = &txn{createTime: time.Now()}
+	c.store.lock.Unlock()
+	return clone, nil
+}
+
+// Commit 提交事务
+func (c *DB) Commit(ctx context.Context) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	if _, ok := c.store.txns[c.TxnID]; !ok {
+		return dal.ErrTransactionNotFound
+	}
+	delete(c.store.txns, c.TxnID)
+	c.TxnID = "" // clear TxnID
+	return nil
+}
+
+// Abort 取消事务
+func (c *DB) Abort(ctx context.Context) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	t, ok := c.store.txns[c.TxnID]
+	if !ok {
+		return dal.ErrTransactionNotFound
+	}
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		t.undo[i]()
+	}
+	delete(c.store.txns, c.TxnID)
+	c.TxnID = "" // clear TxnID
+	return nil
+}
+
+// TxnInfo 当前事务信息，用于事务发起者往下传递
+func (c *DB) TxnInfo() *types.Transaction {
+	info := &types.Transaction{
+		TxnID:     c.TxnID,
+		RequestID: c.RequestID,
+	}
+	c.store.lock.RLock()
+	defer c.store.lock.RUnlock()
+	if t, ok := c.store.txns[c.TxnID]; ok {
+		info.Status = types.TxStatusOnProgress
+		info.CreateTime = t.createTime
+		info.LastTime = t.createTime
+	}
+	return info
+}
+
+// txn returns the transaction which the operation joins, the transaction is the
+// one started by this db or the one in the join option of ctx, the store lock
+// should be held by the caller. returns nil if the operation is not in a transaction.
+func (c *DB) txn(ctx context.Context) (*txn, error) {
+	txnID := c.TxnID
+	if txnID == "" {
+		opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
+		if !ok || opt.TxnID == "" {
+			return nil, nil
+		}
+		txnID = opt.TxnID
+	}
+
+	t, ok := c.store.txns[txnID]
+	if !ok {
+		return nil, dal.ErrTransactionNotFound
+	}
+	return t, nil
+}
+
+// HasTable 判断是否存在集合
+func (c *DB) HasTable(collName string) (bool, error) {
+	c.store.lock.RLock()
+	defer c.store.lock.RUnlock()
+	_, ok := c.store.tables[collName]
+	return ok, nil
+}
+
+// DropTable 移除集合
+func (c *DB) DropTable(collName string) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	if _, ok := c.store.tables[collName]; !ok {
+		return ErrTableNotFound
+	}
+	delete(c.store.tables, collName)
+	return nil
+}
+
+// CreateTable 创建集合
+func (c *DB) CreateTable(collName string) error {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	if _, ok := c.store.tables[collName]; ok {
+		return ErrTableExists
+	}
+	c.store.tables[collName] = newTable()
+	return nil
+}
@@ -0,0 +1,315 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/storage/dal"
+
+	"github.com/stretchr/testify/require"
+)
+
+type host struct {
+	HostID   int64         `bson:"bk_host_id"`
+	InnerIP  string        `bson:"bk_host_innerip"`
+	CloudID  int64         `bson:"bk_cloud_id"`
+	Metadata mapstr.MapStr `bson:"metadata"`
+}
+
+func newHosts(t *testing.T) *DB {
+	db := New()
+	err := db.Table("cc_HostBase").Insert(context.Background(), []host{
+		{HostID: 1, InnerIP: "10.0.0.1", CloudID: 0, Metadata: mapstr.MapStr{"label": mapstr.MapStr{"bk_biz_id": "3"}}},
+		{HostID: 2, InnerIP: "10.0.0.2", CloudID: 1, Metadata: mapstr.MapStr{"label": mapstr.MapStr{"bk_biz_id": "4"}}},
+		{HostID: 3, InnerIP: "192.168.0.1", CloudID: 1},
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func TestFind(t *testing.T) {
+	db := newHosts(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		filter mapstr.MapStr
+		ids    []int64
+	}{
+		{nil, []int64{1, 2, 3}},
+		{mapstr.MapStr{"bk_host_id": mapstr.MapStr{common.BKDBIN: []int64{1, 3}}}, []int64{1, 3}},
+		{mapstr.MapStr{"bk_host_innerip": mapstr.MapStr{common.BKDBLIKE: "^10\\."}}, []int64{1, 2}},
+		{mapstr.MapStr{common.BKDBOR: []mapstr.MapStr{{"bk_host_id": 1}, {"bk_cloud_id": 1}}}, []int64{1, 2, 3}},
+		{mapstr.MapStr{"metadata.label.bk_biz_id": "4"}, []int64{2}},
+		{mapstr.MapStr{"metadata.label": mapstr.MapStr{common.BKDBExists: false}}, []int64{3}},
+	}
+	for _, c := range cases {
+		hosts := make([]host, 0)
+		require.NoError(t, db.Table("cc_HostBase").Find(c.filter).All(ctx, &hosts), c.filter)
+		ids := make([]int64, 0)
+		for _, h := range hosts {
+			ids = append(ids, h.HostID)
+		}
+		require.Equal(t, c.ids, ids, c.filter)
+
+		count, err := db.Table("cc_HostBase").Find(c.filter).Count(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(len(c.ids)), count)
+	}
+
+	// sort, skip, limit and fields
+	results := make([]mapstr.MapStr, 0)
+	err := db.Table("cc_HostBase").Find(nil).Fields("bk_host_id").Sort("-bk_cloud_id,bk_host_id").Start(1).Limit(1).All(ctx, &results)
+	require.NoError(t, err)
+	require.Equal(t, []mapstr.MapStr{{"bk_host_id": int64(3)}}, results)
+
+	one := host{}
+	require.NoError(t, db.Table("cc_HostBase").Find(mapstr.MapStr{"bk_host_id": 2}).One(ctx, &one))
+	require.Equal(t, "10.0.0.2", one.InnerIP)
+	err = db.Table("cc_HostBase").Find(mapstr.MapStr{"bk_host_id": 4}).One(ctx, &one)
+	require.True(t, db.IsNotFoundError(err))
+
+	// unsupported operator
+	err = db.Table("cc_HostBase").Find(mapstr.MapStr{"$where": "1"}).All(ctx, &results)
+	require.Error(t, err)
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	db := newHosts(t)
+	ctx := context.Background()
+
+	err := db.Table("cc_HostBase").Update(ctx, mapstr.MapStr{"bk_cloud_id": 1}, mapstr.MapStr{"bk_cloud_id": 2, "metadata.label.bk_biz_id": "5"})
+	require.NoError(t, err)
+	count, err := db.Table("cc_HostBase").Find(mapstr.MapStr{"bk_cloud_id": 2, "metadata.label.bk_biz_id": "5"}).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+
+	require.NoError(t, db.Table("cc_HostBase").Delete(ctx, mapstr.MapStr{"bk_cloud_id": 2}))
+	count, err = db.Table("cc_HostBase").Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), count)
+
+	require.NoError(t, db.Table("cc_HostBase").RenameColumn(ctx, "bk_cloud_id", "cloud"))
+	require.NoError(t, db.Table("cc_HostBase").AddColumn(ctx, "bk_os_type", "1"))
+	result := mapstr.MapStr{}
+	require.NoError(t, db.Table("cc_HostBase").Find(nil).Fields("cloud", "bk_os_type", "bk_cloud_id").One(ctx, &result))
+	require.Equal(t, mapstr.MapStr{"cloud": int64(0), "bk_os_type": "1"}, result)
+}
+
+func TestUniqueIndex(t *testing.T) {
+	db := newHosts(t)
+	ctx := context.Background()
+
+	index := dal.Index{Name: "bk_host_innerip_1_bk_cloud_id_1", Keys: []dal.IndexKey{{Key: "bk_host_innerip", Direction: 1}, {Key: "bk_cloud_id", Direction: 1}}, Unique: true}
+	require.NoError(t, db.Table("cc_HostBase").CreateIndex(ctx, index))
+	indexes, err := db.Table("cc_HostBase").Indexes(ctx)
+	require.NoError(t, err)
+	require.Len(t, indexes, 2)
+
+	err = db.Table("cc_HostBase").Insert(ctx, host{HostID: 4, InnerIP: "10.0.0.1", CloudID: 0})
+	require.True(t, db.IsDuplicatedError(err))
+	err = db.Table("cc_HostBase").Update(ctx, mapstr.MapStr{"bk_host_id": 2}, mapstr.MapStr{"bk_host_innerip": "10.0.0.1", "bk_cloud_id": 0})
+	require.True(t, db.IsDuplicatedError(err))
+	require.NoError(t, db.Table("cc_HostBase").Insert(ctx, host{HostID: 4, InnerIP: "10.0.0.1", CloudID: 1}))
+
+	require.NoError(t, db.Table("cc_HostBase").DropIndex(ctx, index.Name))
+	require.NoError(t, db.Table("cc_HostBase").Insert(ctx, host{HostID: 5, InnerIP: "10.0.0.1", CloudID: 0}))
+	err = db.Table("cc_HostBase").CreateIndex(ctx, index)
+	require.True(t, db.IsDuplicatedError(err))
+}
+
+func TestNextSequence(t *testing.T) {
+	db := New()
+	for i := uint64(1); i <= 3; i++ {
+		id, err := db.NextSequence(context.Background(), "cc_HostBase")
+		require.NoError(t, err)
+		require.Equal(t, i, id)
+	}
+	id, err := db.NextSequence(context.Background(), "cc_ModuleBase")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+}
+
+func TestAggregate(t *testing.T) {
+	db := newHosts(t)
+	ctx := context.Background()
+
+	pipeline := []mapstr.MapStr{
+		{common.BKDBMatch: mapstr.MapStr{"bk_host_id": mapstr.MapStr{common.BKDBGT: 1}}},
+		{common.BKDBGroup: mapstr.MapStr{
+			"_id":   "$bk_cloud_id",
+			"count": mapstr.MapStr{common.BKDBSum: 1},
+			"ips":   mapstr.MapStr{common.BKDBPush: "$bk_host_innerip"},
+		}},
+	}
+	results := make([]mapstr.MapStr, 0)
+	require.NoError(t, db.Table("cc_HostBase").AggregateAll(ctx, pipeline, &results))
+	require.Equal(t, []mapstr.MapStr{{"_id": int64(1), "count": int64(2), "ips": []interface{}{"10.0.0.2", "192.168.0.1"}}}, results)
+
+	// the first document of each group
+	firsts := make([]struct {
+		Doc struct {
+			HostID int64 `bson:"bk_host_id"`
+		} `bson:"doc"`
+	}, 0)
+	pipeline = []mapstr.MapStr{
+		{"$sort": mapstr.MapStr{"bk_host_id": -1}},
+		{common.BKDBGroup: mapstr.MapStr{"_id": "$bk_cloud_id", "doc": mapstr.MapStr{"$first": "$$ROOT"}}},
+		{"$sort": mapstr.MapStr{"doc.bk_host_id": 1}},
+	}
+	require.NoError(t, db.Table("cc_HostBase").AggregateAll(ctx, pipeline, &firsts))
+	require.Len(t, firsts, 2)
+	require.Equal(t, int64(1), firsts[0].Doc.HostID)
+	require.Equal(t, int64(3), firsts[1].Doc.HostID)
+
+	// the fields of $sort are in the order of the struct
+	sorted := make([]host, 0)
+	sort := struct {
+		CloudID int `bson:"bk_cloud_id"`
+		HostID  int `bson:"bk_host_id"`
+	}{CloudID: -1, HostID: 1}
+	require.NoError(t, db.Table("cc_HostBase").AggregateAll(ctx, []mapstr.MapStr{{"$sort": sort}}, &sorted))
+	require.Len(t, sorted, 3)
+	require.Equal(t, []int64{2, 3, 1}, []int64{sorted[0].HostID, sorted[1].HostID, sorted[2].HostID})
+
+	count := struct {
+		Count int64 `bson:"count"`
+	}{}
+	require.NoError(t, db.Table("cc_HostBase").AggregateOne(ctx, []mapstr.MapStr{{common.BKDBCount: "count"}}, &count))
+	require.Equal(t, int64(3), count.Count)
+
+	err := db.Table("cc_HostBase").AggregateAll(ctx, []mapstr.MapStr{{"$lookup": mapstr.MapStr{}}}, &results)
+	require.Error(t, err)
+}
+
+func TestTransaction(t *testing.T) {
+	db := newHosts(t)
+	ctx := context.Background()
+
+	// abort
+	tx, err := db.StartTransaction(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, tx.TxnInfo().TxnID)
+	require.NoError(t, tx.Table("cc_HostBase").Insert(ctx, host{HostID: 4}))
+	require.NoError(t, tx.Table("cc_HostBase").Update(ctx, mapstr.MapStr{"bk_host_id": 1}, mapstr.MapStr{"bk_cloud_id": 9}))
+	// join the transaction by the context
+	joinCtx := context.WithValue(ctx, common.CCContextKeyJoinOption, dal.JoinOption{TxnID: tx.TxnInfo().TxnID})
+	require.NoError(t, db.Table("cc_HostBase").Delete(joinCtx, mapstr.MapStr{"bk_host_id": 2}))
+	require.NoError(t, tx.Abort(ctx))
+
+	hosts := make([]host, 0)
+	require.NoError(t, db.Table("cc_HostBase").Find(nil).Sort("bk_host_id").All(ctx, &hosts))
+	require.Len(t, hosts, 3)
+	require.Equal(t, int64(0), hosts[0].CloudID)
+	require.Equal(t, int64(2), hosts[1].HostID)
+
+	// commit
+	tx, err = db.StartTransaction(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Table("cc_HostBase").Delete(ctx, mapstr.MapStr{"bk_host_id": 3}))
+	require.NoError(t, tx.Commit(ctx))
+	require.Equal(t, dal.ErrTransactionNotFound, tx.Abort(ctx))
+	count, err := db.Table("cc_HostBase").Find(nil).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+
+	// the finished transaction can not be joined
+	err = db.Table("cc_HostBase").Insert(joinCtx, host{HostID: 5})
+	require.Equal(t, dal.ErrTransactionNotFound, err)
+}
+
+func TestIterate(t *testing.T) {
+	db := newHosts(t)
+	ctx := context.Background()
+
+	ids := []int64{}
+	err := db.Table("cc_HostBase").Find(nil).Sort("-bk_host_id").Iterate(ctx, func(decode func(interface{}) error) error {
+		h := host{}
+		if err := decode(&h); err != nil {
+			return err
+		}
+		ids = append(ids, h.HostID)
+		if len(ids) == 2 {
+			return dal.ErrStopIteration
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int64{3, 2}, ids)
+}
+
+func TestBulkWrite(t *testing.T) {
+	db := newHosts(t)
+	ctx := context.Background()
+
+	index := dal.Index{Name: "bk_host_innerip_1", Keys: []dal.IndexKey{{Key: "bk_host_innerip", Direction: 1}}, Unique: true}
+	require.NoError(t, db.Table("cc_HostBase").CreateIndex(ctx, index))
+
+	models := []dal.BulkModel{
+		dal.NewBulkInsert(host{HostID: 4, InnerIP: "10.0.0.4"}),
+		dal.NewBulkInsert(host{HostID: 5, InnerIP: "10.0.0.1"}),
+		dal.NewBulkUpdate(mapstr.MapStr{"bk_cloud_id": 1}, mapstr.MapStr{"bk_cloud_id": 2}),
+		dal.NewBulkUpsert(mapstr.MapStr{"bk_host_id": 6}, mapstr.MapStr{"bk_host_innerip": "10.0.0.6"}),
+		dal.NewBulkDelete(mapstr.MapStr{"bk_host_id": 1}),
+	}
+	result, err := db.Table("cc_HostBase").BulkWrite(ctx, models, true)
+	require.Equal(t, dal.ErrBulkWrite, err)
+	require.Equal(t, uint64(1), result.InsertedCount)
+	require.Len(t, result.Errors, 1)
+	require.Equal(t, 1, result.Errors[0].Index)
+	require.True(t, result.Errors[0].IsDuplicated())
+
+	result, err = db.Table("cc_HostBase").BulkWrite(ctx, models[2:], false)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), result.MatchedCount)
+	require.Equal(t, uint64(1), result.UpsertedCount)
+	require.Equal(t, uint64(1), result.DeletedCount)
+
+	upserted := host{}
+	require.NoError(t, db.Table("cc_HostBase").Find(mapstr.MapStr{"bk_host_id": 6}).One(ctx, &upserted))
+	require.Equal(t, "10.0.0.6", upserted.InnerIP)
+	count, err := db.Table("cc_HostBase").Find(mapstr.MapStr{"bk_cloud_id": 2}).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+}
+
+func TestUpsertAndFindOneAndModify(t *testing.T) {
+	db := newHosts(t)
+	ctx := context.Background()
+
+	require.NoError(t, db.Table("cc_HostBase").Upsert(ctx, mapstr.MapStr{"bk_host_id": 1}, mapstr.MapStr{"bk_cloud_id": 5}))
+	require.NoError(t, db.Table("cc_HostBase").Upsert(ctx, mapstr.MapStr{"bk_host_id": 4}, mapstr.MapStr{"bk_host_innerip": "10.0.0.4"}))
+	count, err := db.Table("cc_HostBase").Find(mapstr.MapStr{"bk_host_id": mapstr.MapStr{"$in": []int64{1, 4}}, "bk_host_innerip": mapstr.MapStr{"$ne": ""}}).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+
+	counter := struct {
+		Count int64 `bson:"count"`
+	}{}
+	update := mapstr.MapStr{"$inc": mapstr.MapStr{"count": 1}, "$setOnInsert": mapstr.MapStr{"bk_host_id": 10}}
+	err = db.Table("counter").FindOneAndModify(ctx, mapstr.MapStr{"name": "host"}, update, dal.ModifyOptions{}, &counter)
+	require.Equal(t, dal.ErrDocumentNotFound, err)
+	require.NoError(t, db.Table("counter").FindOneAndModify(ctx, mapstr.MapStr{"name": "host"}, update, dal.ModifyOptions{Upsert: true}, &counter))
+	require.Equal(t, int64(0), counter.Count)
+	require.NoError(t, db.Table("counter").FindOneAndModify(ctx, mapstr.MapStr{"name": "host"}, update, dal.ModifyOptions{ReturnNew: true}, &counter))
+	require.Equal(t, int64(2), counter.Count)
+	require.NoError(t, db.Table("counter").FindOneAndModify(ctx, mapstr.MapStr{"name": "host"}, update, dal.ModifyOptions{}, &counter))
+	require.Equal(t, int64(2), counter.Count)
+
+	result := mapstr.MapStr{}
+	require.NoError(t, db.Table("counter").Find(nil).Fields("name", "count", "bk_host_id").One(ctx, &result))
+	require.Equal(t, mapstr.MapStr{"name": "host", "count": int64(3), "bk_host_id": 10}, result)
+}
@@ -0,0 +1,195 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"configcenter/src/storage/dal"
+)
+
+// Upsert 更新数据, 没有匹配的数据时插入
+func (c *Collection) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	data, err := toDocument(doc)
+	if err != nil {
+		return err
+	}
+
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	txn, err := c.txn(ctx)
+	if err != nil {
+		return err
+	}
+	matched, err := c.modifyDocs(txn, filter, 1, func(doc map[string]interface{}) {
+		for key, val := range data {
+			setPath(doc, key, copyValue(val))
+		}
+	})
+	if err != nil || matched > 0 {
+		return err
+	}
+	newDoc, err := upsertDocument(filter, data)
+	if err != nil {
+		return err
+	}
+	return c.insertDocs(txn, []interface{}{newDoc})
+}
+
+// FindOneAndModify 查找并修改一条数据
+func (c *Collection) FindOneAndModify(ctx context.Context, filter dal.Filter, update interface{}, opts dal.ModifyOptions, result interface{}) error {
+	change, err := toDocument(update)
+	if err != nil {
+		return err
+	}
+	if err := checkUpdate(change); err != nil {
+		return err
+	}
+
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	txn, err := c.txn(ctx)
+	if err != nil {
+		return err
+	}
+	var oldDoc, newDoc map[string]interface{}
+	matched, err := c.modifyDocs(txn, filter, 1, func(doc map[string]interface{}) {
+		oldDoc = copyValue(doc).(map[string]interface{})
+		applyUpdate(doc, change, false)
+		newDoc = doc
+	})
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		if !opts.Upsert {
+			return dal.ErrDocumentNotFound
+		}
+		newDoc, err = upsertDocument(filter, nil)
+		if err != nil {
+			return err
+		}
+		applyUpdate(newDoc, change, true)
+		if err := c.insertDocs(txn, []interface{}{newDoc}); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case opts.ReturnNew:
+		return decodeOne(newDoc, result)
+	case oldDoc != nil:
+		return decodeOne(oldDoc, result)
+	}
+	return nil
+}
+
+// checkUpdate the update document should be either all the operators supported or a replacement
+func checkUpdate(update map[string]interface{}) error {
+	operators := 0
+	for key, val := range update {
+		if !strings.HasPrefix(key, "$") {
+			continue
+		}
+		operators++
+		switch key {
+		case "$set", "$setOnInsert", "$unset", "$inc":
+		default:
+			return fmt.Errorf("unsupported update operator %s", key)
+		}
+		if _, ok := val.(map[string]interface{}); !ok {
+			return fmt.Errorf("invalid update operator %s: %v", key, val)
+		}
+		if key != "$inc" {
+			continue
+		}
+		for field, inc := range val.(map[string]interface{}) {
+			if _, ok := toFloat(inc); !ok {
+				return fmt.Errorf("cannot increment %s with non-numeric value %v", field, inc)
+			}
+		}
+	}
+	if operators > 0 && operators != len(update) {
+		return fmt.Errorf("the update document mixes the operators and the fields")
+	}
+	return nil
+}
+
+// applyUpdate apply the update document checked by checkUpdate, the document is replaced
+// except the _id if there are no operators. $setOnInsert only works when inserting
+func applyUpdate(doc map[string]interface{}, update map[string]interface{}, inserting bool) {
+	replace := true
+	for key, val := range update {
+		if !strings.HasPrefix(key, "$") {
+			continue
+		}
+		replace = false
+		fields := val.(map[string]interface{})
+		for field, item := range fields {
+			switch key {
+			case "$set":
+				setPath(doc, field, copyValue(item))
+			case "$setOnInsert":
+				if inserting {
+					setPath(doc, field, copyValue(item))
+				}
+			case "$unset":
+				unsetPath(doc, field)
+			case "$inc":
+				setPath(doc, field, increase(getPath(doc, field), item))
+			}
+		}
+	}
+	if !replace {
+		return
+	}
+	for key := range doc {
+		if key != idField {
+			delete(doc, key)
+		}
+	}
+	for key, val := range update {
+		if key != idField {
+			doc[key] = copyValue(val)
+		}
+	}
+}
+
+// increase adds the numbers, the result is an integer if both of them are integers
+func increase(val, inc interface{}) interface{} {
+	if val == nil {
+		return inc
+	}
+	a, aInt := toInt64(val)
+	b, bInt := toInt64(inc)
+	if aInt && bInt {
+		return a + b
+	}
+	x, _ := toFloat(val)
+	y, _ := toFloat(inc)
+	return x + y
+}
+
+func toInt64(val interface{}) (int64, bool) {
+	switch v := val.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
@@ -16,6 +16,19 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
+
+	"configcenter/src/storage/dal"
+)
+
+// the modes of the transaction config
+const (
+	// TransactionEnable transactions are managed by the txc server
+	TransactionEnable = "enable"
+	// TransactionLocal transactions are managed by the db client itself with the
+	// sessions of the mongodb replica set, which requires mongodb 4.0+. they are
+	// kept in the process, the transactions across the processes need the txc server.
+	TransactionLocal = "local"
 )
 
 // Config config
@@ -30,6 +43,12 @@ type Config struct {
 	MaxOpenConns string
 	MaxIdleConns string
 	Transaction  string
+	// ReadPreference 可读从节点的查询默认使用的读偏好, 为空时读主节点
+	ReadPreference string
+	// MaxStalenessSeconds 从节点的最大延迟, 只有通过 txc 访问时支持, 本地连接配置后启动失败
+	MaxStalenessSeconds string
+	// SlowQueryThresholdMs 慢查询阈值, 单位毫秒, 为空时不记录慢查询
+	SlowQueryThresholdMs string
 }
 
 // BuildURI return mongo uri according to  https://docs.mongodb.com/manual/reference/connection-string/
@@ -66,6 +85,20 @@ func (c Config) GetMaxIdleConns() int {
 	return max
 }
 
+// GetReadOption returns the default read option of the secondary eligible reads
+func (c Config) GetReadOption() (dal.ReadOption, error) {
+	return dal.ParseReadOption(c.ReadPreference, c.MaxStalenessSeconds)
+}
+
+// GetSlowQueryThreshold returns the threshold of the slow queries, 0 if not configured
+func (c Config) GetSlowQueryThreshold() time.Duration {
+	ms, err := strconv.Atoi(c.SlowQueryThresholdMs)
+	if err != nil || ms < 0 {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
 // ParseConfigFromKV returns a new config
 func ParseConfigFromKV(prefix string, conifgmap map[string]string) Config {
 	return Config{
@@ -78,5 +111,10 @@ func ParseConfigFromKV(prefix string, conifgmap map[string]string) Config {
 		MaxIdleConns: conifgmap[prefix+".maxIDleConns"],
 		Mechanism:    conifgmap[prefix+".mechanism"],
 		Transaction:  conifgmap[prefix+".transaction"],
+
+		ReadPreference:      conifgmap[prefix+".readPreference"],
+		MaxStalenessSeconds: conifgmap[prefix+".maxStalenessSeconds"],
+
+		SlowQueryThresholdMs: conifgmap[prefix+".slowQueryThresholdMs"],
 	}
 }
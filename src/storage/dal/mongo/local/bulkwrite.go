@@ -0,0 +1,192 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/mongodb"
+	"configcenter/src/storage/mongodb/options/bulkwriteopt"
+	"configcenter/src/storage/types"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// maxWriteBatchSize the max operation count of one write command
+const maxWriteBatchSize = 1000
+
+// BulkWrite 批量写, ordered 为 true 时遇到错误即停止
+func (c *Collection) BulkWrite(ctx context.Context, models []dal.BulkModel, ordered bool) (*types.BulkWriteResult, error) {
+	session, err := c.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	span := startSpan(ctx, "bulkWrite", c.collName)
+	var result *types.BulkWriteResult
+	if session != nil {
+		result, err = bulkWriteSession(ctx, session.Collection(c.collName), models, ordered)
+	} else {
+		c.dbc.Refresh()
+		result, err = bulkWrite(c.dbc.DB(c.dbname), c.collName, models, ordered)
+	}
+	if err == nil && len(result.Errors) > 0 {
+		err = dal.ErrBulkWrite
+	}
+	finishSpan(span, err)
+	return result, err
+}
+
+var bulkWriteTypes = map[types.BulkWriteType]mongodb.BulkWriteType{
+	types.BulkWriteInsert: mongodb.BulkWriteInsert,
+	types.BulkWriteUpdate: mongodb.BulkWriteUpdate,
+	types.BulkWriteUpsert: mongodb.BulkWriteUpsert,
+	types.BulkWriteDelete: mongodb.BulkWriteDelete,
+}
+
+func bulkWriteSession(ctx context.Context, collection mongodb.CollectionInterface, models []dal.BulkModel, ordered bool) (*types.BulkWriteResult, error) {
+	writeModels := make([]mongodb.BulkWriteModel, 0, len(models))
+	for index, model := range models {
+		typ, ok := bulkWriteTypes[model.Type]
+		if !ok {
+			return nil, fmt.Errorf("unknown bulk write type %s of the model %d", model.Type, index)
+		}
+		writeModels = append(writeModels, mongodb.BulkWriteModel{Type: typ, Filter: bulkFilter(model.Filter), Doc: model.Doc})
+	}
+	ret, err := collection.BulkWrite(ctx, writeModels, &bulkwriteopt.Opts{Ordered: ordered})
+	if err != nil {
+		return nil, err
+	}
+	result := &types.BulkWriteResult{
+		InsertedCount: ret.InsertedCount,
+		MatchedCount:  ret.MatchedCount,
+		ModifiedCount: ret.ModifiedCount,
+		DeletedCount:  ret.DeletedCount,
+		UpsertedCount: ret.UpsertedCount,
+	}
+	for _, writeErr := range ret.Errors {
+		result.Errors = append(result.Errors, types.BulkWriteError{Index: writeErr.Index, Code: writeErr.Code, Message: writeErr.Message})
+	}
+	return result, nil
+}
+
+// writeCommandReply the reply of the insert, update and delete command
+type writeCommandReply struct {
+	N         int `bson:"n"`
+	NModified int `bson:"nModified"`
+	Upserted  []struct {
+		Index int `bson:"index"`
+	} `bson:"upserted"`
+	WriteErrors []struct {
+		Index  int    `bson:"index"`
+		Code   int    `bson:"code"`
+		ErrMsg string `bson:"errmsg"`
+	} `bson:"writeErrors"`
+	WriteConcernError *struct {
+		Code   int    `bson:"code"`
+		ErrMsg string `bson:"errmsg"`
+	} `bson:"writeConcernError"`
+}
+
+// bulkWrite runs the consecutive models of the same kind with one write command,
+// which is how the mongodb drivers implement the bulk write
+func bulkWrite(db *mgo.Database, collName string, models []dal.BulkModel, ordered bool) (*types.BulkWriteResult, error) {
+	result := &types.BulkWriteResult{}
+	for start := 0; start < len(models); {
+		kind := commandOf(models[start].Type)
+		if kind == "" {
+			return result, fmt.Errorf("unknown bulk write type %s of the model %d", models[start].Type, start)
+		}
+		end := start + 1
+		for end < len(models) && end-start < maxWriteBatchSize && commandOf(models[end].Type) == kind {
+			end++
+		}
+
+		ops := make([]interface{}, 0, end-start)
+		for _, model := range models[start:end] {
+			switch model.Type {
+			case types.BulkWriteInsert:
+				ops = append(ops, model.Doc)
+			case types.BulkWriteUpdate:
+				ops = append(ops, bson.M{"q": bulkFilter(model.Filter), "u": bson.M{"$set": model.Doc}, "multi": true})
+			case types.BulkWriteUpsert:
+				ops = append(ops, bson.M{"q": bulkFilter(model.Filter), "u": bson.M{"$set": model.Doc}, "upsert": true})
+			case types.BulkWriteDelete:
+				ops = append(ops, bson.M{"q": bulkFilter(model.Filter), "limit": 0})
+			}
+		}
+		cmd := bson.D{
+			{Name: kind, Value: collName},
+			{Name: opsFieldOf[kind], Value: ops},
+			{Name: "ordered", Value: ordered},
+		}
+		reply := writeCommandReply{}
+		if err := db.Run(cmd, &reply); err != nil {
+			return result, err
+		}
+		if reply.WriteConcernError != nil {
+			return result, fmt.Errorf("write concern error: %s", reply.WriteConcernError.ErrMsg)
+		}
+
+		switch kind {
+		case "insert":
+			result.InsertedCount += uint64(reply.N)
+		case "update":
+			result.MatchedCount += uint64(reply.N - len(reply.Upserted))
+			result.ModifiedCount += uint64(reply.NModified)
+			result.UpsertedCount += uint64(len(reply.Upserted))
+		case "delete":
+			result.DeletedCount += uint64(reply.N)
+		}
+		for _, writeErr := range reply.WriteErrors {
+			result.Errors = append(result.Errors, types.BulkWriteError{
+				Index:   start + writeErr.Index,
+				Code:    writeErr.Code,
+				Message: writeErr.ErrMsg,
+			})
+		}
+		if ordered && len(reply.WriteErrors) > 0 {
+			break
+		}
+		start = end
+	}
+	return result, nil
+}
+
+var opsFieldOf = map[string]string{
+	"insert": "documents",
+	"update": "updates",
+	"delete": "deletes",
+}
+
+func commandOf(typ types.BulkWriteType) string {
+	switch typ {
+	case types.BulkWriteInsert:
+		return "insert"
+	case types.BulkWriteUpdate, types.BulkWriteUpsert:
+		return "update"
+	case types.BulkWriteDelete:
+		return "delete"
+	}
+	return ""
+}
+
+// bulkFilter the empty filter should be an empty document rather than null
+func bulkFilter(filter dal.Filter) interface{} {
+	if filter == nil {
+		return bson.M{}
+	}
+	return filter
+}
@@ -67,6 +67,7 @@ type MockResult struct {
 	SequenceID uint64
 	Info       types.Transaction
 	Indexs     []dal.Index
+	BulkWrite  types.BulkWriteResult
 }
 
 // Mock mock method
@@ -115,6 +116,7 @@ type MockFind struct {
 	start           uint64
 	limit           uint64
 	sort            []string
+	batchSize       uint64
 }
 
 // Fields 查询字段
@@ -218,6 +220,45 @@ func (f *MockFind) Count(ctx context.Context) (uint64, error) {
 	return f.Mock.retval.Count, err
 }
 
+// BatchSize 游标每批获取的数量
+func (f *MockFind) BatchSize(size uint64) dal.Find {
+	f.batchSize = size
+	return f
+}
+
+// ReadPreference 读选项不影响 mock 的结果
+func (f *MockFind) ReadPreference(opt dal.ReadOption) dal.Find {
+	return f
+}
+
+// Iterate 遍历 All 记录的查询结果
+func (f *MockFind) Iterate(ctx context.Context, handler dal.IterateHandler) error {
+	out, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	key := "FINDALL:" + f.collName + ":" + string(out)
+
+	retval, ok := f.Mock.cache[string(key)]
+	if !ok {
+		return nil
+	}
+	docs := []bson.Raw{}
+	raw := bson.Raw{Kind: 4, Data: retval.RawResult}
+	if err = raw.Unmarshal(&docs); err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if err = handler(doc.Unmarshal); err == dal.ErrStopIteration {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return retval.Err
+}
+
 // Insert 插入数据, docs 可以为 单个数据 或者 多个数据
 func (c *MockCollection) Insert(ctx context.Context, docs interface{}) error {
 	bsonout, err := bson.Marshal(docs)
@@ -254,6 +295,69 @@ func (c *MockCollection) Update(ctx context.Context, filter dal.Filter, doc inte
 	return nil
 }
 
+// BulkWrite 批量写
+func (c *MockCollection) BulkWrite(ctx context.Context, models []dal.BulkModel, ordered bool) (*types.BulkWriteResult, error) {
+	bsonout, err := bson.Marshal([]interface{}{models, ordered})
+	if err != nil {
+		return nil, err
+	}
+
+	key := "BULKWRITE:" + c.collName + ":" + string(bsonout)
+	if retval, ok := c.Mock.cache[key]; ok {
+		result := retval.BulkWrite
+		return &result, retval.Err
+	}
+
+	c.Mock.cache[key] = c.Mock.retval
+	c.Mock.retval = nil
+
+	return &types.BulkWriteResult{}, nil
+}
+
+// Upsert 更新数据, 没有匹配的数据时插入
+func (c *MockCollection) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	bsonout, err := bson.Marshal([]interface{}{filter, doc})
+	if err != nil {
+		return err
+	}
+
+	key := "UPSERT:" + c.collName + ":" + string(bsonout)
+	if retval, ok := c.Mock.cache[key]; ok {
+		return retval.Err
+	}
+
+	c.Mock.cache[key] = c.Mock.retval
+	c.Mock.retval = nil
+
+	return nil
+}
+
+// FindOneAndModify 查找并修改一条数据
+func (c *MockCollection) FindOneAndModify(ctx context.Context, filter dal.Filter, update interface{}, opts dal.ModifyOptions, result interface{}) error {
+	bsonout, err := bson.Marshal([]interface{}{filter, update, opts})
+	if err != nil {
+		return err
+	}
+
+	key := "FINDANDMODIFY:" + c.collName + ":" + string(bsonout)
+	if retval, ok := c.Mock.cache[key]; ok {
+		err = bson.Unmarshal(retval.RawResult, result)
+		if err != nil {
+			return err
+		}
+		return retval.Err
+	}
+
+	bsonout, err = bson.Marshal(result)
+	if err != nil {
+		return err
+	}
+	c.Mock.retval.RawResult = bsonout
+	c.Mock.cache[key] = c.Mock.retval
+	c.Mock.retval = nil
+	return nil
+}
+
 // Delete 删除数据
 func (c *MockCollection) Delete(ctx context.Context, filter dal.Filter) error {
 	bsonout, err := bson.Marshal(filter)
@@ -17,6 +17,9 @@ import (
 	"fmt"
 	"testing"
 
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/types"
+
 	"github.com/stretchr/testify/require"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -66,6 +69,23 @@ func TestMockDelete(t *testing.T) {
 	require.EqualError(t, err, errmsg)
 }
 
+func TestMockBulkWrite(t *testing.T) {
+	db := NewMock()
+	tablename := "test"
+	ctx := context.Background()
+
+	models := []dal.BulkModel{
+		dal.NewBulkInsert(map[string]interface{}{"name": "name"}),
+		dal.NewBulkDelete(map[string]interface{}{"name": "other"}),
+	}
+	mockResult := types.BulkWriteResult{InsertedCount: 1, Errors: []types.BulkWriteError{{Index: 1, Code: 11000}}}
+	_, err := db.Mock(MockResult{Err: dal.ErrBulkWrite, BulkWrite: mockResult}).Table(tablename).BulkWrite(ctx, models, false)
+	require.NoError(t, err)
+	result, err := db.Table(tablename).BulkWrite(ctx, models, false)
+	require.Equal(t, dal.ErrBulkWrite, err)
+	require.Equal(t, mockResult, *result)
+}
+
 func TestMockAll(t *testing.T) {
 	var err error
 	db := NewMock()
@@ -118,3 +138,21 @@ func TestMockOne(t *testing.T) {
 
 	require.Equal(t, mockout, actualout)
 }
+
+func TestMockFindOneAndModify(t *testing.T) {
+	db := NewMock()
+	tablename := "test"
+	ctx := context.Background()
+
+	filter := map[string]interface{}{"name": "name"}
+	update := map[string]interface{}{"$inc": map[string]interface{}{"count": 1}}
+	opts := dal.ModifyOptions{Upsert: true, ReturnNew: true}
+	mockResult := map[string]interface{}{"name": "name", "count": 2}
+	err := db.Mock(MockResult{}).Table(tablename).FindOneAndModify(ctx, filter, update, opts, &mockResult)
+	require.NoError(t, err)
+
+	actualResult := map[string]interface{}{}
+	err = db.Table(tablename).FindOneAndModify(ctx, filter, update, opts, &actualResult)
+	require.NoError(t, err)
+	require.Equal(t, mockResult, actualResult)
+}
@@ -0,0 +1,79 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/mongodb/options/findopt"
+	"configcenter/src/storage/mongodb/options/updateopt"
+
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Upsert 更新数据, 没有匹配的数据时插入
+func (c *Collection) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	session, err := c.session(ctx)
+	if err != nil {
+		return err
+	}
+	span := startSpan(ctx, "upsert", c.collName)
+	if session != nil {
+		// UpdateOne of the session sets the fields by itself
+		_, err = session.Collection(c.collName).UpdateOne(ctx, bulkFilter(filter), doc, &updateopt.One{Upsert: true})
+	} else {
+		c.dbc.Refresh()
+		_, err = c.dbc.DB(c.dbname).C(c.collName).Upsert(bulkFilter(filter), bson.M{"$set": doc})
+	}
+	finishSpan(span, err)
+	return err
+}
+
+// FindOneAndModify 查找并修改一条数据
+func (c *Collection) FindOneAndModify(ctx context.Context, filter dal.Filter, update interface{}, opts dal.ModifyOptions, result interface{}) error {
+	session, err := c.session(ctx)
+	if err != nil {
+		return err
+	}
+	span := startSpan(ctx, "findAndModify", c.collName)
+	if session != nil {
+		modifyOpts := &findopt.FindAndModify{Upsert: opts.Upsert, New: opts.ReturnNew}
+		err = session.Collection(c.collName).FindOneAndModify(ctx, bulkFilter(filter), update, modifyOpts, result)
+		// the document inserted is not returned when the old one is required
+		if err == mongo.ErrNoDocuments && opts.Upsert {
+			err = nil
+		}
+	} else {
+		err = c.findOneAndModify(filter, update, opts, result)
+	}
+	if err == mgo.ErrNotFound || err == mongo.ErrNoDocuments {
+		err = dal.ErrDocumentNotFound
+	}
+	finishSpan(span, err)
+	return err
+}
+
+// findOneAndModify find and modify without the transaction
+func (c *Collection) findOneAndModify(filter dal.Filter, update interface{}, opts dal.ModifyOptions, result interface{}) error {
+	c.dbc.Refresh()
+	change := mgo.Change{
+		Update:    update,
+		Upsert:    opts.Upsert,
+		ReturnNew: opts.ReturnNew,
+	}
+	_, err := c.dbc.DB(c.dbname).C(c.collName).Find(filter).Apply(change, result)
+	return err
+}
@@ -18,10 +18,14 @@ import (
 	"time"
 
 	// "configcenter/src/common/blog"
+	"configcenter/src/common/trace"
 	"configcenter/src/common/util"
 	"configcenter/src/storage/dal"
+	"configcenter/src/storage/mongodb"
+	"configcenter/src/storage/mongodb/options/findopt"
 	"configcenter/src/storage/types"
 
+	"github.com/mongodb/mongo-go-driver/mongo"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -30,6 +34,12 @@ import (
 type Mongo struct {
 	dbc    *mgo.Session
 	dbname string
+	txn    *txnManager // nil if the transaction is not supported
+	// the read option of the secondary eligible reads
+	readOpt dal.ReadOption
+
+	TxnID     string // 事务ID,uuid
+	RequestID string // 请求ID,可选项
 }
 
 var _ dal.DB = new(Mongo)
@@ -56,6 +66,9 @@ func NewMgo(uri string, timeout time.Duration) (*Mongo, error) {
 
 // Close replica client
 func (c *Mongo) Close() error {
+	if c.txn != nil {
+		c.txn.close()
+	}
 	c.dbc.Close()
 	return nil
 }
@@ -68,8 +81,10 @@ func (c *Mongo) Ping() error {
 // Clone return the new client
 func (c *Mongo) Clone() dal.DB {
 	nc := Mongo{
-		dbc:    c.dbc,
-		dbname: c.dbname,
+		dbc:     c.dbc,
+		dbname:  c.dbname,
+		txn:     c.txn,
+		readOpt: c.readOpt,
 	}
 	return &nc
 }
@@ -119,6 +134,8 @@ type Find struct {
 	start      uint64
 	limit      uint64
 	sort       []string
+	batchSize  uint64
+	readOpt    *dal.ReadOption
 }
 
 // Fields 查询字段
@@ -155,69 +172,178 @@ func (f *Find) Limit(limit uint64) dal.Find {
 
 // All 查询多个
 func (f *Find) All(ctx context.Context, result interface{}) error {
-	f.dbc.Refresh()
-	query := f.dbc.DB(f.dbname).C(f.collName).Find(f.filter)
-	query = query.Select(f.projection)
-	query = query.Skip(int(f.start))
-	query = query.Limit(int(f.limit))
-	query = query.Sort(f.sort...)
-	return query.All(result)
+	session, err := f.session(ctx)
+	if err != nil {
+		return err
+	}
+	span := startSpan(ctx, "find", f.collName)
+	if session != nil {
+		err = session.Collection(f.collName).Find(ctx, f.filter, f.findOpts(), result)
+	} else {
+		db, done := f.readDB(ctx, f.readOpt)
+		query := db.C(f.collName).Find(f.filter)
+		query = query.Select(f.projection)
+		query = query.Skip(int(f.start))
+		query = query.Limit(int(f.limit))
+		query = query.Sort(f.sort...)
+		err = query.All(result)
+		done()
+	}
+	finishSpan(span, err)
+	return err
 }
 
 // One 查询一个
 func (f *Find) One(ctx context.Context, result interface{}) error {
-	f.dbc.Refresh()
-
-	err := f.dbc.DB(f.dbname).C(f.collName).Find(f.filter).One(result)
-	if err == mgo.ErrNotFound {
+	session, err := f.session(ctx)
+	if err != nil {
+		return err
+	}
+	span := startSpan(ctx, "findOne", f.collName)
+	if session != nil {
+		opts := &findopt.One{Opts: f.findOpts().Opts}
+		err = session.Collection(f.collName).FindOne(ctx, f.filter, opts, result)
+	} else {
+		db, done := f.readDB(ctx, f.readOpt)
+		err = db.C(f.collName).Find(f.filter).One(result)
+		done()
+	}
+	if err == mgo.ErrNotFound || err == mongo.ErrNoDocuments {
 		err = dal.ErrDocumentNotFound
 	}
+	finishSpan(span, err)
 	return err
 }
 
 // Count 统计数量(非事务)
 func (f *Find) Count(ctx context.Context) (uint64, error) {
-	count, err := f.dbc.DB(f.dbname).C(f.collName).Find(f.filter).Count()
-	return uint64(count), err
+	session, err := f.session(ctx)
+	if err != nil {
+		return 0, err
+	}
+	span := startSpan(ctx, "count", f.collName)
+	var count uint64
+	if session != nil {
+		count, err = session.Collection(f.collName).Count(ctx, f.filter)
+	} else {
+		var n int
+		db, done := f.readDB(ctx, f.readOpt)
+		n, err = db.C(f.collName).Find(f.filter).Count()
+		count = uint64(n)
+		done()
+	}
+	finishSpan(span, err)
+	return count, err
+}
+
+// BatchSize 游标每批获取的数量
+func (f *Find) BatchSize(size uint64) dal.Find {
+	f.batchSize = size
+	return f
+}
+
+// Iterate 以游标方式逐个遍历
+func (f *Find) Iterate(ctx context.Context, handler dal.IterateHandler) error {
+	session, err := f.session(ctx)
+	if err != nil {
+		return err
+	}
+	span := startSpan(ctx, "iterate", f.collName)
+	if session != nil {
+		opts := f.findOpts()
+		opts.BatchSize = int32(f.batchSize)
+		err = session.Collection(f.collName).FindIterate(ctx, f.filter, opts, mongodb.IterateHandler(handler))
+	} else {
+		db, done := f.readDB(ctx, f.readOpt)
+		defer done()
+		query := db.C(f.collName).Find(f.filter)
+		query = query.Select(f.projection)
+		query = query.Skip(int(f.start))
+		query = query.Limit(int(f.limit))
+		query = query.Sort(f.sort...)
+		if f.batchSize > 0 {
+			query = query.Batch(int(f.batchSize))
+		}
+		iter := query.Iter()
+		raw := bson.Raw{}
+		for iter.Next(&raw) {
+			if err = handler(raw.Unmarshal); err != nil {
+				break
+			}
+		}
+		if closeErr := iter.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err == dal.ErrStopIteration {
+		err = nil
+	}
+	finishSpan(span, err)
+	return err
 }
 
 // Insert 插入数据, docs 可以为 单个数据 或者 多个数据
 func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
-	c.dbc.Refresh()
-	return c.dbc.DB(c.dbname).C(c.collName).Insert(util.ConverToInterfaceSlice(docs)...)
+	session, err := c.session(ctx)
+	if err != nil {
+		return err
+	}
+	span := startSpan(ctx, "insert", c.collName)
+	if session != nil {
+		err = session.Collection(c.collName).InsertMany(ctx, util.ConverToInterfaceSlice(docs), nil)
+	} else {
+		c.dbc.Refresh()
+		err = c.dbc.DB(c.dbname).C(c.collName).Insert(util.ConverToInterfaceSlice(docs)...)
+	}
+	finishSpan(span, err)
+	return err
 }
 
 // Update 更新数据
 func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
-	c.dbc.Refresh()
-	data := bson.M{"$set": doc}
-	_, err := c.dbc.DB(c.dbname).C(c.collName).UpdateAll(filter, data)
+	session, err := c.session(ctx)
+	if err != nil {
+		return err
+	}
+	span := startSpan(ctx, "update", c.collName)
+	if session != nil {
+		// UpdateMany of the session sets the fields by itself
+		_, err = session.Collection(c.collName).UpdateMany(ctx, filter, doc, nil)
+	} else {
+		c.dbc.Refresh()
+		_, err = c.dbc.DB(c.dbname).C(c.collName).UpdateAll(filter, bson.M{"$set": doc})
+	}
+	finishSpan(span, err)
 	return err
 }
 
 // Delete 删除数据
 func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
-	c.dbc.Refresh()
-	_, err := c.dbc.DB(c.dbname).C(c.collName).RemoveAll(filter)
+	session, err := c.session(ctx)
+	if err != nil {
+		return err
+	}
+	span := startSpan(ctx, "delete", c.collName)
+	if session != nil {
+		_, err = session.Collection(c.collName).DeleteMany(ctx, filter, nil)
+	} else {
+		c.dbc.Refresh()
+		_, err = c.dbc.DB(c.dbname).C(c.collName).RemoveAll(filter)
+	}
+	finishSpan(span, err)
 	return err
 }
 
 // NextSequence 获取新序列号(非事务)
 func (c *Mongo) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
-	c.dbc.Refresh()
-	coll := c.dbc.DB(c.dbname).C("cc_idgenerator")
-	change := mgo.Change{
-		Update: bson.M{
-			"$inc":         bson.M{"SequenceID": int64(1)},
-			"$setOnInsert": bson.M{"create_time": time.Now()},
-			"$set":         bson.M{"last_time": time.Now()},
-		},
-		ReturnNew: true,
-		Upsert:    true,
+	coll := &Collection{collName: "cc_idgenerator", Mongo: c}
+	update := bson.M{
+		"$inc":         bson.M{"SequenceID": int64(1)},
+		"$setOnInsert": bson.M{"create_time": time.Now()},
+		"$set":         bson.M{"last_time": time.Now()},
 	}
 	doc := Idgen{}
-
-	_, err := coll.Find(bson.M{"_id": sequenceName}).Apply(change, &doc)
+	err := coll.findOneAndModify(bson.M{"_id": sequenceName}, update, dal.ModifyOptions{Upsert: true, ReturnNew: true}, &doc)
 	if err != nil {
 		return 0, err
 	}
@@ -229,26 +355,6 @@ type Idgen struct {
 	SequenceID uint64 `bson:"SequenceID"`
 }
 
-// StartTransaction 开启新事务
-func (c *Mongo) StartTransaction(ctx context.Context) (dal.DB, error) {
-	return c, nil
-}
-
-// Commit 提交事务
-func (c *Mongo) Commit(ctx context.Context) error {
-	return nil
-}
-
-// Abort 取消事务
-func (c *Mongo) Abort(ctx context.Context) error {
-	return nil
-}
-
-// TxnInfo 当前事务信息，用于事务发起者往下传递
-func (c *Mongo) TxnInfo() *types.Transaction {
-	return &types.Transaction{}
-}
-
 // HasTable 判断是否存在集合
 func (c *Mongo) HasTable(collName string) (bool, error) {
 	c.dbc.Refresh()
@@ -277,19 +383,34 @@ func (c *Mongo) CreateTable(collName string) error {
 	return c.dbc.DB(c.dbname).C(collName).Create(&mgo.CollectionInfo{})
 }
 
+// Explain 查询的执行计划
+func (c *Mongo) Explain(ctx context.Context, collName string, filter dal.Filter, sort string, result interface{}) error {
+	c.dbc.Refresh()
+	query := c.dbc.DB(c.dbname).C(collName).Find(filter)
+	if sort != "" {
+		query = query.Sort(strings.Split(sort, ",")...)
+	}
+	return query.Explain(result)
+}
+
 // CreateIndex 创建索引
 func (c *Collection) CreateIndex(ctx context.Context, index dal.Index) error {
 	c.dbc.Refresh()
 	keys := []string{}
-	for key := range index.Keys {
-		keys = append(keys, key)
+	for _, key := range index.Keys {
+		if key.Direction < 0 {
+			keys = append(keys, "-"+key.Key)
+			continue
+		}
+		keys = append(keys, key.Key)
 	}
 
 	i := mgo.Index{
-		Key:        keys,
-		Name:       index.Name,
-		Unique:     index.Unique,
-		Background: index.Background,
+		Key:         keys,
+		Name:        index.Name,
+		Unique:      index.Unique,
+		Background:  index.Background,
+		ExpireAfter: time.Duration(index.ExpireAfterSeconds) * time.Second,
 	}
 	return c.dbc.DB(c.dbname).C(c.collName).EnsureIndex(i)
 }
@@ -310,13 +431,12 @@ func (c *Collection) Indexes(ctx context.Context) ([]dal.Index, error) {
 
 	indexs := []dal.Index{}
 	for _, dbindex := range dbindexs {
-		keys := map[string]int32{}
+		keys := make([]dal.IndexKey, 0, len(dbindex.Key))
 		for _, key := range dbindex.Key {
 			if strings.HasPrefix(key, "-") {
-				key = strings.TrimLeft(key, "-")
-				keys[key] = -1
+				keys = append(keys, dal.IndexKey{Key: strings.TrimLeft(key, "-"), Direction: -1})
 			} else {
-				keys[key] = 1
+				keys = append(keys, dal.IndexKey{Key: key, Direction: 1})
 			}
 		}
 
@@ -324,6 +444,7 @@ func (c *Collection) Indexes(ctx context.Context) ([]dal.Index, error) {
 		index.Name = dbindex.Name
 		index.Unique = dbindex.Unique
 		index.Background = dbindex.Background
+		index.ExpireAfterSeconds = int32(dbindex.ExpireAfter / time.Second)
 		index.Keys = keys
 		indexs = append(indexs, index)
 	}
@@ -358,10 +479,50 @@ func (c *Collection) DropColumn(ctx context.Context, field string) error {
 
 // AggregateAll aggregate all operation
 func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
-	return c.dbc.DB(c.dbname).C(c.collName).Pipe(pipeline).All(result)
+	session, err := c.session(ctx)
+	if err != nil {
+		return err
+	}
+	span := startSpan(ctx, "aggregate", c.collName)
+	if session != nil {
+		err = session.Collection(c.collName).AggregateAll(ctx, pipeline, nil, result)
+	} else {
+		db, done := c.readDB(ctx, nil)
+		err = db.C(c.collName).Pipe(pipeline).All(result)
+		done()
+	}
+	finishSpan(span, err)
+	return err
 }
 
 // AggregateOne aggregate one operation
 func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
-	return c.dbc.DB(c.dbname).C(c.collName).Pipe(pipeline).One(result)
+	session, err := c.session(ctx)
+	if err != nil {
+		return err
+	}
+	span := startSpan(ctx, "aggregate", c.collName)
+	if session != nil {
+		err = session.Collection(c.collName).AggregateOne(ctx, pipeline, nil, result)
+	} else {
+		db, done := c.readDB(ctx, nil)
+		err = db.C(c.collName).Pipe(pipeline).One(result)
+		done()
+	}
+	finishSpan(span, err)
+	return err
+}
+
+// startSpan start a span of the db operation in the trace of the request
+func startSpan(ctx context.Context, op, collName string) *trace.Span {
+	span := trace.StartSpan("mongo "+op, trace.SpanKindClient, trace.SpanContextFromContext(ctx))
+	span.SetTag("db.collection", collName)
+	return span
+}
+
+func finishSpan(span *trace.Span, err error) {
+	if err != mgo.ErrNotFound && err != dal.ErrDocumentNotFound {
+		span.SetError(err)
+	}
+	span.Finish()
 }
@@ -52,3 +52,15 @@ func BenchmarkLocalCUD(b *testing.B) {
 
 	}
 }
+
+func TestSetDefaultReadOption(t *testing.T) {
+	db := &Mongo{}
+	require.NoError(t, db.SetDefaultReadOption(dal.ReadOption{Preference: dal.SecondaryPreferred}))
+	require.Equal(t, dal.SecondaryPreferred, db.readOpt.Preference)
+
+	err := db.SetDefaultReadOption(dal.ReadOption{Preference: dal.Secondary, MaxStaleness: 2 * time.Minute})
+	require.Equal(t, ErrMaxStalenessNotSupported, err)
+	require.Equal(t, dal.SecondaryPreferred, db.readOpt.Preference)
+
+	require.Error(t, db.SetDefaultReadOption(dal.ReadOption{Preference: "fastest"}))
+}
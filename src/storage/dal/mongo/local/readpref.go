@@ -0,0 +1,68 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"errors"
+
+	"configcenter/src/storage/dal"
+
+	"gopkg.in/mgo.v2"
+)
+
+var readModes = map[dal.ReadPreference]mgo.Mode{
+	dal.Primary:            mgo.Primary,
+	dal.PrimaryPreferred:   mgo.PrimaryPreferred,
+	dal.Secondary:          mgo.Secondary,
+	dal.SecondaryPreferred: mgo.SecondaryPreferred,
+	dal.Nearest:            mgo.Nearest,
+}
+
+// ErrMaxStalenessNotSupported mgo can't limit the staleness of the secondaries it reads
+var ErrMaxStalenessNotSupported = errors.New("max staleness is not supported by the local mongo driver")
+
+// SetDefaultReadOption set the read option of the secondary eligible reads,
+// the max staleness is rejected as mgo doesn't support it
+func (c *Mongo) SetDefaultReadOption(opt dal.ReadOption) error {
+	if err := opt.Validate(); err != nil {
+		return err
+	}
+	if opt.MaxStaleness != 0 {
+		return ErrMaxStalenessNotSupported
+	}
+	c.readOpt = opt
+	return nil
+}
+
+// ReadPreference 设置读选项
+func (f *Find) ReadPreference(opt dal.ReadOption) dal.Find {
+	f.readOpt = &opt
+	return f
+}
+
+// readDB returns the database to read with the read option resolved, done should be called after reading.
+// the max staleness is not supported by mgo, so the reads with it are served by the primary
+// which is never stale, rather than the secondaries which may be staler than it allows
+func (c *Mongo) readDB(ctx context.Context, explicit *dal.ReadOption) (db *mgo.Database, done func()) {
+	opt := dal.ResolveReadOption(ctx, explicit, c.readOpt)
+	mode, ok := readModes[opt.Preference]
+	if !ok || mode == mgo.Primary || opt.MaxStaleness != 0 {
+		c.dbc.Refresh()
+		return c.dbc.DB(c.dbname), func() {}
+	}
+
+	session := c.dbc.Copy()
+	session.SetMode(mode, true)
+	return session.DB(c.dbname), session.Close
+}
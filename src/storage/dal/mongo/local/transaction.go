@@ -0,0 +1,297 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/mongo"
+	"configcenter/src/storage/mongodb"
+	"configcenter/src/storage/mongodb/driver"
+	"configcenter/src/storage/mongodb/options/findopt"
+	"configcenter/src/storage/types"
+
+	"github.com/rs/xid"
+)
+
+const (
+	// the label of the commit errors which are safe to retry the commit
+	unknownCommitResultLabel = "UnknownTransactionCommitResult"
+	maxCommitRetry           = 3
+	// mongodb aborts the transactions which last longer than 60s by default,
+	// so the sessions which last longer than txnLifeLimit are useless
+	txnLifeLimit = 2 * time.Minute
+)
+
+// NewMgoWithTransaction returns new RDB which supports multi-document transactions
+// by the sessions of the mongodb replica set, mongodb 4.0+ is required. the sessions
+// are kept in the process, so the transactions can only be joined in the process.
+func NewMgoWithTransaction(uri string, timeout time.Duration) (*Mongo, error) {
+	db, err := NewMgo(uri, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	client := driver.NewClient(uri)
+	if err := client.Open(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	db.txn = newTxnManager(client)
+	go db.txn.reconcile()
+	return db, nil
+}
+
+// NewMgoByConfig returns new RDB, which supports the transactions if they are
+// managed by the db client itself in the config
+func NewMgoByConfig(config mongo.Config, timeout time.Duration) (*Mongo, error) {
+	if config.Transaction == mongo.TransactionLocal {
+		return NewMgoWithTransaction(config.BuildURI(), timeout)
+	}
+	return NewMgo(config.BuildURI(), timeout)
+}
+
+// StartTransaction 开启新事务
+func (c *Mongo) StartTransaction(ctx context.Context) (dal.DB, error) {
+	if c.txn == nil {
+		return nil, dal.ErrNotImplemented
+	}
+	if c.TxnID != "" {
+		return nil, dal.ErrTransactionStated
+	}
+
+	requestID := ""
+	if opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption); ok {
+		requestID = opt.RequestID
+	}
+
+	session, err := c.txn.start()
+	if err != nil {
+		blog.Errorf("start transaction failed, rid: %s, err: %v", requestID, err)
+		return nil, err
+	}
+
+	clone := c.Clone().(*Mongo)
+	clone.TxnID = session.txnID
+	clone.RequestID = requestID
+	return clone, nil
+}
+
+// Commit 提交事务
+func (c *Mongo) Commit(ctx context.Context) error {
+	if c.txn == nil {
+		return dal.ErrNotImplemented
+	}
+	session := c.txn.remove(c.TxnID)
+	c.TxnID = "" // clear TxnID
+	if session == nil {
+		return dal.ErrTransactionNotFound
+	}
+	defer session.Close()
+
+	var err error
+	for retry := 0; retry < maxCommitRetry; retry++ {
+		if err = session.CommitTransaction(); err == nil || !hasErrorLabel(err, unknownCommitResultLabel) {
+			break
+		}
+		blog.Warnf("commit transaction %s with unknown result, retry: %d, rid: %s, err: %v", session.txnID, retry, c.RequestID, err)
+	}
+	if err != nil {
+		blog.Errorf("commit transaction %s failed, rid: %s, err: %v", session.txnID, c.RequestID, err)
+	}
+	return err
+}
+
+// Abort 取消事务
+func (c *Mongo) Abort(ctx context.Context) error {
+	if c.txn == nil {
+		return dal.ErrNotImplemented
+	}
+	session := c.txn.remove(c.TxnID)
+	c.TxnID = "" // clear TxnID
+	if session == nil {
+		return dal.ErrTransactionNotFound
+	}
+	defer session.Close()
+
+	if err := session.AbortTransaction(); err != nil {
+		blog.Errorf("abort transaction %s failed, rid: %s, err: %v", session.txnID, c.RequestID, err)
+		return err
+	}
+	return nil
+}
+
+// TxnInfo 当前事务信息，用于事务发起者往下传递
+func (c *Mongo) TxnInfo() *types.Transaction {
+	txn := &types.Transaction{
+		TxnID:     c.TxnID,
+		RequestID: c.RequestID,
+	}
+	if c.txn == nil {
+		return txn
+	}
+	if session := c.txn.get(c.TxnID); session != nil {
+		txn.Status = types.TxStatusOnProgress
+		txn.CreateTime = session.createTime
+		txn.LastTime = session.createTime
+	}
+	return txn
+}
+
+// session returns the session of the transaction which the operation joins, the
+// transaction is the one started by this db or the one in the join option of ctx.
+// returns nil if the operation is not in a transaction.
+func (c *Mongo) session(ctx context.Context) (*txnSession, error) {
+	if c.txn == nil {
+		return nil, nil
+	}
+
+	txnID := c.TxnID
+	if txnID == "" {
+		opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
+		if !ok || opt.TxnID == "" {
+			return nil, nil
+		}
+		txnID = opt.TxnID
+	}
+
+	session := c.txn.get(txnID)
+	if session == nil {
+		blog.Errorf("transaction %s not found, it may be committed, aborted or expired", txnID)
+		return nil, dal.ErrTransactionNotFound
+	}
+	return session, nil
+}
+
+// findOpts converts the find operation into the options of the session collection
+func (f *Find) findOpts() *findopt.Many {
+	opts := &findopt.Many{}
+	opts.Skip = int64(f.start)
+	opts.Limit = int64(f.limit)
+	for field, show := range f.projection {
+		opts.Fields = append(opts.Fields, findopt.FieldItem{Name: field, Hide: show == false})
+	}
+	opts.Sort = findopt.NewSortItems(strings.Join(f.sort, ","))
+	return opts
+}
+
+// hasErrorLabel check whether the error returned by mongodb has the label
+func hasErrorLabel(err error, label string) bool {
+	labeled, ok := err.(interface {
+		HasErrorLabel(string) bool
+	})
+	return ok && labeled.HasErrorLabel(label)
+}
+
+type txnSession struct {
+	mongodb.Session
+	txnID      string
+	createTime time.Time
+}
+
+// txnManager keeps the sessions of the transactions in progress
+type txnManager struct {
+	client   mongodb.CommonClient
+	sessions map[string]*txnSession
+	lock     sync.RWMutex
+	done     chan struct{}
+	once     sync.Once
+}
+
+func newTxnManager(client mongodb.CommonClient) *txnManager {
+	return &txnManager{
+		client:   client,
+		sessions: map[string]*txnSession{},
+		done:     make(chan struct{}),
+	}
+}
+
+func (tm *txnManager) start() (*txnSession, error) {
+	session := tm.client.Session().Create()
+	if err := session.Open(); err != nil {
+		return nil, err
+	}
+	if err := session.StartTransaction(); err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	txn := &txnSession{
+		Session:    session,
+		txnID:      xid.New().String(),
+		createTime: time.Now(),
+	}
+	tm.lock.Lock()
+	tm.sessions[txn.txnID] = txn
+	tm.lock.Unlock()
+	return txn, nil
+}
+
+func (tm *txnManager) get(txnID string) *txnSession {
+	tm.lock.RLock()
+	defer tm.lock.RUnlock()
+	return tm.sessions[txnID]
+}
+
+func (tm *txnManager) remove(txnID string) *txnSession {
+	tm.lock.Lock()
+	defer tm.lock.Unlock()
+	session := tm.sessions[txnID]
+	delete(tm.sessions, txnID)
+	return session
+}
+
+// reconcile aborts the transactions which are neither committed nor aborted in time
+func (tm *txnManager) reconcile() {
+	ticker := time.NewTicker(txnLifeLimit / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-tm.done:
+			return
+		case <-ticker.C:
+		}
+
+		expired := []*txnSession{}
+		tm.lock.Lock()
+		for txnID, session := range tm.sessions {
+			if time.Since(session.createTime) > txnLifeLimit {
+				expired = append(expired, session)
+				delete(tm.sessions, txnID)
+			}
+		}
+		tm.lock.Unlock()
+
+		for _, session := range expired {
+			blog.Warnf("transaction %s expired, abort it", session.txnID)
+			if err := session.AbortTransaction(); err != nil {
+				blog.Errorf("abort expired transaction %s failed, err: %v", session.txnID, err)
+			}
+			session.Close()
+		}
+	}
+}
+
+func (tm *txnManager) close() {
+	tm.once.Do(func() {
+		close(tm.done)
+		tm.client.Close()
+	})
+}
@@ -0,0 +1,64 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/mongodb/options/findopt"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionNotImplemented(t *testing.T) {
+	db := &Mongo{}
+
+	_, err := db.StartTransaction(context.Background())
+	require.Equal(t, dal.ErrNotImplemented, err)
+	require.Equal(t, dal.ErrNotImplemented, db.Commit(context.Background()))
+	require.Equal(t, dal.ErrNotImplemented, db.Abort(context.Background()))
+	require.Equal(t, "", db.TxnInfo().TxnID)
+
+	// operations never join a transaction without the transaction support
+	ctx := context.WithValue(context.Background(), common.CCContextKeyJoinOption, dal.JoinOption{TxnID: "txn"})
+	session, err := db.session(ctx)
+	require.NoError(t, err)
+	require.Nil(t, session)
+}
+
+func TestTransactionNotFound(t *testing.T) {
+	db := &Mongo{txn: newTxnManager(nil)}
+
+	require.Equal(t, dal.ErrTransactionNotFound, db.Commit(context.Background()))
+	require.Equal(t, dal.ErrTransactionNotFound, db.Abort(context.Background()))
+
+	db.TxnID = "unknown"
+	_, err := db.StartTransaction(context.Background())
+	require.Equal(t, dal.ErrTransactionStated, err)
+	_, err = db.session(context.Background())
+	require.Equal(t, dal.ErrTransactionNotFound, err)
+}
+
+func TestFindOpts(t *testing.T) {
+	db := &Mongo{}
+	find := db.Table("cc_test").Find(nil).Fields("name").Sort("-create_time, bk_host_id").Start(10).Limit(20).(*Find)
+
+	opts := find.findOpts()
+	require.Equal(t, int64(10), opts.Skip)
+	require.Equal(t, int64(20), opts.Limit)
+	require.ElementsMatch(t, []findopt.FieldItem{{Name: "_id", Hide: true}, {Name: "name"}}, opts.Fields)
+	require.Equal(t, []findopt.SortItem{{Name: "create_time", Descending: true}, {Name: "bk_host_id"}}, opts.Sort)
+}
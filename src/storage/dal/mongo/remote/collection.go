@@ -29,6 +29,7 @@ type Collection struct {
 	TxnID      string // 事务ID,uuid
 	collection string // 集合名
 	rpc        rpc.Client
+	readOpt    dal.ReadOption // 可读从节点的查询默认使用的读选项
 }
 
 // Find 查询多个并反序列化到 Result
@@ -71,7 +72,7 @@ func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interfac
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.CallContext(ctx, types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -81,6 +82,57 @@ func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interfac
 	return nil
 }
 
+// Upsert 更新数据, 没有匹配的数据时插入
+func (c *Collection) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	update := types.Document{"$set": doc}
+	return c.FindOneAndModify(ctx, filter, update, dal.ModifyOptions{Upsert: true}, &types.Document{})
+}
+
+// FindOneAndModify 查找并修改一条数据
+func (c *Collection) FindOneAndModify(ctx context.Context, filter dal.Filter, update interface{}, opts dal.ModifyOptions, result interface{}) error {
+	// build msg
+	msg := types.OPFindAndModifyOperation{}
+	msg.OPCode = types.OPFindAndModifyCode
+	msg.Collection = c.collection
+	if err := msg.DOC.Encode(update); err != nil {
+		return err
+	}
+	if err := msg.Selector.Encode(filter); err != nil {
+		return err
+	}
+	msg.Upsert = opts.Upsert
+	msg.ReturnNew = opts.ReturnNew
+
+	// set txn
+	opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
+	if ok {
+		msg.RequestID = opt.RequestID
+		msg.TxnID = opt.TxnID
+	}
+	if c.TxnID != "" {
+		msg.TxnID = c.TxnID
+	}
+
+	// call
+	reply := types.OPReply{}
+	err := c.rpc.CallContext(ctx, types.CommandRDBOperation, &msg, &reply)
+	if err != nil {
+		return err
+	}
+	if !reply.Success {
+		return errors.New(reply.Message)
+	}
+
+	// the document inserted is not returned when the old one is required
+	if len(reply.Docs) <= 0 {
+		if opts.Upsert {
+			return nil
+		}
+		return dal.ErrDocumentNotFound
+	}
+	return reply.Docs[0].Decode(result)
+}
+
 // Delete 删除数据
 func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
 	// build msg
@@ -103,7 +155,7 @@ func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.CallContext(ctx, types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -113,6 +165,49 @@ func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
 	return nil
 }
 
+// BulkWrite 批量写, ordered 为 true 时遇到错误即停止
+func (c *Collection) BulkWrite(ctx context.Context, models []dal.BulkModel, ordered bool) (*types.BulkWriteResult, error) {
+	// build msg
+	msg := types.OPBulkWriteOperation{}
+	msg.OPCode = types.OPBulkWriteCode
+	msg.Collection = c.collection
+	msg.Ordered = ordered
+	for _, model := range models {
+		item := types.BulkWriteModel{Type: model.Type}
+		if err := item.Selector.Encode(model.Filter); err != nil {
+			return nil, err
+		}
+		if err := item.DOC.Encode(model.Doc); err != nil {
+			return nil, err
+		}
+		msg.Models = append(msg.Models, item)
+	}
+
+	// set txn
+	opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
+	if ok {
+		msg.RequestID = opt.RequestID
+		msg.TxnID = opt.TxnID
+	}
+	if c.TxnID != "" {
+		msg.TxnID = c.TxnID
+	}
+
+	// call
+	reply := types.OPReply{}
+	err := c.rpc.CallContext(ctx, types.CommandRDBOperation, &msg, &reply)
+	if err != nil {
+		return nil, err
+	}
+	if !reply.Success {
+		return nil, errors.New(reply.Message)
+	}
+	if len(reply.BulkWrite.Errors) > 0 {
+		return &reply.BulkWrite, dal.ErrBulkWrite
+	}
+	return &reply.BulkWrite, nil
+}
+
 // Insert 插入数据, docs 可以为 单个数据 或者 多个数据
 func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
 
@@ -137,7 +232,7 @@ func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.CallContext(ctx, types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -197,10 +292,11 @@ func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, res
 	if c.TxnID != "" {
 		msg.TxnID = c.TxnID
 	}
+	msg.ReadPreference = readPreference(dal.ResolveReadOption(ctx, nil, c.readOpt))
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, msg, &reply)
+	err := c.rpc.CallContext(ctx, types.CommandRDBOperation, msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -15,16 +15,19 @@ package remote
 import (
 	"context"
 	"errors"
+	"time"
 
 	"configcenter/src/common"
 	"configcenter/src/storage/dal"
+	"configcenter/src/storage/rpc"
 	"configcenter/src/storage/types"
 )
 
 // Find define a find operation
 type Find struct {
 	*Collection
-	msg *types.OPFindOperation
+	msg     *types.OPFindOperation
+	readOpt *dal.ReadOption
 }
 
 // Fields 查询字段
@@ -55,6 +58,12 @@ func (f *Find) Limit(limit uint64) dal.Find {
 	return f
 }
 
+// ReadPreference 设置读选项, 优先于 ctx 中的读选项和服务的默认读选项
+func (f *Find) ReadPreference(opt dal.ReadOption) dal.Find {
+	f.readOpt = &opt
+	return f
+}
+
 // All 查询多个
 func (f *Find) All(ctx context.Context, result interface{}) error {
 	// set txn
@@ -66,10 +75,11 @@ func (f *Find) All(ctx context.Context, result interface{}) error {
 	if f.TxnID != "" {
 		f.msg.TxnID = f.TxnID
 	}
+	f.msg.ReadPreference = readPreference(dal.ResolveReadOption(ctx, f.readOpt, f.Collection.readOpt))
 
 	// call
 	reply := types.OPReply{}
-	err := f.rpc.Call(types.CommandRDBOperation, f.msg, &reply)
+	err := f.rpc.CallContext(ctx, types.CommandRDBOperation, f.msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -90,10 +100,11 @@ func (f *Find) One(ctx context.Context, result interface{}) error {
 	if f.TxnID != "" {
 		f.msg.TxnID = f.TxnID
 	}
+	f.msg.ReadPreference = readPreference(dal.ResolveReadOption(ctx, f.readOpt, f.Collection.readOpt))
 
 	// call
 	reply := types.OPReply{}
-	err := f.rpc.Call(types.CommandRDBOperation, f.msg, &reply)
+	err := f.rpc.CallContext(ctx, types.CommandRDBOperation, f.msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -121,10 +132,11 @@ func (f *Find) Count(ctx context.Context) (uint64, error) {
 	if f.TxnID != "" {
 		f.msg.TxnID = f.TxnID
 	}
+	f.msg.ReadPreference = readPreference(dal.ResolveReadOption(ctx, f.readOpt, f.Collection.readOpt))
 
 	// call
 	reply := types.OPReply{}
-	err := f.rpc.Call(types.CommandRDBOperation, f.msg, &reply)
+	err := f.rpc.CallContext(ctx, types.CommandRDBOperation, f.msg, &reply)
 	if err != nil {
 		return 0, err
 	}
@@ -133,3 +145,65 @@ func (f *Find) Count(ctx context.Context) (uint64, error) {
 	}
 	return reply.Count, nil
 }
+
+// BatchSize 游标每批获取的数量
+func (f *Find) BatchSize(size uint64) dal.Find {
+	f.msg.BatchSize = size
+	return f
+}
+
+// Iterate 以游标方式逐个遍历，查询结果由 tmserver 分批推送
+func (f *Find) Iterate(ctx context.Context, handler dal.IterateHandler) error {
+	// build msg
+	f.msg.OPCode = types.OPFindCode
+
+	// set txn
+	opt, ok := ctx.Value(common.CCContextKeyJoinOption).(dal.JoinOption)
+	if ok {
+		f.msg.RequestID = opt.RequestID
+		f.msg.TxnID = opt.TxnID
+	}
+	if f.TxnID != "" {
+		f.msg.TxnID = f.TxnID
+	}
+	f.msg.ReadPreference = readPreference(dal.ResolveReadOption(ctx, f.readOpt, f.Collection.readOpt))
+
+	// call
+	stream, err := f.rpc.CallStreamContext(ctx, types.CommandRDBStreamOperation, f.msg)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	for {
+		// ask for the next batch, the stream is stoped by server after the last batch
+		if err = stream.Send(true); err != nil && err != rpc.ErrStreamStoped {
+			return err
+		}
+		reply := types.OPReply{}
+		if err = stream.Recv(&reply); err == rpc.ErrStreamStoped {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !reply.Success {
+			return errors.New(reply.Message)
+		}
+		for _, doc := range reply.Docs {
+			if err = handler(doc.Decode); err == dal.ErrStopIteration {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func readPreference(opt dal.ReadOption) types.ReadPreference {
+	return types.ReadPreference{
+		Mode:                string(opt.Preference),
+		MaxStalenessSeconds: int64(opt.MaxStaleness / time.Second),
+	}
+}
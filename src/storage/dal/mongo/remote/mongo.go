@@ -37,6 +37,7 @@ type Mongo struct {
 	rpc       rpc.Client
 	getServer types.GetServerFunc
 	parent    *Mongo
+	readOpt   dal.ReadOption // 可读从节点的查询默认使用的读选项
 
 	enableTransaction bool
 }
@@ -44,9 +45,13 @@ type Mongo struct {
 // NewWithDiscover returns new DB
 func NewWithDiscover(getServer types.GetServerFunc, config mongo.Config) (db dal.DB, err error) {
 	var enableTransaction bool
-	if config.Transaction == "enable" {
+	if config.Transaction == mongo.TransactionEnable {
 		enableTransaction = true
 	}
+	readOpt, err := config.GetReadOption()
+	if err != nil {
+		return nil, err
+	}
 
 	if !enableTransaction {
 		blog.Warnf("not enable transaction")
@@ -61,6 +66,7 @@ func NewWithDiscover(getServer types.GetServerFunc, config mongo.Config) (db dal
 	}
 	return &Mongo{
 		rpc:               pool,
+		readOpt:           readOpt,
 		enableTransaction: enableTransaction,
 	}, nil
 }
@@ -94,6 +100,7 @@ func (c *Mongo) Clone() dal.DB {
 		RequestID:         c.RequestID,
 		rpc:               c.rpc,
 		parent:            c,
+		readOpt:           c.readOpt,
 		enableTransaction: c.enableTransaction,
 	}
 	return &nc
@@ -129,6 +136,7 @@ func (c *Mongo) Table(collection string) dal.Table {
 	}
 	col.collection = collection
 	col.rpc = c.rpc
+	col.readOpt = c.readOpt
 
 	return &col
 }
@@ -163,7 +171,7 @@ func (c *Mongo) NextSequence(ctx context.Context, sequenceName string) (uint64,
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.CallContext(ctx, types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return 0, err
 	}
@@ -25,8 +25,7 @@ import (
 // StartTransaction create a new transaction
 func (c *Mongo) StartTransaction(ctx context.Context) (dal.DB, error) {
 	if !c.enableTransaction {
-		blog.Warnf("not enable transaction")
-		return c, nil
+		return nil, dal.ErrNotImplemented
 	}
 	if c.TxnID != "" {
 		blog.Warnf("transaction started")
@@ -44,7 +43,7 @@ func (c *Mongo) StartTransaction(ctx context.Context) (dal.DB, error) {
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.CallContext(ctx, types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return nil, err
 	}
@@ -61,8 +60,7 @@ func (c *Mongo) StartTransaction(ctx context.Context) (dal.DB, error) {
 // Commit 提交事务
 func (c *Mongo) Commit(ctx context.Context) error {
 	if !c.enableTransaction {
-		blog.Warnf("not enable transaction")
-		return nil
+		return dal.ErrNotImplemented
 	}
 	if c.TxnID == "" {
 		blog.Warnf("TxnID is empty")
@@ -74,7 +72,7 @@ func (c *Mongo) Commit(ctx context.Context) error {
 	msg.TxnID = c.TxnID
 
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.CallContext(ctx, types.CommandRDBOperation, &msg, &reply)
 	c.TxnID = "" // clear TxnID
 	if err != nil {
 		return err
@@ -88,8 +86,7 @@ func (c *Mongo) Commit(ctx context.Context) error {
 // Abort 取消事务
 func (c *Mongo) Abort(ctx context.Context) error {
 	if !c.enableTransaction {
-		blog.Warnf("not enable transaction")
-		return nil
+		return dal.ErrNotImplemented
 	}
 	if c.TxnID == "" {
 		blog.Warnf("TxnID is empty")
@@ -101,7 +98,7 @@ func (c *Mongo) Abort(ctx context.Context) error {
 	msg.TxnID = c.TxnID
 
 	reply := types.OPReply{}
-	err := c.rpc.Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.CallContext(ctx, types.CommandRDBOperation, &msg, &reply)
 	c.TxnID = "" // clear TxnID
 	if err != nil {
 		return err
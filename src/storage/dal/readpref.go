@@ -0,0 +1,116 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dal
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+)
+
+// ReadPreference 读偏好, 决定读操作由复制集的哪些节点处理
+type ReadPreference string
+
+// the read preferences of the mongodb replica set
+const (
+	// Primary 只读主节点
+	Primary ReadPreference = "primary"
+	// PrimaryPreferred 优先读主节点, 主节点不可用时读从节点
+	PrimaryPreferred ReadPreference = "primaryPreferred"
+	// Secondary 只读从节点
+	Secondary ReadPreference = "secondary"
+	// SecondaryPreferred 优先读从节点, 从节点不可用时读主节点
+	SecondaryPreferred ReadPreference = "secondaryPreferred"
+	// Nearest 读网络延迟最低的节点
+	Nearest ReadPreference = "nearest"
+)
+
+// MinMaxStaleness the minimum max staleness mongodb accepts
+const MinMaxStaleness = 90 * time.Second
+
+// ReadOption 读选项
+type ReadOption struct {
+	// Preference 读偏好, 为空时读主节点
+	Preference ReadPreference
+	// MaxStaleness 从节点相对主节点的最大延迟, 延迟更大的从节点不会处理读操作, 0 表示不限制
+	MaxStaleness time.Duration
+}
+
+// IsPrimary returns whether the reads are served by the primary only
+func (o ReadOption) IsPrimary() bool {
+	return o.Preference == "" || o.Preference == Primary
+}
+
+// Validate validate the read option
+func (o ReadOption) Validate() error {
+	switch o.Preference {
+	case "", Primary:
+		if o.MaxStaleness != 0 {
+			return fmt.Errorf("max staleness is not allowed with the read preference primary")
+		}
+		return nil
+	case PrimaryPreferred, Secondary, SecondaryPreferred, Nearest:
+	default:
+		return fmt.Errorf("unknown read preference %s", o.Preference)
+	}
+	if o.MaxStaleness != 0 && o.MaxStaleness < MinMaxStaleness {
+		return fmt.Errorf("max staleness %v is less than %v", o.MaxStaleness, MinMaxStaleness)
+	}
+	return nil
+}
+
+// ParseReadOption parse the read option from the config, maxStalenessSeconds can be empty
+func ParseReadOption(preference, maxStalenessSeconds string) (ReadOption, error) {
+	opt := ReadOption{Preference: ReadPreference(preference)}
+	if maxStalenessSeconds != "" {
+		seconds, err := strconv.Atoi(maxStalenessSeconds)
+		if err != nil {
+			return ReadOption{}, fmt.Errorf("invalid max staleness seconds %s: %v", maxStalenessSeconds, err)
+		}
+		opt.MaxStaleness = time.Duration(seconds) * time.Second
+	}
+	if err := opt.Validate(); err != nil {
+		return ReadOption{}, err
+	}
+	return opt, nil
+}
+
+// WithReadOption 设置 ctx 中读操作的读选项, Find.ReadPreference 设置的读选项优先
+func WithReadOption(ctx context.Context, opt ReadOption) context.Context {
+	return context.WithValue(ctx, common.CCContextKeyReadOption, opt)
+}
+
+// WithSecondaryEligible 标记 ctx 中的读操作可以由从节点处理, 使用服务配置的默认读选项,
+// 只应该用于能容忍读到旧数据的查询
+func WithSecondaryEligible(ctx context.Context) context.Context {
+	return context.WithValue(ctx, common.CCContextKeySecondaryEligible, true)
+}
+
+// ResolveReadOption returns the read option of a read, which is the explicit one set by the find,
+// or the one in the ctx, or the default one of the service if the ctx is secondary eligible.
+// the reads are served by the primary otherwise
+func ResolveReadOption(ctx context.Context, explicit *ReadOption, defaultOpt ReadOption) ReadOption {
+	if explicit != nil {
+		return *explicit
+	}
+	if opt, ok := ctx.Value(common.CCContextKeyReadOption).(ReadOption); ok {
+		return opt
+	}
+	if eligible, _ := ctx.Value(common.CCContextKeySecondaryEligible).(bool); eligible {
+		return defaultOpt
+	}
+	return ReadOption{Preference: Primary}
+}
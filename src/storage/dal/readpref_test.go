@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dal_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/storage/dal"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReadOption(t *testing.T) {
+	opt, err := dal.ParseReadOption("", "")
+	require.NoError(t, err)
+	require.True(t, opt.IsPrimary())
+
+	opt, err = dal.ParseReadOption("secondaryPreferred", "120")
+	require.NoError(t, err)
+	require.Equal(t, dal.ReadOption{Preference: dal.SecondaryPreferred, MaxStaleness: 120 * time.Second}, opt)
+
+	_, err = dal.ParseReadOption("secondary", "10")
+	require.Error(t, err, "max staleness less than 90s")
+	_, err = dal.ParseReadOption("primary", "120")
+	require.Error(t, err, "max staleness with primary")
+	_, err = dal.ParseReadOption("slave", "")
+	require.Error(t, err, "unknown read preference")
+	_, err = dal.ParseReadOption("nearest", "abc")
+	require.Error(t, err, "invalid max staleness")
+}
+
+func TestResolveReadOption(t *testing.T) {
+	defaultOpt := dal.ReadOption{Preference: dal.SecondaryPreferred}
+	explicit := dal.ReadOption{Preference: dal.Nearest}
+	ctx := context.Background()
+
+	// reads are served by the primary unless the ctx is secondary eligible
+	require.True(t, dal.ResolveReadOption(ctx, nil, defaultOpt).IsPrimary())
+	require.Equal(t, defaultOpt, dal.ResolveReadOption(dal.WithSecondaryEligible(ctx), nil, defaultOpt))
+
+	ctxOpt := dal.ReadOption{Preference: dal.Secondary}
+	ctx = dal.WithReadOption(dal.WithSecondaryEligible(ctx), ctxOpt)
+	require.Equal(t, ctxOpt, dal.ResolveReadOption(ctx, nil, defaultOpt))
+	require.Equal(t, explicit, dal.ResolveReadOption(ctx, &explicit, defaultOpt))
+}
@@ -0,0 +1,58 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dal
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/storage/types"
+)
+
+// RunTransaction run the operation in a new transaction of the db, the transaction is
+// committed if the operation succeeded, and aborted otherwise. the operation passes
+// the txn to the ones it calls, so that they join the transaction.
+// the operation runs with nil txn if the db is nil or does not support the transaction,
+// or ctx is already in a transaction, which the operation joins then.
+// the error of the operation is returned as it is, dbErr is returned if the transaction
+// can not be started or committed.
+func RunTransaction(ctx context.Context, db DB, dbErr error, operation func(txn *types.Transaction) error) error {
+	opt, _ := ctx.Value(common.CCContextKeyJoinOption).(JoinOption)
+	if db == nil || opt.TxnID != "" {
+		return operation(nil)
+	}
+
+	tx, err := db.StartTransaction(ctx)
+	if err == ErrNotImplemented {
+		return operation(nil)
+	}
+	if err != nil {
+		blog.Errorf("start transaction failed, err: %v, rid: %s", err, opt.RequestID)
+		return dbErr
+	}
+
+	txn := tx.TxnInfo()
+	if err := operation(txn); err != nil {
+		if txErr := tx.Abort(context.Background()); txErr != nil {
+			blog.Errorf("abort transaction %s failed, err: %v, rid: %s", txn.TxnID, txErr, opt.RequestID)
+		}
+		return err
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		blog.Errorf("commit transaction %s failed, err: %v, rid: %s", txn.TxnID, err, opt.RequestID)
+		return dbErr
+	}
+	return nil
+}
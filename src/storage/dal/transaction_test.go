@@ -0,0 +1,106 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+type txnDB struct {
+	dal.DB
+	startErr  error
+	commitErr error
+	committed bool
+	aborted   bool
+}
+
+func (d *txnDB) StartTransaction(ctx context.Context) (dal.DB, error) {
+	if d.startErr != nil {
+		return nil, d.startErr
+	}
+	return d, nil
+}
+
+func (d *txnDB) Commit(ctx context.Context) error {
+	d.committed = true
+	return d.commitErr
+}
+
+func (d *txnDB) Abort(ctx context.Context) error {
+	d.aborted = true
+	return nil
+}
+
+func (d *txnDB) TxnInfo() *types.Transaction {
+	return &types.Transaction{TxnID: "txn"}
+}
+
+func TestRunTransaction(t *testing.T) {
+	dbErr := errors.New("db error")
+	opErr := errors.New("operation error")
+	ctx := context.Background()
+
+	db := &txnDB{}
+	err := dal.RunTransaction(ctx, db, dbErr, func(txn *types.Transaction) error {
+		require.Equal(t, "txn", txn.TxnID)
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, db.committed)
+	require.False(t, db.aborted)
+
+	db = &txnDB{}
+	err = dal.RunTransaction(ctx, db, dbErr, func(txn *types.Transaction) error { return opErr })
+	require.Equal(t, opErr, err)
+	require.True(t, db.aborted)
+	require.False(t, db.committed)
+
+	db = &txnDB{commitErr: errors.New("commit failed")}
+	err = dal.RunTransaction(ctx, db, dbErr, func(txn *types.Transaction) error { return nil })
+	require.Equal(t, dbErr, err)
+
+	db = &txnDB{startErr: errors.New("start failed")}
+	err = dal.RunTransaction(ctx, db, dbErr, func(txn *types.Transaction) error {
+		t.Fatal("the operation should not run when the transaction can not be started")
+		return nil
+	})
+	require.Equal(t, dbErr, err)
+
+	// the operation runs without the transaction
+	joined := context.WithValue(ctx, common.CCContextKeyJoinOption, dal.JoinOption{TxnID: "caller"})
+	for _, c := range []struct {
+		ctx context.Context
+		db  dal.DB
+	}{
+		{ctx: ctx, db: nil},
+		{ctx: ctx, db: &txnDB{startErr: dal.ErrNotImplemented}},
+		{ctx: joined, db: &txnDB{}},
+	} {
+		ran := false
+		err = dal.RunTransaction(c.ctx, c.db, dbErr, func(txn *types.Transaction) error {
+			ran = true
+			require.Nil(t, txn)
+			return nil
+		})
+		require.NoError(t, err)
+		require.True(t, ran)
+	}
+}
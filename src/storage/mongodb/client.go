@@ -17,6 +17,8 @@ type Client interface {
 	Ping() error
 	Database() Database
 	Collection(collName string) CollectionInterface
+	// CollectionWithReadPreference returns the collection whose reads are routed by the read preference
+	CollectionWithReadPreference(collName string, pref ReadPreference) (CollectionInterface, error)
 	Session() SessionOperation
 }
 
@@ -16,6 +16,7 @@ import (
 	"context"
 
 	"configcenter/src/storage/mongodb/options/aggregateopt"
+	"configcenter/src/storage/mongodb/options/bulkwriteopt"
 	"configcenter/src/storage/mongodb/options/deleteopt"
 	"configcenter/src/storage/mongodb/options/findopt"
 	"configcenter/src/storage/mongodb/options/insertopt"
@@ -23,6 +24,9 @@ import (
 	"configcenter/src/storage/mongodb/options/updateopt"
 )
 
+// IterateHandler is called for each document of a cursor, decode unmarshals the current document
+type IterateHandler func(decode func(result interface{}) error) error
+
 // CollectionInterface collection operation methods
 type CollectionInterface interface {
 	Name() string
@@ -36,10 +40,12 @@ type CollectionInterface interface {
 	DeleteMany(ctx context.Context, filter interface{}, opts *deleteopt.Many) (*DeleteResult, error)
 
 	Find(ctx context.Context, filter interface{}, opts *findopt.Many, output interface{}) error
+	FindIterate(ctx context.Context, filter interface{}, opts *findopt.Many, handler IterateHandler) error
 	FindOne(ctx context.Context, filter interface{}, opts *findopt.One, output interface{}) error
 	FindOneAndModify(ctx context.Context, filter interface{}, update interface{}, opts *findopt.FindAndModify, output interface{}) error
 
 	AggregateOne(ctx context.Context, pipeline interface{}, opts *aggregateopt.One, output interface{}) error
+	AggregateAll(ctx context.Context, pipeline interface{}, opts *aggregateopt.Many, output interface{}) error
 
 	InsertOne(ctx context.Context, document interface{}, opts *insertopt.One) error
 	InsertMany(ctx context.Context, document []interface{}, opts *insertopt.Many) error
@@ -48,4 +54,6 @@ type CollectionInterface interface {
 	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts *updateopt.One) (*UpdateResult, error)
 
 	ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts *replaceopt.One) (*ReplaceOneResult, error)
+
+	BulkWrite(ctx context.Context, models []BulkWriteModel, opts *bulkwriteopt.Opts) (*BulkWriteResult, error)
 }
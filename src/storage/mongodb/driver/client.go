@@ -18,6 +18,8 @@ import (
 	"configcenter/src/storage/mongodb"
 
 	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+	"github.com/mongodb/mongo-go-driver/mongo/readpref"
 	"github.com/mongodb/mongo-go-driver/x/network/connstring"
 )
 
@@ -82,6 +84,24 @@ func (c *client) Collection(collName string) mongodb.CollectionInterface {
 	return newCollection(c.innerDB.innerDatabase, collName)
 }
 
+func (c *client) CollectionWithReadPreference(collName string, pref mongodb.ReadPreference) (mongodb.CollectionInterface, error) {
+	mode, err := readpref.ModeFromString(pref.Mode)
+	if nil != err {
+		return nil, err
+	}
+	readOpts := []readpref.Option{}
+	if 0 != pref.MaxStaleness {
+		readOpts = append(readOpts, readpref.WithMaxStaleness(pref.MaxStaleness))
+	}
+	rp, err := readpref.New(mode, readOpts...)
+	if nil != err {
+		return nil, err
+	}
+	return &collection{
+		innerCollection: c.innerDB.innerDatabase.Collection(collName, options.Collection().SetReadPreference(rp)),
+	}, nil
+}
+
 func (c *client) Session() mongodb.SessionOperation {
 	return newSessionOperation(c)
 }
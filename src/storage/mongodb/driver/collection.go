@@ -15,10 +15,12 @@ package driver
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 
 	"configcenter/src/storage/mongodb"
 	"configcenter/src/storage/mongodb/options/aggregateopt"
+	"configcenter/src/storage/mongodb/options/bulkwriteopt"
 	"configcenter/src/storage/mongodb/options/deleteopt"
 	"configcenter/src/storage/mongodb/options/findopt"
 	"configcenter/src/storage/mongodb/options/insertopt"
@@ -74,8 +76,8 @@ func (c *collection) CreateIndex(index mongodb.Index) error {
 	indexView := c.innerCollection.Indexes()
 
 	keys := bsonx.Doc{}
-	for key, val := range index.Keys {
-		keys = keys.Append(key, bsonx.Int32(val))
+	for _, key := range index.Keys {
+		keys = keys.Append(key.Key, bsonx.Int32(key.Direction))
 	}
 
 	indexOpts := &options.IndexOptions{
@@ -274,6 +276,64 @@ func (c *collection) Find(ctx context.Context, filter interface{}, opts *findopt
 	return decodeCusorIntoSlice(ctx, cursor, output)
 }
 
+func (c *collection) FindIterate(ctx context.Context, filter interface{}, opts *findopt.Many, handler mongodb.IterateHandler) error {
+
+	findOptions := &options.FindOptions{}
+	if nil != opts {
+		findOptions = opts.ConvertToMongoOptions()
+	}
+
+	// in a session
+	if nil != c.innerSession {
+		return mongo.WithSession(ctx, c.innerSession, func(mctx mongo.SessionContext) error {
+
+			cursor, err := c.innerCollection.Find(mctx, filter, findOptions)
+			if nil != err {
+				return err
+			}
+
+			defer cursor.Close(mctx)
+			return iterateCursor(mctx, cursor, handler)
+		})
+	}
+
+	// no session
+	cursor, err := c.innerCollection.Find(ctx, filter, findOptions)
+	if nil != err {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return iterateCursor(ctx, cursor, handler)
+}
+
+func (c *collection) AggregateAll(ctx context.Context, pipeline interface{}, opts *aggregateopt.Many, output interface{}) error {
+	aggregateOptions := &options.AggregateOptions{}
+	if nil != opts {
+		aggregateOptions = opts.ConvertToMongoOptions()
+	}
+
+	// in a session
+	if nil != c.innerSession {
+		return mongo.WithSession(ctx, c.innerSession, func(mctx mongo.SessionContext) error {
+			cursor, err := c.innerCollection.Aggregate(mctx, pipeline, aggregateOptions)
+			if nil != err {
+				return err
+			}
+
+			defer cursor.Close(mctx)
+			return decodeCusorIntoSlice(mctx, cursor, output)
+		})
+	}
+
+	// no session
+	cursor, err := c.innerCollection.Aggregate(ctx, pipeline, aggregateOptions)
+	if nil != err {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return decodeCusorIntoSlice(ctx, cursor, output)
+}
+
 func decodeCusorIntoSlice(ctx context.Context, cursor *mongo.Cursor, result interface{}) error {
 	resultv := reflect.ValueOf(result)
 	if resultv.Kind() != reflect.Ptr || resultv.Elem().Kind() != reflect.Slice {
@@ -297,6 +357,15 @@ func decodeCusorIntoSlice(ctx context.Context, cursor *mongo.Cursor, result inte
 	return nil
 }
 
+func iterateCursor(ctx context.Context, cursor *mongo.Cursor, handler mongodb.IterateHandler) error {
+	for cursor.Next(ctx) {
+		if err := handler(cursor.Decode); nil != err {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
 func (c *collection) FindOne(ctx context.Context, filter interface{}, opts *findopt.One, output interface{}) error {
 
 	findOptions := &options.FindOneOptions{}
@@ -339,18 +408,21 @@ func (c *collection) AggregateOne(ctx context.Context, pipeline interface{}, opt
 		aggregateOptions = opts.ConvertToMongoOptions()
 	}
 
-	mongo.WithSession(ctx, c.innerSession, func(mctx mongo.SessionContext) error {
-		cursor, err := c.innerCollection.Aggregate(mctx, pipeline, aggregateOptions)
-		if nil != err {
-			return err
-		}
+	// in a session
+	if nil != c.innerSession {
+		return mongo.WithSession(ctx, c.innerSession, func(mctx mongo.SessionContext) error {
+			cursor, err := c.innerCollection.Aggregate(mctx, pipeline, aggregateOptions)
+			if nil != err {
+				return err
+			}
 
-		defer cursor.Close(mctx)
-		for cursor.Next(ctx) {
-			return cursor.Decode(output)
-		}
-		return cursor.Err()
-	})
+			defer cursor.Close(mctx)
+			for cursor.Next(mctx) {
+				return cursor.Decode(output)
+			}
+			return cursor.Err()
+		})
+	}
 
 	// no session
 	cursor, err := c.innerCollection.Aggregate(ctx, pipeline, aggregateOptions)
@@ -526,3 +598,62 @@ func (c *collection) ReplaceOne(ctx context.Context, filter interface{}, replace
 		},
 	}, nil
 }
+
+func (c *collection) BulkWrite(ctx context.Context, models []mongodb.BulkWriteModel, opts *bulkwriteopt.Opts) (*mongodb.BulkWriteResult, error) {
+
+	bulkOption := options.BulkWrite()
+	if nil != opts {
+		bulkOption = opts.ConvertToMongoOptions()
+	}
+
+	writeModels := make([]mongo.WriteModel, 0, len(models))
+	for _, model := range models {
+		switch model.Type {
+		case mongodb.BulkWriteInsert:
+			writeModels = append(writeModels, mongo.NewInsertOneModel().SetDocument(model.Doc))
+		case mongodb.BulkWriteUpdate:
+			writeModels = append(writeModels, mongo.NewUpdateManyModel().SetFilter(model.Filter).SetUpdate(bson.M{"$set": model.Doc}))
+		case mongodb.BulkWriteUpsert:
+			writeModels = append(writeModels, mongo.NewUpdateOneModel().SetFilter(model.Filter).SetUpdate(bson.M{"$set": model.Doc}).SetUpsert(true))
+		case mongodb.BulkWriteDelete:
+			writeModels = append(writeModels, mongo.NewDeleteManyModel().SetFilter(model.Filter))
+		default:
+			return nil, fmt.Errorf("unknown bulk write type %d", model.Type)
+		}
+	}
+
+	returnResult := &mongodb.BulkWriteResult{}
+	bulkWrite := func(ctx context.Context) error {
+		result, err := c.innerCollection.BulkWrite(ctx, writeModels, bulkOption)
+		if exception, ok := err.(mongo.BulkWriteException); ok && nil == exception.WriteConcernError {
+			for _, writeErr := range exception.WriteErrors {
+				returnResult.Errors = append(returnResult.Errors, mongodb.BulkWriteError{
+					Index:   writeErr.Index,
+					Code:    writeErr.Code,
+					Message: writeErr.Message,
+				})
+			}
+			return nil
+		}
+		if nil != err {
+			return err
+		}
+		returnResult.InsertedCount = uint64(result.InsertedCount)
+		returnResult.MatchedCount = uint64(result.MatchedCount)
+		returnResult.ModifiedCount = uint64(result.ModifiedCount)
+		returnResult.DeletedCount = uint64(result.DeletedCount)
+		returnResult.UpsertedCount = uint64(result.UpsertedCount)
+		return nil
+	}
+
+	// in a session
+	if nil != c.innerSession {
+		err := mongo.WithSession(ctx, c.innerSession, func(mctx mongo.SessionContext) error {
+			return bulkWrite(mctx)
+		})
+		return returnResult, err
+	}
+
+	// no session
+	return returnResult, bulkWrite(ctx)
+}
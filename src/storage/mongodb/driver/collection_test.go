@@ -149,7 +149,7 @@ func TestIndexCRUD(t *testing.T) {
 
 		// create a new index
 		err = coll.CreateIndex(mongodb.Index{
-			Keys: map[string]int32{"key": -1},
+			Keys: []mongodb.IndexKey{{Key: "key", Direction: -1}},
 			Name: "test_index_name_key",
 		})
 		require.NoError(t, err)
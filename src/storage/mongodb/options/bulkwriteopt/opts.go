@@ -0,0 +1,27 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bulkwriteopt
+
+import "github.com/mongodb/mongo-go-driver/mongo/options"
+
+// Opts bulk write options
+type Opts struct {
+	// Ordered stop at the first failed operation if true,
+	// otherwise the remaining operations are still executed
+	Ordered bool
+}
+
+// ConvertToMongoOptions convert bulk write opt into mongo options
+func (o *Opts) ConvertToMongoOptions() *options.BulkWriteOptions {
+	return options.BulkWrite().SetOrdered(o.Ordered)
+}
@@ -28,6 +28,10 @@ func (m *Many) ConvertToMongoOptions() *options.FindOptions {
 
 	option.Skip = &m.Skip
 
+	if 0 != m.BatchSize {
+		option.BatchSize = &m.BatchSize
+	}
+
 	sortD := primitive.D{}
 	for _, sortItem := range m.Sort {
 
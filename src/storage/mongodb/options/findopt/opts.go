@@ -13,6 +13,8 @@
 package findopt
 
 import (
+	"strings"
+
 	"configcenter/src/common"
 )
 
@@ -22,6 +24,21 @@ type SortItem struct {
 	Descending bool
 }
 
+// NewSortItems parse the sort string like "field1,-field2" into sort items
+func NewSortItems(sort string) []SortItem {
+	items := []SortItem{}
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		switch {
+		case strings.HasPrefix(field, "-"):
+			items = append(items, SortItem{Name: strings.TrimPrefix(field, "-"), Descending: true})
+		case field != "":
+			items = append(items, SortItem{Name: strings.TrimPrefix(field, "+")})
+		}
+	}
+	return items
+}
+
 // FieldItem used to define the field codnition
 type FieldItem struct {
 	Name string
@@ -44,6 +61,7 @@ type One struct {
 // Many find many options
 type Many struct {
 	Opts
+	BatchSize int32
 }
 
 // FindAndModify find and modify options
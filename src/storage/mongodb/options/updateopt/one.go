@@ -18,6 +18,8 @@ import "github.com/mongodb/mongo-go-driver/mongo/options"
 func (m *One) ConvertToMongoOptions() *options.UpdateOptions {
 
 	option := &options.UpdateOptions{}
-	// TODO: need to be implemented
+	if m.Upsert {
+		option.SetUpsert(true)
+	}
 	return option
 }
@@ -14,6 +14,7 @@ package updateopt
 
 // One update one options
 type One struct {
+	Upsert bool
 }
 
 // Many update many options
@@ -25,6 +25,23 @@ type UpdateResult struct {
 	ModifiedCount uint64 `json:"modifiedCount"`
 }
 
+// BulkWriteError is the error of one operation of a bulk write.
+type BulkWriteError struct {
+	Index   int    `json:"index"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// BulkWriteResult is a result of a bulk write operation.
+type BulkWriteResult struct {
+	InsertedCount uint64           `json:"insertedCount"`
+	MatchedCount  uint64           `json:"matchedCount"`
+	ModifiedCount uint64           `json:"modifiedCount"`
+	DeletedCount  uint64           `json:"deletedCount"`
+	UpsertedCount uint64           `json:"upsertedCount"`
+	Errors        []BulkWriteError `json:"errors"`
+}
+
 // ReplaceOneResult the  replace one function result
 type ReplaceOneResult struct {
 	UpdateResult `json:",inline"`
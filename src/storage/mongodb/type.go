@@ -12,6 +12,8 @@
 
 package mongodb
 
+import "time"
+
 // Opener open method
 type Opener interface {
 	Open() error
@@ -28,10 +30,45 @@ type OpenCloser interface {
 	Closer
 }
 
-// Index the collection index definition
+// IndexKey a key of the index
+type IndexKey struct {
+	Key string `json:"key"`
+	// Direction 1 for the ascending and -1 for the descending
+	Direction int32 `json:"direction"`
+}
+
+// Index the collection index definition, the keys of the compound index are in order
 type Index struct {
-	Keys       map[string]int32 `json:"keys"`
-	Name       string           `json:"name"`
-	Unique     bool             `json:"unique"`
-	Background bool             `json:"background"`
+	Keys       []IndexKey `json:"keys"`
+	Name       string     `json:"name"`
+	Unique     bool       `json:"unique"`
+	Background bool       `json:"background"`
+	// ExpireAfterSeconds the documents are removed the seconds after the date of the key, 0 means never
+	ExpireAfterSeconds int32 `json:"expire_after_seconds,omitempty"`
+}
+
+// ReadPreference the read preference of the reads
+type ReadPreference struct {
+	// Mode primary, primaryPreferred, secondary, secondaryPreferred or nearest
+	Mode string
+	// MaxStaleness 0 means no limit
+	MaxStaleness time.Duration
+}
+
+// BulkWriteType the operation type of the bulk write model
+type BulkWriteType int
+
+// BulkWriteType enumeration
+const (
+	BulkWriteInsert BulkWriteType = iota + 1 // insert the document
+	BulkWriteUpdate                          // set the fields of all the documents matched
+	BulkWriteUpsert                          // set the fields of one document matched, insert if not found
+	BulkWriteDelete                          // delete all the documents matched
+)
+
+// BulkWriteModel one operation of the bulk write, Doc is the document to insert or the fields to set
+type BulkWriteModel struct {
+	Type   BulkWriteType
+	Filter interface{}
+	Doc    interface{}
 }
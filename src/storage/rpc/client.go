@@ -14,6 +14,7 @@ package rpc
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -25,6 +26,7 @@ import (
 	"time"
 
 	"configcenter/src/common/blog"
+	"configcenter/src/common/trace"
 	"configcenter/src/common/util"
 )
 
@@ -38,7 +40,11 @@ const commanLimit = 40
 
 type Client interface {
 	Call(cmd string, input interface{}, result interface{}) error
+	// CallContext call with the span in the context as the parent of the rpc span
+	CallContext(ctx context.Context, cmd string, input interface{}, result interface{}) error
 	CallStream(cmd string, input interface{}) (*StreamMessage, error)
+	// CallStreamContext call stream with the span in the context as the parent of the rpc span
+	CallStreamContext(ctx context.Context, cmd string, input interface{}) (*StreamMessage, error)
 	Ping() error
 	TargetID() string
 	Close() error
@@ -60,6 +66,9 @@ type client struct {
 	response Message
 	done     *util.AtomicBool
 	wg       sync.WaitGroup
+	// traceable is set when the server answered the ping with capabilityTrace,
+	// the traceparent is only sent to such server, the old server rejects the MagicVersionTrace message
+	traceable *util.AtomicBool
 }
 
 //NewClient replica client
@@ -77,13 +86,28 @@ func NewClient(conn net.Conn, compress string) (*client, error) {
 		messages:  map[uint32]*Message{},
 		codec:     JSONCodec,
 		stream:    newStreamStore(),
+		traceable: util.NewBool(false),
 	}
 	blog.V(3).Infof("connected to rpc server %s", c.TargetID())
 	go c.write()
 	go c.read()
+	go c.negotiate()
 	return c, nil
 }
 
+// negotiate pings the server to find out whether it reads the traceparent,
+// the messages are sent without the traceparent until the server answered
+func (c *client) negotiate() {
+	msg, err := c.operation(TypePing, "", nil, trace.SpanContext{}, nil)
+	if err != nil {
+		blog.V(3).Infof("negotiate with rpc server %s failed: %v", c.TargetID(), err)
+		return
+	}
+	if string(msg.Data) == capabilityTrace {
+		c.traceable.Set()
+	}
+}
+
 func Dial(connect string) (*client, error) {
 	uri, err := url.Parse(connect)
 	if err != nil {
@@ -142,8 +166,18 @@ func (c *client) TargetID() string {
 
 // Call replica client
 func (c *client) Call(cmd string, input interface{}, result interface{}) error {
-	msg, err := c.operation(TypeRequest, cmd, input)
+	return c.CallContext(context.Background(), cmd, input, result)
+}
+
+// CallContext replica client, the span is sent to the server by the traceparent
+func (c *client) CallContext(ctx context.Context, cmd string, input interface{}, result interface{}) error {
+	span := trace.StartSpan("rpc "+cmd, trace.SpanKindClient, trace.SpanContextFromContext(ctx))
+	span.SetTag("peer.host", c.TargetID())
+	defer span.Finish()
+
+	msg, err := c.operation(TypeRequest, cmd, input, span.Context(), nil)
 	if err != nil {
+		span.SetError(err)
 		return err
 	}
 	return msg.Decode(result)
@@ -151,26 +185,42 @@ func (c *client) Call(cmd string, input interface{}, result interface{}) error {
 
 // CallStream replica client
 func (c *client) CallStream(cmd string, input interface{}) (*StreamMessage, error) {
-	msg, err := c.operation(TypeRequest, cmd, input)
+	return c.CallStreamContext(context.Background(), cmd, input)
+}
+
+// CallStreamContext replica client, the span lasts until the stream is opened
+func (c *client) CallStreamContext(ctx context.Context, cmd string, input interface{}) (*StreamMessage, error) {
+	span := trace.StartSpan("rpc "+cmd, trace.SpanKindClient, trace.SpanContextFromContext(ctx))
+	span.SetTag("peer.host", c.TargetID())
+	defer span.Finish()
+
+	// the stream is stored before the request is sent,
+	// so that the messages pushed right after the response won't be lost
+	sm := NewStreamMessage(nil)
+	msg, err := c.operation(TypeRequest, cmd, input, span.Context(), sm)
 	if err != nil {
+		span.SetError(err)
 		return nil, err
 	}
 
-	sm := NewStreamMessage(msg)
-	c.stream.store(msg.seq, sm)
 	go func() {
-		for streammsg := range sm.output {
-			c.send <- streammsg
-			if msg.typz == TypeStreamClose {
-				break
-			}
-			if sm.done.IsSet() || c.done.IsSet() {
-				break
+		defer c.stream.remove(msg.seq)
+		for {
+			select {
+			case streammsg := <-sm.output:
+				if c.done.IsSet() {
+					sm.finish()
+					return
+				}
+				c.send <- streammsg
+				if streammsg.typz == TypeStreamClose {
+					sm.finish()
+					return
+				}
+			case <-sm.stop:
+				return
 			}
 		}
-		sm.err = ErrStreamStoped
-		close(sm.input)
-		close(sm.output)
 	}()
 
 	return sm, nil
@@ -178,11 +228,11 @@ func (c *client) CallStream(cmd string, input interface{}) (*StreamMessage, erro
 
 //Ping replica client
 func (c *client) Ping() error {
-	_, err := c.operation(TypePing, "", nil)
+	_, err := c.operation(TypePing, "", nil, trace.SpanContext{}, nil)
 	return err
 }
 
-func (c *client) operation(op MessageType, cmd string, data interface{}) (*Message, error) {
+func (c *client) operation(op MessageType, cmd string, data interface{}, sc trace.SpanContext, stream *StreamMessage) (*Message, error) {
 	retry := 0
 	for {
 		msg := &Message{
@@ -194,6 +244,10 @@ func (c *client) operation(op MessageType, cmd string, data interface{}) (*Messa
 			cmd:          cmd,
 			Data:         nil,
 		}
+		if sc.IsValid() && c.traceable.IsSet() {
+			msg.magicVersion = MagicVersionTrace
+			msg.traceparent = sc.Traceparent()
+		}
 
 		if op == TypeRequest {
 			err := msg.Encode(data)
@@ -210,15 +264,25 @@ func (c *client) operation(op MessageType, cmd string, data interface{}) (*Messa
 			return time.After(opPingTimeout)
 		}(msg.typz)
 
+		if stream != nil {
+			stream.root = msg
+			c.stream.store(msg.seq, stream)
+		}
 		c.handleRequest(msg)
 
 		select {
 		case <-msg.complete:
 			if msg.typz == TypeError {
+				if stream != nil {
+					c.stream.remove(msg.seq)
+				}
 				return nil, errors.New(string(msg.Data))
 			}
 			return msg, nil
 		case <-timeout:
+			if stream != nil {
+				c.stream.remove(msg.seq)
+			}
 			blog.Errorf("%s timeout on replcia %s, seq= %d", msg.typz, c.TargetID(), msg.seq)
 			if retry < opRetries {
 				retry++
@@ -256,8 +320,8 @@ func (c *client) handleRequest(req *Message) {
 	c.messages[req.seq] = req
 	c.messageMutex.Unlock()
 
-	c.send <- req
 	blog.V(5).Infof("[rpc client]sent message data: %s", req.Data)
+	c.send <- req
 }
 
 func (c *client) handleResponse(resp *Message) {
@@ -271,14 +335,15 @@ func (c *client) handleResponse(resp *Message) {
 			c.replyError(msg)
 		}
 		c.messageMutex.Unlock()
+		c.stream.stopAll()
 		return
 	}
 	if resp.typz == TypeStream || resp.typz == TypeStreamClose {
-		c.stream.RLock()
 		stream, ok := c.stream.get(resp.seq)
-		c.stream.RUnlock()
 		if ok {
-			stream.input <- resp
+			// the response is reused by the read loop, so deliver a copy
+			streammsg := *resp
+			stream.deliver(&streammsg)
 		} else {
 			blog.Warnf("[rpc client] stream not found, resp is %s", resp.Data)
 		}
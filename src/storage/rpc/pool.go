@@ -13,6 +13,7 @@
 package rpc
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"sync"
@@ -120,9 +121,13 @@ func (p *Pool) put(conn Client) {
 }
 
 func (p *Pool) Call(cmd string, input interface{}, result interface{}) (err error) {
+	return p.CallContext(context.Background(), cmd, input, result)
+}
+
+func (p *Pool) CallContext(ctx context.Context, cmd string, input interface{}, result interface{}) (err error) {
 	conn := p.pop()
 	if conn != nil {
-		err = conn.Call(cmd, input, result)
+		err = conn.CallContext(ctx, cmd, input, result)
 		if err != nil {
 			if err != ErrRWTimeout {
 				if pingErr := conn.Ping(); pingErr == nil {
@@ -143,7 +148,7 @@ func (p *Pool) Call(cmd string, input interface{}, result interface{}) (err erro
 		return err
 	}
 
-	err = conn.Call(cmd, input, result)
+	err = conn.CallContext(ctx, cmd, input, result)
 	if err != nil {
 		if pingErr := conn.Ping(); pingErr == nil {
 			p.put(conn)
@@ -156,9 +161,13 @@ func (p *Pool) Call(cmd string, input interface{}, result interface{}) (err erro
 }
 
 func (p *Pool) CallStream(cmd string, input interface{}) (*StreamMessage, error) {
+	return p.CallStreamContext(context.Background(), cmd, input)
+}
+
+func (p *Pool) CallStreamContext(ctx context.Context, cmd string, input interface{}) (*StreamMessage, error) {
 	conn := p.pop()
 	if conn != nil {
-		stream, err := conn.CallStream(cmd, input)
+		stream, err := conn.CallStreamContext(ctx, cmd, input)
 		if err != nil {
 			if err != ErrRWTimeout {
 				if pingErr := conn.Ping(); pingErr == nil {
@@ -179,7 +188,7 @@ func (p *Pool) CallStream(cmd string, input interface{}) (*StreamMessage, error)
 		return nil, err
 	}
 
-	stream, err := conn.CallStream(cmd, input)
+	stream, err := conn.CallStreamContext(ctx, cmd, input)
 	if err != nil {
 		if pingErr := conn.Ping(); pingErr == nil {
 			p.put(conn)
@@ -14,11 +14,13 @@ package rpc
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"runtime/debug"
 
 	"configcenter/src/common/blog"
+	"configcenter/src/common/trace"
 	"configcenter/src/common/util"
 )
 
@@ -156,13 +158,15 @@ func (s *ServerSession) readFromWire() error {
 			blog.V(3).Infof("[rpc server] command [%s] not found, existing command are: %#v", msg.cmd, s.srv.handlers)
 			s.pushResponse(&msg, ErrCommandNotFount)
 		}
-	case TypeStream, TypeStreamClose:
-		s.stream.RLock()
-		stream, ok := s.stream.get(msg.seq)
-		if ok {
-			stream.input <- &msg
+	case TypeStream:
+		if stream, ok := s.stream.get(msg.seq); ok {
+			stream.deliver(&msg)
+		}
+	case TypeStreamClose:
+		// the client closed the stream, the handler will get ErrStreamStoped on next Recv or Send
+		if stream, ok := s.stream.get(msg.seq); ok {
+			stream.finish()
 		}
-		s.stream.RUnlock()
 	case TypePing:
 		go s.handlePing(&msg)
 	default:
@@ -179,14 +183,35 @@ func (s *ServerSession) handle(f HandlerFunc, msg *Message) {
 			blog.Errorf("command [%s] failed: %v\n%s", msg.cmd, runtimeErr, stack)
 		}
 	}()
+	span := s.startSpan(msg)
+	if span != nil {
+		defer span.Finish()
+	}
 	result, err := f(msg)
+	if err != nil && span != nil {
+		span.SetError(err)
+	}
 	if encodeErr := msg.Encode(result); encodeErr != nil {
 		blog.Errorf("EncodeData error: %s", encodeErr.Error())
 	}
 	s.pushResponse(msg, err)
 }
+
+// startSpan starts the server span of the traced request and puts it into the message context,
+// it returns nil if the client sent no traceparent
+func (s *ServerSession) startSpan(msg *Message) *trace.Span {
+	if msg.traceparent == "" {
+		return nil
+	}
+	parent, _ := trace.ParseTraceparent(msg.traceparent)
+	span := trace.StartSpan("rpc "+msg.cmd, trace.SpanKindServer, parent)
+	msg.ctx = trace.ContextWithSpan(context.Background(), span)
+	return span
+}
+
 func (s *ServerSession) handleStream(f HandlerStreamFunc, msg *Message) {
-	stream := NewStreamMessage(msg)
+	span := s.startSpan(msg)
+	stream := NewStreamMessage(msg.copy())
 	s.stream.store(msg.seq, stream)
 	s.pushResponse(msg, nil)
 
@@ -195,36 +220,43 @@ func (s *ServerSession) handleStream(f HandlerStreamFunc, msg *Message) {
 			runtimeErr := recover()
 			if runtimeErr != nil {
 				stack := debug.Stack()
-				blog.Errorf("stream command [%s] failed: %v\n%s", msg.cmd, runtimeErr, stack)
+				blog.Errorf("stream command [%s] failed: %v\n%s", stream.root.cmd, runtimeErr, stack)
+				stream.closeWithError(fmt.Errorf("stream command [%s] failed: %v", stream.root.cmd, runtimeErr))
 			}
 		}()
+		if span != nil {
+			defer span.Finish()
+		}
 		err := f(msg, stream)
-		nmsg := msg.copy()
-		nmsg.typz = TypeStreamClose
-		if err != nil {
-			nmsg.Data = []byte(err.Error())
+		if err != nil && span != nil {
+			span.SetError(err)
 		}
-		stream.output <- nmsg
+		stream.closeWithError(err)
 	}()
 	go func() {
-	serverstreamloop:
-		for smsg := range stream.output {
-			s.responses <- smsg
-			if smsg.typz == TypeStreamClose {
-				break serverstreamloop
+		defer s.stream.remove(stream.root.seq)
+		for {
+			select {
+			case smsg := <-stream.output:
+				s.responses <- smsg
+				if smsg.typz == TypeStreamClose {
+					stream.finish()
+					return
+				}
+			case <-stream.stop:
+				return
 			}
-			if s.done.IsSet() || stream.done.IsSet() {
-				stream.err = ErrStreamStoped
-				break
+			if s.done.IsSet() {
+				stream.finish()
+				return
 			}
 		}
-		s.stream.remove(msg.seq)
-		close(stream.input)
-		close(stream.output)
 	}()
 }
 
+// handlePing answers the ping with the capability, so that the client knows it can send the traceparent
 func (s *ServerSession) handlePing(msg *Message) {
+	msg.Data = []byte(capabilityTrace)
 	s.pushResponse(msg, nil)
 }
 
@@ -248,6 +280,7 @@ func (s *ServerSession) readloop() error {
 		}
 		if err = s.readFromWire(); err != nil {
 			s.Stop()
+			s.stream.stopAll()
 			return nil
 		}
 	}
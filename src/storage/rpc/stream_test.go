@@ -0,0 +1,118 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"configcenter/src/common/util"
+)
+
+func newStreamTestClient(t *testing.T, srv *Server) *client {
+	mux := http.NewServeMux()
+	mux.Handle("/rpc", srv)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	address, err := util.GetDailAddress(ts.URL)
+	require.NoError(t, err)
+	cli, err := DialHTTPPath("tcp", address, "/rpc")
+	require.NoError(t, err)
+	t.Cleanup(func() { cli.Close() })
+	return cli
+}
+
+func TestStreamPull(t *testing.T) {
+	srv := NewServer()
+	srv.HandleStream("count", func(req Request, stream ServerStream) error {
+		var limit int
+		if err := req.Decode(&limit); err != nil {
+			return err
+		}
+		for i := 0; i < limit; i++ {
+			var next bool
+			if err := stream.Recv(&next); err != nil {
+				return err
+			}
+			if err := stream.Send(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	cli := newStreamTestClient(t, srv)
+
+	stream, err := cli.CallStream("count", 3)
+	require.NoError(t, err)
+	values := []int{}
+	for {
+		require.NoError(t, stream.Send(true))
+		var value int
+		err := stream.Recv(&value)
+		if err == ErrStreamStoped {
+			break
+		}
+		require.NoError(t, err)
+		values = append(values, value)
+	}
+	require.Equal(t, []int{0, 1, 2}, values)
+	require.Equal(t, ErrStreamStoped, stream.Recv(new(int)))
+	require.NoError(t, stream.Close())
+}
+
+func TestStreamClientClose(t *testing.T) {
+	stoped := make(chan error, 1)
+	srv := NewServer()
+	srv.HandleStream("watch", func(req Request, stream ServerStream) error {
+		for i := 0; ; i++ {
+			if err := stream.Send(i); err != nil {
+				stoped <- err
+				return err
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+	cli := newStreamTestClient(t, srv)
+
+	stream, err := cli.CallStream("watch", nil)
+	require.NoError(t, err)
+	var value int
+	require.NoError(t, stream.Recv(&value))
+	require.Equal(t, 0, value)
+	require.NoError(t, stream.Close())
+
+	select {
+	case err := <-stoped:
+		require.Equal(t, ErrStreamStoped, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server stream handler not stoped after client close")
+	}
+}
+
+func TestStreamHandlerError(t *testing.T) {
+	srv := NewServer()
+	srv.HandleStream("fail", func(req Request, stream ServerStream) error {
+		return ErrCommandNotFount
+	})
+	cli := newStreamTestClient(t, srv)
+
+	stream, err := cli.CallStream("fail", nil)
+	require.NoError(t, err)
+	err = stream.Recv(new(int))
+	require.EqualError(t, err, ErrCommandNotFount.Error())
+}
@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"configcenter/src/common/trace"
+)
+
+func initTestTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.json")
+	conf := trace.ParseConfigFromKV("trace", map[string]string{"trace.enable": "true", "trace.sample_rate": "1", "trace.file": path})
+	require.NoError(t, trace.Init("test", conf))
+	t.Cleanup(func() { trace.Init("test", trace.Config{}) })
+}
+
+func TestTraceNegotiated(t *testing.T) {
+	initTestTrace(t)
+	srv := NewServer()
+	srv.Handle("trace", func(req Request) (interface{}, error) {
+		return trace.SpanContextFromContext(req.Context()).TraceID, nil
+	})
+	srv.HandleStream("stream", func(req Request, stream ServerStream) error {
+		return stream.Send(trace.SpanContextFromContext(req.Context()).TraceID)
+	})
+	cli := newStreamTestClient(t, srv)
+	for i := 0; i < 50 && !cli.traceable.IsSet(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.True(t, cli.traceable.IsSet())
+
+	root := trace.StartSpan("GET /", trace.SpanKindServer, trace.SpanContext{})
+	defer root.Finish()
+	ctx := trace.ContextWithSpan(context.Background(), root)
+
+	traceID := [16]byte{}
+	require.NoError(t, cli.CallContext(ctx, "trace", nil, &traceID))
+	require.Equal(t, root.Context().TraceID, traceID)
+
+	stream, err := cli.CallStreamContext(ctx, "stream", nil)
+	require.NoError(t, err)
+	traceID = [16]byte{}
+	require.NoError(t, stream.Recv(&traceID))
+	require.Equal(t, root.Context().TraceID, traceID)
+}
+
+// TestTraceOldServer the server answering the ping without the capability should never get the MagicVersionTrace message
+func TestTraceOldServer(t *testing.T) {
+	initTestTrace(t)
+	serverConn, clientConn := net.Pipe()
+	versions := make(chan uint16, 10)
+	go func() {
+		wire, err := NewBinaryWire(serverConn, "")
+		if err != nil {
+			return
+		}
+		msg := Message{}
+		for wire.Read(&msg) == nil {
+			versions <- msg.magicVersion
+			msg.magicVersion = MagicVersion
+			msg.typz = TypeResponse
+			if wire.Write(&msg) != nil {
+				return
+			}
+		}
+	}()
+	cli, err := NewClient(clientConn, "")
+	require.NoError(t, err)
+	defer cli.Close()
+	cli.negotiate()
+	require.False(t, cli.traceable.IsSet())
+
+	root := trace.StartSpan("GET /", trace.SpanKindServer, trace.SpanContext{})
+	defer root.Finish()
+	require.NoError(t, cli.CallContext(trace.ContextWithSpan(context.Background(), root), "ok", nil, new(Reply)))
+	for len(versions) > 0 {
+		require.Equal(t, MagicVersion, <-versions)
+	}
+}
@@ -14,6 +14,7 @@ package rpc
 
 import (
 	"bytes"
+	"context"
 	"encoding"
 	"encoding/json"
 	"errors"
@@ -57,21 +58,34 @@ func (s *streamstore) store(seq uint32, stream *StreamMessage) {
 }
 
 func (s *streamstore) get(seq uint32) (*StreamMessage, bool) {
+	s.RLock()
 	stream, ok := s.stream[seq]
+	s.RUnlock()
 	return stream, ok
 }
+
 func (s *streamstore) remove(seq uint32) {
 	s.Lock()
 	delete(s.stream, seq)
 	s.Unlock()
 }
 
+// stopAll stops all the streams, used when the connection is broken
+func (s *streamstore) stopAll() {
+	s.RLock()
+	for _, stream := range s.stream {
+		stream.finish()
+	}
+	s.RUnlock()
+}
+
 // StreamMessage define
 type StreamMessage struct {
 	root   *Message
 	input  chan *Message
 	output chan *Message
 	done   *util.AtomicBool
+	stop   chan struct{}
 	err    error
 }
 
@@ -88,47 +102,87 @@ func NewStreamMessage(root *Message) *StreamMessage {
 		input:  make(chan *Message, 10),
 		output: make(chan *Message, 10),
 		done:   util.NewBool(false),
+		stop:   make(chan struct{}),
 	}
 }
 
 // Recv receive message
-func (m StreamMessage) Recv(result interface{}) error {
+func (m *StreamMessage) Recv(result interface{}) error {
 	if m.err != nil {
 		return m.err
 	}
-	msg := <-m.input
-	if msg.typz == TypeStreamClose {
-		m.err = ErrStreamStoped
-		if len(msg.Data) > 0 {
-			return errors.New(string(msg.Data))
+	select {
+	case msg := <-m.input:
+		if msg.typz == TypeStreamClose {
+			m.err = ErrStreamStoped
+			m.finish()
+			if len(msg.Data) > 0 {
+				return errors.New(string(msg.Data))
+			}
+			return m.err
 		}
+		return msg.Decode(result)
+	case <-m.stop:
+		m.err = ErrStreamStoped
 		return m.err
 	}
-	return msg.Decode(result)
 }
 
 // Send send message
-func (m StreamMessage) Send(data interface{}) error {
-	if m.err != nil {
-		return m.err
+func (m *StreamMessage) Send(data interface{}) error {
+	if m.done.IsSet() {
+		return ErrStreamStoped
 	}
 	msg := m.root.copy()
 	msg.typz = TypeStream
 	if err := msg.Encode(data); err != nil {
 		return err
 	}
-	m.output <- msg
-	return nil
+	select {
+	case m.output <- msg:
+		return nil
+	case <-m.stop:
+		return ErrStreamStoped
+	}
 }
 
 // Close should only call by client
-func (m StreamMessage) Close() error {
+func (m *StreamMessage) Close() error {
+	return m.closeWithError(nil)
+}
+
+// closeWithError notify the peer that the stream is closed
+func (m *StreamMessage) closeWithError(err error) error {
+	if m.done.IsSet() {
+		return nil
+	}
 	msg := m.root.copy()
 	msg.typz = TypeStreamClose
-	m.output <- msg
+	if err != nil {
+		msg.Data = []byte(err.Error())
+	}
+	select {
+	case m.output <- msg:
+	case <-m.stop:
+	}
 	return nil
 }
 
+// deliver push the message received from the peer into the stream
+func (m *StreamMessage) deliver(msg *Message) {
+	select {
+	case m.input <- msg:
+	case <-m.stop:
+	}
+}
+
+// finish mark the stream as stopped, it is safe to call it more than once
+func (m *StreamMessage) finish() {
+	if m.done.SetIfNotSet() {
+		close(m.stop)
+	}
+}
+
 // MessageType define
 type MessageType uint32
 
@@ -204,11 +258,20 @@ func (bsonCodec) Encode(v interface{}) ([]byte, error) {
 const (
 	// MagicVersion is the cc rpc protocol version
 	MagicVersion = uint16(0x1b01) // cmdb01
+	// MagicVersionTrace is the protocol version of the message carrying
+	// a W3C traceparent after the command
+	MagicVersionTrace = uint16(0x1b02) // cmdb02
+
+	// capabilityTrace is answered to the ping by the server which reads
+	// the MagicVersionTrace messages, the old servers answer the ping without data
+	capabilityTrace = "traceparent"
 )
 
 // Request define a request interface
 type Request interface {
 	Decode(value interface{}) error
+	// Context returns the context of the request, it carries the server span of the traced request
+	Context() context.Context
 }
 
 // Message define a rpc message
@@ -216,11 +279,13 @@ type Message struct {
 	complete     chan struct{}
 	transportErr error
 	codec        Codec
+	ctx          context.Context
 
 	magicVersion uint16
 	seq          uint32
 	typz         MessageType
 	cmd          string // maybe should use uint32
+	traceparent  string
 	Data         []byte
 }
 
@@ -230,8 +295,18 @@ func (msg Message) copy() *Message {
 		seq:          msg.seq,
 		typz:         msg.typz,
 		cmd:          msg.cmd,
+		traceparent:  msg.traceparent,
 		codec:        msg.codec,
+		ctx:          msg.ctx,
+	}
+}
+
+// Context returns the context of the message
+func (msg *Message) Context() context.Context {
+	if msg.ctx == nil {
+		return context.Background()
 	}
+	return msg.ctx
 }
 
 // Decode decode the message data
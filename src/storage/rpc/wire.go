@@ -65,6 +65,11 @@ func (w *BinaryWire) Write(msg *Message) error {
 	if err = writeString(w.writer, msg.cmd); err != nil {
 		return err
 	}
+	if msg.magicVersion == MagicVersionTrace {
+		if err = writeString(w.writer, msg.traceparent); err != nil {
+			return err
+		}
+	}
 	if err = writeBytes(w.writer, msg.Data); err != nil {
 		return err
 	}
@@ -80,7 +85,7 @@ func (w *BinaryWire) Read(msg *Message) error {
 		return err
 	}
 
-	if msg.magicVersion != MagicVersion {
+	if msg.magicVersion != MagicVersion && msg.magicVersion != MagicVersionTrace {
 		return fmt.Errorf("Wrong API version received: 0x%x", &msg.magicVersion)
 	}
 
@@ -93,6 +98,12 @@ func (w *BinaryWire) Read(msg *Message) error {
 	if msg.cmd, err = readString(w.reader); err != nil {
 		return err
 	}
+	msg.traceparent = ""
+	if msg.magicVersion == MagicVersionTrace {
+		if msg.traceparent, err = readString(w.reader); err != nil {
+			return err
+		}
+	}
 	if msg.Data, err = readBytes(w.reader); err != nil {
 		return err
 	}
@@ -0,0 +1,49 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type bufferCloser struct {
+	bytes.Buffer
+}
+
+func (b *bufferCloser) Close() error {
+	return nil
+}
+
+func TestWireTraceparent(t *testing.T) {
+	wire, err := NewBinaryWire(new(bufferCloser), "")
+	require.NoError(t, err)
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	require.NoError(t, wire.Write(&Message{magicVersion: MagicVersionTrace, seq: 1, typz: TypeRequest, cmd: "ok", traceparent: traceparent, Data: []byte("traced")}))
+	require.NoError(t, wire.Write(&Message{magicVersion: MagicVersion, seq: 2, typz: TypeRequest, cmd: "ok", traceparent: traceparent, Data: []byte("plain")}))
+
+	msg := Message{}
+	require.NoError(t, wire.Read(&msg))
+	require.Equal(t, uint32(1), msg.seq)
+	require.Equal(t, traceparent, msg.traceparent)
+	require.Equal(t, "traced", string(msg.Data))
+
+	require.NoError(t, wire.Read(&msg))
+	require.Equal(t, uint32(2), msg.seq)
+	require.Equal(t, "", msg.traceparent)
+	require.Equal(t, "ok", msg.cmd)
+	require.Equal(t, "plain", string(msg.Data))
+}
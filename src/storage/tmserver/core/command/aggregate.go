@@ -48,14 +48,13 @@ func (d *aggregate) Execute(ctx core.ContextParams, decoder rpc.Request) (*types
 	opt := aggregateopt.One{}
 	//opt.Sort = msg.Sort
 
-	var targetCol mongodb.CollectionInterface
-	if nil != ctx.Session {
-		targetCol = ctx.Session.Collection(msg.Collection)
-	} else {
-		targetCol = d.dbProxy.Collection(msg.Collection)
+	targetCol, err := readCollection(ctx, d.dbProxy, msg.Collection, msg.ReadPreference)
+	if nil != err {
+		reply.Message = err.Error()
+		return reply, err
 	}
 
-	err := targetCol.AggregateOne(ctx, msg.Pipiline, &opt, &reply.Docs)
+	err = targetCol.AggregateOne(ctx, msg.Pipiline, &opt, &reply.Docs)
 	if nil == err {
 		reply.Success = true
 	} else {
@@ -0,0 +1,98 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"fmt"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/storage/mongodb"
+	"configcenter/src/storage/mongodb/options/bulkwriteopt"
+	"configcenter/src/storage/rpc"
+	"configcenter/src/storage/tmserver/core"
+	"configcenter/src/storage/types"
+)
+
+func init() {
+	core.GCommands.SetCommand(types.OPBulkWriteCode, &bulkWrite{})
+}
+
+var _ core.SetDBProxy = (*bulkWrite)(nil)
+
+var bulkWriteTypes = map[types.BulkWriteType]mongodb.BulkWriteType{
+	types.BulkWriteInsert: mongodb.BulkWriteInsert,
+	types.BulkWriteUpdate: mongodb.BulkWriteUpdate,
+	types.BulkWriteUpsert: mongodb.BulkWriteUpsert,
+	types.BulkWriteDelete: mongodb.BulkWriteDelete,
+}
+
+type bulkWrite struct {
+	dbProxy mongodb.Client
+}
+
+func (d *bulkWrite) SetDBProxy(db mongodb.Client) {
+	d.dbProxy = db
+}
+
+func (d *bulkWrite) Execute(ctx core.ContextParams, decoder rpc.Request) (*types.OPReply, error) {
+
+	msg := types.OPBulkWriteOperation{}
+	reply := &types.OPReply{}
+	reply.RequestID = ctx.Header.RequestID
+	if err := decoder.Decode(&msg); nil != err {
+		reply.Message = err.Error()
+		return reply, err
+	}
+	blog.V(4).Infof("[MONGO OPERATION] %+v", &msg)
+
+	models := make([]mongodb.BulkWriteModel, 0, len(msg.Models))
+	for index, model := range msg.Models {
+		typ, ok := bulkWriteTypes[model.Type]
+		if !ok {
+			err := fmt.Errorf("unknown bulk write type %s of the model %d", model.Type, index)
+			reply.Message = err.Error()
+			return reply, err
+		}
+		models = append(models, mongodb.BulkWriteModel{Type: typ, Filter: model.Selector, Doc: model.DOC})
+	}
+
+	var targetCol mongodb.CollectionInterface
+	if nil != ctx.Session {
+		targetCol = ctx.Session.Collection(msg.Collection)
+	} else {
+		targetCol = d.dbProxy.Collection(msg.Collection)
+	}
+
+	result, err := targetCol.BulkWrite(ctx, models, &bulkwriteopt.Opts{Ordered: msg.Ordered})
+	if nil != err {
+		reply.Message = err.Error()
+		return reply, err
+	}
+
+	reply.Success = true
+	reply.BulkWrite = types.BulkWriteResult{
+		InsertedCount: result.InsertedCount,
+		MatchedCount:  result.MatchedCount,
+		ModifiedCount: result.ModifiedCount,
+		DeletedCount:  result.DeletedCount,
+		UpsertedCount: result.UpsertedCount,
+	}
+	for _, writeErr := range result.Errors {
+		reply.BulkWrite.Errors = append(reply.BulkWrite.Errors, types.BulkWriteError{
+			Index:   writeErr.Index,
+			Code:    writeErr.Code,
+			Message: writeErr.Message,
+		})
+	}
+	return reply, nil
+}
@@ -36,7 +36,7 @@ func (d *count) SetDBProxy(db mongodb.Client) {
 
 func (d *count) Execute(ctx core.ContextParams, decoder rpc.Request) (*types.OPReply, error) {
 
-	msg := types.OPDeleteOperation{}
+	msg := types.OPCountOperation{}
 	reply := &types.OPReply{}
 	reply.RequestID = ctx.Header.RequestID
 	if err := decoder.Decode(&msg); nil != err {
@@ -45,11 +45,10 @@ func (d *count) Execute(ctx core.ContextParams, decoder rpc.Request) (*types.OPR
 	}
 	blog.V(4).Infof("[MONGO OPERATION] %+v", &msg)
 
-	var targetCol mongodb.CollectionInterface
-	if nil != ctx.Session {
-		targetCol = ctx.Session.Collection(msg.Collection)
-	} else {
-		targetCol = d.dbProxy.Collection(msg.Collection)
+	targetCol, err := readCollection(ctx, d.dbProxy, msg.Collection, msg.ReadPreference)
+	if nil != err {
+		reply.Message = err.Error()
+		return reply, err
 	}
 
 	cnt, err := targetCol.Count(ctx, msg.Selector)
@@ -19,10 +19,13 @@ import (
 	"configcenter/src/storage/rpc"
 	"configcenter/src/storage/tmserver/core"
 	"configcenter/src/storage/types"
+
+	"github.com/mongodb/mongo-go-driver/mongo"
 )
 
 func init() {
 	core.GCommands.SetCommand(types.OPFindCode, &find{})
+	core.GCommands.SetStreamCommand(types.OPFindCode, &find{})
 	core.GCommands.SetCommand(types.OPFindAndModifyCode, &findAndModify{})
 }
 
@@ -46,19 +49,15 @@ func (d *find) Execute(ctx core.ContextParams, decoder rpc.Request) (*types.OPRe
 	}
 	blog.V(4).Infof("[MONGO OPERATION] %+v", &msg)
 
-	opt := findopt.Many{}
-	opt.Skip = int64(msg.Start)
-	opt.Limit = int64(msg.Limit)
-	//opt.Sort = msg.Sort
+	opt := newFindOpts(&msg)
 
-	var targetCol mongodb.CollectionInterface
-	if nil != ctx.Session {
-		targetCol = ctx.Session.Collection(msg.Collection)
-	} else {
-		targetCol = d.dbProxy.Collection(msg.Collection)
+	targetCol, err := readCollection(ctx, d.dbProxy, msg.Collection, msg.ReadPreference)
+	if nil != err {
+		reply.Message = err.Error()
+		return reply, err
 	}
 
-	err := targetCol.Find(ctx, msg.Selector, &opt, &reply.Docs)
+	err = targetCol.Find(ctx, msg.Selector, opt, &reply.Docs)
 	if nil == err {
 		reply.Success = true
 	} else {
@@ -68,6 +67,63 @@ func (d *find) Execute(ctx core.ContextParams, decoder rpc.Request) (*types.OPRe
 	return reply, err
 }
 
+// ExecuteStream reply the documents batch by batch, the client asks for the next batch
+// by sending a message, so that at most one batch is buffered on the client side
+func (d *find) ExecuteStream(ctx core.ContextParams, decoder rpc.Request, stream rpc.ServerStream) error {
+
+	msg := types.OPFindOperation{}
+	if err := decoder.Decode(&msg); nil != err {
+		return err
+	}
+	blog.V(4).Infof("[MONGO OPERATION] stream %+v", &msg)
+
+	batchSize := msg.BatchSize
+	if 0 == batchSize {
+		batchSize = defaultBatchSize
+	}
+	opt := newFindOpts(&msg)
+	opt.BatchSize = int32(batchSize)
+
+	targetCol, err := readCollection(ctx, d.dbProxy, msg.Collection, msg.ReadPreference)
+	if nil != err {
+		return err
+	}
+
+	reply := &types.OPReply{}
+	reply.RequestID = ctx.Header.RequestID
+	reply.Success = true
+	flush := func() error {
+		var next bool
+		if err := stream.Recv(&next); nil != err {
+			return err
+		}
+		if err := stream.Send(reply); nil != err {
+			return err
+		}
+		reply.Docs = reply.Docs[:0]
+		return nil
+	}
+
+	err = targetCol.FindIterate(ctx, msg.Selector, opt, func(decode func(result interface{}) error) error {
+		doc := types.Document{}
+		if err := decode(&doc); nil != err {
+			return err
+		}
+		reply.Docs = append(reply.Docs, doc)
+		if uint64(len(reply.Docs)) < batchSize {
+			return nil
+		}
+		return flush()
+	})
+	if nil != err {
+		return err
+	}
+	if 0 != len(reply.Docs) {
+		return flush()
+	}
+	return nil
+}
+
 var _ core.SetDBProxy = (*findAndModify)(nil)
 
 type findAndModify struct {
@@ -103,6 +159,11 @@ func (d *findAndModify) Execute(ctx core.ContextParams, decoder rpc.Request) (*t
 
 	reply.Docs = types.Documents{types.Document{}}
 	err := targetCol.FindOneAndModify(ctx, msg.Selector, msg.DOC, &opt, &reply.Docs[0])
+	if mongo.ErrNoDocuments == err {
+		// nothing matched, or the old document is required while a new one is upserted
+		reply.Docs = types.Documents{}
+		err = nil
+	}
 	if nil == err {
 		reply.Success = true
 	} else {
@@ -110,3 +171,16 @@ func (d *findAndModify) Execute(ctx core.ContextParams, decoder rpc.Request) (*t
 	}
 	return reply, err
 }
+
+const defaultBatchSize = 500
+
+func newFindOpts(msg *types.OPFindOperation) *findopt.Many {
+	opt := &findopt.Many{}
+	opt.Skip = int64(msg.Start)
+	opt.Limit = int64(msg.Limit)
+	opt.Sort = findopt.NewSortItems(msg.Sort)
+	for field := range msg.Projection {
+		opt.Fields = append(opt.Fields, findopt.FieldItem{Name: field})
+	}
+	return opt
+}
@@ -0,0 +1,35 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"time"
+
+	"configcenter/src/storage/mongodb"
+	"configcenter/src/storage/tmserver/core"
+	"configcenter/src/storage/types"
+)
+
+// readCollection 返回读操作使用的集合, 事务中以及主节点读走默认集合, 其余按读偏好路由到从节点
+func readCollection(ctx core.ContextParams, db mongodb.Client, collName string, pref types.ReadPreference) (mongodb.CollectionInterface, error) {
+	if nil != ctx.Session {
+		return ctx.Session.Collection(collName), nil
+	}
+	if "" == pref.Mode || "primary" == pref.Mode {
+		return db.Collection(collName), nil
+	}
+	return db.CollectionWithReadPreference(collName, mongodb.ReadPreference{
+		Mode:         pref.Mode,
+		MaxStaleness: time.Duration(pref.MaxStalenessSeconds) * time.Second,
+	})
+}
@@ -27,8 +27,14 @@ type Command interface {
 	Execute(ctx ContextParams, decoder rpc.Request) (*types.OPReply, error)
 }
 
+// StreamCommand db operation which replies the result by stream
+type StreamCommand interface {
+	ExecuteStream(ctx ContextParams, decoder rpc.Request, stream rpc.ServerStream) error
+}
+
 type commands struct {
-	cmds map[types.OPCode]Command
+	cmds       map[types.OPCode]Command
+	streamCmds map[types.OPCode]StreamCommand
 }
 
 func (c *commands) SetCommand(opCode types.OPCode, cmd Command) {
@@ -38,3 +44,11 @@ func (c *commands) SetCommand(opCode types.OPCode, cmd Command) {
 
 	c.cmds[opCode] = cmd
 }
+
+func (c *commands) SetStreamCommand(opCode types.OPCode, cmd StreamCommand) {
+	if nil == c.streamCmds {
+		c.streamCmds = make(map[types.OPCode]StreamCommand)
+	}
+
+	c.streamCmds[opCode] = cmd
+}
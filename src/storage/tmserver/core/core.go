@@ -25,6 +25,7 @@ import (
 // Core core operation methods
 type Core interface {
 	ExecuteCommand(ctx ContextParams, input rpc.Request) (*types.OPReply, error)
+	ExecuteStream(ctx ContextParams, input rpc.Request, stream rpc.ServerStream) error
 	Subscribe(chan *types.Transaction)
 	UnSubscribe(chan<- *types.Transaction)
 }
@@ -54,6 +55,11 @@ func New(txnMgr *transaction.Manager, db mongodb.Client) Core {
 			tmp.SetDBProxy(db)
 		}
 	}
+	for _, cmd := range GCommands.streamCmds {
+		if tmp, ok := cmd.(SetDBProxy); ok {
+			tmp.SetDBProxy(db)
+		}
+	}
 
 	return &core{txn: txnMgr}
 }
@@ -85,6 +91,28 @@ func (c *core) ExecuteCommand(ctx ContextParams, input rpc.Request) (*types.OPRe
 
 }
 
+func (c *core) ExecuteStream(ctx ContextParams, input rpc.Request, stream rpc.ServerStream) error {
+
+	cmd, ok := GCommands.streamCmds[ctx.Header.OPCode]
+	if !ok {
+		return fmt.Errorf("unknow stream operation, invalid code: %d", ctx.Header.OPCode)
+	}
+
+	if 0 != len(ctx.Header.TxnID) {
+		session := c.txn.GetSession(ctx.Header.TxnID)
+		if nil == session {
+			return fmt.Errorf("session not found")
+		}
+		ctx.Session = session.Session
+	}
+
+	err := cmd.ExecuteStream(ctx, input, stream)
+	if err != nil && err != rpc.ErrStreamStoped {
+		blog.Errorf("[MONGO OPERATION] stream failed: %v, cmd: %s", err, input)
+	}
+	return err
+}
+
 func (c *core) Subscribe(ch chan *types.Transaction) {
 	c.txn.Subscribe(ch)
 }
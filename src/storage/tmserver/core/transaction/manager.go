@@ -117,7 +117,7 @@ func (tm *Manager) reconcileCache() {
 }
 
 func (tm *Manager) reconcilePersistence() {
-	const Limit int64 = 100
+	const Limit int32 = 100
 	ticker := time.NewTicker(tm.txnLifeLimit * 2)
 	for {
 		select {
@@ -126,46 +126,35 @@ func (tm *Manager) reconcilePersistence() {
 			return
 		case <-ticker.C:
 			blog.Infof("reconciling persistence")
-			txns := []types.Transaction{}
-			opt := findopt.Many{
-				Opts: findopt.Opts{
-					Limit: Limit,
-					Skip:  0,
-				},
-			}
+			opt := findopt.Many{BatchSize: Limit}
 
 			tranCond := mongo.NewCondition()
 			tranCond.Element(&mongo.Eq{Key: "status", Val: types.TxStatusOnProgress})
-			for {
-				err := tm.db.Collection(common.BKTableNameTransaction).Find(tm.ctx, tranCond.ToMapStr(), &opt, &txns)
-				if err != nil {
-					blog.Errorf("reconcile persistence faile: %v, we will retry %v later", err, tm.txnLifeLimit*2)
-					break
+			err := tm.db.Collection(common.BKTableNameTransaction).FindIterate(tm.ctx, tranCond.ToMapStr(), &opt, func(decode func(result interface{}) error) error {
+				txn := types.Transaction{}
+				if err := decode(&txn); err != nil {
+					return err
 				}
-
-				if len(txns) <= 0 {
-					break
+				if time.Since(txn.LastTime) <= tm.txnLifeLimit {
+					return nil
 				}
 
-				for _, txn := range txns {
-					if time.Since(txn.LastTime) > tm.txnLifeLimit {
-
-						updateCond := mongo.NewCondition()
-						updateCond.Element(&mongo.Eq{Key: common.BKTxnIDField, Val: txn.TxnID})
-						update := types.Document{
-							"status":             types.TxStatusException,
-							common.LastTimeField: time.Now(),
-						}
-						_, err := tm.db.Collection(common.BKTableNameTransaction).UpdateOne(tm.ctx, updateCond.ToMapStr(), update, nil)
-						if nil != err {
-							// the reconcile will handle this error, so we will not return this error
-							blog.Errorf("save transaction [%s] status to %v faile: %s", txn.TxnID, types.TxStatusException, err.Error())
-						}
-						ntxn := txn
-						tm.eventChan <- &ntxn
-					}
+				updateCond := mongo.NewCondition()
+				updateCond.Element(&mongo.Eq{Key: common.BKTxnIDField, Val: txn.TxnID})
+				update := types.Document{
+					"status":             types.TxStatusException,
+					common.LastTimeField: time.Now(),
+				}
+				_, err := tm.db.Collection(common.BKTableNameTransaction).UpdateOne(tm.ctx, updateCond.ToMapStr(), update, nil)
+				if nil != err {
+					// the reconcile will handle this error, so we will not return this error
+					blog.Errorf("save transaction [%s] status to %v faile: %s", txn.TxnID, types.TxStatusException, err.Error())
 				}
-				txns = txns[:0]
+				tm.eventChan <- &txn
+				return nil
+			})
+			if err != nil {
+				blog.Errorf("reconcile persistence faile: %v, we will retry %v later", err, tm.txnLifeLimit*2)
 			}
 
 			removeCond := mongo.NewCondition()
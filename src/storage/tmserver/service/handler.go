@@ -22,7 +22,7 @@ import (
 
 func (s *coreService) DBOperation(input rpc.Request) (interface{}, error) {
 
-	ctx := core.ContextParams{Context: context.Background(), ListenIP: s.listenIP}
+	ctx := core.ContextParams{Context: input.Context(), ListenIP: s.listenIP}
 
 	reply := types.OPReply{}
 	err := input.Decode(&ctx.Header)
@@ -35,6 +35,18 @@ func (s *coreService) DBOperation(input rpc.Request) (interface{}, error) {
 
 }
 
+func (s *coreService) DBStreamOperation(input rpc.Request, stream rpc.ServerStream) error {
+
+	ctx, cancel := context.WithCancel(input.Context())
+	defer cancel()
+	params := core.ContextParams{Context: ctx, ListenIP: s.listenIP}
+	if err := input.Decode(&params.Header); nil != err {
+		return err
+	}
+
+	return s.core.ExecuteStream(params, input, stream)
+}
+
 func (s *coreService) WatchTransaction(input rpc.Request, stream rpc.ServerStream) (err error) {
 	ch := make(chan *types.Transaction, 100)
 	s.core.Subscribe(ch)
@@ -61,6 +61,7 @@ func (s *coreService) SetConfig(engin *backbone.Engine, db mongodb.Client, txnCf
 
 	// init all handlers
 	s.rpc.Handle(types.CommandRDBOperation, s.DBOperation)
+	s.rpc.HandleStream(types.CommandRDBStreamOperation, s.DBStreamOperation)
 	s.rpc.HandleStream(types.CommandWatchTransactionOperation, s.WatchTransaction)
 
 	// create a new core instance
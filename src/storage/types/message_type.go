@@ -37,6 +37,8 @@ const (
 	OPCountCode
 	// OPAggregateCode aggregate operation code
 	OPAggregateCode
+	// OPBulkWriteCode bulk write operation code
+	OPBulkWriteCode
 	// OPStartTransactionCode start a transaction code
 	OPStartTransactionCode OPCode = 666
 	// OPCommitCode transaction commit operation code
@@ -67,11 +69,19 @@ func (c OPCode) String() string {
 		return "OPAbortTransaction"
 	case OPAggregateCode:
 		return "OPAggregate"
+	case OPBulkWriteCode:
+		return "OPBulkWrite"
 	default:
 		return "UNKNOW"
 	}
 }
 
+// ReadPreference the read preference of the read operations
+type ReadPreference struct {
+	Mode                string // primary, primaryPreferred, secondary, secondaryPreferred, nearest
+	MaxStalenessSeconds int64  // 从节点最大延迟, 0 表示不限制
+}
+
 // OPInsertOperation insert operation request structure
 type OPInsertOperation struct {
 	MsgHeader            // 标准报文头
@@ -81,9 +91,10 @@ type OPInsertOperation struct {
 
 // OPPipelineOperation insert operation request structure
 type OPAggregateOperation struct {
-	MsgHeader            // 标准报文头
-	Collection string    // "dbname.collectionname"
-	Pipiline   Documents // 要插入集合的文档
+	MsgHeader                     // 标准报文头
+	Collection     string         // "dbname.collectionname"
+	Pipiline       Documents      // 要插入集合的文档
+	ReadPreference ReadPreference // 读偏好, 事务中忽略
 }
 
 // OPUpdateOperation update operation request structure
@@ -103,20 +114,23 @@ type OPDeleteOperation struct {
 
 // OPFindOperation find operation request structure
 type OPFindOperation struct {
-	MsgHeader           // 标准报文头
-	Collection string   // "dbname.collectionname"
-	Projection Document // ""
-	Selector   Document // 文档查询条件
-	Start      uint64   // start index
-	Limit      uint64   // limit index
-	Sort       string   // sort string
+	MsgHeader                     // 标准报文头
+	Collection     string         // "dbname.collectionname"
+	Projection     Document       // ""
+	Selector       Document       // 文档查询条件
+	Start          uint64         // start index
+	Limit          uint64         // limit index
+	Sort           string         // sort string
+	BatchSize      uint64         // cursor batch size, used by stream find
+	ReadPreference ReadPreference // 读偏好, 事务中忽略
 }
 
 // OPCountOperation count operation request structure
 type OPCountOperation struct {
-	MsgHeader           // 标准报文头
-	Collection string   // "dbname.collectionname"
-	Selector   Document // 文档查询条件
+	MsgHeader                     // 标准报文头
+	Collection     string         // "dbname.collectionname"
+	Selector       Document       // 文档查询条件
+	ReadPreference ReadPreference // 读偏好, 事务中忽略
 }
 
 // OPFindAndModifyOperation find and modify operation request structure
@@ -130,6 +144,58 @@ type OPFindAndModifyOperation struct {
 	ReturnNew  bool
 }
 
+// BulkWriteType the operation type of the bulk write model
+type BulkWriteType string
+
+// BulkWriteType enumeration
+const (
+	// BulkWriteInsert insert the document
+	BulkWriteInsert BulkWriteType = "insert"
+	// BulkWriteUpdate set the fields of all the documents matched
+	BulkWriteUpdate BulkWriteType = "update"
+	// BulkWriteUpsert set the fields of one document matched, insert it if not found
+	BulkWriteUpsert BulkWriteType = "upsert"
+	// BulkWriteDelete delete all the documents matched
+	BulkWriteDelete BulkWriteType = "delete"
+)
+
+// BulkWriteModel one operation of the bulk write
+type BulkWriteModel struct {
+	Type     BulkWriteType
+	Selector Document // 文档查询条件
+	DOC      Document // 要插入的文档或者要更新的字段
+}
+
+// OPBulkWriteOperation bulk write operation request structure
+type OPBulkWriteOperation struct {
+	MsgHeader                   // 标准报文头
+	Collection string           // "dbname.collectionname"
+	Models     []BulkWriteModel // 批量写操作
+	Ordered    bool             // 为 true 时遇到错误即停止, 否则继续执行剩余的操作
+}
+
+// BulkWriteError the error of one operation of the bulk write
+type BulkWriteError struct {
+	Index   int    // 操作在批量写中的序号
+	Code    int    // 数据库错误码
+	Message string // 错误信息
+}
+
+// IsDuplicated returns whether the operation failed for the duplicated key
+func (e BulkWriteError) IsDuplicated() bool {
+	return e.Code == 11000 || e.Code == 11001 || e.Code == 12582
+}
+
+// BulkWriteResult the result of the bulk write
+type BulkWriteResult struct {
+	InsertedCount uint64
+	MatchedCount  uint64
+	ModifiedCount uint64
+	DeletedCount  uint64
+	UpsertedCount uint64
+	Errors        []BulkWriteError // 失败的操作
+}
+
 // OPStartTransactionOperation transaction request structure
 type OPStartTransactionOperation struct {
 	MsgHeader
@@ -156,7 +222,8 @@ type ReplyHeader struct {
 
 // OPReply the operation reply message header structure
 type OPReply struct {
-	ReplyHeader           // 标准报文头
-	Count       uint64    // 文档查询结果数
-	Docs        Documents // 文档查询结果
+	ReplyHeader                 // 标准报文头
+	Count       uint64          // 文档查询结果数
+	Docs        Documents       // 文档查询结果
+	BulkWrite   BulkWriteResult // 批量写结果
 }
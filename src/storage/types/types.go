@@ -191,6 +191,7 @@ func decodeBsonArray(inArr, outArr interface{}) error {
 
 const (
 	CommandRDBOperation              = "RDB"
+	CommandRDBStreamOperation        = "RDBStream"
 	CommandWatchTransactionOperation = "WatchTransaction"
 )
 
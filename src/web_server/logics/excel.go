@@ -14,13 +14,18 @@ package logics
 
 import (
 	"context"
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"strings"
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	ccError "configcenter/src/common/errors"
 	lang "configcenter/src/common/language"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
@@ -80,32 +85,159 @@ func (lgc *Logics) BuildExcelFromData(ctx context.Context, objID string, fields
 // BuildHostExcelFromData product excel from data
 func (lgc *Logics) BuildHostExcelFromData(ctx context.Context, objID string, fields map[string]Property, filter []string, data []mapstr.MapStr, xlsxFile *xlsx.File, header http.Header, meta metadata.Metadata) error {
 	ccLang := lgc.Language.CreateDefaultCCLanguageIf(util.GetLanguage(header))
-	ccErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
 
 	sheet, err := xlsxFile.AddSheet("host")
 	if err != nil {
 		blog.Errorf("BuildHostExcelFromData add excel sheet error, err:%s, rid:%s", err.Error(), util.GetHTTPCCRequestID(header))
 		return err
 	}
-	extFieldsTopoID := "cc_ext_field_topo"
+	fields = addHostExtFields(fields, ccLang)
+	productExcelHealer(fields, filter, sheet, ccLang)
+
+	instPrimaryKeyValMap, err := setHostExcelRows(objID, data, sheet, common.HostAddMethodExcelIndexOffset, fields, header, lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header)))
+	if err != nil {
+		return err
+	}
+
+	err = lgc.BuildAssociationExcelFromData(ctx, objID, instPrimaryKeyValMap, xlsxFile, header, meta)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// BuildHostExcelByPage product the host excel to the writer from the data pages returned by iterate one by one.
+// the host rows are streamed to the writer page by page, and the association rows of each page are kept
+// in a temporary file until the host sheet is finished, so neither the data nor the excel is kept in memory.
+func (lgc *Logics) BuildHostExcelByPage(ctx context.Context, objID string, fields map[string]Property, filter []string, iterate func(handler func(data []mapstr.MapStr) error) error, writer io.Writer, header http.Header, meta metadata.Metadata) error {
+	ccLang := lgc.Language.CreateDefaultCCLanguageIf(util.GetLanguage(header))
+	ccErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	rid := util.GetHTTPCCRequestID(header)
+
+	// the template keeps the headers of the sheets and the comment sheet
+	template := xlsx.NewFile()
+	sheet, err := template.AddSheet("host")
+	if err != nil {
+		blog.Errorf("BuildHostExcelByPage add excel sheet error, err:%s, rid:%s", err.Error(), rid)
+		return err
+	}
+	fields = addHostExtFields(fields, ccLang)
+	productExcelHealer(fields, filter, sheet, ccLang)
+	asstSheet, err := template.AddSheet("assocation")
+	if err != nil {
+		blog.Errorf("BuildHostExcelByPage add excel assocation sheet error, err:%s, rid:%s", err.Error(), rid)
+		return err
+	}
+	productExcelAssociationHealer(asstSheet, ccLang)
+	ProductExcelCommentSheet(template, ccLang)
+
+	asstFile, err := ioutil.TempFile("", "cc_export_association_")
+	if err != nil {
+		blog.Errorf("BuildHostExcelByPage create the association temporary file error, err:%s, rid:%s", err.Error(), rid)
+		return err
+	}
+	defer func() {
+		asstFile.Close()
+		os.Remove(asstFile.Name())
+	}()
+	asstWriter := csv.NewWriter(asstFile)
+
+	stream, err := newExcelStreamWriter(writer, template)
+	if err != nil {
+		blog.Errorf("BuildHostExcelByPage write the excel error, err:%s, rid:%s", err.Error(), rid)
+		return err
+	}
+	if err := stream.NextSheet(); err != nil {
+		return err
+	}
+
+	rowIndex := common.HostAddMethodExcelIndexOffset
+	err = iterate(func(data []mapstr.MapStr) error {
+		page, err := xlsx.NewFile().AddSheet("host")
+		if err != nil {
+			return err
+		}
+		instPrimaryKeyValMap, err := setHostExcelRows(objID, data, page, 0, fields, header, ccErr)
+		if err != nil {
+			return err
+		}
+		if err := stream.WriteRows(page, rowIndex); err != nil {
+			blog.Errorf("BuildHostExcelByPage write the host rows error, err:%s, rid:%s", err.Error(), rid)
+			return err
+		}
+		rowIndex += len(page.Rows)
+
+		asstRows, err := lgc.getAssociationExcelRows(ctx, objID, instPrimaryKeyValMap, header, meta)
+		if err != nil {
+			return err
+		}
+		return asstWriter.WriteAll(asstRows)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := stream.NextSheet(); err != nil {
+		return err
+	}
+	if _, err := asstFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	asstReader := csv.NewReader(asstFile)
+	rowIndex = common.HostAddMethodExcelAssociationIndexOffset
+	for eof := false; !eof; {
+		page, err := xlsx.NewFile().AddSheet("assocation")
+		if err != nil {
+			return err
+		}
+		for idx := 0; idx < excelAssociationPageSize; idx++ {
+			row, err := asstReader.Read()
+			if err == io.EOF {
+				eof = true
+				break
+			}
+			if err != nil {
+				blog.Errorf("BuildHostExcelByPage read the association temporary file error, err:%s, rid:%s", err.Error(), rid)
+				return err
+			}
+			for colIndex, value := range row {
+				page.Cell(idx, colIndex).SetString(value)
+			}
+		}
+		if err := stream.WriteRows(page, rowIndex); err != nil {
+			blog.Errorf("BuildHostExcelByPage write the association rows error, err:%s, rid:%s", err.Error(), rid)
+			return err
+		}
+		rowIndex += len(page.Rows)
+	}
+
+	return stream.Close()
+}
+
+// excelAssociationPageSize the row count of the association sheet written at once
+const excelAssociationPageSize = 1000
+
+func addHostExtFields(fields map[string]Property, ccLang lang.DefaultCCLanguageIf) map[string]Property {
 	extFields := map[string]string{
 		extFieldsTopoID: ccLang.Language("web_ext_field_topo"),
 	}
 	fields = addExtFields(fields, extFields)
 	addSystemField(fields, common.BKInnerObjIDHost, ccLang)
+	return fields
+}
 
-	productExcelHealer(fields, filter, sheet, ccLang)
+const extFieldsTopoID = "cc_ext_field_topo"
 
+// setHostExcelRows set the hosts to the sheet from the row of rowIndex, returns the primary keys of the hosts
+func setHostExcelRows(objID string, data []mapstr.MapStr, sheet *xlsx.Sheet, rowIndex int, fields map[string]Property, header http.Header, ccErr ccError.DefaultCCErrorIf) (map[int64][]PropertyPrimaryVal, error) {
 	instPrimaryKeyValMap := make(map[int64][]PropertyPrimaryVal)
-	//indexID := getFieldsIDIndexMap(fields)
-	rowIndex := common.HostAddMethodExcelIndexOffset
 	for _, hostData := range data {
 
 		rowMap, err := mapstr.NewFromInterface(hostData[common.BKInnerObjIDHost])
 		if err != nil {
 			msg := fmt.Sprintf("data format error:%v", hostData)
 			blog.Errorf(msg)
-			return errors.New(msg)
+			return nil, errors.New(msg)
 		}
 		moduleMap, ok := hostData[common.BKInnerObjIDModule].([]interface{})
 		if ok {
@@ -117,46 +249,65 @@ func (lgc *Logics) BuildHostExcelFromData(ctx context.Context, objID string, fie
 		instID, err := rowMap.Int64(instIDKey)
 		if err != nil {
 			blog.Errorf("setExcelRowDataByIndex inst:%+v, not inst id key:%s, objID:%s, rid:%s", rowMap, instIDKey, objID, util.GetHTTPCCRequestID(header))
-			return ccErr.Errorf(common.CCErrCommInstFieldNotFound, "instIDKey", objID)
+			return nil, ccErr.Errorf(common.CCErrCommInstFieldNotFound, "instIDKey", objID)
 		}
 		primaryKeyArr := setExcelRowDataByIndex(rowMap, sheet, rowIndex, fields)
 		instPrimaryKeyValMap[instID] = primaryKeyArr
 		rowIndex++
 
 	}
+	return instPrimaryKeyValMap, nil
+}
 
-	err = lgc.BuildAssociationExcelFromData(ctx, objID, instPrimaryKeyValMap, xlsxFile, header, meta)
+func (lgc *Logics) BuildAssociationExcelFromData(ctx context.Context, objID string, instPrimaryInfo map[int64][]PropertyPrimaryVal, xlsxFile *xlsx.File, header http.Header, meta metadata.Metadata) error {
+	rows, err := lgc.getAssociationExcelRows(ctx, objID, instPrimaryInfo, header, meta)
+	if err != nil {
+		return err
+	}
+
+	sheet, err := xlsxFile.AddSheet("assocation")
 	if err != nil {
+		blog.Errorf("setExcelRowDataByIndex add excel  assocation sheet error, rid:%s", err.Error(), util.GetHTTPCCRequestID(header))
 		return err
 	}
+	productExcelAssociationHealer(sheet, lgc.Language.CreateDefaultCCLanguageIf(util.GetLanguage(header)))
+
+	rowIndex := common.HostAddMethodExcelAssociationIndexOffset
+	for _, row := range rows {
+		for colIndex, value := range row {
+			sheet.Cell(rowIndex, colIndex).SetString(value)
+		}
+		style := sheet.Cell(rowIndex, assciationSrcInstIndex).GetStyle()
+		style.Alignment.WrapText = true
+		style = sheet.Cell(rowIndex, assciationDstInstIndex).GetStyle()
+		style.Alignment.WrapText = true
+		rowIndex++
+	}
+
 	return nil
+
 }
 
-func (lgc *Logics) BuildAssociationExcelFromData(ctx context.Context, objID string, instPrimaryInfo map[int64][]PropertyPrimaryVal, xlsxFile *xlsx.File, header http.Header, meta metadata.Metadata) error {
+// getAssociationExcelRows returns the rows of the association sheet of the instances
+func (lgc *Logics) getAssociationExcelRows(ctx context.Context, objID string, instPrimaryInfo map[int64][]PropertyPrimaryVal, header http.Header, meta metadata.Metadata) ([][]string, error) {
 	var instIDArr []int64
 	for instID := range instPrimaryInfo {
 		instIDArr = append(instIDArr, instID)
 	}
+	if len(instIDArr) == 0 {
+		return nil, nil
+	}
 	instAsst, err := lgc.fetchAssocationData(ctx, header, objID, instIDArr)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	asstData, err := lgc.getAssociationData(ctx, header, objID, instAsst, meta)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	sheet, err := xlsxFile.AddSheet("assocation")
-	if err != nil {
-		blog.Errorf("setExcelRowDataByIndex add excel  assocation sheet error, rid:%s", err.Error(), util.GetHTTPCCRequestID(header))
-		return err
-	}
-	productExcelAssociationHealer(sheet, lgc.Language.CreateDefaultCCLanguageIf(util.GetLanguage(header)))
-
-	rowIndex := common.HostAddMethodExcelAssociationIndexOffset
+	rows := make([][]string, 0, len(instAsst))
 	for _, inst := range instAsst {
-		sheet.Cell(rowIndex, 0).SetString(inst.ObjectAsstID)
-		sheet.Cell(rowIndex, 1).SetString("")
 		srcInst, ok := instPrimaryInfo[inst.InstID]
 		if !ok {
 			blog.Warnf("BuildAssociationExcelFromData association inst:%+v, not inst id :%d, objID:%s, rid:%s", inst, inst.InstID, objID, util.GetHTTPCCRequestID(header))
@@ -168,17 +319,10 @@ func (lgc *Logics) BuildAssociationExcelFromData(ctx context.Context, objID stri
 			blog.Warnf("BuildAssociationExcelFromData association inst:%+v, not inst id :%d, objID:%s, rid:%s", inst, inst.InstID, inst.AsstObjectID, util.GetHTTPCCRequestID(header))
 			continue
 		}
-		sheet.Cell(rowIndex, 2).SetString(buildEexcelPrimaryKey(srcInst))
-		sheet.Cell(rowIndex, 3).SetString(buildEexcelPrimaryKey(dstInst))
-		style := sheet.Cell(rowIndex, 2).GetStyle()
-		style.Alignment.WrapText = true
-		style = sheet.Cell(rowIndex, 3).GetStyle()
-		style.Alignment.WrapText = true
-		rowIndex++
+		rows = append(rows, []string{inst.ObjectAsstID, "", buildEexcelPrimaryKey(srcInst), buildEexcelPrimaryKey(dstInst)})
 	}
 
-	return nil
-
+	return rows, nil
 }
 
 func buildEexcelPrimaryKey(propertyArr []PropertyPrimaryVal) string {
@@ -209,7 +353,7 @@ func (lgc *Logics) BuildExcelTemplate(objID, filename string, header http.Header
 		blog.Errorf("get %s fields error: %v", objID, err)
 		return err
 	}
-	blog.V(5).Infof("BuildExcelTemplate fields count:%d", fields)
+	blog.V(5).Infof("BuildExcelTemplate fields count:%d", len(fields))
 	productExcelHealer(fields, filterFields, sheet, defLang)
 	ProductExcelCommentSheet(file, defLang)
 
@@ -0,0 +1,165 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/rentiansheng/xlsx"
+)
+
+const (
+	excelSheetPathFormat = "xl/worksheets/sheet%d.xml"
+	excelSheetDataEnd    = "</sheetData>"
+)
+
+// excelDimensionTag the dimension of the template sheet, which is removed as the rows are appended,
+// or the readers sizing the sheet by the dimension will miss the appended rows
+var excelDimensionTag = regexp.MustCompile(`<dimension [^>]*?(/>|></dimension>)`)
+
+// excelStreamWriter writes the xlsx file sheet by sheet, each sheet keeps the header rows, the styles
+// and the data validations of the template, the rows appended to the sheet being written are
+// flushed to the writer at once, so that the whole excel is never kept in memory.
+type excelStreamWriter struct {
+	zipWriter *zip.Writer
+	// the xml of the template sheets ordered by the sheet index
+	sheets []string
+	// the index of the sheet being written, -1 means no sheet is being written
+	index  int
+	sheet  io.Writer
+	suffix string
+}
+
+// newExcelStreamWriter writes the parts of the template except the sheets, which are written by NextSheet
+func newExcelStreamWriter(writer io.Writer, template *xlsx.File) (*excelStreamWriter, error) {
+	parts, err := template.MarshallParts()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &excelStreamWriter{
+		zipWriter: zip.NewWriter(writer),
+		sheets:    make([]string, len(template.Sheets)),
+		index:     -1,
+	}
+	for idx := range w.sheets {
+		path := fmt.Sprintf(excelSheetPathFormat, idx+1)
+		w.sheets[idx] = parts[path]
+		delete(parts, path)
+	}
+	for path, part := range parts {
+		partWriter, err := w.zipWriter.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(partWriter, part); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// NextSheet finish the sheet being written and start writing the next sheet of the template
+func (w *excelStreamWriter) NextSheet() error {
+	if err := w.finishSheet(); err != nil {
+		return err
+	}
+	if w.index+1 >= len(w.sheets) {
+		return errors.New("no more sheet to write")
+	}
+	w.index++
+
+	data := w.sheets[w.index]
+	end := strings.LastIndex(data, excelSheetDataEnd)
+	if end < 0 {
+		return fmt.Errorf("the sheet data of sheet %d not found", w.index+1)
+	}
+	sheet, err := w.zipWriter.Create(fmt.Sprintf(excelSheetPathFormat, w.index+1))
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(sheet, excelDimensionTag.ReplaceAllString(data[:end], "")); err != nil {
+		return err
+	}
+	w.sheet = sheet
+	w.suffix = data[end:]
+	return nil
+}
+
+// WriteRows append the rows of the sheet to the sheet being written, the first row is
+// written as the row of rowIndex, which starts from 0 and must be after the written rows.
+func (w *excelStreamWriter) WriteRows(sheet *xlsx.Sheet, rowIndex int) error {
+	if w.sheet == nil {
+		return errors.New("no sheet is being written")
+	}
+
+	buf := bytes.Buffer{}
+	for idx, row := range sheet.Rows {
+		fmt.Fprintf(&buf, `<row r="%d">`, rowIndex+idx+1)
+		for colIndex, cell := range row.Cells {
+			if cell == nil || cell.Value == "" {
+				continue
+			}
+			ref := xlsx.GetCellIDStringFromCoords(colIndex, rowIndex+idx)
+			switch cell.Type() {
+			case xlsx.CellTypeNumeric:
+				fmt.Fprintf(&buf, `<c r="%s"><v>%s</v></c>`, ref, cell.Value)
+			case xlsx.CellTypeBool:
+				fmt.Fprintf(&buf, `<c r="%s" t="b"><v>%s</v></c>`, ref, cell.Value)
+			default:
+				fmt.Fprintf(&buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+				if err := xml.EscapeText(&buf, []byte(cell.Value)); err != nil {
+					return err
+				}
+				buf.WriteString(`</t></is></c>`)
+			}
+		}
+		buf.WriteString(`</row>`)
+	}
+	if _, err := buf.WriteTo(w.sheet); err != nil {
+		return err
+	}
+	return w.zipWriter.Flush()
+}
+
+// Close finish the remaining sheets of the template and the xlsx file
+func (w *excelStreamWriter) Close() error {
+	for w.index+1 < len(w.sheets) {
+		if err := w.NextSheet(); err != nil {
+			return err
+		}
+	}
+	if err := w.finishSheet(); err != nil {
+		return err
+	}
+	return w.zipWriter.Close()
+}
+
+func (w *excelStreamWriter) finishSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+	if _, err := io.WriteString(w.sheet, w.suffix); err != nil {
+		return err
+	}
+	w.sheet = nil
+	w.suffix = ""
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rentiansheng/xlsx"
+)
+
+func TestExcelStreamWriter(t *testing.T) {
+	template := xlsx.NewFile()
+	sheet, err := template.AddSheet("host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet.Cell(0, 0).SetString("name")
+	sheet.Cell(0, 1).SetString("count")
+	sheet.Cell(0, 2).SetString("enabled")
+	dd := xlsx.NewXlsxCellDataValidation(true, true, true)
+	dd.SetDropList([]string{"yes", "no"})
+	sheet.Col(2).SetDataValidationWithStart(dd, 1)
+	if _, err := template.AddSheet("assocation"); err != nil {
+		t.Fatal(err)
+	}
+	comment, err := template.AddSheet("comment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment.Cell(0, 0).SetString("the comment")
+
+	buf := bytes.Buffer{}
+	stream, err := newExcelStreamWriter(&buf, template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.WriteRows(sheet, 0); err == nil {
+		t.Fatal("rows should not be written before starting a sheet")
+	}
+	if err := stream.NextSheet(); err != nil {
+		t.Fatal(err)
+	}
+	for _, start := range []int{1, 3} {
+		page, _ := xlsx.NewFile().AddSheet("host")
+		for idx := 0; idx < 2; idx++ {
+			page.Cell(idx, 0).SetString("a<b>\n&c")
+			page.Cell(idx, 1).SetInt64(int64(start + idx))
+			page.Cell(idx, 2).SetString("yes")
+		}
+		if err := stream.WriteRows(page, start); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the association sheet is left empty, and the comment sheet is written by Close
+	if err := stream.NextSheet(); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := xlsx.OpenBinary(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Sheets) != 3 {
+		t.Fatalf("expect 3 sheets, got %d", len(file.Sheets))
+	}
+	host := file.Sheet["host"]
+	if len(host.Rows) != 5 {
+		t.Fatalf("expect 5 host rows, got %d", len(host.Rows))
+	}
+	if host.Cell(0, 1).String() != "count" {
+		t.Errorf("the header should be kept, got %s", host.Cell(0, 1).String())
+	}
+	for idx := 1; idx < 5; idx++ {
+		if value := host.Cell(idx, 0).String(); value != "a<b>\n&c" {
+			t.Errorf("row %d: unexpected name %q", idx, value)
+		}
+		count, err := host.Cell(idx, 1).Int()
+		if err != nil || count != idx || host.Cell(idx, 1).Type() != xlsx.CellTypeNumeric {
+			t.Errorf("row %d: unexpected count %s, err: %v", idx, host.Cell(idx, 1).Value, err)
+		}
+		if value := host.Cell(idx, 2).String(); value != "yes" {
+			t.Errorf("row %d: unexpected enabled %q", idx, value)
+		}
+	}
+	if len(host.Col(2).DataValidation) == 0 {
+		t.Error("the data validation should be kept")
+	}
+	if len(file.Sheet["assocation"].Rows) != 0 {
+		t.Errorf("expect empty association sheet, got %d rows", len(file.Sheet["assocation"].Rows))
+	}
+	if value := file.Sheet["comment"].Cell(0, 0).String(); value != "the comment" {
+		t.Errorf("the comment sheet should be kept, got %q", value)
+	}
+}
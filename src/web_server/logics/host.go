@@ -29,9 +29,22 @@ import (
 	"github.com/rentiansheng/xlsx"
 )
 
+// ExportHostPageSize the host count of each page when export hosts
+const ExportHostPageSize = 1000
+
 // GetHostData get host data from excel
 func (lgc *Logics) GetHostData(appIDStr, hostIDStr string, header http.Header) ([]mapstr.MapStr, error) {
 	hostInfo := make([]mapstr.MapStr, 0)
+	err := lgc.IterateHostData(appIDStr, hostIDStr, header, ExportHostPageSize, func(hosts []mapstr.MapStr) error {
+		hostInfo = append(hostInfo, hosts...)
+		return nil
+	})
+	return hostInfo, err
+}
+
+// IterateHostData get host data page by page ordered by bk_host_id, each page is fetched
+// after the last host id of the previous page, so only one page is kept in memory
+func (lgc *Logics) IterateHostData(appIDStr, hostIDStr string, header http.Header, pageSize int, handler func(hosts []mapstr.MapStr) error) error {
 	sHostCond := make(map[string]interface{})
 	appID, _ := strconv.Atoi(appIDStr)
 	hostIDArr := strings.Split(hostIDStr, ",")
@@ -40,60 +53,102 @@ func (lgc *Logics) GetHostData(appIDStr, hostIDStr string, header http.Header) (
 		hostID, _ := strconv.Atoi(j)
 		iHostIDArr = append(iHostIDArr, hostID)
 	}
+	hostCondArr := make([]interface{}, 0)
 	if -1 != appID {
 		sHostCond[common.BKAppIDField] = appID
 		sHostCond["ip"] = make(map[string]interface{})
-		sHostCond["condition"] = make([]interface{}, 0)
-		sHostCond["page"] = make(map[string]interface{})
 	} else {
 		sHostCond[common.BKAppIDField] = -1
 		sHostCond["ip"] = make(map[string]interface{})
-		condArr := make([]interface{}, 0)
 
-		//host condition
-		condition := make(map[string]interface{})
-		hostCondArr := make([]interface{}, 0)
 		hostCond := make(map[string]interface{})
 		hostCond["field"] = common.BKHostIDField
 		hostCond["operator"] = common.BKDBIN
 		hostCond["value"] = iHostIDArr
 		hostCondArr = append(hostCondArr, hostCond)
-		condition[common.BKObjIDField] = common.BKInnerObjIDHost
-		condition["fields"] = make([]string, 0)
-		condition["condition"] = hostCondArr
-		condArr = append(condArr, condition)
-
-		//biz conditon
-		condition = make(map[string]interface{})
-		condition[common.BKObjIDField] = common.BKInnerObjIDApp
-		condition["fields"] = make([]interface{}, 0)
-		condition["condition"] = make([]interface{}, 0)
-		condArr = append(condArr, condition)
-
-		//set conditon
-		condition = make(map[string]interface{})
-		condition[common.BKObjIDField] = common.BKInnerObjIDSet
-		condition["fields"] = make([]interface{}, 0)
-		condition["condition"] = make([]interface{}, 0)
-		condArr = append(condArr, condition)
-
-		//module condition
-		condition = make(map[string]interface{})
-		condition[common.BKObjIDField] = common.BKInnerObjIDModule
-		condition["fields"] = make([]interface{}, 0)
-		condition["condition"] = make([]interface{}, 0)
-		condArr = append(condArr, condition)
-
-		sHostCond["condition"] = condArr
-		sHostCond["page"] = make(map[string]interface{})
+	}
+
+	var lastHostID int64
+	for {
+		// the keyset condition should be after the $in condition of the same field,
+		// or it will be overwrite when parsing the host conditions
+		pageHostCondArr := hostCondArr
+		if lastHostID > 0 {
+			keysetCond := make(map[string]interface{})
+			keysetCond["field"] = common.BKHostIDField
+			keysetCond["operator"] = common.BKDBGT
+			keysetCond["value"] = lastHostID
+			pageHostCondArr = append(hostCondArr[:len(hostCondArr):len(hostCondArr)], keysetCond)
+		}
+		sHostCond["condition"] = getHostSearchCondition(appID, pageHostCondArr)
+		sHostCond["page"] = map[string]interface{}{
+			"start": 0,
+			"limit": pageSize,
+			"sort":  common.BKHostIDField,
+		}
+
+		result, err := lgc.Engine.CoreAPI.ApiServer().GetHostData(context.Background(), header, sHostCond)
+		if nil != err || false == result.Result {
+			return errors.New("no host")
+		}
+		hosts := result.Data.Info
+		if 0 == len(hosts) {
+			return nil
+		}
+		if err := handler(hosts); nil != err {
+			return err
+		}
+		if len(hosts) < pageSize {
+			return nil
+		}
 
+		host, err := hosts[len(hosts)-1].MapStr(common.BKInnerObjIDHost)
+		if nil != err {
+			blog.Errorf("IterateHostData get host from search result failed, err: %v, rid: %s", err, util.GetHTTPCCRequestID(header))
+			return err
+		}
+		if lastHostID, err = host.Int64(common.BKHostIDField); nil != err {
+			blog.Errorf("IterateHostData get host id from search result failed, err: %v, rid: %s", err, util.GetHTTPCCRequestID(header))
+			return err
+		}
 	}
-	result, err := lgc.Engine.CoreAPI.ApiServer().GetHostData(context.Background(), header, sHostCond)
-	if nil != err || false == result.Result {
-		return hostInfo, errors.New("no host")
+}
+
+func getHostSearchCondition(appID int, hostCondArr []interface{}) []interface{} {
+	condArr := make([]interface{}, 0)
+
+	//host condition
+	condition := make(map[string]interface{})
+	condition[common.BKObjIDField] = common.BKInnerObjIDHost
+	condition["fields"] = make([]string, 0)
+	condition["condition"] = hostCondArr
+	condArr = append(condArr, condition)
+	if -1 != appID {
+		return condArr
 	}
 
-	return result.Data.Info, nil
+	//biz conditon
+	condition = make(map[string]interface{})
+	condition[common.BKObjIDField] = common.BKInnerObjIDApp
+	condition["fields"] = make([]interface{}, 0)
+	condition["condition"] = make([]interface{}, 0)
+	condArr = append(condArr, condition)
+
+	//set conditon
+	condition = make(map[string]interface{})
+	condition[common.BKObjIDField] = common.BKInnerObjIDSet
+	condition["fields"] = make([]interface{}, 0)
+	condition["condition"] = make([]interface{}, 0)
+	condArr = append(condArr, condition)
+
+	//module condition
+	condition = make(map[string]interface{})
+	condition[common.BKObjIDField] = common.BKInnerObjIDModule
+	condition["fields"] = make([]interface{}, 0)
+	condition["condition"] = make([]interface{}, 0)
+	condArr = append(condArr, condition)
+
+	return condArr
 }
 
 // GetImportHosts get import hosts
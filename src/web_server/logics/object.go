@@ -43,7 +43,7 @@ func (lgc *Logics) GetObjectData(ownerID, objID string, header http.Header, meta
 	}
 
 	if false == result.Result {
-		return nil, fmt.Errorf(result.ErrMsg)
+		return nil, fmt.Errorf("%s", result.ErrMsg)
 	}
 
 	return result.Data[objID].Attr, nil
@@ -23,6 +23,7 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	webCommon "configcenter/src/web_server/common"
@@ -86,21 +87,11 @@ func (s *Service) ExportHost(c *gin.Context) {
 	hostIDStr := c.PostForm("bk_host_id")
 
 	logics.SetProxyHeader(c)
-	pheader := c.Request.Header
-	defLang := s.Language.CreateDefaultCCLanguageIf(util.GetLanguage(pheader))
+	// exporting is read only, the reads can be served by the secondaries
+	pheader := util.SecondaryEligibleHeader(c.Request.Header)
 	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
 	customFieldsStr := c.PostForm(common.ExportCustomFields)
 
-	hostInfo, err := s.Logics.GetHostData(appIDStr, hostIDStr, pheader)
-	if err != nil {
-		blog.Error(err.Error())
-		msg := getReturnStr(common.CCErrWebGetHostFail, defErr.Errorf(common.CCErrWebGetHostFail, err.Error()).Error(), nil)
-		c.String(http.StatusInternalServerError, msg, nil)
-		return
-	}
-	var file *xlsx.File
-	file = xlsx.NewFile()
-
 	objID := common.BKInnerObjIDHost
 	filterFields := logics.GetFilterFields(objID)
 	customFields := logics.GetCustomFields(filterFields, customFieldsStr)
@@ -111,14 +102,19 @@ func (s *Service) ExportHost(c *gin.Context) {
 		c.Writer.Write([]byte(reply))
 		return
 	}
-	err = s.Logics.BuildHostExcelFromData(context.Background(), objID, fields, nil, hostInfo, file, pheader, metadata.Metadata{})
-	if nil != err {
-		blog.Errorf("ExportHost object:%s error:%s, rid:%s", objID, err.Error(), util.GetHTTPCCRequestID(c.Request.Header))
-		reply := getReturnStr(common.CCErrCommExcelTemplateFailed, defErr.Errorf(common.CCErrCommExcelTemplateFailed, objID).Error(), nil)
-		c.Writer.Write([]byte(reply))
-		return
+	// the hosts are fetched page by page, so that exporting a lot of hosts won't run out of memory
+	var getHostErr error
+	iterate := func(handler func(data []mapstr.MapStr) error) error {
+		var buildErr error
+		err := s.Logics.IterateHostData(appIDStr, hostIDStr, pheader, logics.ExportHostPageSize, func(data []mapstr.MapStr) error {
+			buildErr = handler(data)
+			return buildErr
+		})
+		if nil != err && nil == buildErr {
+			getHostErr = err
+		}
+		return err
 	}
-
 	dirFileName := fmt.Sprintf("%s/export", webCommon.ResourcePath)
 	_, err = os.Stat(dirFileName)
 	if nil != err {
@@ -126,20 +122,38 @@ func (s *Service) ExportHost(c *gin.Context) {
 	}
 	fileName := fmt.Sprintf("%dhost.xlsx", time.Now().UnixNano())
 	dirFileName = fmt.Sprintf("%s/%s", dirFileName, fileName)
+	defer os.Remove(dirFileName)
 
-	logics.ProductExcelCommentSheet(file, defLang)
-	err = file.Save(dirFileName)
+	// the excel is written to the file while the hosts are fetched
+	file, err := os.Create(dirFileName)
 	if err != nil {
-		blog.Errorf("ExportHost save file error:%s", err.Error())
+		blog.Errorf("ExportHost create file error:%s", err.Error())
 		reply := getReturnStr(common.CCErrWebCreateEXCELFail, defErr.Errorf(common.CCErrCommExcelTemplateFailed, err.Error()).Error(), nil)
 		c.Writer.Write([]byte(reply))
 		return
 	}
+	err = s.Logics.BuildHostExcelByPage(context.Background(), objID, fields, nil, iterate, file, pheader, metadata.Metadata{})
+	if closeErr := file.Close(); nil == err && nil != closeErr {
+		blog.Errorf("ExportHost save file error:%s", closeErr.Error())
+		reply := getReturnStr(common.CCErrWebCreateEXCELFail, defErr.Errorf(common.CCErrCommExcelTemplateFailed, closeErr.Error()).Error(), nil)
+		c.Writer.Write([]byte(reply))
+		return
+	}
+	if nil != getHostErr {
+		blog.Error(err.Error())
+		msg := getReturnStr(common.CCErrWebGetHostFail, defErr.Errorf(common.CCErrWebGetHostFail, err.Error()).Error(), nil)
+		c.String(http.StatusInternalServerError, msg, nil)
+		return
+	}
+	if nil != err {
+		blog.Errorf("ExportHost object:%s error:%s, rid:%s", objID, err.Error(), util.GetHTTPCCRequestID(c.Request.Header))
+		reply := getReturnStr(common.CCErrCommExcelTemplateFailed, defErr.Errorf(common.CCErrCommExcelTemplateFailed, objID).Error(), nil)
+		c.Writer.Write([]byte(reply))
+		return
+	}
+
 	logics.AddDownExcelHttpHeader(c, "host.xlsx")
 	c.File(dirFileName)
-
-	os.Remove(dirFileName)
-
 }
 
 //BuildDownLoadExcelTemplate build download excel template